@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// newAliasCommand creates the built-in "alias" command group
+// Aliases are personal shorthands that run a command with preset flags and
+// arguments, e.g. "goldfish rp" for "goldfish replace --in-place". They are
+// kept in the user's commands.yml (e.g. ~/.config/goldfish/commands.yml).
+func (app *GoldfishApp) newAliasCommand() *cobra.Command {
+	aliasCmd := &cobra.Command{
+		Use:   "alias",
+		Short: "Manage personal command aliases",
+	}
+
+	aliasCmd.AddCommand(&cobra.Command{
+		Use:     "add <alias> <command> [preset flags and arguments]",
+		Short:   "Add an alias, or change an existing one",
+		Example: "  goldfish alias add rp replace --in-place\n  goldfish alias add todos find-files --name '*.todo'",
+		// The preset flags belong to the aliased command, not to alias add
+		DisableFlagParsing: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 && (args[0] == "-h" || args[0] == "--help") {
+				return cmd.Help()
+			}
+			if len(args) < 2 {
+				return fmt.Errorf("usage: goldfish alias add <alias> <command> [preset flags and arguments]")
+			}
+			alias, err := app.newAlias(args[0], args[1], args[2:])
+			if err != nil {
+				return err
+			}
+			path, err := config.UserConfigPath()
+			if err != nil {
+				return err
+			}
+			if err := config.SetAlias(path, *alias); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Added alias %s for '%s' to %s\n", alias.Name, alias.String(), path)
+			return nil
+		},
+	})
+
+	aliasCmd.AddCommand(&cobra.Command{
+		Use:     "rm <alias>",
+		Aliases: []string{"remove"},
+		Short:   "Remove an alias",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := config.UserConfigPath()
+			if err != nil {
+				return err
+			}
+			if err := config.RemoveAlias(path, args[0]); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Removed alias %s from %s\n", args[0], path)
+			return nil
+		},
+	})
+
+	aliasCmd.AddCommand(&cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List aliases from every configuration file",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(app.config.Aliases) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No aliases defined")
+				return nil
+			}
+			writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+			fmt.Fprintln(writer, "ALIAS\tRUNS")
+			for _, alias := range app.config.Aliases {
+				fmt.Fprintf(writer, "%s\t%s\n", alias.Name, alias.String())
+			}
+			return writer.Flush()
+		},
+	})
+
+	return aliasCmd
+}
+
+// newAlias checks a new alias and returns it
+// The name must not hide a command or built-in, the command must exist, and
+// the preset flags must be ones the command accepts.
+func (app *GoldfishApp) newAlias(name, command string, presets []string) (*config.Alias, error) {
+	if existing, found := app.config.FindCommand(name); found {
+		return nil, fmt.Errorf("'%s' is already the command '%s'", name, existing.Name)
+	}
+	for _, builtin := range app.rootCmd.Commands() {
+		if app.isConfiguredCommand(builtin) {
+			continue
+		}
+		if builtin.Name() == name || builtin.HasAlias(name) {
+			return nil, fmt.Errorf("'%s' is a built-in goldfish command", name)
+		}
+	}
+	target, found := app.config.FindCommand(command)
+	if !found {
+		return nil, &config.CommandNotFoundError{Name: command}
+	}
+	if err := app.newCommand(target, app.currentPlatform()).ParseFlags(presets); err != nil {
+		return nil, fmt.Errorf("invalid preset for '%s': %w", target.Name, err)
+	}
+	return &config.Alias{Name: name, Command: target.Name, Args: presets}, nil
+}
+
+// isConfiguredCommand reports whether a registered Cobra command (or its
+// stub) was generated from a command or alias of the configuration rather
+// than built in
+func (app *GoldfishApp) isConfiguredCommand(cobraCmd *cobra.Command) bool {
+	if _, found := app.config.FindCommand(cobraCmd.Name()); found {
+		return true
+	}
+	_, found := app.config.FindAlias(cobraCmd.Name())
+	return found
+}
+
+// expandAlias replaces an alias among the raw arguments with the command
+// line it stands for, so the aliased command is built and parsed as if the
+// user had typed it. Commands of the configuration win over an alias of the
+// same name. It reports whether an alias was expanded.
+func (app *GoldfishApp) expandAlias() bool {
+	i := app.positionalIndex()
+	if i < 0 {
+		return false
+	}
+	alias, found := app.config.FindAlias(app.args[i])
+	if !found {
+		return false
+	}
+	if _, isCommand := app.config.FindCommand(alias.Name); isCommand {
+		return false
+	}
+	expanded := append([]string{}, app.args[:i]...)
+	expanded = append(expanded, alias.Command)
+	expanded = append(expanded, alias.Args...)
+	app.args = append(expanded, app.args[i+1:]...)
+	return true
+}
+
+// newAliasStub creates the command that lists an alias in help and completion
+// Running an alias normally never reaches it, since expandAlias has already
+// replaced the alias; it only runs when the aliased command is missing.
+func (app *GoldfishApp) newAliasStub(alias config.Alias) *cobra.Command {
+	return &cobra.Command{
+		Use:                alias.Name,
+		Short:              "Alias for " + alias.String(),
+		DisableFlagParsing: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return fmt.Errorf("alias '%s' runs '%s', which is not a known command", alias.Name, alias.String())
+		},
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// TestAliasCommand tests adding, listing and removing aliases through the CLI
+func TestAliasCommand(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	path, _ := config.UserConfigPath()
+
+	run := func(args ...string) (string, error) {
+		t.Helper()
+		app := newLazyTestApp(nil)
+		// Aliases written so far are loaded, as the CLI would
+		if cfg, err := config.NewLoader(path).Load(); err == nil {
+			app.config.Aliases = cfg.Aliases
+		}
+		app.rootCmd.AddCommand(app.newAliasCommand())
+		var out bytes.Buffer
+		app.rootCmd.SetOut(&out)
+		app.rootCmd.SetArgs(args)
+		err := app.rootCmd.Execute()
+		return out.String(), err
+	}
+
+	if out, err := run("alias", "add", "ff", "f", "extra"); err != nil || !strings.Contains(out, "Added alias ff for 'first extra'") {
+		t.Fatalf("Expected the alias to be added, got %v: %s", err, out)
+	}
+	if out, _ := run("alias", "list"); !strings.Contains(out, "ff") || !strings.Contains(out, "first extra") {
+		t.Errorf("Expected the alias to be listed, got %s", out)
+	}
+
+	// The name may not hide a command or built-in, and the target must exist
+	for _, args := range [][]string{{"second", "first"}, {"alias", "first"}, {"x", "missing"}, {"x", "first", "--unknown"}} {
+		if _, err := run(append([]string{"alias", "add"}, args...)...); err == nil {
+			t.Errorf("alias add %v: expected an error", args)
+		}
+	}
+
+	if _, err := run("alias", "rm", "ff"); err != nil {
+		t.Errorf("alias rm failed: %v", err)
+	}
+	if out, _ := run("alias", "list"); !strings.Contains(out, "No aliases defined") {
+		t.Errorf("Expected no aliases left, got %s", out)
+	}
+	if _, err := run("alias", "rm", "ff"); err == nil {
+		t.Error("Expected removing a missing alias to fail")
+	}
+}
+
+// TestGoldfishApp_expandAlias tests replacing an alias with the command line it stands for
+func TestGoldfishApp_expandAlias(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expanded bool
+		expected []string
+	}{
+		{[]string{"ff", "a"}, true, []string{"first", "--flag", "x", "a"}},
+		{[]string{"--verbose", "ff"}, true, []string{"--verbose", "first", "--flag", "x"}},
+		{[]string{"first", "ff"}, false, []string{"first", "ff"}},
+		// A command of the same name wins
+		{[]string{"second"}, false, []string{"second"}},
+	}
+	for _, tc := range testCases {
+		app := newLazyTestApp(tc.args)
+		app.config.Aliases = []config.Alias{
+			{Name: "ff", Command: "first", Args: []string{"--flag", "x"}},
+			{Name: "second", Command: "first"},
+		}
+		if expanded := app.expandAlias(); expanded != tc.expanded || !reflect.DeepEqual(app.args, tc.expected) {
+			t.Errorf("%v: expected %v %v, got %v %v", tc.args, tc.expanded, tc.expected, expanded, app.args)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/danballance/goldfish/internal/batch"
+)
+
+// newBatchCommand creates the built-in "batch" command
+// It runs every job listed in a manifest file and prints a summary table
+func (app *GoldfishApp) newBatchCommand() *cobra.Command {
+	var opts batch.Options
+
+	batchCmd := &cobra.Command{
+		Use:   "batch <manifest.yml>",
+		Short: "Run a list of goldfish commands from a manifest file",
+		Long: "Run a list of goldfish commands from a manifest file, optionally in parallel.\n\n" +
+			"The manifest lists jobs, each naming a command with its own arguments and parameters:\n\n" +
+			"  jobs:\n" +
+			"    - command: replace\n" +
+			"      args: [\"s/foo/bar/g\", \"file.txt\"]\n" +
+			"      params:\n" +
+			"        in_place: true",
+		Example: "  goldfish batch tasks.yml\n  goldfish batch --parallel 4 --fail-fast tasks.yml",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.Parallel < 1 {
+				return fmt.Errorf("--parallel must be at least 1")
+			}
+
+			if err := app.checkPlatform("batch"); err != nil {
+				return err
+			}
+
+			manifest, err := batch.LoadManifest(args[0])
+			if err != nil {
+				return err
+			}
+
+			closeLog, err := app.configureEngine()
+			if err != nil {
+				return err
+			}
+			defer closeLog()
+
+			runner := batch.NewRunner(app.config, app.engine, app.currentPlatform(), app.timeout())
+			results := runner.Run(manifest.Jobs, opts)
+
+			fmt.Fprintln(cmd.OutOrStdout())
+			batch.WriteSummary(cmd.OutOrStdout(), results)
+
+			if failures := batch.CountFailures(results); failures > 0 {
+				// The summary already explains what went wrong
+				cmd.SilenceUsage = true
+				return fmt.Errorf("%d of %d jobs failed", failures, len(results))
+			}
+			return nil
+		},
+	}
+
+	batchCmd.Flags().IntVar(&opts.Parallel, "parallel", 1, "number of jobs to run at the same time")
+	batchCmd.Flags().BoolVar(&opts.FailFast, "fail-fast", false, "stop starting new jobs after the first failure")
+
+	return batchCmd
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/engine"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// TestBatchCommand tests running a manifest and printing the summary
+func TestBatchCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+
+	app := &GoldfishApp{
+		engine:           engine.NewEngine(5 * time.Second),
+		platformDetector: platform.NewDetector(),
+		config: &config.Config{
+			Commands: []config.Command{
+				{
+					Name:        "ok",
+					BaseCommand: "true",
+					Platforms: map[string]config.PlatformCommand{
+						"linux":  {Template: "true"},
+						"darwin": {Template: "true"},
+					},
+				},
+			},
+		},
+	}
+
+	manifest := filepath.Join(t.TempDir(), "jobs.yml")
+	if err := os.WriteFile(manifest, []byte("jobs:\n  - command: ok\n  - command: missing\n"), 0644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+
+	cmd := app.newBatchCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"--parallel", "2", manifest})
+
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "1 of 2 jobs failed") {
+		t.Errorf("Expected one failed job, got %v", err)
+	}
+	if !strings.Contains(out.String(), "JOB") || !strings.Contains(out.String(), "unknown command 'missing'") {
+		t.Errorf("Expected summary table, got:\n%s", out.String())
+	}
+}
+
+// TestBatchCommand_InvalidParallel tests --parallel validation
+func TestBatchCommand_InvalidParallel(t *testing.T) {
+	app := &GoldfishApp{}
+	cmd := app.newBatchCommand()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"--parallel", "0", "jobs.yml"})
+
+	if err := cmd.Execute(); err == nil {
+		t.Error("Expected error for --parallel 0")
+	}
+}
@@ -0,0 +1,375 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/settings"
+)
+
+// newConfigCommand creates the built-in "config" command group
+// Its subcommands inspect and troubleshoot goldfish's own configuration, and
+// read and change the user's settings
+func (app *GoldfishApp) newConfigCommand() *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect goldfish configuration and change settings",
+	}
+
+	configCmd.AddCommand(app.newConfigBenchCommand())
+	configCmd.AddCommand(app.newConfigExportCommand())
+	configCmd.AddCommand(app.newConfigMigrateCommand())
+	configCmd.AddCommand(app.newConfigWhereCommand())
+	configCmd.AddCommand(app.newConfigGetCommand())
+	configCmd.AddCommand(app.newConfigSetCommand())
+	configCmd.AddCommand(app.newConfigUnsetCommand())
+
+	return configCmd
+}
+
+// newConfigBenchCommand creates "goldfish config bench"
+// It measures how long loading the merged configuration takes with and
+// without the parsed-config and validation caches, so the effect of caching can be verified
+func (app *GoldfishApp) newConfigBenchCommand() *cobra.Command {
+	var iterations int
+
+	benchCmd := &cobra.Command{
+		Use:   "bench",
+		Short: "Measure configuration load time with and without caching",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if iterations < 1 {
+				return fmt.Errorf("--iterations must be at least 1")
+			}
+
+			// Measure with caching disabled first
+			uncached, err := benchmarkLoad(iterations, true)
+			if err != nil {
+				return err
+			}
+			// Then with caching enabled (the first load warms the cache)
+			cached, err := benchmarkLoad(iterations, false)
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "Config load benchmark (%d iterations)\n", iterations)
+			fmt.Fprintf(out, "  without cache: %v per load\n", uncached)
+			fmt.Fprintf(out, "  with cache:    %v per load\n", cached)
+			return nil
+		},
+	}
+
+	benchCmd.Flags().IntVar(&iterations, "iterations", 20, "number of loads to average over")
+
+	return benchCmd
+}
+
+// benchmarkLoad returns the average time taken to load the merged configuration
+// When disableCache is true, GOLDFISH_NO_CACHE is set for the duration of the run
+func benchmarkLoad(iterations int, disableCache bool) (time.Duration, error) {
+	if disableCache {
+		previous, had := os.LookupEnv(config.NoCacheEnvVar)
+		os.Setenv(config.NoCacheEnvVar, "1")
+		defer func() {
+			if had {
+				os.Setenv(config.NoCacheEnvVar, previous)
+			} else {
+				os.Unsetenv(config.NoCacheEnvVar)
+			}
+		}()
+	} else {
+		// Warm the cache so every measured load can hit it
+		if _, err := config.LoadDefaultWithEmbedded(); err != nil {
+			return 0, fmt.Errorf("failed to load configuration: %w", err)
+		}
+	}
+
+	start := time.Now()
+	for i := 0; i < iterations; i++ {
+		if _, err := config.LoadDefaultWithEmbedded(); err != nil {
+			return 0, fmt.Errorf("failed to load configuration: %w", err)
+		}
+	}
+	return time.Since(start) / time.Duration(iterations), nil
+}
+
+// newConfigExportCommand creates "goldfish config export"
+// It prints the effective configuration after merging every layer, optionally
+// annotated with the file each command came from, to debug precedence issues
+func (app *GoldfishApp) newConfigExportCommand() *cobra.Command {
+	var format string
+	var showOrigin bool
+
+	exportCmd := &cobra.Command{
+		Use:     "export",
+		Short:   "Print the fully merged effective configuration",
+		Example: "  goldfish config export --origin\n  goldfish config export --format json",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			layers, err := config.LoadLayers("")
+			if err != nil {
+				return fmt.Errorf("failed to load configuration: %w", err)
+			}
+			merged := config.MergeLoadedLayers(layers)
+
+			var origins map[string]string
+			if showOrigin {
+				origins = config.Origins(layers)
+			}
+
+			switch format {
+			case "yaml":
+				return config.ExportYAML(cmd.OutOrStdout(), merged, origins)
+			case "json":
+				return config.ExportJSON(cmd.OutOrStdout(), merged, origins)
+			default:
+				return fmt.Errorf("unsupported format '%s' (use yaml or json)", format)
+			}
+		},
+	}
+
+	exportCmd.Flags().StringVar(&format, "format", "yaml", "output format: yaml or json")
+	exportCmd.Flags().BoolVar(&showOrigin, "origin", false, "annotate each command with the file it came from")
+
+	return exportCmd
+}
+
+// newConfigMigrateCommand creates "goldfish config migrate"
+// It upgrades a commands.yml written for an older format version, printing the
+// result or, with --write, replacing the file (the original is kept as .bak)
+func (app *GoldfishApp) newConfigMigrateCommand() *cobra.Command {
+	var write bool
+
+	migrateCmd := &cobra.Command{
+		Use:     "migrate [file]",
+		Short:   "Upgrade a commands.yml to the current format version",
+		Example: "  goldfish config migrate\n  goldfish config migrate --write ~/.config/goldfish/commands.yml",
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := "commands.yml"
+			if len(args) == 1 {
+				path = args[0]
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to read config file %s: %w", path, err)
+			}
+
+			migrated, notes, err := config.Migrate(data)
+			if err != nil {
+				return err
+			}
+			for _, note := range notes {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Migrated %s\n", note)
+			}
+
+			if !write {
+				_, err := cmd.OutOrStdout().Write(migrated)
+				return err
+			}
+			if string(migrated) == string(data) {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s is already at version %d\n", path, config.CurrentVersion())
+				return nil
+			}
+			if err := os.WriteFile(path+".bak", data, 0644); err != nil {
+				return fmt.Errorf("failed to back up %s: %w", path, err)
+			}
+			if err := os.WriteFile(path, migrated, 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", path, err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Upgraded %s to version %d (original saved as %s.bak)\n", path, config.CurrentVersion(), path)
+			return nil
+		},
+	}
+
+	migrateCmd.Flags().BoolVar(&write, "write", false, "rewrite the file in place instead of printing the result")
+
+	return migrateCmd
+}
+
+// newConfigWhereCommand creates "goldfish config where"
+// It lists every place configuration is looked for, in the order the layers
+// are merged, with whether each was found and the commands it contributed, so
+// users can see why an override does or does not take effect
+func (app *GoldfishApp) newConfigWhereCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "where",
+		Short: "List configuration sources in precedence order",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sources, err := config.ResolveSources("")
+			if err != nil {
+				return fmt.Errorf("failed to load configuration: %w", err)
+			}
+			return writeSources(cmd.OutOrStdout(), sources)
+		},
+	}
+}
+
+// writeSources prints configuration sources for goldfish config where
+// Commands a later source redefines are listed as overridden, with the
+// source whose definition is used.
+func writeSources(w io.Writer, sources []config.Source) error {
+	origins := config.Origins(config.LayersOf(sources))
+
+	fmt.Fprintln(w, "Configuration sources, lowest precedence first (later sources override earlier ones):")
+	if runtimePath := os.Getenv(config.ConfigEnvVar); runtimePath != "" {
+		fmt.Fprintf(w, "$%s is set, so only %s is loaded instead of the search paths\n", config.ConfigEnvVar, runtimePath)
+	}
+	fmt.Fprintln(w)
+	for i, source := range sources {
+		status := ""
+		switch {
+		case !source.Found:
+			status = " (not found)"
+		case source.Err != nil:
+			status = fmt.Sprintf(" (skipped: %v)", source.Err)
+		}
+		fmt.Fprintf(w, "%2d. %-8s  %s%s\n", i+1, source.Kind, source.Location, status)
+		if source.Config == nil {
+			continue
+		}
+
+		var used, overridden []string
+		for _, command := range source.Config.Commands {
+			if origins[command.Name] == source.Location {
+				used = append(used, command.Name)
+			} else {
+				overridden = append(overridden, fmt.Sprintf("%s (by %s)", command.Name, origins[command.Name]))
+			}
+		}
+		if len(used) == 0 {
+			used = []string{"none"}
+		}
+		fmt.Fprintf(w, "      commands: %s\n", strings.Join(used, ", "))
+		if len(overridden) > 0 {
+			fmt.Fprintf(w, "      overridden: %s\n", strings.Join(overridden, ", "))
+		}
+	}
+
+	// Settings are not commands, but belong to the same picture
+	if path, err := settings.Path(); err == nil {
+		status := ""
+		if _, err := os.Stat(path); err != nil {
+			status = " (not found)"
+		}
+		fmt.Fprintf(w, "\nSettings: %s%s\n", path, status)
+	}
+	return nil
+}
+
+// newConfigGetCommand creates "goldfish config get"
+// With a key it prints that setting's value (or its default); without one it
+// lists every setting, marking those left at their default
+func (app *GoldfishApp) newConfigGetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "get [key]",
+		Short:   "Show user settings",
+		Example: "  goldfish config get\n  goldfish config get timeout",
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := settings.Path()
+			if err != nil {
+				return err
+			}
+			current, err := settings.LoadFile(path)
+			if err != nil {
+				return err
+			}
+
+			if len(args) == 1 {
+				key, err := settings.Lookup(args[0])
+				if err != nil {
+					return err
+				}
+				value := key.Get(current)
+				if value == "" {
+					value = key.Default
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), value)
+				return nil
+			}
+
+			writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+			fmt.Fprintln(writer, "KEY\tVALUE\tDESCRIPTION")
+			for i := range settings.Keys {
+				key := &settings.Keys[i]
+				value := key.Get(current)
+				if value == "" {
+					value = key.Default + " (default)"
+				}
+				fmt.Fprintf(writer, "%s\t%s\t%s\n", key.Name, value, key.Description)
+			}
+			if err := writer.Flush(); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "\nSettings file: %s\n", path)
+			return nil
+		},
+	}
+}
+
+// newConfigSetCommand creates "goldfish config set"
+// The value is checked before the settings file is written
+func (app *GoldfishApp) newConfigSetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "set <key> <value>",
+		Short:   "Change a user setting",
+		Example: "  goldfish config set timeout 5m\n  goldfish config set confirm destructive",
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := updateSetting(args[0], args[1])
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Set %s to %s in %s\n", args[0], args[1], path)
+			return nil
+		},
+	}
+}
+
+// newConfigUnsetCommand creates "goldfish config unset", which restores a
+// setting's default
+func (app *GoldfishApp) newConfigUnsetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "unset <key>",
+		Short: "Restore the default of a user setting",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := updateSetting(args[0], "")
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Unset %s in %s\n", args[0], path)
+			return nil
+		},
+	}
+}
+
+// updateSetting changes one setting in the settings file and returns its path
+func updateSetting(name, value string) (string, error) {
+	key, err := settings.Lookup(name)
+	if err != nil {
+		return "", err
+	}
+	path, err := settings.Path()
+	if err != nil {
+		return "", err
+	}
+	current, err := settings.LoadFile(path)
+	if err != nil {
+		return "", err
+	}
+	if err := key.Set(current, value); err != nil {
+		return "", err
+	}
+	return path, current.Save(path)
+}
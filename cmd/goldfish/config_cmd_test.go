@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/settings"
+)
+
+// TestConfigBenchCommand tests the "config bench" output
+func TestConfigBenchCommand(t *testing.T) {
+	t.Setenv(config.CacheDirEnvVar, t.TempDir())
+	t.Setenv(config.NoCacheEnvVar, "")
+	app := &GoldfishApp{}
+
+	cmd := app.newConfigCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"bench", "--iterations", "2"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("config bench failed: %v", err)
+	}
+
+	output := out.String()
+	for _, expected := range []string{"2 iterations", "without cache:", "with cache:"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, output)
+		}
+	}
+
+	// The benchmark must not leave caching disabled behind it
+	if os.Getenv(config.NoCacheEnvVar) != "" {
+		t.Errorf("Expected %s to be restored", config.NoCacheEnvVar)
+	}
+}
+
+// TestConfigBenchCommand_InvalidIterations tests argument validation
+func TestConfigBenchCommand_InvalidIterations(t *testing.T) {
+	app := &GoldfishApp{}
+
+	cmd := app.newConfigCommand()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"bench", "--iterations", "0"})
+
+	if err := cmd.Execute(); err == nil {
+		t.Error("Expected error for zero iterations")
+	}
+}
+
+// TestConfigExportCommand tests exporting the merged configuration
+func TestConfigExportCommand(t *testing.T) {
+	t.Chdir(t.TempDir())
+	app := &GoldfishApp{}
+
+	cmd := app.newConfigCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"export", "--origin"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("config export failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "# origin: "+config.EmbeddedSource) {
+		t.Errorf("Expected origin annotations, got:\n%s", out.String())
+	}
+
+	cmd.SetArgs([]string{"export", "--format", "toml"})
+	if err := cmd.Execute(); err == nil {
+		t.Error("Expected error for unsupported format")
+	}
+}
+
+// TestConfigMigrateCommand tests printing and writing a migrated config
+func TestConfigMigrateCommand(t *testing.T) {
+	t.Chdir(t.TempDir())
+	original := "commands:\n  - name: \"x\"\n    base_command: \"x\"\n    platforms:\n      linux:\n        template: \"x\"\n"
+	if err := os.WriteFile("commands.yml", []byte(original), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	app := &GoldfishApp{}
+
+	// Without --write the result is printed and the file left alone
+	cmd := app.newConfigCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"migrate"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("config migrate failed: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "version: 1\n") {
+		t.Errorf("Expected migrated output, got:\n%s", out.String())
+	}
+	if data, _ := os.ReadFile("commands.yml"); string(data) != original {
+		t.Errorf("Expected file to be unchanged without --write")
+	}
+
+	// With --write the file is upgraded and backed up
+	cmd.SetArgs([]string{"migrate", "--write"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("config migrate --write failed: %v", err)
+	}
+	if data, _ := os.ReadFile("commands.yml"); !strings.HasPrefix(string(data), "version: 1\n") {
+		t.Errorf("Expected file to be stamped with the version, got:\n%s", data)
+	}
+	if data, _ := os.ReadFile("commands.yml.bak"); string(data) != original {
+		t.Errorf("Expected the original to be backed up")
+	}
+
+	// A second run has nothing to do
+	out.Reset()
+	cmd.SetArgs([]string{"migrate", "--write"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("config migrate --write failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "already at version") {
+		t.Errorf("Expected already-current message, got %s", out.String())
+	}
+}
+
+// TestConfigSetGetCommands tests changing, reading and unsetting user settings
+func TestConfigSetGetCommands(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.yml")
+	t.Setenv(settings.EnvVar, path)
+	app := &GoldfishApp{}
+
+	run := func(args ...string) (string, error) {
+		cmd := app.newConfigCommand()
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetErr(&out)
+		cmd.SetArgs(args)
+		err := cmd.Execute()
+		return out.String(), err
+	}
+
+	if out, err := run("get", "timeout"); err != nil || out != "30s\n" {
+		t.Errorf("Expected the default timeout, got %q (%v)", out, err)
+	}
+	if _, err := run("set", "timeout", "2m"); err != nil {
+		t.Fatalf("config set failed: %v", err)
+	}
+	if out, err := run("get", "timeout"); err != nil || out != "2m0s\n" {
+		t.Errorf("Expected the new timeout, got %q (%v)", out, err)
+	}
+	out, err := run("get")
+	if err != nil || !strings.Contains(out, "2m0s") || !strings.Contains(out, "auto (default)") || !strings.Contains(out, path) {
+		t.Errorf("Expected every setting listed, got %q (%v)", out, err)
+	}
+
+	// Invalid values and unknown keys leave the file alone
+	if _, err := run("set", "confirm", "sometimes"); err == nil {
+		t.Error("Expected an invalid value to be rejected")
+	}
+	if _, err := run("set", "colour", "never"); err == nil || !strings.Contains(err.Error(), "unknown setting") {
+		t.Errorf("Expected an unknown key to be rejected, got %v", err)
+	}
+
+	if _, err := run("unset", "timeout"); err != nil {
+		t.Fatalf("config unset failed: %v", err)
+	}
+	if out, _ := run("get", "timeout"); out != "30s\n" {
+		t.Errorf("Expected the default back, got %q", out)
+	}
+}
+
+// TestWriteSources tests listing sources with the commands each contributes
+func TestWriteSources(t *testing.T) {
+	t.Setenv(config.ConfigEnvVar, "")
+	t.Setenv(settings.EnvVar, filepath.Join(t.TempDir(), "settings.yml"))
+	base := &config.Config{Commands: []config.Command{{Name: "shared"}, {Name: "base-only"}}}
+	project := &config.Config{Commands: []config.Command{{Name: "shared"}}}
+	sources := []config.Source{
+		{Kind: config.SourceEmbedded, Location: config.EmbeddedSource, Found: true, Config: base},
+		{Kind: config.SourceSearch, Location: "/etc/goldfish/commands.yml"},
+		{Kind: config.SourceSearch, Location: "/home/me/commands.yml", Found: true, Err: fmt.Errorf("bad yaml")},
+		{Kind: config.SourceSearch, Location: "/work/commands.yml", Found: true, Config: project},
+	}
+
+	var out bytes.Buffer
+	if err := writeSources(&out, sources); err != nil {
+		t.Fatalf("writeSources() failed: %v", err)
+	}
+	for _, expected := range []string{
+		"commands: base-only\n",
+		"overridden: shared (by /work/commands.yml)",
+		"/etc/goldfish/commands.yml (not found)",
+		"/home/me/commands.yml (skipped: bad yaml)",
+		" 4. search    /work/commands.yml\n      commands: shared",
+		"settings.yml (not found)",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, out.String())
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+	"github.com/danballance/goldfish/internal/docs"
+)
+
+// newDocsCommand creates the built-in "docs" command group
+// Its subcommands generate documentation for goldfish and every configured command
+func (app *GoldfishApp) newDocsCommand() *cobra.Command {
+	docsCmd := &cobra.Command{
+		Use:   "docs",
+		Short: "Generate documentation for goldfish commands",
+	}
+
+	docsCmd.AddCommand(app.newDocsManCommand())
+	docsCmd.AddCommand(app.newDocsMarkdownCommand())
+
+	return docsCmd
+}
+
+// newDocsManCommand creates "goldfish docs man"
+// It writes one man page per command, so distro packages can ship them
+func (app *GoldfishApp) newDocsManCommand() *cobra.Command {
+	var outDir string
+
+	manCmd := &cobra.Command{
+		Use:     "man",
+		Short:   "Generate man pages for goldfish and all configured commands",
+		Example: "  goldfish docs man --out ./man",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := os.MkdirAll(outDir, 0755); err != nil {
+				return fmt.Errorf("failed to create output directory: %w", err)
+			}
+
+			header := &doc.GenManHeader{
+				Title:   "GOLDFISH",
+				Section: "1",
+				Source:  "goldfish " + Version,
+				Manual:  "Goldfish Manual",
+			}
+			// Pages are generated from the whole command tree, which includes
+			// every YAML-defined command with its flags and examples
+			app.materializeAll()
+			if err := doc.GenManTree(app.rootCmd, header, outDir); err != nil {
+				return fmt.Errorf("failed to generate man pages: %w", err)
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Man pages written to %s\n", outDir)
+			return nil
+		},
+	}
+
+	manCmd.Flags().StringVar(&outDir, "out", "man", "directory to write the man pages to")
+
+	return manCmd
+}
+
+// newDocsMarkdownCommand creates "goldfish docs markdown"
+// It writes one Markdown page per command from the merged configuration,
+// ready to publish as a wiki
+func (app *GoldfishApp) newDocsMarkdownCommand() *cobra.Command {
+	var outDir string
+
+	markdownCmd := &cobra.Command{
+		Use:     "markdown",
+		Short:   "Generate Markdown pages for all configured commands",
+		Example: "  goldfish docs markdown --out ./wiki",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			written, err := docs.NewGenerator().WriteAll(app.config, outDir)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%d Markdown pages written to %s\n", len(written), outDir)
+			return nil
+		},
+	}
+
+	markdownCmd.Flags().StringVar(&outDir, "out", "docs", "directory to write the Markdown pages to")
+
+	return markdownCmd
+}
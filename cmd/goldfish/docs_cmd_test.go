@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// TestDocsManCommand tests generating man pages for configured commands
+func TestDocsManCommand(t *testing.T) {
+	app := newLazyTestApp(nil)
+	app.config.Commands[0].Parameters = []config.Parameter{
+		{Name: "pattern", Type: "string", Required: true, Description: "the pattern to use"},
+	}
+	if err := app.generateCommands(); err != nil {
+		t.Fatalf("generateCommands() failed: %v", err)
+	}
+	app.rootCmd.AddCommand(app.newDocsCommand())
+
+	outDir := filepath.Join(t.TempDir(), "man")
+	app.rootCmd.SetOut(&bytes.Buffer{})
+	app.rootCmd.SetArgs([]string{"docs", "man", "--out", outDir})
+	if err := app.rootCmd.Execute(); err != nil {
+		t.Fatalf("docs man failed: %v", err)
+	}
+
+	// One page for the root and one per command
+	for _, page := range []string{"goldfish.1", "goldfish-first.1", "goldfish-second.1"} {
+		if _, err := os.Stat(filepath.Join(outDir, page)); err != nil {
+			t.Errorf("Expected man page %s: %v", page, err)
+		}
+	}
+
+	// Parameters and examples appear in the command's page
+	data, err := os.ReadFile(filepath.Join(outDir, "goldfish-first.1"))
+	if err != nil {
+		t.Fatalf("Failed to read man page: %v", err)
+	}
+	for _, expected := range []string{"pattern", "the pattern to use"} {
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("Expected man page to mention %q", expected)
+		}
+	}
+}
+
+// TestDocsMarkdownCommand tests generating Markdown pages
+func TestDocsMarkdownCommand(t *testing.T) {
+	app := newLazyTestApp(nil)
+	outDir := filepath.Join(t.TempDir(), "wiki")
+
+	cmd := app.newDocsCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"markdown", "--out", outDir})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("docs markdown failed: %v", err)
+	}
+
+	for _, page := range []string{"first.md", "second.md", "index.md"} {
+		if _, err := os.Stat(filepath.Join(outDir, page)); err != nil {
+			t.Errorf("Expected page %s: %v", page, err)
+		}
+	}
+	if !strings.Contains(out.String(), "3 Markdown pages") {
+		t.Errorf("Unexpected output: %s", out.String())
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/danballance/goldfish/internal/cli"
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/engine"
+)
+
+// newExplainCommand creates the built-in "explain" command
+// It shows, step by step, how a command line would be resolved: the file
+// that defines the command, the platform and template chosen and why, each
+// parameter's value and where it came from, and the rendered command line.
+// Nothing is run.
+func (app *GoldfishApp) newExplainCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "explain <command> [flags and arguments]",
+		Short:   "Show how a command line is resolved and rendered, without running it",
+		Example: "  goldfish explain find-files --name '*.go' .\n  goldfish --platform windows explain replace 's/a/b/' notes.txt",
+		// The flags belong to the explained command, not to explain
+		DisableFlagParsing: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Without flag parsing, root flags given before "explain" (such
+			// as --platform, already read from the raw arguments) arrive here
+			for len(args) > 0 && strings.HasPrefix(args[0], "-") && args[0] != "-h" && args[0] != "--help" {
+				if app.takesValue(args[0]) && len(args) > 1 {
+					args = args[1:]
+				}
+				args = args[1:]
+			}
+			if len(args) > 0 && (args[0] == "-h" || args[0] == "--help") {
+				return cmd.Help()
+			}
+			if len(args) == 0 {
+				return fmt.Errorf("usage: goldfish explain <command> [flags and arguments]")
+			}
+			// Errors are about the explained command, not explain's usage
+			cmd.SilenceUsage = true
+			return app.explain(cmd.OutOrStdout(), args[0], args[1:])
+		},
+	}
+}
+
+// explain writes how the named command resolves with the given flags and arguments
+func (app *GoldfishApp) explain(w io.Writer, name string, args []string) error {
+	target, found := app.config.FindCommand(name)
+	if !found {
+		return &config.CommandNotFoundError{Name: name}
+	}
+	currentPlatform := app.currentPlatform()
+	if !engine.Supports(target, currentPlatform) {
+		return engine.NewUnsupportedPlatformError(target, currentPlatform.String())
+	}
+	cobraCmd := app.newCommand(target, currentPlatform)
+	// Root flags may follow the command too, e.g. "explain find --platform darwin"
+	if app.rootCmd != nil {
+		cobraCmd.Flags().AddFlagSet(app.rootCmd.PersistentFlags())
+	}
+	if err := cobraCmd.ParseFlags(args); err != nil {
+		return fmt.Errorf("invalid flags for '%s': %w", target.Name, err)
+	}
+	positional := cobraCmd.Flags().Args()
+	flags := cli.FlagValues(target, cobraCmd)
+	params, err := app.engine.ParseParameters(target, positional, flags)
+	if err != nil {
+		return fmt.Errorf("failed to parse parameters: %w", err)
+	}
+	explanation, err := app.engine.Explain(&engine.ExecutionContext{
+		Command:    target,
+		Platform:   currentPlatform,
+		Parameters: params,
+		Timeout:    app.timeout(),
+	})
+	if err != nil {
+		return err
+	}
+
+	// 1. Where the command is defined; working this out again from the
+	// layers only adds to the explanation, so failing to is not an error
+	fmt.Fprintf(w, "Command: %s\n", target.Name)
+	if name != target.Name {
+		fmt.Fprintf(w, "  '%s' is its alias\n", name)
+	}
+	if layers, err := config.LoadLayers(""); err == nil {
+		if source := config.Origins(layers)[target.Name]; source != "" {
+			fmt.Fprintf(w, "  defined in %s\n", source)
+		}
+	}
+
+	// 2. The platform and the template chosen for it
+	fmt.Fprintf(w, "\nPlatform: %s\n", explanation.Platform)
+	if app.platformOverride != "" {
+		fmt.Fprintln(w, "  chosen with --platform")
+	}
+	if explanation.System != "" {
+		fmt.Fprintf(w, "  detected %s\n", explanation.System)
+	}
+	defined := make([]string, 0, len(target.Platforms))
+	for key := range target.Platforms {
+		defined = append(defined, key)
+	}
+	sort.Strings(defined)
+	fmt.Fprintf(w, "\nTemplate: %s\n", explanation.Selection.Key)
+	fmt.Fprintf(w, "  keys tried, most specific first: %s\n", strings.Join(explanation.Keys, ", "))
+	fmt.Fprintf(w, "  keys the command defines: %s\n", strings.Join(defined, ", "))
+	fmt.Fprintf(w, "  chosen because %s\n", explanation.Reason)
+	if explanation.Selection.BaseCommand != target.BaseCommand {
+		fmt.Fprintf(w, "  base_command: %s (instead of %s)\n", explanation.Selection.BaseCommand, target.BaseCommand)
+	}
+	fmt.Fprintf(w, "  shell: %s\n", explanation.Shell)
+
+	// 3. Each parameter's value and where it came from
+	if len(target.Parameters) > 0 {
+		fmt.Fprintln(w, "\nParameters:")
+		sources := engine.ParameterSources(target, positional, flags)
+		// String flags report their default as if it were given, so the
+		// flags that were not changed are put right
+		for _, param := range target.Parameters {
+			flagName := param.Name
+			if param.Flag != "" {
+				flagName = strings.TrimLeft(param.Flag, "-")
+			}
+			if sources[param.Name] == engine.SourceFlag && !cobraCmd.Flags().Changed(flagName) {
+				sources[param.Name] = engine.SourceDefault
+			}
+		}
+		writer := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		for _, param := range target.Parameters {
+			value := "-"
+			if v, set := params[param.Name]; set {
+				value = fmt.Sprintf("%v", v)
+				if param.Secret {
+					value = "********"
+				}
+			}
+			fmt.Fprintf(writer, "  %s\t%s\t%s\n", param.Name, value, sources[param.Name])
+		}
+		writer.Flush()
+	}
+
+	// 4. The command's vars, then the command line itself
+	if len(explanation.Vars) > 0 {
+		fmt.Fprintln(w, "\nVars:")
+		names := make([]string, 0, len(explanation.Vars))
+		for name := range explanation.Vars {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		writer := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		for _, name := range names {
+			fmt.Fprintf(writer, "  %s\t%s\n", name, explanation.Vars[name])
+		}
+		writer.Flush()
+	}
+	fmt.Fprintf(w, "\nRendered (not run):\n  %s\n", explanation.Rendered)
+	return nil
+}
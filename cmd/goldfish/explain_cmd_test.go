@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// TestExplainCommand tests explaining a command line without running it
+func TestExplainCommand(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	run := func(args ...string) (string, error) {
+		t.Helper()
+		app := newLazyTestApp(nil)
+		app.config.Commands[0].Parameters = []config.Parameter{
+			{Name: "message", Type: "string"},
+			{Name: "times", Type: "int", Default: 1},
+			{Name: "loud", Type: "bool"},
+		}
+		app.config.Commands[0].Platforms = map[string]config.PlatformCommand{
+			"linux":   {Template: "echo {{.params.message}} x{{.params.times}}"},
+			"darwin":  {Template: "echo {{.params.message}} x{{.params.times}}"},
+			"windows": {Template: "echo {{.params.message}} x{{.params.times}}"},
+		}
+		app.rootCmd.PersistentFlags().StringVar(&app.platformOverride, "platform", "", "")
+		app.rootCmd.AddCommand(app.newExplainCommand())
+		var out bytes.Buffer
+		app.rootCmd.SetOut(&out)
+		app.rootCmd.SetErr(&bytes.Buffer{})
+		app.rootCmd.SetArgs(args)
+		err := app.rootCmd.Execute()
+		return out.String(), err
+	}
+
+	out, err := run("explain", "f", "--loud", "hello")
+	if err != nil {
+		t.Fatalf("explain failed: %v", err)
+	}
+	for _, expected := range []string{
+		"Command: first", "'f' is its alias",
+		"Template: ", "chosen because it is the first of the keys tried",
+		"message  hello  argument", "times    1      default", "loud     true   flag",
+		"Rendered (not run):\n  echo hello x1",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("Expected %q in the explanation, got:\n%s", expected, out)
+		}
+	}
+
+	// Root flags are skipped before the command name
+	if out, err := run("explain", "--platform", "windows", "first", "hi"); err != nil || !strings.Contains(out, "echo hi x1") {
+		t.Errorf("Expected the root flag to be skipped, got %q (%v)", out, err)
+	}
+
+	errorCases := map[string][]string{
+		"unknown command 'nope'":  {"explain", "nope"},
+		"invalid flags":           {"explain", "first", "--no-such-flag"},
+		"usage: goldfish explain": {"explain"},
+	}
+	for expected, args := range errorCases {
+		if _, err := run(args...); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("%v: expected an error containing %q, got %v", args, expected, err)
+		}
+	}
+}
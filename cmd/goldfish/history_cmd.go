@@ -0,0 +1,180 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/engine"
+	"github.com/danballance/goldfish/internal/history"
+	"github.com/danballance/goldfish/internal/settings"
+)
+
+// goldfishExecutable returns the program goldfish rerun starts; tests replace it
+var goldfishExecutable = os.Executable
+
+// newHistoryCommand creates the built-in "history" command
+// It lists the commands goldfish has run, newest last as in a shell, with
+// the ID that goldfish rerun takes.
+func (app *GoldfishApp) newHistoryCommand() *cobra.Command {
+	var failed bool
+	var limit int
+	historyCmd := &cobra.Command{
+		Use:     "history",
+		Short:   "List the commands goldfish has run",
+		Example: "  goldfish history\n  goldfish history --failed\n  goldfish rerun last",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := history.Path()
+			if err != nil {
+				return err
+			}
+			entries, err := history.Load(path)
+			if err != nil {
+				return err
+			}
+			if failed {
+				var failures []history.Entry
+				for _, entry := range entries {
+					if entry.Failed() {
+						failures = append(failures, entry)
+					}
+				}
+				entries = failures
+			}
+			if limit > 0 && len(entries) > limit {
+				entries = entries[len(entries)-limit:]
+			}
+			if len(entries) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No commands in the history")
+				return nil
+			}
+
+			writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+			fmt.Fprintln(writer, "ID\tTIME\tSTATUS\tCOMMAND LINE")
+			for _, entry := range entries {
+				fmt.Fprintf(writer, "%d\t%s\t%s\t%s\n", entry.ID, entry.Time.Local().Format("2006-01-02 15:04:05"), historyStatus(&entry), entry.CommandLine())
+			}
+			return writer.Flush()
+		},
+	}
+	historyCmd.Flags().BoolVar(&failed, "failed", false, "only list commands that failed")
+	historyCmd.Flags().IntVarP(&limit, "limit", "n", 20, "list at most this many of the newest entries (0 for all)")
+	return historyCmd
+}
+
+// historyStatus describes how an entry's command ended: ok, its exit code or error
+func historyStatus(entry *history.Entry) string {
+	switch {
+	case entry.Error != "":
+		return "error"
+	case entry.ExitCode != 0:
+		return fmt.Sprintf("exit %d", entry.ExitCode)
+	default:
+		return "ok"
+	}
+}
+
+// newRerunCommand creates the built-in "rerun" command
+// It runs a command from the history again with the same arguments, in the
+// directory it ran in, as a new goldfish process: the configuration is read
+// afresh there, just as when it was first typed.
+func (app *GoldfishApp) newRerunCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "rerun <id|last>",
+		Short:   "Run a command from the history again",
+		Example: "  goldfish rerun last\n  goldfish rerun 42\n  goldfish --dry-run rerun 42",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := history.Path()
+			if err != nil {
+				return err
+			}
+			entries, err := history.Load(path)
+			if err != nil {
+				return err
+			}
+			entry, err := history.Find(entries, args[0])
+			if err != nil {
+				return err
+			}
+			if entry.Masked {
+				return fmt.Errorf("entry %d had secret values, which the history does not keep; run it again by hand: %s", entry.ID, entry.CommandLine())
+			}
+			if app.dryRun {
+				fmt.Fprintln(cmd.OutOrStdout(), entry.CommandLine())
+				return nil
+			}
+			if entry.Dir != "" {
+				if _, err := os.Stat(entry.Dir); err != nil {
+					return fmt.Errorf("entry %d ran in %s, which no longer exists", entry.ID, entry.Dir)
+				}
+			}
+
+			program, err := goldfishExecutable()
+			if err != nil {
+				return fmt.Errorf("failed to locate goldfish: %w", err)
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Rerunning: %s\n", entry.CommandLine())
+			rerun := exec.Command(program, entry.Args...)
+			rerun.Dir = entry.Dir
+			rerun.Stdin = cmd.InOrStdin()
+			rerun.Stdout = cmd.OutOrStdout()
+			rerun.Stderr = cmd.ErrOrStderr()
+			err = rerun.Run()
+			// The new process has already reported its own errors; only its
+			// exit code is passed on
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				cmd.SilenceUsage = true
+				return engine.ExitStatus(exitErr.ExitCode())
+			}
+			if err != nil {
+				return fmt.Errorf("failed to rerun entry %d: %w", entry.ID, err)
+			}
+			return nil
+		},
+	}
+}
+
+// historyPath returns the file to record the commands run in, or "" when
+// the history setting is off or there is nowhere to keep it
+func historyPath(s *settings.Settings) string {
+	if s.History == settings.HistoryOff {
+		return ""
+	}
+	path, err := history.Path()
+	if err != nil {
+		return ""
+	}
+	return path
+}
+
+// recordHistory adds the command ctx ran to the history, if one is kept
+// Recording must never break the command itself, so a failure is only a warning.
+func (app *GoldfishApp) recordHistory(ctx *engine.ExecutionContext, start time.Time, result *engine.ExecutionResult, runErr error) {
+	if app.historyPath == "" {
+		return
+	}
+
+	entry := history.New(ctx.Command, app.args, ctx.Parameters)
+	entry.Time = start.UTC()
+	entry.DurationMS = time.Since(start).Milliseconds()
+	entry.Dir, _ = os.Getwd()
+	entry.ExitCode = -1
+	if result != nil {
+		entry.ExitCode = result.ExitCode
+	}
+	if runErr != nil {
+		// Errors such as a timeout name the command line, secrets included
+		entry.Error = app.engine.MaskError(ctx, runErr)
+	}
+	if _, err := history.Append(app.historyPath, entry); err != nil {
+		fmt.Fprintf(config.Warnings, "Warning: not recorded in the history: %v\n", err)
+	}
+}
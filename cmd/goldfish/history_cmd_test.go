@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/engine"
+	"github.com/danballance/goldfish/internal/history"
+)
+
+// recordTestHistory records commands run by an app whose history is at path
+func recordTestHistory(t *testing.T, path string) {
+	t.Helper()
+	greet := &config.Command{
+		Name:       "greet",
+		Parameters: []config.Parameter{{Name: "name", Type: "string"}, {Name: "token", Type: "string", Secret: true}},
+	}
+	app := &GoldfishApp{engine: engine.NewEngine(0), historyPath: path, args: []string{"greet", "--name", "Ada Lovelace"}}
+	app.recordHistory(&engine.ExecutionContext{Command: greet, Parameters: map[string]interface{}{"name": "Ada Lovelace"}}, time.Now(), &engine.ExecutionResult{}, nil)
+	app.args = []string{"greet", "--name", "Bob"}
+	app.recordHistory(&engine.ExecutionContext{Command: greet, Parameters: map[string]interface{}{"name": "Bob"}}, time.Now(), &engine.ExecutionResult{ExitCode: 3}, nil)
+	app.args = []string{"greet", "--token", "s3cret"}
+	app.recordHistory(&engine.ExecutionContext{Command: greet, Parameters: map[string]interface{}{"token": "s3cret"}}, time.Now(), nil, errors.New("boom: timed out running curl -u s3cret"))
+}
+
+// TestHistoryCommand tests recording commands and listing them
+func TestHistoryCommand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	t.Setenv(history.EnvVar, path)
+	recordTestHistory(t, path)
+
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "s3cret") {
+		t.Errorf("Expected the secret to be masked in the history, got:\n%s", data)
+	}
+
+	var out bytes.Buffer
+	cmd := (&GoldfishApp{}).newHistoryCommand()
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("history failed: %v", err)
+	}
+	for _, expected := range []string{"ID", "1 ", "ok", "goldfish greet --name 'Ada Lovelace'", "exit 3", "error"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected %q in the history, got:\n%s", expected, out.String())
+		}
+	}
+
+	out.Reset()
+	cmd = (&GoldfishApp{}).newHistoryCommand()
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"--failed", "-n", "1"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("history --failed failed: %v", err)
+	}
+	if strings.Contains(out.String(), "Lovelace") || strings.Contains(out.String(), "Bob") || !strings.Contains(out.String(), "error") {
+		t.Errorf("Expected only the newest failure, got:\n%s", out.String())
+	}
+}
+
+// TestRerunCommand tests running an entry again, as it was typed
+func TestRerunCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses echo in place of goldfish")
+	}
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	t.Setenv(history.EnvVar, path)
+	recordTestHistory(t, path)
+
+	// With --dry-run the command line is only printed
+	var out bytes.Buffer
+	cmd := (&GoldfishApp{dryRun: true}).newRerunCommand()
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"1"})
+	if err := cmd.Execute(); err != nil || strings.TrimSpace(out.String()) != "goldfish greet --name 'Ada Lovelace'" {
+		t.Errorf("Expected the command line, got %q (%v)", out.String(), err)
+	}
+
+	// echo stands in for goldfish, printing the arguments it is given
+	goldfishExecutable = func() (string, error) { return "echo", nil }
+	defer func() { goldfishExecutable = os.Executable }()
+	out.Reset()
+	var stderr bytes.Buffer
+	cmd = (&GoldfishApp{}).newRerunCommand()
+	cmd.SetOut(&out)
+	cmd.SetErr(&stderr)
+	cmd.SetArgs([]string{"1"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("rerun failed: %v", err)
+	}
+	if out.String() != "greet --name Ada Lovelace\n" || !strings.Contains(stderr.String(), "Rerunning: goldfish greet") {
+		t.Errorf("Expected entry 1 to run again, got %q and %q", out.String(), stderr.String())
+	}
+
+	// Secrets are not kept, so an entry that had one cannot be run again
+	cmd = (&GoldfishApp{}).newRerunCommand()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"last"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "secret values") {
+		t.Errorf("Expected a masked entry to be refused, got %v", err)
+	}
+}
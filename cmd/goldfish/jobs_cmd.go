@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/engine"
+	"github.com/danballance/goldfish/internal/jobs"
+)
+
+// followInterval is how often goldfish jobs logs --follow checks for more output
+var followInterval = 500 * time.Millisecond
+
+// newJobsCommand creates the built-in "jobs" command
+// It manages the commands goldfish --detach left running in the background:
+// list shows them, logs prints a job's output and stop ends it.
+func (app *GoldfishApp) newJobsCommand() *cobra.Command {
+	jobsCmd := &cobra.Command{
+		Use:     "jobs",
+		Short:   "List, inspect and stop commands started with --detach",
+		Example: "  goldfish --detach archive-create --archive backup.tar.gz --files ~/projects --compress\n  goldfish jobs list\n  goldfish jobs logs --follow last\n  goldfish jobs stop 3",
+		Args:    cobra.NoArgs,
+		// Without a subcommand the jobs are listed
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return listJobs(cmd.OutOrStdout())
+		},
+	}
+	jobsCmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List the background jobs and whether they are still running",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return listJobs(cmd.OutOrStdout())
+		},
+	})
+
+	var follow bool
+	logsCmd := &cobra.Command{
+		Use:   "logs <id|last>",
+		Short: "Print a job's output",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Errors are about the job, not the command's usage
+			cmd.SilenceUsage = true
+			job, err := findJob(args[0])
+			if err != nil {
+				return err
+			}
+			return printJobLog(cmd.OutOrStdout(), job, follow)
+		},
+	}
+	logsCmd.Flags().BoolVarP(&follow, "follow", "f", false, "keep printing output until the job exits")
+	jobsCmd.AddCommand(logsCmd)
+
+	jobsCmd.AddCommand(&cobra.Command{
+		Use:   "stop <id|last>",
+		Short: "Stop a job and the programs it started",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Errors are about the job, not the command's usage
+			cmd.SilenceUsage = true
+			job, err := findJob(args[0])
+			if err != nil {
+				return err
+			}
+			if err := job.Stop(); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Stopped job %d (%s)\n", job.ID, job.Command)
+			return nil
+		},
+	})
+	return jobsCmd
+}
+
+// listJobs writes a table of the recorded jobs, oldest first
+func listJobs(w io.Writer) error {
+	dir, err := jobs.Dir()
+	if err != nil {
+		return err
+	}
+	list, err := jobs.List(dir)
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		fmt.Fprintln(w, "No background jobs")
+		return nil
+	}
+	writer := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "ID\tPID\tSTATUS\tSTARTED\tCOMMAND LINE")
+	for _, job := range list {
+		fmt.Fprintf(writer, "%d\t%d\t%s\t%s\t%s\n", job.ID, job.PID, job.Status(), job.Started.Local().Format("2006-01-02 15:04:05"), job.CommandLine)
+	}
+	return writer.Flush()
+}
+
+// findJob returns the recorded job that ref refers to (see jobs.Find)
+func findJob(ref string) (*jobs.Job, error) {
+	dir, err := jobs.Dir()
+	if err != nil {
+		return nil, err
+	}
+	list, err := jobs.List(dir)
+	if err != nil {
+		return nil, err
+	}
+	return jobs.Find(list, ref)
+}
+
+// printJobLog copies a job's log to w; with follow it keeps copying what the
+// job writes until the job has exited
+func printJobLog(w io.Writer, job *jobs.Job, follow bool) error {
+	log, err := os.Open(job.Log)
+	if err != nil {
+		return fmt.Errorf("failed to open the log of job %d: %w", job.ID, err)
+	}
+	defer log.Close()
+	for {
+		// Checked before copying, so output written just before the job
+		// exited is still printed
+		running := follow && job.Status() == jobs.StatusRunning
+		if _, err := io.Copy(w, log); err != nil {
+			return fmt.Errorf("failed to read the log of job %d: %w", job.ID, err)
+		}
+		if !running {
+			return nil
+		}
+		time.Sleep(followInterval)
+	}
+}
+
+// startJob starts ctx's command in the background instead of running it,
+// and records it as a job
+func (app *GoldfishApp) startJob(cobraCmd *cobra.Command, ctx *engine.ExecutionContext) error {
+	dir, err := jobs.Dir()
+	if err != nil {
+		return err
+	}
+	job, log, err := jobs.Create(dir, ctx.Command.Name)
+	if err != nil {
+		return err
+	}
+	defer log.Close()
+
+	detached, err := app.engine.Detach(commandContext(cobraCmd), ctx, log)
+	if err != nil {
+		// Nothing was started, so the job is not kept
+		log.Close()
+		os.Remove(job.Log)
+		return err
+	}
+	job.PID = detached.PID
+	job.CommandLine = detached.Command
+	job.Dir, _ = os.Getwd()
+	job.Started = time.Now().UTC()
+	if err := jobs.Save(dir, job); err != nil {
+		// The command runs regardless; only goldfish jobs will not know of it
+		fmt.Fprintf(config.Warnings, "Warning: %v\n", err)
+	}
+	fmt.Fprintf(cobraCmd.ErrOrStderr(), "Started job %d (process %d); goldfish jobs logs %d shows its output\n", job.ID, job.PID, job.ID)
+	return nil
+}
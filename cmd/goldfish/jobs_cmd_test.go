@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/engine"
+	"github.com/danballance/goldfish/internal/jobs"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// runJobsCommand runs goldfish jobs with args and returns what it printed
+func runJobsCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	cmd := (&GoldfishApp{}).newJobsCommand()
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+// TestJobsCommand tests starting a job with --detach, listing it and printing its log
+func TestJobsCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+	t.Setenv(jobs.EnvVar, filepath.Join(t.TempDir(), "jobs"))
+	followInterval = 10 * time.Millisecond
+
+	if out, err := runJobsCommand(t, "list"); err != nil || !strings.Contains(out, "No background jobs") {
+		t.Errorf("Expected no jobs, got %q (%v)", out, err)
+	}
+
+	greet := &config.Command{
+		Name:        "greet",
+		BaseCommand: "echo",
+		Platforms:   map[string]config.PlatformCommand{"unix": {Template: "sleep 0.2; echo hello"}},
+	}
+	app := &GoldfishApp{engine: engine.NewEngine(time.Second)}
+	var stderr bytes.Buffer
+	cobraCmd := &cobra.Command{}
+	cobraCmd.SetErr(&stderr)
+	ctx := &engine.ExecutionContext{Command: greet, Platform: platform.SupportedPlatform(runtime.GOOS), Parameters: map[string]interface{}{}}
+	if err := app.startJob(cobraCmd, ctx); err != nil {
+		t.Fatalf("startJob() failed: %v", err)
+	}
+	if !strings.Contains(stderr.String(), "Started job 1") {
+		t.Errorf("Expected the job to be announced, got %q", stderr.String())
+	}
+
+	out, err := runJobsCommand(t)
+	if err != nil || !strings.Contains(out, "sleep 0.2; echo hello") || !strings.Contains(out, "STATUS") {
+		t.Errorf("Expected the job to be listed, got %q (%v)", out, err)
+	}
+
+	// Following the log waits for the job to finish
+	out, err = runJobsCommand(t, "logs", "--follow", "last")
+	if err != nil || out != "hello\n" {
+		t.Errorf("Expected the job's output, got %q (%v)", out, err)
+	}
+	if out, _ := runJobsCommand(t, "list"); !strings.Contains(out, jobs.StatusExited) {
+		t.Errorf("Expected the job to have exited, got %q", out)
+	}
+	if _, err := runJobsCommand(t, "stop", "1"); err == nil || !strings.Contains(err.Error(), "no longer running") {
+		t.Errorf("Expected stopping a finished job to fail, got %v", err)
+	}
+	if _, err := runJobsCommand(t, "logs", "7"); err == nil || !strings.Contains(err.Error(), "no job 7") {
+		t.Errorf("Expected an unknown job to be reported, got %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/engine"
+	"github.com/danballance/goldfish/internal/lint"
+)
+
+// newLintCommand creates the built-in "lint" command
+// It checks templates for unknown and unused parameters and commands for
+// platforms without a template, so mistakes are caught in review rather
+// than when a command runs. Errors fail the command; with --strict,
+// warnings do too.
+func (app *GoldfishApp) newLintCommand() *cobra.Command {
+	var strict bool
+
+	lintCmd := &cobra.Command{
+		Use:     "lint [file]",
+		Short:   "Check command templates for unknown and unused parameters",
+		Example: "  goldfish lint\n  goldfish lint --strict commands.yml",
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := app.config
+			if len(args) == 1 {
+				loaded, err := config.NewLoader(args[0]).Load()
+				if err != nil {
+					return err
+				}
+				cfg = loaded
+				// The file's own fallbacks decide which platforms it covers
+				engine.SetFallbackPlatforms(cfg.FallbackPlatforms)
+			}
+
+			errors, warnings := 0, 0
+			for _, finding := range lint.Lint(cfg) {
+				fmt.Fprintln(cmd.OutOrStdout(), finding)
+				if finding.Severity == lint.Error {
+					errors++
+				} else {
+					warnings++
+				}
+			}
+
+			if errors == 0 && warnings == 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), "%d commands checked, no problems found\n", len(cfg.Commands))
+				return nil
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%d commands checked: %d errors, %d warnings\n", len(cfg.Commands), errors, warnings)
+			if errors > 0 || strict {
+				return fmt.Errorf("lint found %d errors and %d warnings", errors, warnings)
+			}
+			return nil
+		},
+	}
+
+	lintCmd.Flags().BoolVar(&strict, "strict", false, "fail on warnings as well as errors")
+
+	return lintCmd
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// TestLintCommand tests linting the loaded configuration
+func TestLintCommand(t *testing.T) {
+	testCases := []struct {
+		name     string
+		params   []config.Parameter
+		args     []string
+		fails    bool
+		expected string
+	}{
+		{"clean", nil, nil, false, "2 commands checked, no problems found"},
+		{"warning", []config.Parameter{{Name: "unused", Type: "string"}}, nil, false, "parameter 'unused' is not used"},
+		{"strict", []config.Parameter{{Name: "unused", Type: "string"}}, []string{"--strict"}, true, "0 errors, 1 warnings"},
+	}
+	for _, tc := range testCases {
+		app := newLazyTestApp(nil)
+		app.config.Commands[0].Parameters = tc.params
+
+		cmd := app.newLintCommand()
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs(tc.args)
+		err := cmd.Execute()
+		if (err != nil) != tc.fails {
+			t.Errorf("%s: expected failure %v, got %v", tc.name, tc.fails, err)
+		}
+		if !strings.Contains(out.String(), tc.expected) {
+			t.Errorf("%s: expected output to contain %q, got:\n%s", tc.name, tc.expected, out.String())
+		}
+	}
+}
+
+// TestLintCommand_File tests linting a configuration file
+func TestLintCommand_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "commands.yml")
+	content := `commands:
+  - name: "list"
+    description: "List files"
+    base_command: "ls"
+    params:
+      - name: "path"
+        type: "string"
+    platforms:
+      linux:
+        template: "ls {{.params.pth}}"
+      darwin:
+        template: "ls {{.params.path}}"
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	app := newLazyTestApp(nil)
+	cmd := app.newLintCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{path})
+	if err := cmd.Execute(); err == nil {
+		t.Errorf("Expected lint to fail on an unknown parameter")
+	}
+	for _, expected := range []string{
+		"list (platforms.linux.template): error: refers to unknown parameter 'pth'",
+		"list (platforms): warning: no template for windows",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, out.String())
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/danballance/goldfish/internal/engine"
+)
+
+// newListCommand creates the built-in "list" command
+// It lists the commands available on the platform, as help does, and can
+// narrow them to those with given tags.
+func (app *GoldfishApp) newListCommand() *cobra.Command {
+	var tags []string
+
+	listCmd := &cobra.Command{
+		Use:     "list",
+		Short:   "List the configured commands, optionally only those with given tags",
+		Example: "  goldfish list\n  goldfish list --tag network\n  goldfish list --tag files --tag destructive",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			currentPlatform := app.currentPlatform()
+			writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+			listed := 0
+			for i := range app.config.Commands {
+				command := &app.config.Commands[i]
+				if !command.Listed() || !engine.Supports(command, currentPlatform) || !command.HasTags(tags...) {
+					continue
+				}
+				if listed == 0 {
+					fmt.Fprintln(writer, "COMMAND\tTAGS\tDESCRIPTION")
+				}
+				fmt.Fprintf(writer, "%s\t%s\t%s\n", command.Name, strings.Join(command.Tags, ","), command.Description)
+				listed++
+			}
+			if listed == 0 {
+				if len(tags) > 0 {
+					fmt.Fprintf(cmd.OutOrStdout(), "No commands tagged %s\n", strings.Join(tags, " and "))
+					return nil
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), "No commands defined")
+				return nil
+			}
+			return writer.Flush()
+		},
+	}
+	listCmd.Flags().StringSliceVar(&tags, "tag", nil, "only list commands with this tag (repeatable; all must match)")
+	return listCmd
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestListCommand tests listing commands, filtered by tag
+func TestListCommand(t *testing.T) {
+	run := func(args ...string) string {
+		t.Helper()
+		app := newLazyTestApp(nil)
+		app.config.Commands[0].Tags = []string{"files", "destructive"}
+		app.config.Commands[0].Description = "Edit files"
+		app.config.Commands[1].Tags = []string{"network"}
+		app.rootCmd.AddCommand(app.newListCommand())
+		var out bytes.Buffer
+		app.rootCmd.SetOut(&out)
+		app.rootCmd.SetArgs(args)
+		if err := app.rootCmd.Execute(); err != nil {
+			t.Fatalf("%v failed: %v", args, err)
+		}
+		return out.String()
+	}
+
+	out := run("list")
+	if !strings.Contains(out, "first") || !strings.Contains(out, "files,destructive") || !strings.Contains(out, "Edit files") || !strings.Contains(out, "second") {
+		t.Errorf("Expected every command with its tags, got:\n%s", out)
+	}
+	out = run("list", "--tag", "network")
+	if strings.Contains(out, "first") || !strings.Contains(out, "second") {
+		t.Errorf("Expected only the network command, got:\n%s", out)
+	}
+	// Every tag given must match
+	out = run("list", "--tag", "files", "--tag", "destructive")
+	if !strings.Contains(out, "first") || strings.Contains(out, "second") {
+		t.Errorf("Expected only the destructive files command, got:\n%s", out)
+	}
+	if out = run("list", "--tag", "files,network"); !strings.Contains(out, "No commands tagged files and network") {
+		t.Errorf("Expected no commands, got:\n%s", out)
+	}
+}
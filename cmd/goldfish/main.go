@@ -3,23 +3,34 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"runtime"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/danballance/goldfish/internal/cli"
 	"github.com/danballance/goldfish/internal/config"
 	"github.com/danballance/goldfish/internal/engine"
+	"github.com/danballance/goldfish/internal/output"
 	"github.com/danballance/goldfish/internal/platform"
+	"github.com/danballance/goldfish/internal/secrets"
+	"github.com/danballance/goldfish/internal/settings"
+	"github.com/danballance/goldfish/internal/trace"
+	"golang.org/x/term"
 )
 
-const (
-	// Version of the goldfish CLI
-	Version = "0.1.0"
-	// DefaultTimeout for command execution
-	DefaultTimeout = 30 * time.Second
-)
+// Version of the goldfish CLI
+// Release builds set it to the tag's version with -ldflags "-X main.Version=..."
+// so that self-update can tell whether a newer release exists.
+var Version = "0.1.0"
+
+// DefaultTimeout for command execution
+const DefaultTimeout = 30 * time.Second
 
 // GoldfishApp holds the application state
 type GoldfishApp struct {
@@ -27,36 +38,172 @@ type GoldfishApp struct {
 	engine           *engine.Engine
 	platformDetector *platform.Detector
 	rootCmd          *cobra.Command
+	// verbose is set by the persistent --verbose flag
+	verbose bool
+	// logFile is set by the persistent --log-file flag
+	logFile string
+	// platformOverride is set by the persistent --platform flag: templates for
+	// that platform are used instead of those for the one goldfish runs on
+	platformOverride string
+	// dryRun is set by the persistent --dry-run flag
+	dryRun bool
+	// detach is set by the persistent --detach flag: the command runs in the
+	// background as a job (see jobs_cmd.go)
+	detach bool
+	// pipe is set by the persistent --pipe flag: piped input is read before
+	// the command runs, for its templates (see pipe.go)
+	pipe bool
+	// force is set by the persistent --force flag
+	force bool
+	// winrmHost is set by the persistent --winrm flag: commands run on that
+	// Windows host instead of locally (see winrm.go)
+	winrmHost string
+	// winrmInsecure is set by the persistent --winrm-insecure flag
+	winrmInsecure bool
+	// outputFormat is set by the persistent --output flag (see output.go)
+	outputFormat string
+	// quiet, silent and noStderr are set by the persistent --quiet, --silent
+	// and --no-stderr flags, which discard the command's output streams
+	quiet    bool
+	silent   bool
+	noStderr bool
+	// teePath is set by the persistent --tee flag: a file the command's
+	// output is copied to with timestamps
+	teePath string
+	// progress is set by the persistent --progress flag
+	progress bool
+	// sandbox is set by the persistent --sandbox flag: commands run under
+	// their sandbox policy
+	sandbox bool
+	// noCache is set by the persistent --no-cache flag: commands with a
+	// cache: policy run even when their result is cached
+	noCache bool
+	// notify is set by the persistent --notify flag: a desktop notification
+	// is shown when the command finishes (see notify.go)
+	notify bool
+	// settings are the user's preferences from settings.yml
+	settings *settings.Settings
+	// secrets is the store used by the secret commands (the OS keyring by default)
+	secrets secrets.Store
+	// historyPath is the file the commands run are recorded in (see
+	// history_cmd.go); empty when the history setting is off
+	historyPath string
+	// tracer times the phases of the run with --trace, and stopProfile ends
+	// the --cpu-profile (see trace.go)
+	tracer      *trace.Recorder
+	stopProfile func() error
+	// stopTelemetry sends the spans not yet exported when
+	// $GOLDFISH_OTEL_ENDPOINT is set (see telemetry.go)
+	stopTelemetry func()
+	// args are the command line arguments (without the program name)
+	// They are inspected before building commands so that only the invoked
+	// command needs to be registered; nil means register everything
+	args []string
 }
 
 // main is the entry point for the goldfish CLI application
 func main() {
 	app := &GoldfishApp{
-		engine:           engine.NewEngine(DefaultTimeout),
 		platformDetector: platform.NewDetector(),
+		args:             os.Args[1:],
+	}
+
+	// The user's settings decide how errors are printed and commands run; a
+	// broken settings file is reported and the defaults used instead
+	userSettings, settingsErr := settings.Load()
+	if settingsErr != nil {
+		userSettings = &settings.Settings{}
+	}
+	app.settings = userSettings
+	app.historyPath = historyPath(userSettings)
+	app.engine = engine.NewEngine(app.timeout())
+	app.engine.SetShellProgram(userSettings.Shell)
+
+	// Errors and warnings are printed for people, in color on terminals.
+	// Configuration is loaded before Cobra parses the flags, so --no-color
+	// is read from the raw arguments
+	printer := newPrinter(app.args, userSettings)
+	config.Warnings = printer.WarningWriter()
+	if settingsErr != nil {
+		printer.Warning(fmt.Sprintf("ignoring %v\n", settingsErr))
 	}
 
+	// Time the phases of the run from here on with --trace
+	if err := app.startTrace(); err != nil {
+		printer.Error(err)
+		os.Exit(1)
+	}
+	// Trace command executions to OpenTelemetry when a collector is configured
+	app.startTelemetry()
+
 	// Initialize the application
 	if err := app.initialize(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		app.finishTrace()
+		printer.Error(err)
 		os.Exit(1)
 	}
 
 	// Execute the root command
-	if err := app.rootCmd.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+	cmd, err := app.rootCmd.ExecuteC()
+	app.finishTrace()
+	if err != nil {
+		printer.Error(app.addSuggestions(cmd, err))
+		os.Exit(exitCodeFor(err))
 	}
 }
 
+// noColorRequested reports whether --no-color is among the arguments
+// Arguments after "--" belong to the command and are not looked at.
+func noColorRequested(args []string) bool {
+	for _, arg := range args {
+		switch arg {
+		case "--":
+			return false
+		case "--no-color", "--no-color=true":
+			return true
+		}
+	}
+	return false
+}
+
+// exitCodeFor returns the process exit code for an error
+// Errors that carry their own exit code (such as an unsupported platform)
+// keep it so that scripts can detect them; everything else exits with 1
+func exitCodeFor(err error) int {
+	var coded interface{ ExitCode() int }
+	if errors.As(err, &coded) {
+		return coded.ExitCode()
+	}
+	return 1
+}
+
+// commandContext returns the context a Cobra command was run with, which
+// cancels goldfish's own work; commands made without Execute have none
+func commandContext(cobraCmd *cobra.Command) context.Context {
+	if ctx := cobraCmd.Context(); ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
+
 // initialize sets up the CLI application
 func (app *GoldfishApp) initialize() error {
+	// Discovering plugins runs them, which restricted mode must not do
+	if app.restrictedRequested() {
+		config.DiscoverPlugins = false
+	}
+
 	// Load configuration with embedded defaults and optional runtime override
+	stopTimer := app.tracer.Start(trace.PhaseConfig)
 	cfg, err := config.LoadDefaultWithEmbedded()
+	stopTimer()
 	if err != nil {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
 	app.config = cfg
+	app.engine.SetWasmModules(cfg.Wasm)
+	app.engine.SetCommands(cfg.Commands)
+	app.engine.SetGlobals(cfg.Vars)
 
 	// Create root command
 	app.rootCmd = &cobra.Command{
@@ -65,13 +212,133 @@ func (app *GoldfishApp) initialize() error {
 		Long:    "Goldfish provides unified command interfaces that work consistently across different operating systems.",
 		Version: Version,
 		Example: "  goldfish replace --in-place 's/foo/bar/g' file.txt\n  goldfish help replace",
+		// Without a command: a fuzzy finder on terminals, help otherwise
+		RunE: app.runRoot,
 	}
 
 	// Add version flag
 	app.rootCmd.SetVersionTemplate("goldfish version {{.Version}}\n")
 
+	// Errors are printed once by main, with our own "did you mean" suggestions
+	app.rootCmd.SilenceErrors = true
+	app.rootCmd.DisableSuggestions = true
+
+	// Add global flags available to every command
+	app.rootCmd.PersistentFlags().BoolVarP(&app.verbose, "verbose", "v", false,
+		"log platform, template, rendered command, environment and timing to stderr")
+	app.rootCmd.PersistentFlags().StringVar(&app.logFile, "log-file", "",
+		"append a JSON record of each execution to this file (default $"+engine.LogEnvVar+")")
+	app.rootCmd.PersistentFlags().StringVar(&app.platformOverride, "platform", "",
+		"use the templates for this platform (linux, darwin or windows) instead of the current one")
+	app.rootCmd.PersistentFlags().BoolVar(&app.dryRun, "dry-run", false,
+		"print the rendered command line instead of running it")
+	app.rootCmd.PersistentFlags().BoolVar(&app.detach, "detach", false,
+		"run the command in the background, with its output in a log (see goldfish jobs)")
+	app.rootCmd.PersistentFlags().BoolVar(&app.pipe, "pipe", false,
+		"read piped standard input for templates, as .stdin and .stdin_file")
+	app.rootCmd.PersistentFlags().BoolVar(&app.force, "force", false,
+		"run commands even when --platform names another platform")
+	app.rootCmd.PersistentFlags().StringSlice("fallback-platform", nil,
+		"use this platform's templates for commands without one for the current platform (repeatable)")
+	app.rootCmd.PersistentFlags().StringVar(&app.winrmHost, "winrm", "",
+		"run commands on this Windows host over WinRM, e.g. admin@server01 (password from $"+WinRMPasswordEnvVar+")")
+	app.rootCmd.PersistentFlags().BoolVar(&app.winrmInsecure, "winrm-insecure", false,
+		"do not verify the TLS certificate of the --winrm host")
+	app.rootCmd.PersistentFlags().StringVar(&app.outputFormat, "output", OutputText,
+		"text passes command output through; json captures it and prints a JSON result")
+	app.rootCmd.PersistentFlags().BoolVar(&app.quiet, "quiet", false,
+		"discard the command's standard output")
+	app.rootCmd.PersistentFlags().BoolVar(&app.noStderr, "no-stderr", false,
+		"discard the command's standard error, including goldfish's warnings")
+	app.rootCmd.PersistentFlags().BoolVar(&app.silent, "silent", false,
+		"discard all output except goldfish's own errors (--quiet and --no-stderr)")
+	app.rootCmd.PersistentFlags().StringVar(&app.teePath, "tee", "",
+		"also append the command's output to this file, with timestamps")
+	app.rootCmd.PersistentFlags().BoolVar(&app.progress, "progress", false,
+		"show a spinner with the elapsed time while the command prints nothing")
+	// Read by initialize before the flags are parsed (see readProfileFlag)
+	app.rootCmd.PersistentFlags().String("profile", "",
+		"use this profile's vars and environment (default $"+ProfileEnvVar+")")
+	// Read by main before the flags are parsed (see startTrace)
+	app.rootCmd.PersistentFlags().Bool("trace", false,
+		"print how long loading, generating commands, parsing, rendering and running took to stderr")
+	// Not --profile, which already selects a profile of vars (see readProfileFlag)
+	app.rootCmd.PersistentFlags().String("cpu-profile", "",
+		"write a CPU profile of the run to this file, for go tool pprof")
+	app.rootCmd.PersistentFlags().BoolVar(&app.sandbox, "sandbox", false,
+		"run the command in a sandbox: read-only and offline unless its sandbox policy allows more")
+	app.rootCmd.PersistentFlags().BoolVar(&app.noCache, "no-cache", false,
+		"run the command even when its cache: policy has a fresh result, and cache the new one")
+	app.rootCmd.PersistentFlags().BoolVar(&app.notify, "notify", false,
+		"show a desktop notification when the command finishes, and post to the notify_webhook setting")
+	// Read by initialize before the flags are parsed (see readRestrictedFlag)
+	app.rootCmd.PersistentFlags().Bool("restricted", false,
+		"only run programs in allowed_base_commands (also set by $"+engine.RestrictedEnvVar+"=1)")
+	// Read by main before the flags are parsed (see noColorRequested)
+	app.rootCmd.PersistentFlags().Bool("no-color", false,
+		"do not color errors and warnings (also set by $"+output.NoColorEnvVar+")")
+	app.rootCmd.MarkFlagsMutuallyExclusive("silent", "verbose")
+	// A detached command's output goes to its log, and nothing waits for it
+	app.rootCmd.MarkFlagsMutuallyExclusive("detach", "output")
+	app.rootCmd.MarkFlagsMutuallyExclusive("detach", "tee")
+	app.rootCmd.MarkFlagsMutuallyExclusive("detach", "progress")
+	// The piped input's file is removed when goldfish exits
+	app.rootCmd.MarkFlagsMutuallyExclusive("detach", "pipe")
+	// Nothing waits for a detached command to finish, so nothing can announce it
+	app.rootCmd.MarkFlagsMutuallyExclusive("detach", "notify")
+
+	// Aliases are expanded before anything looks at which command was invoked
+	if app.expandAlias() {
+		app.rootCmd.SetArgs(app.args)
+	}
+
+	// A parameter flag named like a global flag would take its value (see checkParameterFlags)
+	if err := app.checkParameterFlags(); err != nil {
+		return err
+	}
+
+	// Commands are built for the platform before Cobra parses the flags, so
+	// --platform, --fallback-platform and --winrm are read from the raw arguments
+	if err := app.readPlatformFlags(); err != nil {
+		return err
+	}
+	if err := app.readWinRMFlag(); err != nil {
+		return err
+	}
+	app.readRestrictedFlag()
+	if err := app.readProfileFlag(); err != nil {
+		return err
+	}
+
+	// Add built-in commands
+	app.rootCmd.AddCommand(app.newConfigCommand())
+	app.rootCmd.AddCommand(app.newServeCommand())
+	app.rootCmd.AddCommand(app.newSecretCommand())
+	app.rootCmd.AddCommand(app.newBatchCommand())
+	app.rootCmd.AddCommand(app.newWorkflowCommand())
+	app.rootCmd.AddCommand(app.newRunCommand())
+	app.rootCmd.AddCommand(app.newUICommand())
+	app.rootCmd.AddCommand(app.newDocsCommand())
+	app.rootCmd.AddCommand(app.newPackCommand())
+	app.rootCmd.AddCommand(app.newSchemaCommand())
+	app.rootCmd.AddCommand(app.newLintCommand())
+	app.rootCmd.AddCommand(app.newTestCommand())
+	app.rootCmd.AddCommand(app.newMCPCommand())
+	app.rootCmd.AddCommand(app.newSelfUpdateCommand())
+	app.rootCmd.AddCommand(app.newAliasCommand())
+	app.rootCmd.AddCommand(app.newListCommand())
+	app.rootCmd.AddCommand(app.newSearchCommand())
+	app.rootCmd.AddCommand(app.newHistoryCommand())
+	app.rootCmd.AddCommand(app.newRerunCommand())
+	app.rootCmd.AddCommand(app.newExplainCommand())
+	app.rootCmd.AddCommand(app.newJobsCommand())
+	app.rootCmd.AddCommand(app.newScheduleCommand())
+
 	// Generate commands from configuration
-	if err := app.generateCommands(); err != nil {
+	stopTimer = app.tracer.Start(trace.PhaseGenerate)
+	err = app.generateCommands()
+	stopTimer()
+	if err != nil {
 		return fmt.Errorf("failed to generate commands: %w", err)
 	}
 
@@ -81,188 +348,462 @@ func (app *GoldfishApp) initialize() error {
 // generateCommands creates Cobra commands from the YAML configuration
 func (app *GoldfishApp) generateCommands() error {
 	// Get current platform
-	currentPlatform, err := app.platformDetector.Current()
-	if err != nil {
-		return fmt.Errorf("failed to detect platform: %w", err)
-	}
+	currentPlatform := app.currentPlatform()
+
+	// Commands whose enabled_if condition does not hold are dropped before
+	// anything is registered, so help, completion, aliases and the servers
+	// started later never see them
+	app.dropDisabledCommands(currentPlatform)
+
+	// When a specific command was invoked, only that one needs building
+	invoked := app.invokedCommandName()
+	// Otherwise help and completion list every command, but only the one
+	// they are asked about (if any) needs its flags and examples
+	target := app.helpTargetName()
 
 	// Generate a command for each configured command
 	for _, cmdConfig := range app.config.Commands {
 		// Create a copy of cmdConfig for the closure
 		cmd := cmdConfig
 
-		// Check if command is supported on current platform
-		if _, exists := cmd.Platforms[currentPlatform.String()]; !exists {
-			// Skip commands not supported on this platform
+		// Skip commands that cannot be the one being run
+		if invoked != "" && cmd.Name != invoked {
 			continue
 		}
 
-		// Create the Cobra command
-		cobraCmd := &cobra.Command{
-			Use:   cmd.Name,
-			Short: cmd.Description,
-			Long:  fmt.Sprintf("%s\n\nThis command provides cross-platform compatibility for '%s'.", cmd.Description, cmd.BaseCommand),
-			RunE: func(cobraCmd *cobra.Command, args []string) error {
-				return app.executeCommand(&cmd, cobraCmd, args, currentPlatform)
-			},
+		// Register a cheap stub for commands that are only listed
+		if invoked == "" && cmd.Name != target {
+			app.addConfiguredCommand(cli.NewStub(&cmd, currentPlatform, app.runStub), &cmd)
+			continue
 		}
 
-		// Add alias if specified
-		if cmd.Alias != "" {
-			cobraCmd.Aliases = []string{cmd.Alias}
-		}
+		// Commands not supported on this platform get a hidden placeholder that
+		// explains where they are supported
+		app.addConfiguredCommand(app.newCommand(&cmd, currentPlatform), &cmd)
+	}
 
-		// Add flags for each parameter
-		for _, param := range cmd.Parameters {
-			app.addParameterFlag(cobraCmd, &param)
+	// Aliases are listed alongside the commands, unless a command or
+	// built-in already has the name
+	if invoked == "" {
+		for _, alias := range app.config.Aliases {
+			if cmd, _, err := app.rootCmd.Find([]string{alias.Name}); err == nil && cmd != app.rootCmd {
+				continue
+			}
+			app.rootCmd.AddCommand(app.newAliasStub(alias))
 		}
+	}
 
-		// Add usage examples
-		if examples := app.generateExamples(&cmd); examples != "" {
-			cobraCmd.Example = examples
+	return nil
+}
+
+// dropDisabledCommands removes the commands whose enabled_if condition does
+// not hold from the configuration; a condition that fails to render drops
+// its command with a warning
+func (app *GoldfishApp) dropDisabledCommands(currentPlatform platform.SupportedPlatform) {
+	enabled := make([]config.Command, 0, len(app.config.Commands))
+	dropped := false
+	for _, cmd := range app.config.Commands {
+		ok, err := app.engine.Enabled(&cmd, currentPlatform.String())
+		if err != nil {
+			fmt.Fprintf(config.Warnings, "Warning: skipping %v\n", err)
 		}
+		if ok {
+			enabled = append(enabled, cmd)
+		} else {
+			dropped = true
+		}
+	}
+	if !dropped {
+		return
+	}
+	// The loaded configuration may be shared, so it is copied rather than changed
+	filtered := *app.config
+	filtered.Commands = enabled
+	app.config = &filtered
+}
 
-		// Add the command to the root
-		app.rootCmd.AddCommand(cobraCmd)
+// newCommand creates the full Cobra command for a configured command
+func (app *GoldfishApp) newCommand(cmd *config.Command, currentPlatform platform.SupportedPlatform) *cobra.Command {
+	return cli.NewCommand(cmd, currentPlatform, "goldfish", func(cmd *config.Command, cobraCmd *cobra.Command, args []string) error {
+		return app.executeCommand(cmd, cobraCmd, args, currentPlatform)
+	})
+}
+
+// materialize replaces a stub registered by generateCommands with the full command
+func (app *GoldfishApp) materialize(stub *cobra.Command) *cobra.Command {
+	cmd, found := app.config.FindCommand(stub.Name())
+	if !found {
+		return stub
 	}
+	full := app.newCommand(cmd, app.currentPlatform())
+	app.rootCmd.RemoveCommand(stub)
+	app.addConfiguredCommand(full, cmd)
+	return full
+}
 
-	return nil
+// materializeAll replaces every stub with its full command
+// Used when the whole tree is needed with flags, e.g. to generate man pages
+func (app *GoldfishApp) materializeAll() {
+	for _, cobraCmd := range app.rootCmd.Commands() {
+		if cli.IsStub(cobraCmd) {
+			app.materialize(cobraCmd)
+		}
+	}
 }
 
-// addParameterFlag adds a flag to the Cobra command based on parameter definition
-func (app *GoldfishApp) addParameterFlag(cobraCmd *cobra.Command, param *config.Parameter) {
-	flagName := param.Name
-	if param.Flag != "" {
-		// Remove leading dashes from flag specification
-		flagName = strings.TrimLeft(param.Flag, "-")
+// runStub runs a command whose stub was registered instead of the full command
+// It should not happen, since the invoked command is always built in full, but
+// if it does the stub is replaced and the arguments are parsed again
+func (app *GoldfishApp) runStub(stub *cobra.Command, args []string) error {
+	app.materialize(stub)
+	return app.rootCmd.Execute()
+}
+
+// currentPlatform returns the platform whose templates should be used
+// That is the one named by --platform, if given, or the one goldfish runs on.
+// On an unsupported OS we carry on with the raw OS name: every command then
+// lacks a template for it and reports where it is supported instead
+func (app *GoldfishApp) currentPlatform() platform.SupportedPlatform {
+	if app.platformOverride != "" {
+		return platform.SupportedPlatform(app.platformOverride)
 	}
+	currentPlatform, err := app.platformDetector.Current()
+	if err != nil {
+		return platform.SupportedPlatform(runtime.GOOS)
+	}
+	return currentPlatform
+}
 
-	description := param.Description
-	if description == "" {
-		description = fmt.Sprintf("%s parameter", param.Name)
+// readPlatformFlags reads --platform and --fallback-platform from the raw
+// arguments, checks them and sets up fallback platforms for the engine
+func (app *GoldfishApp) readPlatformFlags() error {
+	if values := app.rawFlagValues("platform"); len(values) > 0 {
+		app.platformOverride = values[len(values)-1]
+	}
+	if app.platformOverride != "" && !app.platformDetector.IsSupported(app.platformOverride) {
+		return app.unknownPlatformError("--platform", app.platformOverride)
 	}
 
-	// Add the appropriate flag type
-	switch param.Type {
-	case "string":
-		defaultValue := ""
-		if param.Default != nil {
-			if str, ok := param.Default.(string); ok {
-				defaultValue = str
+	// Fallbacks given on the command line come before configured ones
+	var fallbacks []string
+	for _, value := range app.rawFlagValues("fallback-platform") {
+		for _, name := range strings.Split(value, ",") {
+			if !app.platformDetector.IsSupported(name) {
+				return app.unknownPlatformError("--fallback-platform", name)
 			}
+			fallbacks = append(fallbacks, name)
 		}
-		cobraCmd.Flags().String(flagName, defaultValue, description)
-		if param.Required {
-			if err := cobraCmd.MarkFlagRequired(flagName); err != nil {
-				// This should rarely fail, but we handle it gracefully
-				fmt.Fprintf(os.Stderr, "Warning: failed to mark flag %s as required: %v\n", flagName, err)
-			}
+	}
+	byPlatform := make(map[string][]string, len(app.config.FallbackPlatforms)+1)
+	for name, others := range app.config.FallbackPlatforms {
+		byPlatform[name] = others
+	}
+	if len(fallbacks) > 0 {
+		current := app.currentPlatform().String()
+		byPlatform[current] = append(fallbacks, byPlatform[current]...)
+	}
+	engine.SetFallbackPlatforms(byPlatform)
+	return nil
+}
+
+// rawFlagValues returns every value given for a flag in the raw arguments
+// The flag may appear anywhere before "--", as "--name value" or "--name=value",
+// e.g. "goldfish find --platform darwin"
+func (app *GoldfishApp) rawFlagValues(name string) []string {
+	var values []string
+	for i := 0; i < len(app.args); i++ {
+		arg := app.args[i]
+		if arg == "--" {
+			break
 		}
-	case "bool":
-		defaultValue := false
-		if param.Default != nil {
-			if b, ok := param.Default.(bool); ok {
-				defaultValue = b
-			}
+		if value, found := strings.CutPrefix(arg, "--"+name+"="); found {
+			values = append(values, value)
+		} else if arg == "--"+name && i+1 < len(app.args) {
+			values = append(values, app.args[i+1])
+			i++
 		}
-		cobraCmd.Flags().Bool(flagName, defaultValue, description)
-	case "int":
-		defaultValue := 0
-		if param.Default != nil {
-			if i, ok := param.Default.(int); ok {
-				defaultValue = i
-			}
+	}
+	return values
+}
+
+// unknownPlatformError reports a flag naming a platform goldfish does not know
+func (app *GoldfishApp) unknownPlatformError(flag, name string) error {
+	return fmt.Errorf("unknown platform '%s' for %s (supported: %s)",
+		name, flag, strings.Join(platformNames(app.platformDetector.GetSupportedPlatforms()), ", "))
+}
+
+// platformNames returns the names of platforms
+func platformNames(platforms []platform.SupportedPlatform) []string {
+	names := make([]string, len(platforms))
+	for i, p := range platforms {
+		names[i] = p.String()
+	}
+	return names
+}
+
+// checkPlatform is called before running commands with the current platform's templates
+// A command line written for macOS rarely does what it should on Linux, so
+// when --platform names another platform, commands are only run with --force
+// (or --winrm, which runs them on a Windows host).
+// Configured commands print instead of running with --dry-run; anything else
+// that gets here (batch, workflow run, ...) cannot, so it refuses to go on.
+// name is the goldfish command about to run commands, for the error messages.
+func (app *GoldfishApp) checkPlatform(name string) error {
+	if app.dryRun {
+		return fmt.Errorf("%s does not support --dry-run", name)
+	}
+	if app.platformOverride == "" || app.force || app.winrmHost != "" || app.platformDetector.IsCurrent(platform.SupportedPlatform(app.platformOverride)) {
+		return nil
+	}
+	return fmt.Errorf("%s would run %s commands on %s; use --dry-run to preview them or --force to run them anyway",
+		name, app.platformOverride, runtime.GOOS)
+}
+
+// invokedCommandName returns the name of the configured command being run
+// It inspects the raw arguments before Cobra parses them. An empty result means
+// every command must be registered: no command was given, or help/completion
+// was requested (both need the full command tree), or the name is unknown.
+func (app *GoldfishApp) invokedCommandName() string {
+	args := app.positionalArgs()
+	if len(args) == 0 {
+		return ""
+	}
+
+	// Help and shell completion list every command
+	switch args[0] {
+	case "help", "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+		return ""
+	}
+
+	if cmd, found := app.config.FindCommand(args[0]); found {
+		return cmd.Name
+	}
+	return ""
+}
+
+// helpTargetName returns the configured command that help or completion is about
+// For "goldfish help find" or "goldfish __complete find --" that command needs
+// its flags; every other command is only listed
+func (app *GoldfishApp) helpTargetName() string {
+	args := app.positionalArgs()
+	if len(args) < 2 {
+		return ""
+	}
+	switch args[0] {
+	case "help", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+		if cmd, found := app.config.FindCommand(args[1]); found {
+			return cmd.Name
 		}
-		cobraCmd.Flags().Int(flagName, defaultValue, description)
-	case "float":
-		defaultValue := 0.0
-		if param.Default != nil {
-			if f, ok := param.Default.(float64); ok {
-				defaultValue = f
-			}
+	}
+	return ""
+}
+
+// positionalArgs returns the raw arguments that are not root-level flags
+// Values of root flags (e.g. the path in "--log-file path") are skipped too,
+// so they are not mistaken for command names
+func (app *GoldfishApp) positionalArgs() []string {
+	if i := app.positionalIndex(); i >= 0 {
+		return app.args[i:]
+	}
+	return nil
+}
+
+// positionalIndex returns the index in the raw arguments of the first one
+// that is not a root-level flag or its value, or -1 if there is none
+func (app *GoldfishApp) positionalIndex() int {
+	for i := 0; i < len(app.args); i++ {
+		arg := app.args[i]
+		if !strings.HasPrefix(arg, "-") {
+			return i
+		}
+		if app.takesValue(arg) && i+1 < len(app.args) {
+			i++
+		}
+	}
+	return -1
+}
+
+// takesValue reports whether a root-level flag argument consumes the next argument
+func (app *GoldfishApp) takesValue(arg string) bool {
+	if app.rootCmd == nil || strings.Contains(arg, "=") {
+		return false
+	}
+	flags := app.rootCmd.PersistentFlags()
+	var flag *pflag.Flag
+	if strings.HasPrefix(arg, "--") {
+		flag = flags.Lookup(strings.TrimPrefix(arg, "--"))
+	} else if len(arg) == 2 {
+		flag = flags.ShorthandLookup(arg[1:])
+	}
+	return flag != nil && flag.NoOptDefVal == ""
+}
+
+// checkParameterFlags rejects an invoked command with a parameter flag that
+// has the name of a global flag
+// Cobra gives such a flag to the command, so the global flag could not be
+// used with it, while the global flags read from the raw arguments (--profile,
+// --platform, ...) would take the parameter's value as their own.
+func (app *GoldfishApp) checkParameterFlags() error {
+	cmd, found := app.config.FindCommand(app.invokedCommandName())
+	if !found {
+		return nil
+	}
+	for i := range cmd.Parameters {
+		name := cli.FlagName(&cmd.Parameters[i])
+		if app.rootCmd.PersistentFlags().Lookup(name) != nil {
+			return fmt.Errorf("command '%s': parameter '%s' uses the global flag --%s; give it another one with flag:",
+				cmd.Name, cmd.Parameters[i].Name, name)
 		}
-		cobraCmd.Flags().Float64(flagName, defaultValue, description)
 	}
+	return nil
 }
 
 // executeCommand handles the execution of a goldfish command
 func (app *GoldfishApp) executeCommand(cmd *config.Command, cobraCmd *cobra.Command, args []string, currentPlatform platform.SupportedPlatform) error {
 	// Parse flags
-	flags := make(map[string]interface{})
-	for _, param := range cmd.Parameters {
-		flagName := param.Name
-		if param.Flag != "" {
-			flagName = strings.TrimLeft(param.Flag, "-")
-		}
+	stopTimer := app.tracer.Start(trace.PhaseParse)
+	flags := cli.FlagValues(cmd, cobraCmd)
 
-		switch param.Type {
-		case "string":
-			if val, err := cobraCmd.Flags().GetString(flagName); err == nil && val != "" {
-				flags["--"+flagName] = val
-			}
-		case "bool":
-			if val, err := cobraCmd.Flags().GetBool(flagName); err == nil && val {
-				flags["--"+flagName] = val
-			}
-		case "int":
-			if val, err := cobraCmd.Flags().GetInt(flagName); err == nil && cobraCmd.Flags().Changed(flagName) {
-				flags["--"+flagName] = val
-			}
-		case "float":
-			if val, err := cobraCmd.Flags().GetFloat64(flagName); err == nil && cobraCmd.Flags().Changed(flagName) {
-				flags["--"+flagName] = val
-			}
-		}
+	// Read piped input, which may fill a parameter (see pipe.go)
+	input, err := app.readInput(cmd, cobraCmd.InOrStdin(), flags)
+	if err != nil {
+		stopTimer()
+		return err
+	}
+	if input != nil {
+		defer input.Close()
+		app.engine.SetInput(input)
 	}
 
 	// Parse parameters from arguments and flags
 	params, err := app.engine.ParseParameters(cmd, args, flags)
+	stopTimer()
 	if err != nil {
 		return fmt.Errorf("failed to parse parameters: %w", err)
 	}
 
+	if err := app.checkOutputFormat(); err != nil {
+		return err
+	}
+
 	// Create execution context
 	ctx := &engine.ExecutionContext{
 		Command:    cmd,
 		Platform:   currentPlatform,
 		Parameters: params,
-		Timeout:    DefaultTimeout,
+		Timeout:    app.timeout(),
+		Quiet:      app.quiet || app.silent,
+		NoStderr:   app.noStderr || app.silent,
+		OutputLog:  app.teePath,
+		Progress:   app.progress,
+		Sandbox:    app.sandbox,
+		NoCache:    app.noCache,
 	}
 
-	// Execute the command
-	return app.engine.Execute(ctx)
-}
+	// With --dry-run the command line is printed instead of run
+	if app.dryRun {
+		rendered, err := app.engine.Preview(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cobraCmd.OutOrStdout(), rendered)
+		return nil
+	}
+	if err := app.checkPlatform(cmd.Name); err != nil {
+		return err
+	}
+	if err := app.confirmRun(cobraCmd, ctx); err != nil {
+		return err
+	}
 
-// generateExamples creates usage examples for a command
-func (app *GoldfishApp) generateExamples(cmd *config.Command) string {
-	examples := []string{}
-
-	// Basic example with command name
-	example := fmt.Sprintf("  goldfish %s", cmd.Name)
-	
-	// Add parameter examples
-	for _, param := range cmd.Parameters {
-		if param.Required {
-			switch param.Type {
-			case "string":
-				example += fmt.Sprintf(" <%s>", param.Name)
-			case "bool":
-				if param.Flag != "" {
-					example += fmt.Sprintf(" %s", param.Flag)
-				}
-			default:
-				example += fmt.Sprintf(" <%s>", param.Name)
-			}
+	// Apply the global logging flags
+	closeLog, err := app.configureEngine()
+	if err != nil {
+		return err
+	}
+	defer closeLog()
+
+	// With --detach the command is left running in the background
+	start := time.Now()
+	if app.detach {
+		if err := app.startJob(cobraCmd, ctx); err != nil {
+			app.recordHistory(ctx, start, nil, err)
+			return err
 		}
+		app.recordHistory(ctx, start, &engine.ExecutionResult{}, nil)
+		return nil
+	}
+
+	// The spinner for long silent commands is only drawn on a terminal
+	if !app.silent && !app.noStderr && term.IsTerminal(int(os.Stderr.Fd())) {
+		app.engine.SetProgressOutput(os.Stderr, 0)
+	}
+
+	// With --output json the output is captured and reported in a JSON envelope
+	if app.outputFormat == OutputJSON {
+		ctx.Capture = true
+		result, err := app.engine.Execute(commandContext(cobraCmd), ctx)
+		app.recordHistory(ctx, start, result, err)
+		app.notifyFinished(ctx, start, result, err)
+		// The error is in the envelope; it need not be printed again with usage
+		cobraCmd.SilenceUsage = true
+		return writeEnvelope(cobraCmd.OutOrStdout(), cmd.Name, result, err)
+	}
+
+	// Execute the command, recording it before a failure ends goldfish
+	result, err := app.engine.Execute(commandContext(cobraCmd), ctx)
+	app.recordHistory(ctx, start, result, err)
+	app.notifyFinished(ctx, start, result, err)
+	if err != nil {
+		return err
+	}
+	// A failing command ends goldfish right away, so the trace is printed and
+	// the piped input removed first
+	if result.ExitCode != 0 {
+		app.finishTrace()
+		input.Close()
+	}
+	return engine.ExitStatus(result.ExitCode)
+}
+
+// configureEngine applies the global --verbose, --log-file and --winrm flags
+// and $GOLDFISH_EXEC to the engine
+// The returned function closes the execution log and must be called when done
+func (app *GoldfishApp) configureEngine() (func(), error) {
+	// Log execution details to stderr when requested
+	if app.verbose {
+		app.engine.SetVerboseOutput(os.Stderr)
 	}
 
-	examples = append(examples, example)
+	// Run commands on a Windows host when one was given
+	if app.winrmHost != "" {
+		if err := app.configureWinRM(); err != nil {
+			return nil, err
+		}
+	}
 
-	// Add alias example if available
-	if cmd.Alias != "" {
-		aliasExample := strings.Replace(example, cmd.Name, cmd.Alias, 1)
-		examples = append(examples, aliasExample)
+	// Record commands instead of running them with GOLDFISH_EXEC=mock
+	closeTranscript, err := app.configureExec()
+	if err != nil {
+		return nil, err
 	}
 
-	return strings.Join(examples, "\n")
-}
\ No newline at end of file
+	// Append to the structured execution log when one is configured
+	logPath := app.logFile
+	if logPath == "" {
+		logPath = os.Getenv(engine.LogEnvVar)
+	}
+	if logPath == "" {
+		return closeTranscript, nil
+	}
+	logFile, err := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		closeTranscript()
+		return nil, fmt.Errorf("failed to open execution log: %w", err)
+	}
+	app.engine.SetExecutionLog(logFile)
+	return func() {
+		logFile.Close()
+		closeTranscript()
+	}, nil
+}
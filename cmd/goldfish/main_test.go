@@ -1,12 +1,19 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/danballance/goldfish/internal/cli"
 	"github.com/danballance/goldfish/internal/config"
 	"github.com/danballance/goldfish/internal/engine"
 	"github.com/danballance/goldfish/internal/platform"
@@ -84,9 +91,9 @@ commands:
 	if app.rootCmd == nil {
 		t.Error("Root command not created")
 	}
-	// With embedded defaults (5) + test config (1), we should have 6 commands
-	if len(app.config.Commands) != 6 {
-		t.Errorf("Expected 6 commands (5 embedded defaults + 1 test), got %d", len(app.config.Commands))
+	// With embedded defaults (6) + test config (1), we should have 7 commands
+	if len(app.config.Commands) != 7 {
+		t.Errorf("Expected 7 commands (6 embedded defaults + 1 test), got %d", len(app.config.Commands))
 	}
 	
 	// Verify the test command was loaded correctly
@@ -228,7 +235,7 @@ commands:
 	// Note: We're testing the execution path but the actual command
 	// execution might produce output. In a real test environment,
 	// you might want to capture or redirect the output.
-	err = eng.Execute(ctx)
+	err = eng.ExecuteStatus(context.Background(), ctx)
 	if err != nil {
 		t.Fatalf("Command execution failed: %v", err)
 	}
@@ -303,7 +310,7 @@ func TestRealWorldExample(t *testing.T) {
 	}
 
 	// Execute the sed command
-	err = eng.Execute(ctx)
+	err = eng.ExecuteStatus(context.Background(), ctx)
 	if err != nil {
 		// sed might not be available in test environment, so we log but don't fail
 		t.Logf("sed command execution failed (this may be expected in test environment): %v", err)
@@ -322,4 +329,492 @@ func TestRealWorldExample(t *testing.T) {
 	}
 
 	t.Log("Real-world sed replacement test completed successfully")
-}
\ No newline at end of file
+}
+
+// newLazyTestApp creates an app with two commands for lazy registration tests
+func newLazyTestApp(args []string) *GoldfishApp {
+	platforms := map[string]config.PlatformCommand{
+		"linux":   {Template: "echo"},
+		"darwin":  {Template: "echo"},
+		"windows": {Template: "echo"},
+	}
+	return &GoldfishApp{
+		config: &config.Config{
+			Commands: []config.Command{
+				{Name: "first", Alias: "f", BaseCommand: "echo", Platforms: platforms},
+				{Name: "second", BaseCommand: "echo", Platforms: platforms},
+			},
+		},
+		engine:           engine.NewEngine(30 * time.Second),
+		platformDetector: platform.NewDetector(),
+		rootCmd:          &cobra.Command{Use: "goldfish"},
+		args:             args,
+	}
+}
+
+// TestGoldfishApp_invokedCommandName tests detection of the invoked command
+func TestGoldfishApp_invokedCommandName(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected string
+	}{
+		{nil, ""},
+		{[]string{"first", "arg"}, "first"},
+		{[]string{"f"}, "first"},
+		{[]string{"--version"}, ""},
+		{[]string{"help", "first"}, ""},
+		{[]string{"__complete", "fi"}, ""},
+		{[]string{"unknown"}, ""},
+	}
+
+	for _, tc := range testCases {
+		app := newLazyTestApp(tc.args)
+		if got := app.invokedCommandName(); got != tc.expected {
+			t.Errorf("invokedCommandName() with args %v: expected %q, got %q", tc.args, tc.expected, got)
+		}
+	}
+}
+
+// TestGoldfishApp_generateCommands_OnlyInvoked tests that only the invoked command is built
+func TestGoldfishApp_generateCommands_OnlyInvoked(t *testing.T) {
+	app := newLazyTestApp([]string{"second"})
+	if err := app.generateCommands(); err != nil {
+		t.Fatalf("generateCommands() failed: %v", err)
+	}
+	if len(app.rootCmd.Commands()) != 1 || app.rootCmd.Commands()[0].Name() != "second" {
+		t.Errorf("Expected only 'second' to be registered, got %v", app.rootCmd.Commands())
+	}
+
+	// Help needs every command
+	app = newLazyTestApp([]string{"help"})
+	if err := app.generateCommands(); err != nil {
+		t.Fatalf("generateCommands() failed: %v", err)
+	}
+	if len(app.rootCmd.Commands()) != 2 {
+		t.Errorf("Expected all commands to be registered for help, got %d", len(app.rootCmd.Commands()))
+	}
+}
+
+// TestGoldfishApp_generateCommands_Stubs tests that listed commands are stubs
+// until they are needed
+func TestGoldfishApp_generateCommands_Stubs(t *testing.T) {
+	// "help first" lists every command but only first needs its flags
+	app := newLazyTestApp([]string{"help", "f"})
+	if err := app.generateCommands(); err != nil {
+		t.Fatalf("generateCommands() failed: %v", err)
+	}
+	for _, cobraCmd := range app.rootCmd.Commands() {
+		if stub := cli.IsStub(cobraCmd); stub != (cobraCmd.Name() == "second") {
+			t.Errorf("Command %s: expected stub=%v", cobraCmd.Name(), !stub)
+		}
+	}
+
+	// Man pages need every command in full
+	app.materializeAll()
+	for _, cobraCmd := range app.rootCmd.Commands() {
+		if cli.IsStub(cobraCmd) {
+			t.Errorf("Expected %s to be materialized", cobraCmd.Name())
+		}
+	}
+}
+
+// TestGoldfishApp_runStub tests that a stub that is run anyway runs the real command
+func TestGoldfishApp_runStub(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses echo")
+	}
+	app := newLazyTestApp(nil)
+	if err := app.generateCommands(); err != nil {
+		t.Fatalf("generateCommands() failed: %v", err)
+	}
+
+	app.rootCmd.SetArgs([]string{"second"})
+	if err := app.rootCmd.Execute(); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	second, _, err := app.rootCmd.Find([]string{"second"})
+	if err != nil || cli.IsStub(second) {
+		t.Errorf("Expected the stub to be replaced by the full command, got %v, %v", second, err)
+	}
+}
+
+// TestGoldfishApp_positionalArgs tests that values of root flags are not taken for commands
+func TestGoldfishApp_positionalArgs(t *testing.T) {
+	app := newLazyTestApp([]string{"--log-file", "second", "-v", "first", "--x"})
+	app.rootCmd.PersistentFlags().String("log-file", "", "")
+	app.rootCmd.PersistentFlags().BoolP("verbose", "v", false, "")
+
+	if got := app.invokedCommandName(); got != "first" {
+		t.Errorf("Expected first, got %q", got)
+	}
+}
+
+// TestGoldfishApp_checkParameterFlags tests that a command cannot have a
+// parameter flag named like a global flag
+func TestGoldfishApp_checkParameterFlags(t *testing.T) {
+	app := newLazyTestApp([]string{"first", "--profile", "dev"})
+	app.rootCmd.PersistentFlags().String("profile", "", "")
+	app.config.Commands[0].Parameters = []config.Parameter{{Name: "profile", Type: "string"}}
+
+	err := app.checkParameterFlags()
+	if err == nil || !strings.Contains(err.Error(), "parameter 'profile' uses the global flag --profile") {
+		t.Errorf("Expected the parameter to be rejected, got %v", err)
+	}
+
+	// A flag: setting gives the parameter a flag of its own
+	app.config.Commands[0].Parameters[0].Flag = "--aws-profile"
+	if err := app.checkParameterFlags(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+// TestGoldfishApp_VerboseFlag tests that --verbose/-v is available on every command
+func TestGoldfishApp_VerboseFlag(t *testing.T) {
+	t.Chdir(t.TempDir())
+	app := &GoldfishApp{
+		engine:           engine.NewEngine(30 * time.Second),
+		platformDetector: platform.NewDetector(),
+	}
+	if err := app.initialize(); err != nil {
+		t.Fatalf("initialize() failed: %v", err)
+	}
+
+	flag := app.rootCmd.PersistentFlags().Lookup("verbose")
+	if flag == nil {
+		t.Fatal("Expected persistent --verbose flag")
+	}
+	if flag.Shorthand != "v" {
+		t.Errorf("Expected -v shorthand, got %q", flag.Shorthand)
+	}
+
+	// Parsing -v before a subcommand sets the flag
+	if err := app.rootCmd.ParseFlags([]string{"-v"}); err != nil {
+		t.Fatalf("ParseFlags() failed: %v", err)
+	}
+	if !app.verbose {
+		t.Error("Expected -v to enable verbose mode")
+	}
+}
+
+// TestGoldfishApp_VerboseFlag_DefaultCommands tests that no default command
+// hides --verbose/-v or another global flag with a parameter of its own
+func TestGoldfishApp_VerboseFlag_DefaultCommands(t *testing.T) {
+	t.Chdir(t.TempDir())
+	run := func(args ...string) (*GoldfishApp, string, error) {
+		app := &GoldfishApp{
+			engine:           engine.NewEngine(30 * time.Second),
+			platformDetector: platform.NewDetector(),
+			args:             args,
+		}
+		if err := app.initialize(); err != nil {
+			t.Fatalf("initialize() failed: %v", err)
+		}
+		var out bytes.Buffer
+		app.rootCmd.SetOut(&out)
+		app.rootCmd.SetErr(&bytes.Buffer{})
+		app.rootCmd.SetArgs(args)
+		err := app.rootCmd.Execute()
+		return app, out.String(), err
+	}
+
+	app, out, err := run("-v", "--dry-run", "archive-create", "--archive", "out.tar", "--files", "src", "--list")
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if !app.verbose || !strings.Contains(out, "out.tar") {
+		t.Errorf("Expected -v to enable verbose mode and the archive to be printed, got %v and %q", app.verbose, out)
+	}
+	for _, cmd := range app.config.Commands {
+		for i := range cmd.Parameters {
+			if name := cli.FlagName(&cmd.Parameters[i]); app.rootCmd.PersistentFlags().Lookup(name) != nil {
+				t.Errorf("%s: parameter '%s' hides the global --%s", cmd.Name, cmd.Parameters[i].Name, name)
+			}
+		}
+	}
+
+	// --silent and --verbose are still exclusive
+	if _, _, err := run("-v", "--silent", "--dry-run", "archive-create", "--archive", "out.tar", "--files", "src"); err == nil ||
+		!strings.Contains(err.Error(), "[silent verbose]") {
+		t.Errorf("Expected --silent and -v to be refused together, got %v", err)
+	}
+}
+
+// TestGoldfishApp_generateCommands_UnsupportedPlatform tests hidden placeholders for missing platforms
+func TestGoldfishApp_generateCommands_UnsupportedPlatform(t *testing.T) {
+	app := &GoldfishApp{
+		config: &config.Config{
+			Commands: []config.Command{
+				{
+					Name:        "elsewhere",
+					Alias:       "ew",
+					BaseCommand: "true",
+					Platforms:   map[string]config.PlatformCommand{"plan9": {Template: "true"}},
+				},
+			},
+		},
+		engine:           engine.NewEngine(30 * time.Second),
+		platformDetector: platform.NewDetector(),
+		rootCmd:          &cobra.Command{Use: "goldfish"},
+	}
+	if err := app.generateCommands(); err != nil {
+		t.Fatalf("generateCommands() failed: %v", err)
+	}
+
+	placeholder, _, err := app.rootCmd.Find([]string{"ew"})
+	if err != nil || placeholder.Name() != "elsewhere" {
+		t.Fatalf("Expected placeholder to be found by alias, got %v, %v", placeholder, err)
+	}
+	if !placeholder.Hidden {
+		t.Error("Expected unsupported command to be hidden from help")
+	}
+
+	// Running it returns the report with its dedicated exit code
+	runErr := placeholder.RunE(placeholder, []string{"--any", "args"})
+	var unsupported *engine.UnsupportedPlatformError
+	if !errors.As(runErr, &unsupported) {
+		t.Fatalf("Expected UnsupportedPlatformError, got %v", runErr)
+	}
+	if exitCodeFor(runErr) != engine.ExitCodeUnsupportedPlatform {
+		t.Errorf("Expected exit code %d, got %d", engine.ExitCodeUnsupportedPlatform, exitCodeFor(runErr))
+	}
+}
+
+// TestExitCodeFor tests mapping errors to process exit codes
+func TestExitCodeFor(t *testing.T) {
+	if code := exitCodeFor(errors.New("plain")); code != 1 {
+		t.Errorf("Expected exit code 1 for plain errors, got %d", code)
+	}
+	wrapped := fmt.Errorf("wrapped: %w", &engine.UnsupportedPlatformError{Command: "x"})
+	if code := exitCodeFor(wrapped); code != engine.ExitCodeUnsupportedPlatform {
+		t.Errorf("Expected wrapped exit code to be preserved, got %d", code)
+	}
+}
+
+// TestGoldfishApp_executeCommand_ExecutionLog tests logging via GOLDFISH_LOG
+func TestGoldfishApp_executeCommand_ExecutionLog(t *testing.T) {
+	currentPlatform, err := platform.NewDetector().Current()
+	if err != nil || currentPlatform == platform.Windows {
+		t.Skip("uses a POSIX shell")
+	}
+
+	logPath := filepath.Join(t.TempDir(), "exec.log")
+	t.Setenv(engine.LogEnvVar, logPath)
+
+	cmd := &config.Command{
+		Name:        "noop",
+		BaseCommand: "true",
+		Platforms:   map[string]config.PlatformCommand{currentPlatform.String(): {Template: "true"}},
+	}
+	app := &GoldfishApp{engine: engine.NewEngine(5 * time.Second)}
+
+	if err := app.executeCommand(cmd, &cobra.Command{}, nil, currentPlatform); err != nil {
+		t.Fatalf("executeCommand() failed: %v", err)
+	}
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("Expected execution log to be written: %v", err)
+	}
+	if !strings.Contains(string(data), `"command":"noop"`) {
+		t.Errorf("Expected log record for noop, got %s", data)
+	}
+}
+
+// TestGoldfishApp_generateCommands_HiddenExperimental tests hiding commands from help
+func TestGoldfishApp_generateCommands_HiddenExperimental(t *testing.T) {
+	app := newLazyTestApp(nil)
+	app.config.Commands[0].Hidden = true
+	app.config.Commands[1].Experimental = true
+
+	// Without GOLDFISH_EXPERIMENTAL both commands are hidden
+	t.Setenv(config.ExperimentalEnvVar, "")
+	if err := app.generateCommands(); err != nil {
+		t.Fatalf("generateCommands() failed: %v", err)
+	}
+	for _, cmd := range app.rootCmd.Commands() {
+		if !cmd.Hidden {
+			t.Errorf("Expected %s to be hidden", cmd.Name())
+		}
+	}
+
+	// Enabling experimental commands lists them, marked as experimental
+	t.Setenv(config.ExperimentalEnvVar, "1")
+	app = newLazyTestApp(nil)
+	app.config.Commands[1].Experimental = true
+	if err := app.generateCommands(); err != nil {
+		t.Fatalf("generateCommands() failed: %v", err)
+	}
+	second, _, err := app.rootCmd.Find([]string{"second"})
+	if err != nil || second.Hidden || !strings.HasPrefix(second.Short, "[experimental]") {
+		t.Errorf("Expected visible experimental command, got hidden=%v short=%q", second.Hidden, second.Short)
+	}
+}
+
+// TestGoldfishApp_generateCommands_Deprecated tests wiring the deprecation hint into Cobra
+func TestGoldfishApp_generateCommands_Deprecated(t *testing.T) {
+	app := newLazyTestApp(nil)
+	app.config.Commands[0].Deprecated = "use 'second' instead"
+	if err := app.generateCommands(); err != nil {
+		t.Fatalf("generateCommands() failed: %v", err)
+	}
+
+	first, _, err := app.rootCmd.Find([]string{"first"})
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+	if first.Deprecated != "use 'second' instead" {
+		t.Errorf("Expected Cobra deprecation message, got %q", first.Deprecated)
+	}
+}
+
+// TestGoldfishApp_generateCommands_EnabledIf tests that commands whose enabled_if fails are not registered
+func TestGoldfishApp_generateCommands_EnabledIf(t *testing.T) {
+	t.Setenv("GOLDFISH_TEST_VPN", "")
+	var warnings strings.Builder
+	config.Warnings = &warnings
+	defer func() { config.Warnings = os.Stderr }()
+
+	app := newLazyTestApp(nil)
+	loaded := app.config
+	app.config.Commands[0].EnabledIf = `{{if .env.GOLDFISH_TEST_VPN}}true{{end}}`
+	app.config.Commands[1].EnabledIf = `{{.nope | bad}}`
+	if err := app.generateCommands(); err != nil {
+		t.Fatalf("generateCommands() failed: %v", err)
+	}
+	if len(app.rootCmd.Commands()) != 0 || len(app.config.Commands) != 0 {
+		t.Errorf("Expected no commands, got %d registered and %d configured", len(app.rootCmd.Commands()), len(app.config.Commands))
+	}
+	if len(loaded.Commands) != 2 {
+		t.Errorf("Expected the loaded configuration to be left alone, got %d commands", len(loaded.Commands))
+	}
+	if !strings.Contains(warnings.String(), "command 'second': enabled_if") {
+		t.Errorf("Expected a warning about the broken condition, got %q", warnings.String())
+	}
+
+	t.Setenv("GOLDFISH_TEST_VPN", "1")
+	app = newLazyTestApp([]string{"first"})
+	app.config.Commands[0].EnabledIf = `{{if .env.GOLDFISH_TEST_VPN}}true{{end}}`
+	if err := app.generateCommands(); err != nil {
+		t.Fatalf("generateCommands() failed: %v", err)
+	}
+	if _, _, err := app.rootCmd.Find([]string{"first"}); err != nil {
+		t.Errorf("Expected first to be registered once enabled: %v", err)
+	}
+}
+
+// otherPlatform returns a supported platform goldfish is not running on
+func otherPlatform() platform.SupportedPlatform {
+	if runtime.GOOS == "darwin" {
+		return platform.Linux
+	}
+	return platform.Darwin
+}
+
+// TestGoldfishApp_readPlatformFlags tests reading --platform before flags are parsed
+func TestGoldfishApp_readPlatformFlags(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected string
+		wantErr  bool
+	}{
+		{[]string{"first"}, "", false},
+		{[]string{"--platform", "darwin", "first"}, "darwin", false},
+		{[]string{"first", "--platform=windows"}, "windows", false},
+		{[]string{"first", "--", "--platform", "darwin"}, "", false},
+		{[]string{"--platform", "plan9"}, "plan9", true},
+	}
+
+	for _, tc := range testCases {
+		app := newLazyTestApp(tc.args)
+		err := app.readPlatformFlags()
+		if (err != nil) != tc.wantErr || app.platformOverride != tc.expected {
+			t.Errorf("readPlatformFlags() with args %v: got %q, %v", tc.args, app.platformOverride, err)
+		}
+	}
+
+	// The override replaces the detected platform
+	app := newLazyTestApp([]string{"--platform", "windows"})
+	app.readPlatformFlags()
+	if got := app.currentPlatform(); got != platform.Windows {
+		t.Errorf("Expected windows templates, got %s", got)
+	}
+}
+
+// TestGoldfishApp_checkPlatform tests that another platform's commands need --force
+func TestGoldfishApp_checkPlatform(t *testing.T) {
+	app := newLazyTestApp(nil)
+	if err := app.checkPlatform("first"); err != nil {
+		t.Errorf("Expected commands to run without --platform, got %v", err)
+	}
+
+	app.platformOverride = otherPlatform().String()
+	if err := app.checkPlatform("first"); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("Expected another platform to need --force, got %v", err)
+	}
+	app.force = true
+	if err := app.checkPlatform("first"); err != nil {
+		t.Errorf("Expected --force to allow running, got %v", err)
+	}
+
+	// Commands that cannot print instead of running refuse --dry-run
+	app.dryRun = true
+	if err := app.checkPlatform("batch"); err == nil {
+		t.Error("Expected --dry-run to be refused")
+	}
+}
+
+// TestGoldfishApp_executeCommand_DryRun tests printing another platform's command line
+func TestGoldfishApp_executeCommand_DryRun(t *testing.T) {
+	other := otherPlatform()
+	cmd := &config.Command{
+		Name:        "greet",
+		BaseCommand: "echo",
+		Parameters:  []config.Parameter{{Name: "name", Type: "string", Required: true}},
+		Platforms:   map[string]config.PlatformCommand{other.String(): {Template: "{{.base_command}} hello {{.params.name}} from {{.platform.os}}"}},
+	}
+	app := &GoldfishApp{
+		engine:           engine.NewEngine(5 * time.Second),
+		platformDetector: platform.NewDetector(),
+		platformOverride: other.String(),
+		dryRun:           true,
+	}
+
+	var output strings.Builder
+	cobraCmd := &cobra.Command{}
+	cobraCmd.SetOut(&output)
+	if err := app.executeCommand(cmd, cobraCmd, []string{"world"}, other); err != nil {
+		t.Fatalf("executeCommand() failed: %v", err)
+	}
+	if expected := "echo hello world from " + other.String() + "\n"; output.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, output.String())
+	}
+
+	// Without --dry-run the command is not run
+	app.dryRun = false
+	if err := app.executeCommand(cmd, cobraCmd, []string{"world"}, other); err == nil {
+		t.Error("Expected running another platform's command to need --force")
+	}
+}
+
+// TestGoldfishApp_readPlatformFlags_Fallback tests combining configured and command line fallbacks
+func TestGoldfishApp_readPlatformFlags_Fallback(t *testing.T) {
+	defer engine.SetFallbackPlatforms(nil)
+
+	cmd := &config.Command{
+		Name:        "only-windows",
+		BaseCommand: "cmd",
+		Platforms:   map[string]config.PlatformCommand{"windows": {Template: "cmd /c ver"}},
+	}
+	app := newLazyTestApp([]string{"--platform", "linux", "--fallback-platform", "windows", "only-windows"})
+	if err := app.readPlatformFlags(); err != nil {
+		t.Fatalf("readPlatformFlags() failed: %v", err)
+	}
+	if selection, found := engine.SelectPlatform(cmd, platform.Linux); !found || selection.Fallback != platform.Windows {
+		t.Errorf("Expected the windows template as a fallback, got %+v", selection)
+	}
+
+	app = newLazyTestApp([]string{"--fallback-platform=linux,beos"})
+	if err := app.readPlatformFlags(); err == nil || !strings.Contains(err.Error(), "beos") {
+		t.Errorf("Expected an unknown fallback platform to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/danballance/goldfish/internal/mcp"
+)
+
+// newMCPCommand creates the built-in "mcp" command
+// It serves the configured commands as Model Context Protocol tools over
+// stdio, so LLM agents can call them instead of generating raw shell
+func (app *GoldfishApp) newMCPCommand() *cobra.Command {
+	var opts mcp.Options
+
+	mcpCmd := &cobra.Command{
+		Use:   "mcp",
+		Short: "Serve goldfish commands as MCP tools over stdio",
+		Long: "Serve the configured commands as Model Context Protocol tools over stdio.\n\n" +
+			"Each command becomes a tool whose input schema is derived from its params.\n" +
+			"Commands marked destructive: true are only offered with --allow-destructive,\n" +
+			"and even then every call must pass \"confirm\": true.",
+		Example: "  goldfish mcp\n  goldfish mcp --allow-destructive",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := app.checkPlatform("mcp"); err != nil {
+				return err
+			}
+
+			closeLog, err := app.configureEngine()
+			if err != nil {
+				return err
+			}
+			defer closeLog()
+
+			server := mcp.NewServer(app.config, app.engine, app.currentPlatform(), app.timeout(), Version, opts)
+			return server.Serve(cmd.InOrStdin(), cmd.OutOrStdout())
+		},
+	}
+
+	mcpCmd.Flags().BoolVar(&opts.AllowDestructive, "allow-destructive", false, "offer commands marked destructive (each call must still set confirm: true)")
+
+	return mcpCmd
+}
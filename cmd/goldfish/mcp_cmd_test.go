@@ -0,0 +1,24 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestMCPCommand tests serving tools over the command's stdin and stdout
+func TestMCPCommand(t *testing.T) {
+	app := newLazyTestApp(nil)
+	app.rootCmd.AddCommand(app.newMCPCommand())
+
+	var out bytes.Buffer
+	app.rootCmd.SetIn(strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}` + "\n"))
+	app.rootCmd.SetOut(&out)
+	app.rootCmd.SetArgs([]string{"mcp"})
+	if err := app.rootCmd.Execute(); err != nil {
+		t.Fatalf("mcp failed: %v", err)
+	}
+	if !strings.Contains(out.String(), `"tools":[`) {
+		t.Errorf("Expected a tools/list response, got %s", out.String())
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/danballance/goldfish/internal/engine"
+)
+
+// configureExec makes the engine record commands instead of running them
+// when $GOLDFISH_EXEC is "mock"
+// The transcript goes to standard error, or is appended to the file named
+// by $GOLDFISH_MOCK_TRANSCRIPT; the returned function closes that file.
+func (app *GoldfishApp) configureExec() (func(), error) {
+	switch mode := os.Getenv(engine.ExecEnvVar); mode {
+	case "", "local":
+		return func() {}, nil
+	case "mock":
+		if app.winrmHost != "" {
+			return nil, fmt.Errorf("%s=mock cannot be combined with --winrm", engine.ExecEnvVar)
+		}
+	default:
+		return nil, fmt.Errorf("%s: unknown execution mode '%s' (use local or mock)", engine.ExecEnvVar, mode)
+	}
+
+	var transcript io.Writer = os.Stderr
+	closeTranscript := func() {}
+	if path := os.Getenv(engine.MockTranscriptEnvVar); path != "" {
+		file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open mock transcript: %w", err)
+		}
+		transcript = file
+		closeTranscript = func() { file.Close() }
+	}
+	app.engine.SetBackend(engine.NewMockBackend(transcript))
+	return closeTranscript, nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/engine"
+)
+
+// TestGoldfishApp_configureExec tests that GOLDFISH_EXEC=mock records commands to the transcript
+func TestGoldfishApp_configureExec(t *testing.T) {
+	currentPlatform, err := newLazyTestApp(nil).platformDetector.Current()
+	if err != nil {
+		t.Skipf("unsupported platform: %v", err)
+	}
+	transcript := filepath.Join(t.TempDir(), "transcript.txt")
+	t.Setenv(engine.ExecEnvVar, "mock")
+	t.Setenv(engine.MockTranscriptEnvVar, transcript)
+
+	app := newLazyTestApp([]string{"first"})
+	closeEngine, err := app.configureEngine()
+	if err != nil {
+		t.Fatalf("configureEngine() failed: %v", err)
+	}
+	// A program that does not exist shows that nothing runs
+	cmd, _ := app.config.FindCommand("first")
+	cmd.Platforms = map[string]config.PlatformCommand{string(currentPlatform): {Template: "no-such-program --flag"}}
+	if err := app.executeCommand(cmd, &cobra.Command{}, nil, currentPlatform); err != nil {
+		t.Fatalf("executeCommand() failed: %v", err)
+	}
+	closeEngine()
+
+	data, err := os.ReadFile(transcript)
+	if err != nil || string(data) != "[mock default shell] no-such-program --flag\n" {
+		t.Errorf("Expected the command in the transcript, got %q (%v)", data, err)
+	}
+
+	// Mocking cannot be combined with a remote host, and the mode must be known
+	app = newLazyTestApp(nil)
+	app.winrmHost = "server01"
+	if _, err := app.configureExec(); err == nil || !strings.Contains(err.Error(), "--winrm") {
+		t.Errorf("Expected mock with --winrm to be refused, got %v", err)
+	}
+	t.Setenv(engine.ExecEnvVar, "remote")
+	if _, err := newLazyTestApp(nil).configureExec(); err == nil || !strings.Contains(err.Error(), "unknown execution mode") {
+		t.Errorf("Expected an unknown mode to be refused, got %v", err)
+	}
+}
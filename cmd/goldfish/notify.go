@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/engine"
+	"github.com/danballance/goldfish/internal/notify"
+)
+
+// showDesktopNotification shows desktop notifications (replaced in tests)
+var showDesktopNotification = notify.Desktop
+
+// notifyFinished announces that ctx's command finished, as its notify:
+// policy and --notify ask
+// Notifications are best effort: one that cannot be delivered is a warning,
+// and never changes the command's result or exit code.
+func (app *GoldfishApp) notifyFinished(ctx *engine.ExecutionContext, start time.Time, result *engine.ExecutionResult, runErr error) {
+	cmd := ctx.Command
+	event := &notify.Event{Command: cmd.Name, Time: start, Duration: time.Since(start), ExitCode: -1}
+	if result != nil {
+		event.ExitCode = result.ExitCode
+	}
+	if runErr != nil {
+		// Webhooks are other services; secrets the error names stay here
+		event.Error = app.engine.MaskError(ctx, runErr)
+	}
+
+	// --notify always notifies; the policy may only be interested in failures
+	desktop := app.notify
+	var webhooks []string
+	if app.notify && app.settings != nil && app.settings.NotifyWebhook != "" {
+		webhooks = append(webhooks, app.settings.NotifyWebhook)
+	}
+	if policy := cmd.Notify; policy != nil && policy.Matches(event.Succeeded()) {
+		desktop = desktop || policy.Desktop
+		if policy.Webhook != "" && (len(webhooks) == 0 || webhooks[0] != policy.Webhook) {
+			webhooks = append(webhooks, policy.Webhook)
+		}
+	}
+	if !desktop && len(webhooks) == 0 {
+		return
+	}
+
+	event.Host, _ = os.Hostname()
+	if desktop {
+		if err := showDesktopNotification(event); err != nil {
+			fmt.Fprintf(config.Warnings, "Warning: no desktop notification: %v\n", err)
+		}
+	}
+	for _, webhook := range webhooks {
+		// The expanded URL is not shown, since webhook URLs are often secret
+		url := os.ExpandEnv(webhook)
+		if err := config.ValidateWebhook(url); err != nil {
+			fmt.Fprintf(config.Warnings, "Warning: notification not posted: webhook %s is not an http or https URL\n", webhook)
+			continue
+		}
+		if err := notify.Webhook(url, event); err != nil {
+			fmt.Fprintf(config.Warnings, "Warning: notification not posted: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/engine"
+	"github.com/danballance/goldfish/internal/notify"
+	"github.com/danballance/goldfish/internal/settings"
+)
+
+// TestNotifyFinished tests that runs are posted to webhooks as the notify:
+// policy and the notify_webhook setting ask
+func TestNotifyFinished(t *testing.T) {
+	var received []notify.Payload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload notify.Payload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("Failed to decode the payload: %v", err)
+		}
+		payload.Text = r.URL.Path + " " + payload.Text
+		received = append(received, payload)
+	}))
+	defer server.Close()
+	t.Setenv("TEST_WEBHOOK", server.URL+"/policy")
+
+	cmd := &config.Command{Name: "backup", Notify: &config.NotifyPolicy{On: config.NotifyFailure, Webhook: "$TEST_WEBHOOK"}}
+	cmd.Parameters = []config.Parameter{{Name: "token", Type: "string", Secret: true}}
+	app := &GoldfishApp{engine: engine.NewEngine(0), settings: &settings.Settings{NotifyWebhook: server.URL + "/setting"}}
+	start := time.Now().Add(-time.Minute)
+
+	// A success is of no interest to a policy for failures
+	app.notifyFinished(&engine.ExecutionContext{Command: cmd}, start, &engine.ExecutionResult{}, nil)
+	if len(received) != 0 {
+		t.Errorf("Expected no notification, got %+v", received)
+	}
+	app.notifyFinished(&engine.ExecutionContext{Command: cmd}, start, &engine.ExecutionResult{ExitCode: 4}, nil)
+	if len(received) != 1 || received[0].ExitCode != 4 || !strings.HasPrefix(received[0].Text, "/policy goldfish: 'backup' failed with exit code 4 after 1m") {
+		t.Errorf("Expected the failure to be posted, got %+v", received)
+	}
+	received = nil
+	app.notifyFinished(&engine.ExecutionContext{Command: cmd, Parameters: map[string]interface{}{"token": "s3cr3t"}}, start, nil, errors.New("timed out: curl -u s3cr3t"))
+	if len(received) != 1 || received[0].ExitCode != -1 || received[0].Error != "timed out: curl -u ********" {
+		t.Errorf("Expected the error to be posted, got %+v", received)
+	}
+
+	// With --notify the desktop and the setting's webhook hear about every run
+	var shown []string
+	showDesktopNotification = func(ev *notify.Event) error {
+		shown = append(shown, ev.Summary())
+		return nil
+	}
+	defer func() { showDesktopNotification = notify.Desktop }()
+	received = nil
+	app.notify = true
+	cmd.Notify = nil
+	app.notifyFinished(&engine.ExecutionContext{Command: cmd}, start, &engine.ExecutionResult{}, nil)
+	if len(received) != 1 || received[0].Status != "succeeded" || !strings.HasPrefix(received[0].Text, "/setting ") {
+		t.Errorf("Expected the success to be posted, got %+v", received)
+	}
+	if len(shown) != 1 || !strings.HasPrefix(shown[0], "'backup' succeeded in 1m") {
+		t.Errorf("Expected a desktop notification, got %q", shown)
+	}
+}
+
+// TestNotifyFinished_BadWebhook tests that an unusable webhook is a warning
+// that does not show the URL
+func TestNotifyFinished_BadWebhook(t *testing.T) {
+	var warnings bytes.Buffer
+	config.Warnings = &warnings
+	defer func() { config.Warnings = os.Stderr }()
+	t.Setenv("TEST_WEBHOOK", "secret-token")
+
+	cmd := &config.Command{Name: "backup", Notify: &config.NotifyPolicy{Webhook: "$TEST_WEBHOOK"}}
+	(&GoldfishApp{}).notifyFinished(&engine.ExecutionContext{Command: cmd}, time.Now(), &engine.ExecutionResult{}, nil)
+	if !strings.Contains(warnings.String(), "webhook $TEST_WEBHOOK is not an http or https URL") || strings.Contains(warnings.String(), "secret-token") {
+		t.Errorf("Unexpected warning %q", warnings.String())
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/danballance/goldfish/internal/engine"
+)
+
+// Output formats accepted by the persistent --output flag
+const (
+	// OutputText passes the command's output through (the default)
+	OutputText = "text"
+	// OutputJSON captures the output and prints a JSON envelope instead
+	OutputJSON = "json"
+)
+
+// outputEnvelope is what --output json prints for each command run
+// Automation that wraps goldfish reads it instead of scraping the terminal.
+type outputEnvelope struct {
+	// Command is the goldfish command that ran, e.g. "find-files"
+	Command string `json:"command"`
+	// Rendered is the command line it ran, with secrets masked
+	Rendered   string `json:"rendered"`
+	ExitCode   int    `json:"exit_code"`
+	Stdout     string `json:"stdout"`
+	Stderr     string `json:"stderr"`
+	DurationMS int64  `json:"duration_ms"`
+	// Cached is set when the output is a cached result (cache:)
+	Cached bool `json:"cached,omitempty"`
+	// Skipped is set when the command was already done (skip_if:)
+	Skipped bool `json:"skipped,omitempty"`
+	// Error explains why the command could not run or its postconditions failed
+	Error string `json:"error,omitempty"`
+}
+
+// checkOutputFormat validates the --output flag
+func (app *GoldfishApp) checkOutputFormat() error {
+	switch app.outputFormat {
+	case "", OutputText, OutputJSON:
+		return nil
+	}
+	return fmt.Errorf("invalid --output %q: expected %s or %s", app.outputFormat, OutputText, OutputJSON)
+}
+
+// writeEnvelope prints the JSON envelope for a command's result
+// The returned error carries the command's exit code, so goldfish still
+// exits with it after the envelope is printed.
+func writeEnvelope(w io.Writer, name string, result *engine.ExecutionResult, runErr error) error {
+	envelope := outputEnvelope{
+		Command:    name,
+		Rendered:   result.Command,
+		ExitCode:   result.ExitCode,
+		Stdout:     result.Stdout,
+		Stderr:     result.Stderr,
+		DurationMS: result.Duration.Milliseconds(),
+		Cached:     result.Cached,
+		Skipped:    result.Skipped,
+	}
+	if runErr != nil {
+		envelope.Error = runErr.Error()
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(envelope); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+
+	if runErr != nil {
+		return runErr
+	}
+	if result.ExitCode != 0 {
+		return &engine.ExitError{Command: name, Code: result.ExitCode}
+	}
+	return nil
+}
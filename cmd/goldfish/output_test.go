@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/engine"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// TestGoldfishApp_executeCommand_OutputJSON tests printing a JSON envelope instead of the output
+func TestGoldfishApp_executeCommand_OutputJSON(t *testing.T) {
+	currentPlatform, err := platform.NewDetector().Current()
+	if err != nil || currentPlatform == platform.Windows {
+		t.Skip("uses a POSIX shell")
+	}
+
+	cmd := &config.Command{
+		Name:        "greet",
+		BaseCommand: "echo",
+		Parameters:  []config.Parameter{{Name: "code", Type: "int", Default: 0}},
+		Platforms: map[string]config.PlatformCommand{
+			currentPlatform.String(): {Template: "echo hello; echo warn >&2; exit {{.params.code}}"},
+		},
+	}
+	app := &GoldfishApp{engine: engine.NewEngine(5 * time.Second), platformDetector: platform.NewDetector(), outputFormat: OutputJSON}
+
+	run := func(args []string) (outputEnvelope, error) {
+		var output strings.Builder
+		cobraCmd := &cobra.Command{}
+		cobraCmd.SetOut(&output)
+		runErr := app.executeCommand(cmd, cobraCmd, args, currentPlatform)
+		var envelope outputEnvelope
+		if err := json.Unmarshal([]byte(output.String()), &envelope); err != nil {
+			t.Fatalf("Expected a JSON envelope, got %q: %v", output.String(), err)
+		}
+		return envelope, runErr
+	}
+
+	envelope, err := run(nil)
+	if err != nil {
+		t.Fatalf("executeCommand() failed: %v", err)
+	}
+	expected := outputEnvelope{Command: "greet", Rendered: "echo hello; echo warn >&2; exit 0", Stdout: "hello\n", Stderr: "warn\n"}
+	envelope.DurationMS = 0
+	if envelope != expected {
+		t.Errorf("Expected %+v, got %+v", expected, envelope)
+	}
+
+	// A failing command is reported in the envelope and goldfish exits with its code
+	envelope, err = run([]string{"4"})
+	if envelope.ExitCode != 4 || exitCodeFor(err) != 4 {
+		t.Errorf("Expected exit code 4, got %d (%v)", envelope.ExitCode, err)
+	}
+
+	app.outputFormat = "yaml"
+	if err := app.executeCommand(cmd, &cobra.Command{}, nil, currentPlatform); err == nil || !strings.Contains(err.Error(), "--output") {
+		t.Errorf("Expected an invalid --output to be refused, got %v", err)
+	}
+}
+
+// TestNoColorRequested tests finding --no-color before the flags are parsed
+func TestNoColorRequested(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected bool
+	}{
+		{[]string{"--no-color", "ps"}, true},
+		{[]string{"ps", "--no-color=true"}, true},
+		{[]string{"ps"}, false},
+		// After "--" the argument is the command's
+		{[]string{"ps", "--", "--no-color"}, false},
+	}
+	for _, tc := range testCases {
+		if got := noColorRequested(tc.args); got != tc.expected {
+			t.Errorf("%v: expected %v, got %v", tc.args, tc.expected, got)
+		}
+	}
+}
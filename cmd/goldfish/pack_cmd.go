@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/packs"
+)
+
+// newPackCommand creates the built-in "pack" command group
+// Packs are shareable commands.yml files installed into the packs directory;
+// their commands are merged in above the embedded defaults but below any
+// commands.yml found on disk, so local files can still override them
+func (app *GoldfishApp) newPackCommand() *cobra.Command {
+	packCmd := &cobra.Command{
+		Use:   "pack",
+		Short: "Install and manage command packs",
+		Long: "Install and manage command packs such as \"docker-essentials\" or \"macos-admin\".\n" +
+			"Packs are installed by URL or by name from the registry in $" + packs.RegistryEnvVar + ".",
+	}
+
+	packCmd.AddCommand(&cobra.Command{
+		Use:     "install <name[@version]|url>",
+		Short:   "Download and install a pack (an explicit version pins it)",
+		Example: "  goldfish pack install docker-essentials\n  goldfish pack install macos-admin@1.2.0\n  goldfish pack install https://example.com/pack.yml",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			manager, err := packs.NewManager()
+			if err != nil {
+				return err
+			}
+			installed, err := manager.Install(args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Installed %s %s into %s\n", installed.Name, installed.Version, manager.Dir())
+			return nil
+		},
+	})
+
+	packCmd.AddCommand(&cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List installed packs",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			manager, err := packs.NewManager()
+			if err != nil {
+				return err
+			}
+			installed, err := manager.List()
+			if err != nil {
+				return err
+			}
+			if len(installed) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No packs installed")
+				return nil
+			}
+
+			writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+			fmt.Fprintln(writer, "NAME\tVERSION\tPINNED\tSOURCE")
+			for _, pack := range installed {
+				fmt.Fprintf(writer, "%s\t%s\t%t\t%s\n", pack.Name, pack.Version, pack.Pinned, pack.Source)
+			}
+			return writer.Flush()
+		},
+	})
+
+	packCmd.AddCommand(&cobra.Command{
+		Use:   "update",
+		Short: "Re-download every pack that is not pinned to a version",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			manager, err := packs.NewManager()
+			if err != nil {
+				return err
+			}
+			updated, err := manager.Update()
+			for _, pack := range updated {
+				fmt.Fprintf(cmd.OutOrStdout(), "Updated %s to %s\n", pack.Name, pack.Version)
+			}
+			if err != nil {
+				return err
+			}
+			if len(updated) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "All packs are up to date")
+			}
+			return nil
+		},
+	})
+
+	packCmd.AddCommand(&cobra.Command{
+		Use:     "remove <name>",
+		Aliases: []string{"rm", "uninstall"},
+		Short:   "Remove an installed pack",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			manager, err := packs.NewManager()
+			if err != nil {
+				return err
+			}
+			return manager.Remove(args[0])
+		},
+	})
+
+	packCmd.AddCommand(app.newPackKeygenCommand())
+	packCmd.AddCommand(app.newPackSignCommand())
+
+	return packCmd
+}
+
+// newPackKeygenCommand creates "goldfish pack keygen"
+// It writes a new private signing key and prints the public key to trust
+func (app *GoldfishApp) newPackKeygenCommand() *cobra.Command {
+	var out string
+
+	keygenCmd := &cobra.Command{
+		Use:   "keygen",
+		Short: "Generate a key pair for signing packs and remote configs",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := os.Stat(out); err == nil {
+				return fmt.Errorf("%s already exists; refusing to overwrite a signing key", out)
+			}
+			publicKey, privateKey, err := config.GenerateKeyPair()
+			if err != nil {
+				return err
+			}
+			// The private key must stay private, so only the owner may read it
+			if err := os.WriteFile(out, []byte(privateKey+"\n"), 0600); err != nil {
+				return fmt.Errorf("failed to write private key: %w", err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Private key written to %s\n", out)
+			fmt.Fprintf(cmd.OutOrStdout(), "Add the public key to trusted_keys: in commands.yml:\n  - \"%s\"\n", publicKey)
+			return nil
+		},
+	}
+
+	keygenCmd.Flags().StringVar(&out, "out", "goldfish.key", "file to write the private key to")
+
+	return keygenCmd
+}
+
+// newPackSignCommand creates "goldfish pack sign"
+// It writes <file>.sig next to a pack or config so it can be published with it
+func (app *GoldfishApp) newPackSignCommand() *cobra.Command {
+	var keyPath string
+
+	signCmd := &cobra.Command{
+		Use:     "sign <file>",
+		Short:   "Sign a pack or config file, writing <file>.sig",
+		Example: "  goldfish pack sign --key goldfish.key docker-essentials.yml",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			privateKey, err := os.ReadFile(keyPath)
+			if err != nil {
+				return fmt.Errorf("failed to read private key: %w", err)
+			}
+			data, err := os.ReadFile(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", args[0], err)
+			}
+			signature, err := config.Sign(data, string(privateKey))
+			if err != nil {
+				return err
+			}
+			signaturePath := args[0] + config.SignatureSuffix
+			if err := os.WriteFile(signaturePath, []byte(signature+"\n"), 0644); err != nil {
+				return fmt.Errorf("failed to write signature: %w", err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Signature written to %s\n", signaturePath)
+			return nil
+		},
+	}
+
+	signCmd.Flags().StringVar(&keyPath, "key", "goldfish.key", "private key file from goldfish pack keygen")
+
+	return signCmd
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/packs"
+)
+
+// TestPackCommand tests installing, listing and removing a pack through the CLI
+func TestPackCommand(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/tools/latest.yml" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("pack:\n  name: tools\n  version: \"1.0.0\"\ncommands:\n  - name: \"tool\"\n    base_command: \"echo\"\n    platforms:\n      linux:\n        template: \"echo\"\n"))
+	}))
+	defer server.Close()
+	t.Setenv(config.PacksDirEnvVar, t.TempDir())
+	t.Setenv(packs.RegistryEnvVar, server.URL)
+
+	run := func(args ...string) string {
+		t.Helper()
+		app := newLazyTestApp(nil)
+		app.rootCmd.AddCommand(app.newPackCommand())
+		var out bytes.Buffer
+		app.rootCmd.SetOut(&out)
+		app.rootCmd.SetArgs(args)
+		if err := app.rootCmd.Execute(); err != nil {
+			t.Fatalf("%v failed: %v", args, err)
+		}
+		return out.String()
+	}
+
+	if out := run("pack", "install", "tools"); !strings.Contains(out, "Installed tools 1.0.0") {
+		t.Errorf("Unexpected install output: %s", out)
+	}
+	if out := run("pack", "list"); !strings.Contains(out, "tools") || !strings.Contains(out, "1.0.0") {
+		t.Errorf("Unexpected list output: %s", out)
+	}
+	if out := run("pack", "update"); !strings.Contains(out, "up to date") {
+		t.Errorf("Unexpected update output: %s", out)
+	}
+	run("pack", "remove", "tools")
+	if out := run("pack", "list"); !strings.Contains(out, "No packs installed") {
+		t.Errorf("Expected no packs after removal, got: %s", out)
+	}
+}
+
+// TestPackKeygenAndSign tests generating a key and signing a file with it
+func TestPackKeygenAndSign(t *testing.T) {
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "goldfish.key")
+	packPath := filepath.Join(dir, "pack.yml")
+	content := []byte("commands:\n  - name: \"tool\"\n    base_command: \"echo\"\n    platforms:\n      linux:\n        template: \"echo\"\n")
+	os.WriteFile(packPath, content, 0644)
+
+	run := func(args ...string) (string, error) {
+		app := newLazyTestApp(nil)
+		app.rootCmd.AddCommand(app.newPackCommand())
+		var out bytes.Buffer
+		app.rootCmd.SetOut(&out)
+		app.rootCmd.SetArgs(args)
+		err := app.rootCmd.Execute()
+		return out.String(), err
+	}
+
+	out, err := run("pack", "keygen", "--out", keyPath)
+	if err != nil {
+		t.Fatalf("pack keygen failed: %v", err)
+	}
+	// The public key is printed as a trusted_keys: entry
+	start := strings.Index(out, "- \"")
+	if start < 0 {
+		t.Fatalf("Expected a public key in output: %s", out)
+	}
+	publicKey := strings.TrimSuffix(strings.TrimSpace(out[start+3:]), "\"")
+
+	if _, err := run("pack", "keygen", "--out", keyPath); err == nil {
+		t.Errorf("Expected keygen to refuse to overwrite an existing key")
+	}
+
+	if _, err := run("pack", "sign", "--key", keyPath, packPath); err != nil {
+		t.Fatalf("pack sign failed: %v", err)
+	}
+	signature, err := os.ReadFile(packPath + config.SignatureSuffix)
+	if err != nil {
+		t.Fatalf("Expected a signature file: %v", err)
+	}
+	if err := config.VerifySignature(content, signature, []string{publicKey}); err != nil {
+		t.Errorf("Expected signature to verify with the printed key: %v", err)
+	}
+}
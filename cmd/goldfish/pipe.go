@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/engine"
+)
+
+// readInput reads standard input piped to goldfish, as in "cat data |
+// goldfish transform --to json", for --pipe or a command with a stdin_param;
+// it returns nil when there is none
+// Templates see the input as .stdin and .stdin_file. It also fills the
+// stdin_param parameter, without trailing newlines, unless that parameter's
+// flag was given; flags must already hold the flags given.
+func (app *GoldfishApp) readInput(cmd *config.Command, in io.Reader, flags map[string]interface{}) (*engine.Input, error) {
+	if !app.pipe && cmd.StdinParam == "" {
+		return nil, nil
+	}
+	// A terminal is someone typing, not input to read up front
+	if file, ok := in.(*os.File); ok && term.IsTerminal(int(file.Fd())) {
+		if app.pipe {
+			return nil, fmt.Errorf("--pipe reads piped input, as in: cat data | goldfish %s --pipe", cmd.Name)
+		}
+		return nil, nil
+	}
+
+	input, err := engine.ReadInput(in)
+	if err != nil {
+		return nil, err
+	}
+	if cmd.StdinParam == "" {
+		return input, nil
+	}
+	// Nothing piped, e.g. </dev/null under cron, leaves the parameter alone
+	param, found := cmd.FindParameter(cmd.StdinParam)
+	if !found || input.Size == 0 {
+		return input, nil
+	}
+	flagName := param.Name
+	if param.Flag != "" {
+		flagName = strings.TrimLeft(param.Flag, "-")
+	}
+	if _, given := flags["--"+flagName]; !given {
+		text, err := input.Text()
+		if err != nil {
+			input.Close()
+			return nil, fmt.Errorf("stdin_param '%s': %w", param.Name, err)
+		}
+		// Like $(...) in a shell, trailing newlines are dropped
+		flags["--"+flagName] = strings.TrimRight(text, "\r\n")
+	}
+	return input, nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// TestGoldfishApp_readInput tests reading piped input for --pipe and stdin_param
+func TestGoldfishApp_readInput(t *testing.T) {
+	cmd := &config.Command{Name: "shout", Parameters: []config.Parameter{{Name: "text", Type: "string", Flag: "--words"}}}
+	app := newLazyTestApp(nil)
+
+	// Without --pipe or stdin_param the input is left for the command
+	if input, err := app.readInput(cmd, strings.NewReader("hello\n"), map[string]interface{}{}); input != nil || err != nil {
+		t.Errorf("Expected no input to be read, got %v (%v)", input, err)
+	}
+
+	app.pipe = true
+	input, err := app.readInput(cmd, strings.NewReader("hello\n"), map[string]interface{}{})
+	if err != nil || input == nil {
+		t.Fatalf("Expected --pipe to read the input, got %v (%v)", input, err)
+	}
+	input.Close()
+
+	// stdin_param fills the parameter, without the trailing newline, unless its flag was given
+	app.pipe = false
+	cmd.StdinParam = "text"
+	flags := map[string]interface{}{}
+	input, err = app.readInput(cmd, strings.NewReader("hello\n"), flags)
+	if err != nil || input == nil || flags["--words"] != "hello" {
+		t.Errorf("Expected the parameter to be filled, got %v (%v)", flags, err)
+	}
+	input.Close()
+	flags = map[string]interface{}{"--words": "given"}
+	input, err = app.readInput(cmd, strings.NewReader("hello\n"), flags)
+	if err != nil || flags["--words"] != "given" {
+		t.Errorf("Expected the flag to win, got %v (%v)", flags, err)
+	}
+	input.Close()
+	// Empty input, e.g. from /dev/null, fills nothing
+	flags = map[string]interface{}{}
+	input, err = app.readInput(cmd, strings.NewReader(""), flags)
+	if err != nil || len(flags) != 0 {
+		t.Errorf("Expected empty input to fill nothing, got %v (%v)", flags, err)
+	}
+	input.Close()
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ProfileEnvVar selects a profile when --profile is not given
+const ProfileEnvVar = "GOLDFISH_PROFILE"
+
+// readProfileFlag selects the profile named by --profile or $GOLDFISH_PROFILE
+// It is read from the raw arguments so that the profile applies before any
+// command is built, whichever way it then runs commands (directly, in a
+// batch or workflow, or for a server).
+func (app *GoldfishApp) readProfileFlag() error {
+	name := os.Getenv(ProfileEnvVar)
+	flag := "$" + ProfileEnvVar
+	if values := app.rawFlagValues("profile"); len(values) > 0 {
+		name = values[len(values)-1]
+		flag = "--profile"
+	}
+	if name == "" {
+		return nil
+	}
+	profile, found := app.config.FindProfile(name)
+	if !found {
+		if len(app.config.Profiles) == 0 {
+			return fmt.Errorf("unknown profile '%s' for %s: no profiles are defined", name, flag)
+		}
+		return fmt.Errorf("unknown profile '%s' for %s (profiles: %s)", name, flag, strings.Join(app.config.ProfileNames(), ", "))
+	}
+	app.engine.SetProfile(profile)
+	return nil
+}
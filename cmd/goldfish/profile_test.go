@@ -0,0 +1,58 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/engine"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// TestGoldfishApp_readProfileFlag tests selecting a profile with --profile or its variable
+func TestGoldfishApp_readProfileFlag(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		env      string
+		expected string
+		err      string
+	}{
+		{[]string{"first"}, "", "http://localhost", ""},
+		{[]string{"--profile", "prod", "first"}, "", "https://example.com", ""},
+		{[]string{"--profile=prod", "first"}, "", "https://example.com", ""},
+		{[]string{"first"}, "prod", "https://example.com", ""},
+		// The flag wins over the variable
+		{[]string{"--profile", "dev", "first"}, "prod", "http://localhost", ""},
+		// Arguments after -- belong to the command
+		{[]string{"first", "--", "--profile", "prod"}, "", "http://localhost", ""},
+		{[]string{"--profile", "qa", "first"}, "", "", "unknown profile 'qa' for --profile (profiles: dev, prod)"},
+		{[]string{"first"}, "qa", "", "unknown profile 'qa' for $" + ProfileEnvVar},
+	}
+	for _, tc := range testCases {
+		t.Setenv(ProfileEnvVar, tc.env)
+		app := newLazyTestApp(tc.args)
+		app.config.Vars = map[string]string{"endpoint": "http://localhost"}
+		app.config.Profiles = []config.Profile{
+			{Name: "dev"},
+			{Name: "prod", Vars: map[string]string{"endpoint": "https://example.com"}},
+		}
+		app.engine.SetGlobals(app.config.Vars)
+
+		err := app.readProfileFlag()
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("%v (%s=%s): expected error containing %q, got %v", tc.args, ProfileEnvVar, tc.env, tc.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v (%s=%s): unexpected error: %v", tc.args, ProfileEnvVar, tc.env, err)
+			continue
+		}
+		cmd := &config.Command{Name: "first", BaseCommand: "echo", Platforms: map[string]config.PlatformCommand{"linux": {Template: "echo {{.globals.endpoint}}"}}}
+		rendered, err := app.engine.Preview(&engine.ExecutionContext{Command: cmd, Platform: platform.Linux, Parameters: map[string]interface{}{}})
+		if err != nil || rendered != "echo "+tc.expected {
+			t.Errorf("%v (%s=%s): expected %q, got %q (%v)", tc.args, ProfileEnvVar, tc.env, "echo "+tc.expected, rendered, err)
+		}
+	}
+}
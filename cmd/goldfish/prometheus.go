@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/danballance/goldfish/internal/engine"
+)
+
+// prometheusContentType is the content type of the Prometheus text format
+const prometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// prometheusHandler serves the engine metrics at /metrics in the Prometheus
+// text format, for teams that scrape goldfish serve as an execution service
+func prometheusHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", prometheusContentType)
+	writePrometheus(w, engine.CurrentStats(), engine.CurrentCommandStats())
+}
+
+// writePrometheus writes the engine metrics in the Prometheus text format
+// Counters per command are labeled with its name; the process-wide ones are
+// the engine's expvar counters under Prometheus names.
+func writePrometheus(w io.Writer, stats engine.Stats, commands []engine.CommandStats) error {
+	out := bufio.NewWriter(w)
+	counter := func(name, help string) {
+		fmt.Fprintf(out, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+	}
+
+	counter("goldfish_command_executions_total", "Executions of each command.")
+	for _, command := range commands {
+		fmt.Fprintf(out, "goldfish_command_executions_total{command=%s} %d\n", promLabel(command.Command), command.Executions)
+	}
+	counter("goldfish_command_failures_total", "Executions of each command that failed to run or exited with a non-zero code.")
+	for _, command := range commands {
+		fmt.Fprintf(out, "goldfish_command_failures_total{command=%s} %d\n", promLabel(command.Command), command.Failures)
+	}
+	counter("goldfish_command_timeouts_total", "Executions of each command that ended because it timed out.")
+	for _, command := range commands {
+		fmt.Fprintf(out, "goldfish_command_timeouts_total{command=%s} %d\n", promLabel(command.Command), command.Timeouts)
+	}
+
+	fmt.Fprintf(out, "# HELP goldfish_command_duration_seconds How long executions of each command took.\n")
+	fmt.Fprintf(out, "# TYPE goldfish_command_duration_seconds histogram\n")
+	for _, command := range commands {
+		label := promLabel(command.Command)
+		for i, bound := range engine.DurationBuckets {
+			fmt.Fprintf(out, "goldfish_command_duration_seconds_bucket{command=%s,le=\"%s\"} %d\n", label, promFloat(bound), command.BucketCounts[i])
+		}
+		fmt.Fprintf(out, "goldfish_command_duration_seconds_bucket{command=%s,le=\"+Inf\"} %d\n", label, command.Executions)
+		fmt.Fprintf(out, "goldfish_command_duration_seconds_sum{command=%s} %s\n", label, promFloat(command.Duration.Seconds()))
+		fmt.Fprintf(out, "goldfish_command_duration_seconds_count{command=%s} %d\n", label, command.Executions)
+	}
+
+	counter("goldfish_render_seconds_total", "Time spent rendering templates.")
+	fmt.Fprintf(out, "goldfish_render_seconds_total %s\n", promFloat(stats.RenderTime.Seconds()))
+	counter("goldfish_template_cache_hits_total", "Renders that reused an already parsed template.")
+	fmt.Fprintf(out, "goldfish_template_cache_hits_total %d\n", stats.TemplateCacheHits)
+	counter("goldfish_result_cache_hits_total", "Executions answered from the result cache.")
+	fmt.Fprintf(out, "goldfish_result_cache_hits_total %d\n", stats.ResultCacheHits)
+	return out.Flush()
+}
+
+// promLabelEscaper escapes the characters special in label values
+var promLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// promLabel quotes a label value
+func promLabel(value string) string {
+	return `"` + promLabelEscaper.Replace(value) + `"`
+}
+
+// promFloat formats a sample value or bucket bound, e.g. "0.25" or "300"
+func promFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/engine"
+)
+
+// TestWritePrometheus tests the Prometheus text format of the metrics
+func TestWritePrometheus(t *testing.T) {
+	buckets := make([]int64, len(engine.DurationBuckets))
+	for i := 4; i < len(buckets); i++ {
+		buckets[i] = 2
+	}
+	commands := []engine.CommandStats{{
+		Command:      `say "hi"`,
+		Executions:   3,
+		Failures:     1,
+		Timeouts:     1,
+		BucketCounts: buckets,
+		Duration:     2500 * time.Millisecond,
+	}}
+	var out bytes.Buffer
+	if err := writePrometheus(&out, engine.Stats{RenderTime: time.Second / 4, ResultCacheHits: 7}, commands); err != nil {
+		t.Fatalf("writePrometheus failed: %v", err)
+	}
+	for _, expected := range []string{
+		"# TYPE goldfish_command_executions_total counter\n",
+		`goldfish_command_executions_total{command="say \"hi\""} 3` + "\n",
+		`goldfish_command_failures_total{command="say \"hi\""} 1` + "\n",
+		`goldfish_command_timeouts_total{command="say \"hi\""} 1` + "\n",
+		"# TYPE goldfish_command_duration_seconds histogram\n",
+		`goldfish_command_duration_seconds_bucket{command="say \"hi\"",le="0.5"} 0` + "\n",
+		`goldfish_command_duration_seconds_bucket{command="say \"hi\"",le="1"} 2` + "\n",
+		`goldfish_command_duration_seconds_bucket{command="say \"hi\"",le="+Inf"} 3` + "\n",
+		`goldfish_command_duration_seconds_sum{command="say \"hi\""} 2.5` + "\n",
+		`goldfish_command_duration_seconds_count{command="say \"hi\""} 3` + "\n",
+		"goldfish_render_seconds_total 0.25\n",
+		"goldfish_result_cache_hits_total 7\n",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected the metrics to contain %q, got:\n%s", expected, out.String())
+		}
+	}
+}
+
+// TestNewServeMux_Metrics tests that /metrics serves the Prometheus format
+func TestNewServeMux_Metrics(t *testing.T) {
+	server := httptest.NewServer(newServeMux(false))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("GET /metrics failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read /metrics: %v", err)
+	}
+	if resp.Header.Get("Content-Type") != prometheusContentType {
+		t.Errorf("Expected the Prometheus content type, got %q", resp.Header.Get("Content-Type"))
+	}
+	if !strings.Contains(string(body), "# TYPE goldfish_command_duration_seconds histogram") {
+		t.Errorf("Expected the metrics, got:\n%s", body)
+	}
+}
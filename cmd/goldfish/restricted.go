@@ -0,0 +1,35 @@
+package main
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/danballance/goldfish/internal/engine"
+)
+
+// readRestrictedFlag turns on restricted mode for --restricted or $GOLDFISH_RESTRICTED
+// It is read from the raw arguments so that the engine is restricted before
+// any command is built, whichever way it then runs commands (directly, in a
+// batch or workflow, or for a server).
+func (app *GoldfishApp) readRestrictedFlag() {
+	if app.restrictedRequested() {
+		app.engine.SetRestricted(app.config.AllowedBaseCommands)
+	}
+}
+
+// restrictedRequested reports whether --restricted or $GOLDFISH_RESTRICTED
+// asks for restricted mode
+// initialize also checks it before loading the configuration, so that
+// plugins on PATH are not run to discover their commands.
+func (app *GoldfishApp) restrictedRequested() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv(engine.RestrictedEnvVar))
+	for _, arg := range app.args {
+		if arg == "--" {
+			break
+		}
+		if arg == "--restricted" || arg == "--restricted=true" {
+			enabled = true
+		}
+	}
+	return enabled
+}
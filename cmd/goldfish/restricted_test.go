@@ -0,0 +1,45 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/danballance/goldfish/internal/engine"
+)
+
+// TestGoldfishApp_readRestrictedFlag tests that --restricted and its variable limit the programs run
+func TestGoldfishApp_readRestrictedFlag(t *testing.T) {
+	currentPlatform, err := newLazyTestApp(nil).platformDetector.Current()
+	if err != nil {
+		t.Skipf("unsupported platform: %v", err)
+	}
+	testCases := []struct {
+		args    []string
+		env     string
+		refused bool
+	}{
+		{[]string{"first"}, "", false},
+		{[]string{"--restricted", "first"}, "", true},
+		{[]string{"first"}, "1", true},
+		// Arguments after -- belong to the command
+		{[]string{"first", "--", "--restricted"}, "", false},
+	}
+	for _, tc := range testCases {
+		t.Setenv(engine.RestrictedEnvVar, tc.env)
+		app := newLazyTestApp(tc.args)
+		app.config.AllowedBaseCommands = []string{"sed"}
+		if app.restrictedRequested() != tc.refused {
+			t.Errorf("%v (%s=%s): expected restricted mode %t", tc.args, engine.RestrictedEnvVar, tc.env, tc.refused)
+		}
+		app.readRestrictedFlag()
+
+		// echo is a shell builtin, which is always allowed
+		cmd, _ := app.config.FindCommand("first")
+		cmd.BaseCommand = "cat"
+		err := app.executeCommand(cmd, &cobra.Command{}, nil, currentPlatform)
+		if refused := err != nil && strings.Contains(err.Error(), "restricted mode"); refused != tc.refused {
+			t.Errorf("%v (%s=%s): expected refused=%t, got %v", tc.args, engine.RestrictedEnvVar, tc.env, tc.refused, err)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/danballance/goldfish/internal/batch"
+	"github.com/danballance/goldfish/internal/runbook"
+)
+
+// newRunCommand creates "goldfish run <file>"
+// A runbook is a scripted sequence of goldfish commands with descriptions
+// and pauses for the operator to confirm, kept in its own YAML file
+func (app *GoldfishApp) newRunCommand() *cobra.Command {
+	var varFlags []string
+	var assumeYes bool
+
+	runCmd := &cobra.Command{
+		Use:     "run <file>",
+		Short:   "Run a runbook: a scripted sequence of goldfish commands",
+		Example: "  goldfish run failover.yml\n  goldfish run failover.yml --var region=eu-west-1 --yes",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			book, err := runbook.Load(args[0])
+			if err != nil {
+				return err
+			}
+
+			if err := app.checkPlatform("run"); err != nil {
+				return err
+			}
+
+			vars, err := parseVars(varFlags)
+			if err != nil {
+				return err
+			}
+
+			closeLog, err := app.configureEngine()
+			if err != nil {
+				return err
+			}
+			defer closeLog()
+
+			jobs := batch.NewRunner(app.config, app.engine, app.currentPlatform(), app.timeout())
+			runner := runbook.NewRunner(jobs, cmd.InOrStdin(), cmd.OutOrStdout())
+			results, runErr := runner.Run(book, runbook.Options{Vars: vars, AssumeYes: assumeYes})
+
+			fmt.Fprintln(cmd.OutOrStdout())
+			batch.WriteSummary(cmd.OutOrStdout(), results)
+
+			// The summary already explains what went wrong
+			cmd.SilenceUsage = true
+			if errors.Is(runErr, runbook.ErrDeclined) {
+				return fmt.Errorf("runbook stopped: %w", runErr)
+			}
+
+			// Steps skipped by their condition or after a failure are not failures themselves
+			failures := 0
+			for _, result := range results {
+				if result.Err != nil || (!result.Skipped && result.ExitCode != 0) {
+					failures++
+				}
+			}
+			if failures > 0 {
+				return fmt.Errorf("runbook %s: %d of %d steps failed", args[0], failures, len(results))
+			}
+			return nil
+		},
+	}
+
+	runCmd.Flags().StringArrayVar(&varFlags, "var", nil, "set a runbook variable (name=value); may be repeated")
+	runCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "confirm every pause without asking")
+
+	return runCmd
+}
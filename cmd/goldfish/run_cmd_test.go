@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// TestRunCommand tests running a runbook file from the command line
+func TestRunCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+
+	path := filepath.Join(t.TempDir(), "release.yml")
+	content := "steps:\n  - name: build\n    command: ok\n  - name: publish\n    pause: Publish?\n    command: ok\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := testWorkflowApp().newRunCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetIn(strings.NewReader("n\n"))
+	cmd.SetArgs([]string{path})
+
+	// Declining the pause stops the runbook
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "not confirmed") {
+		t.Errorf("Expected the declined pause to be reported, got %v", err)
+	}
+
+	cmd.SetArgs([]string{path, "--yes"})
+	out.Reset()
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("run --yes failed: %v\n%s", err, out.String())
+	}
+	if !strings.Contains(out.String(), "publish") {
+		t.Errorf("Expected a summary of the steps, got:\n%s", out.String())
+	}
+}
@@ -0,0 +1,350 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/schedule"
+)
+
+// newScheduleCommand creates the built-in "schedule" command group
+// Schedules run goldfish command lines on cron expressions. They are declared
+// once in schedules.yml (e.g. ~/.config/goldfish/schedules.yml) and either
+// run by goldfish schedule run or exported for cron, launchd or Task
+// Scheduler, so the same schedule works on every platform.
+func (app *GoldfishApp) newScheduleCommand() *cobra.Command {
+	scheduleCmd := &cobra.Command{
+		Use:   "schedule",
+		Short: "Run commands on a schedule",
+		Example: "  goldfish schedule add \"0 3 * * *\" backup-home\n  goldfish schedule list\n" +
+			"  goldfish schedule run\n  goldfish schedule export --format cron",
+		Args: cobra.NoArgs,
+		// Without a subcommand the schedules are listed
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return listSchedules(cmd.OutOrStdout())
+		},
+	}
+
+	var name, dir string
+	addCmd := &cobra.Command{
+		Use:   "add <cron> <command> [flags and arguments]",
+		Short: "Schedule a command line, e.g. every day at 03:00 with \"0 3 * * *\"",
+		Long: "Schedule a command line on a cron expression: minute, hour, day of month, month and day of week,\n" +
+			"or @hourly, @daily, @weekly, @monthly or @yearly. The command runs in the current directory\n" +
+			"unless --dir names another.",
+		Example: "  goldfish schedule add \"0 3 * * *\" backup-home\n  goldfish schedule add --name hourly-sync @hourly sync --dest /mnt/backup",
+		Args:    cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Errors are about the schedule, not the command's usage
+			cmd.SilenceUsage = true
+			if !app.isSchedulable(args[1]) {
+				return &config.CommandNotFoundError{Name: args[1]}
+			}
+			s := schedule.Schedule{Name: name, Cron: args[0], Args: args[1:], Dir: dir}
+			if s.Name == "" {
+				s.Name = args[1]
+			}
+			if s.Dir == "" {
+				s.Dir, _ = os.Getwd()
+			} else if absolute, err := filepath.Abs(s.Dir); err == nil {
+				s.Dir = absolute
+			}
+
+			path, file, err := loadSchedules()
+			if err != nil {
+				return err
+			}
+			if err := file.Add(s); err != nil {
+				return err
+			}
+			if err := file.Save(path); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Scheduled %s (%s), next at %s\n", s.Name, s.CommandLine(), formatNextRun(&s, time.Now()))
+			return nil
+		},
+	}
+	addCmd.Flags().StringVar(&name, "name", "", "name of the schedule (default the command's name)")
+	addCmd.Flags().StringVar(&dir, "dir", "", "directory to run the command in (default the current one)")
+	// Flags after the command belong to it, not to schedule add
+	addCmd.Flags().SetInterspersed(false)
+	scheduleCmd.AddCommand(addCmd)
+
+	scheduleCmd.AddCommand(&cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List the schedules and when each runs next",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return listSchedules(cmd.OutOrStdout())
+		},
+	})
+
+	scheduleCmd.AddCommand(&cobra.Command{
+		Use:     "rm <name>",
+		Aliases: []string{"remove"},
+		Short:   "Remove a schedule",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, file, err := loadSchedules()
+			if err != nil {
+				return err
+			}
+			if err := file.Remove(args[0]); err != nil {
+				return err
+			}
+			if err := file.Save(path); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Removed schedule %s\n", args[0])
+			return nil
+		},
+	})
+
+	scheduleCmd.AddCommand(&cobra.Command{
+		Use:   "run",
+		Short: "Run the schedules in the foreground until interrupted",
+		Long: "Run each schedule's command line when it is due, until interrupted. The schedules are read\n" +
+			"again every minute, so changes apply without a restart. Runs missed while the computer\n" +
+			"slept are not made up.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := schedule.Path()
+			if err != nil {
+				return err
+			}
+			// Load once up front so that a broken file is reported right away
+			if _, err := schedule.Load(path); err != nil {
+				return err
+			}
+			stop := make(chan os.Signal, 1)
+			signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+			defer signal.Stop(stop)
+			fmt.Fprintf(cmd.ErrOrStderr(), "Running the schedules in %s; press Ctrl-C to stop\n", path)
+			return runSchedules(cmd.OutOrStdout(), cmd.ErrOrStderr(), path, stop)
+		},
+	})
+
+	var format, program, outDir string
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Write the schedules as crontab lines, launchd agents or Task Scheduler commands",
+		Long: "Write the schedules for the platform's own scheduler, so that they run without goldfish schedule run:\n" +
+			"crontab lines (install them with crontab -e), launchd agents (one .plist file each, written to\n" +
+			"--out-dir, for ~/Library/LaunchAgents) or schtasks commands that create Task Scheduler tasks.",
+		Example: "  goldfish schedule export --format cron\n  goldfish schedule export --format launchd --out-dir ~/Library/LaunchAgents\n" +
+			"  goldfish schedule export --format schtasks > schedules.cmd",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, file, err := loadSchedules()
+			if err != nil {
+				return err
+			}
+			if len(file.Schedules) == 0 {
+				return fmt.Errorf("there are no schedules; goldfish schedule add creates one")
+			}
+			if program == "" {
+				if program, err = goldfishExecutable(); err != nil {
+					return fmt.Errorf("failed to locate goldfish: %w", err)
+				}
+			}
+			return exportSchedules(cmd.OutOrStdout(), file.Schedules, format, program, outDir)
+		},
+	}
+	exportCmd.Flags().StringVar(&format, "format", defaultScheduleFormat(runtime.GOOS),
+		"scheduler to write entries for: "+strings.Join(schedule.Formats, ", "))
+	exportCmd.Flags().StringVar(&program, "program", "", "path of goldfish in the entries (default this goldfish)")
+	exportCmd.Flags().StringVar(&outDir, "out-dir", ".", "directory to write launchd agents to")
+	scheduleCmd.AddCommand(exportCmd)
+
+	return scheduleCmd
+}
+
+// isSchedulable reports whether name is a configured command or alias
+func (app *GoldfishApp) isSchedulable(name string) bool {
+	if _, found := app.config.FindCommand(name); found {
+		return true
+	}
+	for _, alias := range app.config.Aliases {
+		if alias.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// loadSchedules reads the schedules file, returning its path too
+func loadSchedules() (string, *schedule.File, error) {
+	path, err := schedule.Path()
+	if err != nil {
+		return "", nil, err
+	}
+	file, err := schedule.Load(path)
+	if err != nil {
+		return "", nil, err
+	}
+	return path, file, nil
+}
+
+// listSchedules writes a table of the schedules
+func listSchedules(w io.Writer) error {
+	_, file, err := loadSchedules()
+	if err != nil {
+		return err
+	}
+	if len(file.Schedules) == 0 {
+		fmt.Fprintln(w, "No schedules")
+		return nil
+	}
+	now := time.Now()
+	writer := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "NAME\tSCHEDULE\tNEXT RUN\tCOMMAND LINE")
+	for _, s := range file.Schedules {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", s.Name, s.Cron, formatNextRun(&s, now), s.CommandLine())
+	}
+	return writer.Flush()
+}
+
+// formatNextRun says when a schedule is next due after now
+func formatNextRun(s *schedule.Schedule, now time.Time) string {
+	next := s.Next(now)
+	if next.IsZero() {
+		return "never"
+	}
+	return next.Format("2006-01-02 15:04")
+}
+
+// runSchedules runs the schedules in the file at path as they fall due,
+// until a signal arrives on stop; it then waits for the commands running
+// The commands' output goes to out, and what the daemon does to log.
+func runSchedules(out, log io.Writer, path string, stop <-chan os.Signal) error {
+	var running sync.WaitGroup
+	var mu sync.Mutex
+	logf := func(format string, args ...interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Fprintf(log, time.Now().Format("2006-01-02 15:04:05")+" "+format+"\n", args...)
+	}
+
+	var file *schedule.File
+	minute := time.Now().Truncate(time.Minute)
+	for {
+		minute = minute.Add(time.Minute)
+		select {
+		case <-stop:
+			logf("stopping; waiting for the commands running")
+			running.Wait()
+			return nil
+		case <-time.After(time.Until(minute)):
+		}
+
+		// A broken file keeps the schedules that were last read
+		latest, err := schedule.Load(path)
+		if err != nil {
+			logf("%v", err)
+		} else {
+			file = latest
+		}
+		if file == nil {
+			continue
+		}
+		for _, s := range file.Due(minute) {
+			running.Add(1)
+			go func(s schedule.Schedule) {
+				defer running.Done()
+				runScheduled(out, logf, &s)
+			}(s)
+		}
+	}
+}
+
+// runScheduled runs a schedule's command line as a new goldfish process, in
+// the schedule's directory, logging when it starts and how it ends
+func runScheduled(out io.Writer, logf func(string, ...interface{}), s *schedule.Schedule) {
+	program, err := goldfishExecutable()
+	if err != nil {
+		logf("%s: failed to locate goldfish: %v", s.Name, err)
+		return
+	}
+	logf("%s: running %s", s.Name, s.CommandLine())
+	start := time.Now()
+	cmd := exec.Command(program, s.Args...)
+	cmd.Dir = s.Dir
+	cmd.Stdout = out
+	cmd.Stderr = out
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		logf("%s: failed with exit code %d after %v", s.Name, exitErr.ExitCode(), time.Since(start).Round(time.Millisecond))
+	case err != nil:
+		logf("%s: failed to run: %v", s.Name, err)
+	default:
+		logf("%s: finished after %v", s.Name, time.Since(start).Round(time.Millisecond))
+	}
+}
+
+// defaultScheduleFormat returns the native scheduler of a platform
+func defaultScheduleFormat(goos string) string {
+	switch goos {
+	case "darwin":
+		return schedule.FormatLaunchd
+	case "windows":
+		return schedule.FormatSchtasks
+	default:
+		return schedule.FormatCron
+	}
+}
+
+// exportSchedules writes the schedules for a native scheduler: crontab lines
+// and schtasks commands to w, and launchd agents as files in outDir
+func exportSchedules(w io.Writer, schedules []schedule.Schedule, format, program, outDir string) error {
+	switch format {
+	case schedule.FormatCron:
+		fmt.Fprint(w, schedule.Crontab(schedules, program))
+	case schedule.FormatSchtasks:
+		for i := range schedules {
+			command, err := schedule.SchtasksCommand(&schedules[i], program)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(w, command)
+		}
+	case schedule.FormatLaunchd:
+		// Every schedule is checked before any file is written
+		plists := make([][]byte, len(schedules))
+		for i := range schedules {
+			plist, err := schedule.LaunchdPlist(&schedules[i], program)
+			if err != nil {
+				return err
+			}
+			plists[i] = plist
+		}
+		if err := os.MkdirAll(outDir, 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", outDir, err)
+		}
+		for i := range schedules {
+			path := filepath.Join(outDir, schedule.LaunchdLabel(&schedules[i])+".plist")
+			if err := os.WriteFile(path, plists[i], 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", path, err)
+			}
+			fmt.Fprintf(w, "Wrote %s; load it with: launchctl load %s\n", path, path)
+		}
+	default:
+		return fmt.Errorf("unknown format '%s' (expected %s)", format, strings.Join(schedule.Formats, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/danballance/goldfish/internal/schedule"
+)
+
+// runScheduleCommand runs goldfish schedule with args and returns what it printed
+func runScheduleCommand(t *testing.T, app *GoldfishApp, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	cmd := app.newScheduleCommand()
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+// TestScheduleCommand tests adding, listing, exporting and removing schedules
+func TestScheduleCommand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schedules.yml")
+	t.Setenv(schedule.EnvVar, path)
+	app := newLazyTestApp(nil)
+
+	out, err := runScheduleCommand(t, app, "add", "0 3 * * *", "first", "--name", "Ada Lovelace")
+	if err != nil || !strings.Contains(out, "Scheduled first (goldfish first --name 'Ada Lovelace')") {
+		t.Fatalf("Expected the schedule to be added, got %q (%v)", out, err)
+	}
+	// Flags after the command are the command's, while those before are add's
+	if _, err := runScheduleCommand(t, app, "add", "--name", "often", "--dir", "/srv", "*/5 * * * *", "f"); err != nil {
+		t.Fatalf("add --name failed: %v", err)
+	}
+	if _, err := runScheduleCommand(t, app, "add", "@daily", "missing"); err == nil || !strings.Contains(err.Error(), "unknown command") {
+		t.Errorf("Expected an unknown command to be refused, got %v", err)
+	}
+	if _, err := runScheduleCommand(t, app, "add", "0 25 * * *", "second"); err == nil || !strings.Contains(err.Error(), "hour field") {
+		t.Errorf("Expected an invalid expression to be refused, got %v", err)
+	}
+
+	file, err := schedule.Load(path)
+	if err != nil || len(file.Schedules) != 2 {
+		t.Fatalf("Expected 2 schedules in the file, got %+v (%v)", file, err)
+	}
+	if wd, _ := os.Getwd(); file.Schedules[0].Dir != wd || file.Schedules[1].Dir != "/srv" {
+		t.Errorf("Expected the current directory and --dir, got %+v", file.Schedules)
+	}
+
+	out, err = runScheduleCommand(t, app)
+	if err != nil || !strings.Contains(out, "NEXT RUN") || !strings.Contains(out, "often") || !strings.Contains(out, "*/5 * * * *") {
+		t.Errorf("Expected the schedules to be listed, got %q (%v)", out, err)
+	}
+
+	out, err = runScheduleCommand(t, app, "export", "--format", "cron", "--program", "/usr/bin/goldfish")
+	if err != nil || !strings.Contains(out, "*/5 * * * * cd /srv && /usr/bin/goldfish f\n") {
+		t.Errorf("Expected crontab lines, got %q (%v)", out, err)
+	}
+	outDir := t.TempDir()
+	if _, err := runScheduleCommand(t, app, "export", "--format", "launchd", "--program", "goldfish", "--out-dir", outDir); err != nil {
+		t.Errorf("export --format launchd failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "io.goldfish.schedule.often.plist")); err != nil {
+		t.Errorf("Expected a launchd agent to be written: %v", err)
+	}
+	if _, err := runScheduleCommand(t, app, "export", "--format", "systemd"); err == nil || !strings.Contains(err.Error(), "unknown format") {
+		t.Errorf("Expected an unknown format to be refused, got %v", err)
+	}
+
+	if out, err := runScheduleCommand(t, app, "rm", "often"); err != nil || !strings.Contains(out, "Removed schedule often") {
+		t.Errorf("Expected the schedule to be removed, got %q (%v)", out, err)
+	}
+	if file, _ := schedule.Load(path); len(file.Schedules) != 1 {
+		t.Errorf("Expected 1 schedule left, got %+v", file.Schedules)
+	}
+}
+
+// TestRunScheduled tests running a schedule's command line and logging how it ended
+func TestRunScheduled(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses echo in place of goldfish")
+	}
+	// echo stands in for goldfish, printing the arguments it is given
+	goldfishExecutable = func() (string, error) { return "echo", nil }
+	defer func() { goldfishExecutable = os.Executable }()
+
+	var out bytes.Buffer
+	var log []string
+	logf := func(format string, args ...interface{}) { log = append(log, fmt.Sprintf(format, args...)) }
+	runScheduled(&out, logf, &schedule.Schedule{Name: "greet", Args: []string{"greet", "--name", "Ada"}, Dir: t.TempDir()})
+	if out.String() != "greet --name Ada\n" {
+		t.Errorf("Expected the command line to run, got %q", out.String())
+	}
+	if len(log) != 2 || !strings.Contains(log[0], "greet: running goldfish greet --name Ada") || !strings.Contains(log[1], "greet: finished") {
+		t.Errorf("Unexpected log: %q", log)
+	}
+
+	log = nil
+	goldfishExecutable = func() (string, error) { return "false", nil }
+	runScheduled(&out, logf, &schedule.Schedule{Name: "fail", Args: []string{"fail"}})
+	if len(log) != 2 || !strings.Contains(log[1], "fail: failed with exit code 1") {
+		t.Errorf("Expected the failure to be logged, got %q", log)
+	}
+}
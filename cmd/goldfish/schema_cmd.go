@@ -0,0 +1,25 @@
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/spf13/cobra"
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// newSchemaCommand creates the built-in "schema" command
+// It prints the JSON Schema for commands.yml so editors can validate files
+// inline, e.g. with a "# yaml-language-server: $schema=..." comment
+func (app *GoldfishApp) newSchemaCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "schema",
+		Short:   "Print the JSON Schema for commands.yml",
+		Example: "  goldfish schema > goldfish.schema.json",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			encoder := json.NewEncoder(cmd.OutOrStdout())
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(config.Schema())
+		},
+	}
+}
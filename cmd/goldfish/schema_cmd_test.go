@@ -0,0 +1,30 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// TestSchemaCommand tests that goldfish schema prints valid JSON Schema
+func TestSchemaCommand(t *testing.T) {
+	app := newLazyTestApp(nil)
+	app.rootCmd.AddCommand(app.newSchemaCommand())
+
+	var out bytes.Buffer
+	app.rootCmd.SetOut(&out)
+	app.rootCmd.SetArgs([]string{"schema"})
+	if err := app.rootCmd.Execute(); err != nil {
+		t.Fatalf("schema failed: %v", err)
+	}
+
+	var schema map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &schema); err != nil {
+		t.Fatalf("Expected JSON output, got %v", err)
+	}
+	if schema["$id"] != config.SchemaID {
+		t.Errorf("Expected $id %s, got %v", config.SchemaID, schema["$id"])
+	}
+}
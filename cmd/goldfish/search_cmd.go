@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/engine"
+	"github.com/danballance/goldfish/internal/search"
+)
+
+// newSearchCommand creates the built-in "search" command
+// It finds commands by keywords in their names, aliases, tags, base commands,
+// parameters and descriptions, best matches first, and shows the file each
+// one comes from so that it can be found and changed.
+func (app *GoldfishApp) newSearchCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "search <keyword>...",
+		Short:   "Search the configured commands by keyword",
+		Example: "  goldfish search archive\n  goldfish search replace text",
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			currentPlatform := app.currentPlatform()
+			var available []config.Command
+			for _, command := range app.config.Commands {
+				if command.Listed() && engine.Supports(&command, currentPlatform) {
+					available = append(available, command)
+				}
+			}
+			hits := search.Commands(available, strings.Join(args, " "))
+			if len(hits) == 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), "No commands match '%s'\n", strings.Join(args, " "))
+				return nil
+			}
+
+			// Where each command came from is worked out again from the
+			// layers; it only adds to the results, so failing to is not an error
+			var origins map[string]string
+			if layers, err := config.LoadLayers(""); err == nil {
+				origins = config.Origins(layers)
+			}
+
+			writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+			fmt.Fprintln(writer, "COMMAND\tDESCRIPTION\tMATCHED\tSOURCE")
+			for _, hit := range hits {
+				source := origins[hit.Command.Name]
+				if source == "" {
+					source = "-"
+				}
+				fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", hit.Command.Name, hit.Command.Description, strings.Join(hit.Fields, ","), source)
+			}
+			return writer.Flush()
+		},
+	}
+}
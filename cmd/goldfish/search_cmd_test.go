@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestSearchCommand tests searching the configured commands
+func TestSearchCommand(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	run := func(args ...string) string {
+		t.Helper()
+		app := newLazyTestApp(nil)
+		app.config.Commands[0].Description = "Print the first greeting"
+		app.config.Commands[1].Description = "Print another greeting"
+		app.config.Commands[1].Hidden = true
+		app.rootCmd.AddCommand(app.newSearchCommand())
+		var out bytes.Buffer
+		app.rootCmd.SetOut(&out)
+		app.rootCmd.SetArgs(args)
+		if err := app.rootCmd.Execute(); err != nil {
+			t.Fatalf("%v failed: %v", args, err)
+		}
+		return out.String()
+	}
+
+	out := run("search", "greeting")
+	if !strings.Contains(out, "first") || !strings.Contains(out, "description") {
+		t.Errorf("Expected first to match on its description, got:\n%s", out)
+	}
+	// Commands kept out of help are not found either
+	if strings.Contains(out, "second") {
+		t.Errorf("Expected the hidden command to be left out, got:\n%s", out)
+	}
+	if out := run("search", "nothing", "matches"); !strings.Contains(out, "No commands match 'nothing matches'") {
+		t.Errorf("Expected no matches, got:\n%s", out)
+	}
+}
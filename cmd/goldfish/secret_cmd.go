@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/danballance/goldfish/internal/secrets"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// newSecretCommand creates the built-in "secret" command group
+// Secrets live in the OS keyring and are referenced from templates with
+// {{secret "name"}}, so credentials never have to be written into YAML
+func (app *GoldfishApp) newSecretCommand() *cobra.Command {
+	secretCmd := &cobra.Command{
+		Use:   "secret",
+		Short: "Manage secrets stored in the OS keyring",
+		Long: "Manage secrets stored in the OS keyring (macOS Keychain, Linux Secret Service,\n" +
+			"Windows Credential Manager). Templates reference them with {{secret \"name\"}}.",
+	}
+
+	secretCmd.AddCommand(&cobra.Command{
+		Use:   "set <name>",
+		Short: "Store a secret (the value is read from stdin)",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			value, err := readSecretValue(cmd.InOrStdin(), cmd.ErrOrStderr(), args[0])
+			if err != nil {
+				return err
+			}
+			return app.secretStore().Set(args[0], value)
+		},
+	})
+
+	secretCmd.AddCommand(&cobra.Command{
+		Use:   "get <name>",
+		Short: "Print a stored secret",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			value, err := app.secretStore().Get(args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), value)
+			return nil
+		},
+	})
+
+	secretCmd.AddCommand(&cobra.Command{
+		Use:     "rm <name>",
+		Aliases: []string{"remove", "delete"},
+		Short:   "Remove a stored secret",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return app.secretStore().Delete(args[0])
+		},
+	})
+
+	return secretCmd
+}
+
+// secretStore returns the store used by the secret commands
+// It defaults to the OS keyring when none has been injected
+func (app *GoldfishApp) secretStore() secrets.Store {
+	if app.secrets == nil {
+		app.secrets = secrets.NewKeyringStore()
+	}
+	return app.secrets
+}
+
+// readSecretValue reads a secret value without putting it on the command line
+// On a terminal the user is prompted and typing is not echoed; otherwise the
+// first line of stdin is used (e.g. `echo "$TOKEN" | goldfish secret set api`)
+func readSecretValue(in io.Reader, prompt io.Writer, name string) (string, error) {
+	if file, ok := in.(*os.File); ok && term.IsTerminal(int(file.Fd())) {
+		fmt.Fprintf(prompt, "Value for secret '%s': ", name)
+		value, err := term.ReadPassword(int(file.Fd()))
+		fmt.Fprintln(prompt)
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret value: %w", err)
+		}
+		return string(value), nil
+	}
+
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("failed to read secret value: %w", err)
+	}
+	value := strings.TrimRight(line, "\r\n")
+	if value == "" {
+		return "", fmt.Errorf("no secret value provided on stdin")
+	}
+	return value, nil
+}
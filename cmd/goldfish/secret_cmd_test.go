@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/danballance/goldfish/internal/secrets"
+	"github.com/zalando/go-keyring"
+)
+
+// runSecretCommand runs "goldfish secret ..." with the given stdin
+func runSecretCommand(app *GoldfishApp, stdin string, args ...string) (string, error) {
+	cmd := app.newSecretCommand()
+	var out bytes.Buffer
+	cmd.SetIn(strings.NewReader(stdin))
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+// TestSecretCommand tests set, get and rm against an in-memory keyring
+func TestSecretCommand(t *testing.T) {
+	keyring.MockInit()
+	app := &GoldfishApp{secrets: secrets.NewKeyringStore()}
+
+	if _, err := runSecretCommand(app, "s3cr3t\n", "set", "api-token"); err != nil {
+		t.Fatalf("secret set failed: %v", err)
+	}
+
+	out, err := runSecretCommand(app, "", "get", "api-token")
+	if err != nil {
+		t.Fatalf("secret get failed: %v", err)
+	}
+	if out != "s3cr3t\n" {
+		t.Errorf("Expected stored value, got %q", out)
+	}
+
+	if _, err := runSecretCommand(app, "", "rm", "api-token"); err != nil {
+		t.Fatalf("secret rm failed: %v", err)
+	}
+	if _, err := runSecretCommand(app, "", "get", "api-token"); err == nil {
+		t.Error("Expected error getting a removed secret")
+	}
+}
+
+// TestReadSecretValue tests reading secret values from non-terminal input
+func TestReadSecretValue(t *testing.T) {
+	value, err := readSecretValue(strings.NewReader("token\r\nignored"), &bytes.Buffer{}, "x")
+	if err != nil || value != "token" {
+		t.Errorf("Expected 'token', got %q (%v)", value, err)
+	}
+
+	// Value without a trailing newline
+	value, err = readSecretValue(strings.NewReader("token"), &bytes.Buffer{}, "x")
+	if err != nil || value != "token" {
+		t.Errorf("Expected 'token', got %q (%v)", value, err)
+	}
+
+	// Empty input is rejected
+	if _, err := readSecretValue(strings.NewReader(""), &bytes.Buffer{}, "x"); err == nil {
+		t.Error("Expected error for empty input")
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/danballance/goldfish/internal/selfupdate"
+)
+
+// newSelfUpdateCommand creates the built-in "self-update" command
+// It replaces the running binary with the latest GitHub release when that is
+// newer. With --check nothing is installed: the command only reports, and
+// fails when an update is available, so CI can flag machines that are behind.
+func (app *GoldfishApp) newSelfUpdateCommand() *cobra.Command {
+	var check bool
+
+	selfUpdateCmd := &cobra.Command{
+		Use:     "self-update",
+		Short:   "Update goldfish to the latest release",
+		Long:    "Update goldfish to the latest GitHub release of " + selfupdate.DefaultRepository + " (or $" + selfupdate.RepositoryEnvVar + ").\nThe download is checked against the release's checksums, signed by one of your trusted_keys: when any are configured.",
+		Example: "  goldfish self-update\n  goldfish self-update --check",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return app.selfUpdate(cmd, selfupdate.NewUpdater(), check)
+		},
+	}
+
+	selfUpdateCmd.Flags().BoolVar(&check, "check", false, "only report whether an update is available, failing when one is")
+
+	return selfUpdateCmd
+}
+
+// selfUpdate checks for and, unless check is set, installs a newer release
+func (app *GoldfishApp) selfUpdate(cmd *cobra.Command, updater *selfupdate.Updater, check bool) error {
+	release, err := updater.Latest()
+	if err != nil {
+		return err
+	}
+	if !selfupdate.Newer(Version, release.Version()) {
+		fmt.Fprintf(cmd.OutOrStdout(), "goldfish %s is up to date\n", Version)
+		return nil
+	}
+	if check {
+		fmt.Fprintf(cmd.OutOrStdout(), "goldfish %s is available (installed: %s)\n", release.Version(), Version)
+		return fmt.Errorf("an update is available; run goldfish self-update")
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find the goldfish executable: %w", err)
+	}
+	// Replace the file a symlink such as /usr/local/bin/goldfish points to
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	if err := updater.Update(release, exe); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Updated goldfish %s to %s (%s)\n", Version, release.Version(), exe)
+	return nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/danballance/goldfish/internal/selfupdate"
+)
+
+// TestGoldfishApp_selfUpdate_Check tests reporting whether a newer release exists
+func TestGoldfishApp_selfUpdate_Check(t *testing.T) {
+	latest := Version
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"tag_name": "v%s", "assets": []}`, latest)
+	}))
+	defer server.Close()
+	updater := selfupdate.NewUpdaterFor(server.URL, "acme/goldfish")
+
+	run := func() (string, error) {
+		cmd := &cobra.Command{}
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		err := newLazyTestApp(nil).selfUpdate(cmd, updater, true)
+		return out.String(), err
+	}
+
+	if out, err := run(); err != nil || !strings.Contains(out, "is up to date") {
+		t.Errorf("Expected the current release to be up to date, got %v: %s", err, out)
+	}
+	latest = "99.0.0"
+	if out, err := run(); err == nil || !strings.Contains(out, "goldfish 99.0.0 is available") {
+		t.Errorf("Expected --check to fail with a newer release, got %v: %s", err, out)
+	}
+}
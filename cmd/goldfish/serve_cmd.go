@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/danballance/goldfish/internal/rpc"
+	"google.golang.org/grpc"
+)
+
+// DefaultServeAddr is the address "goldfish serve" listens on by default
+// It binds to localhost only, because profiling data can leak sensitive details
+const DefaultServeAddr = "127.0.0.1:7878"
+
+// DefaultGRPCAddr is the address the gRPC API listens on with --grpc
+// Like the HTTP endpoints it is localhost only: clients can run commands
+const DefaultGRPCAddr = "127.0.0.1:7879"
+
+// newServeCommand creates "goldfish serve"
+// It runs goldfish as a long-lived service exposing its internal metrics
+// (and, with --pprof, Go profiling endpoints) for operators to monitor
+func (app *GoldfishApp) newServeCommand() *cobra.Command {
+	var addr string
+	var enablePprof bool
+	var enableGRPC bool
+	var grpcAddr string
+
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run goldfish as a service with monitoring endpoints",
+		Long: "Run goldfish as a long-lived service.\n\n" +
+			"Metrics are available at /metrics (Prometheus text format, with counters and\n" +
+			"duration histograms per command) and /debug/vars (expvar JSON), and a health\n" +
+			"check at /healthz.\n" +
+			"With --pprof, Go profiling endpoints are served under /debug/pprof/.\n" +
+			"With --grpc, the gRPC API (list, render and execute commands with streamed\n" +
+			"output) is served on --grpc-addr as well.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			server := &http.Server{
+				Addr:              addr,
+				Handler:           newServeMux(enablePprof),
+				ReadHeaderTimeout: 10 * time.Second,
+			}
+
+			// Start the gRPC API first so a bad address fails before anything is served
+			var grpcServer *grpc.Server
+			if enableGRPC {
+				if err := app.checkPlatform("serve --grpc"); err != nil {
+					return err
+				}
+				listener, err := net.Listen("tcp", grpcAddr)
+				if err != nil {
+					return fmt.Errorf("failed to listen for gRPC: %w", err)
+				}
+				closeLog, err := app.configureEngine()
+				if err != nil {
+					listener.Close()
+					return err
+				}
+				defer closeLog()
+
+				grpcServer = grpc.NewServer()
+				rpc.NewServer(app.config, app.engine, app.currentPlatform(), app.timeout()).Register(grpcServer)
+				defer grpcServer.Stop()
+				go func() {
+					_ = grpcServer.Serve(listener)
+				}()
+				fmt.Fprintf(cmd.OutOrStdout(), "goldfish gRPC API on %s\n", listener.Addr())
+			}
+
+			// Shut down cleanly on Ctrl-C or SIGTERM
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			go func() {
+				<-ctx.Done()
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				_ = server.Shutdown(shutdownCtx)
+				if grpcServer != nil {
+					grpcServer.GracefulStop()
+				}
+			}()
+
+			fmt.Fprintf(cmd.OutOrStdout(), "goldfish serving on http://%s\n", addr)
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				return fmt.Errorf("server failed: %w", err)
+			}
+			return nil
+		},
+	}
+
+	serveCmd.Flags().StringVar(&addr, "addr", DefaultServeAddr, "address to listen on")
+	serveCmd.Flags().BoolVar(&enablePprof, "pprof", false, "expose Go profiling endpoints under /debug/pprof/")
+	serveCmd.Flags().BoolVar(&enableGRPC, "grpc", false, "also serve the gRPC API for listing, rendering and executing commands")
+	serveCmd.Flags().StringVar(&grpcAddr, "grpc-addr", DefaultGRPCAddr, "address the gRPC API listens on")
+
+	return serveCmd
+}
+
+// newServeMux builds the HTTP routes served by "goldfish serve"
+func newServeMux(enablePprof bool) *http.ServeMux {
+	mux := http.NewServeMux()
+
+	// Simple liveness check for load balancers and supervisors
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+
+	// expvar publishes the engine and config counters as JSON
+	mux.Handle("/debug/vars", expvar.Handler())
+
+	// Prometheus scrapes the engine metrics, per command, from /metrics
+	mux.HandleFunc("/metrics", prometheusHandler)
+
+	// Profiling is opt-in; the pprof package registers on the default mux,
+	// so its handlers are wired up explicitly here instead
+	if enablePprof {
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
+
+	return mux
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestNewServeMux tests the monitoring routes
+func TestNewServeMux(t *testing.T) {
+	server := httptest.NewServer(newServeMux(false))
+	defer server.Close()
+
+	// Health check
+	resp, err := http.Get(server.URL + "/healthz")
+	if err != nil {
+		t.Fatalf("GET /healthz failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected /healthz to return 200, got %d", resp.StatusCode)
+	}
+
+	// Metrics include the engine and config counters
+	resp, err = http.Get(server.URL + "/debug/vars")
+	if err != nil {
+		t.Fatalf("GET /debug/vars failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read /debug/vars: %v", err)
+	}
+	for _, expected := range []string{"goldfish_engine", "goldfish_config", "executions"} {
+		if !strings.Contains(string(body), expected) {
+			t.Errorf("Expected /debug/vars to contain %q", expected)
+		}
+	}
+
+	// pprof is disabled by default
+	resp, err = http.Get(server.URL + "/debug/pprof/")
+	if err != nil {
+		t.Fatalf("GET /debug/pprof/ failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected pprof to be disabled, got status %d", resp.StatusCode)
+	}
+}
+
+// TestNewServeMux_Pprof tests that --pprof enables the profiling routes
+func TestNewServeMux_Pprof(t *testing.T) {
+	server := httptest.NewServer(newServeMux(true))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/debug/pprof/")
+	if err != nil {
+		t.Fatalf("GET /debug/pprof/ failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected pprof index to return 200, got %d", resp.StatusCode)
+	}
+}
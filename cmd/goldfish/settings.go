@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/danballance/goldfish/internal/engine"
+	"github.com/danballance/goldfish/internal/output"
+	"github.com/danballance/goldfish/internal/settings"
+)
+
+// newPrinter creates the printer for errors and warnings
+// --no-color and NO_COLOR win over the color setting, which can otherwise
+// turn colors off, or on even when stderr is not a terminal.
+func newPrinter(args []string, s *settings.Settings) *output.Printer {
+	switch {
+	case noColorRequested(args) || s.Color == settings.ColorNever:
+		return output.New(os.Stderr, true)
+	case s.Color == settings.ColorAlways && os.Getenv(output.NoColorEnvVar) == "":
+		return output.NewColored(os.Stderr, true)
+	default:
+		return output.New(os.Stderr, false)
+	}
+}
+
+// timeout returns how long commands may run: the timeout setting, or DefaultTimeout
+func (app *GoldfishApp) timeout() time.Duration {
+	return app.settings.TimeoutOr(DefaultTimeout)
+}
+
+// confirmRun asks before running a command when the confirm setting calls for it
+// The prompt shows the command line with secrets masked. Anything but y or
+// yes declines, including the end of input, so scripts never run it unasked.
+func (app *GoldfishApp) confirmRun(cobraCmd *cobra.Command, ctx *engine.ExecutionContext) error {
+	policy := settings.ConfirmNever
+	if app.settings != nil && app.settings.Confirm != "" {
+		policy = app.settings.Confirm
+	}
+	if policy == settings.ConfirmNever || (policy == settings.ConfirmDestructive && !ctx.Command.Destructive) {
+		return nil
+	}
+
+	rendered, err := app.engine.Preview(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(cobraCmd.ErrOrStderr(), "Run: %s\nContinue? [y/N] ", rendered)
+	answer, complete := readLine(cobraCmd.InOrStdin())
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+	if !complete {
+		fmt.Fprintln(cobraCmd.ErrOrStderr())
+	}
+	// Declining is an answer, not a usage mistake
+	cobraCmd.SilenceUsage = true
+	return fmt.Errorf("'%s' was not confirmed (confirm: %s)", ctx.Command.Name, policy)
+}
+
+// readLine reads one line a byte at a time, leaving the rest of the input
+// for the command that is about to run; complete is false when the input
+// ended before a newline
+func readLine(r io.Reader) (line string, complete bool) {
+	var read []byte
+	buf := make([]byte, 1)
+	for {
+		n, err := r.Read(buf)
+		if n == 1 {
+			if buf[0] == '\n' {
+				return string(read), true
+			}
+			read = append(read, buf[0])
+		}
+		if err != nil {
+			return string(read), false
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/danballance/goldfish/internal/engine"
+	"github.com/danballance/goldfish/internal/settings"
+)
+
+// TestGoldfishApp_timeout tests that the timeout setting replaces the default
+func TestGoldfishApp_timeout(t *testing.T) {
+	app := &GoldfishApp{}
+	if app.timeout() != DefaultTimeout {
+		t.Errorf("Expected the default timeout without settings, got %v", app.timeout())
+	}
+	app.settings = &settings.Settings{Timeout: time.Minute}
+	if app.timeout() != time.Minute {
+		t.Errorf("Expected the timeout setting, got %v", app.timeout())
+	}
+}
+
+// TestGoldfishApp_confirmRun tests asking before commands under each confirm policy
+func TestGoldfishApp_confirmRun(t *testing.T) {
+	app := newLazyTestApp(nil)
+	command := app.config.Commands[0]
+	ctx := &engine.ExecutionContext{Command: &command, Platform: app.currentPlatform(), Parameters: map[string]interface{}{}}
+
+	confirm := func(policy, input string) (string, error) {
+		app.settings = &settings.Settings{Confirm: policy}
+		cmd := &cobra.Command{}
+		var stderr bytes.Buffer
+		cmd.SetErr(&stderr)
+		cmd.SetIn(strings.NewReader(input))
+		err := app.confirmRun(cmd, ctx)
+		return stderr.String(), err
+	}
+
+	if prompt, err := confirm(settings.ConfirmNever, ""); err != nil || prompt != "" {
+		t.Errorf("Expected no prompt, got %q (%v)", prompt, err)
+	}
+	if prompt, err := confirm(settings.ConfirmDestructive, ""); err != nil || prompt != "" {
+		t.Errorf("Expected no prompt for a safe command, got %q (%v)", prompt, err)
+	}
+	prompt, err := confirm(settings.ConfirmAlways, "y\n")
+	if err != nil || !strings.Contains(prompt, "Run: echo") || !strings.Contains(prompt, "[y/N]") {
+		t.Errorf("Expected a confirmed prompt, got %q (%v)", prompt, err)
+	}
+	if _, err := confirm(settings.ConfirmAlways, ""); err == nil || !strings.Contains(err.Error(), "not confirmed") {
+		t.Errorf("Expected the end of input to decline, got %v", err)
+	}
+
+	command.Destructive = true
+	if _, err := confirm(settings.ConfirmDestructive, "no\n"); err == nil {
+		t.Error("Expected a destructive command to need confirmation")
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// maxSuggestionDistance is the largest edit distance still offered as a suggestion
+	maxSuggestionDistance = 2
+	// maxSuggestions limits how many suggestions are printed
+	maxSuggestions = 3
+)
+
+var (
+	// unknownCommandPattern matches Cobra's error for an unknown command
+	unknownCommandPattern = regexp.MustCompile(`^unknown command "([^"]+)"`)
+	// unknownFlagPattern matches pflag's error for an unknown long flag
+	unknownFlagPattern = regexp.MustCompile(`^unknown flag: --([^\s=]+)`)
+)
+
+// addSuggestions extends unknown command and unknown flag errors with the closest matches
+// cmd is the command Cobra was running when the error happened. Other errors
+// are returned unchanged.
+func (app *GoldfishApp) addSuggestions(cmd *cobra.Command, err error) error {
+	if match := unknownCommandPattern.FindStringSubmatch(err.Error()); match != nil {
+		suggestions := closestMatches(match[1], app.commandNames())
+		if len(suggestions) == 0 {
+			return fmt.Errorf("%w\nRun 'goldfish --help' for usage", err)
+		}
+		return fmt.Errorf("%w\n\nDid you mean this?\n\t%s", err, strings.Join(suggestions, "\n\t"))
+	}
+
+	if match := unknownFlagPattern.FindStringSubmatch(err.Error()); match != nil && cmd != nil {
+		suggestions := closestMatches(match[1], flagNames(cmd))
+		if len(suggestions) == 0 {
+			return err
+		}
+		for i, name := range suggestions {
+			suggestions[i] = "--" + name
+		}
+		return fmt.Errorf("%w\n\nDid you mean this?\n\t%s", err, strings.Join(suggestions, "\n\t"))
+	}
+
+	return err
+}
+
+// commandNames lists every command name and alias a user could have meant
+// This covers the configured commands and goldfish's built-in commands
+func (app *GoldfishApp) commandNames() []string {
+	var names []string
+	if app.config != nil {
+		// Commands kept out of help are not suggested either
+		for _, name := range app.config.GetCommandNames() {
+			if cmd, found := app.config.FindCommand(name); found && cmd.Listed() {
+				names = append(names, name)
+			}
+		}
+	}
+	if app.rootCmd != nil {
+		for _, cmd := range app.rootCmd.Commands() {
+			if cmd.Hidden {
+				continue
+			}
+			names = append(names, cmd.Name())
+			names = append(names, cmd.Aliases...)
+		}
+	}
+	return names
+}
+
+// flagNames lists the long flag names accepted by a command, including inherited ones
+func flagNames(cmd *cobra.Command) []string {
+	var names []string
+	collect := func(flag *pflag.Flag) {
+		if !flag.Hidden {
+			names = append(names, flag.Name)
+		}
+	}
+	cmd.LocalFlags().VisitAll(collect)
+	cmd.InheritedFlags().VisitAll(collect)
+	return names
+}
+
+// closestMatches returns the candidates closest to input, best match first
+// Only candidates within maxSuggestionDistance edits are returned.
+func closestMatches(input string, candidates []string) []string {
+	type match struct {
+		name     string
+		distance int
+	}
+
+	seen := make(map[string]bool)
+	var matches []match
+	for _, candidate := range candidates {
+		if seen[candidate] || candidate == input {
+			continue
+		}
+		seen[candidate] = true
+
+		distance := levenshtein(strings.ToLower(input), strings.ToLower(candidate))
+		if distance <= maxSuggestionDistance {
+			matches = append(matches, match{candidate, distance})
+		}
+	}
+
+	// Closest first; ties are broken alphabetically so output is stable
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].name < matches[j].name
+	})
+
+	var names []string
+	for i := 0; i < len(matches) && i < maxSuggestions; i++ {
+		names = append(names, matches[i].name)
+	}
+	return names
+}
+
+// levenshtein returns the edit distance between two strings: the number of
+// single-character insertions, deletions and substitutions turning a into b
+func levenshtein(a, b string) int {
+	source := []rune(a)
+	target := []rune(b)
+
+	// previous holds the distances for the previous row of the DP table
+	previous := make([]int, len(target)+1)
+	current := make([]int, len(target)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(source); i++ {
+		current[0] = i
+		for j := 1; j <= len(target); j++ {
+			cost := 1
+			if source[i-1] == target[j-1] {
+				cost = 0
+			}
+			current[j] = min(
+				previous[j]+1,      // deletion
+				current[j-1]+1,     // insertion
+				previous[j-1]+cost, // substitution
+			)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(target)]
+}
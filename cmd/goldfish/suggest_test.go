@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// TestLevenshtein tests the edit distance calculation
+func TestLevenshtein(t *testing.T) {
+	testCases := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"replace", "replace", 0},
+		{"replce", "replace", 1},
+		{"rpelace", "replace", 2},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+	}
+
+	for _, tc := range testCases {
+		if got := levenshtein(tc.a, tc.b); got != tc.expected {
+			t.Errorf("levenshtein(%q, %q): expected %d, got %d", tc.a, tc.b, tc.expected, got)
+		}
+	}
+}
+
+// TestClosestMatches tests ordering and limits of suggestions
+func TestClosestMatches(t *testing.T) {
+	candidates := []string{"replace", "rp", "find", "grep", "replace", "place"}
+
+	got := closestMatches("replce", candidates)
+	if fmt.Sprint(got) != "[replace]" {
+		t.Errorf("Expected [replace], got %v", got)
+	}
+
+	// Closest first, duplicates removed
+	got = closestMatches("grp", candidates)
+	if fmt.Sprint(got) != "[grep rp]" {
+		t.Errorf("Expected [grep rp], got %v", got)
+	}
+
+	if got := closestMatches("zzzzzz", candidates); len(got) != 0 {
+		t.Errorf("Expected no suggestions, got %v", got)
+	}
+}
+
+// TestAddSuggestions tests extending Cobra errors with suggestions
+func TestAddSuggestions(t *testing.T) {
+	app := &GoldfishApp{
+		config: &config.Config{
+			Commands: []config.Command{{Name: "replace-in-file", Alias: "replace"}},
+		},
+		rootCmd: &cobra.Command{Use: "goldfish"},
+	}
+	app.rootCmd.AddCommand(&cobra.Command{Use: "batch"})
+
+	err := app.addSuggestions(nil, errors.New(`unknown command "replce" for "goldfish"`))
+	if !strings.Contains(err.Error(), "Did you mean this?\n\treplace") {
+		t.Errorf("Expected command suggestion, got %v", err)
+	}
+
+	err = app.addSuggestions(nil, errors.New(`unknown command "bach" for "goldfish"`))
+	if !strings.Contains(err.Error(), "\tbatch") {
+		t.Errorf("Expected built-in command suggestion, got %v", err)
+	}
+
+	// Flags are suggested from the failing command, including inherited flags
+	cmd := &cobra.Command{Use: "replace"}
+	cmd.Flags().Bool("in-place", false, "")
+	app.rootCmd.PersistentFlags().Bool("verbose", false, "")
+	app.rootCmd.AddCommand(cmd)
+
+	err = app.addSuggestions(cmd, errors.New("unknown flag: --inplace"))
+	if !strings.Contains(err.Error(), "\t--in-place") {
+		t.Errorf("Expected flag suggestion, got %v", err)
+	}
+	err = app.addSuggestions(cmd, errors.New("unknown flag: --verbos"))
+	if !strings.Contains(err.Error(), "\t--verbose") {
+		t.Errorf("Expected inherited flag suggestion, got %v", err)
+	}
+
+	// Other errors are unchanged
+	original := errors.New("something else")
+	if app.addSuggestions(cmd, original) != original {
+		t.Error("Expected unrelated error to be returned unchanged")
+	}
+}
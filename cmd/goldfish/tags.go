@@ -0,0 +1,32 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// addConfiguredCommand registers a command of the configuration (or its stub)
+// on the root command, in the help group of its first tag
+// Cobra lists each group under its own heading, and commands without tags
+// under "Additional Commands" with the built-ins.
+func (app *GoldfishApp) addConfiguredCommand(cobraCmd *cobra.Command, cmd *config.Command) {
+	if len(cmd.Tags) > 0 {
+		tag := cmd.Tags[0]
+		if !app.rootCmd.ContainsGroup(tag) {
+			app.rootCmd.AddGroup(&cobra.Group{ID: tag, Title: tagTitle(tag)})
+		}
+		cobraCmd.GroupID = tag
+	}
+	app.rootCmd.AddCommand(cobraCmd)
+}
+
+// tagTitle returns the help heading of a tag's group, e.g. "Network Commands:"
+func tagTitle(tag string) string {
+	words := strings.FieldsFunc(tag, func(r rune) bool { return r == '-' || r == '_' })
+	for i, word := range words {
+		words[i] = strings.ToUpper(word[:1]) + word[1:]
+	}
+	return strings.Join(words, " ") + " Commands:"
+}
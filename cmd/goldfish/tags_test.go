@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestGoldfishApp_generateCommands_Tags tests grouping commands in help by their first tag
+func TestGoldfishApp_generateCommands_Tags(t *testing.T) {
+	app := newLazyTestApp(nil)
+	app.config.Commands[0].Tags = []string{"network-tools", "files"}
+	if err := app.generateCommands(); err != nil {
+		t.Fatalf("generateCommands() failed: %v", err)
+	}
+	first, _, err := app.rootCmd.Find([]string{"first"})
+	if err != nil || first.GroupID != "network-tools" {
+		t.Fatalf("Expected first in the network-tools group, got %v (%v)", first.GroupID, err)
+	}
+
+	var out bytes.Buffer
+	app.rootCmd.SetOut(&out)
+	app.rootCmd.SetArgs([]string{"--help"})
+	if err := app.rootCmd.Execute(); err != nil {
+		t.Fatalf("help failed: %v", err)
+	}
+	help := out.String()
+	grouped := strings.Index(help, "Network Tools Commands:")
+	additional := strings.Index(help, "Additional Commands:")
+	if grouped < 0 || additional < grouped || !strings.Contains(help[grouped:additional], "first") || !strings.Contains(help[additional:], "second") {
+		t.Errorf("Expected first under its tag and second under Additional Commands, got:\n%s", help)
+	}
+
+	// The full command keeps the group of the stub it replaces
+	if full := app.materialize(first); full.GroupID != "network-tools" {
+		t.Errorf("Expected the materialized command in its group, got %q", full.GroupID)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// OTelEndpointEnvVar names the OpenTelemetry collector that command
+// executions are traced to (see internal/engine/telemetry.go)
+const OTelEndpointEnvVar = "GOLDFISH_OTEL_ENDPOINT"
+
+// otelTracesPath is where OTLP over HTTP receives traces
+const otelTracesPath = "/v1/traces"
+
+// otelTimeout bounds each export, so a collector that is down delays
+// goldfish by seconds at most, rather than the exporter's minute of retries
+const otelTimeout = 5 * time.Second
+
+// startTelemetry exports the engine's spans over OTLP/HTTP when
+// $GOLDFISH_OTEL_ENDPOINT names a collector, e.g. http://localhost:4318
+// The exporter's other settings, such as headers for authentication, come
+// from the standard OTEL_EXPORTER_OTLP_* variables. A bad endpoint is a
+// warning: tracing is for observing commands, not a reason to stop them.
+func (app *GoldfishApp) startTelemetry() {
+	endpoint := os.Getenv(OTelEndpointEnvVar)
+	if endpoint == "" {
+		return
+	}
+	target, err := otelTracesURL(endpoint)
+	if err != nil {
+		fmt.Fprintf(config.Warnings, "Warning: tracing is off: %v\n", err)
+		return
+	}
+
+	exporter, err := otlptracehttp.New(context.Background(),
+		otlptracehttp.WithEndpointURL(target),
+		otlptracehttp.WithTimeout(otelTimeout),
+		otlptracehttp.WithRetry(otlptracehttp.RetryConfig{Enabled: false}))
+	if err != nil {
+		fmt.Fprintf(config.Warnings, "Warning: tracing is off: %v\n", err)
+		return
+	}
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES can rename goldfish
+	// or add to what is known about it, e.g. the deployment environment
+	serviceResource, err := resource.New(context.Background(),
+		resource.WithAttributes(attribute.String("service.name", "goldfish"), attribute.String("service.version", Version)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK())
+	if err != nil {
+		fmt.Fprintf(config.Warnings, "Warning: tracing: %v\n", err)
+	}
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(serviceResource))
+	otel.SetTracerProvider(provider)
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		fmt.Fprintf(config.Warnings, "Warning: tracing: %v\n", err)
+	}))
+	app.stopTelemetry = func() {
+		ctx, cancel := context.WithTimeout(context.Background(), otelTimeout)
+		defer cancel()
+		if err := provider.Shutdown(ctx); err != nil {
+			fmt.Fprintf(config.Warnings, "Warning: tracing: %v\n", err)
+		}
+	}
+}
+
+// otelTracesURL returns the URL traces are posted to for an endpoint: the
+// collector's address, to which /v1/traces is added, or the full URL
+func otelTracesURL(endpoint string) (string, error) {
+	parsed, err := url.Parse(endpoint)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", fmt.Errorf("%s must be an http or https URL such as http://localhost:4318, got '%s'", OTelEndpointEnvVar, endpoint)
+	}
+	if strings.Trim(parsed.Path, "/") == "" {
+		parsed.Path = otelTracesPath
+	}
+	return parsed.String(), nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/engine"
+	"github.com/danballance/goldfish/internal/platform"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// TestOTelTracesURL tests where traces are sent for an endpoint
+func TestOTelTracesURL(t *testing.T) {
+	testCases := map[string]string{
+		"http://localhost:4318":                   "http://localhost:4318/v1/traces",
+		"https://otel.example.com/":               "https://otel.example.com/v1/traces",
+		"https://otel.example.com/otlp/v1/traces": "https://otel.example.com/otlp/v1/traces",
+	}
+	for endpoint, expected := range testCases {
+		if target, err := otelTracesURL(endpoint); err != nil || target != expected {
+			t.Errorf("%s: expected %s, got %s (%v)", endpoint, expected, target, err)
+		}
+	}
+	if _, err := otelTracesURL("localhost:4318"); err == nil || !strings.Contains(err.Error(), OTelEndpointEnvVar) {
+		t.Errorf("Expected an endpoint without a scheme to be rejected, got %v", err)
+	}
+}
+
+// TestStartTelemetry tests that executions are exported to the collector
+// when goldfish finishes
+func TestStartTelemetry(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+	}))
+	defer server.Close()
+	t.Setenv(OTelEndpointEnvVar, server.URL)
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	app := &GoldfishApp{}
+	app.startTelemetry()
+	if app.stopTelemetry == nil {
+		t.Fatal("Expected tracing to be on")
+	}
+	cmd := &config.Command{Name: "hello", BaseCommand: "echo", Platforms: map[string]config.PlatformCommand{
+		"linux": {Template: "echo hello"}, "darwin": {Template: "echo hello"}, "windows": {Template: "echo hello"},
+	}}
+	engine.NewEngine(30*time.Second).Execute(context.Background(), &engine.ExecutionContext{Command: cmd, Platform: platform.Linux,
+		Parameters: map[string]interface{}{}, Capture: true})
+	app.finishTrace()
+	if len(paths) != 1 || paths[0] != "/v1/traces" {
+		t.Errorf("Expected the spans to be posted to /v1/traces, got %v", paths)
+	}
+}
+
+// TestStartTelemetry_BadEndpoint tests that a bad endpoint leaves tracing off with a warning
+func TestStartTelemetry_BadEndpoint(t *testing.T) {
+	var warnings bytes.Buffer
+	config.Warnings = &warnings
+	defer func() { config.Warnings = os.Stderr }()
+	t.Setenv(OTelEndpointEnvVar, "localhost:4318")
+
+	app := &GoldfishApp{}
+	app.startTelemetry()
+	if app.stopTelemetry != nil || !strings.Contains(warnings.String(), "Warning: tracing is off") {
+		t.Errorf("Expected tracing to be off with a warning, got %q", warnings.String())
+	}
+}
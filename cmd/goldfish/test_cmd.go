@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/engine"
+	"github.com/danballance/goldfish/internal/templatetest"
+)
+
+// newTestCommand creates the built-in "test" command
+// It renders the tests: of every command on the platforms they name and
+// compares the command lines with those expected. Nothing is executed, so
+// it is safe to run in CI; any failure fails the command. When the golden
+// directory exists, every command is also compared with its golden file;
+// --update-golden writes them instead.
+func (app *GoldfishApp) newTestCommand() *cobra.Command {
+	var only string
+	var verbose bool
+	var goldenDir string
+	var updateGolden bool
+
+	testCmd := &cobra.Command{
+		Use:     "test [file]",
+		Short:   "Check that command templates render as their tests expect",
+		Example: "  goldfish test\n  goldfish test --command replace commands.yml\n  goldfish test --update-golden --golden-dir testdata/goldens",
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := app.config
+			if len(args) == 1 {
+				loaded, err := config.NewLoader(args[0]).Load()
+				if err != nil {
+					return err
+				}
+				cfg = loaded
+				// The file's own fallbacks decide which templates are used
+				engine.SetFallbackPlatforms(cfg.FallbackPlatforms)
+			}
+
+			runner := templatetest.NewRunner(cfg)
+			results, err := runner.Run(only)
+			if err != nil {
+				return err
+			}
+
+			if updateGolden {
+				written, err := runner.UpdateGolden(goldenDir, only)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "%d golden files written to %s\n", len(written), goldenDir)
+			} else if _, err := os.Stat(goldenDir); err == nil {
+				golden, err := runner.CheckGolden(goldenDir, only)
+				if err != nil {
+					return err
+				}
+				results = append(results, golden...)
+			}
+
+			failed := writeTestResults(cmd, results, verbose)
+			if len(results) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "no tests defined")
+				return nil
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%d passed, %d failed\n", len(results)-failed, failed)
+			if failed > 0 {
+				return fmt.Errorf("%d of %d template tests failed", failed, len(results))
+			}
+			return nil
+		},
+	}
+
+	testCmd.Flags().StringVar(&only, "command", "", "only run the tests of this command")
+	testCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "list passing tests too")
+	testCmd.Flags().StringVar(&goldenDir, "golden-dir", templatetest.DefaultGoldenDir, "directory of the golden files every command is compared with")
+	testCmd.Flags().BoolVar(&updateGolden, "update-golden", false, "write the golden files from what commands render now")
+
+	return testCmd
+}
+
+// writeTestResults prints the failed tests, with what was expected and
+// rendered, and the passed ones when verbose; it returns the number failed
+func writeTestResults(cmd *cobra.Command, results []templatetest.Result, verbose bool) int {
+	out := cmd.OutOrStdout()
+	failed := 0
+	for _, result := range results {
+		if !result.Failed() {
+			if verbose {
+				fmt.Fprintf(out, "ok   %s\n", result.Name())
+			}
+			continue
+		}
+		failed++
+		fmt.Fprintf(out, "FAIL %s\n", result.Name())
+		if result.Err != nil {
+			fmt.Fprintf(out, "     error:    %v\n", result.Err)
+			continue
+		}
+		fmt.Fprintf(out, "     expected: %s\n", result.Expected)
+		fmt.Fprintf(out, "     rendered: %s\n", result.Rendered)
+	}
+	return failed
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// TestTestCommand tests running the template tests of the loaded configuration
+func TestTestCommand(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expect   string
+		args     []string
+		fails    bool
+		expected []string
+	}{
+		{"passes", "echo", nil, false, []string{"1 passed, 0 failed"}},
+		{"verbose", "echo", []string{"-v"}, false, []string{"ok   first/example (linux)"}},
+		{"fails", "echo hi", nil, true, []string{"FAIL first/example (linux)", "expected: echo hi", "rendered: echo", "0 passed, 1 failed"}},
+		{"other command", "echo hi", []string{"--command", "second"}, false, []string{"no tests defined"}},
+	}
+	for _, tc := range testCases {
+		app := newLazyTestApp(nil)
+		app.config.Commands[0].Tests = []config.TemplateTest{{Name: "example", Expect: map[string]string{"linux": tc.expect}}}
+
+		cmd := app.newTestCommand()
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs(tc.args)
+		err := cmd.Execute()
+		if (err != nil) != tc.fails {
+			t.Errorf("%s: expected failure %v, got %v", tc.name, tc.fails, err)
+		}
+		for _, expected := range tc.expected {
+			if !strings.Contains(out.String(), expected) {
+				t.Errorf("%s: expected output to contain %q, got:\n%s", tc.name, expected, out.String())
+			}
+		}
+	}
+}
+
+// TestTestCommand_Golden tests writing golden files and comparing with them
+func TestTestCommand_Golden(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "goldens")
+	run := func(app *GoldfishApp, args ...string) (string, error) {
+		cmd := app.newTestCommand()
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs(append([]string{"--golden-dir", dir}, args...))
+		err := cmd.Execute()
+		return out.String(), err
+	}
+
+	out, err := run(newLazyTestApp(nil), "--update-golden")
+	if err != nil || !strings.Contains(out, "2 golden files written") {
+		t.Fatalf("Expected golden files to be written, got %v:\n%s", err, out)
+	}
+	out, err = run(newLazyTestApp(nil))
+	if err != nil || !strings.Contains(out, "6 passed, 0 failed") {
+		t.Errorf("Expected every golden case to pass, got %v:\n%s", err, out)
+	}
+
+	// A changed template fails until the golden files are updated
+	app := newLazyTestApp(nil)
+	app.config.Commands[0].Platforms = map[string]config.PlatformCommand{"linux": {Template: "echo changed"}}
+	out, err = run(app)
+	if err == nil || !strings.Contains(out, "FAIL first/example (linux, golden)") {
+		t.Errorf("Expected the changed template to fail, got %v:\n%s", err, out)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/trace"
+)
+
+// startTrace turns on --trace and --cpu-profile, read from the raw arguments
+// because loading the configuration, which happens before Cobra parses the
+// flags, is the first phase timed
+func (app *GoldfishApp) startTrace() error {
+	if traceRequested(app.args) {
+		app.tracer = trace.New()
+		app.engine.SetTrace(app.tracer)
+	}
+	if values := app.rawFlagValues("cpu-profile"); len(values) > 0 {
+		stop, err := trace.StartCPUProfile(values[len(values)-1])
+		if err != nil {
+			return err
+		}
+		app.stopProfile = stop
+	}
+	return nil
+}
+
+// finishTrace prints the phase timings to stderr, completes the CPU profile
+// and exports the last OpenTelemetry spans; it is called on every way out
+// of goldfish, and only acts once
+func (app *GoldfishApp) finishTrace() {
+	if app.tracer != nil {
+		app.tracer.Write(os.Stderr)
+		app.tracer = nil
+	}
+	if app.stopProfile != nil {
+		if err := app.stopProfile(); err != nil {
+			fmt.Fprintf(config.Warnings, "Warning: %v\n", err)
+		}
+		app.stopProfile = nil
+	}
+	if app.stopTelemetry != nil {
+		app.stopTelemetry()
+		app.stopTelemetry = nil
+	}
+}
+
+// traceRequested reports whether --trace is among the arguments
+// Arguments after "--" belong to the command and are not looked at.
+func traceRequested(args []string) bool {
+	for _, arg := range args {
+		switch arg {
+		case "--":
+			return false
+		case "--trace", "--trace=true":
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/engine"
+	"github.com/danballance/goldfish/internal/trace"
+)
+
+// TestTraceRequested tests finding --trace among the raw arguments
+func TestTraceRequested(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected bool
+	}{
+		{[]string{"--trace", "find"}, true},
+		{[]string{"find", "--trace=true"}, true},
+		{[]string{"find", "--", "--trace"}, false},
+		{[]string{"find"}, false},
+	}
+	for _, tc := range testCases {
+		if got := traceRequested(tc.args); got != tc.expected {
+			t.Errorf("traceRequested(%v) = %t, expected %t", tc.args, got, tc.expected)
+		}
+	}
+}
+
+// TestGoldfishApp_startTrace tests turning tracing and the CPU profile on and off
+func TestGoldfishApp_startTrace(t *testing.T) {
+	profile := filepath.Join(t.TempDir(), "cpu.out")
+	app := &GoldfishApp{
+		engine: engine.NewEngine(time.Second),
+		args:   []string{"--trace", "--cpu-profile", profile, "find"},
+	}
+	if err := app.startTrace(); err != nil {
+		t.Fatalf("startTrace() failed: %v", err)
+	}
+	if app.tracer == nil || app.stopProfile == nil {
+		t.Fatal("Expected tracing and the CPU profile to be on")
+	}
+	app.tracer.Add(trace.PhaseConfig, time.Millisecond)
+
+	// The trace goes to stderr, which is discarded here
+	stderr := os.Stderr
+	os.Stderr, _ = os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	app.finishTrace()
+	os.Stderr.Close()
+	os.Stderr = stderr
+	if app.tracer != nil || app.stopProfile != nil {
+		t.Error("Expected finishTrace to act only once")
+	}
+	if info, err := os.Stat(profile); err != nil || info.Size() == 0 {
+		t.Errorf("Expected a CPU profile to be written (%v)", err)
+	}
+
+	app = &GoldfishApp{engine: engine.NewEngine(time.Second), args: []string{"find"}}
+	if err := app.startTrace(); err != nil || app.tracer != nil || app.stopProfile != nil {
+		t.Errorf("Expected no tracing without the flags (%v)", err)
+	}
+	app.finishTrace()
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"github.com/danballance/goldfish/internal/ui"
+)
+
+// newUICommand creates the built-in "ui" command
+// It lets users browse commands and fill in parameters interactively instead
+// of memorizing flags; the chosen command runs once the UI has closed
+func (app *GoldfishApp) newUICommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "ui",
+		Short: "Browse and run commands in an interactive terminal UI",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !isInteractive() {
+				return fmt.Errorf("goldfish ui needs an interactive terminal")
+			}
+			return app.runUI(cmd)
+		},
+	}
+}
+
+// runRoot runs when goldfish is invoked without a command
+// On a terminal it opens the UI's fuzzy finder, so nobody has to remember
+// command names; otherwise, as for scripts and pipes, it prints help.
+func (app *GoldfishApp) runRoot(cmd *cobra.Command, args []string) error {
+	if !isInteractive() {
+		return cmd.Help()
+	}
+	return app.runUI(cmd)
+}
+
+// isInteractive reports whether both stdin and stdout are terminals
+func isInteractive() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// runUI shows the UI and runs the command the user chose, if any
+func (app *GoldfishApp) runUI(cmd *cobra.Command) error {
+	ctx, err := ui.Run(app.config, app.engine, app.currentPlatform())
+	if err != nil {
+		return err
+	}
+	if ctx == nil {
+		// The user quit without choosing a command
+		return nil
+	}
+	ctx.Timeout = app.timeout()
+	if app.dryRun {
+		rendered, err := app.engine.Preview(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), rendered)
+		return nil
+	}
+	// Browsing another platform's commands is fine; running one is checked
+	if err := app.checkPlatform(ctx.Command.Name); err != nil {
+		return err
+	}
+
+	closeLog, err := app.configureEngine()
+	if err != nil {
+		return err
+	}
+	defer closeLog()
+
+	return app.engine.ExecuteStatus(commandContext(cmd), ctx)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// TestUICommand_NoTerminal tests that goldfish ui refuses to start without a terminal
+func TestUICommand_NoTerminal(t *testing.T) {
+	cmd := (&GoldfishApp{}).newUICommand()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{})
+
+	// go test runs without a terminal on stdin
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "interactive terminal") {
+		t.Errorf("Expected interactive terminal error, got %v", err)
+	}
+}
+
+// TestRunRoot_NoTerminal tests that goldfish without a command prints help
+// rather than opening the fuzzy finder when there is no terminal
+func TestRunRoot_NoTerminal(t *testing.T) {
+	app := &GoldfishApp{}
+	root := &cobra.Command{Use: "goldfish", Short: "Cross-platform commands", RunE: app.runRoot}
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetErr(&bytes.Buffer{})
+	root.SetArgs([]string{})
+
+	if err := root.Execute(); err != nil {
+		t.Fatalf("Expected help, got %v", err)
+	}
+	if !strings.Contains(out.String(), "Cross-platform commands") || !strings.Contains(out.String(), "Usage:") {
+		t.Errorf("Expected help output, got:\n%s", out.String())
+	}
+}
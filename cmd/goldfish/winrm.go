@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/danballance/goldfish/internal/platform"
+	"github.com/danballance/goldfish/internal/secrets"
+	"github.com/danballance/goldfish/internal/winrm"
+)
+
+const (
+	// WinRMUserEnvVar names the WinRM user when the --winrm address has none
+	WinRMUserEnvVar = "GOLDFISH_WINRM_USER"
+	// WinRMPasswordEnvVar holds the WinRM password; without it the password
+	// is read from the secret "winrm/<host>" (see goldfish secret set)
+	WinRMPasswordEnvVar = "GOLDFISH_WINRM_PASSWORD"
+)
+
+// readWinRMFlag reads --winrm from the raw arguments
+// Commands run on a Windows host with it, so their windows templates are used:
+// --platform defaults to windows and may not name another platform.
+func (app *GoldfishApp) readWinRMFlag() error {
+	if values := app.rawFlagValues("winrm"); len(values) > 0 {
+		app.winrmHost = values[len(values)-1]
+	}
+	if app.winrmHost == "" {
+		return nil
+	}
+	if app.platformOverride == "" {
+		app.platformOverride = platform.Windows.String()
+	}
+	if app.platformOverride != platform.Windows.String() {
+		return fmt.Errorf("--winrm runs windows templates, not %s ones", app.platformOverride)
+	}
+	return nil
+}
+
+// configureWinRM makes the engine run commands on the --winrm host
+func (app *GoldfishApp) configureWinRM() error {
+	_, user, err := winrm.ParseAddress(app.winrmHost)
+	if err != nil {
+		return err
+	}
+	if user == "" {
+		user = os.Getenv(WinRMUserEnvVar)
+	}
+	password, err := app.winrmPassword()
+	if err != nil {
+		return err
+	}
+	client, err := winrm.NewClient(app.winrmHost, user, password, app.winrmInsecure)
+	if err != nil {
+		return err
+	}
+	app.engine.SetBackend(client)
+	return nil
+}
+
+// winrmPassword returns the password for the --winrm host
+// $GOLDFISH_WINRM_PASSWORD wins over the secret "winrm/<host>"
+func (app *GoldfishApp) winrmPassword() (string, error) {
+	if password := os.Getenv(WinRMPasswordEnvVar); password != "" {
+		return password, nil
+	}
+	endpoint, _, err := winrm.ParseAddress(app.winrmHost)
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	name := "winrm/" + u.Hostname()
+	password, err := app.secretStore().Get(name)
+	if errors.Is(err, secrets.ErrNotFound) {
+		return "", fmt.Errorf("no password for WinRM host; set $%s or run 'goldfish secret set %s'", WinRMPasswordEnvVar, name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read the WinRM password: %w", err)
+	}
+	return password, nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/danballance/goldfish/internal/platform"
+	"github.com/danballance/goldfish/internal/secrets"
+	"github.com/zalando/go-keyring"
+)
+
+// TestGoldfishApp_readWinRMFlag tests that --winrm selects the windows templates
+func TestGoldfishApp_readWinRMFlag(t *testing.T) {
+	app := newLazyTestApp([]string{"--winrm", "admin@server01", "first"})
+	if err := app.readPlatformFlags(); err != nil {
+		t.Fatalf("readPlatformFlags() failed: %v", err)
+	}
+	if err := app.readWinRMFlag(); err != nil {
+		t.Fatalf("readWinRMFlag() failed: %v", err)
+	}
+	if app.winrmHost != "admin@server01" || app.currentPlatform() != platform.Windows {
+		t.Errorf("Expected windows templates for server01, got %q on %s", app.winrmHost, app.currentPlatform())
+	}
+	// Running windows commands on the host needs no --force
+	if err := app.checkPlatform("first"); err != nil {
+		t.Errorf("Expected commands to run on the WinRM host, got %v", err)
+	}
+
+	// Another platform's templates cannot run there
+	app = newLazyTestApp([]string{"--winrm=server01", "--platform", "darwin", "first"})
+	app.readPlatformFlags()
+	if err := app.readWinRMFlag(); err == nil || !strings.Contains(err.Error(), "darwin") {
+		t.Errorf("Expected --platform darwin to be refused, got %v", err)
+	}
+}
+
+// TestGoldfishApp_winrmPassword tests reading the password from the environment or keyring
+func TestGoldfishApp_winrmPassword(t *testing.T) {
+	keyring.MockInit()
+	app := &GoldfishApp{secrets: secrets.NewKeyringStore(), winrmHost: "admin@server01:5986"}
+
+	t.Setenv(WinRMPasswordEnvVar, "")
+	if _, err := app.winrmPassword(); err == nil || !strings.Contains(err.Error(), "goldfish secret set winrm/server01") {
+		t.Errorf("Expected a hint to store the password, got %v", err)
+	}
+
+	app.secrets.Set("winrm/server01", "from-keyring")
+	if password, err := app.winrmPassword(); err != nil || password != "from-keyring" {
+		t.Errorf("Expected the stored password, got %q (%v)", password, err)
+	}
+
+	t.Setenv(WinRMPasswordEnvVar, "from-env")
+	if password, _ := app.winrmPassword(); password != "from-env" {
+		t.Errorf("Expected $%s to win, got %q", WinRMPasswordEnvVar, password)
+	}
+}
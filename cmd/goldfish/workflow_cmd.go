@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/danballance/goldfish/internal/batch"
+	"github.com/danballance/goldfish/internal/workflow"
+)
+
+// newWorkflowCommand creates the built-in "workflow" command group
+// Workflows are defined in the workflows: section of the configuration
+func (app *GoldfishApp) newWorkflowCommand() *cobra.Command {
+	workflowCmd := &cobra.Command{
+		Use:   "workflow",
+		Short: "Run multi-step workflows defined in configuration",
+	}
+
+	workflowCmd.AddCommand(app.newWorkflowListCommand())
+	workflowCmd.AddCommand(app.newWorkflowRunCommand())
+
+	return workflowCmd
+}
+
+// newWorkflowListCommand creates "goldfish workflow list"
+func (app *GoldfishApp) newWorkflowListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List configured workflows",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			table := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			for _, wf := range app.config.Workflows {
+				fmt.Fprintf(table, "%s\t%d steps\t%s\n", wf.Name, len(wf.Steps), wf.Description)
+			}
+			return table.Flush()
+		},
+	}
+}
+
+// newWorkflowRunCommand creates "goldfish workflow run <name>"
+func (app *GoldfishApp) newWorkflowRunCommand() *cobra.Command {
+	var varFlags []string
+
+	runCmd := &cobra.Command{
+		Use:     "run <name>",
+		Short:   "Run a workflow's steps in dependency order",
+		Example: "  goldfish workflow run release\n  goldfish workflow run release --var version=1.2.0",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wf, found := app.config.FindWorkflow(args[0])
+			if !found {
+				return fmt.Errorf("unknown workflow '%s'", args[0])
+			}
+
+			if err := app.checkPlatform("workflow run"); err != nil {
+				return err
+			}
+
+			vars, err := parseVars(varFlags)
+			if err != nil {
+				return err
+			}
+
+			closeLog, err := app.configureEngine()
+			if err != nil {
+				return err
+			}
+			defer closeLog()
+
+			jobs := batch.NewRunner(app.config, app.engine, app.currentPlatform(), app.timeout())
+			results, err := workflow.NewRunner(jobs).Run(wf, vars)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintln(cmd.OutOrStdout())
+			batch.WriteSummary(cmd.OutOrStdout(), results)
+
+			// Steps skipped by their condition are not failures
+			failures := 0
+			for _, result := range results {
+				if result.Err != nil || (!result.Skipped && result.ExitCode != 0) {
+					failures++
+				}
+			}
+			if failures > 0 {
+				// The summary already explains what went wrong
+				cmd.SilenceUsage = true
+				return fmt.Errorf("workflow '%s': %d of %d steps failed", wf.Name, failures, len(results))
+			}
+			return nil
+		},
+	}
+
+	runCmd.Flags().StringArrayVar(&varFlags, "var", nil, "set a workflow variable (name=value); may be repeated")
+
+	return runCmd
+}
+
+// parseVars converts repeated name=value flags into a map
+func parseVars(entries []string) (map[string]string, error) {
+	vars := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		name, value, found := strings.Cut(entry, "=")
+		if !found || name == "" {
+			return nil, fmt.Errorf("invalid --var %q: expected name=value", entry)
+		}
+		vars[name] = value
+	}
+	return vars, nil
+}
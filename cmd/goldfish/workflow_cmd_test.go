@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/engine"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// testWorkflowApp creates an app with one command and a two-step workflow
+func testWorkflowApp() *GoldfishApp {
+	posix := map[string]config.PlatformCommand{
+		"linux":  {Template: "true"},
+		"darwin": {Template: "true"},
+	}
+	return &GoldfishApp{
+		engine:           engine.NewEngine(5 * time.Second),
+		platformDetector: platform.NewDetector(),
+		config: &config.Config{
+			Commands: []config.Command{{Name: "ok", BaseCommand: "true", Platforms: posix}},
+			Workflows: []config.Workflow{
+				{
+					Name:        "ci",
+					Description: "build and test",
+					Steps: []config.WorkflowStep{
+						{Name: "build", Command: "ok"},
+						{Name: "test", Command: "ok", DependsOn: []string{"build"}},
+						{Name: "publish", Command: "ok", If: `{{eq .vars.publish "yes"}}`},
+					},
+					Vars: map[string]string{"publish": "no"},
+				},
+			},
+		},
+	}
+}
+
+// TestWorkflowCommand_List tests listing configured workflows
+func TestWorkflowCommand_List(t *testing.T) {
+	cmd := testWorkflowApp().newWorkflowCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"list"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("workflow list failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "ci") || !strings.Contains(out.String(), "3 steps") {
+		t.Errorf("Unexpected output:\n%s", out.String())
+	}
+}
+
+// TestWorkflowCommand_Run tests running a workflow with a variable override
+func TestWorkflowCommand_Run(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+
+	cmd := testWorkflowApp().newWorkflowCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"run", "ci", "--var", "publish=yes"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("workflow run failed: %v", err)
+	}
+	if strings.Contains(out.String(), "skipped") {
+		t.Errorf("Expected publish step to run, got:\n%s", out.String())
+	}
+
+	// Unknown workflows are reported
+	cmd.SetArgs([]string{"run", "deploy"})
+	if err := cmd.Execute(); err == nil {
+		t.Error("Expected error for unknown workflow")
+	}
+}
+
+// TestParseVars tests parsing of --var flags
+func TestParseVars(t *testing.T) {
+	vars, err := parseVars([]string{"a=1", "b=x=y"})
+	if err != nil {
+		t.Fatalf("parseVars() failed: %v", err)
+	}
+	if vars["a"] != "1" || vars["b"] != "x=y" {
+		t.Errorf("Unexpected vars: %v", vars)
+	}
+
+	if _, err := parseVars([]string{"novalue"}); err == nil {
+		t.Error("Expected error for entry without '='")
+	}
+}
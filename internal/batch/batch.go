@@ -0,0 +1,240 @@
+// Package batch runs lists of goldfish command invocations from a manifest file.
+// Jobs can run one after another or on a pool of parallel workers, which turns
+// goldfish into a lightweight cross-platform task runner.
+package batch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/engine"
+	"github.com/danballance/goldfish/internal/platform"
+	"gopkg.in/yaml.v3"
+)
+
+// Manifest is the content of a batch file
+type Manifest struct {
+	// Jobs are the command invocations to run, in order
+	Jobs []Job `yaml:"jobs"`
+}
+
+// Job is a single goldfish command invocation in a batch
+type Job struct {
+	// Name labels the job in the summary (defaults to the command line)
+	Name string `yaml:"name,omitempty"`
+	// Command is the goldfish command name or alias to run
+	Command string `yaml:"command"`
+	// Args are positional arguments, exactly as they would be typed on the command line
+	Args []string `yaml:"args,omitempty"`
+	// Params sets parameters by name (e.g. in_place: true)
+	Params map[string]interface{} `yaml:"params,omitempty"`
+}
+
+// Label returns the name shown for the job in the summary
+func (j *Job) Label() string {
+	if j.Name != "" {
+		return j.Name
+	}
+	return strings.TrimSpace(j.Command + " " + strings.Join(j.Args, " "))
+}
+
+// LoadManifest reads and validates a batch manifest file
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read batch manifest %s: %w", path, err)
+	}
+
+	var manifest Manifest
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse batch manifest %s: %w", path, err)
+	}
+
+	if len(manifest.Jobs) == 0 {
+		return nil, fmt.Errorf("batch manifest %s has no jobs", path)
+	}
+	for i, job := range manifest.Jobs {
+		if job.Command == "" {
+			return nil, fmt.Errorf("batch manifest %s: job at index %d: command is required", path, i)
+		}
+	}
+
+	return &manifest, nil
+}
+
+// Options control how a batch is run
+type Options struct {
+	// Parallel is the number of jobs that may run at once (values below 1 mean 1)
+	Parallel int
+	// FailFast stops starting new jobs once one has failed
+	FailFast bool
+}
+
+// Result records the outcome of one job
+type Result struct {
+	// Job is the job that was run
+	Job Job
+	// ExitCode is the command's exit code (-1 if it did not run to completion)
+	ExitCode int
+	// Err describes why the job could not run or failed its postconditions
+	Err error
+	// Duration is how long the job took
+	Duration time.Duration
+	// Skipped is set for jobs that never started because of --fail-fast
+	Skipped bool
+}
+
+// Failed reports whether the job did not complete successfully
+func (r *Result) Failed() bool {
+	return r.Skipped || r.Err != nil || r.ExitCode != 0
+}
+
+// Runner runs batch jobs against a loaded configuration
+type Runner struct {
+	config   *config.Config
+	engine   *engine.Engine
+	platform platform.SupportedPlatform
+	timeout  time.Duration
+	// warnings receives deprecation notices, printed once per command
+	warnings io.Writer
+	warned   sync.Map
+}
+
+// NewRunner creates a batch runner
+// Every job runs on the given platform with the given timeout
+func NewRunner(cfg *config.Config, eng *engine.Engine, currentPlatform platform.SupportedPlatform, timeout time.Duration) *Runner {
+	return &Runner{
+		config:   cfg,
+		engine:   eng,
+		platform: currentPlatform,
+		timeout:  timeout,
+		warnings: os.Stderr,
+	}
+}
+
+// Run executes the jobs and returns one result per job, in manifest order
+// Jobs are handed to a pool of opts.Parallel workers in order.
+func (r *Runner) Run(jobs []Job, opts Options) []Result {
+	workers := opts.Parallel
+	if workers < 1 {
+		workers = 1
+	}
+
+	results := make([]Result, len(jobs))
+	queue := make(chan int)
+	var failed atomic.Bool
+	var wg sync.WaitGroup
+
+	// Start the worker pool; each worker takes job indexes from the queue
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				// With --fail-fast, jobs that have not started yet are skipped
+				if opts.FailFast && failed.Load() {
+					results[i] = Result{Job: jobs[i], ExitCode: -1, Skipped: true}
+					continue
+				}
+				results[i] = r.RunJob(jobs[i])
+				if results[i].Failed() {
+					failed.Store(true)
+				}
+			}
+		}()
+	}
+
+	for i := range jobs {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+
+	return results
+}
+
+// RunJob runs a single job through the engine
+func (r *Runner) RunJob(job Job) Result {
+	return r.RunJobTo(job, nil)
+}
+
+// RunJobTo runs a single job like RunJob, writing its standard output to stdout
+// A nil stdout is goldfish's own; runbooks use this to capture a step's output.
+func (r *Runner) RunJobTo(job Job, stdout io.Writer) Result {
+	start := time.Now()
+	result := Result{Job: job, ExitCode: -1}
+
+	cmd, found := r.config.FindCommand(job.Command)
+	if !found {
+		result.Err = &config.CommandNotFoundError{Name: job.Command}
+		return result
+	}
+
+	// Deprecated commands still run, but say so once per batch
+	if cmd.Deprecated != "" {
+		if _, warned := r.warned.LoadOrStore(cmd.Name, true); !warned {
+			fmt.Fprintf(r.warnings, "Command %q is deprecated, %s\n", cmd.Name, cmd.Deprecated)
+		}
+	}
+
+	// Named parameters are passed like flags; values are converted to each parameter's type
+	flags := make(map[string]interface{}, len(job.Params))
+	for name, value := range job.Params {
+		flags["--"+name] = fmt.Sprint(value)
+	}
+	params, err := r.engine.ParseParameters(cmd, job.Args, flags)
+	if err != nil {
+		result.Err = fmt.Errorf("failed to parse parameters: %w", err)
+		return result
+	}
+
+	result.ExitCode, result.Err = r.engine.Run(context.Background(), &engine.ExecutionContext{
+		Command:    cmd,
+		Platform:   r.platform,
+		Parameters: params,
+		Timeout:    r.timeout,
+		Stdout:     stdout,
+	})
+	result.Duration = time.Since(start)
+	return result
+}
+
+// WriteSummary prints a table with the outcome of every job
+func WriteSummary(w io.Writer, results []Result) {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "JOB\tSTATUS\tEXIT\tDURATION")
+	for _, result := range results {
+		status := "ok"
+		exitCode := fmt.Sprint(result.ExitCode)
+		switch {
+		case result.Skipped:
+			status = "skipped"
+			exitCode = "-"
+		case result.Err != nil:
+			status = "error: " + result.Err.Error()
+		case result.ExitCode != 0:
+			status = "failed"
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%v\n", result.Job.Label(), status, exitCode, result.Duration.Round(time.Millisecond))
+	}
+	table.Flush()
+}
+
+// CountFailures returns how many jobs did not complete successfully
+func CountFailures(results []Result) int {
+	failures := 0
+	for _, result := range results {
+		if result.Failed() {
+			failures++
+		}
+	}
+	return failures
+}
@@ -0,0 +1,191 @@
+package batch
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/engine"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// testRunner creates a runner with a single "exit" command that exits with its code parameter
+func testRunner(t *testing.T) *Runner {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+
+	template := "sleep 0.05; exit {{.params.code}}"
+	cfg := &config.Config{
+		Commands: []config.Command{
+			{
+				Name:        "exit",
+				BaseCommand: "sh",
+				Parameters:  []config.Parameter{{Name: "code", Type: "int", Default: 0}},
+				Platforms: map[string]config.PlatformCommand{
+					"linux":  {Template: template},
+					"darwin": {Template: template},
+				},
+			},
+		},
+	}
+	return NewRunner(cfg, engine.NewEngine(5*time.Second), platform.SupportedPlatform(runtime.GOOS), 5*time.Second)
+}
+
+// TestLoadManifest tests reading and validating manifest files
+func TestLoadManifest(t *testing.T) {
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "valid.yml")
+	content := `jobs:
+  - name: first
+    command: replace
+    args: ["s/a/b/", "file.txt"]
+    params:
+      in-place: true
+  - command: find
+`
+	if err := os.WriteFile(valid, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+
+	manifest, err := LoadManifest(valid)
+	if err != nil {
+		t.Fatalf("LoadManifest() failed: %v", err)
+	}
+	if len(manifest.Jobs) != 2 {
+		t.Fatalf("Expected 2 jobs, got %d", len(manifest.Jobs))
+	}
+	if manifest.Jobs[0].Params["in-place"] != true || len(manifest.Jobs[0].Args) != 2 {
+		t.Errorf("Unexpected first job: %+v", manifest.Jobs[0])
+	}
+
+	// Manifests without jobs, or with jobs lacking a command, are rejected
+	invalid := map[string]string{
+		"empty.yml":   "jobs: []\n",
+		"nocmd.yml":   "jobs:\n  - name: x\n",
+		"broken.yml":  "jobs: [\n",
+		"missing.yml": "",
+	}
+	for name, body := range invalid {
+		path := filepath.Join(dir, name)
+		if body != "" {
+			if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+				t.Fatalf("Failed to write manifest: %v", err)
+			}
+		}
+		if _, err := LoadManifest(path); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+// TestJob_Label tests the summary label for a job
+func TestJob_Label(t *testing.T) {
+	job := Job{Command: "replace", Args: []string{"s/a/b/", "file.txt"}}
+	if job.Label() != "replace s/a/b/ file.txt" {
+		t.Errorf("Unexpected label: %s", job.Label())
+	}
+
+	job.Name = "fix typos"
+	if job.Label() != "fix typos" {
+		t.Errorf("Expected name to be used as label, got %s", job.Label())
+	}
+}
+
+// TestRunner_Run tests that every job runs and results keep manifest order
+func TestRunner_Run(t *testing.T) {
+	runner := testRunner(t)
+
+	jobs := []Job{
+		{Command: "exit"},
+		{Command: "exit", Params: map[string]interface{}{"code": 3}},
+		{Command: "exit", Args: []string{"0"}},
+		{Command: "unknown"},
+	}
+
+	start := time.Now()
+	results := runner.Run(jobs, Options{Parallel: 4})
+	elapsed := time.Since(start)
+
+	expected := []int{0, 3, 0, -1}
+	for i, result := range results {
+		if result.ExitCode != expected[i] {
+			t.Errorf("Job %d: expected exit code %d, got %d", i, expected[i], result.ExitCode)
+		}
+	}
+	if results[3].Err == nil {
+		t.Error("Expected error for unknown command")
+	}
+	if CountFailures(results) != 2 {
+		t.Errorf("Expected 2 failures, got %d", CountFailures(results))
+	}
+
+	// Jobs sleep 50ms each; run in parallel they should finish well within the serial time
+	if elapsed >= 150*time.Millisecond {
+		t.Errorf("Expected parallel jobs to overlap, took %v", elapsed)
+	}
+}
+
+// TestRunner_Run_FailFast tests that no new jobs start after a failure
+func TestRunner_Run_FailFast(t *testing.T) {
+	runner := testRunner(t)
+
+	jobs := []Job{
+		{Command: "exit", Params: map[string]interface{}{"code": 1}},
+		{Command: "exit"},
+		{Command: "exit"},
+	}
+
+	results := runner.Run(jobs, Options{Parallel: 1, FailFast: true})
+	if results[0].ExitCode != 1 {
+		t.Errorf("Expected first job to fail with exit code 1, got %d", results[0].ExitCode)
+	}
+	for _, result := range results[1:] {
+		if !result.Skipped {
+			t.Errorf("Expected remaining jobs to be skipped, got %+v", result)
+		}
+	}
+}
+
+// TestRunner_RunJob_Deprecated tests that deprecation hints are printed once
+func TestRunner_RunJob_Deprecated(t *testing.T) {
+	runner := testRunner(t)
+	runner.config.Commands[0].Deprecated = "use 'quit' instead"
+	var warnings bytes.Buffer
+	runner.warnings = &warnings
+
+	runner.Run([]Job{{Command: "exit"}, {Command: "exit"}}, Options{Parallel: 2})
+
+	if count := strings.Count(warnings.String(), "is deprecated, use 'quit' instead"); count != 1 {
+		t.Errorf("Expected one deprecation warning, got %d:\n%s", count, warnings.String())
+	}
+}
+
+// TestWriteSummary tests the summary table
+func TestWriteSummary(t *testing.T) {
+	results := []Result{
+		{Job: Job{Name: "good"}, ExitCode: 0, Duration: time.Second},
+		{Job: Job{Name: "bad"}, ExitCode: 2},
+		{Job: Job{Name: "later"}, ExitCode: -1, Skipped: true},
+	}
+
+	var out bytes.Buffer
+	WriteSummary(&out, results)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected header and 3 rows, got:\n%s", out.String())
+	}
+	for i, expected := range []string{"JOB", "good  ok", "bad   failed", "later skipped"} {
+		if !strings.Contains(strings.Join(strings.Fields(lines[i]), " "), strings.Join(strings.Fields(expected), " ")) {
+			t.Errorf("Line %d: expected %q, got %q", i, expected, lines[i])
+		}
+	}
+}
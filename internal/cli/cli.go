@@ -0,0 +1,252 @@
+// Package cli builds Cobra commands from goldfish command definitions.
+// It is shared by the goldfish CLI and by pkg/goldfish, which lets other
+// programs mount the same commands under their own CLI.
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/engine"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// RunFunc runs a configured command once Cobra has parsed its flags
+// Use FlagValues to read the parameter flags from cobraCmd.
+type RunFunc func(cmd *config.Command, cobraCmd *cobra.Command, args []string) error
+
+// NewCommand creates the Cobra command for one configured command
+// program is the name shown in usage examples (e.g. "goldfish" or "acme tools").
+// Commands not supported on currentPlatform are hidden from help, but running
+// one explains where it is supported instead of "unknown command".
+func NewCommand(cmd *config.Command, currentPlatform platform.SupportedPlatform, program string, run RunFunc) *cobra.Command {
+	if !engine.Supports(cmd, currentPlatform) {
+		return newUnsupportedCommand(cmd, currentPlatform)
+	}
+
+	// Create the Cobra command
+	cobraCmd := newSummaryCommand(cmd)
+	cobraCmd.Long = fmt.Sprintf("%s\n\nThis command provides cross-platform compatibility for '%s'.", cmd.Description, cmd.BaseCommand)
+	cobraCmd.RunE = func(cobraCmd *cobra.Command, args []string) error {
+		return run(cmd, cobraCmd, args)
+	}
+
+	// Add flags for each parameter
+	for _, param := range cmd.Parameters {
+		addParameterFlag(cobraCmd, &param, param.Name == cmd.StdinParam)
+	}
+
+	// Add usage examples
+	if examples := generateExamples(program, cmd); examples != "" {
+		cobraCmd.Example = examples
+	}
+
+	return cobraCmd
+}
+
+// stubAnnotation marks the commands created by NewStub
+const stubAnnotation = "goldfish_stub"
+
+// NewStub creates a lightweight stand-in for a configured command
+// It has what help and shell completion need to list the command (name,
+// aliases, summary) but no flags or examples, which are costly to build for
+// hundreds of commands. Callers replace stubs with NewCommand before they are
+// used; run is only a safety net for a stub that is run anyway and receives
+// the arguments unparsed. Unsupported commands get their usual placeholder.
+func NewStub(cmd *config.Command, currentPlatform platform.SupportedPlatform, run func(stub *cobra.Command, args []string) error) *cobra.Command {
+	if !engine.Supports(cmd, currentPlatform) {
+		return newUnsupportedCommand(cmd, currentPlatform)
+	}
+
+	stub := newSummaryCommand(cmd)
+	stub.Annotations = map[string]string{stubAnnotation: "true"}
+	// The stub has no flags of its own to parse
+	stub.DisableFlagParsing = true
+	stub.RunE = run
+	return stub
+}
+
+// IsStub reports whether a Cobra command was created by NewStub
+func IsStub(cobraCmd *cobra.Command) bool {
+	return cobraCmd.Annotations[stubAnnotation] == "true"
+}
+
+// newSummaryCommand creates a Cobra command with just the name, aliases and
+// summary of a configured command, shared by full commands and stubs
+func newSummaryCommand(cmd *config.Command) *cobra.Command {
+	cobraCmd := &cobra.Command{
+		Use:   cmd.Name,
+		Short: cmd.Description,
+		// Hidden and (unless enabled) experimental commands stay out of help
+		Hidden: !cmd.Listed(),
+		// Cobra prints "Command x is deprecated, <hint>" when it is run
+		Deprecated: cmd.Deprecated,
+	}
+	if cmd.Experimental {
+		cobraCmd.Short = "[experimental] " + cobraCmd.Short
+	}
+
+	// Add alias if specified
+	if cmd.Alias != "" {
+		cobraCmd.Aliases = []string{cmd.Alias}
+	}
+	return cobraCmd
+}
+
+// FlagValues returns the parameter flags the user set, keyed "--name"
+// The result is passed to engine.ParseParameters.
+func FlagValues(cmd *config.Command, cobraCmd *cobra.Command) map[string]interface{} {
+	flags := make(map[string]interface{})
+	for _, param := range cmd.Parameters {
+		flagName := FlagName(&param)
+
+		switch param.Type {
+		case "string":
+			if val, err := cobraCmd.Flags().GetString(flagName); err == nil && val != "" {
+				flags["--"+flagName] = val
+			}
+		case "bool":
+			if val, err := cobraCmd.Flags().GetBool(flagName); err == nil && val {
+				flags["--"+flagName] = val
+			}
+		case "int":
+			if val, err := cobraCmd.Flags().GetInt(flagName); err == nil && cobraCmd.Flags().Changed(flagName) {
+				flags["--"+flagName] = val
+			}
+		case "float":
+			if val, err := cobraCmd.Flags().GetFloat64(flagName); err == nil && cobraCmd.Flags().Changed(flagName) {
+				flags["--"+flagName] = val
+			}
+		}
+	}
+	return flags
+}
+
+// newUnsupportedCommand creates a hidden placeholder for a command lacking this platform
+// Running it fails with an UnsupportedPlatformError report and its dedicated exit code
+func newUnsupportedCommand(cmd *config.Command, currentPlatform platform.SupportedPlatform) *cobra.Command {
+	unsupportedErr := engine.NewUnsupportedPlatformError(cmd, currentPlatform.String())
+
+	placeholder := &cobra.Command{
+		Use:    cmd.Name,
+		Short:  cmd.Description,
+		Hidden: true,
+		// Accept any arguments, since the command's flags were never defined
+		DisableFlagParsing: true,
+		// main prints the report; usage text would only bury it
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			return unsupportedErr
+		},
+	}
+	if cmd.Alias != "" {
+		placeholder.Aliases = []string{cmd.Alias}
+	}
+	return placeholder
+}
+
+// FlagName returns the name of a parameter's flag: its flag: setting without
+// the leading dashes, or else the parameter's name
+func FlagName(param *config.Parameter) string {
+	if param.Flag != "" {
+		return strings.TrimLeft(param.Flag, "-")
+	}
+	return param.Name
+}
+
+// addParameterFlag adds a flag to the Cobra command based on parameter definition
+// A required parameter that piped input can fill (stdin_param) is checked
+// when the command runs instead, since its flag may be left out.
+func addParameterFlag(cobraCmd *cobra.Command, param *config.Parameter, fromStdin bool) {
+	flagName := FlagName(param)
+
+	description := param.Description
+	if description == "" {
+		description = fmt.Sprintf("%s parameter", param.Name)
+	}
+
+	// Add the appropriate flag type
+	switch param.Type {
+	case "string":
+		defaultValue := ""
+		if text, templated := param.DefaultTemplate(); templated {
+			// Templated defaults are computed when the command runs, so the
+			// flag itself has none; help shows the template instead
+			description += fmt.Sprintf(" (default %s)", text)
+		} else if param.Default != nil {
+			if str, ok := param.Default.(string); ok {
+				defaultValue = str
+			}
+		}
+		cobraCmd.Flags().String(flagName, defaultValue, description)
+		if param.Required && !fromStdin {
+			if err := cobraCmd.MarkFlagRequired(flagName); err != nil {
+				// This should rarely fail, but we handle it gracefully
+				fmt.Fprintf(os.Stderr, "Warning: failed to mark flag %s as required: %v\n", flagName, err)
+			}
+		}
+	case "bool":
+		defaultValue := false
+		if param.Default != nil {
+			if b, ok := param.Default.(bool); ok {
+				defaultValue = b
+			}
+		}
+		cobraCmd.Flags().Bool(flagName, defaultValue, description)
+	case "int":
+		defaultValue := 0
+		if param.Default != nil {
+			if i, ok := param.Default.(int); ok {
+				defaultValue = i
+			}
+		}
+		cobraCmd.Flags().Int(flagName, defaultValue, description)
+	case "float":
+		defaultValue := 0.0
+		if param.Default != nil {
+			if f, ok := param.Default.(float64); ok {
+				defaultValue = f
+			}
+		}
+		cobraCmd.Flags().Float64(flagName, defaultValue, description)
+	}
+}
+
+// generateExamples creates usage examples for a command
+func generateExamples(program string, cmd *config.Command) string {
+	examples := []string{}
+
+	// Basic example with command name
+	prefix := fmt.Sprintf("  %s ", program)
+	example := prefix + cmd.Name
+	
+	// Add parameter examples
+	for _, param := range cmd.Parameters {
+		if param.Required {
+			switch param.Type {
+			case "string":
+				example += fmt.Sprintf(" <%s>", param.Name)
+			case "bool":
+				if param.Flag != "" {
+					example += fmt.Sprintf(" %s", param.Flag)
+				}
+			default:
+				example += fmt.Sprintf(" <%s>", param.Name)
+			}
+		}
+	}
+
+	examples = append(examples, example)
+
+	// Add alias example if available
+	if cmd.Alias != "" {
+		aliasExample := prefix + cmd.Alias + strings.TrimPrefix(example, prefix+cmd.Name)
+		examples = append(examples, aliasExample)
+	}
+
+	return strings.Join(examples, "\n")
+}
\ No newline at end of file
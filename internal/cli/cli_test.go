@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/engine"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// testCommand returns a Linux-only command with one parameter of each type
+func testCommand() *config.Command {
+	return &config.Command{
+		Name:        "find-files",
+		Alias:       "find",
+		Description: "Find files",
+		BaseCommand: "find",
+		Parameters: []config.Parameter{
+			{Name: "path", Type: "string", Required: true},
+			{Name: "follow", Type: "bool", Flag: "-L"},
+			{Name: "depth", Type: "int", Default: 1},
+			{Name: "ratio", Type: "float"},
+		},
+		Platforms: map[string]config.PlatformCommand{"linux": {Template: "find"}},
+	}
+}
+
+// TestNewCommand tests the generated command and the flags passed to run
+func TestNewCommand(t *testing.T) {
+	var flags map[string]interface{}
+	var gotArgs []string
+	cobraCmd := NewCommand(testCommand(), platform.Linux, "goldfish", func(cmd *config.Command, cobraCmd *cobra.Command, args []string) error {
+		flags = FlagValues(cmd, cobraCmd)
+		gotArgs = args
+		return nil
+	})
+
+	if cobraCmd.Aliases[0] != "find" {
+		t.Errorf("Expected alias, got %v", cobraCmd.Aliases)
+	}
+	if cobraCmd.Example != "  goldfish find-files <path>\n  goldfish find <path>" {
+		t.Errorf("Unexpected examples: %q", cobraCmd.Example)
+	}
+
+	cobraCmd.SetArgs([]string{"extra", "--path", "/tmp", "--L", "--ratio", "0.5"})
+	if err := cobraCmd.Execute(); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	// Unchanged int and float flags are left out so parameter defaults apply
+	want := map[string]interface{}{"--path": "/tmp", "--L": true, "--ratio": 0.5}
+	if len(flags) != len(want) {
+		t.Errorf("Expected flags %v, got %v", want, flags)
+	}
+	for name, value := range want {
+		if flags[name] != value {
+			t.Errorf("Expected %s=%v, got %v", name, value, flags[name])
+		}
+	}
+	if len(gotArgs) != 1 || gotArgs[0] != "extra" {
+		t.Errorf("Expected positional args to be passed on, got %v", gotArgs)
+	}
+}
+
+// TestNewCommand_DefaultTemplate tests that templated defaults are not flag values
+func TestNewCommand_DefaultTemplate(t *testing.T) {
+	cmd := testCommand()
+	cmd.Parameters = append(cmd.Parameters, config.Parameter{Name: "output", Type: "string", Default: "{{.params.path}}.out"})
+	var flags map[string]interface{}
+	cobraCmd := NewCommand(cmd, platform.Linux, "goldfish", func(cmd *config.Command, cobraCmd *cobra.Command, args []string) error {
+		flags = FlagValues(cmd, cobraCmd)
+		return nil
+	})
+
+	cobraCmd.SetArgs([]string{"--path", "/tmp"})
+	if err := cobraCmd.Execute(); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	// Left unset, the engine computes the default
+	if _, found := flags["--output"]; found {
+		t.Errorf("Expected no value for output, got %v", flags["--output"])
+	}
+	if usage := cobraCmd.Flags().Lookup("output").Usage; !strings.Contains(usage, "(default {{.params.path}}.out)") {
+		t.Errorf("Expected the help to show the default, got %q", usage)
+	}
+}
+
+// TestNewCommand_Unsupported tests the placeholder for unsupported platforms
+func TestNewCommand_Unsupported(t *testing.T) {
+	cobraCmd := NewCommand(testCommand(), platform.Windows, "goldfish", func(*config.Command, *cobra.Command, []string) error {
+		t.Error("run must not be called on an unsupported platform")
+		return nil
+	})
+	if !cobraCmd.Hidden {
+		t.Error("Expected the placeholder to be hidden")
+	}
+
+	cobraCmd.SetArgs([]string{"--anything"})
+	err := cobraCmd.Execute()
+	var unsupported *engine.UnsupportedPlatformError
+	if !errors.As(err, &unsupported) || !strings.Contains(err.Error(), "windows") {
+		t.Errorf("Expected unsupported platform error, got %v", err)
+	}
+}
+
+// TestNewStub tests that stubs carry the summary but leave arguments unparsed
+func TestNewStub(t *testing.T) {
+	var gotArgs []string
+	stub := NewStub(testCommand(), platform.Linux, func(stub *cobra.Command, args []string) error {
+		gotArgs = args
+		return nil
+	})
+	if !IsStub(stub) || stub.Short != "Find files" || stub.Aliases[0] != "find" {
+		t.Errorf("Unexpected stub: %+v", stub)
+	}
+	if stub.Flags().HasFlags() {
+		t.Error("Expected a stub without parameter flags")
+	}
+	if IsStub(NewCommand(testCommand(), platform.Linux, "goldfish", nil)) {
+		t.Error("Expected a full command not to be a stub")
+	}
+
+	stub.SetArgs([]string{"--path", "/tmp"})
+	if err := stub.Execute(); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if len(gotArgs) != 2 || gotArgs[0] != "--path" {
+		t.Errorf("Expected the arguments unparsed, got %v", gotArgs)
+	}
+
+	if IsStub(NewStub(testCommand(), platform.Windows, nil)) {
+		t.Error("Expected the unsupported placeholder on Windows")
+	}
+}
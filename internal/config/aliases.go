@@ -0,0 +1,204 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Aliases are personal shorthands for commands, e.g. "rp" for
+// "replace-in-file --in-place". Unlike a command's own alias: they can bake
+// in flags and arguments, which are inserted after the command name when the
+// alias is run, as with git aliases. goldfish alias add writes them to the
+// user's commands.yml so nobody has to edit the YAML by hand.
+
+// Alias runs a command with preset arguments
+type Alias struct {
+	// Name is what the user types instead of the command
+	Name string `yaml:"name"`
+	// Command is the name (or alias:) of the command it runs
+	Command string `yaml:"command"`
+	// Args are flags and arguments placed right after the command name,
+	// before whatever the user passes, e.g. ["--in-place"]
+	Args []string `yaml:"args,omitempty"`
+}
+
+// String returns the command line the alias stands for, e.g. "replace --in-place"
+func (a *Alias) String() string {
+	return strings.Join(append([]string{a.Command}, a.Args...), " ")
+}
+
+// FindAlias returns the alias with the given name
+func (c *Config) FindAlias(name string) (*Alias, bool) {
+	for i := range c.Aliases {
+		if c.Aliases[i].Name == name {
+			return &c.Aliases[i], true
+		}
+	}
+	return nil, false
+}
+
+// validateAliases checks that aliases are named, unique and run a command
+// Whether the command exists is only known once every layer is merged.
+func validateAliases(aliases []Alias) error {
+	names := make(map[string]bool, len(aliases))
+	for i, alias := range aliases {
+		if alias.Name == "" {
+			return fmt.Errorf("alias at index %d: name is required", i)
+		}
+		if strings.HasPrefix(alias.Name, "-") || strings.ContainsAny(alias.Name, " \t") {
+			return fmt.Errorf("alias '%s': names cannot start with '-' or contain spaces", alias.Name)
+		}
+		if alias.Command == "" {
+			return fmt.Errorf("alias '%s': command is required", alias.Name)
+		}
+		if names[alias.Name] {
+			return fmt.Errorf("duplicate alias name: %s", alias.Name)
+		}
+		names[alias.Name] = true
+	}
+	return nil
+}
+
+// UserConfigPath returns the user's own commands.yml in UserConfigDir
+func UserConfigPath() (string, error) {
+	dir, err := UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "commands.yml"), nil
+}
+
+// SetAlias adds an alias to the configuration file at path, replacing any
+// alias of the same name; the file is created if it does not exist
+// The YAML is edited in place, so the file's comments and layout survive.
+func SetAlias(path string, alias Alias) error {
+	return editAliases(path, func(aliases *yaml.Node) error {
+		var value yaml.Node
+		if err := value.Encode(alias); err != nil {
+			return fmt.Errorf("failed to encode alias: %w", err)
+		}
+		if i := aliasIndex(aliases, alias.Name); i >= 0 {
+			aliases.Content[i] = &value
+			return nil
+		}
+		aliases.Content = append(aliases.Content, &value)
+		return nil
+	})
+}
+
+// ErrAliasNotFound is returned by RemoveAlias for an alias the file does not define
+var ErrAliasNotFound = errors.New("alias not found")
+
+// RemoveAlias removes the named alias from the configuration file at path
+func RemoveAlias(path, name string) error {
+	return editAliases(path, func(aliases *yaml.Node) error {
+		i := aliasIndex(aliases, name)
+		if i < 0 {
+			return fmt.Errorf("%w: '%s' is not defined in %s", ErrAliasNotFound, name, path)
+		}
+		aliases.Content = append(aliases.Content[:i], aliases.Content[i+1:]...)
+		return nil
+	})
+}
+
+// aliasIndex returns the position of the named alias in an aliases: sequence, or -1
+func aliasIndex(aliases *yaml.Node, name string) int {
+	for i, item := range aliases.Content {
+		var alias Alias
+		if item.Decode(&alias) == nil && alias.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// editAliases applies edit to the aliases: sequence of the file at path and
+// writes the file back, once the result has passed validation
+func editAliases(path string, edit func(aliases *yaml.Node) error) error {
+	var document yaml.Node
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	default:
+		if err := yaml.Unmarshal(data, &document); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	}
+	if len(document.Content) == 0 {
+		document = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	root := document.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s must be a YAML mapping", path)
+	}
+
+	var aliases *yaml.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "aliases" {
+			aliases = root.Content[i+1]
+		}
+	}
+	if aliases == nil {
+		aliases = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "aliases"}
+		root.Content = append(root.Content, key, aliases)
+	}
+	if aliases.Kind != yaml.SequenceNode {
+		return fmt.Errorf("%s: aliases must be a list", path)
+	}
+	if err := edit(aliases); err != nil {
+		return err
+	}
+	// An empty list is dropped rather than left behind as "aliases: []"
+	if len(aliases.Content) == 0 {
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			if root.Content[i+1] == aliases {
+				root.Content = append(root.Content[:i], root.Content[i+2:]...)
+				break
+			}
+		}
+	}
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	if err := encoder.Close(); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	// Never write a file that would then fail to load; a file left with
+	// nothing in it is removed instead
+	if len(root.Content) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		return nil
+	}
+	if _, err := NewLoader(path).Parse(out.Bytes()); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	// Write to a temporary file first so a failed write never truncates the file
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, out.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
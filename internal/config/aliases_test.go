@@ -0,0 +1,106 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestSetAlias tests adding and replacing aliases while keeping the rest of the file
+func TestSetAlias(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "goldfish", "commands.yml")
+
+	// The file and its directory are created when missing
+	if err := SetAlias(path, Alias{Name: "rp", Command: "replace", Args: []string{"--in-place"}}); err != nil {
+		t.Fatalf("SetAlias() failed: %v", err)
+	}
+	cfg, err := NewLoader(path).Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if alias, found := cfg.FindAlias("rp"); !found || alias.String() != "replace --in-place" {
+		t.Errorf("Expected the alias to be written, got %+v", cfg.Aliases)
+	}
+
+	// Comments and other settings survive, and an alias of the same name is replaced
+	existing := "# my settings\nallowed_base_commands: [sed]\naliases:\n  - name: rp\n    command: replace\n"
+	if err := os.WriteFile(path, []byte(existing), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetAlias(path, Alias{Name: "rp", Command: "find"}); err != nil {
+		t.Fatalf("SetAlias() failed: %v", err)
+	}
+	if err := SetAlias(path, Alias{Name: "ff", Command: "find"}); err != nil {
+		t.Fatalf("SetAlias() failed: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "# my settings") || !strings.Contains(string(data), "allowed_base_commands") {
+		t.Errorf("Expected the rest of the file to be kept, got:\n%s", data)
+	}
+	cfg, _ = NewLoader(path).Load()
+	if len(cfg.Aliases) != 2 || cfg.Aliases[0].Command != "find" || cfg.Aliases[1].Name != "ff" {
+		t.Errorf("Expected rp replaced and ff added, got %+v", cfg.Aliases)
+	}
+
+	// Invalid aliases are refused without touching the file
+	if err := SetAlias(path, Alias{Name: "-x", Command: "find"}); err == nil {
+		t.Error("Expected an alias starting with '-' to be refused")
+	}
+	if after, _ := os.ReadFile(path); string(after) != string(data) {
+		t.Errorf("Expected the file to be unchanged, got:\n%s", after)
+	}
+}
+
+// TestRemoveAlias tests removing aliases and removing a file left empty
+func TestRemoveAlias(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "commands.yml")
+	SetAlias(path, Alias{Name: "rp", Command: "replace"})
+
+	if err := RemoveAlias(path, "missing"); !errors.Is(err, ErrAliasNotFound) {
+		t.Errorf("Expected ErrAliasNotFound, got %v", err)
+	}
+	if err := RemoveAlias(path, "rp"); err != nil {
+		t.Fatalf("RemoveAlias() failed: %v", err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected the empty file to be removed, got %v", err)
+	}
+}
+
+// TestValidateAliases tests the checks on alias definitions
+func TestValidateAliases(t *testing.T) {
+	testCases := []struct {
+		aliases  []Alias
+		expected string
+	}{
+		{[]Alias{{Name: "rp", Command: "replace"}}, ""},
+		{[]Alias{{Command: "replace"}}, "name is required"},
+		{[]Alias{{Name: "rp"}}, "command is required"},
+		{[]Alias{{Name: "r p", Command: "replace"}}, "contain spaces"},
+		{[]Alias{{Name: "rp", Command: "replace"}, {Name: "rp", Command: "find"}}, "duplicate alias name"},
+	}
+	for _, tc := range testCases {
+		err := validateAliases(tc.aliases)
+		if tc.expected == "" && err != nil {
+			t.Errorf("%+v: unexpected error %v", tc.aliases, err)
+		}
+		if tc.expected != "" && (err == nil || !strings.Contains(err.Error(), tc.expected)) {
+			t.Errorf("%+v: expected error containing %q, got %v", tc.aliases, tc.expected, err)
+		}
+	}
+}
+
+// TestMergeLayers_Aliases tests that a higher layer's alias replaces a lower one
+func TestMergeLayers_Aliases(t *testing.T) {
+	shared := &Config{Aliases: []Alias{{Name: "rp", Command: "replace"}, {Name: "ff", Command: "find"}}}
+	user := &Config{Aliases: []Alias{{Name: "rp", Command: "replace", Args: []string{"--in-place"}}}}
+	merged := MergeLayers(shared, user)
+	if len(merged.Aliases) != 2 {
+		t.Fatalf("Expected 2 aliases, got %+v", merged.Aliases)
+	}
+	if alias, _ := merged.FindAlias("rp"); len(alias.Args) != 1 {
+		t.Errorf("Expected the user's rp, got %+v", alias)
+	}
+}
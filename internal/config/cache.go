@@ -0,0 +1,209 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"expvar"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	// CacheDirEnvVar overrides the directory goldfish uses for cached data
+	CacheDirEnvVar = "GOLDFISH_CACHE_DIR"
+	// NoCacheEnvVar disables all configuration caching when set to a non-empty value
+	NoCacheEnvVar = "GOLDFISH_NO_CACHE"
+)
+
+// Cache metrics are process-wide counters published through expvar under
+// "goldfish_config" (visible at /debug/vars in serve mode)
+var (
+	metricValidationCacheHits   = new(expvar.Int)
+	metricValidationCacheMisses = new(expvar.Int)
+	metricParsedCacheHits       = new(expvar.Int)
+	metricParsedCacheMisses     = new(expvar.Int)
+)
+
+// init registers the counters; expvar names are global, so this happens once
+func init() {
+	published := expvar.NewMap("goldfish_config")
+	published.Set("validation_cache_hits", metricValidationCacheHits)
+	published.Set("validation_cache_misses", metricValidationCacheMisses)
+	published.Set("parsed_cache_hits", metricParsedCacheHits)
+	published.Set("parsed_cache_misses", metricParsedCacheMisses)
+
+	// YAML decodes lists and maps held in interface{} fields (parameter
+	// defaults, workflow step params) into these types, and gob can only
+	// encode interface values of types it has been told about
+	gob.Register([]interface{}{})
+	gob.Register(map[string]interface{}{})
+}
+
+// CacheDir returns the directory where goldfish stores cached data
+// GOLDFISH_CACHE_DIR takes precedence; otherwise UserCacheDir is used
+// (e.g. ~/.cache/goldfish on Linux)
+func CacheDir() (string, error) {
+	if dir := os.Getenv(CacheDirEnvVar); dir != "" {
+		return dir, nil
+	}
+	return UserCacheDir()
+}
+
+// cacheEnabled reports whether configuration caching is switched on
+func cacheEnabled() bool {
+	return os.Getenv(NoCacheEnvVar) == ""
+}
+
+// binaryFingerprint identifies the running goldfish binary
+// Including it in cache keys means a new build (which may have different
+// validation rules) never trusts results recorded by an older one
+var binaryFingerprint = sync.OnceValue(func() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return "unknown"
+	}
+	info, err := os.Stat(exe)
+	if err != nil {
+		return exe
+	}
+	return fmt.Sprintf("%s|%d|%d", exe, info.Size(), info.ModTime().UnixNano())
+})
+
+// contentCacheKey returns the cache key for a configuration's raw content
+func contentCacheKey(data []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(binaryFingerprint()))
+	hash.Write([]byte{0})
+	hash.Write(data)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// validationMarkerPath returns the marker file recording that data passed validation
+func validationMarkerPath(data []byte) (string, error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "validated", contentCacheKey(data)), nil
+}
+
+// isValidated reports whether identical content has already passed validation
+// Because the key is a hash of the content, any change to a file automatically
+// misses the cache
+func isValidated(data []byte) bool {
+	if !cacheEnabled() {
+		return false
+	}
+	path, err := validationMarkerPath(data)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+// markValidated records that the content passed validation
+// Caching is an optimization, so failures to write the marker are ignored
+func markValidated(data []byte) {
+	if !cacheEnabled() {
+		return
+	}
+	path, err := validationMarkerPath(data)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+	_ = os.WriteFile(path, nil, 0644)
+}
+
+// validateCached validates a configuration unless identical content was validated before
+// data is the raw file content the configuration was parsed from
+func (l *Loader) validateCached(config *Config, data []byte) error {
+	if isValidated(data) {
+		metricValidationCacheHits.Add(1)
+		return nil
+	}
+	metricValidationCacheMisses.Add(1)
+	if err := l.validate(config); err != nil {
+		return err
+	}
+	markValidated(data)
+	return nil
+}
+
+// parsedCachePath returns the file holding the configuration parsed from data
+func parsedCachePath(data []byte) (string, error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "parsed", contentCacheKey(data)+".gob"), nil
+}
+
+// loadParsed returns the configuration parsed and validated from identical content before
+// Decoding the cached gob is much faster than parsing YAML, which matters when
+// shell prompts and scripts run goldfish many times a second. Like the
+// validation marker, the key is a hash of the content, so edited files miss.
+func loadParsed(data []byte) (*Config, bool) {
+	if !cacheEnabled() {
+		return nil, false
+	}
+	path, err := parsedCachePath(data)
+	if err != nil {
+		return nil, false
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		metricParsedCacheMisses.Add(1)
+		return nil, false
+	}
+	defer file.Close()
+
+	var config Config
+	if err := gob.NewDecoder(bufio.NewReader(file)).Decode(&config); err != nil {
+		// A damaged entry is treated as a miss and replaced by storeParsed
+		metricParsedCacheMisses.Add(1)
+		return nil, false
+	}
+	metricParsedCacheHits.Add(1)
+	return &config, true
+}
+
+// storeParsed records a configuration that was parsed from data and passed validation
+// Caching is an optimization, so failures (including values gob cannot
+// encode) only mean the next load parses the YAML again
+func storeParsed(data []byte, config *Config) {
+	if !cacheEnabled() {
+		return
+	}
+	path, err := parsedCachePath(data)
+	if err != nil {
+		return
+	}
+	var encoded bytes.Buffer
+	if err := gob.NewEncoder(&encoded).Encode(config); err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+
+	// Several goldfish processes may store the same entry at once, so each
+	// writes its own temporary file and renames it into place
+	tmp, err := os.CreateTemp(filepath.Dir(path), "parsing-*")
+	if err != nil {
+		return
+	}
+	_, writeErr := tmp.Write(encoded.Bytes())
+	closeErr := tmp.Close()
+	if writeErr != nil || closeErr != nil || os.Rename(tmp.Name(), path) != nil {
+		os.Remove(tmp.Name())
+	}
+}
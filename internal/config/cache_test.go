@@ -0,0 +1,210 @@
+package config
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestMain keeps the package's tests from writing into the real user cache
+// and silences warnings about skipped files
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "goldfish-cache-test")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv(CacheDirEnvVar, dir)
+	// Several tests load broken files on purpose
+	Warnings = io.Discard
+	// Plugins on the developer's PATH must not leak into layer tests
+	os.Setenv(NoPluginsEnvVar, "1")
+
+	code := m.Run()
+
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// TestCacheDir tests cache directory resolution
+func TestCacheDir(t *testing.T) {
+	t.Setenv(CacheDirEnvVar, "/tmp/goldfish-custom-cache")
+	dir, err := CacheDir()
+	if err != nil {
+		t.Fatalf("CacheDir() failed: %v", err)
+	}
+	if dir != "/tmp/goldfish-custom-cache" {
+		t.Errorf("Expected %s override to be used, got %s", CacheDirEnvVar, dir)
+	}
+
+	// Without the override the platform cache directory is used
+	t.Setenv(CacheDirEnvVar, "")
+	dir, err = CacheDir()
+	if err == nil && filepath.Base(dir) != "goldfish" {
+		t.Errorf("Expected default cache dir to end in goldfish, got %s", dir)
+	}
+}
+
+// TestLoader_validateCached tests that validation results are reused for identical content
+func TestLoader_validateCached(t *testing.T) {
+	t.Setenv(CacheDirEnvVar, t.TempDir())
+	loader := NewLoader("")
+	data := []byte("commands: [cached-content]")
+
+	valid := &Config{Commands: []Command{{
+		Name:        "test",
+		BaseCommand: "echo",
+		Platforms:   map[string]PlatformCommand{"linux": {Template: "echo"}},
+	}}}
+
+	if isValidated(data) {
+		t.Fatal("Expected fresh content not to be cached")
+	}
+	if err := loader.validateCached(valid, data); err != nil {
+		t.Fatalf("validateCached() failed: %v", err)
+	}
+	if !isValidated(data) {
+		t.Fatal("Expected content to be cached after successful validation")
+	}
+
+	// Identical content is not validated again, so even an (impossible)
+	// invalid parse result passes; this proves validation was skipped
+	if err := loader.validateCached(&Config{}, data); err != nil {
+		t.Errorf("Expected cached content to skip validation, got %v", err)
+	}
+
+	// Different content misses the cache and is validated normally
+	if err := loader.validateCached(&Config{}, []byte("commands: []")); err == nil {
+		t.Error("Expected changed content to be validated")
+	}
+}
+
+// TestLoader_validateCached_Disabled tests that GOLDFISH_NO_CACHE disables the cache
+func TestLoader_validateCached_Disabled(t *testing.T) {
+	t.Setenv(CacheDirEnvVar, t.TempDir())
+	t.Setenv(NoCacheEnvVar, "1")
+	loader := NewLoader("")
+	data := []byte("commands: [uncached]")
+
+	valid := &Config{Commands: []Command{{
+		Name:        "test",
+		BaseCommand: "echo",
+		Platforms:   map[string]PlatformCommand{"linux": {Template: "echo"}},
+	}}}
+	if err := loader.validateCached(valid, data); err != nil {
+		t.Fatalf("validateCached() failed: %v", err)
+	}
+	if isValidated(data) {
+		t.Error("Expected nothing to be cached when caching is disabled")
+	}
+}
+
+// TestLoader_validateCached_InvalidNotCached tests that failures are never cached
+func TestLoader_validateCached_InvalidNotCached(t *testing.T) {
+	t.Setenv(CacheDirEnvVar, t.TempDir())
+	loader := NewLoader("")
+	data := []byte("commands: []")
+
+	if err := loader.validateCached(&Config{}, data); err == nil {
+		t.Fatal("Expected validation error for empty config")
+	}
+	if isValidated(data) {
+		t.Error("Expected failed validation not to be cached")
+	}
+}
+
+// TestLoader_Parse_ParsedCache tests that parsed configurations are reused for identical content
+func TestLoader_Parse_ParsedCache(t *testing.T) {
+	t.Setenv(CacheDirEnvVar, t.TempDir())
+	data := []byte(`commands:
+  - name: "greet"
+    base_command: "echo"
+    params:
+      - name: "count"
+        type: "int"
+        default: 2
+      - name: "names"
+        type: "string"
+        default: ["a", "b"]
+    expect: {}
+    platforms:
+      linux:
+        template: "echo hi"
+`)
+
+	parsed, err := NewLoader("").Parse(data)
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+	cached, found := loadParsed(data)
+	if !found {
+		t.Fatal("Expected the parsed configuration to be cached")
+	}
+	// Typed defaults must survive the round trip (an int stays an int)
+	if !reflect.DeepEqual(parsed, cached) {
+		t.Errorf("Cached configuration differs:\nparsed: %+v\ncached: %+v", parsed, cached)
+	}
+
+	// Later loads return the cached copy, which callers may modify freely
+	cached.Commands[0].Name = "changed"
+	again, err := NewLoader("").Parse(data)
+	if err != nil || again.Commands[0].Name != "greet" {
+		t.Errorf("Expected an unmodified copy from the cache, got %+v, %v", again, err)
+	}
+}
+
+// TestLoader_Parse_ParsedCacheSkipped tests content that must not be cached
+func TestLoader_Parse_ParsedCacheSkipped(t *testing.T) {
+	t.Setenv(CacheDirEnvVar, t.TempDir())
+
+	// Outdated files are upgraded on every load so the warning keeps appearing
+	withRenameMigration(t)
+	outdated := []byte("version: 1\ncmds:\n  - name: old\n    base_command: echo\n    platforms:\n      linux:\n        template: echo\n")
+	if _, err := NewLoader("old.yml").Parse(outdated); err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+	if _, found := loadParsed(outdated); found {
+		t.Error("Expected an outdated file not to be cached")
+	}
+
+	// Invalid content is never cached
+	invalid := []byte("commands: []")
+	if _, err := NewLoader("").Parse(invalid); err == nil {
+		t.Fatal("Expected validation error")
+	}
+	if _, found := loadParsed(invalid); found {
+		t.Error("Expected invalid content not to be cached")
+	}
+
+	// A damaged entry is a miss rather than an error
+	valid := []byte("commands:\n  - name: ok\n    base_command: echo\n    platforms:\n      linux:\n        template: echo\n")
+	path, _ := parsedCachePath(valid)
+	os.MkdirAll(filepath.Dir(path), 0755)
+	os.WriteFile(path, []byte("not gob"), 0644)
+	if cfg, err := NewLoader("").Parse(valid); err != nil || cfg.Commands[0].Name != "ok" {
+		t.Errorf("Expected a damaged entry to be replaced, got %+v, %v", cfg, err)
+	}
+	if _, found := loadParsed(valid); !found {
+		t.Error("Expected the damaged entry to be rewritten")
+	}
+}
+
+// TestLoadDefaults_ParsedCache tests that cached defaults match freshly parsed ones
+func TestLoadDefaults_ParsedCache(t *testing.T) {
+	t.Setenv(CacheDirEnvVar, t.TempDir())
+	fresh, err := LoadDefaults()
+	if err != nil {
+		t.Fatalf("LoadDefaults() failed: %v", err)
+	}
+	cached, err := LoadDefaults()
+	if err != nil {
+		t.Fatalf("LoadDefaults() failed: %v", err)
+	}
+	if _, found := loadParsed(defaultCommandsYAML); !found {
+		t.Fatal("Expected the defaults to be cached")
+	}
+	if !reflect.DeepEqual(fresh, cached) {
+		t.Error("Expected cached defaults to match the parsed ones")
+	}
+}
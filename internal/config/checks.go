@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Check is a precondition a command verifies before it runs (check:)
+// Each check is one of a templated check command or a built-in assertion.
+// All the text fields are templates, rendered with the command's data.
+// A failing check stops the command before it starts, so a destructive
+// operation is not half run on a bad precondition.
+type Check struct {
+	// Run is a command line, escaped and run like the template, that passes
+	// when it exits with 0, e.g. "systemctl is-active --quiet postgresql"
+	Run string `yaml:"run,omitempty"`
+	// FileExists passes when the file or directory exists
+	FileExists string `yaml:"file_exists,omitempty"`
+	// PortOpen passes when a TCP connection to host:port can be made
+	PortOpen string `yaml:"port_open,omitempty"`
+	// MinDiskSpace passes when the file system holding Path has at least
+	// this much space available, e.g. "10GB", "512MiB" or a number of bytes
+	MinDiskSpace string `yaml:"min_disk_space,omitempty"`
+	// Path is the path whose file system min_disk_space checks (default ".")
+	Path string `yaml:"path,omitempty"`
+	// Message replaces the description of the failure, e.g. "the database
+	// must be running; start it with systemctl start postgresql"
+	Message string `yaml:"message,omitempty"`
+}
+
+// Kind returns which check c is: run, file_exists, port_open or min_disk_space
+func (c *Check) Kind() string {
+	switch {
+	case c.Run != "":
+		return "run"
+	case c.FileExists != "":
+		return "file_exists"
+	case c.PortOpen != "":
+		return "port_open"
+	case c.MinDiskSpace != "":
+		return "min_disk_space"
+	}
+	return ""
+}
+
+// validateChecks checks that each check is exactly one kind of check
+func validateChecks(checks []Check) error {
+	for i, check := range checks {
+		set := 0
+		for _, value := range []string{check.Run, check.FileExists, check.PortOpen, check.MinDiskSpace} {
+			if value != "" {
+				set++
+			}
+		}
+		if set != 1 {
+			return fmt.Errorf("check %d: set exactly one of run, file_exists, port_open and min_disk_space", i+1)
+		}
+		if check.Path != "" && check.MinDiskSpace == "" {
+			return fmt.Errorf("check %d: path only applies to min_disk_space", i+1)
+		}
+		// Sizes given as templates are checked when they are rendered
+		if check.MinDiskSpace != "" && !strings.Contains(check.MinDiskSpace, "{{") {
+			if _, err := ParseSize(check.MinDiskSpace); err != nil {
+				return fmt.Errorf("check %d: min_disk_space: %w", i+1, err)
+			}
+		}
+	}
+	return nil
+}
+
+// sizeUnits are the units ParseSize accepts, in bytes
+var sizeUnits = map[string]uint64{
+	"":    1,
+	"B":   1,
+	"KB":  1000,
+	"MB":  1000 * 1000,
+	"GB":  1000 * 1000 * 1000,
+	"TB":  1000 * 1000 * 1000 * 1000,
+	"KIB": 1 << 10,
+	"MIB": 1 << 20,
+	"GIB": 1 << 30,
+	"TIB": 1 << 40,
+}
+
+// ParseSize parses a size such as "10GB", "1.5 GiB" or "4096" into bytes
+// KB, MB, GB and TB are powers of 1000, and KiB, MiB, GiB and TiB of 1024.
+func ParseSize(text string) (uint64, error) {
+	trimmed := strings.TrimSpace(text)
+	number := strings.TrimRight(trimmed, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ ")
+	unit, found := sizeUnits[strings.ToUpper(strings.TrimSpace(trimmed[len(number):]))]
+	value, err := strconv.ParseFloat(number, 64)
+	if !found || err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size '%s': use a number of bytes or a unit such as 500MB or 10GiB", text)
+	}
+	return uint64(value * float64(unit)), nil
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestValidateChecks tests that each check is one kind with valid settings
+func TestValidateChecks(t *testing.T) {
+	testCases := []struct {
+		check Check
+		err   string
+	}{
+		{Check{Run: "systemctl is-active --quiet postgresql"}, ""},
+		{Check{FileExists: "{{.params.file}}", Message: "no file"}, ""},
+		{Check{PortOpen: "localhost:5432"}, ""},
+		{Check{MinDiskSpace: "10GB", Path: "/var"}, ""},
+		{Check{MinDiskSpace: "{{.vars.needed}}"}, ""},
+		{Check{}, "set exactly one of"},
+		{Check{Run: "true", FileExists: "/etc"}, "set exactly one of"},
+		{Check{FileExists: "/etc", Path: "/var"}, "path only applies to min_disk_space"},
+		{Check{MinDiskSpace: "lots"}, "invalid size 'lots'"},
+	}
+	for _, tc := range testCases {
+		err := validateChecks([]Check{tc.check})
+		if tc.err == "" && err != nil {
+			t.Errorf("%+v: unexpected error: %v", tc.check, err)
+		}
+		if tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)) {
+			t.Errorf("%+v: expected error containing %q, got %v", tc.check, tc.err, err)
+		}
+	}
+}
+
+// TestParseSize tests parsing sizes with decimal and binary units
+func TestParseSize(t *testing.T) {
+	testCases := map[string]uint64{
+		"4096":    4096,
+		"10GB":    10 * 1000 * 1000 * 1000,
+		"1.5 GiB": 3 << 29,
+		"512mib":  512 << 20,
+		"2 KB":    2000,
+		"0":       0,
+	}
+	for text, expected := range testCases {
+		if size, err := ParseSize(text); err != nil || size != expected {
+			t.Errorf("ParseSize(%q): expected %d, got %d (%v)", text, expected, size, err)
+		}
+	}
+	for _, text := range []string{"", "GB", "ten", "-1GB", "5 parsecs"} {
+		if _, err := ParseSize(text); err == nil {
+			t.Errorf("ParseSize(%q): expected an error", text)
+		}
+	}
+}
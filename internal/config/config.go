@@ -4,11 +4,17 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
-	"gopkg.in/yaml.v3"
+	"github.com/danballance/goldfish/internal/platform"
 )
 
 // Parameter represents a command parameter definition
@@ -26,6 +32,14 @@ type Parameter struct {
 	Default interface{} `yaml:"default,omitempty"`
 	// Description explains what this parameter does
 	Description string `yaml:"description,omitempty"`
+	// Secret marks values (passwords, tokens) that must be masked in logs
+	Secret bool `yaml:"secret,omitempty"`
+	// Validate names a validator exported by a WASM module (see wasm.go) that
+	// the value must pass before the command runs
+	Validate string `yaml:"validate,omitempty"`
+	// Use names an entry of the file's param_defs: to start from; fields set
+	// alongside it override the definition (see paramdefs.go)
+	Use string `yaml:"use,omitempty"`
 }
 
 // PlatformCommand represents a platform-specific command template
@@ -33,6 +47,219 @@ type Parameter struct {
 type PlatformCommand struct {
 	// Template is the Go template string for command generation
 	Template string `yaml:"template"`
+	// Arch limits the template to these CPU architectures (e.g. ["arm64"]);
+	// on others the next matching platforms: key is used instead
+	Arch []string `yaml:"arch,omitempty"`
+	// MinVersion and MaxVersion limit the template to a range of OS releases
+	// (e.g. "13" for macOS 13 and later); both ends are inclusive
+	MinVersion string `yaml:"min_version,omitempty"`
+	MaxVersion string `yaml:"max_version,omitempty"`
+	// When is a template condition over the parameters and platform (e.g.
+	// "{{.params.extended}}"); the template is only used when it renders true
+	When string `yaml:"when,omitempty"`
+	// Variants are alternative templates for other CPUs, OS releases or
+	// parameters. The first variant whose conditions hold is used, then this template.
+	Variants []PlatformCommand `yaml:"variants,omitempty"`
+	// Shell runs the rendered template: "sh", "cmd" or "powershell" (pwsh,
+	// or Windows PowerShell where pwsh is not installed). The default is sh,
+	// or cmd on Windows.
+	Shell string `yaml:"shell,omitempty"`
+	// FlagMap builds the command line instead of a template: it maps
+	// parameter names to the flags they become on this platform (e.g.
+	// in_place: "-i" on linux, "-i ''" on darwin). The engine appends them to
+	// base_command in the order the parameters are declared, quoting values.
+	FlagMap map[string]string `yaml:"flag_map,omitempty"`
+	// OutputFilter is a template applied to each line of the command's
+	// stdout, seen as {{.line}}, to normalise how tools print on this
+	// platform; lines that render to nothing are dropped
+	OutputFilter string `yaml:"output_filter,omitempty"`
+}
+
+// SupportsArch reports whether the template may be used on a CPU architecture
+// An empty arch (not known, e.g. when rendering for another platform) always matches
+func (p *PlatformCommand) SupportsArch(arch string) bool {
+	if len(p.Arch) == 0 || arch == "" {
+		return true
+	}
+	for _, allowed := range p.Arch {
+		if allowed == arch {
+			return true
+		}
+	}
+	return false
+}
+
+// SupportsVersion reports whether the template may be used on an OS release
+// Like an unknown arch, an unknown or unparsable version always matches
+func (p *PlatformCommand) SupportsVersion(version string) bool {
+	if p.MinVersion != "" {
+		if result, ok := platform.CompareVersion(version, p.MinVersion); ok && result < 0 {
+			return false
+		}
+	}
+	if p.MaxVersion != "" {
+		if result, ok := platform.CompareVersion(version, p.MaxVersion); ok && result > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Resolve returns the template to use on a system with the given CPU
+// architecture and OS version: the first matching variant, or else p itself
+// It reports false when neither the variants nor p match. when: conditions
+// are assumed to hold; see ResolveWhen.
+func (p *PlatformCommand) Resolve(arch, version string) (PlatformCommand, bool) {
+	return p.ResolveWhen(arch, version, nil)
+}
+
+// ResolveWhen is Resolve with when: conditions checked by holds
+// holds is only called for templates whose other conditions hold; nil
+// assumes every when: condition holds.
+func (p *PlatformCommand) ResolveWhen(arch, version string, holds func(when string) bool) (PlatformCommand, bool) {
+	matches := func(candidate *PlatformCommand) bool {
+		if !candidate.SupportsArch(arch) || !candidate.SupportsVersion(version) {
+			return false
+		}
+		return candidate.When == "" || holds == nil || holds(candidate.When)
+	}
+	for _, variant := range p.Variants {
+		if matches(&variant) {
+			return variant, true
+		}
+	}
+	if matches(p) {
+		return *p, true
+	}
+	return PlatformCommand{}, false
+}
+
+// Expectation describes postconditions that are verified after a command runs
+// It lets command packs detect flaky tools that exit successfully without doing their job
+type Expectation struct {
+	// ExitCodes lists the exit codes that count as success (default: only 0)
+	ExitCodes []int `yaml:"exit_codes,omitempty"`
+	// StdoutMatches is a regular expression the command's stdout must match
+	StdoutMatches string `yaml:"stdout_matches,omitempty"`
+	// FilesExist lists paths (which may use template syntax) that must exist afterwards
+	FilesExist []string `yaml:"files_exist,omitempty"`
+}
+
+// AllowsExitCode reports whether the given exit code counts as success
+func (e *Expectation) AllowsExitCode(code int) bool {
+	if len(e.ExitCodes) == 0 {
+		return code == 0
+	}
+	for _, allowed := range e.ExitCodes {
+		if allowed == code {
+			return true
+		}
+	}
+	return false
+}
+
+// CachePolicy describes how long a command's output is reused instead of
+// running it again, for slow discovery commands (cloud CLIs, inventories)
+// that prompts and scripts call over and over
+type CachePolicy struct {
+	// TTL is how long a result is reused, e.g. 10m
+	TTL time.Duration `yaml:"ttl"`
+	// Key is a template whose rendered value, with the rendered command line,
+	// identifies a result, e.g. "{{.params.host}}"; results are kept apart by
+	// the command line alone when it is empty
+	Key string `yaml:"key,omitempty"`
+}
+
+// RetryPolicy describes how a command that fails is run again
+// It is meant for flaky tools such as network transfers (curl, scp)
+type RetryPolicy struct {
+	// Attempts is the total number of times the command may run, including the first
+	Attempts int `yaml:"attempts"`
+	// Backoff is the delay before the first retry; it doubles before each further retry
+	Backoff time.Duration `yaml:"backoff,omitempty"`
+	// OnExitCodes lists the exit codes that trigger a retry (default: any non-zero code)
+	OnExitCodes []int `yaml:"on_exit_codes,omitempty"`
+}
+
+// ShouldRetry reports whether a run that ended with exitCode should be retried
+// attempt is the number of the run that just finished, starting at 1.
+// A nil policy never retries, so callers need not check for one.
+func (r *RetryPolicy) ShouldRetry(exitCode, attempt int) bool {
+	if r == nil || exitCode == 0 || attempt >= r.Attempts {
+		return false
+	}
+	if len(r.OnExitCodes) == 0 {
+		return true
+	}
+	for _, code := range r.OnExitCodes {
+		if code == exitCode {
+			return true
+		}
+	}
+	return false
+}
+
+// maxRetryDelay caps the wait between retries, however many attempts a
+// policy allows
+const maxRetryDelay = 10 * time.Minute
+
+// Delay returns how long to wait after the given attempt before running again
+// The backoff doubles each time: 2s, 4s, 8s, ... up to maxRetryDelay. It is
+// doubled step by step rather than shifted, which would overflow into zero or
+// negative delays after enough attempts.
+func (r *RetryPolicy) Delay(attempt int) time.Duration {
+	if r == nil || attempt < 1 || r.Backoff <= 0 {
+		return 0
+	}
+	delay := r.Backoff
+	for i := 1; i < attempt && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetryDelay)
+}
+
+// ResourceLimits keeps a heavy command (compression, media conversion) from
+// starving interactive sessions. The engine applies them before the command
+// starts, and everything the command starts inherits them.
+type ResourceLimits struct {
+	// Nice is the scheduling priority from -20 (first) to 19 (last), as for
+	// nice(1); on Windows it picks a priority class. Below 0 needs root.
+	Nice int `yaml:"nice,omitempty"`
+	// IOPriority is "idle" (disk access only when nothing else needs it) or
+	// "low"; it is applied with ionice on Linux, where it is installed
+	IOPriority string `yaml:"io_priority,omitempty"`
+	// MemoryMB caps the memory each process may use
+	MemoryMB int `yaml:"memory_mb,omitempty"`
+	// CPUSeconds caps the CPU time each process may use; it is killed after
+	CPUSeconds int `yaml:"cpu_seconds,omitempty"`
+}
+
+// SandboxPolicy says what a command may do when it runs with --sandbox
+// Templates run arbitrary shell, so a shared configuration can do anything
+// the user can; the sandbox is a second line of defence.
+type SandboxPolicy struct {
+	// ReadOnly makes the file system read-only, except for AllowPaths
+	ReadOnly bool `yaml:"read_only,omitempty"`
+	// Network lets the command use the network, which the sandbox blocks otherwise
+	Network bool `yaml:"network,omitempty"`
+	// AllowPaths stay writable when ReadOnly is set; they may use templates,
+	// e.g. "{{.params.output_dir}}"
+	AllowPaths []string `yaml:"allow_paths,omitempty"`
+}
+
+// TemplateTest is an example invocation of a command and the command lines
+// it renders to; "goldfish test" renders it on each platform, without
+// running anything, and reports where the result differs
+type TemplateTest struct {
+	// Name identifies the test in reports
+	Name string `yaml:"name"`
+	// Args and Params are the invocation: positional arguments, and
+	// parameters by name as in a batch job
+	Args   []string               `yaml:"args,omitempty"`
+	Params map[string]interface{} `yaml:"params,omitempty"`
+	// Expect maps platform names (linux, darwin, windows) to the command
+	// line expected there
+	Expect map[string]string `yaml:"expect"`
 }
 
 // Command represents a unified command definition
@@ -44,24 +271,160 @@ type Command struct {
 	Alias string `yaml:"alias,omitempty"`
 	// Description explains what this command does
 	Description string `yaml:"description"`
+	// Tags sort the command into topics, e.g. [files, network]; help groups
+	// commands by their first tag (see tags.go)
+	Tags []string `yaml:"tags,omitempty"`
 	// BaseCommand is the underlying system command (e.g., "sed", "find")
 	BaseCommand string `yaml:"base_command"`
 	// Parameters defines the accepted command parameters
 	Parameters []Parameter `yaml:"params,omitempty"`
+	// Vars are templates computed after the parameters are parsed and given
+	// to platform templates as {{.vars.name}} (see vars.go)
+	Vars map[string]string `yaml:"vars,omitempty"`
 	// Platforms maps platform names to their command templates
 	Platforms map[string]PlatformCommand `yaml:"platforms"`
+	// Script is optional Starlark run before rendering; it can fill in
+	// parameters, choose the template or build the command line (see script.go)
+	Script string `yaml:"script,omitempty"`
+	// NormalizeLocale runs the command with a fixed locale and timezone so that
+	// sort orders, date formats and decimal separators match on every machine
+	NormalizeLocale bool `yaml:"normalize_locale,omitempty"`
+	// Locale overrides the LC_ALL value used when NormalizeLocale is set (default "C.UTF-8")
+	Locale string `yaml:"locale,omitempty"`
+	// Timezone overrides the TZ value used when NormalizeLocale is set (default "UTC")
+	Timezone string `yaml:"timezone,omitempty"`
+	// LogOutput is a file the command's output is copied to, with each line
+	// timestamped; it may use templates, e.g. "/var/log/backup-{{now.Format "20060102"}}.log"
+	LogOutput string `yaml:"log_output,omitempty"`
+	// Progress shows a spinner with the elapsed time while the command prints
+	// nothing, for long commands such as backups that would look frozen
+	Progress bool `yaml:"progress,omitempty"`
+	// StdinParam names a parameter that takes piped standard input when it is
+	// not given, as in "cat data | goldfish transform"; templates also see the
+	// input as {{.stdin}} and {{.stdin_file}}, as with --pipe
+	StdinParam string `yaml:"stdin_param,omitempty"`
+	// MaxOutput caps the bytes of each output stream goldfish keeps in memory
+	// (captured output and expect.stdout_matches); 0 uses the engine's limit
+	MaxOutput int64 `yaml:"max_output,omitempty"`
+	// MaxLineLength truncates output lines longer than this many bytes, for
+	// tools that print megabytes without a newline; 0 uses the engine's limit
+	MaxLineLength int `yaml:"max_line_length,omitempty"`
+	// Expect declares optional postconditions checked after execution
+	Expect *Expectation `yaml:"expect,omitempty"`
+	// Hidden keeps the command out of help output; it can still be run by name
+	Hidden bool `yaml:"hidden,omitempty"`
+	// Experimental marks an in-progress command that only runs when
+	// GOLDFISH_EXPERIMENTAL=1 is set; otherwise it is also hidden
+	Experimental bool `yaml:"experimental,omitempty"`
+	// Deprecated is a migration hint (e.g. "use 'replace' instead"); the command
+	// still runs but is hidden from help and prints the hint when invoked
+	Deprecated string `yaml:"deprecated,omitempty"`
+	// EnabledIf is a template condition checked when goldfish starts, e.g.
+	// `{{has "vpn"}}` or `{{exists "/opt/cisco"}}`; the command only exists
+	// where it holds, and is not even listed elsewhere
+	EnabledIf string `yaml:"enabled_if,omitempty"`
+	// Destructive marks commands that modify or delete data (e.g. in-place edits);
+	// automated callers such as goldfish mcp must get explicit confirmation to run them
+	Destructive bool `yaml:"destructive,omitempty"`
+	// Retry optionally re-runs the command when it fails
+	Retry *RetryPolicy `yaml:"retry,omitempty"`
+	// Cache optionally reuses the output of a successful run for a while
+	Cache *CachePolicy `yaml:"cache,omitempty"`
+	// SkipIf is a check command line, rendered like the template, that makes
+	// the command report "already done" and exit 0 without running when it
+	// succeeds, e.g. "test -d {{.params.dir}}"; provisioning steps then
+	// converge rather than failing or repeating work
+	SkipIf string `yaml:"skip_if,omitempty"`
+	// Checks are preconditions that must all pass before the command runs
+	// (see checks.go)
+	Checks []Check `yaml:"check,omitempty"`
+	// OnFailure are command lines, rendered like the template, run in order
+	// when the command fails, e.g. to restore the backup an in-place edit made
+	OnFailure []string `yaml:"on_failure,omitempty"`
+	// Always are command lines run after the command however it ended, after
+	// any on_failure steps, e.g. to remove temporary files
+	Always []string `yaml:"always,omitempty"`
+	// Notify optionally announces that the command finished (see notify.go)
+	Notify *NotifyPolicy `yaml:"notify,omitempty"`
+	// Limits lowers the command's priority and caps its memory and CPU time
+	Limits *ResourceLimits `yaml:"limits,omitempty"`
+	// Sandbox is the policy the command runs under with --sandbox; without
+	// one, --sandbox makes the file system read-only and blocks the network
+	Sandbox *SandboxPolicy `yaml:"sandbox,omitempty"`
+	// Fallback names another command to suggest on platforms this one does not support
+	Fallback string `yaml:"fallback,omitempty"`
+	// InstallHints maps platform names to advice on making the command work there
+	// (e.g. darwin: "brew install gnu-sed"); shown when the platform is unsupported
+	InstallHints map[string]string `yaml:"install_hints,omitempty"`
+	// Tests are example invocations checked by "goldfish test"
+	Tests []TemplateTest `yaml:"tests,omitempty"`
+	// Merge is how the command overrides one of the same name in a lower
+	// layer: MergeReplace (the default) or MergeDeep (see deepmerge.go)
+	Merge string `yaml:"merge,omitempty"`
+	// Plugin is the executable that runs this command itself instead of a
+	// template; it is set for commands contributed by plugins (see plugins.go)
+	Plugin string `yaml:"-"`
+}
+
+// ExperimentalEnvVar enables commands marked experimental when set to 1 (or true)
+const ExperimentalEnvVar = "GOLDFISH_EXPERIMENTAL"
+
+// ExperimentalEnabled reports whether experimental commands may run
+func ExperimentalEnabled() bool {
+	enabled, err := strconv.ParseBool(os.Getenv(ExperimentalEnvVar))
+	return err == nil && enabled
+}
+
+// Listed reports whether the command should appear in help and command lists
+// Hidden and deprecated commands never do; experimental ones only when enabled
+func (c *Command) Listed() bool {
+	return !c.Hidden && c.Deprecated == "" && (!c.Experimental || ExperimentalEnabled())
 }
 
 // Config represents the complete goldfish configuration
 // It contains all command definitions loaded from commands.yml
 type Config struct {
+	// Version is the format version the file is written for (see migrate.go)
+	Version int `yaml:"version,omitempty"`
 	// Commands is the list of all available command definitions
 	Commands []Command `yaml:"commands"`
+	// Workflows compose commands into multi-step DAGs (see workflow.go)
+	Workflows []Workflow `yaml:"workflows,omitempty"`
+	// Pack describes the file when it is an installable command pack (see packs.go)
+	Pack *PackMetadata `yaml:"pack,omitempty"`
+	// Includes lists further files or URLs merged in beneath this one (see remote.go)
+	Includes []string `yaml:"includes,omitempty"`
+	// TrustedKeys are public keys remote configs and packs must be signed with (see signing.go)
+	TrustedKeys []string `yaml:"trusted_keys,omitempty"`
+	// Wasm lists sandboxed modules providing template helpers and validators (see wasm.go)
+	Wasm []WasmModule `yaml:"wasm,omitempty"`
+	// FallbackPlatforms lists, per platform, other platforms whose templates
+	// commands without a template of their own may use, e.g. {darwin: [linux]}
+	// GNU and BSD tools often share enough syntax for this to work.
+	FallbackPlatforms map[string][]string `yaml:"fallback_platforms,omitempty"`
+	// AllowedBaseCommands are the only programs commands may run in restricted
+	// mode (--restricted), e.g. [sed, find, grep]; see restricted.go in the engine
+	AllowedBaseCommands []string `yaml:"allowed_base_commands,omitempty"`
+	// Aliases are shorthands for commands with preset arguments (see aliases.go)
+	Aliases []Alias `yaml:"aliases,omitempty"`
+	// Merge is the default merge: of the file's commands (see deepmerge.go)
+	Merge string `yaml:"merge,omitempty"`
+	// ParamDefs are parameters defined once for the file's commands to
+	// reference with use: (see paramdefs.go)
+	ParamDefs []Parameter `yaml:"param_defs,omitempty"`
+	// Vars are templates rendered for every command and given to its
+	// templates as .globals (see globals.go)
+	Vars map[string]string `yaml:"vars,omitempty"`
+	// Profiles are named sets of globals and environment variables, one of
+	// which is selected per run (see profiles.go)
+	Profiles []Profile `yaml:"profiles,omitempty"`
 }
 
 // Loader handles loading and parsing of configuration files
 type Loader struct {
 	configPath string
+	// trustedKeys, when set, require the file to carry a valid signature
+	trustedKeys []string
 }
 
 // NewLoader creates a new configuration loader
@@ -72,9 +435,35 @@ func NewLoader(configPath string) *Loader {
 	}
 }
 
+// TrustKeys makes the loader reject content that is not signed by one of keys
+// It returns the loader so it can be chained after NewLoader
+func (l *Loader) TrustKeys(keys []string) *Loader {
+	l.trustedKeys = keys
+	return l
+}
+
 // Load reads and parses the YAML configuration file
 // It returns a Config struct containing all command definitions
 func (l *Loader) Load() (*Config, error) {
+	// Remote configuration is downloaded (and cached) rather than read from disk
+	if IsRemote(l.configPath) {
+		data, err := fetchRemote(l.configPath)
+		if err != nil {
+			return nil, err
+		}
+		if err := l.verify(data); err != nil {
+			return nil, err
+		}
+		config, err := l.Parse(data)
+		if err != nil {
+			return nil, err
+		}
+		if err := resolveWasmPaths(config, ""); err != nil {
+			return nil, err
+		}
+		return config, nil
+	}
+
 	// Check if config file exists
 	if _, err := os.Stat(l.configPath); os.IsNotExist(err) {
 		return nil, fmt.Errorf("config file not found: %s", l.configPath)
@@ -85,86 +474,366 @@ func (l *Loader) Load() (*Config, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file %s: %w", l.configPath, err)
 	}
+	if err := l.verify(data); err != nil {
+		return nil, err
+	}
 
-	// Parse YAML content
+	config, err := l.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	// WASM module paths are relative to the file that names them
+	if err := resolveWasmPaths(config, filepath.Dir(l.configPath)); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// Parse parses and validates configuration content that has already been read
+// It lets content from other sources (such as downloaded packs) be checked
+// exactly like a file on disk
+func (l *Loader) Parse(data []byte) (*Config, error) {
+	// Reuse the result of parsing this exact content before (see cache.go)
+	if cached, found := loadParsed(data); found {
+		return cached, nil
+	}
+
+	// Upgrade files written for an older format version first
+	upgraded, err := l.upgrade(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse YAML content, rejecting unknown keys
 	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
+	if err := decodeStrict(upgraded, &config); err != nil {
 		return nil, fmt.Errorf("failed to parse YAML config: %w", err)
 	}
 
-	// Validate the loaded configuration
-	if err := l.validate(&config); err != nil {
+	// Expand parameters that use: a shared definition, so they are
+	// validated like any other
+	if err := l.resolveParamDefs(&config); err != nil {
 		return nil, fmt.Errorf("config validation failed: %w", err)
 	}
 
+	// Validate the loaded configuration (skipped if this exact content was validated before)
+	if err := l.validateCached(&config, upgraded); err != nil {
+		return nil, fmt.Errorf("config validation failed: %w", err)
+	}
+
+	// Outdated files are not cached, so the upgrade warning keeps appearing
+	// until the file is migrated
+	if bytes.Equal(upgraded, data) {
+		storeParsed(data, &config)
+	}
 	return &config, nil
 }
 
 // validate performs validation on the loaded configuration
 // It checks for required fields and logical consistency
 func (l *Loader) validate(config *Config) error {
-	if len(config.Commands) == 0 {
+	// A file may add only workflows on top of commands defined elsewhere, or
+	// only include other files, configure trusted keys, load WASM modules,
+	// set fallback platforms or the programs allowed in restricted mode,
+	// define aliases, set globals or define profiles
+	if len(config.Commands) == 0 && len(config.Workflows) == 0 && len(config.Includes) == 0 && len(config.TrustedKeys) == 0 && len(config.Wasm) == 0 && len(config.FallbackPlatforms) == 0 && len(config.AllowedBaseCommands) == 0 && len(config.Aliases) == 0 && len(config.Vars) == 0 && len(config.Profiles) == 0 {
 		return fmt.Errorf("no commands defined in configuration")
 	}
 
+	if err := validateAliases(config.Aliases); err != nil {
+		return &ValidationError{Source: l.configPath, Path: "aliases", Err: err}
+	}
+
+	if err := validateProfiles(config.Profiles); err != nil {
+		return &ValidationError{Source: l.configPath, Path: "profiles", Err: err}
+	}
+
+	if err := validateFallbackPlatforms(config.FallbackPlatforms); err != nil {
+		return err
+	}
+
+	if err := validateWasmModules(config.Wasm); err != nil {
+		return err
+	}
+
+	for _, program := range config.AllowedBaseCommands {
+		if strings.TrimSpace(program) == "" {
+			return fmt.Errorf("allowed_base_commands: empty program name")
+		}
+	}
+
+	for _, key := range config.TrustedKeys {
+		if _, err := decodePublicKey(key); err != nil {
+			return err
+		}
+	}
+
+	if err := validateGlobalNames(config.Vars); err != nil {
+		return &ValidationError{Source: l.configPath, Path: "vars", Err: err}
+	}
+
+	if !isValidMergeStrategy(config.Merge) {
+		return &ValidationError{Source: l.configPath, Path: "merge", Err: fmt.Errorf("merge must be %s or %s, got '%s'", MergeReplace, MergeDeep, config.Merge)}
+	}
+
 	// Track command names to detect duplicates
 	nameMap := make(map[string]bool)
 	aliasMap := make(map[string]bool)
 
 	for i, cmd := range config.Commands {
-		// Validate required fields
+		// Errors name the offending value's path in the file (see ValidationError)
+		path := fmt.Sprintf("commands[%d]", i)
+		invalid := func(subpath string, err error) error {
+			return &ValidationError{Source: l.configPath, Path: path + subpath, Err: err}
+		}
+
+		// Validate required fields; a command merged deeply into a lower
+		// layer's may leave out whatever it inherits from there
 		if cmd.Name == "" {
-			return fmt.Errorf("command at index %d: name is required", i)
+			return invalid(".name", fmt.Errorf("command at index %d: name is required", i))
+		}
+		if !isValidMergeStrategy(cmd.Merge) {
+			return invalid(".merge", fmt.Errorf("command '%s': merge must be %s or %s, got '%s'", cmd.Name, MergeReplace, MergeDeep, cmd.Merge))
 		}
-		if cmd.BaseCommand == "" {
-			return fmt.Errorf("command '%s': base_command is required", cmd.Name)
+		deep := cmd.mergeStrategy(config.Merge) == MergeDeep
+		if cmd.BaseCommand == "" && !deep {
+			return invalid(".base_command", fmt.Errorf("command '%s': base_command is required", cmd.Name))
 		}
-		if len(cmd.Platforms) == 0 {
-			return fmt.Errorf("command '%s': at least one platform must be defined", cmd.Name)
+		if len(cmd.Platforms) == 0 && !deep {
+			return invalid(".platforms", fmt.Errorf("command '%s': at least one platform must be defined", cmd.Name))
 		}
 
 		// Check for duplicate names
 		if nameMap[cmd.Name] {
-			return fmt.Errorf("duplicate command name: %s", cmd.Name)
+			return invalid(".name", fmt.Errorf("duplicate command name: %s", cmd.Name))
 		}
 		nameMap[cmd.Name] = true
 
 		// Check for duplicate aliases
 		if cmd.Alias != "" {
 			if aliasMap[cmd.Alias] || nameMap[cmd.Alias] {
-				return fmt.Errorf("duplicate command alias: %s", cmd.Alias)
+				return invalid(".alias", fmt.Errorf("duplicate command alias: %s", cmd.Alias))
 			}
 			aliasMap[cmd.Alias] = true
 		}
 
+		if err := validateTags(cmd.Tags); err != nil {
+			return invalid(".tags", fmt.Errorf("command '%s': %w", cmd.Name, err))
+		}
+
 		// Validate parameters
 		for j, param := range cmd.Parameters {
+			paramPath := fmt.Sprintf(".params[%d]", j)
 			if param.Name == "" {
-				return fmt.Errorf("command '%s': parameter at index %d: name is required", cmd.Name, j)
+				return invalid(paramPath+".name", fmt.Errorf("command '%s': parameter at index %d: name is required", cmd.Name, j))
 			}
-			if param.Type == "" {
-				return fmt.Errorf("command '%s': parameter '%s': type is required", cmd.Name, param.Name)
+			if param.Type == "" && !deep {
+				return invalid(paramPath+".type", fmt.Errorf("command '%s': parameter '%s': type is required", cmd.Name, param.Name))
 			}
-			if !isValidParameterType(param.Type) {
-				return fmt.Errorf("command '%s': parameter '%s': invalid type '%s'", cmd.Name, param.Name, param.Type)
+			if param.Type != "" && !isValidParameterType(param.Type) {
+				return invalid(paramPath+".type", fmt.Errorf("command '%s': parameter '%s': invalid type '%s'", cmd.Name, param.Name, param.Type))
 			}
 		}
 
+		// Templated defaults must refer to known parameters, and vars to other
+		// vars that exist, without cycles; for a deep merge, those may be
+		// in the lower layer
+		if !deep {
+			if _, err := cmd.DefaultOrder(); err != nil {
+				return invalid(".params", fmt.Errorf("command '%s': %w", cmd.Name, err))
+			}
+			if _, err := cmd.VarOrder(); err != nil {
+				return invalid(".vars", fmt.Errorf("command '%s': %w", cmd.Name, err))
+			}
+		}
+
+		// Piped input is text, so it can only fill a string parameter
+		if cmd.StdinParam != "" && !deep {
+			param, found := cmd.FindParameter(cmd.StdinParam)
+			if !found {
+				return invalid(".stdin_param", fmt.Errorf("command '%s': stdin_param: unknown parameter '%s'", cmd.Name, cmd.StdinParam))
+			}
+			if param.Type != "string" {
+				return invalid(".stdin_param", fmt.Errorf("command '%s': stdin_param: parameter '%s' must be a string, not %s", cmd.Name, cmd.StdinParam, param.Type))
+			}
+		}
+
+		// Validate the stdout expectation is a usable regular expression
+		if cmd.Expect != nil && cmd.Expect.StdoutMatches != "" {
+			if _, err := regexp.Compile(cmd.Expect.StdoutMatches); err != nil {
+				return invalid(".expect.stdout_matches", fmt.Errorf("command '%s': expect.stdout_matches: %w", cmd.Name, err))
+			}
+		}
+
+		// Output limits are sizes in bytes
+		if cmd.MaxOutput < 0 {
+			return invalid(".max_output", fmt.Errorf("command '%s': max_output must not be negative", cmd.Name))
+		}
+		if cmd.MaxLineLength < 0 {
+			return invalid(".max_line_length", fmt.Errorf("command '%s': max_line_length must not be negative", cmd.Name))
+		}
+
+		// Validate the resource limits
+		if cmd.Limits != nil {
+			if cmd.Limits.Nice < -20 || cmd.Limits.Nice > 19 {
+				return invalid(".limits.nice", fmt.Errorf("command '%s': limits.nice must be between -20 and 19", cmd.Name))
+			}
+			if cmd.Limits.IOPriority != "" && cmd.Limits.IOPriority != "idle" && cmd.Limits.IOPriority != "low" {
+				return invalid(".limits.io_priority", fmt.Errorf("command '%s': limits.io_priority must be idle or low", cmd.Name))
+			}
+			if cmd.Limits.MemoryMB < 0 {
+				return invalid(".limits.memory_mb", fmt.Errorf("command '%s': limits.memory_mb must not be negative", cmd.Name))
+			}
+			if cmd.Limits.CPUSeconds < 0 {
+				return invalid(".limits.cpu_seconds", fmt.Errorf("command '%s': limits.cpu_seconds must not be negative", cmd.Name))
+			}
+		}
+
+		// Validate the retry policy
+		if cmd.Retry != nil {
+			if cmd.Retry.Attempts < 1 {
+				return invalid(".retry.attempts", fmt.Errorf("command '%s': retry.attempts must be at least 1", cmd.Name))
+			}
+			if cmd.Retry.Backoff < 0 {
+				return invalid(".retry.backoff", fmt.Errorf("command '%s': retry.backoff must not be negative", cmd.Name))
+			}
+		}
+
+		// Validate the cache policy
+		if cmd.Cache != nil && cmd.Cache.TTL <= 0 {
+			return invalid(".cache.ttl", fmt.Errorf("command '%s': cache.ttl must be a positive duration, e.g. 10m", cmd.Name))
+		}
+
+		// Each precondition is one kind of check
+		if err := validateChecks(cmd.Checks); err != nil {
+			return invalid(".check", fmt.Errorf("command '%s': %w", cmd.Name, err))
+		}
+
+		// A notification needs somewhere to go
+		if cmd.Notify != nil {
+			if err := validateNotify(cmd.Notify); err != nil {
+				return invalid(".notify", fmt.Errorf("command '%s': notify: %w", cmd.Name, err))
+			}
+		}
+
+		// Template tests need a name and a command line for a known platform
+		if err := validateTemplateTests(cmd.Tests); err != nil {
+			return invalid(".tests", fmt.Errorf("command '%s': %w", cmd.Name, err))
+		}
+
+		// Validate the script, which may stand in for platform templates
+		if cmd.Script != "" {
+			if err := validateScript(&cmd); err != nil {
+				return invalid(".script", err)
+			}
+		}
+
+		// "posix" and "unix" (or "*", "any" and "default") are the same key, so
+		// a command may only use one of them
+		if err := validatePlatformKeys(&cmd); err != nil {
+			return invalid(".platforms", err)
+		}
+
 		// Validate platform templates
 		for platform, platformCmd := range cmd.Platforms {
-			if platformCmd.Template == "" {
-				return fmt.Errorf("command '%s': platform '%s': template is required", cmd.Name, platform)
+			platformPath := ".platforms." + platform
+			if err := validatePlatformCommand(&platformCmd, cmd.Script != ""); err != nil {
+				return invalid(platformPath, fmt.Errorf("command '%s': platform '%s': %w", cmd.Name, platform, err))
+			}
+			for i, variant := range platformCmd.Variants {
+				variantPath := fmt.Sprintf("%s.variants[%d]", platformPath, i)
+				if len(variant.Variants) > 0 {
+					return invalid(variantPath, fmt.Errorf("command '%s': platform '%s': variant %d: variants cannot be nested", cmd.Name, platform, i))
+				}
+				if err := validatePlatformCommand(&variant, cmd.Script != ""); err != nil {
+					return invalid(variantPath, fmt.Errorf("command '%s': platform '%s': variant %d: %w", cmd.Name, platform, i, err))
+				}
+				if err := validateFlagMap(&cmd, variant.FlagMap); err != nil && !deep {
+					return invalid(variantPath+".flag_map", fmt.Errorf("command '%s': platform '%s': variant %d: %w", cmd.Name, platform, i, err))
+				}
+			}
+			if err := validateFlagMap(&cmd, platformCmd.FlagMap); err != nil && !deep {
+				return invalid(platformPath+".flag_map", fmt.Errorf("command '%s': platform '%s': %w", cmd.Name, platform, err))
+			}
+		}
+	}
+
+	// Validate workflow structure (steps, dependencies)
+	if err := validateWorkflows(config.Workflows); err != nil {
+		return &ValidationError{Source: l.configPath, Path: "workflows", Err: err}
+	}
+
+	return nil
+}
+
+// validateTemplateTests checks that tests have unique names and expect
+// command lines for the platforms goldfish supports
+func validateTemplateTests(tests []TemplateTest) error {
+	names := make(map[string]bool, len(tests))
+	for i, test := range tests {
+		if test.Name == "" {
+			return fmt.Errorf("test %d: name is required", i+1)
+		}
+		if names[test.Name] {
+			return fmt.Errorf("duplicate test name: %s", test.Name)
+		}
+		names[test.Name] = true
+		if len(test.Expect) == 0 {
+			return fmt.Errorf("test '%s': expect needs a command line for at least one platform", test.Name)
+		}
+		for name := range test.Expect {
+			if !platform.NewDetector().IsSupported(name) {
+				return fmt.Errorf("test '%s': unknown platform '%s' (use linux, darwin or windows)", test.Name, name)
 			}
 		}
 	}
+	return nil
+}
+
+// validatePlatformKeys checks that no two platforms: keys are spellings of the
+// same key and that pm- keys name a known package manager
+func validatePlatformKeys(cmd *Command) error {
+	// Sorted so the error names the same keys every time
+	keys := make([]string, 0, len(cmd.Platforms))
+	for key := range cmd.Platforms {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
 
+	seen := make(map[string]string, len(keys))
+	for _, key := range keys {
+		if manager, found := strings.CutPrefix(key, platform.PackageManagerKeyPrefix); found {
+			manager, _, _ = strings.Cut(manager, "/")
+			if !isKnownPackageManager(manager) {
+				return fmt.Errorf("command '%s': platform key '%s': unknown package manager '%s' (known: %s)",
+					cmd.Name, key, manager, strings.Join(platform.PackageManagerNames(), ", "))
+			}
+		}
+		canonical := platform.CanonicalKey(key)
+		if other, found := seen[canonical]; found {
+			return fmt.Errorf("command '%s': platform keys '%s' and '%s' mean the same; use one of them", cmd.Name, other, key)
+		}
+		seen[canonical] = key
+	}
 	return nil
 }
 
+// isKnownPackageManager checks if a package manager name is one goldfish detects
+func isKnownPackageManager(name string) bool {
+	for _, known := range platform.PackageManagerNames() {
+		if name == known {
+			return true
+		}
+	}
+	return false
+}
+
+// validParameterTypes are the supported parameter types
+var validParameterTypes = []string{"string", "bool", "int", "float"}
+
 // isValidParameterType checks if the parameter type is supported
 func isValidParameterType(paramType string) bool {
-	validTypes := []string{"string", "bool", "int", "float"}
-	for _, validType := range validTypes {
+	for _, validType := range validParameterTypes {
 		if paramType == validType {
 			return true
 		}
@@ -172,6 +841,95 @@ func isValidParameterType(paramType string) bool {
 	return false
 }
 
+// validatePlatformCommand checks one platform template and its conditions
+// hasScript relaxes the template requirement, since a script can provide one
+func validatePlatformCommand(platformCmd *PlatformCommand, hasScript bool) error {
+	if platformCmd.Template == "" && len(platformCmd.FlagMap) == 0 && !hasScript {
+		return fmt.Errorf("template is required (or flag_map)")
+	}
+	if platformCmd.Template != "" && len(platformCmd.FlagMap) > 0 {
+		return fmt.Errorf("template and flag_map cannot both be set")
+	}
+	for _, arch := range platformCmd.Arch {
+		if !isValidArch(arch) {
+			return fmt.Errorf("unknown arch '%s' (use Go names such as amd64 or arm64)", arch)
+		}
+	}
+	for _, version := range []string{platformCmd.MinVersion, platformCmd.MaxVersion} {
+		if _, ok := platform.ParseVersion(version); version != "" && !ok {
+			return fmt.Errorf("invalid version '%s' (use dotted numbers such as 13 or 10.0.22000)", version)
+		}
+	}
+	if platformCmd.MinVersion != "" && platformCmd.MaxVersion != "" {
+		if result, _ := platform.CompareVersion(platformCmd.MinVersion, platformCmd.MaxVersion); result > 0 {
+			return fmt.Errorf("min_version %s is above max_version %s", platformCmd.MinVersion, platformCmd.MaxVersion)
+		}
+	}
+	if platformCmd.Shell != "" && !isValidShell(platformCmd.Shell) {
+		return fmt.Errorf("unknown shell '%s' (supported: %s)", platformCmd.Shell, strings.Join(validShells, ", "))
+	}
+	return nil
+}
+
+// validateFlagMap checks that a flag_map only maps the command's parameters
+func validateFlagMap(cmd *Command, flagMap map[string]string) error {
+	// Sorted so the error names the same parameter every time
+	names := make([]string, 0, len(flagMap))
+	for name := range flagMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if _, found := cmd.FindParameter(name); !found {
+			return fmt.Errorf("flag_map: unknown parameter '%s'", name)
+		}
+	}
+	return nil
+}
+
+// validShells are the shells a template can be run with
+var validShells = []string{"sh", "cmd", "powershell"}
+
+// isValidShell checks if a template's shell is supported
+func isValidShell(shell string) bool {
+	for _, valid := range validShells {
+		if shell == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// validateFallbackPlatforms checks that fallback_platforms only names known platforms
+func validateFallbackPlatforms(fallbacks map[string][]string) error {
+	detector := platform.NewDetector()
+	for name, others := range fallbacks {
+		if !detector.IsSupported(name) {
+			return fmt.Errorf("fallback_platforms: unknown platform '%s'", name)
+		}
+		for _, other := range others {
+			if !detector.IsSupported(other) {
+				return fmt.Errorf("fallback_platforms: %s: unknown platform '%s'", name, other)
+			}
+			if other == name {
+				return fmt.Errorf("fallback_platforms: %s cannot fall back to itself", name)
+			}
+		}
+	}
+	return nil
+}
+
+// isValidArch checks if a CPU architecture name is one goldfish runs on
+func isValidArch(arch string) bool {
+	for _, known := range platform.Architectures {
+		if arch == known {
+			return true
+		}
+	}
+	return false
+}
+
 // FindCommand searches for a command by name or alias
 // It returns the command definition and true if found, nil and false otherwise
 func (c *Config) FindCommand(nameOrAlias string) (*Command, bool) {
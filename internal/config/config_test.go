@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 // TestLoader_Load tests the Load method of the Loader
@@ -150,6 +151,549 @@ func TestLoader_validate(t *testing.T) {
 	}
 }
 
+// TestLoader_validate_Expect tests validation of the expect section
+func TestLoader_validate_Expect(t *testing.T) {
+	loader := NewLoader("")
+
+	config := &Config{
+		Commands: []Command{
+			{
+				Name:        "test",
+				BaseCommand: "echo",
+				Platforms: map[string]PlatformCommand{
+					"linux": {Template: "echo test"},
+				},
+				Expect: &Expectation{StdoutMatches: "^test$"},
+			},
+		},
+	}
+	if err := loader.validate(config); err != nil {
+		t.Errorf("Expected valid expect section to pass validation, got error: %v", err)
+	}
+
+	// An invalid regular expression must be rejected at load time
+	config.Commands[0].Expect.StdoutMatches = "([unclosed"
+	err := loader.validate(config)
+	if err == nil || !strings.Contains(err.Error(), "expect.stdout_matches") {
+		t.Errorf("Expected expect.stdout_matches error, got: %v", err)
+	}
+}
+
+// TestLoader_validate_StdinParam tests that stdin_param names a string parameter
+func TestLoader_validate_StdinParam(t *testing.T) {
+	loader := NewLoader("")
+
+	config := &Config{
+		Commands: []Command{
+			{
+				Name:        "test",
+				BaseCommand: "tr",
+				Platforms: map[string]PlatformCommand{
+					"linux": {Template: "echo {{.params.text}}"},
+				},
+				Parameters: []Parameter{{Name: "text", Type: "string"}, {Name: "count", Type: "int"}},
+				StdinParam: "text",
+			},
+		},
+	}
+	if err := loader.validate(config); err != nil {
+		t.Errorf("Expected stdin_param naming a string to pass validation, got error: %v", err)
+	}
+
+	for name, expected := range map[string]string{"missing": "unknown parameter 'missing'", "count": "must be a string, not int"} {
+		config.Commands[0].StdinParam = name
+		err := loader.validate(config)
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("stdin_param %s: expected error containing %q, got: %v", name, expected, err)
+		}
+	}
+}
+
+// TestLoader_validate_Cache tests that a cache policy has a positive TTL
+func TestLoader_validate_Cache(t *testing.T) {
+	loader := NewLoader("")
+
+	config := &Config{
+		Commands: []Command{
+			{
+				Name:        "test",
+				BaseCommand: "aws",
+				Platforms: map[string]PlatformCommand{
+					"linux": {Template: "aws ec2 describe-instances"},
+				},
+				Cache: &CachePolicy{TTL: 10 * time.Minute},
+			},
+		},
+	}
+	if err := loader.validate(config); err != nil {
+		t.Errorf("Expected valid cache policy to pass validation, got error: %v", err)
+	}
+
+	config.Commands[0].Cache.TTL = 0
+	err := loader.validate(config)
+	if err == nil || !strings.Contains(err.Error(), "cache.ttl") {
+		t.Errorf("Expected cache.ttl error, got: %v", err)
+	}
+}
+
+// TestExpectation_AllowsExitCode tests exit code matching for expectations
+func TestExpectation_AllowsExitCode(t *testing.T) {
+	// Without explicit codes only zero is allowed
+	expect := &Expectation{}
+	if !expect.AllowsExitCode(0) || expect.AllowsExitCode(1) {
+		t.Error("Expected default expectation to allow only exit code 0")
+	}
+
+	// Explicit codes replace the default
+	expect = &Expectation{ExitCodes: []int{0, 2}}
+	if !expect.AllowsExitCode(2) {
+		t.Error("Expected exit code 2 to be allowed")
+	}
+	if expect.AllowsExitCode(1) {
+		t.Error("Expected exit code 1 not to be allowed")
+	}
+}
+
+// TestLoader_validate_Arch tests validation of platform arch conditions
+func TestLoader_validate_Arch(t *testing.T) {
+	loader := NewLoader("")
+
+	config := &Config{
+		Commands: []Command{
+			{
+				Name:        "test",
+				BaseCommand: "echo",
+				Platforms: map[string]PlatformCommand{
+					"darwin/arm64": {Template: "echo apple silicon"},
+					"darwin":       {Template: "echo intel", Arch: []string{"amd64"}},
+				},
+			},
+		},
+	}
+	if err := loader.validate(config); err != nil {
+		t.Errorf("Expected valid arch conditions to pass validation, got error: %v", err)
+	}
+
+	// uname-style names are a likely mistake
+	config.Commands[0].Platforms["darwin"] = PlatformCommand{Template: "echo intel", Arch: []string{"x86_64"}}
+	err := loader.validate(config)
+	if err == nil || !strings.Contains(err.Error(), "unknown arch 'x86_64'") {
+		t.Errorf("Expected unknown arch error, got: %v", err)
+	}
+}
+
+// TestLoader_validate_PlatformKeys tests that a key may only be spelled one way
+// and that package manager keys are known
+func TestLoader_validate_PlatformKeys(t *testing.T) {
+	loader := NewLoader("")
+	newConfig := func(keys ...string) *Config {
+		platforms := make(map[string]PlatformCommand)
+		for _, key := range keys {
+			platforms[key] = PlatformCommand{Template: "ls"}
+		}
+		return &Config{Commands: []Command{{Name: "list", BaseCommand: "ls", Platforms: platforms}}}
+	}
+
+	if err := loader.validate(newConfig("linux", "unix", "default")); err != nil {
+		t.Errorf("Expected distinct keys to pass validation, got error: %v", err)
+	}
+	if err := loader.validate(newConfig("pm-apt", "pm-brew/arm64")); err != nil {
+		t.Errorf("Expected package manager keys to pass validation, got error: %v", err)
+	}
+	if err := loader.validate(newConfig("pm-yum")); err == nil || !strings.Contains(err.Error(), "unknown package manager") {
+		t.Errorf("Expected an unknown package manager to be rejected, got: %v", err)
+	}
+
+	for _, keys := range [][]string{{"unix", "posix"}, {"*", "default"}, {"any", "*"}, {"unix/arm64", "posix/arm64"}} {
+		if err := loader.validate(newConfig(keys...)); err == nil || !strings.Contains(err.Error(), "mean the same") {
+			t.Errorf("Expected %v to be rejected, got: %v", keys, err)
+		}
+	}
+}
+
+// TestLoader_validate_FallbackPlatforms tests validation of fallback_platforms
+func TestLoader_validate_FallbackPlatforms(t *testing.T) {
+	loader := NewLoader("")
+
+	// A file may only set fallbacks
+	if err := loader.validate(&Config{FallbackPlatforms: map[string][]string{"darwin": {"linux"}}}); err != nil {
+		t.Errorf("Expected valid fallbacks to pass validation, got error: %v", err)
+	}
+
+	for _, fallbacks := range []map[string][]string{
+		{"beos": {"linux"}},
+		{"darwin": {"plan9"}},
+		{"darwin": {"darwin"}},
+	} {
+		if err := loader.validate(&Config{FallbackPlatforms: fallbacks}); err == nil || !strings.Contains(err.Error(), "fallback_platforms") {
+			t.Errorf("Expected %v to be rejected, got: %v", fallbacks, err)
+		}
+	}
+}
+
+// TestPlatformCommand_SupportsArch tests matching arch conditions
+func TestPlatformCommand_SupportsArch(t *testing.T) {
+	unrestricted := &PlatformCommand{}
+	if !unrestricted.SupportsArch("arm64") {
+		t.Error("Expected a template without arch to match any architecture")
+	}
+	restricted := &PlatformCommand{Arch: []string{"arm64"}}
+	if !restricted.SupportsArch("arm64") || restricted.SupportsArch("amd64") {
+		t.Error("Expected only arm64 to match")
+	}
+	if !restricted.SupportsArch("") {
+		t.Error("Expected an unknown architecture to match")
+	}
+}
+
+// TestLoader_validate_Versions tests validation of version conditions and variants
+func TestLoader_validate_Versions(t *testing.T) {
+	loader := NewLoader("")
+	newConfig := func(platformCmd PlatformCommand) *Config {
+		return &Config{Commands: []Command{{
+			Name:        "test",
+			BaseCommand: "sed",
+			Platforms:   map[string]PlatformCommand{"darwin": platformCmd},
+		}}}
+	}
+
+	valid := PlatformCommand{
+		Template: "sed -i ''",
+		Variants: []PlatformCommand{{Template: "sed -i", MinVersion: "13", MaxVersion: "14.2"}},
+	}
+	if err := loader.validate(newConfig(valid)); err != nil {
+		t.Errorf("Expected valid variants to pass validation, got error: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		platformCmd PlatformCommand
+		wantErr     string
+	}{
+		{"unparsable", PlatformCommand{Template: "sed", MinVersion: "13.x"}, "invalid version '13.x'"},
+		{"empty range", PlatformCommand{Template: "sed", MinVersion: "14", MaxVersion: "13"}, "above max_version"},
+		{"variant without template", PlatformCommand{Template: "sed", Variants: []PlatformCommand{{MinVersion: "13"}}}, "variant 0: template is required"},
+		{"nested variants", PlatformCommand{Template: "sed", Variants: []PlatformCommand{{Template: "sed", Variants: []PlatformCommand{{Template: "sed"}}}}}, "cannot be nested"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := loader.validate(newConfig(tt.platformCmd))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestLoader_validate_Shell tests validation of the shell templates run in
+func TestLoader_validate_Shell(t *testing.T) {
+	loader := NewLoader("")
+	newConfig := func(shell string) *Config {
+		return &Config{Commands: []Command{{
+			Name:        "test",
+			BaseCommand: "Get-Date",
+			Platforms:   map[string]PlatformCommand{"windows": {Template: "Get-Date", Shell: shell}},
+		}}}
+	}
+
+	for _, shell := range []string{"", "sh", "cmd", "powershell"} {
+		if err := loader.validate(newConfig(shell)); err != nil {
+			t.Errorf("Expected shell %q to pass validation, got error: %v", shell, err)
+		}
+	}
+	if err := loader.validate(newConfig("fish")); err == nil || !strings.Contains(err.Error(), "unknown shell 'fish'") {
+		t.Errorf("Expected an unknown shell to be rejected, got: %v", err)
+	}
+}
+
+// TestLoader_validate_FlagMap tests validation of flag_map entries
+func TestLoader_validate_FlagMap(t *testing.T) {
+	loader := NewLoader("")
+	newConfig := func(platformCmd PlatformCommand) *Config {
+		return &Config{Commands: []Command{{
+			Name:        "test",
+			BaseCommand: "sed",
+			Parameters:  []Parameter{{Name: "in_place", Type: "bool"}, {Name: "file", Type: "string"}},
+			Platforms:   map[string]PlatformCommand{"linux": platformCmd},
+		}}}
+	}
+
+	if err := loader.validate(newConfig(PlatformCommand{FlagMap: map[string]string{"in_place": "-i", "file": ""}})); err != nil {
+		t.Errorf("Expected a flag_map to stand in for the template, got error: %v", err)
+	}
+
+	testCases := []struct {
+		name        string
+		platformCmd PlatformCommand
+		expected    string
+	}{
+		{"unknown parameter", PlatformCommand{FlagMap: map[string]string{"backup": "-b"}}, "flag_map: unknown parameter 'backup'"},
+		{"template and flag_map", PlatformCommand{Template: "sed", FlagMap: map[string]string{"file": ""}}, "cannot both be set"},
+		{"variant parameter", PlatformCommand{Template: "sed", Variants: []PlatformCommand{{MinVersion: "13", FlagMap: map[string]string{"x": ""}}}}, "variant 0: flag_map: unknown parameter 'x'"},
+	}
+	for _, tc := range testCases {
+		err := loader.validate(newConfig(tc.platformCmd))
+		if err == nil || !strings.Contains(err.Error(), tc.expected) {
+			t.Errorf("%s: expected error containing %q, got %v", tc.name, tc.expected, err)
+		}
+	}
+}
+
+// TestPlatformCommand_ResolveWhen tests choosing between variants by when: conditions
+func TestPlatformCommand_ResolveWhen(t *testing.T) {
+	platformCmd := &PlatformCommand{
+		Template: "sed",
+		Variants: []PlatformCommand{
+			{Template: "sed -E", When: "{{.params.extended}}"},
+			{Template: "sed -E arm", When: "{{.params.extended}}", Arch: []string{"arm64"}},
+		},
+	}
+	holds := func(result bool) func(string) bool {
+		return func(string) bool { return result }
+	}
+
+	if resolved, _ := platformCmd.ResolveWhen("amd64", "", holds(true)); resolved.Template != "sed -E" {
+		t.Errorf("Expected the variant whose condition holds, got %q", resolved.Template)
+	}
+	if resolved, _ := platformCmd.ResolveWhen("amd64", "", holds(false)); resolved.Template != "sed" {
+		t.Errorf("Expected the entry itself, got %q", resolved.Template)
+	}
+	// Without a way to check them, conditions hold
+	if resolved, _ := platformCmd.Resolve("amd64", ""); resolved.Template != "sed -E" {
+		t.Errorf("Expected conditions to hold in Resolve, got %q", resolved.Template)
+	}
+	// A failing condition on the entry itself leaves nothing
+	platformCmd.When = "{{.params.never}}"
+	if _, found := platformCmd.ResolveWhen("amd64", "", holds(false)); found {
+		t.Error("Expected no template to match")
+	}
+}
+
+// TestPlatformCommand_Resolve tests choosing between variants by version and arch
+func TestPlatformCommand_Resolve(t *testing.T) {
+	platformCmd := &PlatformCommand{
+		Template:   "legacy",
+		MaxVersion: "12",
+		Variants: []PlatformCommand{
+			{Template: "modern-arm", MinVersion: "13", Arch: []string{"arm64"}},
+			{Template: "modern", MinVersion: "13"},
+		},
+	}
+
+	tests := []struct {
+		arch, version string
+		want          string
+		found         bool
+	}{
+		{"arm64", "14.1", "modern-arm", true},
+		{"amd64", "13.0.1", "modern", true},
+		{"amd64", "12.7", "legacy", true},
+		// Unknown versions satisfy every condition, so the first variant wins
+		{"arm64", "", "modern-arm", true},
+	}
+	for _, tt := range tests {
+		resolved, found := platformCmd.Resolve(tt.arch, tt.version)
+		if found != tt.found || resolved.Template != tt.want {
+			t.Errorf("Resolve(%s, %s) = %q, %v; expected %q", tt.arch, tt.version, resolved.Template, found, tt.want)
+		}
+	}
+
+	// Without a fallback, releases outside every range do not match
+	platformCmd.Template, platformCmd.MinVersion, platformCmd.MaxVersion = "only-15", "15", ""
+	platformCmd.Variants = nil
+	if _, found := platformCmd.Resolve("amd64", "14"); found {
+		t.Error("Expected no template for an older release")
+	}
+}
+
+// TestLoader_validate_Retry tests validation of the retry policy
+func TestLoader_validate_Retry(t *testing.T) {
+	loader := NewLoader("")
+
+	testCases := []struct {
+		name       string
+		retry      RetryPolicy
+		shouldFail bool
+	}{
+		{"valid policy", RetryPolicy{Attempts: 3, Backoff: 2 * time.Second}, false},
+		{"zero attempts", RetryPolicy{Attempts: 0}, true},
+		{"negative backoff", RetryPolicy{Attempts: 2, Backoff: -time.Second}, true},
+	}
+
+	for _, tc := range testCases {
+		retry := tc.retry
+		config := &Config{
+			Commands: []Command{
+				{
+					Name:        "test",
+					BaseCommand: "echo",
+					Platforms:   map[string]PlatformCommand{"linux": {Template: "echo test"}},
+					Retry:       &retry,
+				},
+			},
+		}
+		err := loader.validate(config)
+		if tc.shouldFail && err == nil {
+			t.Errorf("%s: expected validation error", tc.name)
+		}
+		if !tc.shouldFail && err != nil {
+			t.Errorf("%s: expected no error, got %v", tc.name, err)
+		}
+	}
+}
+
+// TestLoader_validate_Limits tests validation of resource limits
+func TestLoader_validate_Limits(t *testing.T) {
+	loader := NewLoader("")
+
+	testCases := []struct {
+		name       string
+		limits     ResourceLimits
+		shouldFail bool
+	}{
+		{"valid limits", ResourceLimits{Nice: 10, IOPriority: "idle", MemoryMB: 512, CPUSeconds: 60}, false},
+		{"nice too high", ResourceLimits{Nice: 20}, true},
+		{"unknown io priority", ResourceLimits{IOPriority: "realtime"}, true},
+		{"negative memory", ResourceLimits{MemoryMB: -1}, true},
+		{"negative cpu time", ResourceLimits{CPUSeconds: -1}, true},
+	}
+
+	for _, tc := range testCases {
+		limits := tc.limits
+		config := &Config{
+			Commands: []Command{
+				{
+					Name:        "test",
+					BaseCommand: "echo",
+					Platforms:   map[string]PlatformCommand{"linux": {Template: "echo test"}},
+					Limits:      &limits,
+				},
+			},
+		}
+		err := loader.validate(config)
+		if tc.shouldFail && err == nil {
+			t.Errorf("%s: expected validation error", tc.name)
+		}
+		if !tc.shouldFail && err != nil {
+			t.Errorf("%s: expected no error, got %v", tc.name, err)
+		}
+	}
+}
+
+// TestLoader_Load_Retry tests that retry policies are parsed from YAML
+func TestLoader_Load_Retry(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "commands.yml")
+	configContent := `commands:
+  - name: "fetch"
+    base_command: "curl"
+    retry:
+      attempts: 3
+      backoff: 2s
+      on_exit_codes: [1, 75]
+    platforms:
+      linux:
+        template: "curl example.com"
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	config, err := NewLoader(configPath).Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	retry := config.Commands[0].Retry
+	if retry == nil {
+		t.Fatal("Expected retry policy to be parsed")
+	}
+	if retry.Attempts != 3 || retry.Backoff != 2*time.Second || len(retry.OnExitCodes) != 2 {
+		t.Errorf("Unexpected retry policy: %+v", retry)
+	}
+}
+
+// TestRetryPolicy_ShouldRetry tests which exit codes and attempts are retried
+func TestRetryPolicy_ShouldRetry(t *testing.T) {
+	// A missing policy never retries
+	var none *RetryPolicy
+	if none.ShouldRetry(1, 1) {
+		t.Error("Expected nil policy not to retry")
+	}
+
+	// Without explicit codes any failure is retried until attempts run out
+	retry := &RetryPolicy{Attempts: 3}
+	if retry.ShouldRetry(0, 1) {
+		t.Error("Expected success not to be retried")
+	}
+	if !retry.ShouldRetry(1, 1) || !retry.ShouldRetry(7, 2) {
+		t.Error("Expected failures to be retried while attempts remain")
+	}
+	if retry.ShouldRetry(1, 3) {
+		t.Error("Expected no retry after the last attempt")
+	}
+
+	// Explicit codes restrict which failures are retried
+	retry.OnExitCodes = []int{75}
+	if !retry.ShouldRetry(75, 1) || retry.ShouldRetry(1, 1) {
+		t.Error("Expected only exit code 75 to be retried")
+	}
+}
+
+// TestRetryPolicy_Delay tests the exponential backoff between attempts
+func TestRetryPolicy_Delay(t *testing.T) {
+	retry := &RetryPolicy{Attempts: 4, Backoff: 2 * time.Second}
+
+	expected := []time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second}
+	for i, want := range expected {
+		if got := retry.Delay(i + 1); got != want {
+			t.Errorf("Delay(%d): expected %v, got %v", i+1, want, got)
+		}
+	}
+
+	// Long runs of retries wait at most maxRetryDelay, and never overflow
+	for _, attempt := range []int{12, 64, 1 << 20} {
+		if got := retry.Delay(attempt); got != maxRetryDelay {
+			t.Errorf("Delay(%d): expected %v, got %v", attempt, maxRetryDelay, got)
+		}
+	}
+	if got := (&RetryPolicy{Backoff: 1000 * time.Hour}).Delay(2); got != maxRetryDelay {
+		t.Errorf("Expected a long backoff to be capped, got %v", got)
+	}
+
+	var none *RetryPolicy
+	if none.Delay(1) != 0 {
+		t.Error("Expected nil policy to have no delay")
+	}
+}
+
+// TestCommand_Listed tests which commands appear in help
+func TestCommand_Listed(t *testing.T) {
+	t.Setenv(ExperimentalEnvVar, "")
+
+	if !(&Command{}).Listed() {
+		t.Error("Expected ordinary command to be listed")
+	}
+	if (&Command{Hidden: true}).Listed() {
+		t.Error("Expected hidden command not to be listed")
+	}
+	if (&Command{Experimental: true}).Listed() {
+		t.Error("Expected experimental command not to be listed by default")
+	}
+	if (&Command{Deprecated: "use 'new' instead"}).Listed() {
+		t.Error("Expected deprecated command not to be listed")
+	}
+
+	t.Setenv(ExperimentalEnvVar, "1")
+	if !ExperimentalEnabled() || !(&Command{Experimental: true}).Listed() {
+		t.Error("Expected experimental command to be listed when enabled")
+	}
+
+	t.Setenv(ExperimentalEnvVar, "nope")
+	if ExperimentalEnabled() {
+		t.Error("Expected invalid value not to enable experimental commands")
+	}
+}
+
 // TestConfig_FindCommand tests the FindCommand method
 func TestConfig_FindCommand(t *testing.T) {
 	config := &Config{
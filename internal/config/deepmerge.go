@@ -0,0 +1,121 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// By default a command in a higher layer replaces a command of the same name
+// below it entirely. With merge: deep (on the command, or at the top of the
+// file for all its commands) it is merged into the lower definition instead,
+// so an override can add a windows: template or change one parameter's
+// default and inherit everything else:
+//
+//	commands:
+//	  - name: find-files
+//	    merge: deep
+//	    params:
+//	      - name: path
+//	        default: ./src
+//
+// Platforms, vars and install_hints are merged by key, and parameters by
+// name, field by field. Any other field the override sets replaces the lower
+// one; true booleans cannot be turned off this way.
+
+// The merge strategies of a command that overrides one in a lower layer
+const (
+	// MergeReplace replaces the lower command entirely (the default)
+	MergeReplace = "replace"
+	// MergeDeep merges the command into the lower one
+	MergeDeep = "deep"
+)
+
+// isValidMergeStrategy checks a merge: value ("" means the default)
+func isValidMergeStrategy(strategy string) bool {
+	return strategy == "" || strategy == MergeReplace || strategy == MergeDeep
+}
+
+// mergeStrategy returns how the command overrides lower definitions: its own
+// merge:, or else the merge: of its file
+func (c *Command) mergeStrategy(fileDefault string) string {
+	if c.Merge != "" {
+		return c.Merge
+	}
+	if fileDefault != "" {
+		return fileDefault
+	}
+	return MergeReplace
+}
+
+// deepMerge returns override merged into base; neither is modified
+// The result keeps base's merge:, which decides whether it in turn merges
+// into a yet lower layer.
+func deepMerge(base, override Command) Command {
+	merged := base
+	overlay(reflect.ValueOf(&merged).Elem(), reflect.ValueOf(override))
+	merged.Platforms = mergeMaps(base.Platforms, override.Platforms)
+	merged.Vars = mergeMaps(base.Vars, override.Vars)
+	merged.InstallHints = mergeMaps(base.InstallHints, override.InstallHints)
+	merged.Parameters = mergeParameters(base.Parameters, override.Parameters)
+	// The override may name the command by its alias
+	merged.Name = base.Name
+	merged.Merge = base.Merge
+	return merged
+}
+
+// overlay sets every field of the struct base to the field of override when
+// that is not its zero value
+func overlay(base, override reflect.Value) {
+	for i := 0; i < base.NumField(); i++ {
+		if field := override.Field(i); !field.IsZero() {
+			base.Field(i).Set(field)
+		}
+	}
+}
+
+// mergeMaps returns base with the entries of override added or replaced
+func mergeMaps[V any](base, override map[string]V) map[string]V {
+	if len(override) == 0 {
+		return base
+	}
+	merged := make(map[string]V, len(base)+len(override))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range override {
+		merged[key] = value
+	}
+	return merged
+}
+
+// mergeParameters merges parameters by name, keeping base's order; a
+// parameter only in override is added at the end
+func mergeParameters(base, override []Parameter) []Parameter {
+	if len(override) == 0 {
+		return base
+	}
+	merged := append([]Parameter{}, base...)
+	for _, param := range override {
+		found := false
+		for i := range merged {
+			if merged[i].Name == param.Name {
+				overlay(reflect.ValueOf(&merged[i]).Elem(), reflect.ValueOf(param))
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, param)
+		}
+	}
+	return merged
+}
+
+// incompleteMerge reports a command left without a base command or
+// platforms because its merge: deep found nothing to merge into
+func incompleteMerge(cmd *Command) error {
+	if cmd.BaseCommand != "" && len(cmd.Platforms) > 0 {
+		return nil
+	}
+	return fmt.Errorf("command '%s': merge: deep found no lower definition, and it has no base_command and platforms of its own", cmd.Name)
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestDeepMerge tests merging an override into a command field by field
+func TestDeepMerge(t *testing.T) {
+	base := Command{
+		Name:        "find-files",
+		Description: "Find files",
+		BaseCommand: "find",
+		Parameters: []Parameter{
+			{Name: "path", Type: "string", Default: "."},
+			{Name: "name", Type: "string", Flag: "--name"},
+		},
+		Vars:      map[string]string{"depth": "1"},
+		Platforms: map[string]PlatformCommand{"linux": {Template: "find {{.path}}"}},
+	}
+	override := Command{
+		Name:       "find-files",
+		Merge:      MergeDeep,
+		Parameters: []Parameter{{Name: "path", Default: "./src"}, {Name: "size", Type: "string"}},
+		Vars:       map[string]string{"depth": "2"},
+		Platforms:  map[string]PlatformCommand{"windows": {Template: "dir {{.path}}"}},
+	}
+
+	merged := deepMerge(base, override)
+	if merged.BaseCommand != "find" || merged.Description != "Find files" || merged.Merge != "" {
+		t.Errorf("Expected the base's fields to be kept, got %+v", merged)
+	}
+	if len(merged.Platforms) != 2 || merged.Platforms["linux"].Template != "find {{.path}}" {
+		t.Errorf("Expected the windows template to be added, got %v", merged.Platforms)
+	}
+	expected := []Parameter{
+		{Name: "path", Type: "string", Default: "./src"},
+		{Name: "name", Type: "string", Flag: "--name"},
+		{Name: "size", Type: "string"},
+	}
+	if !reflect.DeepEqual(merged.Parameters, expected) {
+		t.Errorf("Expected parameters %+v, got %+v", expected, merged.Parameters)
+	}
+	if merged.Vars["depth"] != "2" {
+		t.Errorf("Expected the override's var, got %v", merged.Vars)
+	}
+
+	// Neither input is modified
+	if base.Parameters[0].Default != "." || len(base.Platforms) != 1 || base.Vars["depth"] != "1" {
+		t.Errorf("Expected the base to be unchanged, got %+v", base)
+	}
+}
+
+// TestMergeLayers_Deep tests deep overrides across layers, per command and per file
+func TestMergeLayers_Deep(t *testing.T) {
+	defaults := testLayer("defaults", "replace:rp", "find")
+	system := &Config{Commands: []Command{{
+		Name:      "rp",
+		Merge:     MergeDeep,
+		Platforms: map[string]PlatformCommand{"windows": {Template: "system"}},
+	}}}
+	user := &Config{Merge: MergeDeep, Commands: []Command{{
+		Name:        "find",
+		Description: "Find, but mine",
+	}}}
+
+	merged := MergeLayers(defaults, system, user)
+	if summary := strings.Join(commandSummary(merged), ","); summary != "find=defaults,replace=defaults" {
+		t.Fatalf("Unexpected merge result: %s", summary)
+	}
+	replace, _ := merged.FindCommand("replace")
+	if len(replace.Platforms) != 2 || replace.Platforms["windows"].Template != "system" {
+		t.Errorf("Expected the system's windows template merged in, got %v", replace.Platforms)
+	}
+	find, _ := merged.FindCommand("find")
+	if find.Description != "Find, but mine" || find.Platforms["linux"].Template != "defaults" {
+		t.Errorf("Expected the user's description over the default command, got %+v", find)
+	}
+
+	// A replacing layer in between stops the merge from reaching further down
+	replacing := testLayer("replacing", "find")
+	merged = MergeLayers(defaults, replacing, user)
+	if find, _ := merged.FindCommand("find"); find.BaseCommand != "replacing" || find.Description != "Find, but mine" {
+		t.Errorf("Expected the user's command merged into the replacing one, got %+v", find)
+	}
+}
+
+// TestMergeLayers_DeepWithoutBase tests that a deep override of nothing is skipped with a warning
+func TestMergeLayers_DeepWithoutBase(t *testing.T) {
+	var warnings bytes.Buffer
+	Warnings = &warnings
+	defer func() { Warnings = os.Stderr }()
+
+	orphan := &Config{Commands: []Command{{Name: "orphan-deep-command", Merge: MergeDeep, Description: "x"}}}
+	merged := MergeLayers(testLayer("defaults", "find"), orphan)
+	if _, found := merged.FindCommand("orphan-deep-command"); found {
+		t.Error("Expected the incomplete command to be skipped")
+	}
+	if !strings.Contains(warnings.String(), "orphan-deep-command': merge: deep found no lower definition") {
+		t.Errorf("Expected a warning, got %q", warnings.String())
+	}
+}
+
+// TestLoader_DeepMergeValidation tests that deep overrides may leave out inherited fields
+func TestLoader_DeepMergeValidation(t *testing.T) {
+	valid := []string{
+		"commands:\n  - name: find\n    merge: deep\n    params:\n      - name: path\n        default: src\n",
+		"merge: deep\ncommands:\n  - name: find\n    platforms:\n      windows:\n        flag_map:\n          path: /s\n",
+	}
+	for _, content := range valid {
+		if _, err := NewLoader("test.yml").Parse([]byte(content)); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", content, err)
+		}
+	}
+
+	invalid := map[string]string{
+		"commands:\n  - name: find\n    params:\n      - name: path\n":  "base_command is required",
+		"commands:\n  - name: find\n    merge: shallow\n":               "merge must be replace or deep",
+		"merge: sideways\ncommands:\n  - name: find\n    merge: deep\n": "merge must be replace or deep",
+	}
+	for content, expected := range invalid {
+		if _, err := NewLoader("test.yml").Parse([]byte(content)); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected %q to fail with %q, got %v", content, expected, err)
+		}
+	}
+}
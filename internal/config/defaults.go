@@ -8,18 +8,14 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-
-	"gopkg.in/yaml.v3"
+	"runtime"
 )
 
 // ConfigSearchPaths defines the directories to search for commands.yml
-// in order of precedence (highest to lowest)
-var ConfigSearchPaths = []string{
-	".", // Current working directory (highest precedence)
-	"$HOME/.config/goldfish",
-	"$HOME/.goldfish",
-	"/etc/goldfish", // System-wide configuration (lowest precedence)
-}
+// in order of precedence (highest to lowest): the current working directory,
+// the user's configuration directory (see UserConfigDir), ~/.goldfish and
+// finally the system-wide configuration
+var ConfigSearchPaths = searchPaths(runtime.GOOS, os.Getenv)
 
 // defaultCommandsYAML contains the embedded default commands configuration
 // This is loaded from default_commands.yml at build time
@@ -30,17 +26,23 @@ var defaultCommandsYAML []byte
 // This provides a baseline set of commands that are always available
 // without requiring an external commands.yml file
 func LoadDefaults() (*Config, error) {
+	// Reuse the parsed defaults from an earlier run of this binary
+	if cached, found := loadParsed(defaultCommandsYAML); found {
+		return cached, nil
+	}
+
 	// Parse the embedded YAML content
 	var config Config
-	if err := yaml.Unmarshal(defaultCommandsYAML, &config); err != nil {
+	if err := decodeStrict(defaultCommandsYAML, &config); err != nil {
 		return nil, fmt.Errorf("failed to parse embedded default commands: %w", err)
 	}
 
 	// Validate the embedded configuration
-	loader := &Loader{configPath: "embedded://defaults"}
-	if err := loader.validate(&config); err != nil {
+	loader := &Loader{configPath: EmbeddedSource}
+	if err := loader.validateCached(&config, defaultCommandsYAML); err != nil {
 		return nil, fmt.Errorf("embedded default commands validation failed: %w", err)
 	}
+	storeParsed(defaultCommandsYAML, &config)
 
 	return &config, nil
 }
@@ -48,37 +50,7 @@ func LoadDefaults() (*Config, error) {
 // MergeConfigs combines two configurations, with the override config
 // taking precedence over the base config for commands with the same name or alias
 func MergeConfigs(base, override *Config) *Config {
-	if base == nil {
-		return override
-	}
-	if override == nil {
-		return base
-	}
-
-	// Create a map of existing commands from override config for fast lookup
-	overrideMap := make(map[string]bool)
-	for _, cmd := range override.Commands {
-		overrideMap[cmd.Name] = true
-		if cmd.Alias != "" {
-			overrideMap[cmd.Alias] = true
-		}
-	}
-
-	// Start with all override commands
-	merged := &Config{
-		Commands: make([]Command, len(override.Commands)),
-	}
-	copy(merged.Commands, override.Commands)
-
-	// Add base commands that aren't overridden
-	for _, baseCmd := range base.Commands {
-		// Check if this command is overridden by name or alias
-		if !overrideMap[baseCmd.Name] && (baseCmd.Alias == "" || !overrideMap[baseCmd.Alias]) {
-			merged.Commands = append(merged.Commands, baseCmd)
-		}
-	}
-
-	return merged
+	return MergeLayers(base, override)
 }
 
 // expandPath expands environment variables in a path
@@ -128,37 +100,12 @@ func findConfigFile() (string, bool) {
 }
 
 // LoadWithDefaults loads configuration with embedded defaults as fallback
-// It first loads the embedded defaults, then attempts to load and merge
-// an optional runtime configuration file if it exists
+// It first loads the embedded defaults, then merges every runtime configuration
+// file found (or the one given) over them; see LoadLayers for the order
 func LoadWithDefaults(runtimeConfigPath string) (*Config, error) {
-	// Always load embedded defaults first
-	defaultConfig, err := LoadDefaults()
+	layers, err := LoadLayers(runtimeConfigPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load embedded defaults: %w", err)
-	}
-
-	// If a specific runtime config path was provided, try to load it
-	if runtimeConfigPath != "" {
-		loader := NewLoader(runtimeConfigPath)
-		runtimeConfig, err := loader.Load()
-		if err == nil {
-			// Merge runtime config over defaults
-			return MergeConfigs(defaultConfig, runtimeConfig), nil
-		}
-		// If runtime config doesn't exist or fails to load, use defaults only
-		return defaultConfig, nil
-	}
-	
-	// Otherwise, search for config files in the standard locations
-	if configPath, found := findConfigFile(); found {
-		loader := NewLoader(configPath)
-		runtimeConfig, err := loader.Load()
-		if err == nil {
-			// Merge runtime config over defaults
-			return MergeConfigs(defaultConfig, runtimeConfig), nil
-		}
+		return nil, err
 	}
-
-	// If no runtime config found or loaded, use defaults only
-	return defaultConfig, nil
+	return MergeLoadedLayers(layers), nil
 }
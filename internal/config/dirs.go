@@ -0,0 +1,131 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// goldfish keeps its files where each platform expects them: on Linux and
+// other Unix systems under the XDG base directories ($XDG_CONFIG_HOME,
+// default ~/.config, and $XDG_CACHE_HOME, default ~/.cache), and on Windows
+// under %APPDATA% (roaming settings) and %LOCALAPPDATA% (the machine-local
+// cache and state). macOS follows the Unix layout for configuration and
+// state, as dotfiles managers expect, and keeps its cache in ~/Library/Caches.
+// State, such as the history of commands run, lives under $XDG_STATE_HOME
+// (default ~/.local/state).
+
+// UserConfigDir returns the directory of the user's own goldfish configuration
+// ($XDG_CONFIG_HOME/goldfish, ~/.config/goldfish or %APPDATA%\goldfish)
+func UserConfigDir() (string, error) {
+	return userConfigDir(runtime.GOOS, os.Getenv)
+}
+
+// UserCacheDir returns the directory goldfish caches data in
+// ($XDG_CACHE_HOME/goldfish, ~/.cache/goldfish, ~/Library/Caches/goldfish or
+// %LOCALAPPDATA%\goldfish)
+func UserCacheDir() (string, error) {
+	return userCacheDir(runtime.GOOS, os.Getenv)
+}
+
+// UserStateDir returns the directory goldfish keeps state in, such as the
+// history of commands run ($XDG_STATE_HOME/goldfish, ~/.local/state/goldfish
+// or %LOCALAPPDATA%\goldfish)
+func UserStateDir() (string, error) {
+	return userStateDir(runtime.GOOS, os.Getenv)
+}
+
+// userConfigDir is UserConfigDir for the given platform and environment
+func userConfigDir(goos string, getenv func(string) string) (string, error) {
+	if goos == "windows" {
+		return windowsDir(getenv, "APPDATA", "Roaming")
+	}
+	return xdgDir(getenv, "XDG_CONFIG_HOME", ".config")
+}
+
+// userCacheDir is UserCacheDir for the given platform and environment
+func userCacheDir(goos string, getenv func(string) string) (string, error) {
+	switch goos {
+	case "windows":
+		return windowsDir(getenv, "LOCALAPPDATA", "Local")
+	case "darwin", "ios":
+		// $XDG_CACHE_HOME is honored when set, but is not the convention
+		if dir := getenv("XDG_CACHE_HOME"); filepath.IsAbs(dir) {
+			return filepath.Join(dir, "goldfish"), nil
+		}
+		home := getenv("HOME")
+		if home == "" {
+			return "", fmt.Errorf("failed to locate home directory: $HOME is not set")
+		}
+		return filepath.Join(home, "Library", "Caches", "goldfish"), nil
+	}
+	return xdgDir(getenv, "XDG_CACHE_HOME", ".cache")
+}
+
+// userStateDir is UserStateDir for the given platform and environment
+func userStateDir(goos string, getenv func(string) string) (string, error) {
+	if goos == "windows" {
+		return windowsDir(getenv, "LOCALAPPDATA", "Local")
+	}
+	return xdgDir(getenv, "XDG_STATE_HOME", filepath.Join(".local", "state"))
+}
+
+// xdgDir returns the goldfish directory under an XDG base directory, or under
+// its default in the home directory. As the specification requires, a
+// relative path in the variable is ignored.
+func xdgDir(getenv func(string) string, variable, fallback string) (string, error) {
+	if dir := getenv(variable); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "goldfish"), nil
+	}
+	home := getenv("HOME")
+	if home == "" {
+		return "", fmt.Errorf("failed to locate home directory: $HOME is not set")
+	}
+	return filepath.Join(home, fallback, "goldfish"), nil
+}
+
+// windowsDir returns the goldfish directory under an AppData folder, falling
+// back to its usual place in the user's profile when the variable is unset
+func windowsDir(getenv func(string) string, variable, folder string) (string, error) {
+	if dir := getenv(variable); dir != "" {
+		return filepath.Join(dir, "goldfish"), nil
+	}
+	profile := getenv("USERPROFILE")
+	if profile == "" {
+		return "", fmt.Errorf("failed to locate the AppData folder: %%%s%% and %%USERPROFILE%% are not set", variable)
+	}
+	return filepath.Join(profile, "AppData", folder, "goldfish"), nil
+}
+
+// searchPaths returns the directories searched for commands.yml on the given
+// platform, highest precedence first: the working directory, the user's
+// configuration directory, the older ~/.goldfish, then the system-wide ones
+// ($XDG_CONFIG_DIRS and /etc/goldfish, or %ProgramData%\goldfish)
+func searchPaths(goos string, getenv func(string) string) []string {
+	paths := []string{"."}
+	if dir, err := userConfigDir(goos, getenv); err == nil {
+		paths = append(paths, dir)
+	}
+
+	if goos == "windows" {
+		if profile := getenv("USERPROFILE"); profile != "" {
+			paths = append(paths, filepath.Join(profile, ".goldfish"))
+		}
+		programData := getenv("ProgramData")
+		if programData == "" {
+			programData = `C:\ProgramData`
+		}
+		return append(paths, filepath.Join(programData, "goldfish"))
+	}
+
+	if home := getenv("HOME"); home != "" {
+		paths = append(paths, filepath.Join(home, ".goldfish"))
+	}
+	for _, dir := range filepath.SplitList(getenv("XDG_CONFIG_DIRS")) {
+		if filepath.IsAbs(dir) {
+			paths = append(paths, filepath.Join(dir, "goldfish"))
+		}
+	}
+	return append(paths, "/etc/goldfish")
+}
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// env returns a getenv function backed by a map
+func env(values map[string]string) func(string) string {
+	return func(name string) string { return values[name] }
+}
+
+// TestUserConfigDir tests the configuration directory on each platform
+func TestUserConfigDir(t *testing.T) {
+	testCases := []struct {
+		goos     string
+		env      map[string]string
+		expected string
+	}{
+		{"linux", map[string]string{"HOME": "/home/fish"}, filepath.Join("/home/fish", ".config", "goldfish")},
+		{"linux", map[string]string{"HOME": "/home/fish", "XDG_CONFIG_HOME": "/xdg"}, filepath.Join("/xdg", "goldfish")},
+		// Relative XDG paths are invalid and ignored
+		{"linux", map[string]string{"HOME": "/home/fish", "XDG_CONFIG_HOME": "xdg"}, filepath.Join("/home/fish", ".config", "goldfish")},
+		{"darwin", map[string]string{"HOME": "/Users/fish"}, filepath.Join("/Users/fish", ".config", "goldfish")},
+		{"windows", map[string]string{"APPDATA": `C:\Users\fish\AppData\Roaming`}, filepath.Join(`C:\Users\fish\AppData\Roaming`, "goldfish")},
+		{"windows", map[string]string{"USERPROFILE": `C:\Users\fish`}, filepath.Join(`C:\Users\fish`, "AppData", "Roaming", "goldfish")},
+	}
+	for _, tc := range testCases {
+		dir, err := userConfigDir(tc.goos, env(tc.env))
+		if err != nil || dir != tc.expected {
+			t.Errorf("userConfigDir(%s, %v) = %s, %v; expected %s", tc.goos, tc.env, dir, err, tc.expected)
+		}
+	}
+
+	if _, err := userConfigDir("linux", env(nil)); err == nil {
+		t.Error("Expected an error without a home directory")
+	}
+	if _, err := userConfigDir("windows", env(nil)); err == nil {
+		t.Error("Expected an error without APPDATA or USERPROFILE")
+	}
+}
+
+// TestUserStateDir tests the state directory on each platform
+func TestUserStateDir(t *testing.T) {
+	testCases := []struct {
+		goos     string
+		env      map[string]string
+		expected string
+	}{
+		{"linux", map[string]string{"HOME": "/home/fish"}, filepath.Join("/home/fish", ".local", "state", "goldfish")},
+		{"linux", map[string]string{"HOME": "/home/fish", "XDG_STATE_HOME": "/xdg-state"}, filepath.Join("/xdg-state", "goldfish")},
+		{"darwin", map[string]string{"HOME": "/Users/fish"}, filepath.Join("/Users/fish", ".local", "state", "goldfish")},
+		{"windows", map[string]string{"LOCALAPPDATA": `C:\Users\fish\AppData\Local`}, filepath.Join(`C:\Users\fish\AppData\Local`, "goldfish")},
+	}
+	for _, tc := range testCases {
+		dir, err := userStateDir(tc.goos, env(tc.env))
+		if err != nil || dir != tc.expected {
+			t.Errorf("userStateDir(%s, %v) = %s, %v; expected %s", tc.goos, tc.env, dir, err, tc.expected)
+		}
+	}
+}
+
+// TestUserCacheDir tests the cache directory on each platform
+func TestUserCacheDir(t *testing.T) {
+	testCases := []struct {
+		goos     string
+		env      map[string]string
+		expected string
+	}{
+		{"linux", map[string]string{"HOME": "/home/fish"}, filepath.Join("/home/fish", ".cache", "goldfish")},
+		{"linux", map[string]string{"HOME": "/home/fish", "XDG_CACHE_HOME": "/xdg-cache"}, filepath.Join("/xdg-cache", "goldfish")},
+		{"darwin", map[string]string{"HOME": "/Users/fish"}, filepath.Join("/Users/fish", "Library", "Caches", "goldfish")},
+		{"darwin", map[string]string{"HOME": "/Users/fish", "XDG_CACHE_HOME": "/xdg-cache"}, filepath.Join("/xdg-cache", "goldfish")},
+		{"windows", map[string]string{"LOCALAPPDATA": `C:\Users\fish\AppData\Local`, "APPDATA": `C:\Roaming`}, filepath.Join(`C:\Users\fish\AppData\Local`, "goldfish")},
+	}
+	for _, tc := range testCases {
+		dir, err := userCacheDir(tc.goos, env(tc.env))
+		if err != nil || dir != tc.expected {
+			t.Errorf("userCacheDir(%s, %v) = %s, %v; expected %s", tc.goos, tc.env, dir, err, tc.expected)
+		}
+	}
+}
+
+// TestSearchPaths tests the commands.yml search order on each platform
+func TestSearchPaths(t *testing.T) {
+	linux := searchPaths("linux", env(map[string]string{
+		"HOME":            "/home/fish",
+		"XDG_CONFIG_HOME": "/home/fish/xdg",
+		"XDG_CONFIG_DIRS": "/etc/xdg" + string(filepath.ListSeparator) + "relative",
+	}))
+	expected := []string{
+		".",
+		filepath.Join("/home/fish/xdg", "goldfish"),
+		filepath.Join("/home/fish", ".goldfish"),
+		filepath.Join("/etc/xdg", "goldfish"),
+		"/etc/goldfish",
+	}
+	if !reflect.DeepEqual(linux, expected) {
+		t.Errorf("Expected Linux search paths %v, got %v", expected, linux)
+	}
+
+	windows := searchPaths("windows", env(map[string]string{
+		"APPDATA":     `C:\Users\fish\AppData\Roaming`,
+		"USERPROFILE": `C:\Users\fish`,
+		"ProgramData": `D:\ProgramData`,
+	}))
+	expected = []string{
+		".",
+		filepath.Join(`C:\Users\fish\AppData\Roaming`, "goldfish"),
+		filepath.Join(`C:\Users\fish`, ".goldfish"),
+		filepath.Join(`D:\ProgramData`, "goldfish"),
+	}
+	if !reflect.DeepEqual(windows, expected) {
+		t.Errorf("Expected Windows search paths %v, got %v", expected, windows)
+	}
+}
@@ -0,0 +1,31 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrCommandNotFound is matched (with errors.Is) by errors for a command
+// name or alias that no configured command has
+var ErrCommandNotFound = errors.New("unknown command")
+
+// ErrValidation is matched (with errors.Is) by errors for an invalid
+// configuration or invalid parameter values, as opposed to a command that
+// ran and failed
+var ErrValidation = errors.New("validation failed")
+
+// CommandNotFoundError reports a command name or alias that is not configured
+type CommandNotFoundError struct {
+	// Name is the name that was looked up
+	Name string
+}
+
+// Error returns the message the CLI prints, e.g. "unknown command 'bakup'"
+func (e *CommandNotFoundError) Error() string {
+	return fmt.Sprintf("unknown command '%s'", e.Name)
+}
+
+// Is reports whether target is ErrCommandNotFound
+func (e *CommandNotFoundError) Is(target error) bool {
+	return target == ErrCommandNotFound
+}
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// TestCommandNotFoundError tests the message and that it matches ErrCommandNotFound
+func TestCommandNotFoundError(t *testing.T) {
+	err := fmt.Errorf("batch job 2: %w", &CommandNotFoundError{Name: "bakup"})
+	if err.Error() != "batch job 2: unknown command 'bakup'" {
+		t.Errorf("Unexpected message: %v", err)
+	}
+	if !errors.Is(err, ErrCommandNotFound) || errors.Is(err, ErrValidation) {
+		t.Errorf("Expected only ErrCommandNotFound to match %v", err)
+	}
+	var notFound *CommandNotFoundError
+	if !errors.As(err, &notFound) || notFound.Name != "bakup" {
+		t.Errorf("Expected errors.As to find the name, got %+v", notFound)
+	}
+}
+
+// TestValidationError_Is tests that invalid configurations match ErrValidation
+func TestValidationError_Is(t *testing.T) {
+	_, err := NewLoader("").Parse([]byte("commands:\n  - name: \"broken\"\n"))
+	if err == nil {
+		t.Fatal("Expected an invalid configuration to fail")
+	}
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected %v to match ErrValidation", err)
+	}
+	if errors.Is(errors.New("unexpected end of input"), ErrValidation) {
+		t.Error("Expected other errors not to match ErrValidation")
+	}
+}
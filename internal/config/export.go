@@ -0,0 +1,89 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ExportYAML writes the configuration as YAML
+// When origins is not nil, each command is preceded by a comment naming its source
+func ExportYAML(w io.Writer, config *Config, origins map[string]string) error {
+	var document yaml.Node
+	if err := document.Encode(config); err != nil {
+		return fmt.Errorf("failed to encode configuration: %w", err)
+	}
+
+	if origins != nil {
+		for _, item := range commandNodes(&document) {
+			if source, found := origins[mappingValue(item, "name")]; found {
+				item.HeadComment = "origin: " + source
+			}
+		}
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return fmt.Errorf("failed to write configuration: %w", err)
+	}
+	return encoder.Close()
+}
+
+// ExportJSON writes the configuration as indented JSON using the YAML field names
+// When origins is not nil, each command gets an "origin" field naming its source
+func ExportJSON(w io.Writer, config *Config, origins map[string]string) error {
+	// Round-trip through YAML so the JSON keys match commands.yml
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	var generic map[string]interface{}
+	if err := yaml.Unmarshal(data, &generic); err != nil {
+		return fmt.Errorf("failed to encode configuration: %w", err)
+	}
+
+	if origins != nil {
+		commands, _ := generic["commands"].([]interface{})
+		for _, item := range commands {
+			if cmd, ok := item.(map[string]interface{}); ok {
+				if source, found := origins[fmt.Sprint(cmd["name"])]; found {
+					cmd["origin"] = source
+				}
+			}
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(generic); err != nil {
+		return fmt.Errorf("failed to write configuration: %w", err)
+	}
+	return nil
+}
+
+// commandNodes returns the YAML nodes of the entries in the commands list
+func commandNodes(document *yaml.Node) []*yaml.Node {
+	root := document
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "commands" {
+			return root.Content[i+1].Content
+		}
+	}
+	return nil
+}
+
+// mappingValue returns the scalar value stored under key in a YAML mapping node
+func mappingValue(mapping *yaml.Node, key string) string {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1].Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// testExportConfig returns a small configuration for export tests
+func testExportConfig() *Config {
+	return &Config{
+		Commands: []Command{
+			{Name: "one", BaseCommand: "echo", Platforms: map[string]PlatformCommand{"linux": {Template: "echo 1"}}},
+			{Name: "two", BaseCommand: "echo", Platforms: map[string]PlatformCommand{"linux": {Template: "echo 2"}}},
+		},
+	}
+}
+
+// TestExportYAML tests YAML export with origin comments
+func TestExportYAML(t *testing.T) {
+	var out bytes.Buffer
+	origins := map[string]string{"one": "/etc/goldfish/commands.yml", "two": EmbeddedSource}
+	if err := ExportYAML(&out, testExportConfig(), origins); err != nil {
+		t.Fatalf("ExportYAML() failed: %v", err)
+	}
+
+	output := out.String()
+	for _, expected := range []string{"# origin: /etc/goldfish/commands.yml\n  - name: one", "# origin: " + EmbeddedSource} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, output)
+		}
+	}
+
+	// The export is itself a valid configuration
+	var parsed Config
+	if err := yaml.Unmarshal(out.Bytes(), &parsed); err != nil || len(parsed.Commands) != 2 {
+		t.Errorf("Expected export to parse back, got %v (%d commands)", err, len(parsed.Commands))
+	}
+
+	// Without origins there are no comments
+	out.Reset()
+	if err := ExportYAML(&out, testExportConfig(), nil); err != nil {
+		t.Fatalf("ExportYAML() failed: %v", err)
+	}
+	if strings.Contains(out.String(), "origin") {
+		t.Errorf("Expected no origin comments, got:\n%s", out.String())
+	}
+}
+
+// TestExportJSON tests JSON export with origin fields
+func TestExportJSON(t *testing.T) {
+	var out bytes.Buffer
+	if err := ExportJSON(&out, testExportConfig(), map[string]string{"one": "a.yml"}); err != nil {
+		t.Fatalf("ExportJSON() failed: %v", err)
+	}
+
+	var parsed struct {
+		Commands []map[string]interface{} `json:"commands"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &parsed); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if parsed.Commands[0]["base_command"] != "echo" || parsed.Commands[0]["origin"] != "a.yml" {
+		t.Errorf("Unexpected first command: %v", parsed.Commands[0])
+	}
+	if _, found := parsed.Commands[1]["origin"]; found {
+		t.Error("Expected no origin for command without a known source")
+	}
+}
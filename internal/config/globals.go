@@ -0,0 +1,34 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// A configuration's root vars: are values every template can use as
+// .globals, e.g. a backup suffix, the corporate proxy or the user's editor,
+// instead of copying them into each template. Globals are templates too: they
+// see the environment as .env, the platform as .platform and each other as
+// .globals. Layers merge them by name, so a project's file can change one
+// global set system-wide.
+
+// globalReference matches the globals a global's template refers to
+var globalReference = regexp.MustCompile(`\.globals\.([A-Za-z_][A-Za-z0-9_]*)`)
+
+// GlobalOrder returns the names of globals, each after those it refers to
+// It fails when a global has an invalid name, refers to an unknown global or
+// globals refer to each other in a cycle. Only the merged configuration is
+// checked for unknown globals, since one file may use another's.
+func GlobalOrder(globals map[string]string) ([]string, error) {
+	return templateOrder(globals, globalReference, "global")
+}
+
+// validateGlobalNames checks that a file's globals can be used as .globals.name
+func validateGlobalNames(globals map[string]string) error {
+	for name := range globals {
+		if !varName.MatchString(name) {
+			return fmt.Errorf("vars: global '%s': names may only contain letters, digits and underscores", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestGlobalOrder tests ordering globals by the globals they use
+func TestGlobalOrder(t *testing.T) {
+	order, err := GlobalOrder(map[string]string{
+		"backup": "{{.globals.suffix}}",
+		"editor": "{{or .env.EDITOR \"vi\"}}",
+		"suffix": ".bak",
+	})
+	if err != nil {
+		t.Fatalf("GlobalOrder() failed: %v", err)
+	}
+	position := make(map[string]int, len(order))
+	for i, name := range order {
+		position[name] = i
+	}
+	if len(order) != 3 || position["suffix"] > position["backup"] {
+		t.Errorf("Expected backup after suffix, got %v", order)
+	}
+
+	testCases := []struct {
+		globals  map[string]string
+		expected string
+	}{
+		{map[string]string{"a": "{{.globals.b}}", "b": "{{.globals.a}}"}, "globals form a cycle: a -> b -> a"},
+		{map[string]string{"a": "{{.globals.missing}}"}, "global 'a' refers to unknown global 'missing'"},
+		{map[string]string{"http-proxy": "x"}, "global 'http-proxy': names may only contain"},
+	}
+	for _, tc := range testCases {
+		if _, err := GlobalOrder(tc.globals); err == nil || !strings.Contains(err.Error(), tc.expected) {
+			t.Errorf("%v: expected error containing %q, got %v", tc.globals, tc.expected, err)
+		}
+	}
+}
+
+// TestLoader_Globals tests loading and merging root vars:
+func TestLoader_Globals(t *testing.T) {
+	// A file may set only globals, and may use globals set by another file
+	system, err := NewLoader("system.yml").Parse([]byte("vars:\n  suffix: .bak\n  proxy: http://proxy:3128\n"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	user, err := NewLoader("user.yml").Parse([]byte("vars:\n  suffix: .orig\n  backup: '{{.globals.suffix}}'\n"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	merged := MergeLayers(system, user)
+	expected := map[string]string{"suffix": ".orig", "proxy": "http://proxy:3128", "backup": "{{.globals.suffix}}"}
+	if len(merged.Vars) != len(expected) {
+		t.Fatalf("Expected %d globals, got %v", len(expected), merged.Vars)
+	}
+	for name, value := range expected {
+		if merged.Vars[name] != value {
+			t.Errorf("Global %s: expected %q, got %q", name, value, merged.Vars[name])
+		}
+	}
+
+	if _, err := NewLoader("bad.yml").Parse([]byte("vars:\n  http-proxy: x\n")); err == nil || !strings.Contains(err.Error(), "global 'http-proxy'") {
+		t.Errorf("Expected an invalid global name to fail, got %v", err)
+	}
+}
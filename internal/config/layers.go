@@ -0,0 +1,244 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// EmbeddedSource is the source name of the built-in default commands layer
+const EmbeddedSource = "embedded://defaults"
+
+// Warnings receives notices about configuration that was skipped while loading
+// Tests may replace it to keep their output quiet
+var Warnings io.Writer = os.Stderr
+
+// warned remembers which warnings were printed, since configuration may be
+// loaded more than once per run
+var warned sync.Map
+
+// warnSkipped reports a configuration source that failed to load
+// Loading carries on without it, so the message is the only sign of the problem
+// Validation errors also name the offending YAML path.
+func warnSkipped(source string, err error) {
+	var invalid *ValidationError
+	if errors.As(err, &invalid) {
+		warnOnce(fmt.Sprintf("Warning: skipping %s: %v (at %s)\n", source, err, invalid.Path))
+		return
+	}
+	warnOnce(fmt.Sprintf("Warning: skipping %s: %v\n", source, err))
+}
+
+// warnOnce writes a warning to Warnings unless it was already printed
+func warnOnce(message string) {
+	if _, seen := warned.LoadOrStore(message, true); !seen {
+		fmt.Fprint(Warnings, message)
+	}
+}
+
+// Layer is one configuration source taking part in the merge
+type Layer struct {
+	// Source is the file or URL the layer was loaded from (or EmbeddedSource)
+	Source string
+	// Config is the layer's parsed configuration
+	Config *Config
+}
+
+// The kinds of Source, saying why a source was consulted
+const (
+	// SourceEmbedded is the built-in default commands
+	SourceEmbedded = "embedded"
+	// SourcePack is an installed command pack
+	SourcePack = "pack"
+	// SourcePlugin is a goldfish-* plugin found on PATH
+	SourcePlugin = "plugin"
+	// SourceSearch is a commands.yml location from ConfigSearchPaths
+	SourceSearch = "search"
+	// SourceProject is the nearest .goldfish.yml at or above the working directory
+	SourceProject = "project"
+	// SourceRuntime is the one file or URL loaded instead of searching,
+	// e.g. the one named by $GOLDFISH_CONFIG
+	SourceRuntime = "runtime"
+	// SourceInclude is a file named by another file's includes:
+	SourceInclude = "include"
+)
+
+// Source is a place configuration was looked for, whether or not it was there
+// goldfish config where lists them to explain which file a setting came from.
+type Source struct {
+	// Kind is one of the Source* constants
+	Kind string
+	// Location is the file, directory or URL consulted
+	Location string
+	// Found reports whether anything was at Location
+	Found bool
+	// Err is why a source that was found could not be loaded
+	Err error
+	// Config is the source's configuration when it loaded
+	Config *Config
+}
+
+// LoadLayers loads every configuration layer, from lowest to highest precedence
+// The embedded defaults come first, then installed command packs and plugins, followed by
+// each commands.yml found in ConfigSearchPaths from the system-wide location up
+// to the current directory.
+// If runtimeConfigPath (or $GOLDFISH_CONFIG) is set, that file or URL is used
+// instead of searching. Each file's includes: are loaded just beneath it.
+// Files that fail to load are skipped, matching LoadWithDefaults.
+func LoadLayers(runtimeConfigPath string) ([]Layer, error) {
+	sources, err := ResolveSources(runtimeConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	return LayersOf(sources), nil
+}
+
+// ResolveSources consults every configuration source in the order LoadLayers
+// merges them, lowest precedence first, including those that are missing or
+// fail to load
+func ResolveSources(runtimeConfigPath string) ([]Source, error) {
+	defaults, err := LoadDefaults()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load embedded defaults: %w", err)
+	}
+	sources := []Source{{Kind: SourceEmbedded, Location: EmbeddedSource, Found: true, Config: defaults}}
+
+	if runtimeConfigPath == "" {
+		runtimeConfigPath = os.Getenv(ConfigEnvVar)
+	}
+	trustedKeys := TrustedKeys()
+	sources = append(sources, packSources(trustedKeys)...)
+	sources = append(sources, pluginSources()...)
+
+	seen := make(map[string]bool)
+	for _, candidate := range searchSources(runtimeConfigPath) {
+		if !candidate.Found {
+			sources = append(sources, candidate)
+			continue
+		}
+		sources = append(sources, loadWithIncludes(candidate, trustedKeys, seen, 0)...)
+	}
+
+	return sources, nil
+}
+
+// LayersOf returns the sources that loaded, as layers for MergeLoadedLayers
+func LayersOf(sources []Source) []Layer {
+	var layers []Layer
+	for _, source := range sources {
+		if source.Config != nil {
+			layers = append(layers, Layer{Source: source.Location, Config: source.Config})
+		}
+	}
+	return layers
+}
+
+// maxIncludeDepth bounds how deeply includes: may nest
+const maxIncludeDepth = 8
+
+// loadWithIncludes loads a source and, beneath it, everything it includes
+// Remote sources must be signed by one of trustedKeys when any are configured.
+// seen stops a file that is included twice (or includes itself) from loading again
+func loadWithIncludes(source Source, trustedKeys []string, seen map[string]bool, depth int) []Source {
+	if seen[source.Location] || depth > maxIncludeDepth {
+		return nil
+	}
+	seen[source.Location] = true
+
+	loader := NewLoader(source.Location)
+	if IsRemote(source.Location) {
+		loader.TrustKeys(trustedKeys)
+	}
+	layerConfig, err := loader.Load()
+	if err != nil {
+		warnSkipped(source.Location, err)
+		source.Err = err
+		// A missing local include is reported as not found rather than broken
+		if !IsRemote(source.Location) {
+			_, statErr := os.Stat(source.Location)
+			source.Found = statErr == nil
+		}
+		return []Source{source}
+	}
+	source.Config = layerConfig
+
+	// Included files come first so the including file overrides them
+	var sources []Source
+	for _, include := range layerConfig.Includes {
+		included := Source{Kind: SourceInclude, Location: resolveInclude(source.Location, include), Found: true}
+		sources = append(sources, loadWithIncludes(included, trustedKeys, seen, depth+1)...)
+	}
+	return append(sources, source)
+}
+
+// layerPaths returns the configuration files to load, lowest precedence first
+func layerPaths(runtimeConfigPath string) []string {
+	var paths []string
+	for _, source := range searchSources(runtimeConfigPath) {
+		if source.Found {
+			paths = append(paths, source.Location)
+		}
+	}
+	return paths
+}
+
+// searchSources returns the commands.yml locations to consult, lowest
+// precedence first, noting which exist
+// When the working directory (".") is searched, the project's .goldfish.yml
+// sits just beneath its commands.yml, overriding the user's and system-wide
+// files.
+func searchSources(runtimeConfigPath string) []Source {
+	if runtimeConfigPath != "" {
+		return []Source{{Kind: SourceRuntime, Location: runtimeConfigPath, Found: true}}
+	}
+
+	// ConfigSearchPaths is ordered highest precedence first, so walk it backwards
+	var sources []Source
+	seen := make(map[string]bool)
+	for i := len(ConfigSearchPaths) - 1; i >= 0; i-- {
+		if ConfigSearchPaths[i] == "." {
+			if project, ok := projectSource(); ok {
+				sources = append(sources, project)
+			}
+		}
+		configPath := filepath.Join(expandPath(ConfigSearchPaths[i]), "commands.yml")
+		// Absolute paths make origins unambiguous, and the same file can be
+		// reachable twice (e.g. when run from ~/.goldfish)
+		if absolute, err := filepath.Abs(configPath); err == nil {
+			configPath = absolute
+		}
+		if seen[configPath] {
+			continue
+		}
+		seen[configPath] = true
+		_, err := os.Stat(configPath)
+		sources = append(sources, Source{Kind: SourceSearch, Location: configPath, Found: err == nil})
+	}
+	return sources
+}
+
+// MergeLoadedLayers merges loaded layers into the effective configuration
+func MergeLoadedLayers(layers []Layer) *Config {
+	configs := make([]*Config, len(layers))
+	for i, layer := range layers {
+		configs[i] = layer.Config
+	}
+	return MergeLayers(configs...)
+}
+
+// Origins maps each command name in the merged configuration to the source it came from
+// A command comes from the highest precedence layer that defines its name.
+func Origins(layers []Layer) map[string]string {
+	origins := make(map[string]string)
+	for i := len(layers) - 1; i >= 0; i-- {
+		for _, cmd := range layers[i].Config.Commands {
+			if _, found := origins[cmd.Name]; !found {
+				origins[cmd.Name] = layers[i].Source
+			}
+		}
+	}
+	return origins
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeLayer writes a commands.yml defining one command into dir
+func writeLayer(t *testing.T, dir, name, baseCommand string) string {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	content := "commands:\n  - name: \"" + name + "\"\n    base_command: \"" + baseCommand + "\"\n    platforms:\n      linux:\n        template: \"" + baseCommand + "\"\n"
+	path := filepath.Join(dir, "commands.yml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	return path
+}
+
+// TestLoadLayers tests that every found config file is merged in precedence order
+func TestLoadLayers(t *testing.T) {
+	originalPaths := ConfigSearchPaths
+	defer func() { ConfigSearchPaths = originalPaths }()
+
+	tempDir := t.TempDir()
+	projectDir := filepath.Join(tempDir, "project")
+	systemDir := filepath.Join(tempDir, "etc")
+	ConfigSearchPaths = []string{projectDir, filepath.Join(tempDir, "missing"), systemDir}
+
+	systemPath := writeLayer(t, systemDir, "shared", "system")
+	projectPath := writeLayer(t, projectDir, "shared", "project")
+
+	layers, err := LoadLayers("")
+	if err != nil {
+		t.Fatalf("LoadLayers() failed: %v", err)
+	}
+
+	// Embedded defaults first, then system, then project
+	if len(layers) != 3 {
+		t.Fatalf("Expected 3 layers, got %d", len(layers))
+	}
+	expected := []string{EmbeddedSource, systemPath, projectPath}
+	for i, layer := range layers {
+		if layer.Source != expected[i] {
+			t.Errorf("Layer %d: expected source %s, got %s", i, expected[i], layer.Source)
+		}
+	}
+
+	// The project layer wins, and origins say so
+	merged := MergeLoadedLayers(layers)
+	if cmd, _ := merged.FindCommand("shared"); cmd.BaseCommand != "project" {
+		t.Errorf("Expected project layer to win, got %s", cmd.BaseCommand)
+	}
+	origins := Origins(layers)
+	if origins["shared"] != projectPath {
+		t.Errorf("Expected origin %s, got %s", projectPath, origins["shared"])
+	}
+	if origins["replace-in-file"] != EmbeddedSource {
+		t.Errorf("Expected default command to come from %s, got %s", EmbeddedSource, origins["replace-in-file"])
+	}
+}
+
+// TestLoadLayers_RuntimePath tests that an explicit path replaces the search
+func TestLoadLayers_RuntimePath(t *testing.T) {
+	path := writeLayer(t, t.TempDir(), "explicit", "explicit")
+
+	layers, err := LoadLayers(path)
+	if err != nil {
+		t.Fatalf("LoadLayers() failed: %v", err)
+	}
+	if len(layers) != 2 || layers[1].Source != path {
+		t.Errorf("Expected defaults and the explicit file, got %+v", layers)
+	}
+}
+
+// TestResolveSources tests that missing and broken sources are reported in merge order
+func TestResolveSources(t *testing.T) {
+	originalPaths := ConfigSearchPaths
+	defer func() { ConfigSearchPaths = originalPaths }()
+	t.Setenv(PacksDirEnvVar, t.TempDir())
+	t.Setenv(NoPluginsEnvVar, "1")
+	t.Setenv(ConfigEnvVar, "")
+	Warnings = io.Discard
+	defer func() { Warnings = os.Stderr }()
+
+	tempDir := t.TempDir()
+	projectDir := filepath.Join(tempDir, "project")
+	brokenDir := filepath.Join(tempDir, "broken")
+	missingDir := filepath.Join(tempDir, "missing")
+	ConfigSearchPaths = []string{projectDir, brokenDir, missingDir}
+
+	projectPath := writeLayer(t, projectDir, "shared", "project")
+	os.MkdirAll(brokenDir, 0755)
+	os.WriteFile(filepath.Join(brokenDir, "commands.yml"), []byte("commands: ["), 0644)
+
+	sources, err := ResolveSources("")
+	if err != nil {
+		t.Fatalf("ResolveSources() failed: %v", err)
+	}
+	if len(sources) != 4 {
+		t.Fatalf("Expected 4 sources, got %+v", sources)
+	}
+	if sources[0].Kind != SourceEmbedded || sources[0].Config == nil {
+		t.Errorf("Expected the embedded defaults first, got %+v", sources[0])
+	}
+	if sources[1].Location != filepath.Join(missingDir, "commands.yml") || sources[1].Found {
+		t.Errorf("Expected the missing file next, got %+v", sources[1])
+	}
+	if !sources[2].Found || sources[2].Err == nil || sources[2].Config != nil {
+		t.Errorf("Expected the broken file to be found but skipped, got %+v", sources[2])
+	}
+	if sources[3].Location != projectPath || sources[3].Kind != SourceSearch || sources[3].Config == nil {
+		t.Errorf("Expected the project file last, got %+v", sources[3])
+	}
+	if layers := LayersOf(sources); len(layers) != 2 {
+		t.Errorf("Expected 2 layers, got %d", len(layers))
+	}
+
+	// An explicit path is the only file consulted
+	sources, _ = ResolveSources(projectPath)
+	if len(sources) != 2 || sources[1].Kind != SourceRuntime {
+		t.Errorf("Expected the defaults and the runtime file, got %+v", sources)
+	}
+}
@@ -0,0 +1,199 @@
+package config
+
+import "fmt"
+
+// MergeLayers combines any number of configuration layers in a single pass
+// Layers are given from lowest to highest precedence (e.g. embedded defaults,
+// system, user, project). A command in a higher layer replaces every command in
+// lower layers that shares its name or alias, unless it sets merge: deep, in
+// which case it is merged into the next lower one (see deepmerge.go). Nil
+// layers are skipped.
+//
+// The result lists the highest layer's commands first, followed by the
+// surviving commands of each lower layer in turn, which is the same order that
+// repeatedly calling MergeConfigs would produce. Unlike repeated pairwise
+// merging, each command is copied exactly once and the lookup map is sized up
+// front, so merging many layers stays linear in the total number of commands.
+//
+// Workflows, WASM modules, aliases, profiles and globals (vars:) are merged the
+// same way by name, and fallback_platforms entries by platform.
+// allowed_base_commands can only be narrowed: a program is allowed if every
+// layer that has a list allows it, so a project's file cannot widen the list
+// of a system-wide one.
+func MergeLayers(layers ...*Config) *Config {
+	// Count commands and non-nil layers to size allocations once
+	total := 0
+	totalWorkflows := 0
+	nonNil := 0
+	var only *Config
+	for _, layer := range layers {
+		if layer == nil {
+			continue
+		}
+		nonNil++
+		only = layer
+		total += len(layer.Commands)
+		totalWorkflows += len(layer.Workflows)
+	}
+
+	// Nothing to merge: return the single layer (or nil) unchanged
+	if nonNil <= 1 {
+		return only
+	}
+
+	// claimed maps every name and alias defined by a higher layer to the
+	// position of its command in the result
+	claimed := make(map[string]int, total*2)
+	// deepPending marks commands in the result still to be merged into the
+	// next lower layer that defines them
+	deepPending := make(map[int]bool)
+	claimedWorkflows := make(map[string]bool, totalWorkflows)
+	claimedWasm := make(map[string]bool)
+	claimedAliases := make(map[string]bool)
+	claimedProfiles := make(map[string]bool)
+	merged := &Config{
+		Commands: make([]Command, 0, total),
+	}
+
+	// Walk from the highest precedence layer down to the lowest
+	for i := len(layers) - 1; i >= 0; i-- {
+		layer := layers[i]
+		if layer == nil {
+			continue
+		}
+
+		// Keep commands not overridden by name or alias in any higher layer,
+		// and merge those a higher layer merges deeply into
+		positions := make([]int, len(layer.Commands))
+		mergedInLayer := make(map[int]bool)
+		for j, cmd := range layer.Commands {
+			position, found := claimed[cmd.Name]
+			if !found && cmd.Alias != "" {
+				position, found = claimed[cmd.Alias]
+			}
+			positions[j] = -1
+			switch {
+			case !found:
+				merged.Commands = append(merged.Commands, cmd)
+				positions[j] = len(merged.Commands) - 1
+				deepPending[positions[j]] = cmd.mergeStrategy(layer.Merge) == MergeDeep
+			case deepPending[position] && !mergedInLayer[position]:
+				merged.Commands[position] = deepMerge(cmd, merged.Commands[position])
+				positions[j] = position
+				mergedInLayer[position] = true
+				deepPending[position] = cmd.mergeStrategy(layer.Merge) == MergeDeep
+			}
+		}
+
+		// Only now claim this layer's names, so commands within the same
+		// layer never override each other
+		for j, cmd := range layer.Commands {
+			if positions[j] < 0 {
+				continue
+			}
+			for _, name := range []string{cmd.Name, cmd.Alias} {
+				if _, taken := claimed[name]; name != "" && !taken {
+					claimed[name] = positions[j]
+				}
+			}
+		}
+
+		// Workflows are overridden by name in the same way
+		for _, workflow := range layer.Workflows {
+			if !claimedWorkflows[workflow.Name] {
+				merged.Workflows = append(merged.Workflows, workflow)
+			}
+		}
+		for _, workflow := range layer.Workflows {
+			claimedWorkflows[workflow.Name] = true
+		}
+
+		// A higher layer's module replaces a lower one with the same name
+		for _, module := range layer.Wasm {
+			if !claimedWasm[module.Name] {
+				merged.Wasm = append(merged.Wasm, module)
+			}
+		}
+		for _, module := range layer.Wasm {
+			claimedWasm[module.Name] = true
+		}
+
+		// Aliases too: the user's own file overrides a shared one
+		for _, alias := range layer.Aliases {
+			if !claimedAliases[alias.Name] {
+				merged.Aliases = append(merged.Aliases, alias)
+			}
+		}
+		for _, alias := range layer.Aliases {
+			claimedAliases[alias.Name] = true
+		}
+
+		// A higher layer's profile replaces a lower one of the same name
+		for _, profile := range layer.Profiles {
+			if !claimedProfiles[profile.Name] {
+				merged.Profiles = append(merged.Profiles, profile)
+			}
+		}
+		for _, profile := range layer.Profiles {
+			claimedProfiles[profile.Name] = true
+		}
+
+		// Globals are merged by name, the higher layer's value winning
+		for name, value := range layer.Vars {
+			if _, claimed := merged.Vars[name]; claimed {
+				continue
+			}
+			if merged.Vars == nil {
+				merged.Vars = make(map[string]string)
+			}
+			merged.Vars[name] = value
+		}
+
+		// A higher layer's fallbacks for a platform replace lower ones
+		for name, others := range layer.FallbackPlatforms {
+			if _, claimed := merged.FallbackPlatforms[name]; claimed {
+				continue
+			}
+			if merged.FallbackPlatforms == nil {
+				merged.FallbackPlatforms = make(map[string][]string)
+			}
+			merged.FallbackPlatforms[name] = others
+		}
+
+		if layer.AllowedBaseCommands != nil {
+			merged.AllowedBaseCommands = narrowAllowed(merged.AllowedBaseCommands, layer.AllowedBaseCommands)
+		}
+	}
+
+	// A deep merge that found nothing to merge into may be unusable
+	complete := merged.Commands[:0]
+	for i := range merged.Commands {
+		if err := incompleteMerge(&merged.Commands[i]); err != nil {
+			warnOnce(fmt.Sprintf("Warning: skipping %v\n", err))
+			continue
+		}
+		complete = append(complete, merged.Commands[i])
+	}
+	merged.Commands = complete
+
+	return merged
+}
+
+// narrowAllowed returns the programs of allowed that layer allows too
+// A nil allowed means no layer has set a list yet.
+func narrowAllowed(allowed, layer []string) []string {
+	if allowed == nil {
+		return append([]string{}, layer...)
+	}
+	inLayer := make(map[string]bool, len(layer))
+	for _, program := range layer {
+		inLayer[program] = true
+	}
+	narrowed := []string{}
+	for _, program := range allowed {
+		if inLayer[program] {
+			narrowed = append(narrowed, program)
+		}
+	}
+	return narrowed
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// testLayer builds a config layer whose commands use the given base command
+// names is a list of "name" or "name:alias" entries
+func testLayer(baseCommand string, names ...string) *Config {
+	layer := &Config{}
+	for _, entry := range names {
+		name, alias, _ := strings.Cut(entry, ":")
+		layer.Commands = append(layer.Commands, Command{
+			Name:        name,
+			Alias:       alias,
+			BaseCommand: baseCommand,
+			Platforms:   map[string]PlatformCommand{"linux": {Template: baseCommand}},
+		})
+	}
+	return layer
+}
+
+// commandSummary lists "name=base_command" for each command in order
+func commandSummary(config *Config) []string {
+	var summary []string
+	for _, cmd := range config.Commands {
+		summary = append(summary, cmd.Name+"="+cmd.BaseCommand)
+	}
+	return summary
+}
+
+// TestMergeLayers tests precedence across several layers
+func TestMergeLayers(t *testing.T) {
+	defaults := testLayer("defaults", "replace:rp", "find", "tar")
+	system := testLayer("system", "find", "ps")
+	user := testLayer("user", "grep:rp", "ps")
+
+	merged := MergeLayers(defaults, system, user)
+
+	// Highest layer first, then surviving commands from each lower layer
+	expected := []string{"grep=user", "ps=user", "find=system", "tar=defaults"}
+	got := commandSummary(merged)
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+// TestMergeLayers_MatchesPairwiseMerge tests equivalence with repeated MergeConfigs
+func TestMergeLayers_MatchesPairwiseMerge(t *testing.T) {
+	a := testLayer("a", "one:1", "two", "three")
+	b := testLayer("b", "two:2", "four")
+	c := testLayer("c", "1", "five:four")
+
+	pairwise := MergeConfigs(MergeConfigs(a, b), c)
+	single := MergeLayers(a, b, c)
+
+	if fmt.Sprint(commandSummary(single)) != fmt.Sprint(commandSummary(pairwise)) {
+		t.Errorf("Expected %v, got %v", commandSummary(pairwise), commandSummary(single))
+	}
+}
+
+// TestMergeLayers_NilLayers tests that nil layers are ignored
+func TestMergeLayers_NilLayers(t *testing.T) {
+	if MergeLayers() != nil {
+		t.Error("Expected merging no layers to return nil")
+	}
+	if MergeLayers(nil, nil) != nil {
+		t.Error("Expected merging only nil layers to return nil")
+	}
+
+	layer := testLayer("only", "cmd")
+	if MergeLayers(nil, layer, nil) != layer {
+		t.Error("Expected a single non-nil layer to be returned unchanged")
+	}
+}
+
+// benchmarkLayers creates n layers of size commands with partial overlap
+func benchmarkLayers(n, size int) []*Config {
+	layers := make([]*Config, n)
+	for i := range layers {
+		names := make([]string, size)
+		for j := range names {
+			// Half of each layer overrides the previous layer, half is new
+			names[j] = fmt.Sprintf("cmd-%d", i*size/2+j)
+		}
+		layers[i] = testLayer(fmt.Sprintf("layer%d", i), names...)
+	}
+	return layers
+}
+
+// BenchmarkMergeLayers benchmarks single-pass merging of many layers
+func BenchmarkMergeLayers(b *testing.B) {
+	layers := benchmarkLayers(20, 200)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = MergeLayers(layers...)
+	}
+}
+
+// BenchmarkMergeConfigs_Pairwise benchmarks the same merge done pairwise
+func BenchmarkMergeConfigs_Pairwise(b *testing.B) {
+	layers := benchmarkLayers(20, 200)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		merged := layers[0]
+		for _, layer := range layers[1:] {
+			merged = MergeConfigs(merged, layer)
+		}
+	}
+}
+
+// TestMergeLayers_FallbackPlatforms tests that higher layers replace fallbacks per platform
+func TestMergeLayers_FallbackPlatforms(t *testing.T) {
+	system := testLayer("system", "find")
+	system.FallbackPlatforms = map[string][]string{"darwin": {"linux"}, "windows": {"linux"}}
+	user := testLayer("user", "ps")
+	user.FallbackPlatforms = map[string][]string{"darwin": {}}
+
+	merged := MergeLayers(system, user)
+	expected := map[string][]string{"darwin": {}, "windows": {"linux"}}
+	if fmt.Sprint(merged.FallbackPlatforms) != fmt.Sprint(expected) {
+		t.Errorf("Expected %v, got %v", expected, merged.FallbackPlatforms)
+	}
+}
+
+// TestMergeLayers_AllowedBaseCommands tests that layers can only narrow the allowlist
+func TestMergeLayers_AllowedBaseCommands(t *testing.T) {
+	system := testLayer("system", "find")
+	system.AllowedBaseCommands = []string{"find", "sed", "grep"}
+	user := testLayer("user", "ps")
+	project := testLayer("project", "ls")
+	project.AllowedBaseCommands = []string{"sed", "curl", "find"}
+
+	merged := MergeLayers(system, user, project)
+	if strings.Join(merged.AllowedBaseCommands, ",") != "sed,find" {
+		t.Errorf("Expected only sed and find to stay allowed, got %v", merged.AllowedBaseCommands)
+	}
+	if merged := MergeLayers(system, user); len(merged.AllowedBaseCommands) != 3 {
+		t.Errorf("Expected layers without a list to keep it, got %v", merged.AllowedBaseCommands)
+	}
+}
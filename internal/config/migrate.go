@@ -0,0 +1,125 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// migration upgrades a file from one format version to the next
+// It edits the YAML node tree rather than decoded structs so that keys the
+// current types no longer have can still be read, and comments survive
+// goldfish config migrate --write
+type migration struct {
+	// description is shown to the user, e.g. "renamed 'args' to 'params'"
+	description string
+	// apply rewrites the document's top-level mapping node in place
+	apply func(root *yaml.Node) error
+}
+
+// migrations lists every format change, oldest first: migrations[0] upgrades
+// version 1 to 2, and so on. A breaking change to the format adds an entry
+// here that rewrites older files, so they keep loading (with a warning) until
+// the user runs goldfish config migrate --write.
+var migrations []migration
+
+// CurrentVersion returns the newest commands.yml format this build understands
+// Each migration adds one version, so the format starts at version 1.
+// Files without a version: key are assumed to be written for it.
+func CurrentVersion() int {
+	return len(migrations) + 1
+}
+
+// fileVersion returns the version: a file declares, or CurrentVersion if it has none
+func fileVersion(data []byte) (int, error) {
+	var header struct {
+		Version int `yaml:"version"`
+	}
+	if err := yaml.Unmarshal(data, &header); err != nil {
+		return 0, fmt.Errorf("failed to parse YAML config: %w", err)
+	}
+	if header.Version == 0 {
+		return CurrentVersion(), nil
+	}
+	return header.Version, nil
+}
+
+// Migrate upgrades configuration content to CurrentVersion
+// It returns the upgraded content, stamped with the current version, and a
+// description of each change made. Content from a newer goldfish is an error.
+func Migrate(data []byte) ([]byte, []string, error) {
+	version, err := fileVersion(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if version < 1 || version > CurrentVersion() {
+		return nil, nil, fmt.Errorf("config version %d is not supported by this goldfish (1-%d); upgrade goldfish", version, CurrentVersion())
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse YAML config: %w", err)
+	}
+	if len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+		return nil, nil, fmt.Errorf("config must be a YAML mapping")
+	}
+	root := document.Content[0]
+
+	var notes []string
+	for from := version; from < CurrentVersion(); from++ {
+		step := migrations[from-1]
+		if err := step.apply(root); err != nil {
+			return nil, nil, fmt.Errorf("migrating from version %d: %w", from, err)
+		}
+		notes = append(notes, fmt.Sprintf("version %d -> %d: %s", from, from+1, step.description))
+	}
+	setVersion(root, CurrentVersion())
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return nil, nil, fmt.Errorf("failed to encode migrated config: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, nil, fmt.Errorf("failed to encode migrated config: %w", err)
+	}
+	return out.Bytes(), notes, nil
+}
+
+// setVersion sets the version: key of a mapping, adding it at the top if missing
+func setVersion(root *yaml.Node, version int) {
+	value := strconv.Itoa(version)
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "version" {
+			root.Content[i+1].Value = value
+			root.Content[i+1].Tag = "!!int"
+			return
+		}
+	}
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "version"}
+	val := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: value}
+	root.Content = append([]*yaml.Node{key, val}, root.Content...)
+}
+
+// upgrade migrates content in memory while loading, warning that the file is outdated
+// Content already at the current version is returned unchanged
+func (l *Loader) upgrade(data []byte) ([]byte, error) {
+	version, err := fileVersion(data)
+	if err != nil {
+		return nil, err
+	}
+	if version == CurrentVersion() {
+		return data, nil
+	}
+
+	migrated, _, err := Migrate(data)
+	if err != nil {
+		return nil, err
+	}
+	warnOnce(fmt.Sprintf("Warning: %s uses config version %d; run 'goldfish config migrate --write %s' to upgrade it to version %d\n",
+		l.configPath, version, l.configPath, CurrentVersion()))
+	return migrated, nil
+}
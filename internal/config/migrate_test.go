@@ -0,0 +1,93 @@
+package config
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// withRenameMigration simulates a format change: version 2 renamed "cmds" to "commands"
+func withRenameMigration(t *testing.T) {
+	t.Helper()
+	original := migrations
+	t.Cleanup(func() { migrations = original })
+	migrations = []migration{{
+		description: "renamed 'cmds' to 'commands'",
+		apply: func(root *yaml.Node) error {
+			for i := 0; i+1 < len(root.Content); i += 2 {
+				if root.Content[i].Value == "cmds" {
+					root.Content[i].Value = "commands"
+				}
+			}
+			return nil
+		},
+	}}
+}
+
+// TestMigrate tests upgrading content through the registered migrations
+func TestMigrate(t *testing.T) {
+	withRenameMigration(t)
+	if CurrentVersion() != 2 {
+		t.Fatalf("Expected each migration to add a version, got %d", CurrentVersion())
+	}
+
+	data := []byte("version: 1\n# The team's commands\ncmds:\n  - name: \"x\"\n    base_command: \"x\"\n    platforms:\n      linux:\n        template: \"x\"\n")
+	migrated, notes, err := Migrate(data)
+	if err != nil {
+		t.Fatalf("Migrate() failed: %v", err)
+	}
+	if len(notes) != 1 || !strings.Contains(notes[0], "version 1 -> 2") {
+		t.Errorf("Expected one migration note, got %v", notes)
+	}
+	text := string(migrated)
+	if !strings.Contains(text, "version: 2") || !strings.Contains(text, "commands:") || strings.Contains(text, "cmds:") {
+		t.Errorf("Unexpected migrated content:\n%s", text)
+	}
+	if !strings.Contains(text, "# The team's commands") {
+		t.Errorf("Expected comments to survive migration:\n%s", text)
+	}
+
+	// Newer and invalid versions are rejected
+	for _, version := range []string{"3", "-1"} {
+		if _, _, err := Migrate([]byte("version: " + version + "\n")); err == nil {
+			t.Errorf("Expected version %s to be rejected", version)
+		}
+	}
+}
+
+// TestMigrate_Unversioned tests that files without a version are stamped with the current one
+func TestMigrate_Unversioned(t *testing.T) {
+	migrated, notes, err := Migrate([]byte("commands: []\n"))
+	if err != nil {
+		t.Fatalf("Migrate() failed: %v", err)
+	}
+	if len(notes) != 0 {
+		t.Errorf("Expected no migrations, got %v", notes)
+	}
+	if !strings.HasPrefix(string(migrated), "version: 1\n") {
+		t.Errorf("Expected the version to be added at the top, got:\n%s", migrated)
+	}
+}
+
+// TestLoader_Parse_Migrates tests that outdated files load with a warning
+func TestLoader_Parse_Migrates(t *testing.T) {
+	withRenameMigration(t)
+	var warnings bytes.Buffer
+	original := Warnings
+	Warnings = &warnings
+	defer func() { Warnings = original }()
+
+	data := []byte("version: 1\ncmds:\n  - name: \"old\"\n    base_command: \"x\"\n    platforms:\n      linux:\n        template: \"x\"\n")
+	cfg, err := NewLoader("old-format.yml").Parse(data)
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+	if _, found := cfg.FindCommand("old"); !found {
+		t.Errorf("Expected the migrated command to load")
+	}
+	if !strings.Contains(warnings.String(), "goldfish config migrate --write old-format.yml") {
+		t.Errorf("Expected a migration warning, got %q", warnings.String())
+	}
+}
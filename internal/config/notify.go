@@ -0,0 +1,69 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// The values of notify.on
+const (
+	// NotifyAlways notifies however the command ended (the default)
+	NotifyAlways = "always"
+	// NotifyFailure notifies only when the command failed
+	NotifyFailure = "failure"
+	// NotifySuccess notifies only when the command succeeded
+	NotifySuccess = "success"
+)
+
+// NotifyPolicy describes how people hear that a command has finished, for
+// long running jobs nobody sits and watches (notify:)
+type NotifyPolicy struct {
+	// On is NotifyAlways, NotifyFailure or NotifySuccess
+	On string `yaml:"on,omitempty"`
+	// Desktop shows a desktop notification on the machine goldfish runs on
+	Desktop bool `yaml:"desktop,omitempty"`
+	// Webhook is a URL a JSON summary of the run is posted to, e.g. a Slack
+	// incoming webhook; $VARIABLES are expanded from the environment, so a
+	// secret URL can be kept out of the file, e.g. "$SLACK_WEBHOOK_URL"
+	Webhook string `yaml:"webhook,omitempty"`
+}
+
+// Matches reports whether the policy notifies about a run that succeeded or failed
+func (p *NotifyPolicy) Matches(succeeded bool) bool {
+	switch p.On {
+	case NotifyFailure:
+		return !succeeded
+	case NotifySuccess:
+		return succeeded
+	}
+	return true
+}
+
+// validateNotify checks that a notify policy says when and where to notify
+func validateNotify(policy *NotifyPolicy) error {
+	switch policy.On {
+	case "", NotifyAlways, NotifyFailure, NotifySuccess:
+	default:
+		return fmt.Errorf("on must be one of %s, %s and %s, got '%s'", NotifyAlways, NotifyFailure, NotifySuccess, policy.On)
+	}
+	if !policy.Desktop && policy.Webhook == "" {
+		return fmt.Errorf("set desktop: true, a webhook, or both")
+	}
+	// A URL taken from the environment is checked when it is used
+	if policy.Webhook != "" && !strings.Contains(policy.Webhook, "$") {
+		if err := ValidateWebhook(policy.Webhook); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateWebhook checks that a webhook is an http or https URL
+func ValidateWebhook(webhook string) error {
+	parsed, err := url.Parse(webhook)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("webhook must be an http or https URL, got '%s'", webhook)
+	}
+	return nil
+}
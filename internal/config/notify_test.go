@@ -0,0 +1,45 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestValidateNotify tests that notify policies say when and where to notify
+func TestValidateNotify(t *testing.T) {
+	testCases := []struct {
+		policy   NotifyPolicy
+		expected string
+	}{
+		{NotifyPolicy{Desktop: true}, ""},
+		{NotifyPolicy{On: NotifyFailure, Webhook: "https://hooks.slack.com/services/T0/B0/x"}, ""},
+		{NotifyPolicy{Webhook: "$SLACK_WEBHOOK_URL"}, ""},
+		{NotifyPolicy{On: NotifyFailure}, "set desktop: true, a webhook, or both"},
+		{NotifyPolicy{On: "error", Desktop: true}, "on must be one of always, failure and success"},
+		{NotifyPolicy{Webhook: "hooks.slack.com/services"}, "webhook must be an http or https URL"},
+	}
+	for _, tc := range testCases {
+		err := validateNotify(&tc.policy)
+		if tc.expected == "" && err != nil {
+			t.Errorf("%+v: expected no error, got %v", tc.policy, err)
+		}
+		if tc.expected != "" && (err == nil || !strings.Contains(err.Error(), tc.expected)) {
+			t.Errorf("%+v: expected an error containing %q, got %v", tc.policy, tc.expected, err)
+		}
+	}
+}
+
+// TestNotifyPolicy_Matches tests which runs a policy notifies about
+func TestNotifyPolicy_Matches(t *testing.T) {
+	for on, expected := range map[string][2]bool{
+		"":            {true, true},
+		NotifyAlways:  {true, true},
+		NotifyFailure: {false, true},
+		NotifySuccess: {true, false},
+	} {
+		policy := &NotifyPolicy{On: on}
+		if policy.Matches(true) != expected[0] || policy.Matches(false) != expected[1] {
+			t.Errorf("on: %q: expected success %v and failure %v", on, expected[0], expected[1])
+		}
+	}
+}
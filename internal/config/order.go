@@ -0,0 +1,42 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// orderByReferences orders names so that each comes after the names it refers to
+// refs returns the names one refers to, which must all be among names; what
+// describes them in the error reported for a cycle (e.g. "parameter defaults").
+// Names that do not depend on each other keep their order.
+func orderByReferences(names []string, refs func(name string) []string, what string) ([]string, error) {
+	// state is 1 while a name's references are being visited, 2 once done
+	state := make(map[string]int, len(names))
+	order := make([]string, 0, len(names))
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case 1:
+			return fmt.Errorf("%s form a cycle: %s", what, strings.Join(append(path, name), " -> "))
+		case 2:
+			return nil
+		}
+		state[name] = 1
+		for _, ref := range refs(name) {
+			if err := visit(ref, append(path, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = 2
+		order = append(order, name)
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// PacksDirEnvVar overrides the directory installed command packs are read from
+const PacksDirEnvVar = "GOLDFISH_PACKS_DIR"
+
+// PackMetadata identifies a command pack: a shareable commands.yml such as
+// "docker-essentials" that is installed with goldfish pack install
+type PackMetadata struct {
+	// Name is the pack's registry name; it is also its file name once installed
+	Name string `yaml:"name"`
+	// Version is the pack's release version (e.g. "1.2.0")
+	Version string `yaml:"version"`
+	// Description explains what the pack provides
+	Description string `yaml:"description,omitempty"`
+}
+
+// PacksDir returns the directory installed packs live in
+// It is $GOLDFISH_PACKS_DIR if set, otherwise packs.d in UserConfigDir
+func PacksDir() (string, error) {
+	if dir := os.Getenv(PacksDirEnvVar); dir != "" {
+		return dir, nil
+	}
+	dir, err := UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "packs.d"), nil
+}
+
+// packSources loads every installed pack, in file name order
+// Packs that fail to load, or are not signed by one of trustedKeys when any
+// are configured, are skipped like other runtime configuration files
+func packSources(trustedKeys []string) []Source {
+	dir, err := PacksDir()
+	if err != nil {
+		return nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".yml") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	var sources []Source
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		packConfig, err := NewLoader(path).TrustKeys(trustedKeys).Load()
+		if err != nil {
+			warnSkipped(path, err)
+		}
+		sources = append(sources, Source{Kind: SourcePack, Location: path, Found: true, Err: err, Config: packConfig})
+	}
+	return sources
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestPacksDir tests the environment override and the default location
+func TestPacksDir(t *testing.T) {
+	t.Setenv(PacksDirEnvVar, "/tmp/goldfish-packs")
+	if dir, err := PacksDir(); err != nil || dir != "/tmp/goldfish-packs" {
+		t.Errorf("Expected override directory, got %s (%v)", dir, err)
+	}
+
+	t.Setenv(PacksDirEnvVar, "")
+	t.Setenv("HOME", "/home/fish")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	dir, err := PacksDir()
+	if err != nil {
+		t.Fatalf("PacksDir() failed: %v", err)
+	}
+	if dir != filepath.Join("/home/fish", ".config", "goldfish", "packs.d") {
+		t.Errorf("Unexpected default directory %s", dir)
+	}
+}
+
+// TestLoadLayers_Packs tests that installed packs sit between the defaults and runtime files
+func TestLoadLayers_Packs(t *testing.T) {
+	originalPaths := ConfigSearchPaths
+	defer func() { ConfigSearchPaths = originalPaths }()
+
+	tempDir := t.TempDir()
+	packsDir := filepath.Join(tempDir, "packs.d")
+	projectDir := filepath.Join(tempDir, "project")
+	t.Setenv(PacksDirEnvVar, packsDir)
+	ConfigSearchPaths = []string{projectDir}
+
+	// Packs are loaded in file name order; the lock file and broken packs are ignored
+	writeLayer(t, packsDir, "shared", "pack")
+	packPath := filepath.Join(packsDir, "tools.yml")
+	if err := os.Rename(filepath.Join(packsDir, "commands.yml"), packPath); err != nil {
+		t.Fatalf("Failed to rename pack: %v", err)
+	}
+	os.WriteFile(filepath.Join(packsDir, "broken.yml"), []byte("commands: ["), 0644)
+	os.WriteFile(filepath.Join(packsDir, "packs.lock"), []byte("packs: []\n"), 0644)
+	projectPath := writeLayer(t, projectDir, "shared", "project")
+
+	layers, err := LoadLayers("")
+	if err != nil {
+		t.Fatalf("LoadLayers() failed: %v", err)
+	}
+	expected := []string{EmbeddedSource, packPath, projectPath}
+	if len(layers) != len(expected) {
+		t.Fatalf("Expected %d layers, got %d", len(expected), len(layers))
+	}
+	for i, layer := range layers {
+		if layer.Source != expected[i] {
+			t.Errorf("Layer %d: expected source %s, got %s", i, expected[i], layer.Source)
+		}
+	}
+
+	// A runtime file still overrides the pack
+	merged := MergeLoadedLayers(layers)
+	if cmd, _ := merged.FindCommand("shared"); cmd.BaseCommand != "project" {
+		t.Errorf("Expected project layer to win over the pack, got %s", cmd.BaseCommand)
+	}
+}
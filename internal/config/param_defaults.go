@@ -0,0 +1,69 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// A parameter's default may be a template computed from the other parameters
+// and the environment when the command is invoked, e.g.
+// default: "{{.params.file}}.bak" or default: "{{.env.HOME}}/backups".
+// Such defaults may refer to parameters with templated defaults of their own,
+// so they are worked out in dependency order and must not form a cycle.
+
+// defaultReference matches the parameters a templated default refers to
+var defaultReference = regexp.MustCompile(`\.params\.([A-Za-z_][A-Za-z0-9_]*)`)
+
+// DefaultTemplate returns the parameter's default if it is a template
+func (p *Parameter) DefaultTemplate() (string, bool) {
+	text, ok := p.Default.(string)
+	return text, ok && strings.Contains(text, "{{")
+}
+
+// DefaultOrder returns the parameters with templated defaults, each after
+// those its default refers to
+// It fails when a default refers to an unknown parameter or defaults refer
+// to each other in a cycle.
+func (c *Command) DefaultOrder() ([]string, error) {
+	// refs holds the parameters each templated default refers to
+	refs := make(map[string][]string)
+	names := make([]string, 0, len(c.Parameters))
+	for _, param := range c.Parameters {
+		names = append(names, param.Name)
+		text, templated := param.DefaultTemplate()
+		if !templated {
+			continue
+		}
+		for _, match := range defaultReference.FindAllStringSubmatch(text, -1) {
+			if _, found := c.FindParameter(match[1]); !found {
+				return nil, fmt.Errorf("parameter '%s': default refers to unknown parameter '%s'", param.Name, match[1])
+			}
+			refs[param.Name] = append(refs[param.Name], match[1])
+		}
+	}
+
+	order, err := orderByReferences(names, func(name string) []string { return refs[name] }, "parameter defaults")
+	if err != nil {
+		return nil, err
+	}
+	// Only templated defaults need computing
+	templated := order[:0]
+	for _, name := range order {
+		param, _ := c.FindParameter(name)
+		if _, ok := param.DefaultTemplate(); ok {
+			templated = append(templated, name)
+		}
+	}
+	return templated, nil
+}
+
+// FindParameter returns the command's parameter with the given name
+func (c *Command) FindParameter(name string) (*Parameter, bool) {
+	for i := range c.Parameters {
+		if c.Parameters[i].Name == name {
+			return &c.Parameters[i], true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestCommand_DefaultOrder tests ordering templated defaults by what they refer to
+func TestCommand_DefaultOrder(t *testing.T) {
+	cmd := &Command{
+		Name: "backup",
+		Parameters: []Parameter{
+			{Name: "archive", Type: "string", Default: "{{.params.backup}}.tar"},
+			{Name: "backup", Type: "string", Default: "{{.params.file}}.bak"},
+			{Name: "file", Type: "string", Required: true},
+			{Name: "level", Type: "int", Default: 3},
+		},
+	}
+	order, err := cmd.DefaultOrder()
+	if err != nil {
+		t.Fatalf("DefaultOrder() failed: %v", err)
+	}
+	if strings.Join(order, ",") != "backup,archive" {
+		t.Errorf("Expected backup before archive, got %v", order)
+	}
+
+	// Defaults that refer to each other cannot be worked out
+	cmd.Parameters[2].Default = "{{.params.archive}}"
+	if _, err := cmd.DefaultOrder(); err == nil || !strings.Contains(err.Error(), "archive -> backup -> file -> archive") {
+		t.Errorf("Expected a cycle error, got %v", err)
+	}
+
+	// A reference to a parameter that does not exist is a mistake
+	cmd.Parameters[2].Default = "{{.params.fiel}}"
+	if _, err := cmd.DefaultOrder(); err == nil || !strings.Contains(err.Error(), "unknown parameter 'fiel'") {
+		t.Errorf("Expected an unknown parameter error, got %v", err)
+	}
+
+	// Loading reports the same errors
+	config := &Config{Commands: []Command{*cmd}}
+	config.Commands[0].BaseCommand = "tar"
+	config.Commands[0].Platforms = map[string]PlatformCommand{"linux": {Template: "tar"}}
+	if err := NewLoader("").validate(config); err == nil || !strings.Contains(err.Error(), "command 'backup'") {
+		t.Errorf("Expected validation to fail, got %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Parameters that many commands share (a file, a recursive switch, a dry-run
+// flag) can be written once under param_defs: and referenced by name:
+//
+//	param_defs:
+//	  - name: file
+//	    type: string
+//	    flag: --file
+//	    description: File to process
+//	commands:
+//	  - name: replace-in-file
+//	    params:
+//	      - use: file
+//	        required: true
+//
+// Fields set next to use: override the definition's, as with merge: deep.
+// Definitions belong to the file that declares them; they are expanded when
+// the file is loaded, before validation.
+
+// resolveParamDefs replaces every parameter that uses a definition with the
+// definition and the parameter's own fields over it
+func (l *Loader) resolveParamDefs(config *Config) error {
+	defs := make(map[string]Parameter, len(config.ParamDefs))
+	for i, def := range config.ParamDefs {
+		invalid := func(err error) error {
+			return &ValidationError{Source: l.configPath, Path: fmt.Sprintf("param_defs[%d]", i), Err: err}
+		}
+		switch {
+		case def.Name == "":
+			return invalid(fmt.Errorf("param_defs: definition at index %d: name is required", i))
+		case def.Use != "":
+			return invalid(fmt.Errorf("param_defs: '%s': a definition cannot use another", def.Name))
+		case def.Type == "":
+			return invalid(fmt.Errorf("param_defs: '%s': type is required", def.Name))
+		case !isValidParameterType(def.Type):
+			return invalid(fmt.Errorf("param_defs: '%s': invalid type '%s'", def.Name, def.Type))
+		}
+		if _, duplicate := defs[def.Name]; duplicate {
+			return invalid(fmt.Errorf("param_defs: duplicate definition: %s", def.Name))
+		}
+		defs[def.Name] = def
+	}
+
+	for i := range config.Commands {
+		cmd := &config.Commands[i]
+		for j, param := range cmd.Parameters {
+			if param.Use == "" {
+				continue
+			}
+			def, found := defs[param.Use]
+			if !found {
+				return &ValidationError{
+					Source: l.configPath,
+					Path:   fmt.Sprintf("commands[%d].params[%d].use", i, j),
+					Err:    fmt.Errorf("command '%s': unknown parameter definition '%s'", cmd.Name, param.Use),
+				}
+			}
+			overlay(reflect.ValueOf(&def).Elem(), reflect.ValueOf(param))
+			def.Use = ""
+			cmd.Parameters[j] = def
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestLoader_ParamDefs tests expanding parameters that use shared definitions
+func TestLoader_ParamDefs(t *testing.T) {
+	data := []byte(`param_defs:
+  - name: file
+    type: string
+    flag: --file
+    description: File to process
+  - name: recursive
+    type: bool
+    flag: --recursive
+commands:
+  - name: scan
+    base_command: grep
+    params:
+      - use: file
+        required: true
+      - use: recursive
+    platforms:
+      linux:
+        template: "grep {{if .recursive}}-r {{end}}x {{.file}}"
+  - name: show
+    base_command: cat
+    params:
+      - use: file
+        name: path
+        description: File to show
+    platforms:
+      linux:
+        template: "cat {{.path}}"
+`)
+	config, err := NewLoader("commands.yml").Parse(data)
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+
+	scan, _ := config.FindCommand("scan")
+	expected := []Parameter{
+		{Name: "file", Type: "string", Flag: "--file", Description: "File to process", Required: true},
+		{Name: "recursive", Type: "bool", Flag: "--recursive"},
+	}
+	if !reflect.DeepEqual(scan.Parameters, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, scan.Parameters)
+	}
+
+	// Fields next to use: override the definition, including its name
+	show, _ := config.FindCommand("show")
+	param := show.Parameters[0]
+	if param.Name != "path" || param.Type != "string" || param.Description != "File to show" || param.Required {
+		t.Errorf("Expected the renamed parameter, got %+v", param)
+	}
+	if config.ParamDefs[0].Description != "File to process" {
+		t.Errorf("Expected the definition to be unchanged, got %+v", config.ParamDefs[0])
+	}
+}
+
+// TestLoader_ParamDefs_Invalid tests rejecting broken definitions and references
+func TestLoader_ParamDefs_Invalid(t *testing.T) {
+	command := "commands:\n  - name: x\n    base_command: x\n    params:\n      - use: file\n    platforms:\n      linux:\n        template: x\n"
+	testCases := map[string]string{
+		command: "unknown parameter definition 'file'",
+		"param_defs:\n  - name: file\n" + command:                                              "'file': type is required",
+		"param_defs:\n  - name: file\n    type: path\n" + command:                              "invalid type 'path'",
+		"param_defs:\n  - name: file\n    use: other\n" + command:                              "cannot use another",
+		"param_defs:\n  - {name: file, type: string}\n  - {name: file, type: int}\n" + command: "duplicate definition: file",
+	}
+	for content, expected := range testCases {
+		_, err := NewLoader("commands.yml").Parse([]byte(content))
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected an error containing %q, got %v", expected, err)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// PluginPrefix starts the file name of every plugin executable on PATH
+	PluginPrefix = "goldfish-plugin-"
+	// PluginManifestFlag asks a plugin to print its manifest to stdout
+	PluginManifestFlag = "--goldfish-manifest"
+	// PluginExecFlag asks a plugin to run one of its commands (see engine/plugin.go)
+	PluginExecFlag = "--goldfish-exec"
+	// NoPluginsEnvVar disables plugin discovery when set to a non-empty value
+	NoPluginsEnvVar = "GOLDFISH_NO_PLUGINS"
+)
+
+// DiscoverPlugins switches plugin discovery on; goldfish switches it off in
+// restricted mode, since discovery runs every plugin on PATH
+var DiscoverPlugins = true
+
+// pluginQueryTimeout bounds how long a plugin may take to print its manifest
+var pluginQueryTimeout = 5 * time.Second
+
+// PluginInfo describes a plugin in its manifest
+type PluginInfo struct {
+	// Name identifies the plugin (e.g. "docker")
+	Name string `yaml:"name"`
+	// Version is the plugin's release version
+	Version string `yaml:"version,omitempty"`
+	// HandlesExecution means goldfish runs the plugin with --goldfish-exec
+	// instead of rendering the commands' templates
+	HandlesExecution bool `yaml:"handles_execution,omitempty"`
+}
+
+// PluginManifest is what a plugin prints for --goldfish-manifest
+// It is a commands.yml with an extra plugin: section
+type PluginManifest struct {
+	Plugin   PluginInfo `yaml:"plugin"`
+	Commands []Command  `yaml:"commands"`
+}
+
+// pluginSources queries every plugin on PATH and returns their commands, in name order
+// Plugins that fail are skipped with a warning, like other configuration sources.
+// Manifests are cached per binary (path, size and modification time), so
+// plugins are only run again after they change.
+func pluginSources() []Source {
+	if !DiscoverPlugins || os.Getenv(NoPluginsEnvVar) != "" {
+		return nil
+	}
+
+	var sources []Source
+	for _, path := range findPlugins() {
+		pluginConfig, err := loadPlugin(path)
+		if err != nil {
+			warnSkipped(path, err)
+		}
+		sources = append(sources, Source{Kind: SourcePlugin, Location: path, Found: true, Err: err, Config: pluginConfig})
+	}
+	return sources
+}
+
+// findPlugins returns the plugin executables on PATH
+// When the same plugin name is in several directories the first one wins, as
+// it would for a shell
+func findPlugins() []string {
+	found := make(map[string]string)
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || !strings.HasPrefix(name, PluginPrefix) {
+				continue
+			}
+			if runtime.GOOS == "windows" {
+				if !strings.EqualFold(filepath.Ext(name), ".exe") {
+					continue
+				}
+				name = strings.TrimSuffix(name, filepath.Ext(name))
+			} else if info, err := entry.Info(); err != nil || info.Mode()&0111 == 0 {
+				// Not executable
+				continue
+			}
+			if _, seen := found[name]; !seen {
+				found[name] = filepath.Join(dir, entry.Name())
+			}
+		}
+	}
+
+	names := make([]string, 0, len(found))
+	for name := range found {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	paths := make([]string, len(names))
+	for i, name := range names {
+		paths[i] = found[name]
+	}
+	return paths
+}
+
+// loadPlugin reads, validates and prepares a plugin's commands
+func loadPlugin(path string) (*Config, error) {
+	data, err := pluginManifest(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest PluginManifest
+	if err := decodeStrictInto(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid plugin manifest: %w", err)
+	}
+	if manifest.Plugin.Name == "" {
+		return nil, fmt.Errorf("invalid plugin manifest: plugin.name is required")
+	}
+
+	// Plugins that run their own commands need no templates; a placeholder
+	// keeps the commands valid and shows up in previews
+	if manifest.Plugin.HandlesExecution {
+		for i := range manifest.Commands {
+			cmd := &manifest.Commands[i]
+			cmd.Plugin = path
+			for platformName, platformCmd := range cmd.Platforms {
+				if platformCmd.Template == "" {
+					platformCmd.Template = filepath.Base(path) + " " + PluginExecFlag + " " + cmd.Name
+					cmd.Platforms[platformName] = platformCmd
+				}
+			}
+		}
+	}
+
+	pluginConfig := &Config{Commands: manifest.Commands}
+	if err := (&Loader{configPath: path}).validate(pluginConfig); err != nil {
+		return nil, fmt.Errorf("invalid plugin manifest: %w", err)
+	}
+	return pluginConfig, nil
+}
+
+// pluginManifest returns a plugin's manifest, from the cache when the binary is unchanged
+func pluginManifest(path string) ([]byte, error) {
+	cachePath, cacheable := pluginCachePath(path)
+	if cacheable {
+		if data, err := os.ReadFile(cachePath); err == nil {
+			return data, nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pluginQueryTimeout)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	query := exec.CommandContext(ctx, path, PluginManifestFlag)
+	query.Stdout = &stdout
+	query.Stderr = &stderr
+	if err := query.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("plugin did not answer %s within %v", PluginManifestFlag, pluginQueryTimeout)
+		}
+		return nil, fmt.Errorf("plugin failed to print its manifest: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	// Caching is an optimization, so failures to write it are ignored
+	if cacheable {
+		if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err == nil {
+			_ = os.WriteFile(cachePath, stdout.Bytes(), 0644)
+		}
+	}
+	return stdout.Bytes(), nil
+}
+
+// pluginCachePath returns where a plugin binary's manifest is cached
+func pluginCachePath(path string) (string, bool) {
+	if !cacheEnabled() {
+		return "", false
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", false
+	}
+	dir, err := CacheDir()
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d|%d", path, info.Size(), info.ModTime().UnixNano())))
+	return filepath.Join(dir, "plugins", hex.EncodeToString(sum[:])+".yml"), true
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// writePlugin writes an executable shell script plugin into dir
+func writePlugin(t *testing.T, dir, name, script string, mode os.FileMode) string {
+	t.Helper()
+	path := filepath.Join(dir, PluginPrefix+name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), mode); err != nil {
+		t.Fatalf("Failed to write plugin: %v", err)
+	}
+	return path
+}
+
+// TestPluginLayers tests discovering, validating and caching plugin manifests
+func TestPluginLayers(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses shell script plugins")
+	}
+	t.Setenv(NoPluginsEnvVar, "")
+	t.Setenv(CacheDirEnvVar, t.TempDir())
+
+	dir := t.TempDir()
+	counter := filepath.Join(t.TempDir(), "queries")
+	manifest := `plugin:
+  name: "docker"
+  handles_execution: true
+commands:
+  - name: "dock"
+    description: "Run docker things"
+    base_command: "docker"
+    platforms:
+      linux: {}
+      darwin: {}
+`
+	dockerPath := writePlugin(t, dir, "docker", "echo run >> "+counter+"\ncat <<'EOF'\n"+manifest+"EOF\n", 0755)
+	writePlugin(t, dir, "broken", "echo 'commands: [' \n", 0755)
+	writePlugin(t, dir, "failing", "exit 3\n", 0755)
+	writePlugin(t, dir, "disabled", "echo never\n", 0644)
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	for i := 0; i < 2; i++ {
+		layers := LayersOf(pluginSources())
+		if len(layers) != 1 || layers[0].Source != dockerPath {
+			t.Fatalf("Expected only the docker plugin, got %+v", layers)
+		}
+		cmd := layers[0].Config.Commands[0]
+		if cmd.Plugin != dockerPath {
+			t.Errorf("Expected the command to be handled by %s, got %q", dockerPath, cmd.Plugin)
+		}
+		if !strings.Contains(cmd.Platforms["linux"].Template, PluginExecFlag) {
+			t.Errorf("Expected a placeholder template, got %q", cmd.Platforms["linux"].Template)
+		}
+	}
+
+	// The second load used the cached manifest
+	data, _ := os.ReadFile(counter)
+	if runs := strings.Count(string(data), "run"); runs != 1 {
+		t.Errorf("Expected the plugin to be queried once, got %d", runs)
+	}
+
+	// Discovery can be switched off
+	DiscoverPlugins = false
+	if layers := LayersOf(pluginSources()); len(layers) != 0 {
+		t.Errorf("Expected no plugins without discovery, got %d", len(layers))
+	}
+	DiscoverPlugins = true
+	t.Setenv(NoPluginsEnvVar, "1")
+	if layers := LayersOf(pluginSources()); len(layers) != 0 {
+		t.Errorf("Expected no plugins with %s set, got %d", NoPluginsEnvVar, len(layers))
+	}
+}
+
+// TestLoadPlugin_TemplatesRequired tests that template plugins are validated normally
+func TestLoadPlugin_TemplatesRequired(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses shell script plugins")
+	}
+	t.Setenv(NoCacheEnvVar, "1")
+
+	dir := t.TempDir()
+	path := writePlugin(t, dir, "tmpl", "cat <<'EOF'\nplugin:\n  name: tmpl\ncommands:\n  - name: \"t\"\n    base_command: \"t\"\n    platforms:\n      linux: {}\nEOF\n", 0755)
+	if _, err := loadPlugin(path); err == nil || !strings.Contains(err.Error(), "template is required") {
+		t.Errorf("Expected missing template error, got %v", err)
+	}
+}
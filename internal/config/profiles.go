@@ -0,0 +1,69 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Profiles are named sets of globals and environment variables, such as dev,
+// staging and prod, for commands that differ only in the endpoint or
+// credentials they use. One is selected per run (--profile or
+// $GOLDFISH_PROFILE): its vars are merged over the globals (see globals.go)
+// and its env is set for the commands it runs and seen by templates as .env.
+
+// Profile is a named set of globals and environment variables
+type Profile struct {
+	// Name selects the profile, e.g. "prod"
+	Name string `yaml:"name"`
+	// Description says what the profile is for
+	Description string `yaml:"description,omitempty"`
+	// Vars are globals that override or add to the root vars: while the
+	// profile is selected; like them they are templates
+	Vars map[string]string `yaml:"vars,omitempty"`
+	// Env are environment variables set while the profile is selected
+	Env map[string]string `yaml:"env,omitempty"`
+}
+
+// FindProfile returns the profile with the given name
+func (c *Config) FindProfile(name string) (*Profile, bool) {
+	for i := range c.Profiles {
+		if c.Profiles[i].Name == name {
+			return &c.Profiles[i], true
+		}
+	}
+	return nil, false
+}
+
+// ProfileNames returns the names of the configuration's profiles
+func (c *Config) ProfileNames() []string {
+	names := make([]string, len(c.Profiles))
+	for i, profile := range c.Profiles {
+		names[i] = profile.Name
+	}
+	return names
+}
+
+// validateProfiles checks that profiles are named, unique and set valid
+// globals and environment variable names
+func validateProfiles(profiles []Profile) error {
+	names := make(map[string]bool, len(profiles))
+	for i, profile := range profiles {
+		if profile.Name == "" {
+			return fmt.Errorf("profile at index %d: name is required", i)
+		}
+		if names[profile.Name] {
+			return fmt.Errorf("duplicate profile name: %s", profile.Name)
+		}
+		names[profile.Name] = true
+
+		if err := validateGlobalNames(profile.Vars); err != nil {
+			return fmt.Errorf("profile '%s': %w", profile.Name, err)
+		}
+		for name := range profile.Env {
+			if name == "" || strings.ContainsAny(name, "= \t") {
+				return fmt.Errorf("profile '%s': env: invalid variable name '%s'", profile.Name, name)
+			}
+		}
+	}
+	return nil
+}
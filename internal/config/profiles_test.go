@@ -0,0 +1,57 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestLoader_Profiles tests loading, validating and merging profiles
+func TestLoader_Profiles(t *testing.T) {
+	shared, err := NewLoader("shared.yml").Parse([]byte(`
+profiles:
+  - name: staging
+    vars: {endpoint: "https://staging.example.com"}
+  - name: prod
+    description: Production
+    vars: {endpoint: "https://example.com"}
+    env: {AWS_PROFILE: prod}
+`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	user, err := NewLoader("user.yml").Parse([]byte(`
+profiles:
+  - name: prod
+    env: {AWS_PROFILE: prod-admin}
+`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	merged := MergeLayers(shared, user)
+	if strings.Join(merged.ProfileNames(), ",") != "prod,staging" {
+		t.Errorf("Expected the user's prod and the shared staging, got %v", merged.ProfileNames())
+	}
+	prod, found := merged.FindProfile("prod")
+	if !found || prod.Env["AWS_PROFILE"] != "prod-admin" || len(prod.Vars) != 0 {
+		t.Errorf("Expected the user's prod to replace the shared one, got %+v", prod)
+	}
+	if _, found := merged.FindProfile("dev"); found {
+		t.Error("Expected no dev profile")
+	}
+
+	testCases := []struct {
+		yaml     string
+		expected string
+	}{
+		{"profiles:\n  - vars: {a: b}\n", "profile at index 0: name is required"},
+		{"profiles:\n  - name: dev\n  - name: dev\n", "duplicate profile name: dev"},
+		{"profiles:\n  - name: dev\n    vars: {api-url: x}\n", "profile 'dev': vars: global 'api-url'"},
+		{"profiles:\n  - name: dev\n    env: {'A=B': x}\n", "profile 'dev': env: invalid variable name 'A=B'"},
+	}
+	for _, tc := range testCases {
+		if _, err := NewLoader("bad.yml").Parse([]byte(tc.yaml)); err == nil || !strings.Contains(err.Error(), tc.expected) {
+			t.Errorf("%q: expected error containing %q, got %v", tc.yaml, tc.expected, err)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// ProjectConfigName is the file that holds a project's shared commands
+// Like git with .git, goldfish looks for it in the working directory and then
+// each parent in turn, so every subdirectory of a repository (e.g. each
+// package of a monorepo) gets the commands defined at its root.
+const ProjectConfigName = ".goldfish.yml"
+
+// projectSource returns the nearest .goldfish.yml at or above the working
+// directory, or a not-found source naming where the search started
+func projectSource() (Source, bool) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return Source{}, false
+	}
+	if path, found := findProjectConfig(cwd); found {
+		return Source{Kind: SourceProject, Location: path, Found: true}, true
+	}
+	return Source{Kind: SourceProject, Location: filepath.Join(cwd, ProjectConfigName)}, true
+}
+
+// findProjectConfig searches dir and its parents for ProjectConfigName
+func findProjectConfig(dir string) (string, bool) {
+	for {
+		path := filepath.Join(dir, ProjectConfigName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
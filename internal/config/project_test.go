@@ -0,0 +1,65 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestFindProjectConfig tests finding .goldfish.yml in parent directories
+func TestFindProjectConfig(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "services", "api")
+	os.MkdirAll(nested, 0755)
+	// A directory of the same name is not a config file
+	os.MkdirAll(filepath.Join(root, "services", ProjectConfigName), 0755)
+
+	if _, found := findProjectConfig(nested); found {
+		t.Fatal("Expected no project config yet")
+	}
+	path := filepath.Join(root, ProjectConfigName)
+	os.WriteFile(path, []byte("commands: []\n"), 0644)
+	if found, ok := findProjectConfig(nested); !ok || found != path {
+		t.Errorf("Expected %s, got %s (%v)", path, found, ok)
+	}
+}
+
+// TestLoadLayers_Project tests that a project's .goldfish.yml overrides the
+// user's configuration but not the working directory's commands.yml
+func TestLoadLayers_Project(t *testing.T) {
+	originalPaths := ConfigSearchPaths
+	defer func() { ConfigSearchPaths = originalPaths }()
+	t.Setenv(PacksDirEnvVar, t.TempDir())
+	t.Setenv(NoPluginsEnvVar, "1")
+	t.Setenv(ConfigEnvVar, "")
+
+	tempDir := t.TempDir()
+	userDir := filepath.Join(tempDir, "user")
+	repo := filepath.Join(tempDir, "repo")
+	nested := filepath.Join(repo, "services", "api")
+	ConfigSearchPaths = []string{".", userDir}
+
+	writeLayer(t, userDir, "shared", "user")
+	projectPath := filepath.Join(repo, ProjectConfigName)
+	os.Rename(writeLayer(t, repo, "shared", "project"), projectPath)
+	os.MkdirAll(nested, 0755)
+	t.Chdir(nested)
+
+	layers, err := LoadLayers("")
+	if err != nil {
+		t.Fatalf("LoadLayers() failed: %v", err)
+	}
+	if len(layers) != 3 || layers[2].Source != projectPath {
+		t.Fatalf("Expected the project file above the user's, got %+v", layers)
+	}
+	if cmd, _ := MergeLoadedLayers(layers).FindCommand("shared"); cmd.BaseCommand != "project" {
+		t.Errorf("Expected the project's command, got %s", cmd.BaseCommand)
+	}
+
+	// The working directory's own commands.yml still wins
+	writeLayer(t, nested, "shared", "local")
+	layers, _ = LoadLayers("")
+	if cmd, _ := MergeLoadedLayers(layers).FindCommand("shared"); cmd.BaseCommand != "local" {
+		t.Errorf("Expected the working directory's command, got %s", cmd.BaseCommand)
+	}
+}
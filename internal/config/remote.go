@@ -0,0 +1,165 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ConfigEnvVar names a configuration file or https:// URL to load instead of
+// searching ConfigSearchPaths, e.g. GOLDFISH_CONFIG=https://intranet/goldfish/commands.yml
+const ConfigEnvVar = "GOLDFISH_CONFIG"
+
+// maxRemoteConfigSize caps how much is read from a remote configuration
+const maxRemoteConfigSize = 4 << 20
+
+// remoteClient fetches remote configuration; the timeout keeps an unreachable
+// server from hanging every goldfish invocation before the offline fallback
+var remoteClient = &http.Client{Timeout: 10 * time.Second, CheckRedirect: httpsRedirectsOnly}
+
+// httpsRedirectsOnly refuses to follow a redirect away from https://, which
+// would let the configuration be replaced on the network path after all
+func httpsRedirectsOnly(req *http.Request, via []*http.Request) error {
+	if req.URL.Scheme != "https" {
+		return fmt.Errorf("refusing to follow a redirect to %s: only https:// URLs are allowed", req.URL)
+	}
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	return nil
+}
+
+// IsRemote reports whether a configuration source is a URL rather than a file
+// Plain http:// URLs count, so that they are refused by fetchRemote rather
+// than looked for as files.
+func IsRemote(source string) bool {
+	return strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://")
+}
+
+// remoteCachePaths returns where a URL's content and ETag are cached
+func remoteCachePaths(source string) (body, etag string, err error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return "", "", err
+	}
+	sum := sha256.Sum256([]byte(source))
+	base := filepath.Join(dir, "remote", hex.EncodeToString(sum[:]))
+	return base + ".yml", base + ".etag", nil
+}
+
+// fetchRemote downloads a remote configuration
+// The last good copy is cached with its ETag: the server is asked whether it
+// changed (304 Not Modified reuses the cache), and when the server cannot be
+// reached the cached copy is used so goldfish keeps working offline.
+// Only https:// is fetched: commands from a plain http:// URL could be
+// replaced by anyone on the network path before goldfish runs them.
+func fetchRemote(source string) ([]byte, error) {
+	if !strings.HasPrefix(source, "https://") {
+		return nil, fmt.Errorf("refusing to fetch config %s: only https:// URLs are allowed", source)
+	}
+
+	bodyPath, etagPath, cacheErr := remoteCachePaths(source)
+	useCache := cacheEnabled() && cacheErr == nil
+
+	var cached []byte
+	var etag string
+	if useCache {
+		if data, err := os.ReadFile(bodyPath); err == nil {
+			cached = data
+			if tag, err := os.ReadFile(etagPath); err == nil {
+				etag = string(tag)
+			}
+		}
+	}
+
+	data, newEtag, notModified, err := requestRemote(source, etag)
+	if err != nil {
+		if cached != nil {
+			return cached, nil
+		}
+		return nil, err
+	}
+	if notModified && cached != nil {
+		return cached, nil
+	}
+
+	// Caching is an optimization, so failures to write it are ignored
+	if useCache {
+		if err := os.MkdirAll(filepath.Dir(bodyPath), 0755); err == nil {
+			_ = os.WriteFile(bodyPath, data, 0644)
+			if newEtag != "" {
+				_ = os.WriteFile(etagPath, []byte(newEtag), 0644)
+			} else {
+				os.Remove(etagPath)
+			}
+		}
+	}
+	return data, nil
+}
+
+// requestRemote performs the conditional GET for fetchRemote
+func requestRemote(source, etag string) (data []byte, newEtag string, notModified bool, err error) {
+	req, err := http.NewRequest(http.MethodGet, source, nil)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("invalid config URL %s: %w", source, err)
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	resp, err := remoteClient.Do(req)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("failed to fetch config %s: %w", source, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil, etag, true, nil
+	case http.StatusOK:
+	default:
+		return nil, "", false, fmt.Errorf("failed to fetch config %s: %s", source, resp.Status)
+	}
+
+	data, err = io.ReadAll(io.LimitReader(resp.Body, maxRemoteConfigSize+1))
+	if err != nil {
+		return nil, "", false, fmt.Errorf("failed to read config %s: %w", source, err)
+	}
+	if len(data) > maxRemoteConfigSize {
+		return nil, "", false, fmt.Errorf("config %s exceeds %d bytes", source, maxRemoteConfigSize)
+	}
+	return data, resp.Header.Get("ETag"), false, nil
+}
+
+// resolveInclude resolves an includes: entry relative to the file that lists it
+// URLs stay as they are; relative entries are relative to the including file,
+// or to the including URL when that file is itself remote
+func resolveInclude(base, include string) string {
+	if IsRemote(include) {
+		return include
+	}
+	if IsRemote(base) {
+		baseURL, err := url.Parse(base)
+		if err != nil {
+			return include
+		}
+		ref, err := url.Parse(include)
+		if err != nil {
+			return include
+		}
+		return baseURL.ResolveReference(ref).String()
+	}
+	include = expandPath(include)
+	if filepath.IsAbs(include) {
+		return include
+	}
+	return filepath.Join(filepath.Dir(base), include)
+}
@@ -0,0 +1,172 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// remoteYAML returns a configuration defining one command
+func remoteYAML(name, baseCommand string) string {
+	return "commands:\n  - name: \"" + name + "\"\n    base_command: \"" + baseCommand + "\"\n    platforms:\n      linux:\n        template: \"" + baseCommand + "\"\n"
+}
+
+// newRemoteServer starts an https:// server for remote configuration, and
+// fetches remote configuration with a client that trusts it, and follows
+// redirects like the real one
+func newRemoteServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	server := httptest.NewTLSServer(handler)
+	client := remoteClient
+	remoteClient = server.Client()
+	remoteClient.CheckRedirect = client.CheckRedirect
+	t.Cleanup(func() {
+		remoteClient = client
+		server.Close()
+	})
+	return server
+}
+
+// TestIsRemote tests URL detection
+func TestIsRemote(t *testing.T) {
+	if !IsRemote("https://intranet/goldfish/commands.yml") || !IsRemote("http://localhost/commands.yml") {
+		t.Errorf("Expected http(s) URLs to be remote")
+	}
+	if IsRemote("commands.yml") || IsRemote("/etc/goldfish/commands.yml") {
+		t.Errorf("Expected paths not to be remote")
+	}
+}
+
+// TestLoader_Load_Remote tests ETag revalidation and the offline fallback
+func TestLoader_Load_Remote(t *testing.T) {
+	t.Setenv(CacheDirEnvVar, t.TempDir())
+
+	var requests, notModified atomic.Int32
+	body := remoteYAML("remote", "v1")
+	server := newRemoteServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(body))
+	})
+	source := server.URL + "/commands.yml"
+
+	for i := 0; i < 2; i++ {
+		cfg, err := NewLoader(source).Load()
+		if err != nil {
+			t.Fatalf("Load() %d failed: %v", i, err)
+		}
+		if cmd, found := cfg.FindCommand("remote"); !found || cmd.BaseCommand != "v1" {
+			t.Errorf("Load() %d: expected remote command, got %+v", i, cfg.Commands)
+		}
+	}
+	if requests.Load() != 2 || notModified.Load() != 1 {
+		t.Errorf("Expected the second load to revalidate with the ETag, got %d requests, %d not modified", requests.Load(), notModified.Load())
+	}
+
+	// With the server gone the cached copy is used
+	server.Close()
+	cfg, err := NewLoader(source).Load()
+	if err != nil {
+		t.Fatalf("Expected offline fallback, got %v", err)
+	}
+	if _, found := cfg.FindCommand("remote"); !found {
+		t.Errorf("Expected cached command offline")
+	}
+
+	// Nothing cached and no server is an error
+	if _, err := NewLoader(server.URL + "/other.yml").Load(); err == nil {
+		t.Errorf("Expected an error for an unreachable, uncached URL")
+	}
+}
+
+// TestLoader_Load_RemoteHTTP tests that configuration is not fetched over plain http
+func TestLoader_Load_RemoteHTTP(t *testing.T) {
+	t.Setenv(CacheDirEnvVar, t.TempDir())
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte(remoteYAML("remote", "v1")))
+	}))
+	defer server.Close()
+
+	_, err := NewLoader(server.URL + "/commands.yml").Load()
+	if err == nil || !strings.Contains(err.Error(), "only https:// URLs are allowed") {
+		t.Errorf("Expected plain http to be refused, got %v", err)
+	}
+	if requests.Load() != 0 {
+		t.Errorf("Expected no request, got %d", requests.Load())
+	}
+
+	// Nor by following a redirect from https
+	redirect := newRemoteServer(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, server.URL+"/commands.yml", http.StatusFound)
+	})
+	_, err = NewLoader(redirect.URL + "/commands.yml").Load()
+	if err == nil || !strings.Contains(err.Error(), "refusing to follow a redirect") {
+		t.Errorf("Expected the redirect to plain http to be refused, got %v", err)
+	}
+	if requests.Load() != 0 {
+		t.Errorf("Expected no request over plain http, got %d", requests.Load())
+	}
+}
+
+// TestLoadLayers_Includes tests includes: of files and URLs, and GOLDFISH_CONFIG
+func TestLoadLayers_Includes(t *testing.T) {
+	t.Setenv(CacheDirEnvVar, t.TempDir())
+	t.Setenv(PacksDirEnvVar, t.TempDir())
+
+	server := newRemoteServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/team/commands.yml":
+			// Includes a sibling URL relative to itself
+			w.Write([]byte("includes:\n  - base.yml\n" + remoteYAML("shared", "team")))
+		case "/team/base.yml":
+			w.Write([]byte(remoteYAML("base", "base") + "  - name: \"shared\"\n    base_command: \"base\"\n    platforms:\n      linux:\n        template: \"base\"\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	dir := t.TempDir()
+	local := filepath.Join(dir, "commands.yml")
+	content := "includes:\n  - " + server.URL + "/team/commands.yml\n  - missing.yml\n  - commands.yml\n" + remoteYAML("local", "local")
+	if err := os.WriteFile(local, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	t.Setenv(ConfigEnvVar, local)
+	layers, err := LoadLayers("")
+	if err != nil {
+		t.Fatalf("LoadLayers() failed: %v", err)
+	}
+
+	// Defaults, then the included chain beneath the including file; the
+	// missing include and the self include are skipped
+	var sources []string
+	for _, layer := range layers {
+		sources = append(sources, layer.Source)
+	}
+	expected := []string{EmbeddedSource, server.URL + "/team/base.yml", server.URL + "/team/commands.yml", local}
+	if strings.Join(sources, ",") != strings.Join(expected, ",") {
+		t.Fatalf("Expected layers %v, got %v", expected, sources)
+	}
+
+	merged := MergeLoadedLayers(layers)
+	if cmd, _ := merged.FindCommand("shared"); cmd.BaseCommand != "team" {
+		t.Errorf("Expected the including file to override its include, got %s", cmd.BaseCommand)
+	}
+	for _, name := range []string{"base", "local"} {
+		if _, found := merged.FindCommand(name); !found {
+			t.Errorf("Expected command %s in merged config", name)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// SchemaID identifies the JSON Schema generated for commands.yml
+const SchemaID = "https://github.com/danballance/goldfish/commands.schema.json"
+
+// schemaRequired lists the fields each type must set, by YAML name
+// Other fields are optional; validate enforces the rules a schema cannot
+// express, such as base_command and platforms being required unless the
+// command is merged deeply into another (which the file may set for it)
+var schemaRequired = map[reflect.Type][]string{
+	reflect.TypeOf(Command{}):      {"name"},
+	reflect.TypeOf(RetryPolicy{}):  {"attempts"},
+	reflect.TypeOf(CachePolicy{}):  {"ttl"},
+	reflect.TypeOf(Workflow{}):     {"name", "steps"},
+	reflect.TypeOf(WorkflowStep{}): {"command"},
+	reflect.TypeOf(PackMetadata{}): {"name", "version"},
+	reflect.TypeOf(WasmModule{}):   {"name", "path"},
+	reflect.TypeOf(Alias{}):        {"name", "command"},
+	reflect.TypeOf(Profile{}):      {"name"},
+}
+
+// schemaRequiredAnyOf lists alternative sets of required fields: one set
+// must be complete, e.g. a parameter either defines itself or uses a definition
+var schemaRequiredAnyOf = map[reflect.Type][][]string{
+	reflect.TypeOf(Parameter{}): {{"name", "type"}, {"use"}},
+}
+
+// schemaEnums restricts string fields to fixed values, keyed by "Type.field"
+var schemaEnums = map[string][]string{
+	"Parameter.type":        validParameterTypes,
+	"PlatformCommand.arch":  platform.Architectures,
+	"PlatformCommand.shell": validShells,
+	"Command.merge":         {MergeReplace, MergeDeep},
+	"Config.merge":          {MergeReplace, MergeDeep},
+	"NotifyPolicy.on":       {NotifyAlways, NotifyFailure, NotifySuccess},
+}
+
+// Schema returns a JSON Schema (draft-07) describing the commands.yml format
+// It is generated from the Config types so it cannot drift from what Load
+// accepts, and can be used by editors (e.g. yaml-language-server) for inline
+// validation and completion
+func Schema() map[string]interface{} {
+	schema := schemaFor(reflect.TypeOf(Config{}), map[reflect.Type]bool{})
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["$id"] = SchemaID
+	schema["title"] = "goldfish commands.yml"
+	return schema
+}
+
+// schemaFor builds the schema for a Go type
+// enclosing holds the structs being built around it; inside a struct of the
+// same type, fields that would nest it once more (a platform variant's own
+// variants) are left out, since that is not allowed and would never end
+func schemaFor(t reflect.Type, enclosing map[reflect.Type]bool) map[string]interface{} {
+	if t == reflect.TypeOf(time.Duration(0)) {
+		// Durations are written as Go duration strings such as "2s" or "1m30s"
+		return map[string]interface{}{"type": "string", "pattern": `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return schemaFor(t.Elem(), enclosing)
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": schemaFor(t.Elem(), enclosing)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaFor(t.Elem(), enclosing)}
+	case reflect.Struct:
+		nested := enclosing[t]
+		if !nested {
+			enclosing[t] = true
+			defer delete(enclosing, t)
+		}
+
+		properties := make(map[string]interface{})
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := yamlFieldName(field)
+			if name == "" || (nested && elementType(field.Type) == t) {
+				continue
+			}
+			property := schemaFor(field.Type, enclosing)
+			if values, found := schemaEnums[t.Name()+"."+name]; found {
+				// Lists restrict their items
+				if items, isList := property["items"].(map[string]interface{}); isList {
+					items["enum"] = values
+				} else {
+					property["enum"] = values
+				}
+			}
+			properties[name] = property
+		}
+		schema := map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"additionalProperties": false,
+		}
+		if required := schemaRequired[t]; len(required) > 0 {
+			schema["required"] = required
+		}
+		if alternatives := schemaRequiredAnyOf[t]; len(alternatives) > 0 {
+			anyOf := make([]interface{}, len(alternatives))
+			for i, required := range alternatives {
+				anyOf[i] = map[string]interface{}{"required": required}
+			}
+			schema["anyOf"] = anyOf
+		}
+		return schema
+	default:
+		// interface{} values (such as parameter defaults) accept anything
+		return map[string]interface{}{}
+	}
+}
+
+// elementType returns the type a field holds, looking through pointers and slices
+func elementType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	return t
+}
+
+// yamlFieldName returns the YAML key of a struct field, or "" if it is not serialized
+func yamlFieldName(field reflect.StructField) string {
+	if !field.IsExported() {
+		return ""
+	}
+	name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return strings.ToLower(field.Name)
+	}
+	return name
+}
+
+// decodeStrict parses YAML into config, rejecting keys the format does not define
+// A misspelt key (e.g. "platfroms") would otherwise be silently ignored; the
+// error names the line and the field so it can be fixed straight away
+func decodeStrict(data []byte, config *Config) error {
+	return decodeStrictInto(data, config)
+}
+
+// decodeStrictInto is decodeStrict for any target, such as a plugin manifest
+func decodeStrictInto(data []byte, target interface{}) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	// An empty document decodes to io.EOF; treat it as an empty configuration
+	// so validation reports what is missing
+	if err := decoder.Decode(target); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
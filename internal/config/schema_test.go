@@ -0,0 +1,62 @@
+package config
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestSchema tests the generated JSON Schema
+func TestSchema(t *testing.T) {
+	schema := Schema()
+	if _, err := json.Marshal(schema); err != nil {
+		t.Fatalf("Expected schema to marshal to JSON: %v", err)
+	}
+	if schema["additionalProperties"] != false {
+		t.Errorf("Expected unknown top-level keys to be rejected")
+	}
+
+	properties := schema["properties"].(map[string]interface{})
+	commands := properties["commands"].(map[string]interface{})
+	command := commands["items"].(map[string]interface{})
+	required := command["required"].([]string)
+	if strings.Join(required, ",") != "name" {
+		t.Errorf("Unexpected required command fields: %v", required)
+	}
+
+	commandProperties := command["properties"].(map[string]interface{})
+	if _, found := commandProperties["params"]; !found {
+		t.Errorf("Expected params to use its YAML name")
+	}
+	retry := commandProperties["retry"].(map[string]interface{})
+	backoff := retry["properties"].(map[string]interface{})["backoff"].(map[string]interface{})
+	if backoff["type"] != "string" {
+		t.Errorf("Expected durations to be strings, got %v", backoff["type"])
+	}
+
+	params := commandProperties["params"].(map[string]interface{})["items"].(map[string]interface{})
+	paramType := params["properties"].(map[string]interface{})["type"].(map[string]interface{})
+	if enum, _ := paramType["enum"].([]string); len(enum) != len(validParameterTypes) {
+		t.Errorf("Expected parameter type enum, got %v", paramType["enum"])
+	}
+	if anyOf, _ := params["anyOf"].([]interface{}); len(anyOf) != 2 {
+		t.Errorf("Expected parameters to either define themselves or use a definition, got %v", params["anyOf"])
+	}
+}
+
+// TestLoader_Parse_UnknownFields tests that misspelt keys are rejected with their line
+func TestLoader_Parse_UnknownFields(t *testing.T) {
+	data := []byte("commands:\n  - name: \"x\"\n    base_command: \"x\"\n    platfroms:\n      linux:\n        template: \"x\"\n")
+	_, err := NewLoader("commands.yml").Parse(data)
+	if err == nil {
+		t.Fatalf("Expected an unknown field to be rejected")
+	}
+	if !strings.Contains(err.Error(), "line 4") || !strings.Contains(err.Error(), "platfroms") {
+		t.Errorf("Expected the error to name the line and field, got %v", err)
+	}
+
+	// An empty file is reported as missing commands rather than a parse error
+	if _, err := NewLoader("commands.yml").Parse(nil); err == nil || !strings.Contains(err.Error(), "no commands") {
+		t.Errorf("Expected an empty file to fail validation, got %v", err)
+	}
+}
@@ -0,0 +1,26 @@
+package config
+
+import (
+	"fmt"
+
+	"go.starlark.net/syntax"
+)
+
+// ScriptOptions are the Starlark dialect options for command scripts
+// Top-level if/for statements and reassigning globals are allowed so a short
+// script can choose values without defining functions; while loops and
+// recursion stay disabled so scripts always terminate quickly.
+var ScriptOptions = &syntax.FileOptions{
+	Set:             true,
+	TopLevelControl: true,
+	GlobalReassign:  true,
+}
+
+// validateScript checks that a command's script is valid Starlark
+// It only parses the script; it is run by the engine (see engine/script.go)
+func validateScript(cmd *Command) error {
+	if _, err := ScriptOptions.Parse(cmd.Name+".star", cmd.Script, 0); err != nil {
+		return fmt.Errorf("command '%s': script: %w", cmd.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestValidate_Script tests that scripts are parsed and may replace templates
+func TestValidate_Script(t *testing.T) {
+	loader := NewLoader("")
+	cmd := Command{
+		Name:        "build",
+		BaseCommand: "make",
+		Platforms:   map[string]PlatformCommand{"linux": {}},
+		Script:      "command = base_command + ' all'\n",
+	}
+	if err := loader.validate(&Config{Commands: []Command{cmd}}); err != nil {
+		t.Errorf("Expected a script to stand in for the template, got %v", err)
+	}
+
+	cmd.Script = "command = (\n"
+	err := loader.validate(&Config{Commands: []Command{cmd}})
+	if err == nil || !strings.Contains(err.Error(), "command 'build': script") {
+		t.Errorf("Expected script syntax error, got %v", err)
+	}
+
+	cmd.Script = ""
+	if err := loader.validate(&Config{Commands: []Command{cmd}}); err == nil {
+		t.Error("Expected error for a missing template without a script")
+	}
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SignatureSuffix is appended to a configuration's file name or URL to find its
+// detached signature, e.g. docker-essentials.yml.sig
+const SignatureSuffix = ".sig"
+
+// ErrUntrustedSignature is returned when a signature is missing or was not
+// made by any of the trusted keys
+var ErrUntrustedSignature = errors.New("configuration is not signed by a trusted key")
+
+// Signatures are ed25519 (the scheme minisign uses): keys and signatures are
+// stored as base64 text so they can be pasted into YAML and served over HTTP.
+// Because templates run arbitrary shell commands, once any trusted_keys: are
+// configured every remote configuration and installed pack must be signed by
+// one of them before it is merged.
+
+// GenerateKeyPair creates a new signing key pair, returned base64 encoded
+// The public key goes in trusted_keys:; the private key signs packs
+func GenerateKeyPair() (publicKey, privateKey string, err error) {
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate key pair: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(public), base64.StdEncoding.EncodeToString(private), nil
+}
+
+// Sign returns the base64 signature of data made with a base64 private key
+func Sign(data []byte, privateKey string) (string, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(privateKey))
+	if err != nil || len(key) != ed25519.PrivateKeySize {
+		return "", fmt.Errorf("invalid private key")
+	}
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(ed25519.PrivateKey(key), data)), nil
+}
+
+// VerifySignature checks that signature is a valid signature of data by one of trustedKeys
+func VerifySignature(data, signature []byte, trustedKeys []string) error {
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return fmt.Errorf("%w: malformed signature", ErrUntrustedSignature)
+	}
+	for _, trusted := range trustedKeys {
+		key, err := decodePublicKey(trusted)
+		if err != nil {
+			continue
+		}
+		if ed25519.Verify(key, data, sig) {
+			return nil
+		}
+	}
+	return ErrUntrustedSignature
+}
+
+// decodePublicKey decodes a base64 public key from trusted_keys:
+func decodePublicKey(encoded string) (ed25519.PublicKey, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid trusted key '%s'", encoded)
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// TrustedKeys returns the trusted_keys: of every local configuration file
+// Only files on this machine are consulted: a remote file or pack vouching for
+// its own signer would defeat the point of verifying it
+func TrustedKeys() []string {
+	paths := layerPaths("")
+	if runtimePath := os.Getenv(ConfigEnvVar); runtimePath != "" && !IsRemote(runtimePath) {
+		paths = append(paths, runtimePath)
+	}
+
+	var keys []string
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var settings struct {
+			TrustedKeys []string `yaml:"trusted_keys"`
+		}
+		if err := yaml.Unmarshal(data, &settings); err != nil {
+			continue
+		}
+		for _, key := range settings.TrustedKeys {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
+// verify checks a loaded configuration's signature when the loader has trusted keys
+// The signature is read from the same place as the configuration plus SignatureSuffix
+func (l *Loader) verify(data []byte) error {
+	if len(l.trustedKeys) == 0 {
+		return nil
+	}
+
+	signaturePath := l.configPath + SignatureSuffix
+	var signature []byte
+	var err error
+	if IsRemote(l.configPath) {
+		signature, err = fetchRemote(signaturePath)
+	} else {
+		signature, err = os.ReadFile(signaturePath)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: no signature for %s", ErrUntrustedSignature, l.configPath)
+	}
+
+	if err := VerifySignature(data, signature, l.trustedKeys); err != nil {
+		return fmt.Errorf("%s: %w", l.configPath, err)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestSignAndVerify tests round-tripping a signature
+func TestSignAndVerify(t *testing.T) {
+	publicKey, privateKey, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("GenerateKeyPair() failed: %v", err)
+	}
+	otherKey, _, _ := GenerateKeyPair()
+	data := []byte(remoteYAML("signed", "echo"))
+
+	signature, err := Sign(data, privateKey)
+	if err != nil {
+		t.Fatalf("Sign() failed: %v", err)
+	}
+	if err := VerifySignature(data, []byte(signature+"\n"), []string{otherKey, publicKey}); err != nil {
+		t.Errorf("Expected signature to verify, got %v", err)
+	}
+	if err := VerifySignature(data, []byte(signature), []string{otherKey}); !errors.Is(err, ErrUntrustedSignature) {
+		t.Errorf("Expected untrusted key to fail, got %v", err)
+	}
+	if err := VerifySignature(append(data, '#'), []byte(signature), []string{publicKey}); !errors.Is(err, ErrUntrustedSignature) {
+		t.Errorf("Expected tampered data to fail, got %v", err)
+	}
+	if _, err := Sign(data, "not a key"); err == nil {
+		t.Errorf("Expected an invalid private key to fail")
+	}
+}
+
+// TestValidate_TrustedKeys tests that trusted keys are checked and may stand alone
+func TestValidate_TrustedKeys(t *testing.T) {
+	publicKey, _, _ := GenerateKeyPair()
+	loader := &Loader{}
+	if err := loader.validate(&Config{TrustedKeys: []string{publicKey}}); err != nil {
+		t.Errorf("Expected a keys-only config to be valid, got %v", err)
+	}
+	if err := loader.validate(&Config{TrustedKeys: []string{"bogus"}}); err == nil {
+		t.Errorf("Expected an invalid trusted key to fail validation")
+	}
+}
+
+// TestLoadLayers_Signatures tests that remote configs and packs need a trusted signature
+func TestLoadLayers_Signatures(t *testing.T) {
+	originalPaths := ConfigSearchPaths
+	defer func() { ConfigSearchPaths = originalPaths }()
+	t.Setenv(CacheDirEnvVar, t.TempDir())
+
+	publicKey, privateKey, _ := GenerateKeyPair()
+	signed := remoteYAML("signed", "signed")
+	unsigned := remoteYAML("unsigned", "unsigned")
+	signature, _ := Sign([]byte(signed), privateKey)
+
+	server := newRemoteServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/signed.yml":
+			w.Write([]byte(signed))
+		case "/signed.yml.sig":
+			w.Write([]byte(signature))
+		case "/unsigned.yml":
+			w.Write([]byte(unsigned))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	// Packs: one signed, one not
+	packsDir := t.TempDir()
+	t.Setenv(PacksDirEnvVar, packsDir)
+	os.WriteFile(filepath.Join(packsDir, "good.yml"), []byte(signed), 0644)
+	os.WriteFile(filepath.Join(packsDir, "good.yml.sig"), []byte(signature), 0644)
+	os.WriteFile(filepath.Join(packsDir, "bad.yml"), []byte(unsigned), 0644)
+
+	// The local config trusts the key and includes both remote files
+	projectDir := t.TempDir()
+	ConfigSearchPaths = []string{projectDir}
+	local := filepath.Join(projectDir, "commands.yml")
+	content := "trusted_keys:\n  - \"" + publicKey + "\"\nincludes:\n  - " + server.URL + "/signed.yml\n  - " + server.URL + "/unsigned.yml\n"
+	if err := os.WriteFile(local, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	if keys := TrustedKeys(); len(keys) != 1 || keys[0] != publicKey {
+		t.Fatalf("Expected the local trusted key, got %v", keys)
+	}
+
+	layers, err := LoadLayers("")
+	if err != nil {
+		t.Fatalf("LoadLayers() failed: %v", err)
+	}
+	expected := []string{EmbeddedSource, filepath.Join(packsDir, "good.yml"), server.URL + "/signed.yml", local}
+	if len(layers) != len(expected) {
+		t.Fatalf("Expected %d layers, got %d", len(expected), len(layers))
+	}
+	for i, layer := range layers {
+		if layer.Source != expected[i] {
+			t.Errorf("Layer %d: expected %s, got %s", i, expected[i], layer.Source)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Tags sort a growing catalog of commands into topics such as files, network
+// or destructive. goldfish list --tag filters by them, and help groups
+// commands under their first tag.
+
+// tagPattern is what a tag may look like: lowercase words joined by hyphens
+// or underscores, so that tags are typed the same way everywhere
+var tagPattern = regexp.MustCompile(`^[a-z0-9]+([-_][a-z0-9]+)*$`)
+
+// HasTags reports whether the command has every one of tags
+func (c *Command) HasTags(tags ...string) bool {
+	for _, tag := range tags {
+		found := false
+		for _, own := range c.Tags {
+			if own == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// validateTags checks that a command's tags are well formed and not repeated
+func validateTags(tags []string) error {
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		if !tagPattern.MatchString(tag) {
+			return fmt.Errorf("invalid tag '%s': tags are lowercase letters and digits, joined by - or _", tag)
+		}
+		if seen[tag] {
+			return fmt.Errorf("duplicate tag '%s'", tag)
+		}
+		seen[tag] = true
+	}
+	return nil
+}
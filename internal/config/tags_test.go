@@ -0,0 +1,44 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestCommand_HasTags tests matching commands by tag
+func TestCommand_HasTags(t *testing.T) {
+	cmd := &Command{Name: "rm", Tags: []string{"files", "destructive"}}
+	if !cmd.HasTags() || !cmd.HasTags("files") || !cmd.HasTags("destructive", "files") {
+		t.Error("Expected the command's tags to match")
+	}
+	if cmd.HasTags("network") || cmd.HasTags("files", "network") {
+		t.Error("Expected other tags not to match")
+	}
+}
+
+// TestValidateTags tests that tags must be well formed and unique
+func TestValidateTags(t *testing.T) {
+	if err := validateTags([]string{"files", "network-tools", "ci_cd", "x11"}); err != nil {
+		t.Errorf("Expected valid tags, got %v", err)
+	}
+	testCases := []struct {
+		tags     []string
+		expected string
+	}{
+		{[]string{""}, "invalid tag ''"},
+		{[]string{"Network"}, "invalid tag 'Network'"},
+		{[]string{"two words"}, "invalid tag 'two words'"},
+		{[]string{"-files"}, "invalid tag '-files'"},
+		{[]string{"files", "files"}, "duplicate tag 'files'"},
+	}
+	for _, tc := range testCases {
+		if err := validateTags(tc.tags); err == nil || !strings.Contains(err.Error(), tc.expected) {
+			t.Errorf("%q: expected error containing %q, got %v", tc.tags, tc.expected, err)
+		}
+	}
+
+	yaml := "commands:\n  - name: x\n    base_command: x\n    tags: [Files]\n    platforms:\n      linux: {template: x}\n"
+	if _, err := NewLoader("bad.yml").Parse([]byte(yaml)); err == nil || !strings.Contains(err.Error(), "command 'x': invalid tag 'Files'") {
+		t.Errorf("Expected loading to reject the tag, got %v", err)
+	}
+}
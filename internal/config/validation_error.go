@@ -0,0 +1,28 @@
+package config
+
+// ValidationError is a configuration error together with where it is in the file
+// Its message is the underlying error's; Path lets the CLI point at the
+// offending value, e.g. "commands[2].platforms.linux".
+type ValidationError struct {
+	// Source is the file or URL the configuration was loaded from
+	Source string
+	// Path locates the offending value in the YAML document
+	Path string
+	// Err describes what is wrong
+	Err error
+}
+
+// Error returns the underlying error's message
+func (e *ValidationError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrValidation
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestLoader_validate_Path tests that validation errors locate the offending value
+func TestLoader_validate_Path(t *testing.T) {
+	loader := NewLoader("commands.yml")
+	valid := Command{Name: "ok", BaseCommand: "true", Platforms: map[string]PlatformCommand{"linux": {Template: "true"}}}
+
+	testCases := []struct {
+		command  Command
+		expected string
+	}{
+		{Command{Name: "bad", Platforms: valid.Platforms}, "commands[1].base_command"},
+		{Command{Name: "bad", BaseCommand: "x", Parameters: []Parameter{{Name: "a", Type: "string"}, {Name: "b", Type: "strng"}}, Platforms: valid.Platforms}, "commands[1].params[1].type"},
+		{Command{Name: "bad", BaseCommand: "x", Platforms: map[string]PlatformCommand{"darwin": {}}}, "commands[1].platforms.darwin"},
+	}
+	for _, tc := range testCases {
+		err := loader.validate(&Config{Commands: []Command{valid, tc.command}})
+		var invalid *ValidationError
+		if !errors.As(err, &invalid) {
+			t.Errorf("Expected a ValidationError, got %v", err)
+			continue
+		}
+		if invalid.Path != tc.expected || invalid.Source != "commands.yml" {
+			t.Errorf("Expected %s in commands.yml, got %s in %s (%v)", tc.expected, invalid.Path, invalid.Source, err)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// A command's vars: are templates computed once its parameters are known and
+// given to its platform templates as .vars, e.g. a temporary directory that
+// differs per OS, so the command template itself stays simple. A var may use
+// other vars; they are computed in dependency order and must not form a cycle.
+
+// varName matches valid var names, which templates can use as .vars.name
+var varName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// varReference matches the vars a var's template refers to
+var varReference = regexp.MustCompile(`\.vars\.([A-Za-z_][A-Za-z0-9_]*)`)
+
+// VarOrder returns the names of the command's vars, each after those it refers to
+// It fails when a var has an invalid name, refers to an unknown var or vars
+// refer to each other in a cycle.
+func (c *Command) VarOrder() ([]string, error) {
+	return templateOrder(c.Vars, varReference, "var")
+}
+
+// templateOrder orders named templates (vars or globals) so that each comes
+// after those it refers to, as matched by reference
+func templateOrder(templates map[string]string, reference *regexp.Regexp, kind string) ([]string, error) {
+	// Sorted so that the order, and any error, is the same every time
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	refs := make(map[string][]string, len(names))
+	for _, name := range names {
+		if !varName.MatchString(name) {
+			return nil, fmt.Errorf("%s '%s': names may only contain letters, digits and underscores", kind, name)
+		}
+		for _, match := range reference.FindAllStringSubmatch(templates[name], -1) {
+			if _, found := templates[match[1]]; !found {
+				return nil, fmt.Errorf("%s '%s' refers to unknown %s '%s'", kind, name, kind, match[1])
+			}
+			refs[name] = append(refs[name], match[1])
+		}
+	}
+	return orderByReferences(names, func(name string) []string { return refs[name] }, kind+"s")
+}
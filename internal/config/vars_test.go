@@ -0,0 +1,47 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestCommand_VarOrder tests ordering vars by the vars they use
+func TestCommand_VarOrder(t *testing.T) {
+	cmd := &Command{
+		Name: "archive",
+		Vars: map[string]string{
+			"archive": "{{.vars.dir}}/{{.vars.stamp}}.tar",
+			"dir":     "/tmp",
+			"stamp":   `{{now.Format "20060102"}}`,
+		},
+	}
+	order, err := cmd.VarOrder()
+	if err != nil {
+		t.Fatalf("VarOrder() failed: %v", err)
+	}
+	if strings.Join(order, ",") != "dir,stamp,archive" {
+		t.Errorf("Expected archive last, got %v", order)
+	}
+
+	testCases := []struct {
+		vars     map[string]string
+		expected string
+	}{
+		{map[string]string{"a": "{{.vars.b}}", "b": "{{.vars.a}}"}, "vars form a cycle: a -> b -> a"},
+		{map[string]string{"a": "{{.vars.missing}}"}, "var 'a' refers to unknown var 'missing'"},
+		{map[string]string{"temp-dir": "/tmp"}, "var 'temp-dir': names may only contain"},
+	}
+	for _, tc := range testCases {
+		cmd.Vars = tc.vars
+		if _, err := cmd.VarOrder(); err == nil || !strings.Contains(err.Error(), tc.expected) {
+			t.Errorf("%v: expected error containing %q, got %v", tc.vars, tc.expected, err)
+		}
+	}
+
+	// Loading reports the same errors
+	cmd.BaseCommand = "tar"
+	cmd.Platforms = map[string]PlatformCommand{"linux": {Template: "tar"}}
+	if err := NewLoader("").validate(&Config{Commands: []Command{*cmd}}); err == nil || !strings.Contains(err.Error(), "command 'archive'") {
+		t.Errorf("Expected validation to fail, got %v", err)
+	}
+}
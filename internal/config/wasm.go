@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// WasmModule references a WebAssembly module that extends goldfish
+// Modules run in a sandbox without access to files, the network or the
+// environment. They can export template helper functions and parameter
+// validators, so organisation-specific rules (ticket IDs, path policies) can be
+// added without forking goldfish. The calling convention is described in the
+// engine package (internal/engine/wasm.go).
+type WasmModule struct {
+	// Name identifies the module; a higher config layer replaces a module of the same name
+	Name string `yaml:"name"`
+	// Path is the .wasm file, relative to the config file that lists it
+	Path string `yaml:"path"`
+}
+
+// resolveWasmPaths makes relative module paths relative to dir
+// dir is the directory of the config file; it is empty for remote configs,
+// which may only reference modules by absolute path
+func resolveWasmPaths(config *Config, dir string) error {
+	for i := range config.Wasm {
+		path := expandPath(config.Wasm[i].Path)
+		if filepath.IsAbs(path) {
+			config.Wasm[i].Path = path
+			continue
+		}
+		if dir == "" {
+			return fmt.Errorf("wasm module '%s': remote configs must use an absolute path", config.Wasm[i].Name)
+		}
+		config.Wasm[i].Path = filepath.Join(dir, path)
+	}
+	return nil
+}
+
+// validateWasmModules checks that every module is named and has a path
+func validateWasmModules(modules []WasmModule) error {
+	names := make(map[string]bool)
+	for i, module := range modules {
+		if module.Name == "" {
+			return fmt.Errorf("wasm module at index %d: name is required", i)
+		}
+		if module.Path == "" {
+			return fmt.Errorf("wasm module '%s': path is required", module.Name)
+		}
+		if names[module.Name] {
+			return fmt.Errorf("duplicate wasm module name: %s", module.Name)
+		}
+		names[module.Name] = true
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestLoad_WasmModules tests that module paths are resolved against the config file
+func TestLoad_WasmModules(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "commands.yml")
+	content := "wasm:\n  - name: \"policy\"\n    path: \"wasm/policy.wasm\"\n  - name: \"abs\"\n    path: \"/opt/abs.wasm\"\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	config, err := NewLoader(path).Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if got := config.Wasm[0].Path; got != filepath.Join(dir, "wasm", "policy.wasm") {
+		t.Errorf("Expected path relative to the config file, got %s", got)
+	}
+	if got := config.Wasm[1].Path; got != "/opt/abs.wasm" {
+		t.Errorf("Expected absolute path to be kept, got %s", got)
+	}
+
+	// Remote configs cannot name files relative to themselves
+	if err := resolveWasmPaths(&Config{Wasm: []WasmModule{{Name: "x", Path: "x.wasm"}}}, ""); err == nil {
+		t.Error("Expected error for a relative path in a remote config")
+	}
+}
+
+// TestValidate_WasmModules tests validation of module entries
+func TestValidate_WasmModules(t *testing.T) {
+	tests := []struct {
+		name    string
+		modules []WasmModule
+		want    string
+	}{
+		{"missing name", []WasmModule{{Path: "a.wasm"}}, "name is required"},
+		{"missing path", []WasmModule{{Name: "a"}}, "path is required"},
+		{"duplicate", []WasmModule{{Name: "a", Path: "a.wasm"}, {Name: "a", Path: "b.wasm"}}, "duplicate wasm module"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewLoader("").validate(&Config{Wasm: tt.modules})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+// TestMergeLayers_WasmModules tests that higher layers replace modules by name
+func TestMergeLayers_WasmModules(t *testing.T) {
+	lower := &Config{Wasm: []WasmModule{{Name: "policy", Path: "/lower.wasm"}, {Name: "extra", Path: "/extra.wasm"}}}
+	higher := &Config{Wasm: []WasmModule{{Name: "policy", Path: "/higher.wasm"}}}
+
+	merged := MergeLayers(lower, higher)
+	if len(merged.Wasm) != 2 {
+		t.Fatalf("Expected 2 modules, got %+v", merged.Wasm)
+	}
+	if merged.Wasm[0].Path != "/higher.wasm" || merged.Wasm[1].Name != "extra" {
+		t.Errorf("Unexpected merge result: %+v", merged.Wasm)
+	}
+}
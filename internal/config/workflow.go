@@ -0,0 +1,130 @@
+package config
+
+import "fmt"
+
+// Workflow composes existing goldfish commands into named steps
+// Steps may depend on each other, forming a DAG that is run in dependency order
+type Workflow struct {
+	// Name identifies the workflow (goldfish workflow run <name>)
+	Name string `yaml:"name"`
+	// Description explains what the workflow does
+	Description string `yaml:"description,omitempty"`
+	// Vars are shared variables available to every step as {{.vars.name}}
+	Vars map[string]string `yaml:"vars,omitempty"`
+	// Steps are the command invocations making up the workflow
+	Steps []WorkflowStep `yaml:"steps"`
+}
+
+// WorkflowStep is a single command invocation within a workflow
+type WorkflowStep struct {
+	// Name identifies the step for depends_on and in the summary
+	Name string `yaml:"name"`
+	// Command is the goldfish command name or alias to run
+	Command string `yaml:"command"`
+	// Args are positional arguments; they may use template syntax such as {{.vars.dir}}
+	Args []string `yaml:"args,omitempty"`
+	// Params sets parameters by name; string values may use template syntax
+	Params map[string]interface{} `yaml:"params,omitempty"`
+	// DependsOn lists steps that must succeed before this one runs
+	DependsOn []string `yaml:"depends_on,omitempty"`
+	// If is a template condition; the step is skipped unless it renders to a true value
+	// (e.g. '{{eq .vars.env "prod"}}' or '{{eq .steps.test.status "failed"}}')
+	If string `yaml:"if,omitempty"`
+}
+
+// FindWorkflow returns the workflow with the given name
+func (c *Config) FindWorkflow(name string) (*Workflow, bool) {
+	for _, workflow := range c.Workflows {
+		if workflow.Name == name {
+			return &workflow, true
+		}
+	}
+	return nil, false
+}
+
+// StepOrder returns the workflow's steps sorted so that every step comes after
+// the steps it depends on. Independent steps keep their declaration order.
+// An error is returned for unknown dependencies or dependency cycles.
+func (w *Workflow) StepOrder() ([]WorkflowStep, error) {
+	// Index the steps by name
+	index := make(map[string]int, len(w.Steps))
+	for i, step := range w.Steps {
+		index[step.Name] = i
+	}
+
+	// Count unmet dependencies for each step
+	pending := make([]int, len(w.Steps))
+	for i, step := range w.Steps {
+		for _, dep := range step.DependsOn {
+			if _, exists := index[dep]; !exists {
+				return nil, fmt.Errorf("step '%s' depends on unknown step '%s'", step.Name, dep)
+			}
+			pending[i]++
+		}
+	}
+
+	// Repeatedly take the first step whose dependencies have all been ordered
+	ordered := make([]WorkflowStep, 0, len(w.Steps))
+	done := make([]bool, len(w.Steps))
+	for len(ordered) < len(w.Steps) {
+		next := -1
+		for i := range w.Steps {
+			if !done[i] && pending[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next == -1 {
+			return nil, fmt.Errorf("dependency cycle between steps")
+		}
+
+		done[next] = true
+		ordered = append(ordered, w.Steps[next])
+		for i, step := range w.Steps {
+			for _, dep := range step.DependsOn {
+				if dep == w.Steps[next].Name {
+					pending[i]--
+				}
+			}
+		}
+	}
+
+	return ordered, nil
+}
+
+// validateWorkflows checks workflow definitions for structural errors
+func validateWorkflows(workflows []Workflow) error {
+	names := make(map[string]bool)
+	for i, workflow := range workflows {
+		if workflow.Name == "" {
+			return fmt.Errorf("workflow at index %d: name is required", i)
+		}
+		if names[workflow.Name] {
+			return fmt.Errorf("duplicate workflow name: %s", workflow.Name)
+		}
+		names[workflow.Name] = true
+
+		if len(workflow.Steps) == 0 {
+			return fmt.Errorf("workflow '%s': at least one step is required", workflow.Name)
+		}
+
+		stepNames := make(map[string]bool)
+		for j, step := range workflow.Steps {
+			if step.Name == "" {
+				return fmt.Errorf("workflow '%s': step at index %d: name is required", workflow.Name, j)
+			}
+			if stepNames[step.Name] {
+				return fmt.Errorf("workflow '%s': duplicate step name: %s", workflow.Name, step.Name)
+			}
+			stepNames[step.Name] = true
+			if step.Command == "" {
+				return fmt.Errorf("workflow '%s': step '%s': command is required", workflow.Name, step.Name)
+			}
+		}
+
+		if _, err := workflow.StepOrder(); err != nil {
+			return fmt.Errorf("workflow '%s': %w", workflow.Name, err)
+		}
+	}
+	return nil
+}
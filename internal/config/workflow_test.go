@@ -0,0 +1,116 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// stepNames lists the names of the given steps in order
+func stepNames(steps []WorkflowStep) string {
+	var names []string
+	for _, step := range steps {
+		names = append(names, step.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+// TestWorkflow_StepOrder tests dependency ordering of workflow steps
+func TestWorkflow_StepOrder(t *testing.T) {
+	workflow := &Workflow{
+		Name: "release",
+		Steps: []WorkflowStep{
+			{Name: "package", Command: "tar", DependsOn: []string{"build", "test"}},
+			{Name: "build", Command: "make"},
+			{Name: "test", Command: "make", DependsOn: []string{"build"}},
+			{Name: "lint", Command: "lint"},
+		},
+	}
+
+	ordered, err := workflow.StepOrder()
+	if err != nil {
+		t.Fatalf("StepOrder() failed: %v", err)
+	}
+	if got := stepNames(ordered); got != "build,test,package,lint" {
+		t.Errorf("Unexpected order: %s", got)
+	}
+
+	// Unknown dependencies are reported
+	workflow.Steps[3].DependsOn = []string{"missing"}
+	if _, err := workflow.StepOrder(); err == nil || !strings.Contains(err.Error(), "unknown step 'missing'") {
+		t.Errorf("Expected unknown step error, got %v", err)
+	}
+
+	// Cycles are reported
+	workflow.Steps[3].DependsOn = nil
+	workflow.Steps[1].DependsOn = []string{"package"}
+	if _, err := workflow.StepOrder(); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("Expected cycle error, got %v", err)
+	}
+}
+
+// TestValidateWorkflows tests structural validation of workflows
+func TestValidateWorkflows(t *testing.T) {
+	valid := Workflow{Name: "ci", Steps: []WorkflowStep{{Name: "build", Command: "make"}}}
+	if err := validateWorkflows([]Workflow{valid}); err != nil {
+		t.Errorf("Expected valid workflow to pass, got %v", err)
+	}
+
+	testCases := []struct {
+		name      string
+		workflows []Workflow
+	}{
+		{"missing name", []Workflow{{Steps: valid.Steps}}},
+		{"duplicate name", []Workflow{valid, valid}},
+		{"no steps", []Workflow{{Name: "empty"}}},
+		{"step without name", []Workflow{{Name: "x", Steps: []WorkflowStep{{Command: "make"}}}}},
+		{"step without command", []Workflow{{Name: "x", Steps: []WorkflowStep{{Name: "build"}}}}},
+		{"duplicate step", []Workflow{{Name: "x", Steps: []WorkflowStep{
+			{Name: "build", Command: "make"}, {Name: "build", Command: "make"},
+		}}}},
+		{"self dependency", []Workflow{{Name: "x", Steps: []WorkflowStep{
+			{Name: "build", Command: "make", DependsOn: []string{"build"}},
+		}}}},
+	}
+
+	for _, tc := range testCases {
+		if err := validateWorkflows(tc.workflows); err == nil {
+			t.Errorf("%s: expected validation error", tc.name)
+		}
+	}
+}
+
+// TestConfig_FindWorkflow tests looking up workflows by name
+func TestConfig_FindWorkflow(t *testing.T) {
+	config := &Config{Workflows: []Workflow{{Name: "ci"}}}
+
+	if workflow, found := config.FindWorkflow("ci"); !found || workflow.Name != "ci" {
+		t.Error("Expected to find workflow 'ci'")
+	}
+	if _, found := config.FindWorkflow("deploy"); found {
+		t.Error("Expected not to find workflow 'deploy'")
+	}
+}
+
+// TestMergeLayers_Workflows tests that higher layers override workflows by name
+func TestMergeLayers_Workflows(t *testing.T) {
+	lower := testLayer("defaults", "make")
+	lower.Workflows = []Workflow{{Name: "ci", Description: "lower"}, {Name: "docs"}}
+	higher := testLayer("user", "lint")
+	higher.Workflows = []Workflow{{Name: "ci", Description: "higher"}}
+
+	merged := MergeLayers(lower, higher)
+	if len(merged.Workflows) != 2 {
+		t.Fatalf("Expected 2 workflows, got %d", len(merged.Workflows))
+	}
+	if ci, _ := merged.FindWorkflow("ci"); ci.Description != "higher" {
+		t.Errorf("Expected higher layer to win, got %q", ci.Description)
+	}
+}
+
+// TestLoader_validate_WorkflowsOnly tests that a file may define only workflows
+func TestLoader_validate_WorkflowsOnly(t *testing.T) {
+	config := &Config{Workflows: []Workflow{{Name: "ci", Steps: []WorkflowStep{{Name: "build", Command: "make"}}}}}
+	if err := NewLoader("").validate(config); err != nil {
+		t.Errorf("Expected workflow-only config to be valid, got %v", err)
+	}
+}
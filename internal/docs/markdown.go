@@ -0,0 +1,213 @@
+// Package docs generates Markdown documentation from the merged goldfish configuration.
+// Each command gets its own page with a parameter table and an example of the
+// command line it runs on every platform, so a commands.yml can be published
+// as a browsable wiki.
+package docs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/engine"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// IndexFile is the name of the generated page that links to every command page
+const IndexFile = "index.md"
+
+// placeholderSecrets stands in for the OS keyring while rendering examples
+// Documentation must never contain real credentials, so every secret resolves
+// to a visible placeholder instead.
+type placeholderSecrets struct{}
+
+func (placeholderSecrets) Set(name, value string) error { return nil }
+func (placeholderSecrets) Delete(name string) error     { return nil }
+func (placeholderSecrets) Get(name string) (string, error) {
+	return "<secret:" + name + ">", nil
+}
+
+// Generator renders Markdown pages for commands
+type Generator struct {
+	engine *engine.Engine
+}
+
+// NewGenerator creates a documentation generator
+func NewGenerator() *Generator {
+	eng := engine.NewEngine(0)
+	eng.SetSecretStore(placeholderSecrets{})
+	return &Generator{engine: eng}
+}
+
+// WriteAll writes one page per listed command plus an index page into dir
+// It returns the paths of the files written.
+func (g *Generator) WriteAll(cfg *config.Config, dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	// Examples may use template helpers from the configured WASM modules
+	g.engine.SetWasmModules(cfg.Wasm)
+	g.engine.SetCommands(cfg.Commands)
+	g.engine.SetGlobals(cfg.Vars)
+
+	// Hidden, deprecated and disabled experimental commands are not published
+	var commands []config.Command
+	for _, cmd := range cfg.Commands {
+		if cmd.Listed() {
+			commands = append(commands, cmd)
+		}
+	}
+	sort.Slice(commands, func(i, j int) bool { return commands[i].Name < commands[j].Name })
+
+	var written []string
+	for _, cmd := range commands {
+		path := filepath.Join(dir, PageName(&cmd))
+		if err := os.WriteFile(path, []byte(g.Page(&cmd)), 0644); err != nil {
+			return written, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		written = append(written, path)
+	}
+
+	indexPath := filepath.Join(dir, IndexFile)
+	if err := os.WriteFile(indexPath, []byte(Index(commands)), 0644); err != nil {
+		return written, fmt.Errorf("failed to write %s: %w", indexPath, err)
+	}
+	return append(written, indexPath), nil
+}
+
+// PageName returns the file name of a command's page
+func PageName(cmd *config.Command) string {
+	return cmd.Name + ".md"
+}
+
+// Index renders the page linking to every command
+func Index(commands []config.Command) string {
+	var b strings.Builder
+	b.WriteString("# goldfish commands\n\n")
+	b.WriteString("| Command | Description |\n|---|---|\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(&b, "| [%s](%s) | %s |\n", cmd.Name, PageName(&cmd), escapeCell(cmd.Description))
+	}
+	return b.String()
+}
+
+// Page renders the Markdown page for one command
+func (g *Generator) Page(cmd *config.Command) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s\n\n", cmd.Name)
+	if cmd.Description != "" {
+		fmt.Fprintf(&b, "%s\n\n", cmd.Description)
+	}
+	if cmd.Alias != "" {
+		fmt.Fprintf(&b, "Alias: `%s`\n\n", cmd.Alias)
+	}
+
+	// Usage line with the required parameters as placeholders
+	usage := "goldfish " + cmd.Name
+	for _, param := range cmd.Parameters {
+		if param.Required && param.Flag == "" {
+			usage += " <" + param.Name + ">"
+		}
+	}
+	fmt.Fprintf(&b, "## Usage\n\n```sh\n%s\n```\n\n", usage)
+
+	// Parameter table
+	if len(cmd.Parameters) > 0 {
+		b.WriteString("## Parameters\n\n")
+		b.WriteString("| Name | Flag | Type | Required | Default | Description |\n|---|---|---|---|---|---|\n")
+		for _, param := range cmd.Parameters {
+			flag := param.Flag
+			if flag == "" {
+				flag = "--" + param.Name
+			}
+			required := "no"
+			if param.Required {
+				required = "yes"
+			}
+			defaultValue := ""
+			if param.Default != nil {
+				defaultValue = fmt.Sprintf("`%v`", param.Default)
+			}
+			fmt.Fprintf(&b, "| %s | `%s` | %s | %s | %s | %s |\n",
+				param.Name, flag, param.Type, required, defaultValue, escapeCell(param.Description))
+		}
+		b.WriteString("\n")
+	}
+
+	// The command line each platform runs, with the tool it needs
+	b.WriteString("## Platforms\n\n")
+	b.WriteString("| Platform | Requires | Example |\n|---|---|---|\n")
+	for _, name := range sortedPlatforms(cmd) {
+		example, err := g.example(cmd, platform.SupportedPlatform(name))
+		if err != nil {
+			fmt.Fprintf(&b, "| %s | | _%s_ |\n", name, escapeCell(err.Error()))
+			continue
+		}
+		fmt.Fprintf(&b, "| %s | `%s` | `%s` |\n", name, requiredTool(example), escapeCell(example))
+	}
+
+	return b.String()
+}
+
+// example renders the command for a platform using example parameter values
+func (g *Generator) example(cmd *config.Command, target platform.SupportedPlatform) (string, error) {
+	return g.engine.Preview(&engine.ExecutionContext{
+		Command:    cmd,
+		Platform:   target,
+		Parameters: ExampleParameters(cmd),
+	})
+}
+
+// ExampleParameters picks a documentation value for every parameter
+// Defaults are used where available; otherwise a placeholder of the right type
+func ExampleParameters(cmd *config.Command) map[string]interface{} {
+	params := make(map[string]interface{}, len(cmd.Parameters))
+	for _, param := range cmd.Parameters {
+		// Templated defaults depend on the invocation, so get a placeholder
+		if _, templated := param.DefaultTemplate(); param.Default != nil && !templated {
+			params[param.Name] = param.Default
+			continue
+		}
+		switch param.Type {
+		case "bool":
+			// Show the flag's effect rather than its absence
+			params[param.Name] = true
+		case "int":
+			params[param.Name] = 1
+		case "float":
+			params[param.Name] = 1.0
+		default:
+			params[param.Name] = "<" + param.Name + ">"
+		}
+	}
+	return params
+}
+
+// requiredTool returns the program a rendered command line starts with
+func requiredTool(commandLine string) string {
+	fields := strings.Fields(commandLine)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// sortedPlatforms returns the command's platform names in a stable order
+func sortedPlatforms(cmd *config.Command) []string {
+	names := make([]string, 0, len(cmd.Platforms))
+	for name := range cmd.Platforms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// escapeCell makes text safe to place in a Markdown table cell
+func escapeCell(text string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(text, "|", `\|`), "\n", " ")
+}
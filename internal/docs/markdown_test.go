@@ -0,0 +1,76 @@
+package docs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// testCommand returns a command with parameters and two platforms
+func testCommand() config.Command {
+	return config.Command{
+		Name:        "fetch",
+		Alias:       "get",
+		Description: "Download a URL",
+		BaseCommand: "curl",
+		Parameters: []config.Parameter{
+			{Name: "url", Type: "string", Required: true, Description: "address | to fetch"},
+			{Name: "retries", Type: "int", Flag: "--retries", Default: 3},
+			{Name: "quiet", Type: "bool", Flag: "-q"},
+		},
+		Platforms: map[string]config.PlatformCommand{
+			"linux":   {Template: `{{.base_command}}{{if .params.quiet}} -s{{end}} --retry {{.params.retries}} -H "Token: {{secret "api"}}" {{.params.url}}`},
+			"windows": {Template: `powershell -Command "Invoke-WebRequest {{.params.url}}"`},
+		},
+	}
+}
+
+// TestGenerator_Page tests the content of a command page
+func TestGenerator_Page(t *testing.T) {
+	cmd := testCommand()
+	page := NewGenerator().Page(&cmd)
+
+	expected := []string{
+		"# fetch",
+		"Download a URL",
+		"Alias: `get`",
+		"goldfish fetch <url>",
+		"| url | `--url` | string | yes |  | address \\| to fetch |",
+		"| retries | `--retries` | int | no | `3` |",
+		"| linux | `curl` | `curl -s --retry 3 -H \"Token: ********\" '<url>'` |",
+		"| windows | `powershell` |",
+	}
+	for _, text := range expected {
+		if !strings.Contains(page, text) {
+			t.Errorf("Expected page to contain %q, got:\n%s", text, page)
+		}
+	}
+}
+
+// TestGenerator_WriteAll tests writing command pages and the index
+func TestGenerator_WriteAll(t *testing.T) {
+	visible := testCommand()
+	hidden := testCommand()
+	hidden.Name = "internal"
+	hidden.Hidden = true
+
+	dir := filepath.Join(t.TempDir(), "wiki")
+	written, err := NewGenerator().WriteAll(&config.Config{Commands: []config.Command{visible, hidden}}, dir)
+	if err != nil {
+		t.Fatalf("WriteAll() failed: %v", err)
+	}
+	if len(written) != 2 {
+		t.Errorf("Expected a page and an index, got %v", written)
+	}
+
+	index, err := os.ReadFile(filepath.Join(dir, IndexFile))
+	if err != nil {
+		t.Fatalf("Failed to read index: %v", err)
+	}
+	if !strings.Contains(string(index), "[fetch](fetch.md)") || strings.Contains(string(index), "internal") {
+		t.Errorf("Unexpected index:\n%s", index)
+	}
+}
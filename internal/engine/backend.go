@@ -0,0 +1,76 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// Backend runs rendered command lines somewhere other than this machine,
+// e.g. on a Windows host over WinRM (see internal/winrm)
+type Backend interface {
+	// Run runs command in the template's shell ("" for the default) and
+	// returns its exit code, writing its output as it arrives. When ctx is
+	// done the command must be stopped and ctx's error returned.
+	Run(ctx context.Context, command, shell string, stdout, stderr io.Writer) (int, error)
+}
+
+// SetBackend makes the engine run commands with backend instead of locally
+// Pass nil to run commands locally again. Commands handled by a plugin
+// cannot run on a backend, and the environment goldfish prepares for local
+// commands (nesting depth, locale) is not passed on.
+func (e *Engine) SetBackend(backend Backend) {
+	e.backend = backend
+}
+
+// runOnBackend runs a rendered command with the engine's backend
+// Timeouts, termination signals and cancelling parent cancel the command, as
+// for local commands.
+func (e *Engine) runOnBackend(parent context.Context, command, shell string, timeout time.Duration, streams stdio) (int, error) {
+	if timeout == 0 {
+		timeout = e.timeout
+	}
+	if err := parent.Err(); err != nil {
+		return -1, cancelledError(parent)
+	}
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	// A signal stops the remote command rather than leaving it running
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, terminationSignals...)
+	defer signal.Stop(signals)
+	stopped := make(chan os.Signal, 1)
+	go func() {
+		select {
+		case received := <-signals:
+			stopped <- received
+			cancel()
+		case <-ctx.Done():
+			stopped <- nil
+		}
+	}()
+
+	exitCode, err := e.backend.Run(ctx, command, shell, streams.out, streams.err)
+	// Stop waiting for signals and find out whether one arrived
+	cancel()
+	interrupted := <-stopped
+	if err != nil {
+		if parent.Err() != nil {
+			return -1, cancelledError(parent)
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			metricTimeouts.Add(1)
+			return -1, fmt.Errorf("%w after %v: %s", ErrTimeout, timeout, command)
+		}
+		if errors.Is(err, context.Canceled) && interrupted != nil {
+			return -1, fmt.Errorf("command interrupted by signal: %v", interrupted)
+		}
+		return -1, fmt.Errorf("command execution failed: %w", err)
+	}
+	return exitCode, nil
+}
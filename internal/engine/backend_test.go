@@ -0,0 +1,72 @@
+package engine
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// fakeBackend records the commands it is asked to run
+type fakeBackend struct {
+	command string
+	shell   string
+	// wait makes Run block until its context is done
+	wait bool
+}
+
+// Run records the command and prints it, exiting with code 2
+func (b *fakeBackend) Run(ctx context.Context, command, shell string, stdout, stderr io.Writer) (int, error) {
+	b.command = command
+	b.shell = shell
+	if b.wait {
+		<-ctx.Done()
+		return -1, ctx.Err()
+	}
+	io.WriteString(stdout, "remote: "+command)
+	return 2, nil
+}
+
+// TestEngine_SetBackend tests running rendered commands on a backend
+func TestEngine_SetBackend(t *testing.T) {
+	backend := &fakeBackend{}
+	engine := NewEngine(5 * time.Second)
+	engine.SetBackend(backend)
+
+	cmd := &config.Command{
+		Name:       "list",
+		Parameters: []config.Parameter{{Name: "path", Type: "string"}},
+		Platforms: map[string]config.PlatformCommand{
+			"windows": {Template: "Get-ChildItem {{.params.path}}", Shell: "powershell"},
+		},
+	}
+	var stdout strings.Builder
+	ctx := &ExecutionContext{Command: cmd, Platform: platform.Windows, Parameters: map[string]interface{}{"path": `C:\Temp`}, Stdout: &stdout}
+	exitCode, err := engine.Run(context.Background(), ctx)
+	if err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+	if exitCode != 2 || stdout.String() != `remote: Get-ChildItem 'C:\Temp'` {
+		t.Errorf("Expected the backend's output and exit code, got %d and %q", exitCode, stdout.String())
+	}
+	if backend.shell != "powershell" {
+		t.Errorf("Expected the template's shell, got %q", backend.shell)
+	}
+
+	// Timeouts cancel the remote command
+	backend.wait = true
+	ctx.Timeout = 50 * time.Millisecond
+	if _, err := engine.Run(context.Background(), ctx); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("Expected a timeout error, got %v", err)
+	}
+
+	// Plugins only run locally
+	cmd.Plugin = "example"
+	if _, err := engine.Run(context.Background(), ctx); err == nil || !strings.Contains(err.Error(), "plugin") {
+		t.Errorf("Expected plugin commands to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,26 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// cancelledError is the error of a command stopped because parent was
+// cancelled (or its deadline passed) rather than by its own timeout
+// It wraps the cause, so errors.Is(err, context.Canceled) holds.
+func cancelledError(parent context.Context) error {
+	return fmt.Errorf("command cancelled: %w", context.Cause(parent))
+}
+
+// sleepContext waits for d, returning early with a cancelledError when ctx is cancelled
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return cancelledError(ctx)
+	}
+}
@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// TestEngine_Execute_Cancel tests that cancelling the context an execution
+// was started with stops the command, and is not reported as a timeout
+func TestEngine_Execute_Cancel(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	engine := NewEngine(30 * time.Second)
+	cmd := &config.Command{Name: "slow", BaseCommand: "sleep", Platforms: map[string]config.PlatformCommand{"linux": {Template: "sleep 5"}}}
+	newContext := func() *ExecutionContext {
+		return &ExecutionContext{Command: cmd, Platform: platform.Linux, Parameters: map[string]interface{}{}}
+	}
+
+	parent, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	start := time.Now()
+	result, err := engine.Execute(parent, newContext())
+	if !errors.Is(err, context.Canceled) || errors.Is(err, ErrTimeout) {
+		t.Errorf("Expected a cancellation error, got %v", err)
+	}
+	if result.ExitCode != -1 || time.Since(start) > 3*time.Second {
+		t.Errorf("Expected the command to be stopped, got %+v after %v", result, time.Since(start))
+	}
+
+	// A cancelled context runs nothing
+	if result, err := engine.Execute(parent, newContext()); !errors.Is(err, context.Canceled) || result.Attempts != 1 {
+		t.Errorf("Expected a cancelled context not to run the command, got %+v (%v)", result, err)
+	}
+
+	// A sooner deadline cuts the timeout short
+	parent, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	if _, err := engine.Execute(parent, newContext()); !errors.Is(err, context.DeadlineExceeded) || time.Since(start) > 3*time.Second {
+		t.Errorf("Expected the deadline to stop the command, got %v after %v", err, time.Since(start))
+	}
+}
+
+// TestEngine_Execute_CancelRetry tests that a command is not retried once
+// its context is cancelled
+func TestEngine_Execute_CancelRetry(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	engine := NewEngine(30 * time.Second)
+	cmd := &config.Command{
+		Name:        "flaky",
+		BaseCommand: "sh",
+		Retry:       &config.RetryPolicy{Attempts: 3, Backoff: time.Minute},
+		Platforms:   map[string]config.PlatformCommand{"linux": {Template: "exit 1"}},
+	}
+	parent, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	start := time.Now()
+	result, err := engine.Execute(parent, &ExecutionContext{Command: cmd, Platform: platform.Linux, Parameters: map[string]interface{}{}})
+	if !errors.Is(err, context.Canceled) || result.Attempts != 1 || time.Since(start) > 3*time.Second {
+		t.Errorf("Expected the wait for a retry to be cancelled, got %+v (%v) after %v", result, err, time.Since(start))
+	}
+}
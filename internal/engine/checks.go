@@ -0,0 +1,157 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// portTimeout bounds how long a port_open check waits for a connection
+const portTimeout = 3 * time.Second
+
+// checkOutputLimit is how much of a failing run check's stderr is reported
+const checkOutputLimit = 4096
+
+// runChecks verifies a command's check: preconditions in order and returns
+// an error describing the first that fails
+// Run checks are rendered and run like skip_if: (see skip.go); the built-in
+// assertions are made by goldfish itself, on the machine it runs on.
+func (e *Engine) runChecks(runCtx context.Context, ctx *ExecutionContext, cmd *config.Command, platformCmd *config.PlatformCommand) error {
+	if len(ctx.Command.Checks) == 0 {
+		return nil
+	}
+	data, err := e.commandData(cmd, ctx.Platform.String(), ctx.Parameters, ctx.revealedSet())
+	if err != nil {
+		return err
+	}
+	for i := range ctx.Command.Checks {
+		check := &ctx.Command.Checks[i]
+		start := time.Now()
+		reason, err := e.runCheck(runCtx, ctx, cmd, platformCmd, check, data)
+		if err != nil {
+			return fmt.Errorf("command '%s': check %d (%s): %w", ctx.Command.Name, i+1, check.Kind(), err)
+		}
+		e.debugf("check %d (%s) took %v: %s", i+1, check.Kind(), time.Since(start), passedOrFailed(reason))
+		if reason == "" {
+			continue
+		}
+		if check.Message != "" {
+			if reason, err = e.renderString("check message", check.Message, data, ctx.revealedSet()); err != nil {
+				return fmt.Errorf("command '%s': check %d: message: %w", ctx.Command.Name, i+1, err)
+			}
+		}
+		return fmt.Errorf("command '%s' was not run: check %d failed: %s", ctx.Command.Name, i+1, reason)
+	}
+	return nil
+}
+
+// passedOrFailed describes a check's outcome for verbose logs
+func passedOrFailed(reason string) string {
+	if reason == "" {
+		return "passed"
+	}
+	return "failed: " + reason
+}
+
+// runCheck makes one check and returns why it failed, or "" when it passed
+// The error is set when the check could not be made at all (e.g. a template
+// error), as opposed to failing.
+func (e *Engine) runCheck(runCtx context.Context, ctx *ExecutionContext, cmd *config.Command, platformCmd *config.PlatformCommand, check *config.Check, data map[string]interface{}) (string, error) {
+	switch check.Kind() {
+	case "run":
+		return e.runCheckCommand(runCtx, ctx, cmd, platformCmd, check.Run, data)
+
+	case "file_exists":
+		path, err := e.renderString("file_exists", check.FileExists, data, ctx.revealedSet())
+		if err != nil {
+			return "", err
+		}
+		if _, err := os.Stat(path); err != nil {
+			if os.IsNotExist(err) {
+				return fmt.Sprintf("%s does not exist", path), nil
+			}
+			return err.Error(), nil
+		}
+		return "", nil
+
+	case "port_open":
+		address, err := e.renderString("port_open", check.PortOpen, data, ctx.revealedSet())
+		if err != nil {
+			return "", err
+		}
+		dialer := net.Dialer{Timeout: portTimeout}
+		conn, err := dialer.DialContext(runCtx, "tcp", address)
+		if runCtx.Err() != nil {
+			return "", cancelledError(runCtx)
+		}
+		if err != nil {
+			return fmt.Sprintf("cannot connect to %s: %v", address, err), nil
+		}
+		conn.Close()
+		return "", nil
+
+	case "min_disk_space":
+		sizeText, err := e.renderString("min_disk_space", check.MinDiskSpace, data, ctx.revealedSet())
+		if err != nil {
+			return "", err
+		}
+		required, err := config.ParseSize(sizeText)
+		if err != nil {
+			return "", err
+		}
+		path := "."
+		if check.Path != "" {
+			if path, err = e.renderString("path", check.Path, data, ctx.revealedSet()); err != nil {
+				return "", err
+			}
+		}
+		available, err := diskAvailable(path)
+		if err != nil {
+			return "", fmt.Errorf("cannot read the free space of %s: %w", path, err)
+		}
+		if available < required {
+			return fmt.Sprintf("%s has %s available, less than the %s required", path, formatSize(available), strings.TrimSpace(sizeText)), nil
+		}
+		return "", nil
+	}
+	return "", fmt.Errorf("unknown check")
+}
+
+// runCheckCommand runs a check command line and returns why it failed, with
+// the start of its stderr, or "" when it exited with 0
+func (e *Engine) runCheckCommand(runCtx context.Context, ctx *ExecutionContext, cmd *config.Command, platformCmd *config.PlatformCommand, text string, data map[string]interface{}) (string, error) {
+	stderr := &cappedBuffer{stream: "stderr", limit: checkOutputLimit}
+	commandLine, exitCode, err := e.runStep(runCtx, ctx, cmd, platformCmd, text, data, stdio{out: io.Discard, err: stderr})
+	if err != nil {
+		return "", err
+	}
+	if exitCode == 0 {
+		return "", nil
+	}
+	reason := fmt.Sprintf("%s exited with code %d", e.maskSecrets(ctx, commandLine), exitCode)
+	if output := strings.TrimSpace(stderr.String()); output != "" {
+		reason += ": " + e.maskSecrets(ctx, output)
+	}
+	return reason, nil
+}
+
+// formatSize formats a number of bytes for messages, e.g. "2.5 GB"
+func formatSize(bytes uint64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB"}
+	value := float64(bytes)
+	unit := 0
+	for value >= 1000 && unit < len(units)-1 {
+		value /= 1000
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	return fmt.Sprintf("%.1f %s", value, units[unit])
+}
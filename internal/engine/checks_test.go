@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// TestEngine_Execute_Checks tests that a failing check stops the command
+// with a message saying which check failed and why
+func TestEngine_Execute_Checks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer listener.Close()
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	closedAddress := closed.Addr().String()
+	closed.Close()
+
+	dir := t.TempDir()
+	ran := filepath.Join(dir, "ran")
+	testCases := []struct {
+		name  string
+		check config.Check
+		err   string
+	}{
+		{"file exists", config.Check{FileExists: "{{.params.dir}}"}, ""},
+		{"file missing", config.Check{FileExists: "{{.params.dir}}/missing"}, "check 1 failed: " + dir + "/missing does not exist"},
+		{"port open", config.Check{PortOpen: listener.Addr().String()}, ""},
+		{"port closed", config.Check{PortOpen: closedAddress}, "cannot connect to " + closedAddress},
+		{"enough space", config.Check{MinDiskSpace: "1KB", Path: "{{.params.dir}}"}, ""},
+		{"not enough space", config.Check{MinDiskSpace: "1000000TB", Path: "{{.params.dir}}"}, "less than the 1000000TB required"},
+		{"run passes", config.Check{Run: "test -d {{.params.dir}}"}, ""},
+		{"run fails", config.Check{Run: "echo not ready >&2; exit 4"}, "exited with code 4: not ready"},
+		{"message", config.Check{FileExists: "/no/such/file", Message: "{{.params.dir}} is not set up"}, "check 1 failed: " + dir + " is not set up"},
+	}
+	engine := NewEngine(30 * time.Second)
+	for _, tc := range testCases {
+		os.Remove(ran)
+		cmd := &config.Command{
+			Name:        "deploy",
+			BaseCommand: "touch",
+			Parameters:  []config.Parameter{{Name: "dir", Type: "string"}},
+			Checks:      []config.Check{tc.check},
+			Platforms:   map[string]config.PlatformCommand{"linux": {Template: "touch " + ran}},
+		}
+		_, err := engine.Execute(context.Background(), &ExecutionContext{Command: cmd, Platform: platform.Linux, Parameters: map[string]interface{}{"dir": dir}})
+		_, statErr := os.Stat(ran)
+		if tc.err == "" {
+			if err != nil || statErr != nil {
+				t.Errorf("%s: expected the command to run, got %v", tc.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: expected error containing %q, got %v", tc.name, tc.err, err)
+		}
+		if statErr == nil {
+			t.Errorf("%s: expected the command not to run", tc.name)
+		}
+	}
+}
+
+// TestFormatSize tests formatting byte counts for messages
+func TestFormatSize(t *testing.T) {
+	for bytes, expected := range map[uint64]string{0: "0 B", 999: "999 B", 1500: "1.5 KB", 2500000000: "2.5 GB"} {
+		if got := formatSize(bytes); got != expected {
+			t.Errorf("formatSize(%d): expected %q, got %q", bytes, expected, got)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// runCleanup runs a command's on_failure: steps when it failed and then its
+// always: steps, once it has run; err is the error the command ended with
+// Steps are run in order, each to the end: a step that fails is reported as
+// a warning and the others still run, and nothing they do changes the
+// command's result. Steps see the command's data, and its exit code as
+// .exit_code (-1 when it could not run).
+func (e *Engine) runCleanup(runCtx context.Context, ctx *ExecutionContext, cmd *config.Command, platformCmd *config.PlatformCommand, result *ExecutionResult, err error) {
+	type step struct{ name, text string }
+	var steps []step
+	if err != nil || result.ExitCode != 0 {
+		for i, text := range ctx.Command.OnFailure {
+			steps = append(steps, step{fmt.Sprintf("on_failure step %d", i+1), text})
+		}
+	}
+	for i, text := range ctx.Command.Always {
+		steps = append(steps, step{fmt.Sprintf("always step %d", i+1), text})
+	}
+	if len(steps) == 0 {
+		return
+	}
+
+	// Steps print where the command did; captured output is the command's alone
+	streams := ctx.streams()
+	if ctx.Capture {
+		streams.out = captureStream(ctx.Stdout, ctx.Quiet, io.Discard)
+		streams.err = captureStream(ctx.Stderr, ctx.NoStderr, io.Discard)
+	}
+	streams.in = nil
+	data, dataErr := e.commandData(cmd, ctx.Platform.String(), ctx.Parameters, ctx.revealedSet())
+	if dataErr != nil {
+		fmt.Fprintf(streams.err, "Warning: '%s': cleanup steps not run: %v\n", ctx.Command.Name, dataErr)
+		return
+	}
+	data["exit_code"] = result.ExitCode
+
+	for _, step := range steps {
+		start := time.Now()
+		_, exitCode, stepErr := e.runStep(runCtx, ctx, cmd, platformCmd, step.text, data, streams)
+		e.debugf("%s exited with code %d after %v", step.name, exitCode, time.Since(start))
+		switch {
+		case stepErr != nil:
+			fmt.Fprintf(streams.err, "Warning: '%s' %s: %v\n", ctx.Command.Name, step.name, stepErr)
+		case exitCode != 0:
+			fmt.Fprintf(streams.err, "Warning: '%s' %s exited with code %d\n", ctx.Command.Name, step.name, exitCode)
+		}
+	}
+}
+
+// renderStep renders a step's command line like the command's template, with
+// the same escaping
+func (e *Engine) renderStep(ctx *ExecutionContext, platformCmd *config.PlatformCommand, text string, data map[string]interface{}) (string, error) {
+	tmpl, err := e.parseCommandTemplate(text, platformShell(platformCmd.Shell, ctx.Platform.String()))
+	if err != nil {
+		return "", err
+	}
+	if tmpl, err = e.withSecrets(tmpl, text, ctx.revealedSet()); err != nil {
+		return "", err
+	}
+	return executeTemplate(tmpl, data)
+}
+
+// runStep renders a command line like the command's template, with the same
+// escaping, and runs it in the same shell, environment and limits
+// It returns the command line it ran and its exit code. skip_if:, check:
+// run commands and cleanup steps are all run this way, until runCtx is
+// cancelled, in the command's sandbox when it has one.
+func (e *Engine) runStep(runCtx context.Context, ctx *ExecutionContext, cmd *config.Command, platformCmd *config.PlatformCommand, text string, data map[string]interface{}, streams stdio) (string, int, error) {
+	commandLine, err := e.renderStep(ctx, platformCmd, text, data)
+	if err != nil {
+		return "", -1, err
+	}
+	shell := platformShell(platformCmd.Shell, ctx.Platform.String())
+	if err := e.checkRestricted(cmd, shell, commandLine); err != nil {
+		return commandLine, -1, err
+	}
+	options, err := e.commandProcessOptions(ctx, cmd)
+	if err != nil {
+		return commandLine, -1, err
+	}
+	// A mock backend keeps a transcript, which must not contain secrets
+	run := commandLine
+	if _, mock := e.backend.(*MockBackend); mock {
+		run = e.maskSecrets(ctx, run)
+	}
+	exitCode, err := e.executeCommand(runCtx, run, platformCmd.Shell, ctx.Timeout, e.commandEnvironment(ctx.Command),
+		options, streams)
+	return commandLine, exitCode, err
+}
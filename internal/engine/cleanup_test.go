@@ -0,0 +1,67 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// TestEngine_Execute_Cleanup tests that on_failure: steps run when the
+// command fails and always: steps every time, without changing its result
+func TestEngine_Execute_Cleanup(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	dir := t.TempDir()
+	steps := filepath.Join(dir, "steps")
+	cmd := &config.Command{
+		Name:        "edit",
+		BaseCommand: "sh",
+		Parameters:  []config.Parameter{{Name: "code", Type: "int"}},
+		OnFailure:   []string{"echo restore {{.exit_code}} >> " + steps},
+		Always:      []string{"exit 9", "echo tidy >> " + steps},
+		Platforms:   map[string]config.PlatformCommand{"linux": {Template: "exit {{.params.code}}"}},
+	}
+	engine := NewEngine(30 * time.Second)
+	testCases := []struct {
+		code     int
+		expected string
+	}{
+		{0, "tidy\n"},
+		{3, "restore 3\ntidy\n"},
+	}
+	for _, tc := range testCases {
+		os.Remove(steps)
+		var errOut bytes.Buffer
+		result, err := engine.Execute(context.Background(), &ExecutionContext{Command: cmd, Platform: platform.Linux,
+			Parameters: map[string]interface{}{"code": tc.code}, Stderr: &errOut})
+		if err != nil || result.ExitCode != tc.code {
+			t.Errorf("exit %d: expected the command's own result, got %+v (%v)", tc.code, result, err)
+		}
+		if data, _ := os.ReadFile(steps); string(data) != tc.expected {
+			t.Errorf("exit %d: expected steps %q, got %q", tc.code, tc.expected, data)
+		}
+		// A failing step is a warning, and the steps after it still run
+		if !strings.Contains(errOut.String(), "Warning: 'edit' always step 1 exited with code 9") {
+			t.Errorf("exit %d: expected the failing step to be reported, got %q", tc.code, errOut.String())
+		}
+	}
+
+	// A command stopped by its checks has not run, so there is nothing to clean up
+	os.Remove(steps)
+	cmd.Checks = []config.Check{{FileExists: filepath.Join(dir, "missing")}}
+	if _, err := engine.Execute(context.Background(), &ExecutionContext{Command: cmd, Platform: platform.Linux, Parameters: map[string]interface{}{"code": 0}}); err == nil {
+		t.Fatal("Expected the check to fail")
+	}
+	if _, err := os.Stat(steps); !os.IsNotExist(err) {
+		t.Errorf("Expected no steps to run, got %v", err)
+	}
+}
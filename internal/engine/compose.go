@@ -0,0 +1,113 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// Higher-level commands are often a couple of existing ones chained together.
+// Rather than calling goldfish from a template, which starts a second
+// process that loads the configuration again, a template can use
+// {{goldfish "name" "param=value" ...}}: the engine renders the other
+// command's template for the same platform and inserts its command line.
+// Nothing extra runs; the result is one shell command line.
+
+// SetCommands configures the commands that {{goldfish}} can compose
+// Without them every {{goldfish}} call fails with an unknown command error.
+func (e *Engine) SetCommands(commands []config.Command) {
+	e.commands = &config.Config{Commands: commands}
+}
+
+// composeFunc is the {{goldfish}} function bound while a command's template
+// is rendered for platformName; stack holds the commands being rendered,
+// outermost first, so that a command that ends up composing itself is
+// reported instead of recursing forever; the composed templates' secrets
+// are recorded in revealed, with those of the command being rendered
+func (e *Engine) composeFunc(platformName, shell string, stack []string, revealed *revealedSecrets) func(name string, args ...string) (string, error) {
+	return func(name string, args ...string) (string, error) {
+		return e.compose(platformName, shell, stack, revealed, name, args)
+	}
+}
+
+// compose renders the command line of a configured command for a {{goldfish}} call
+// args are "param=value" pairs; anything without "=" fills the next
+// positional parameter, as on the command line. The composed command must
+// run in the same shell as the template that uses it.
+func (e *Engine) compose(platformName, shell string, stack []string, revealed *revealedSecrets, name string, args []string) (string, error) {
+	if e.commands == nil {
+		return "", fmt.Errorf("goldfish %q: %w", name, config.ErrCommandNotFound)
+	}
+	cmd, found := e.commands.FindCommand(name)
+	if !found {
+		return "", fmt.Errorf("goldfish %q: %w", name, config.ErrCommandNotFound)
+	}
+	for i, caller := range stack {
+		if caller == cmd.Name {
+			cycle := append(append([]string(nil), stack[i:]...), cmd.Name)
+			return "", fmt.Errorf("goldfish %q: commands compose each other in a cycle: %s", name, strings.Join(cycle, " -> "))
+		}
+	}
+	if cmd.Plugin != "" {
+		return "", fmt.Errorf("goldfish %q: command is run by a plugin and has no command line to compose", name)
+	}
+
+	var positional []string
+	flags := make(map[string]interface{})
+	for _, arg := range args {
+		paramName, value, named := strings.Cut(arg, "=")
+		if !named {
+			positional = append(positional, arg)
+			continue
+		}
+		if _, known := cmd.FindParameter(paramName); !known {
+			return "", fmt.Errorf("goldfish %q: unknown parameter '%s'", name, paramName)
+		}
+		flags[paramName] = value
+	}
+	params, err := e.ParseParameters(cmd, positional, flags)
+	if err != nil {
+		return "", fmt.Errorf("goldfish %q: %w", name, err)
+	}
+
+	ctx := &ExecutionContext{Command: cmd, Platform: platform.SupportedPlatform(platformName), Parameters: params}
+	if err := e.validateContext(ctx); err != nil {
+		return "", fmt.Errorf("goldfish %q: %w", name, err)
+	}
+	selection, err := e.selectTemplate(ctx)
+	if err != nil {
+		return "", fmt.Errorf("goldfish %q: %w", name, err)
+	}
+	if composedShell := platformShell(selection.Command.Shell, platformName); composedShell != shell {
+		return "", fmt.Errorf("goldfish %q: its %s template runs in %s, not %s", name, selection.Key, composedShell, shell)
+	}
+
+	rendered, err := e.renderComposed(selection.command(cmd), platformName, &selection.Command, params, stack, revealed)
+	if err != nil {
+		return "", fmt.Errorf("goldfish %q: %w", name, err)
+	}
+	return rendered, nil
+}
+
+// withComposition returns tmpl with {{goldfish}} bound to the command being rendered
+// Parsed templates are cached and shared, so the function is bound on a
+// copy. Templates that do not mention goldfish are returned unchanged.
+func (e *Engine) withComposition(tmpl *template.Template, text, platformName, shell string, stack []string, revealed *revealedSecrets) (*template.Template, error) {
+	if !strings.Contains(text, "goldfish") {
+		return tmpl, nil
+	}
+	bound, err := tmpl.Clone()
+	if err != nil {
+		return nil, err
+	}
+	return bound.Funcs(template.FuncMap{"goldfish": e.composeFunc(platformName, shell, stack, revealed)}), nil
+}
+
+// unboundCompose stands in for {{goldfish}} where no command is being
+// rendered, such as in vars, defaults and when: conditions
+func unboundCompose(name string, args ...string) (string, error) {
+	return "", fmt.Errorf("goldfish %q: commands can only be composed in command templates", name)
+}
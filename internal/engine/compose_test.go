@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// TestEngine_Compose tests building a command from other commands with {{goldfish}}
+func TestEngine_Compose(t *testing.T) {
+	linux := func(template string) map[string]config.PlatformCommand {
+		return map[string]config.PlatformCommand{"linux": {Template: template}}
+	}
+	commands := []config.Command{
+		{
+			Name:        "find-files",
+			Alias:       "ff",
+			BaseCommand: "find",
+			Parameters: []config.Parameter{
+				{Name: "path", Type: "string", Default: "."},
+				{Name: "pattern", Type: "string", Required: true},
+			},
+			Platforms: linux("find {{.params.path}} -name '{{.params.pattern}}'"),
+		},
+		{
+			Name:        "count-go",
+			BaseCommand: "wc",
+			Parameters:  []config.Parameter{{Name: "dir", Type: "string"}},
+			Platforms:   linux(`{{goldfish "ff" "pattern=*.go" (print "path=" .params.dir)}} | wc -l`),
+		},
+		{Name: "ping", BaseCommand: "sh", Platforms: linux(`{{goldfish "pong"}}`)},
+		{Name: "pong", BaseCommand: "sh", Platforms: linux(`{{goldfish "ping"}}`)},
+		{Name: "bad-param", BaseCommand: "sh", Platforms: linux(`{{goldfish "find-files" "pattern=x" "depth=2"}}`)},
+		{
+			Name:        "ps-list",
+			BaseCommand: "Get-ChildItem",
+			Platforms:   map[string]config.PlatformCommand{"linux": {Template: "Get-ChildItem", Shell: "powershell"}},
+		},
+		{Name: "mixed", BaseCommand: "sh", Platforms: linux(`{{goldfish "ps-list"}}`)},
+	}
+	engine := NewEngine(0)
+	engine.SetCommands(commands)
+	preview := func(name string, params map[string]interface{}) (string, error) {
+		for i := range commands {
+			if commands[i].Name == name {
+				return engine.Preview(&ExecutionContext{Command: &commands[i], Platform: platform.Linux, Parameters: params})
+			}
+		}
+		t.Fatalf("no command %s", name)
+		return "", nil
+	}
+
+	rendered, err := preview("count-go", map[string]interface{}{"dir": "src"})
+	if err != nil {
+		t.Fatalf("Preview failed: %v", err)
+	}
+	if expected := "find src -name '*.go' | wc -l"; rendered != expected {
+		t.Errorf("Expected %q, got %q", expected, rendered)
+	}
+
+	errorCases := map[string]string{
+		"ping":      "ping -> pong -> ping",
+		"bad-param": "unknown parameter 'depth'",
+		"mixed":     "runs in powershell, not sh",
+	}
+	for name, expected := range errorCases {
+		if _, err := preview(name, map[string]interface{}{}); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("%s: expected an error containing %q, got %v", name, expected, err)
+		}
+	}
+
+	// Outside command templates there is nothing to compose for
+	if _, err := engine.renderString("when", `{{goldfish "find-files"}}`, nil, nil); err == nil {
+		t.Error("Expected {{goldfish}} to fail outside command templates")
+	}
+}
@@ -0,0 +1,94 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/trace"
+)
+
+// Detached is a command started in the background by Detach
+type Detached struct {
+	// PID is the process ID of the shell running the command; on Unix it
+	// also names the command's process group
+	PID int
+	// Command is the command line that was started, with secrets masked
+	Command string
+}
+
+// Detach starts ctx's command in the background and returns once it is running
+// The command runs in a session of its own with no input, writing its output
+// to log, and goes on after goldfish exits: goldfish neither waits for it nor
+// applies the timeout. Retries, expectations and output filters need goldfish
+// to watch the command, so they do not apply either. Cancelling parent only
+// stops its check: preconditions; once started, the command is on its own.
+func (e *Engine) Detach(parent context.Context, ctx *ExecutionContext, log *os.File) (*Detached, error) {
+	if err := e.validateContext(ctx); err != nil {
+		return nil, fmt.Errorf("invalid execution context: %w", err)
+	}
+	if ctx.Command.Experimental && !config.ExperimentalEnabled() {
+		return nil, fmt.Errorf("command '%s' is experimental; set %s=1 to enable it", ctx.Command.Name, config.ExperimentalEnvVar)
+	}
+	if err := e.checkDepth(ctx.Command.Name); err != nil {
+		return nil, err
+	}
+	// Only a process on this machine can be left running in the background
+	if ctx.Command.Plugin != "" {
+		return nil, fmt.Errorf("command '%s' is run by a plugin, which cannot be detached", ctx.Command.Name)
+	}
+	if e.backend != nil {
+		return nil, fmt.Errorf("command '%s' cannot be detached when it runs on a remote host", ctx.Command.Name)
+	}
+
+	selection, err := e.selectTemplate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cmd := selection.command(ctx.Command)
+	if err := e.runChecks(parent, ctx, cmd, &selection.Command); err != nil {
+		return nil, err
+	}
+	stop := e.trace.Start(trace.PhaseRender)
+	rendered, err := e.renderTemplate(cmd, ctx.Platform.String(), &selection.Command, ctx.Parameters, ctx.revealedSet())
+	stop()
+	if err != nil {
+		return nil, fmt.Errorf("failed to render command template: %w", err)
+	}
+	e.debugf("rendered: %s", e.maskSecrets(ctx, rendered))
+	if err := e.checkRestricted(cmd, platformShell(selection.Command.Shell, ctx.Platform.String()), rendered); err != nil {
+		return nil, err
+	}
+
+	options, err := e.commandProcessOptions(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+	argv, err := e.shellArgv(selection.Command.Shell, rendered)
+	if err != nil {
+		return nil, err
+	}
+	argv = limitCommand(argv, options.limits)
+	child := exec.Command(argv[0], argv[1:]...)
+	child.Stdout = log
+	child.Stderr = log
+	child.Env = e.commandEnvironment(ctx.Command)
+	release, err := detachProcess(child, options)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if err := child.Start(); err != nil {
+		return nil, fmt.Errorf("command execution failed: %w", err)
+	}
+	detached := &Detached{PID: child.Process.Pid, Command: e.maskSecrets(ctx, rendered)}
+	// goldfish does not wait for the command; whoever inherits it reaps it
+	if err := child.Process.Release(); err != nil {
+		return nil, fmt.Errorf("failed to detach command: %w", err)
+	}
+	e.debugf("detached: process %d", detached.PID)
+	return detached, nil
+}
@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// TestEngine_Detach tests starting a command in the background with its output in a log
+func TestEngine_Detach(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+
+	cmd := &config.Command{
+		Name:        "greet",
+		BaseCommand: "echo",
+		Parameters:  []config.Parameter{{Name: "token", Type: "string", Secret: true}},
+		Platforms: map[string]config.PlatformCommand{
+			"unix": {Template: "echo started {{.params.token}}; echo oops >&2"},
+		},
+	}
+	logPath := filepath.Join(t.TempDir(), "1.log")
+	log, err := os.Create(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+
+	ctx := &ExecutionContext{
+		Command:    cmd,
+		Platform:   platform.SupportedPlatform(runtime.GOOS),
+		Parameters: map[string]interface{}{"token": "s3cret"},
+	}
+	detached, err := NewEngine(time.Second).Detach(context.Background(), ctx, log)
+	if err != nil {
+		t.Fatalf("Detach() failed: %v", err)
+	}
+	if detached.PID <= 0 || detached.Command != "echo started ********; echo oops >&2" {
+		t.Errorf("Unexpected detached command: %+v", detached)
+	}
+
+	// The command finishes on its own; both streams end up in the log
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		data, _ := os.ReadFile(logPath)
+		if strings.Contains(string(data), "started s3cret") && strings.Contains(string(data), "oops") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the output in the log, got %q", data)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Plugins are not run by a shell goldfish can leave in the background
+	plugin := &config.Command{Name: "ext", BaseCommand: "ext", Plugin: "/opt/goldfish-ext"}
+	ctx = &ExecutionContext{Command: plugin, Platform: platform.SupportedPlatform(runtime.GOOS), Parameters: map[string]interface{}{}}
+	if _, err := NewEngine(time.Second).Detach(context.Background(), ctx, log); err == nil || !strings.Contains(err.Error(), "cannot be detached") {
+		t.Errorf("Expected a plugin command to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+package engine
+
+import "fmt"
+
+// diskAvailable is not implemented on this system
+func diskAvailable(path string) (uint64, error) {
+	return 0, fmt.Errorf("min_disk_space is not supported on this system")
+}
@@ -0,0 +1,36 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// Enabled reports whether a command's enabled_if condition holds on platformName
+// Commands without one are always enabled. The condition is checked before
+// any parameters are known, so it sees the environment as .env, the platform
+// as .platform, the globals as .globals, the selected profile as .profile and
+// the base command as .base_command, and can use helpers such as
+// {{has "program"}} and {{exists "path"}}. It holds as a when: condition does.
+func (e *Engine) Enabled(cmd *config.Command, platformName string) (bool, error) {
+	if cmd.EnabledIf == "" {
+		return true, nil
+	}
+	globals, err := e.globalData(platformName, nil)
+	if err != nil {
+		return false, err
+	}
+	data := map[string]interface{}{
+		"base_command": cmd.BaseCommand,
+		"env":          e.environmentData(),
+		"platform":     platformData(platform.SupportedPlatform(platformName)),
+		"globals":      globals,
+		"profile":      e.profileName(),
+	}
+	rendered, err := e.renderString("enabled_if", cmd.EnabledIf, data, nil)
+	if err != nil {
+		return false, fmt.Errorf("command '%s': enabled_if %q: %w", cmd.Name, cmd.EnabledIf, err)
+	}
+	return conditionHolds(rendered), nil
+}
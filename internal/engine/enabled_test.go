@@ -0,0 +1,48 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// TestEngine_Enabled tests evaluating enabled_if conditions
+func TestEngine_Enabled(t *testing.T) {
+	dir := t.TempDir()
+	client := filepath.Join(dir, "vpnclient")
+	if err := os.WriteFile(client, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOLDFISH_TEST_CLIENT", client)
+	engine := NewEngine(0)
+	engine.SetGlobals(map[string]string{"vpn_dir": dir})
+
+	testCases := []struct {
+		enabledIf string
+		expected  bool
+	}{
+		{"", true},
+		{`{{exists .env.GOLDFISH_TEST_CLIENT}}`, true},
+		{`{{exists (printf "%s/missing" .globals.vpn_dir)}}`, false},
+		{`{{has "goldfish-test-no-such-program"}}`, false},
+		{`{{eq .platform.os "linux"}}`, true},
+		{`{{eq .platform.os "windows"}}`, false},
+		{`{{if .env.GOLDFISH_TEST_UNSET}}yes{{end}}`, false},
+	}
+	for _, tc := range testCases {
+		cmd := &config.Command{Name: "vpn", BaseCommand: "vpn", EnabledIf: tc.enabledIf}
+		enabled, err := engine.Enabled(cmd, "linux")
+		if err != nil || enabled != tc.expected {
+			t.Errorf("%q: expected %t, got %t (%v)", tc.enabledIf, tc.expected, enabled, err)
+		}
+	}
+
+	// Errors name the command
+	cmd := &config.Command{Name: "vpn", EnabledIf: "{{.env.HOME | bad}}"}
+	if _, err := engine.Enabled(cmd, "linux"); err == nil || !strings.Contains(err.Error(), "command 'vpn': enabled_if") {
+		t.Errorf("Expected the failing condition to be named, got %v", err)
+	}
+}
@@ -6,16 +6,24 @@ package engine
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"os/signal"
 	"strconv"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
 
 	"github.com/danballance/goldfish/internal/config"
 	"github.com/danballance/goldfish/internal/platform"
+	"github.com/danballance/goldfish/internal/secrets"
+	"github.com/danballance/goldfish/internal/trace"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
 )
 
 // ExecutionContext holds the context for command execution
@@ -29,12 +37,121 @@ type ExecutionContext struct {
 	Parameters map[string]interface{}
 	// Timeout specifies the maximum execution time
 	Timeout time.Duration
+	// Stdin, Stdout and Stderr connect the command's standard streams; when nil
+	// goldfish's own are used. Servers set them to stream output to clients.
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	// Capture records the command's output in the ExecutionResult. Output is
+	// then only written to Stdout and Stderr when they are set.
+	Capture bool
+	// Quiet discards the command's stdout and NoStderr its stderr, including
+	// goldfish's own warnings; captured output is still recorded
+	Quiet    bool
+	NoStderr bool
+	// OutputLog is a file the command's output is copied to with timestamps
+	// (--tee); it takes the place of the command's log_output
+	OutputLog string
+	// Progress shows a spinner while the command is silent, as the command's
+	// progress: setting does (--progress)
+	Progress bool
+	// Sandbox runs the command under its sandbox policy (--sandbox)
+	Sandbox bool
+	// NoCache runs a command with a cache: policy even when its result is
+	// cached, and caches the new result (--no-cache)
+	NoCache bool
+
+	// revealed holds the secret values the execution's templates resolved,
+	// so they can be masked in logs (see funcs.go)
+	revealed     *revealedSecrets
+	revealedOnce sync.Once
+}
+
+// revealedSet returns the set of secret values the context's templates resolved
+func (ctx *ExecutionContext) revealedSet() *revealedSecrets {
+	ctx.revealedOnce.Do(func() { ctx.revealed = &revealedSecrets{} })
+	return ctx.revealed
+}
+
+// stdio holds the standard streams a command is connected to
+type stdio struct {
+	in  io.Reader
+	out io.Writer
+	err io.Writer
+}
+
+// streams returns the context's standard streams, defaulting to goldfish's own
+// Streams silenced by Quiet or NoStderr are discarded.
+func (ctx *ExecutionContext) streams() stdio {
+	streams := stdio{in: ctx.Stdin, out: ctx.Stdout, err: ctx.Stderr}
+	if streams.in == nil {
+		streams.in = os.Stdin
+	}
+	if streams.out == nil {
+		streams.out = os.Stdout
+	}
+	if streams.err == nil {
+		streams.err = os.Stderr
+	}
+	if ctx.Quiet {
+		streams.out = io.Discard
+	}
+	if ctx.NoStderr {
+		streams.err = io.Discard
+	}
+	return streams
 }
 
 // Engine handles command execution and template rendering
 type Engine struct {
 	platformDetector *platform.Detector
 	timeout          time.Duration
+	// maxDepth limits how deeply goldfish invocations may nest (see recursion.go)
+	maxDepth int
+	// gracePeriod is how long a signalled child may take to exit (see process.go)
+	gracePeriod time.Duration
+	// verboseOutput receives debug logging when set (see verbose.go)
+	verboseOutput io.Writer
+	// trace records phase timings when set (see trace.go)
+	trace *trace.Recorder
+	// executionLog receives a JSON record per execution when set (see execlog.go)
+	executionLog *executionLog
+	// secrets resolves {{secret "name"}} in templates (see funcs.go)
+	secrets secrets.Store
+	// wasmModules provide extra template helpers and validators; they are
+	// loaded into wasm on first use (see wasm.go)
+	wasmModules []config.WasmModule
+	wasmOnce    sync.Once
+	wasm        *wasmExtensions
+	wasmErr     error
+	// templates caches parsed command templates (see template_cache.go)
+	templates sync.Map
+	// backend runs commands instead of a local shell when set (see backend.go)
+	backend Backend
+	// commands are those {{goldfish}} can compose (see compose.go)
+	commands *config.Config
+	// progressOutput shows a spinner while a command is silent for longer
+	// than progressDelay when set (see progress.go)
+	progressOutput io.Writer
+	progressDelay  time.Duration
+	// maxOutput and maxLineLength limit the output of commands that do not
+	// set their own (see limits.go)
+	maxOutput     int64
+	maxLineLength int
+	// allowed holds the programs commands may run in restricted mode; nil
+	// when it is off (see restricted.go)
+	allowed map[string]bool
+	// shProgram runs templates written for sh when set (see shell.go)
+	shProgram string
+	// globals are the configuration's root vars:, rendered for every
+	// command as .globals (see globals.go)
+	globals map[string]string
+	// profile is the selected profile, whose vars and env apply to every
+	// command; nil when none is (see profiles.go)
+	profile *config.Profile
+	// input is standard input piped to goldfish, given to templates as
+	// .stdin and .stdin_file; nil when there is none (see stdin.go)
+	input *Input
 }
 
 // NewEngine creates a new command execution engine
@@ -43,31 +160,396 @@ func NewEngine(timeout time.Duration) *Engine {
 	return &Engine{
 		platformDetector: platform.NewDetector(),
 		timeout:          timeout,
+		maxDepth:         DefaultMaxDepth,
+		gracePeriod:      DefaultGracePeriod,
+		maxOutput:        DefaultMaxOutput,
+		secrets:          secrets.NewKeyringStore(),
+	}
+}
+
+// Execute runs a command with the given parameters and reports what happened
+// It validates parameters, renders the template, and executes the resulting
+// command. The result is returned even when err is set, with as much as was
+// known: a command that could not run has exit code -1. A non-zero exit code
+// is not an error; the caller decides what it means.
+// Cancelling parent stops the command, as its timeout does, and its deadline
+// shortens the timeout; the execution is traced as part of parent's trace,
+// or else the trace goldfish was started in.
+func (e *Engine) Execute(parent context.Context, ctx *ExecutionContext) (*ExecutionResult, error) {
+	metricExecutions.Add(1)
+
+	// Trace the execution, in the trace goldfish was started in, if any (see telemetry.go)
+	runCtx, span := startExecutionSpan(parent, ctx)
+	start := time.Now()
+	result, err := e.execute(runCtx, ctx)
+	failed := err != nil || result.ExitCode != 0
+	if failed {
+		metricFailures.Add(1)
+	}
+	if ctx.Command != nil {
+		recordExecution(ctx.Command.Name, time.Since(start), failed, errors.Is(err, ErrTimeout))
+	}
+	e.endExecutionSpan(ctx, span, result, err)
+	return result, err
+}
+
+// Run executes a command like Execute but reports only its exit code
+// It is used when goldfish runs several commands in one process (e.g. batches).
+// The error is only set when the command could not run or its postconditions failed.
+func (e *Engine) Run(parent context.Context, ctx *ExecutionContext) (int, error) {
+	result, err := e.Execute(parent, ctx)
+	return result.ExitCode, err
+}
+
+// ExecuteStatus is the CLI's thin wrapper around Execute
+// A non-zero exit code from the command terminates goldfish with the same code.
+func (e *Engine) ExecuteStatus(parent context.Context, ctx *ExecutionContext) error {
+	result, err := e.Execute(parent, ctx)
+	if err != nil {
+		return err
 	}
+	return ExitStatus(result.ExitCode)
 }
 
-// Execute runs a command with the given parameters
-// It validates parameters, renders the template, and executes the resulting command
-func (e *Engine) Execute(ctx *ExecutionContext) error {
+// Preview renders the command line that Execute would run, without running it
+// Secret values are masked, so the result is safe to display (e.g. in goldfish ui)
+func (e *Engine) Preview(ctx *ExecutionContext) (string, error) {
+	if err := e.validateContext(ctx); err != nil {
+		return "", fmt.Errorf("invalid execution context: %w", err)
+	}
+
+	selection, err := e.selectTemplate(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	// A plugin that handles execution has no command line to show but its own
+	if ctx.Command.Plugin != "" {
+		return pluginCommandLine(ctx.Command), nil
+	}
+
+	stop := e.trace.Start(trace.PhaseRender)
+	rendered, err := e.renderTemplate(selection.command(ctx.Command), ctx.Platform.String(), &selection.Command, ctx.Parameters, ctx.revealedSet())
+	stop()
+	if err != nil {
+		return "", fmt.Errorf("failed to render command template: %w", err)
+	}
+	return e.maskSecrets(ctx, rendered), nil
+}
+
+// execute performs the work of Execute, which wraps it so every outcome is counted in the metrics
+// The spans of its phases are children of runCtx's, and cancelling it stops
+// whatever is running.
+func (e *Engine) execute(runCtx context.Context, ctx *ExecutionContext) (result *ExecutionResult, err error) {
+	result = &ExecutionResult{ExitCode: -1}
+	started := time.Now()
+	defer func() { result.Duration = time.Since(started) }()
+
 	// Validate the execution context
 	if err := e.validateContext(ctx); err != nil {
-		return fmt.Errorf("invalid execution context: %w", err)
+		return result, fmt.Errorf("invalid execution context: %w", err)
+	}
+
+	// Experimental commands only run when explicitly enabled
+	if ctx.Command.Experimental && !config.ExperimentalEnabled() {
+		return result, fmt.Errorf("command '%s' is experimental; set %s=1 to enable it", ctx.Command.Name, config.ExperimentalEnvVar)
+	}
+
+	// Refuse to run if goldfish is being invoked recursively by its own templates
+	if err := e.checkDepth(ctx.Command.Name); err != nil {
+		return result, err
+	}
+
+	// Plugins run on this machine, so they cannot be sent to a backend
+	if ctx.Command.Plugin != "" && e.backend != nil {
+		return result, fmt.Errorf("command '%s' is run by a plugin, which cannot run on a remote host", ctx.Command.Name)
 	}
 
 	// Get the platform-specific template
-	platformCmd, exists := ctx.Command.Platforms[ctx.Platform.String()]
-	if !exists {
-		return fmt.Errorf("command '%s' not supported on platform '%s'", ctx.Command.Name, ctx.Platform)
+	selection, err := e.selectTemplate(ctx)
+	if err != nil {
+		return result, err
+	}
+	// Templates see the binary that was selected as .base_command
+	cmd := selection.command(ctx.Command)
+	e.debugf("command: %s", ctx.Command.Name)
+	e.debugf("platform: %s (template for %s)", ctx.Platform, selection.Key)
+	e.debugf("template: %s", selection.Command.Template)
+	if selection.Command.Shell != "" {
+		e.debugf("shell: %s", selection.Command.Shell)
+	}
+	if selection.Fallback != "" {
+		fmt.Fprintf(ctx.streams().err, "Warning: '%s' has no %s template; using the %s one (fallback_platforms)\n",
+			ctx.Command.Name, ctx.Platform, selection.Key)
+	}
+
+	// Skip the command, print its cached result or stop it on a failed check
+	// before running anything (see preflight)
+	preflightCtx, preflightSpan := startSpan(runCtx, "preflight")
+	cacheKey, done, err := e.preflight(preflightCtx, ctx, cmd, &selection.Command, result)
+	preflightSpan.SetAttributes(attribute.Bool("goldfish.skipped", result.Skipped), attribute.Bool("goldfish.cached", result.Cached))
+	e.endSpan(ctx, preflightSpan, err)
+	if err != nil || done {
+		return result, err
+	}
+
+	// Once the command has run, clean up after it with its on_failure: and
+	// always: steps (see cleanup.go); this runs after the output log is closed,
+	// and even when the command was cancelled
+	ran := false
+	defer func() {
+		if ran {
+			e.runCleanup(context.WithoutCancel(runCtx), ctx, cmd, &selection.Command, result, err)
+		}
+	}()
+
+	// Capture stdout alongside the terminal when an expectation needs to inspect it,
+	// and both streams for the result when the caller asked for them. What is
+	// kept in memory is capped (see limits.go).
+	maxOutput, maxLineLength := e.outputLimits(ctx.Command)
+	captured := &cappedBuffer{stream: "stdout", limit: maxOutput}
+	streams := ctx.streams()
+	capturedOut := &cappedBuffer{stream: "stdout", limit: maxOutput}
+	capturedErr := &cappedBuffer{stream: "stderr", limit: maxOutput}
+	if ctx.Capture {
+		streams.out = captureStream(ctx.Stdout, ctx.Quiet, capturedOut)
+		streams.err = captureStream(ctx.Stderr, ctx.NoStderr, capturedErr)
+	}
+	if ctx.Command.Expect != nil && ctx.Command.Expect.StdoutMatches != "" {
+		streams.out = io.MultiWriter(streams.out, captured)
+	}
+	// Keep the output of a cached command for the next run
+	cachedOut := &cappedBuffer{stream: "stdout", limit: maxOutput}
+	cachedErr := &cappedBuffer{stream: "stderr", limit: maxOutput}
+	if cacheKey != "" {
+		streams.out = io.MultiWriter(streams.out, cachedOut)
+		streams.err = io.MultiWriter(streams.err, cachedErr)
+	}
+
+	// Copy the output into a file as well when --tee or log_output asks for it
+	outputLog, err := e.openOutputLog(ctx, cmd)
+	if err != nil {
+		return result, err
+	}
+	if outputLog != nil {
+		defer outputLog.Close()
+		streams.out = io.MultiWriter(streams.out, outputLog.stream("stdout"))
+		streams.err = io.MultiWriter(streams.err, outputLog.stream("stderr"))
+	}
+
+	// Show a spinner while a long command prints nothing (see progress.go)
+	streams, stopProgress := e.startProgress(ctx, streams)
+	defer stopProgress()
+
+	// Rewrite stdout line by line when the template has an output_filter
+	filter, err := e.newOutputFilter(ctx, cmd, &selection.Command, streams.out)
+	if err != nil {
+		return result, err
+	}
+	if filter != nil {
+		streams.out = filter
+	}
+
+	// Cut long lines short before any of the writers above sees them
+	var outLines, errLines *lineLimiter
+	if maxLineLength > 0 {
+		outLines = &lineLimiter{stream: "stdout", target: streams.out, limit: maxLineLength}
+		errLines = &lineLimiter{stream: "stderr", target: streams.err, limit: maxLineLength}
+		streams.out, streams.err = outLines, errLines
+	}
+
+	// Restrict the process with the command's limits, and its sandbox policy with --sandbox
+	options, err := e.commandProcessOptions(ctx, cmd)
+	if err != nil {
+		return result, err
+	}
+	if options.sandbox != nil {
+		e.debugf("sandbox: read_only=%t network=%t allow_paths=%v", options.sandbox.ReadOnly, options.sandbox.Network, options.sandbox.AllowPaths)
+	}
+
+	// Log where and with which environment overrides the command will run
+	if e.verboseOutput != nil {
+		if wd, err := os.Getwd(); err == nil {
+			e.debugf("working directory: %s", wd)
+		}
+		e.debugf("environment: %s", strings.Join(e.envOverrides(ctx.Command), " "))
+	}
+
+	// Run the command, retrying failures that the command's retry policy covers
+	var exitCode int
+	ran = true
+	for attempt := 1; ; attempt++ {
+		// Only the output of the final attempt is checked against expectations
+		// and reported in the result
+		captured.Reset()
+		capturedOut.Reset()
+		capturedErr.Reset()
+		cachedOut.Reset()
+		cachedErr.Reset()
+		outLines.Reset()
+		errLines.Reset()
+		// Piped input was read by goldfish, so each attempt reads it from the start
+		if e.input != nil && ctx.Stdin == nil {
+			if streams.in, err = e.input.rewind(); err != nil {
+				return result, err
+			}
+		}
+
+		var renderedCmd string
+		var start time.Time
+		var err error
+		if ctx.Command.Plugin != "" {
+			// Plugins that handle execution are run directly; there is no template
+			renderedCmd = pluginCommandLine(ctx.Command)
+			e.debugf("plugin: %s", renderedCmd)
+			if err := e.checkRestricted(cmd, "", ""); err != nil {
+				return result, err
+			}
+			if outputLog != nil {
+				outputLog.header(ctx.Command.Name, renderedCmd)
+			}
+			start = time.Now()
+			execCtx, execSpan := startSpan(runCtx, "exec", attribute.Int("goldfish.attempt", attempt))
+			exitCode, err = e.executePlugin(execCtx, ctx, options, streams)
+			e.endCommandSpan(ctx, execSpan, exitCode, err)
+			e.trace.Add(trace.PhaseExecute, time.Since(start))
+		} else {
+			// Render the command template on every attempt, recording how long it takes
+			renderStart := time.Now()
+			_, renderSpan := startSpan(runCtx, "render")
+			renderedCmd, err = e.renderTemplate(cmd, ctx.Platform.String(), &selection.Command, ctx.Parameters, ctx.revealedSet())
+			e.endSpan(ctx, renderSpan, err)
+			metricRenderNanos.Add(int64(time.Since(renderStart)))
+			e.trace.Add(trace.PhaseRender, time.Since(renderStart))
+			if err != nil {
+				return result, fmt.Errorf("failed to render command template: %w", err)
+			}
+			e.debugf("rendered: %s", e.maskSecrets(ctx, renderedCmd))
+			if err := e.checkRestricted(cmd, platformShell(selection.Command.Shell, ctx.Platform.String()), renderedCmd); err != nil {
+				return result, err
+			}
+
+			if outputLog != nil {
+				outputLog.header(ctx.Command.Name, e.maskSecrets(ctx, renderedCmd))
+			}
+
+			// Execute the rendered command; a mock backend keeps a transcript,
+			// which must not contain secrets
+			start = time.Now()
+			if _, mock := e.backend.(*MockBackend); mock {
+				renderedCmd = e.maskSecrets(ctx, renderedCmd)
+			}
+			// The command's own spans, if it makes any, belong under this one
+			execCtx, execSpan := startSpan(runCtx, "exec", attribute.Int("goldfish.attempt", attempt))
+			env := append(e.commandEnvironment(ctx.Command), traceEnvironment(execCtx)...)
+			exitCode, err = e.executeCommand(execCtx, renderedCmd, selection.Command.Shell, ctx.Timeout, env, options, streams)
+			e.endCommandSpan(ctx, execSpan, exitCode, err)
+			e.trace.Add(trace.PhaseExecute, time.Since(start))
+		}
+		// The last line may not have ended with a newline
+		if filter != nil {
+			if filterErr := filter.Flush(); filterErr != nil && err == nil {
+				err = filterErr
+			}
+		}
+		// Output past a limit was dropped, which the caller must hear about
+		if limitErr := limitError(ctx.Command.Name, outLines, errLines, capturedOut, capturedErr, captured); limitErr != nil && err == nil {
+			err = limitErr
+		}
+		result.Command = e.maskSecrets(ctx, renderedCmd)
+		result.ExitCode = exitCode
+		result.Attempts = attempt
+		result.Stdout = capturedOut.String()
+		result.Stderr = capturedErr.String()
+		record := e.newExecutionRecord(ctx, renderedCmd, start, exitCode, err)
+		if ctx.Command.Retry != nil {
+			record.Attempt = attempt
+		}
+		e.logExecution(record)
+		if err != nil {
+			// Timeouts and interrupts are not retried
+			e.debugf("failed after %v: %v", time.Since(start), err)
+			return result, err
+		}
+		e.debugf("finished in %v with exit code %d", time.Since(start), exitCode)
+
+		if !ctx.Command.Retry.ShouldRetry(exitCode, attempt) {
+			break
+		}
+		delay := ctx.Command.Retry.Delay(attempt)
+		e.debugf("attempt %d of %d exited with code %d, retrying in %v", attempt, ctx.Command.Retry.Attempts, exitCode, delay)
+		oteltrace.SpanFromContext(runCtx).AddEvent("retry", oteltrace.WithAttributes(
+			attribute.Int("goldfish.attempt", attempt), attribute.Int("process.exit.code", exitCode), attribute.String("goldfish.delay", delay.String())))
+		// A cancelled command is not retried, even while it waits
+		if err := sleepContext(runCtx, delay); err != nil {
+			return result, err
+		}
+	}
+
+	// Verify declared postconditions, if any
+	if ctx.Command.Expect != nil {
+		// An unexpected failure keeps its exit code rather than becoming a postcondition error
+		if exitCode != 0 && !ctx.Command.Expect.AllowsExitCode(exitCode) {
+			return result, nil
+		}
+		data, err := e.commandData(cmd, ctx.Platform.String(), ctx.Parameters, ctx.revealedSet())
+		if err != nil {
+			return result, err
+		}
+		if err := e.verifyExpectations(ctx.Command, exitCode, captured.String(), data, ctx.revealedSet()); err != nil {
+			return result, err
+		}
+		// Exit codes allowed by the expectation count as success
+		result.ExitCode = 0
+	}
+
+	// Caching is best effort; the command itself has run
+	if cacheKey != "" && result.ExitCode == 0 {
+		if err := storeCachedResult(cacheKey, result.Command, cachedOut, cachedErr); err != nil {
+			e.debugf("cache: result not kept: %v", err)
+		}
 	}
 
-	// Render the command template
-	renderedCmd, err := e.renderTemplate(ctx.Command, &platformCmd, ctx.Parameters)
+	return result, nil
+}
+
+// preflight decides whether a command needs to run and may, before it does
+// Its skip_if: check may find it already done, or the result cache have its
+// output (see result_cache.go); then done is set and the result filled in.
+// A failing check: stops it with an error. cacheKey is where to keep a new
+// result, if anywhere. Cancelling runCtx stops the checks.
+func (e *Engine) preflight(runCtx context.Context, ctx *ExecutionContext, cmd *config.Command, platformCmd *config.PlatformCommand, result *ExecutionResult) (cacheKey string, done bool, err error) {
+	// A command whose skip_if: check succeeds has nothing left to do (see skip.go)
+	skip, err := e.skipCheck(runCtx, ctx, cmd, platformCmd)
 	if err != nil {
-		return fmt.Errorf("failed to render command template: %w", err)
+		return "", false, err
+	}
+	if skip {
+		fmt.Fprintf(ctx.streams().err, "'%s' is already done (skip_if succeeded); not running it\n", ctx.Command.Name)
+		result.ExitCode = 0
+		result.Skipped = true
+		return "", true, nil
+	}
+
+	// Print a fresh cached result instead of running the command again (see result_cache.go)
+	cacheKey, err = e.resultCacheKey(ctx, cmd, platformCmd)
+	if err != nil {
+		return "", false, err
+	}
+	if cacheKey != "" && !ctx.NoCache {
+		if cached := loadCachedResult(cacheKey, ctx.Command.Cache.TTL); cached != nil {
+			e.debugf("cache: using the result of %s", cached.Created.Format(time.RFC3339))
+			metricResultCacheHits.Add(1)
+			replayCachedResult(ctx, result, cached)
+			return cacheKey, true, nil
+		}
 	}
 
-	// Execute the rendered command
-	return e.executeCommand(renderedCmd, ctx.Timeout)
+	// Stop before running anything when a precondition fails (see checks.go)
+	if err := e.runChecks(runCtx, ctx, cmd, platformCmd); err != nil {
+		return "", false, err
+	}
+	return cacheKey, false, nil
 }
 
 // validateContext validates the execution context
@@ -83,7 +565,7 @@ func (e *Engine) validateContext(ctx *ExecutionContext) error {
 	for _, param := range ctx.Command.Parameters {
 		if param.Required {
 			if _, exists := ctx.Parameters[param.Name]; !exists {
-				return fmt.Errorf("required parameter '%s' is missing", param.Name)
+				return &ParameterError{Parameter: param.Name, Err: errParameterRequired}
 			}
 		}
 	}
@@ -100,12 +582,23 @@ func (e *Engine) validateContext(ctx *ExecutionContext) error {
 		}
 
 		if paramDef == nil {
-			return fmt.Errorf("unknown parameter: %s", paramName)
+			return &ParameterError{Parameter: paramName, Err: errParameterUnknown}
 		}
 
 		// Validate parameter type
 		if err := e.validateParameterType(paramDef, paramValue); err != nil {
-			return fmt.Errorf("parameter '%s': %w", paramName, err)
+			return &ParameterError{Parameter: paramName, Err: err}
+		}
+
+		// Run the parameter's custom validator, if it names one
+		if paramDef.Validate != "" {
+			extensions, err := e.wasmExtensions()
+			if err != nil {
+				return err
+			}
+			if err := extensions.validate(paramDef.Validate, paramValue); err != nil {
+				return &ParameterError{Parameter: paramName, Err: err}
+			}
 		}
 	}
 
@@ -154,76 +647,237 @@ func (e *Engine) validateParameterType(param *config.Parameter, value interface{
 }
 
 // renderTemplate renders the command template with the given parameters
-func (e *Engine) renderTemplate(cmd *config.Command, platformCmd *config.PlatformCommand, params map[string]interface{}) (string, error) {
-	// Create template data
-	templateData := map[string]interface{}{
+// The secrets it resolves are recorded in revealed, unless that is nil. A command's script runs first and may change the parameters, replace the
+// template or produce the command line itself (see script.go). Entries with
+// a flag_map instead of a template have their command line built from it.
+func (e *Engine) renderTemplate(cmd *config.Command, platformName string, platformCmd *config.PlatformCommand, params map[string]interface{}, revealed *revealedSecrets) (string, error) {
+	return e.renderComposed(cmd, platformName, platformCmd, params, nil, revealed)
+}
+
+// renderComposed renders a command's template like renderTemplate, where
+// stack holds the commands whose templates compose this one (see compose.go)
+func (e *Engine) renderComposed(cmd *config.Command, platformName string, platformCmd *config.PlatformCommand, params map[string]interface{}, stack []string, revealed *revealedSecrets) (string, error) {
+	text := platformCmd.Template
+	if cmd.Script != "" {
+		result, err := e.runScript(cmd, platformName, params)
+		if err != nil {
+			return "", err
+		}
+		if result.command != "" {
+			return result.command, nil
+		}
+		if result.template != "" {
+			text = result.template
+		}
+		params = result.params
+	}
+	if text == "" && len(platformCmd.FlagMap) > 0 {
+		// The command line is built from the flag_map (see flagmap.go)
+		return buildFlagMapCommand(cmd, platformName, platformCmd, params), nil
+	}
+	if text == "" {
+		return "", fmt.Errorf("no template for platform %s and the script set neither template nor command", platformName)
+	}
+	if err := e.checkInputSize(text); err != nil {
+		return "", err
+	}
+
+	// Platform templates are parsed once and reused (see template_cache.go)
+	shell := platformShell(platformCmd.Shell, platformName)
+	var tmpl *template.Template
+	var err error
+	if text == platformCmd.Template {
+		tmpl, err = e.cachedTemplate(cmd.Name, platformName, shell, text)
+	} else {
+		tmpl, err = e.parseCommandTemplate(text, shell)
+	}
+	if err != nil {
+		return "", err
+	}
+	// {{goldfish}} renders other commands for the same platform and shell
+	stack = append(append([]string(nil), stack...), cmd.Name)
+	tmpl, err = e.withComposition(tmpl, text, platformName, shell, stack, revealed)
+	if err != nil {
+		return "", err
+	}
+	if tmpl, err = e.withSecrets(tmpl, text, revealed); err != nil {
+		return "", err
+	}
+	data, err := e.commandData(cmd, platformName, params, revealed)
+	if err != nil {
+		return "", err
+	}
+	return executeTemplate(tmpl, data)
+}
+
+// templateData builds the data that templates are rendered against
+func templateData(cmd *config.Command, platformName string, params map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
 		"base_command": cmd.BaseCommand,
 		"params":       params,
+		"platform":     platformData(platform.SupportedPlatform(platformName)),
+	}
+}
+
+// renderString parses and executes a single template string
+// name is used in error messages to identify which template failed. The
+// secrets it resolves are recorded in revealed, unless that is nil.
+func (e *Engine) renderString(name, text string, data map[string]interface{}, revealed *revealedSecrets) (string, error) {
+	tmpl, err := e.parseTemplate(name, text)
+	if err != nil {
+		return "", err
+	}
+	if tmpl, err = e.withSecrets(tmpl, text, revealed); err != nil {
+		return "", err
+	}
+	return executeTemplate(tmpl, data)
+}
+
+// parseTemplate parses a template string, making goldfish's helper functions
+// and those of any WASM modules available
+func (e *Engine) parseTemplate(name, text string) (*template.Template, error) {
+	extensions, err := e.wasmExtensions()
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New(name).Funcs(e.templateFuncs()).Funcs(extensions.templateFuncs()).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
 	}
+	return tmpl, nil
+}
 
-	// Parse the template
-	tmpl, err := template.New("command").Parse(platformCmd.Template)
+// parseCommandTemplate parses a command template, escaping the parameter
+// values it prints for shell (see escape.go)
+func (e *Engine) parseCommandTemplate(text, shell string) (*template.Template, error) {
+	tmpl, err := e.parseTemplate("command", text)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse template: %w", err)
+		return nil, err
 	}
+	return escapeTemplate(tmpl, shell)
+}
 
-	// Execute the template
+// executeTemplate renders a parsed template, trimming surrounding whitespace
+func executeTemplate(tmpl *template.Template, data map[string]interface{}) (string, error) {
 	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, templateData); err != nil {
+	if err := tmpl.Execute(&buf, data); err != nil {
 		return "", fmt.Errorf("failed to execute template: %w", err)
 	}
-
 	return strings.TrimSpace(buf.String()), nil
 }
 
 // executeCommand executes the rendered command using the system shell
-func (e *Engine) executeCommand(command string, timeout time.Duration) error {
+// env is the complete environment for the child process and streams are its
+// standard input and output. It returns the child's exit code; an error is
+// only returned when the command could not be run to completion (e.g. timeout).
+// The timeout is a deadline within parent's; cancelling parent stops the command.
+func (e *Engine) executeCommand(parent context.Context, command, shell string, timeout time.Duration, env []string, opts processOptions, streams stdio) (int, error) {
+	if e.backend != nil {
+		return e.runOnBackend(parent, command, shell, timeout, streams)
+	}
+	// Run the command in a shell (sh -c on Unix, cmd /c on Windows, or the
+	// template's own), which allows pipes, redirects, etc.
+	argv, err := e.shellArgv(shell, command)
+	if err != nil {
+		return -1, err
+	}
+	return e.runProcess(parent, argv, command, timeout, env, opts, streams)
+}
+
+// runProcess runs a program to completion, as described for executeCommand
+// description names the command in errors (e.g. the rendered command line)
+func (e *Engine) runProcess(parent context.Context, argv []string, description string, timeout time.Duration, env []string, opts processOptions, streams stdio) (int, error) {
 	// Use the specified timeout or fall back to the engine default
 	if timeout == 0 {
 		timeout = e.timeout
 	}
 
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	// Create context with timeout, stopping the command when parent is cancelled
+	if err := parent.Err(); err != nil {
+		return -1, cancelledError(parent)
+	}
+	ctx, cancel := context.WithTimeout(parent, timeout)
 	defer cancel()
 
-	// Prepare the command
-	// On Unix systems, use sh -c to execute the command
-	// This allows for complex shell commands with pipes, redirects, etc.
-	var cmd *exec.Cmd
-	if isWindows() {
-		cmd = exec.CommandContext(ctx, "cmd", "/c", command)
-	} else {
-		cmd = exec.CommandContext(ctx, "sh", "-c", command)
-	}
+	// Prepare the command, under its resource limits when it has any
+	argv = limitCommand(argv, opts.limits)
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
 
 	// Connect stdio to allow interactive commands and proper output handling
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.Stdin = streams.in
+	cmd.Stdout = streams.out
+	cmd.Stderr = streams.err
+
+	// Pass the prepared environment (nesting depth, locale, ...) to the child
+	cmd.Env = env
+
+	// Run the child in its own process group so signals reach everything it
+	// starts, inside the sandbox when there is one
+	tree, err := newProcessTree(cmd, opts)
+	if err != nil {
+		return -1, err
+	}
+
+	// When the timeout fires, kill the whole tree rather than just the shell,
+	// otherwise grandchildren spawned by the template keep running
+	cmd.Cancel = tree.kill
+	cmd.WaitDelay = waitDelay
+
+	// Catch termination signals so they can be forwarded instead of orphaning the child
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, terminationSignals...)
+	defer signal.Stop(signals)
+
+	// Start the command and wait for it, relaying any signals we receive
+	if err := cmd.Start(); err != nil {
+		tree.release()
+		return -1, fmt.Errorf("command execution failed: %w", err)
+	}
+	if err := tree.started(); err != nil {
+		// Job tracking is best effort; the command itself is already running
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	interrupted, err := waitForProcess(tree, signals, e.gracePeriod)
+	tree.release()
+
+	if interrupted != nil {
+		return -1, fmt.Errorf("command interrupted by signal: %v", interrupted)
+	}
 
-	// Execute the command
-	err := cmd.Run()
-	
 	// Handle different types of errors
 	if err != nil {
+		if parent.Err() != nil {
+			return -1, cancelledError(parent)
+		}
 		if ctx.Err() == context.DeadlineExceeded {
-			return fmt.Errorf("command timed out after %v: %s", timeout, command)
+			metricTimeouts.Add(1)
+			return -1, fmt.Errorf("%w after %v: %s", ErrTimeout, timeout, description)
 		}
-		
-		// For exit code errors, we want to preserve the exit code
+
+		// A non-zero exit code is a normal outcome; the caller decides what it means
 		if exitError, ok := err.(*exec.ExitError); ok {
-			// We defer the exit to allow cleanup functions to run
-			defer func() {
-				os.Exit(exitError.ExitCode())
-			}()
-			return fmt.Errorf("command failed with exit code %d", exitError.ExitCode())
+			return exitError.ExitCode(), nil
 		}
-		
-		return fmt.Errorf("command execution failed: %w", err)
+
+		return -1, fmt.Errorf("command execution failed: %w", err)
 	}
 
-	return nil
+	return 0, nil
+}
+
+// ExitStatus converts a child exit code into goldfish's own result
+// For exit code errors, we want to preserve the exit code so that goldfish
+// behaves like the wrapped command in shell scripts; the error is an *ExitError
+func ExitStatus(exitCode int) error {
+	if exitCode == 0 {
+		return nil
+	}
+
+	// We defer the exit to allow cleanup functions to run
+	defer func() {
+		os.Exit(exitCode)
+	}()
+	return &ExitError{Code: exitCode}
 }
 
 // isWindows checks if the current platform is Windows
@@ -249,6 +903,15 @@ func (e *Engine) ParseParameters(cmd *config.Command, args []string, flags map[s
 			cleanFlagName := strings.TrimLeft(flagName, "-")
 			// Check if this flag matches the parameter's explicit flag or the parameter name
 			if param.Flag == flagName || param.Flag == "--"+cleanFlagName || param.Name == cleanFlagName {
+				// Values given as text (e.g. from a batch manifest) are converted
+				// to the parameter's type just like positional arguments
+				if text, ok := flagValue.(string); ok {
+					converted, err := e.convertArgument(text, param.Type)
+					if err != nil {
+						return nil, &ParameterError{Parameter: param.Name, Err: err}
+					}
+					flagValue = converted
+				}
 				params[param.Name] = flagValue
 				break
 			}
@@ -269,16 +932,24 @@ func (e *Engine) ParseParameters(cmd *config.Command, args []string, flags map[s
 			// Convert the argument to the appropriate type
 			convertedValue, err := e.convertArgument(args[argIndex], param.Type)
 			if err != nil {
-				return nil, fmt.Errorf("parameter '%s': %w", param.Name, err)
+				return nil, &ParameterError{Parameter: param.Name, Err: err}
 			}
 			params[param.Name] = convertedValue
 			argIndex++
 		case param.Required:
-			return nil, fmt.Errorf("required parameter '%s' not provided", param.Name)
+			return nil, &ParameterError{Parameter: param.Name, Err: errParameterRequired}
 		case param.Default != nil:
-			params[param.Name] = param.Default
+			// Templated defaults are worked out once the other values are known
+			if _, templated := param.DefaultTemplate(); !templated {
+				params[param.Name] = param.Default
+			}
 		}
 	}
+
+	// Compute templated defaults (see param_defaults.go)
+	if err := e.applyDefaultTemplates(cmd, params); err != nil {
+		return nil, err
+	}
 	
 	return params, nil
 }
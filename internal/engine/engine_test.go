@@ -2,6 +2,12 @@
 package engine
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 	"time"
 
@@ -164,7 +170,7 @@ func TestEngine_renderTemplate(t *testing.T) {
 		"verbose": true,
 	}
 
-	result, err := engine.renderTemplate(cmd, platformCmd, params)
+	result, err := engine.renderTemplate(cmd, "linux", platformCmd, params, nil)
 	if err != nil {
 		t.Fatalf("renderTemplate() failed: %v", err)
 	}
@@ -176,7 +182,7 @@ func TestEngine_renderTemplate(t *testing.T) {
 
 	// Test with verbose = false
 	params["verbose"] = false
-	result, err = engine.renderTemplate(cmd, platformCmd, params)
+	result, err = engine.renderTemplate(cmd, "linux", platformCmd, params, nil)
 	if err != nil {
 		t.Fatalf("renderTemplate() failed: %v", err)
 	}
@@ -202,7 +208,7 @@ func TestEngine_renderTemplate_InvalidTemplate(t *testing.T) {
 
 	params := map[string]interface{}{}
 
-	_, err := engine.renderTemplate(cmd, platformCmd, params)
+	_, err := engine.renderTemplate(cmd, "linux", platformCmd, params, nil)
 	if err == nil {
 		t.Error("Expected error for invalid template syntax")
 	}
@@ -358,6 +364,146 @@ func TestExecutionContext(t *testing.T) {
 	}
 }
 
+// TestEngine_Run tests that Run reports exit codes instead of exiting
+func TestEngine_Run(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+
+	cmd := &config.Command{
+		Name:        "fail",
+		BaseCommand: "sh",
+		Parameters:  []config.Parameter{{Name: "code", Type: "int", Required: true}},
+		Platforms: map[string]config.PlatformCommand{
+			"linux":  {Template: "exit {{.params.code}}"},
+			"darwin": {Template: "exit {{.params.code}}"},
+		},
+	}
+	ctx := &ExecutionContext{
+		Command:    cmd,
+		Platform:   platform.SupportedPlatform(runtime.GOOS),
+		Parameters: map[string]interface{}{"code": 3},
+	}
+
+	exitCode, err := NewEngine(5 * time.Second).Run(context.Background(), ctx)
+	if err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+	if exitCode != 3 {
+		t.Errorf("Expected exit code 3, got %d", exitCode)
+	}
+}
+
+// TestEngine_ParseParameters_TextFlags tests that text flag values are converted
+func TestEngine_ParseParameters_TextFlags(t *testing.T) {
+	engine := NewEngine(time.Second)
+	cmd := &config.Command{
+		Parameters: []config.Parameter{
+			{Name: "count", Type: "int"},
+			{Name: "force", Type: "bool"},
+		},
+	}
+
+	params, err := engine.ParseParameters(cmd, nil, map[string]interface{}{"--count": "5", "--force": "true"})
+	if err != nil {
+		t.Fatalf("ParseParameters() failed: %v", err)
+	}
+	if params["count"] != 5 || params["force"] != true {
+		t.Errorf("Expected converted values, got %v", params)
+	}
+
+	if _, err := engine.ParseParameters(cmd, nil, map[string]interface{}{"--count": "five"}); err == nil {
+		t.Error("Expected error for unconvertible value")
+	}
+}
+
+// TestEngine_Run_Experimental tests that experimental commands need to be enabled
+func TestEngine_Run_Experimental(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+
+	ctx := &ExecutionContext{
+		Command: &config.Command{
+			Name:         "preview",
+			BaseCommand:  "true",
+			Experimental: true,
+			Platforms: map[string]config.PlatformCommand{
+				"linux":  {Template: "true"},
+				"darwin": {Template: "true"},
+			},
+		},
+		Platform:   platform.SupportedPlatform(runtime.GOOS),
+		Parameters: map[string]interface{}{},
+	}
+	engine := NewEngine(5 * time.Second)
+
+	t.Setenv(config.ExperimentalEnvVar, "")
+	if _, err := engine.Run(context.Background(), ctx); err == nil || !strings.Contains(err.Error(), config.ExperimentalEnvVar) {
+		t.Errorf("Expected experimental error, got %v", err)
+	}
+
+	t.Setenv(config.ExperimentalEnvVar, "1")
+	if _, err := engine.Run(context.Background(), ctx); err != nil {
+		t.Errorf("Expected enabled experimental command to run, got %v", err)
+	}
+}
+
+// TestEngine_Execute_Retry tests that failing attempts are re-run and logged
+func TestEngine_Execute_Retry(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+
+	// The template fails until it has run three times, counting runs in a file
+	counter := filepath.Join(t.TempDir(), "count")
+	template := `n=$(cat ` + counter + ` 2>/dev/null || echo 0); n=$((n+1)); echo $n > ` + counter + `; [ $n -ge 3 ] || exit 75`
+	cmd := &config.Command{
+		Name:        "flaky",
+		BaseCommand: "sh",
+		Platforms: map[string]config.PlatformCommand{
+			"linux":  {Template: template},
+			"darwin": {Template: template},
+		},
+		Retry: &config.RetryPolicy{Attempts: 3, Backoff: time.Millisecond, OnExitCodes: []int{75}},
+	}
+
+	engine := NewEngine(5 * time.Second)
+	var logBuf bytes.Buffer
+	engine.SetExecutionLog(&logBuf)
+
+	ctx := &ExecutionContext{
+		Command:    cmd,
+		Platform:   platform.SupportedPlatform(runtime.GOOS),
+		Parameters: map[string]interface{}{},
+	}
+	if err := engine.ExecuteStatus(context.Background(), ctx); err != nil {
+		t.Fatalf("Expected the third attempt to succeed, got %v", err)
+	}
+
+	// Each attempt is logged with its number and exit code
+	lines := strings.Split(strings.TrimSpace(logBuf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 logged attempts, got %d", len(lines))
+	}
+	for i, line := range lines {
+		var record ExecutionRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Failed to parse log line: %v", err)
+		}
+		if record.Attempt != i+1 {
+			t.Errorf("Expected attempt %d, got %d", i+1, record.Attempt)
+		}
+		wantExit := 75
+		if i == 2 {
+			wantExit = 0
+		}
+		if record.ExitCode != wantExit {
+			t.Errorf("Attempt %d: expected exit code %d, got %d", i+1, wantExit, record.ExitCode)
+		}
+	}
+}
+
 // BenchmarkEngine_validateContext benchmarks the validateContext method
 func BenchmarkEngine_validateContext(b *testing.B) {
 	engine := NewEngine(time.Second)
@@ -398,6 +544,6 @@ func BenchmarkEngine_renderTemplate(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_, _ = engine.renderTemplate(cmd, platformCmd, params)
+		_, _ = engine.renderTemplate(cmd, "linux", platformCmd, params, nil)
 	}
 }
\ No newline at end of file
@@ -0,0 +1,59 @@
+package engine
+
+import (
+	"os"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+const (
+	// DefaultLocale is the LC_ALL value used for commands with normalize_locale enabled
+	// C.UTF-8 gives byte-order sorting and "." decimal separators while still handling UTF-8
+	DefaultLocale = "C.UTF-8"
+	// DefaultTimezone is the TZ value used for commands with normalize_locale enabled
+	DefaultTimezone = "UTC"
+)
+
+// commandEnvironment builds the full environment for running a command
+// It starts from the inherited environment and appends goldfish's overrides.
+// Later entries win, so overrides go last.
+func (e *Engine) commandEnvironment(cmd *config.Command) []string {
+	return append(os.Environ(), e.envOverrides(cmd)...)
+}
+
+// envOverrides returns the variables goldfish sets for a command's child process
+// These are the only differences from the inherited environment
+func (e *Engine) envOverrides(cmd *config.Command) []string {
+	// The selected profile's env comes first, so the variables goldfish
+	// itself sets win (see profiles.go)
+	overrides := e.profileEnvironment()
+
+	// Always record the nesting depth so recursion can be detected (see recursion.go)
+	overrides = append(overrides, depthVariable())
+
+	if cmd.NormalizeLocale {
+		overrides = append(overrides, localeEnvironment(cmd)...)
+	}
+
+	return overrides
+}
+
+// localeEnvironment returns the locale and timezone variables for a command
+// Configured values on the command take precedence over the defaults
+func localeEnvironment(cmd *config.Command) []string {
+	locale := DefaultLocale
+	if cmd.Locale != "" {
+		locale = cmd.Locale
+	}
+
+	timezone := DefaultTimezone
+	if cmd.Timezone != "" {
+		timezone = cmd.Timezone
+	}
+
+	// LC_ALL overrides every other LC_* category as well as LANG
+	return []string{
+		"LC_ALL=" + locale,
+		"TZ=" + timezone,
+	}
+}
@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// envValue returns the effective value of a variable in an environment slice
+// When a variable appears more than once, the last occurrence wins
+func envValue(env []string, name string) (string, bool) {
+	value, found := "", false
+	for _, entry := range env {
+		if len(entry) > len(name) && entry[:len(name)+1] == name+"=" {
+			value, found = entry[len(name)+1:], true
+		}
+	}
+	return value, found
+}
+
+// TestCommandEnvironment_NoNormalization tests that the locale is left alone by default
+func TestCommandEnvironment_NoNormalization(t *testing.T) {
+	t.Setenv("LC_ALL", "de_DE.UTF-8")
+	t.Setenv("TZ", "Europe/Berlin")
+
+	env := NewEngine(0).commandEnvironment(&config.Command{Name: "test"})
+
+	if value, _ := envValue(env, "LC_ALL"); value != "de_DE.UTF-8" {
+		t.Errorf("Expected inherited LC_ALL, got %q", value)
+	}
+	if value, _ := envValue(env, "TZ"); value != "Europe/Berlin" {
+		t.Errorf("Expected inherited TZ, got %q", value)
+	}
+	if _, found := envValue(env, DepthEnvVar); !found {
+		t.Errorf("Expected %s to be set for the child", DepthEnvVar)
+	}
+}
+
+// TestCommandEnvironment_Defaults tests normalization with the default values
+func TestCommandEnvironment_Defaults(t *testing.T) {
+	t.Setenv("LC_ALL", "de_DE.UTF-8")
+	t.Setenv("TZ", "Europe/Berlin")
+
+	env := NewEngine(0).commandEnvironment(&config.Command{Name: "test", NormalizeLocale: true})
+
+	if value, _ := envValue(env, "LC_ALL"); value != DefaultLocale {
+		t.Errorf("Expected LC_ALL=%s, got %q", DefaultLocale, value)
+	}
+	if value, _ := envValue(env, "TZ"); value != DefaultTimezone {
+		t.Errorf("Expected TZ=%s, got %q", DefaultTimezone, value)
+	}
+}
+
+// TestCommandEnvironment_ConfiguredValues tests normalization with configured values
+func TestCommandEnvironment_ConfiguredValues(t *testing.T) {
+	env := NewEngine(0).commandEnvironment(&config.Command{
+		Name:            "test",
+		NormalizeLocale: true,
+		Locale:          "en_US.UTF-8",
+		Timezone:        "America/New_York",
+	})
+
+	if value, _ := envValue(env, "LC_ALL"); value != "en_US.UTF-8" {
+		t.Errorf("Expected configured LC_ALL, got %q", value)
+	}
+	if value, _ := envValue(env, "TZ"); value != "America/New_York" {
+		t.Errorf("Expected configured TZ, got %q", value)
+	}
+}
@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// ErrPlatformUnsupported is matched (with errors.Is) by the errors for a
+// command that has no template for the platform (see UnsupportedPlatformError)
+var ErrPlatformUnsupported = errors.New("command not supported on this platform")
+
+// ErrTimeout is matched (with errors.Is) by the errors of commands that were
+// killed for exceeding their timeout
+var ErrTimeout = errors.New("command timed out")
+
+// The reasons a ParameterError gives for parameters that are missing or not defined
+var (
+	errParameterRequired = errors.New("required but not provided")
+	errParameterUnknown  = errors.New("not defined by the command")
+)
+
+// ParameterError reports a parameter value a command cannot run with: a
+// required parameter that is missing, an unknown one, or a value of the wrong
+// type or that its validator rejects
+// It matches config.ErrValidation, like an invalid configuration.
+type ParameterError struct {
+	// Parameter is the name of the parameter
+	Parameter string
+	// Err is what is wrong with it
+	Err error
+}
+
+// Error returns the message the CLI prints, e.g. "parameter 'count': expected int, got string"
+func (e *ParameterError) Error() string {
+	return fmt.Sprintf("parameter '%s': %v", e.Parameter, e.Err)
+}
+
+// Unwrap returns what is wrong with the parameter
+func (e *ParameterError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is config.ErrValidation
+func (e *ParameterError) Is(target error) bool {
+	return target == config.ErrValidation
+}
+
+// ExitError reports that a command ran but exited with a non-zero code
+// The goldfish CLI exits with the same code; callers can find it with
+// errors.As and do likewise.
+type ExitError struct {
+	// Command is the name of the command that failed, if known
+	Command string
+	// Code is its exit code
+	Code int
+}
+
+// Error describes the failure
+func (e *ExitError) Error() string {
+	if e.Command == "" {
+		return fmt.Sprintf("command failed with exit code %d", e.Code)
+	}
+	return fmt.Sprintf("command '%s' failed with exit code %d", e.Command, e.Code)
+}
+
+// ExitCode returns the command's exit code
+func (e *ExitError) ExitCode() int {
+	return e.Code
+}
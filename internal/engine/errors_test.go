@@ -0,0 +1,103 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// TestParameterError tests that parameter errors name the parameter and match config.ErrValidation
+func TestParameterError(t *testing.T) {
+	engine := NewEngine(30 * time.Second)
+	cmd := &config.Command{
+		Name: "copy",
+		Parameters: []config.Parameter{
+			{Name: "source", Type: "string", Required: true},
+			{Name: "count", Type: "int"},
+		},
+		Platforms: map[string]config.PlatformCommand{"linux": {Template: "cp {{.params.source}}"}},
+	}
+
+	tests := []struct {
+		name      string
+		parse     func() error
+		parameter string
+		message   string
+	}{
+		{"missing", func() error {
+			_, err := engine.ParseParameters(cmd, nil, map[string]interface{}{})
+			return err
+		}, "source", "parameter 'source': required but not provided"},
+		{"conversion", func() error {
+			_, err := engine.ParseParameters(cmd, []string{"a", "many"}, map[string]interface{}{})
+			return err
+		}, "count", ""},
+		{"unknown", func() error {
+			return engine.validateContext(&ExecutionContext{Command: cmd, Parameters: map[string]interface{}{"source": "a", "dest": "b"}})
+		}, "dest", "parameter 'dest': not defined by the command"},
+		{"type", func() error {
+			return engine.validateContext(&ExecutionContext{Command: cmd, Parameters: map[string]interface{}{"source": 1}})
+		}, "source", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.parse()
+			var paramErr *ParameterError
+			if !errors.As(err, &paramErr) || paramErr.Parameter != tt.parameter {
+				t.Fatalf("Expected a ParameterError for '%s', got %v", tt.parameter, err)
+			}
+			if !errors.Is(err, config.ErrValidation) {
+				t.Errorf("Expected %v to match config.ErrValidation", err)
+			}
+			if tt.message != "" && err.Error() != tt.message {
+				t.Errorf("Expected %q, got %q", tt.message, err.Error())
+			}
+		})
+	}
+}
+
+// TestExitError tests the message with and without the command's name
+func TestExitError(t *testing.T) {
+	if err := (&ExitError{Code: 2}); err.Error() != "command failed with exit code 2" || err.ExitCode() != 2 {
+		t.Errorf("Unexpected error %q with code %d", err.Error(), err.ExitCode())
+	}
+	if err := (&ExitError{Command: "backup", Code: 3}); err.Error() != "command 'backup' failed with exit code 3" {
+		t.Errorf("Unexpected error %q", err.Error())
+	}
+}
+
+// TestErrPlatformUnsupported tests that running a command without a template
+// for the platform matches ErrPlatformUnsupported
+func TestErrPlatformUnsupported(t *testing.T) {
+	engine := NewEngine(30 * time.Second)
+	cmd := &config.Command{Name: "winonly", Platforms: map[string]config.PlatformCommand{"windows": {Template: "ver"}}}
+	_, err := engine.Execute(context.Background(), &ExecutionContext{Command: cmd, Platform: platform.Linux, Parameters: map[string]interface{}{}})
+	if !errors.Is(err, ErrPlatformUnsupported) {
+		t.Errorf("Expected %v to match ErrPlatformUnsupported", err)
+	}
+	var unsupported *UnsupportedPlatformError
+	if !errors.As(err, &unsupported) || unsupported.Command != "winonly" {
+		t.Errorf("Expected an UnsupportedPlatformError, got %v", err)
+	}
+}
+
+// TestErrTimeout tests that a command killed at its timeout matches ErrTimeout
+func TestErrTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	engine := NewEngine(30 * time.Second)
+	cmd := &config.Command{Name: "slow", BaseCommand: "sleep", Platforms: map[string]config.PlatformCommand{"linux": {Template: "sleep 5"}}}
+	_, err := engine.Execute(context.Background(), &ExecutionContext{Command: cmd, Platform: platform.Linux, Parameters: map[string]interface{}{}, Timeout: 100 * time.Millisecond})
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("Expected %v to match ErrTimeout", err)
+	}
+	if errors.Is(err, config.ErrValidation) {
+		t.Errorf("Expected a timeout not to match config.ErrValidation")
+	}
+}
@@ -0,0 +1,341 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// Parameter values come from whoever runs the command, and a template puts
+// them into a shell command line. Substituted as they are, a file named
+// "x; rm -rf ~" runs rm. Command templates are therefore rewritten when they
+// are parsed, the way html/template escapes HTML: every action that prints a
+// parameter value gets an escaper appended to its pipeline, chosen by the
+// quoting the template text has open at that point. Unquoted values are
+// quoted as one argument; values inside '...' or "..." are escaped so they
+// cannot end the quotes. {{raw .params.x}} prints a value unchanged, for
+// trusted values that are meant to be shell syntax.
+
+// escapeFunc is the name the escaper is bound to in rewritten templates;
+// it cannot clash with a helper, whose names are identifiers
+const escapeFunc = "_goldfish_escape"
+
+// quoting is the quoting open in a shell command line
+type quoting string
+
+const (
+	unquoted     quoting = "unquoted"
+	singleQuoted quoting = "single"
+	doubleQuoted quoting = "double"
+)
+
+// raw prints a value unescaped in a command template: {{raw .params.x}}
+func raw(value interface{}) interface{} {
+	return value
+}
+
+// escapeTemplate rewrites a parsed command template so that parameter
+// values are escaped for shell (see above)
+// A template whose branches leave different quotes open is an error, since
+// the quoting after them depends on the parameters. Templates it runs with
+// {{template}} are escaped where they are called (see call).
+func escapeTemplate(tmpl *template.Template, shell string) (*template.Template, error) {
+	if tmpl.Tree != nil && tmpl.Tree.Root != nil {
+		escaper := &templateEscaper{shell: shell, tmpl: tmpl, called: make(map[string]bool), tainted: make(map[string]bool)}
+		if _, err := escaper.list(tmpl.Tree.Root, unquoted); err != nil {
+			return nil, fmt.Errorf("failed to parse template: %w", err)
+		}
+	}
+	return tmpl.Funcs(template.FuncMap{escapeFunc: escapeValue}), nil
+}
+
+// templateEscaper walks a template's parse tree adding escapers
+type templateEscaper struct {
+	shell string
+	// tmpl is the command template, which holds the templates it calls
+	tmpl *template.Template
+	// called holds the names of the escaped copies of called templates
+	called map[string]bool
+	// tainted holds the variables set from parameter values
+	tainted map[string]bool
+	// dotTainted is set inside {{with}} and {{range}} over a parameter value
+	dotTainted bool
+}
+
+// list escapes the nodes of a list, which starts in quoting q, and returns
+// the quoting at its end
+func (e *templateEscaper) list(list *parse.ListNode, q quoting) (quoting, error) {
+	if list == nil {
+		return q, nil
+	}
+	for _, node := range list.Nodes {
+		var err error
+		switch n := node.(type) {
+		case *parse.TextNode:
+			q = scanQuoting(e.shell, string(n.Text), q)
+		case *parse.ActionNode:
+			e.action(n.Pipe, q)
+		case *parse.IfNode:
+			q, err = e.branch(&n.BranchNode, q, false)
+		case *parse.WithNode:
+			q, err = e.branch(&n.BranchNode, q, false)
+		case *parse.RangeNode:
+			q, err = e.branch(&n.BranchNode, q, true)
+		case *parse.ListNode:
+			q, err = e.list(n, q)
+		case *parse.TemplateNode:
+			err = e.call(n, q)
+		}
+		if err != nil {
+			return q, err
+		}
+	}
+	return q, nil
+}
+
+// branchNames name the actions that branch, for errors
+var branchNames = map[parse.NodeType]string{parse.NodeIf: "{{if}}", parse.NodeWith: "{{with}}", parse.NodeRange: "{{range}}"}
+
+// branch escapes both lists of an {{if}}, {{with}} or {{range}}
+// Both must end in the same quoting, and the body of a range must end in
+// the quoting it starts in, since it may run any number of times. Inside
+// {{with}} and {{range}} over a parameter value, dot is a parameter value.
+func (e *templateEscaper) branch(n *parse.BranchNode, q quoting, loop bool) (quoting, error) {
+	params := e.usesParams(n.Pipe)
+	if params {
+		e.declare(n.Pipe)
+	}
+	dotTainted := e.dotTainted
+	e.dotTainted = dotTainted || (params && n.NodeType != parse.NodeIf)
+	end, err := e.list(n.List, q)
+	e.dotTainted = dotTainted
+	if err != nil {
+		return q, err
+	}
+	elseEnd, err := e.list(n.ElseList, q)
+	if err != nil {
+		return q, err
+	}
+	if end != elseEnd || (loop && end != q) {
+		return q, fmt.Errorf("line %d: the quoting after %s depends on how it runs (%s or %s)", n.Line, branchNames[n.NodeType], end, elseEnd)
+	}
+	return end, nil
+}
+
+// call escapes the template a {{template}} action runs for the quoting q it
+// is called in, with dot a parameter value when it is passed one
+// As in html/template, each way a template is called runs a copy of it
+// escaped for that call. Its text must end in the quoting it starts in.
+func (e *templateEscaper) call(n *parse.TemplateNode, q quoting) error {
+	called := e.tmpl.Lookup(n.Name)
+	if called == nil || called.Tree == nil || called.Tree.Root == nil {
+		// Running it reports the missing template
+		return nil
+	}
+	dotTainted := e.usesParams(n.Pipe)
+	name := fmt.Sprintf("%s %s %s %t", escapeFunc, n.Name, q, dotTainted)
+	original := n.Name
+	n.Name = name
+	if e.called[name] {
+		return nil
+	}
+	e.called[name] = true
+
+	// $ is the value passed, so it is a parameter value when dot is
+	tree := called.Tree.Copy()
+	tree.Name = name
+	escaper := &templateEscaper{shell: e.shell, tmpl: e.tmpl, called: e.called,
+		tainted: map[string]bool{"$": dotTainted}, dotTainted: dotTainted}
+	end, err := escaper.list(tree.Root, q)
+	if err != nil {
+		return err
+	}
+	if end != q {
+		return fmt.Errorf("line %d: {{template %q}} ends in different quoting (%s) than it is called in (%s)", n.Line, original, end, q)
+	}
+	_, err = e.tmpl.AddParseTree(name, tree)
+	return err
+}
+
+// declare remembers the variables a pipeline sets as parameter values
+func (e *templateEscaper) declare(pipe *parse.PipeNode) {
+	for _, v := range pipe.Decl {
+		e.tainted[v.Ident[0]] = true
+	}
+}
+
+// action adds an escaper for quoting q to an action that prints a parameter value
+// An action that sets variables prints nothing; they are remembered as
+// parameter values instead.
+func (e *templateEscaper) action(pipe *parse.PipeNode, q quoting) {
+	if !e.usesParams(pipe) {
+		return
+	}
+	if len(pipe.Decl) > 0 {
+		e.declare(pipe)
+		return
+	}
+	if unescaped(pipe) {
+		return
+	}
+	pipe.Cmds = append(pipe.Cmds, &parse.CommandNode{
+		NodeType: parse.NodeCommand,
+		Pos:      pipe.Position(),
+		Args: []parse.Node{
+			&parse.IdentifierNode{NodeType: parse.NodeIdentifier, Pos: pipe.Position(), Ident: escapeFunc},
+			&parse.StringNode{NodeType: parse.NodeString, Pos: pipe.Position(), Quoted: fmt.Sprintf("%q", e.shell), Text: e.shell},
+			&parse.StringNode{NodeType: parse.NodeString, Pos: pipe.Position(), Quoted: fmt.Sprintf("%q", q), Text: string(q)},
+		},
+	})
+}
+
+// unescaped reports whether a pipeline ends in a function whose result must
+// not be escaped: raw, or goldfish, whose command line escaped its own values
+func unescaped(pipe *parse.PipeNode) bool {
+	last := pipe.Cmds[len(pipe.Cmds)-1]
+	if ident, ok := last.Args[0].(*parse.IdentifierNode); ok {
+		return ident.Ident == "raw" || ident.Ident == "goldfish"
+	}
+	return false
+}
+
+// untrusted are the template data that hold values given by whoever runs the
+// command: the parameters and piped input (.stdin_file is a path goldfish
+// chose, but is escaped too since the temporary directory may contain spaces),
+// and the vars, globals and environment, which may be copied from them
+var untrusted = map[string]bool{"params": true, "stdin": true, "stdin_file": true, "vars": true, "globals": true, "env": true}
+
+// usesParams reports whether a node refers to a parameter value anywhere in it
+func (e *templateEscaper) usesParams(node parse.Node) bool {
+	switch n := node.(type) {
+	case *parse.PipeNode:
+		if n == nil {
+			return false
+		}
+		for _, cmd := range n.Cmds {
+			if e.usesParams(cmd) {
+				return true
+			}
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			if e.usesParams(arg) {
+				return true
+			}
+		}
+	case *parse.ChainNode:
+		return e.usesParams(n.Node)
+	case *parse.FieldNode:
+		return e.dotTainted || untrusted[n.Ident[0]]
+	case *parse.DotNode:
+		return e.dotTainted
+	case *parse.VariableNode:
+		if n.Ident[0] == "$" && len(n.Ident) > 1 {
+			return e.tainted["$"] || untrusted[n.Ident[1]]
+		}
+		return e.tainted[n.Ident[0]]
+	}
+	return false
+}
+
+// scanQuoting returns the quoting open after text, which starts in quoting q
+// Only quotes and escapes are followed; that is all that decides how a
+// value placed after the text is read.
+func scanQuoting(shell, text string, q quoting) quoting {
+	escape := shellSyntaxes[shell].escape
+	escaped := false
+	for _, c := range text {
+		switch {
+		case escaped:
+			escaped = false
+		case q == singleQuoted:
+			if c == '\'' {
+				q = unquoted
+			}
+		case c == escape:
+			// cmd's ^ escapes nothing inside quotes
+			escaped = q == unquoted || shell != "cmd"
+		case c == '"':
+			if q == doubleQuoted {
+				q = unquoted
+			} else {
+				q = doubleQuoted
+			}
+		case c == '\'' && q == unquoted && shell != "cmd":
+			q = singleQuoted
+		}
+	}
+	return q
+}
+
+// escapeValue is bound as _goldfish_escape: it escapes a value printed in
+// quoting q of shell
+// A value that is not given prints nothing, rather than "<no value>".
+func escapeValue(shell, q string, value interface{}) (string, error) {
+	if value == nil {
+		return "", nil
+	}
+	text := fmt.Sprint(value)
+	if shell == "cmd" {
+		return escapeCmd(text, quoting(q))
+	}
+	switch quoting(q) {
+	case singleQuoted:
+		if shell == "powershell" {
+			return strings.ReplaceAll(text, "'", "''"), nil
+		}
+		return strings.ReplaceAll(text, "'", `'\''`), nil
+	case doubleQuoted:
+		if shell == "powershell" {
+			return backslashEscape(text, "`$\"", '`'), nil
+		}
+		return backslashEscape(text, "\\$`\"", '\\'), nil
+	}
+	if text == "" {
+		return "''", nil
+	}
+	return quoteArgument(shell, text), nil
+}
+
+// backslashEscape puts escape before each of special in text
+func backslashEscape(text, special string, escape rune) string {
+	var b strings.Builder
+	for _, r := range text {
+		if strings.ContainsRune(special, r) {
+			b.WriteRune(escape)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// cmdSpecial are the characters cmd treats specially outside quotes
+const cmdSpecial = `()%!^"<>&|`
+
+// escapeCmd escapes a value for cmd
+// Unquoted, the value is quoted for the program. cmd takes everything
+// between quotes literally except %NAME%, so a value with quotes or % of
+// its own also has every special character escaped with ^, quotes
+// included: cmd never sees a quote open, and a caret stops %NAME%
+// expanding, since no variable is named NAME^. Inside quotes nothing can be
+// escaped, so values that would end them or expand are refused.
+func escapeCmd(text string, q quoting) (string, error) {
+	if strings.ContainsAny(text, "\r\n") {
+		return "", fmt.Errorf("value %q contains a line break, which cmd cannot quote", text)
+	}
+	if q == doubleQuoted {
+		if strings.ContainsAny(text, `"%`) {
+			return "", fmt.Errorf("value %q cannot be quoted safely inside \"...\" for cmd; put it outside the quotes", text)
+		}
+		return text, nil
+	}
+	if text == "" {
+		return `""`, nil
+	}
+	quoted := quoteArgument("cmd", text)
+	if !strings.ContainsAny(text, `"%!`) {
+		return quoted, nil
+	}
+	return backslashEscape(quoted, cmdSpecial, '^'), nil
+}
@@ -0,0 +1,163 @@
+package engine
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// TestEngine_renderTemplate_Escaping tests that parameter values are escaped
+// for the quoting they are printed in
+func TestEngine_renderTemplate_Escaping(t *testing.T) {
+	testCases := []struct {
+		name     string
+		shell    string
+		template string
+		value    interface{}
+		expected string
+	}{
+		{"plain", "sh", "cat {{.params.x}}", "notes.txt", "cat notes.txt"},
+		{"unquoted", "sh", "cat {{.params.x}}", "a; rm -rf ~", `cat 'a; rm -rf ~'`},
+		{"single quotes", "sh", "grep '{{.params.x}}' f", "it's; rm", `grep 'it'\''s; rm' f`},
+		{"double quotes", "sh", `echo "{{.params.x}}"`, "$(rm) `rm` \"\\", "echo \"\\$(rm) \\`rm\\` \\\"\\\\\""},
+		{"escaped quote", "sh", `echo \'{{.params.x}}`, "a b", `echo \''a b'`},
+		{"empty", "sh", "touch {{.params.x}}", "", "touch ''"},
+		{"not given", "sh", "ls {{.params.x}}", nil, "ls"},
+		{"number", "sh", "head -n {{.params.x}}", 5, "head -n 5"},
+		{"raw", "sh", "ls {{raw .params.x}}", "a b | wc -l", "ls a b | wc -l"},
+		{"raw pipeline", "sh", "ls {{.params.x | raw}}", "*.go", "ls *.go"},
+		{"function", "sh", "ls {{printf \"%s/x\" .params.x}}", "my dir", "ls 'my dir/x'"},
+		{"variable", "sh", "{{$p := .params.x}}ls {{$p}}", "a;b", "ls 'a;b'"},
+		{"with", "sh", "ls {{with .params.x}}{{.}}{{end}}", "a;b", "ls 'a;b'"},
+		{"root variable", "sh", "ls {{$.params.x}}", "a;b", "ls 'a;b'"},
+		{"other data", "sh", "{{.base_command}} {{.params.x}}", "a", "ls a"},
+		{"branch", "sh", "ls {{if .params.x}}'{{.params.x}}'{{end}} {{.params.x}}", "a b", "ls 'a b' 'a b'"},
+		{"powershell", "powershell", "Get-Item {{.params.x}}", "a; Remove-Item", `Get-Item 'a; Remove-Item'`},
+		{"powershell single quotes", "powershell", "Get-Item '{{.params.x}}'", "it's", `Get-Item 'it''s'`},
+		{"powershell double quotes", "powershell", `Write-Output "{{.params.x}}"`, "$env:PATH`", "Write-Output \"`$env:PATH``\""},
+		{"cmd", "cmd", "type {{.params.x}}", "a & del x", `type "a & del x"`},
+		{"cmd quotes", "cmd", "type {{.params.x}}", `a" & del "x`, `type ^"a\^" ^& del \^"x^"`},
+		{"cmd variable", "cmd", "type {{.params.x}}", "%PATH%", `type ^%PATH^%`},
+		{"cmd double quotes", "cmd", `type "{{.params.x}}"`, "a & b", `type "a & b"`},
+		{"template in quotes", "sh", `{{define "x"}}{{.params.x}}{{end}}echo "{{template "x" .}}"`, "$(id)", `echo "\$(id)"`},
+		{"template unquoted", "sh", `{{define "x"}}{{.params.x}}{{end}}echo "{{template "x" .}}" {{template "x" .}}`, "a b", `echo "a b" 'a b'`},
+		{"template given a parameter", "sh", `{{define "x"}}{{.}} {{$}}{{end}}echo {{template "x" .params.x}}`, "a;b", `echo 'a;b' 'a;b'`},
+	}
+	engine := NewEngine(0)
+	for _, tc := range testCases {
+		cmd := &config.Command{Name: "test", BaseCommand: "ls"}
+		platformCmd := &config.PlatformCommand{Template: tc.template, Shell: tc.shell}
+		params := map[string]interface{}{"x": tc.value}
+		rendered, err := engine.renderTemplate(cmd, "linux", platformCmd, params, nil)
+		if err != nil {
+			t.Errorf("%s: renderTemplate failed: %v", tc.name, err)
+			continue
+		}
+		if rendered != tc.expected {
+			t.Errorf("%s: expected %s, got %s", tc.name, tc.expected, rendered)
+		}
+	}
+}
+
+// TestEngine_renderTemplate_EscapingErrors tests templates and values that cannot be escaped
+func TestEngine_renderTemplate_EscapingErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		shell    string
+		template string
+		value    string
+		expected string
+	}{
+		{"branches", "sh", "ls {{if .params.x}}'{{end}}{{.params.x}}", "a", "depends on how it runs"},
+		{"range", "sh", `ls {{range .params.x}}"{{end}}`, "a", "depends on how it runs"},
+		{"cmd quote", "cmd", `type "{{.params.x}}"`, `a"b`, "cannot be quoted safely"},
+		{"cmd variable", "cmd", `type "{{.params.x}}"`, "%PATH%", "cannot be quoted safely"},
+		{"cmd line break", "cmd", "type {{.params.x}}", "a\nb", "line break"},
+		{"template quoting", "sh", `{{define "x"}}'{{end}}ls {{template "x"}}{{.params.x}}`, "a", "ends in different quoting"},
+	}
+	engine := NewEngine(0)
+	for _, tc := range testCases {
+		cmd := &config.Command{Name: "test", BaseCommand: "ls"}
+		platformCmd := &config.PlatformCommand{Template: tc.template, Shell: tc.shell}
+		_, err := engine.renderTemplate(cmd, "linux", platformCmd, map[string]interface{}{"x": tc.value}, nil)
+		if err == nil || !strings.Contains(err.Error(), tc.expected) {
+			t.Errorf("%s: expected an error containing %q, got %v", tc.name, tc.expected, err)
+		}
+	}
+}
+
+// TestEngine_renderTemplate_EscapingVars tests that parameter values copied
+// into vars are escaped where the template prints the var
+func TestEngine_renderTemplate_EscapingVars(t *testing.T) {
+	cmd := &config.Command{
+		Name:        "test",
+		BaseCommand: "echo",
+		Parameters:  []config.Parameter{{Name: "file", Type: "string"}},
+		Vars:        map[string]string{"f": "{{.params.file}}"},
+	}
+	platformCmd := &config.PlatformCommand{Template: `echo {{.vars.f}} "{{.vars.f}}"`}
+	rendered, err := NewEngine(0).renderTemplate(cmd, "linux", platformCmd, map[string]interface{}{"file": "a; rm -rf ~"}, nil)
+	if err != nil {
+		t.Fatalf("renderTemplate failed: %v", err)
+	}
+	if rendered != `echo 'a; rm -rf ~' "a; rm -rf ~"` {
+		t.Errorf("Expected the var to be escaped, got %s", rendered)
+	}
+}
+
+// TestEngine_renderTemplate_EscapingGlobals tests that environment values
+// copied into globals are escaped where the template prints the global
+func TestEngine_renderTemplate_EscapingGlobals(t *testing.T) {
+	t.Setenv("GOLDFISH_TEST_TARGET", "a; touch /tmp/pwned $(id)")
+	engine := NewEngine(0)
+	engine.SetGlobals(map[string]string{"target": "{{.env.GOLDFISH_TEST_TARGET}}"})
+	cmd := &config.Command{Name: "test", BaseCommand: "echo"}
+	platformCmd := &config.PlatformCommand{Template: `echo {{.globals.target}} "{{.globals.target}}"`}
+	rendered, err := engine.renderTemplate(cmd, "linux", platformCmd, map[string]interface{}{}, nil)
+	if err != nil {
+		t.Fatalf("renderTemplate failed: %v", err)
+	}
+	if expected := `echo 'a; touch /tmp/pwned $(id)' "a; touch /tmp/pwned \$(id)"`; rendered != expected {
+		t.Errorf("Expected the global to be escaped, got %s", rendered)
+	}
+}
+
+// TestEngine_Escaping_Shell tests that escaped values reach the program unchanged
+func TestEngine_Escaping_Shell(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not installed")
+	}
+	marker := filepath.Join(t.TempDir(), "injected")
+	values := []string{
+		"a; touch " + marker,
+		"$(touch " + marker + ")",
+		"`touch " + marker + "`",
+		"it's \" \\ $HOME",
+	}
+	templates := []string{"printf '%s' {{.params.x}}", "printf '%s' '{{.params.x}}'", `printf '%s' "{{.params.x}}"`}
+
+	engine := NewEngine(0)
+	for _, text := range templates {
+		for _, value := range values {
+			cmd := &config.Command{Name: "test"}
+			rendered, err := engine.renderTemplate(cmd, "linux", &config.PlatformCommand{Template: text}, map[string]interface{}{"x": value}, nil)
+			if err != nil {
+				t.Fatalf("%s: renderTemplate failed: %v", text, err)
+			}
+			output, err := exec.Command("sh", "-c", rendered).Output()
+			if err != nil {
+				t.Fatalf("%s: running %s failed: %v", text, rendered, err)
+			}
+			if string(output) != value {
+				t.Errorf("%s: expected the program to get %q, got %q", text, value, output)
+			}
+		}
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Errorf("Expected no value to run a command")
+	}
+}
@@ -0,0 +1,137 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// LogEnvVar names the environment variable holding the execution log file path
+const LogEnvVar = "GOLDFISH_LOG"
+
+// maskedValue replaces secret parameter values in the execution log
+const maskedValue = "********"
+
+// ExecutionRecord is one line of the JSON lines execution log
+// It provides an audit trail of what goldfish actually ran
+type ExecutionRecord struct {
+	// Time is when the command started
+	Time time.Time `json:"time"`
+	// Command is the name of the goldfish command
+	Command string `json:"command"`
+	// Platform is the platform whose template was used
+	Platform string `json:"platform"`
+	// Parameters are the parsed parameter values, with secrets masked
+	Parameters map[string]interface{} `json:"params"`
+	// Rendered is the command line that was executed, with secrets masked
+	Rendered string `json:"rendered"`
+	// ExitCode is the child's exit code (-1 if it did not run to completion)
+	ExitCode int `json:"exit_code"`
+	// DurationMS is the execution time in milliseconds
+	DurationMS int64 `json:"duration_ms"`
+	// Attempt is the attempt number when the command has a retry policy (starting at 1)
+	Attempt int `json:"attempt,omitempty"`
+	// Error describes why the command did not run to completion, if it didn't
+	Error string `json:"error,omitempty"`
+}
+
+// executionLog serializes records to a writer, one JSON object per line
+type executionLog struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+// SetExecutionLog enables appending an ExecutionRecord to w for every execution
+// Pass nil to disable logging
+func (e *Engine) SetExecutionLog(w io.Writer) {
+	if w == nil {
+		e.executionLog = nil
+		return
+	}
+	e.executionLog = &executionLog{encoder: json.NewEncoder(w)}
+}
+
+// logExecution writes a record to the execution log, if one is configured
+// Logging must never break the command itself, so write errors are ignored
+func (e *Engine) logExecution(record ExecutionRecord) {
+	if e.executionLog == nil {
+		return
+	}
+	e.executionLog.mu.Lock()
+	defer e.executionLog.mu.Unlock()
+	_ = e.executionLog.encoder.Encode(record)
+}
+
+// newExecutionRecord builds a log record with secret values masked
+func (e *Engine) newExecutionRecord(ctx *ExecutionContext, rendered string, start time.Time, exitCode int, err error) ExecutionRecord {
+	record := ExecutionRecord{
+		Time:       start.UTC(),
+		Command:    ctx.Command.Name,
+		Platform:   ctx.Platform.String(),
+		Parameters: maskParameters(ctx.Command, ctx.Parameters),
+		Rendered:   e.maskSecrets(ctx, rendered),
+		ExitCode:   exitCode,
+		DurationMS: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		// Errors such as a timeout name the command line that ran
+		record.Error = e.maskSecrets(ctx, err.Error())
+	}
+	return record
+}
+
+// maskParameters copies params, replacing the values of secret parameters
+func maskParameters(cmd *config.Command, params map[string]interface{}) map[string]interface{} {
+	masked := make(map[string]interface{}, len(params))
+	for name, value := range params {
+		masked[name] = value
+	}
+	for _, param := range cmd.Parameters {
+		if _, set := masked[param.Name]; set && param.Secret {
+			masked[param.Name] = maskedValue
+		}
+	}
+	return masked
+}
+
+// MaskError returns err's message with the secret values of ctx's command
+// masked, for reporting it anywhere but the terminal (e.g. history or an API)
+// Errors such as a timeout name the command line that ran, secrets included.
+func (e *Engine) MaskError(ctx *ExecutionContext, err error) string {
+	if ctx.Command == nil {
+		return err.Error()
+	}
+	return e.maskSecrets(ctx, err.Error())
+}
+
+// maskSecrets removes secret values from text such as a rendered command line
+// Both secret parameters and secrets resolved from the keyring are masked
+func (e *Engine) maskSecrets(ctx *ExecutionContext, text string) string {
+	return maskRendered(ctx.Command, ctx.Parameters, text, ctx.revealedSet().values())
+}
+
+// maskRendered removes secret parameter values and any extra values from a rendered command line
+func maskRendered(cmd *config.Command, params map[string]interface{}, rendered string, extra []string) string {
+	values := extra
+	for _, param := range cmd.Parameters {
+		if !param.Secret {
+			continue
+		}
+		if value, set := params[param.Name]; set {
+			values = append(values, fmt.Sprint(value))
+		}
+	}
+
+	for _, value := range values {
+		// Skip empty values, which would otherwise mask between every character
+		if value != "" {
+			rendered = strings.ReplaceAll(rendered, value, maskedValue)
+		}
+	}
+	return rendered
+}
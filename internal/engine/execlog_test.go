@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// TestEngine_SetExecutionLog tests that executions are logged as JSON lines
+func TestEngine_SetExecutionLog(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+
+	engine := NewEngine(5 * time.Second)
+	var log bytes.Buffer
+	engine.SetExecutionLog(&log)
+
+	ctx := &ExecutionContext{
+		Command: &config.Command{
+			Name:        "login",
+			BaseCommand: "true",
+			Parameters: []config.Parameter{
+				{Name: "user", Type: "string"},
+				{Name: "token", Type: "string", Secret: true},
+			},
+			Platforms: map[string]config.PlatformCommand{
+				runtime.GOOS: {Template: "{{.base_command}} {{.params.user}} {{.params.token}}"},
+			},
+		},
+		Platform:   platform.SupportedPlatform(runtime.GOOS),
+		Parameters: map[string]interface{}{"user": "alice", "token": "s3cr3t"},
+	}
+
+	// Two executions produce two lines
+	for i := 0; i < 2; i++ {
+		if err := engine.ExecuteStatus(context.Background(), ctx); err != nil {
+			t.Fatalf("Execute() failed: %v", err)
+		}
+	}
+	lines := strings.Split(strings.TrimSpace(log.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 log lines, got %d:\n%s", len(lines), log.String())
+	}
+
+	var record ExecutionRecord
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatalf("Log line is not valid JSON: %v", err)
+	}
+	if record.Command != "login" || record.ExitCode != 0 || record.Platform != runtime.GOOS {
+		t.Errorf("Unexpected record: %+v", record)
+	}
+	if record.Rendered != "true alice "+maskedValue {
+		t.Errorf("Expected secret to be masked in rendered command, got %q", record.Rendered)
+	}
+	if record.Parameters["token"] != maskedValue || record.Parameters["user"] != "alice" {
+		t.Errorf("Expected only secret parameters to be masked, got %v", record.Parameters)
+	}
+	if strings.Contains(log.String(), "s3cr3t") {
+		t.Error("Secret value leaked into the execution log")
+	}
+
+	// The original parameters must not be modified by masking
+	if ctx.Parameters["token"] != "s3cr3t" {
+		t.Error("Masking modified the caller's parameters")
+	}
+}
+
+// TestEngine_SetExecutionLog_Error tests that secrets in a logged error are
+// masked, like the timeout error naming the command line that ran
+func TestEngine_SetExecutionLog_Error(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+	engine := NewEngine(5 * time.Second)
+	var log bytes.Buffer
+	engine.SetExecutionLog(&log)
+	ctx := &ExecutionContext{
+		Command: &config.Command{
+			Name:        "slow",
+			BaseCommand: "sleep",
+			Parameters:  []config.Parameter{{Name: "token", Type: "string", Secret: true}},
+			Platforms:   map[string]config.PlatformCommand{runtime.GOOS: {Template: "sleep 5 # {{.params.token}}"}},
+		},
+		Platform:   platform.SupportedPlatform(runtime.GOOS),
+		Parameters: map[string]interface{}{"token": "s3cr3t"},
+		Timeout:    200 * time.Millisecond,
+	}
+
+	_, err := engine.Execute(context.Background(), ctx)
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("Expected a timeout, got %v", err)
+	}
+	if strings.Contains(log.String(), "s3cr3t") || !strings.Contains(log.String(), "timed out") {
+		t.Errorf("Expected the error to be logged with the secret masked, got %s", log.String())
+	}
+	if message := engine.MaskError(ctx, err); strings.Contains(message, "s3cr3t") || !strings.Contains(message, maskedValue) {
+		t.Errorf("Expected MaskError to mask the secret, got %q", message)
+	}
+}
+
+// TestMaskRendered_EmptySecret tests that empty secret values are left alone
+func TestMaskRendered_EmptySecret(t *testing.T) {
+	cmd := &config.Command{Parameters: []config.Parameter{{Name: "token", Secret: true}}}
+	rendered := maskRendered(cmd, map[string]interface{}{"token": ""}, "curl -H 'x'", nil)
+	if rendered != "curl -H 'x'" {
+		t.Errorf("Expected rendered command to be unchanged, got %q", rendered)
+	}
+}
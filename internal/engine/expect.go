@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// PostconditionError reports that a command ran but did not satisfy its expect: section
+// It is kept distinct from execution failures so callers can tell "the tool
+// failed" apart from "the tool claimed success but did not do its job"
+type PostconditionError struct {
+	// Command is the name of the command whose postcondition failed
+	Command string
+	// Reason describes which expectation was not met
+	Reason string
+}
+
+// Error implements the error interface
+func (e *PostconditionError) Error() string {
+	return fmt.Sprintf("postcondition failed for command '%s': %s", e.Command, e.Reason)
+}
+
+// verifyExpectations checks a finished command against its declared expectations
+// exitCode is the child's exit code, stdout its captured output and data the
+// template data used to render any templated file paths
+func (e *Engine) verifyExpectations(cmd *config.Command, exitCode int, stdout string, data map[string]interface{}, revealed *revealedSecrets) error {
+	expect := cmd.Expect
+
+	// Check the exit code first
+	if !expect.AllowsExitCode(exitCode) {
+		return &PostconditionError{
+			Command: cmd.Name,
+			Reason:  fmt.Sprintf("exit code %d is not one of %v", exitCode, expect.ExitCodes),
+		}
+	}
+
+	// Check stdout against the expected pattern
+	if expect.StdoutMatches != "" {
+		pattern, err := regexp.Compile(expect.StdoutMatches)
+		if err != nil {
+			return fmt.Errorf("invalid expect.stdout_matches pattern: %w", err)
+		}
+		if !pattern.MatchString(stdout) {
+			return &PostconditionError{
+				Command: cmd.Name,
+				Reason:  fmt.Sprintf("stdout does not match %q", expect.StdoutMatches),
+			}
+		}
+	}
+
+	// Check that every expected file now exists
+	for _, pathTemplate := range expect.FilesExist {
+		path, err := e.renderString("expect", pathTemplate, data, revealed)
+		if err != nil {
+			return fmt.Errorf("failed to render expect.files_exist entry %q: %w", pathTemplate, err)
+		}
+		if _, err := os.Stat(path); err != nil {
+			return &PostconditionError{
+				Command: cmd.Name,
+				Reason:  fmt.Sprintf("expected file %s does not exist", path),
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// TestVerifyExpectations tests postcondition checks against a finished command
+func TestVerifyExpectations(t *testing.T) {
+	tempDir := t.TempDir()
+	existing := filepath.Join(tempDir, "out.txt")
+	if err := os.WriteFile(existing, []byte("data"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	data := map[string]interface{}{
+		"base_command": "echo",
+		"params":       map[string]interface{}{"dir": tempDir},
+	}
+
+	testCases := []struct {
+		name       string
+		expect     config.Expectation
+		exitCode   int
+		stdout     string
+		shouldFail bool
+	}{
+		{"no expectations", config.Expectation{}, 0, "", false},
+		{"allowed non-zero exit code", config.Expectation{ExitCodes: []int{0, 3}}, 3, "", false},
+		{"zero exit code not allowed", config.Expectation{ExitCodes: []int{3}}, 0, "", true},
+		{"stdout matches", config.Expectation{StdoutMatches: "done$"}, 0, "all done", false},
+		{"stdout does not match", config.Expectation{StdoutMatches: "^done"}, 0, "not done", true},
+		{"file exists", config.Expectation{FilesExist: []string{"{{.params.dir}}/out.txt"}}, 0, "", false},
+		{"file missing", config.Expectation{FilesExist: []string{"{{.params.dir}}/missing.txt"}}, 0, "", true},
+	}
+
+	for _, tc := range testCases {
+		expect := tc.expect
+		cmd := &config.Command{Name: "test", Expect: &expect}
+
+		err := NewEngine(time.Second).verifyExpectations(cmd, tc.exitCode, tc.stdout, data, nil)
+		if tc.shouldFail {
+			// Failures must be reported as the distinct postcondition error type
+			var postErr *PostconditionError
+			if !errors.As(err, &postErr) {
+				t.Errorf("%s: expected PostconditionError, got %v", tc.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: expected no error, got %v", tc.name, err)
+		}
+	}
+}
+
+// TestEngine_Execute_Postcondition tests that Execute verifies captured stdout
+func TestEngine_Execute_Postcondition(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+
+	engine := NewEngine(5 * time.Second)
+	cmd := &config.Command{
+		Name:        "say",
+		BaseCommand: "echo",
+		Platforms: map[string]config.PlatformCommand{
+			"linux":  {Template: "{{.base_command}} hello"},
+			"darwin": {Template: "{{.base_command}} hello"},
+		},
+		Expect: &config.Expectation{StdoutMatches: "goodbye"},
+	}
+	ctx := &ExecutionContext{
+		Command:    cmd,
+		Platform:   platform.SupportedPlatform(runtime.GOOS),
+		Parameters: map[string]interface{}{},
+	}
+
+	var postErr *PostconditionError
+	if err := engine.ExecuteStatus(context.Background(), ctx); !errors.As(err, &postErr) {
+		t.Errorf("Expected PostconditionError, got %v", err)
+	}
+
+	// A matching pattern passes
+	cmd.Expect.StdoutMatches = "^hello"
+	if err := engine.ExecuteStatus(context.Background(), ctx); err != nil {
+		t.Errorf("Expected matching postcondition to pass, got %v", err)
+	}
+
+	// Capturing the output for the caller still feeds the expectation
+	ctx.Capture = true
+	result, err := engine.Execute(context.Background(), ctx)
+	if err != nil || result.Stdout != "hello\n" {
+		t.Errorf("Expected the captured run to pass with its output, got %+v (%v)", result, err)
+	}
+}
+
+// TestPostconditionError_Error tests the error message format
+func TestPostconditionError_Error(t *testing.T) {
+	err := &PostconditionError{Command: "backup", Reason: "archive missing"}
+	expected := "postcondition failed for command 'backup': archive missing"
+	if err.Error() != expected {
+		t.Errorf("Expected %q, got %q", expected, err.Error())
+	}
+}
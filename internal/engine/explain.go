@@ -0,0 +1,216 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// goldfish explain shows how a command resolves without running it: what is
+// known of the platform, the platforms: keys tried and the template chosen
+// and why, where each parameter's value came from, the command's vars and
+// the command line that would run. It is the debugging companion to --dry-run.
+
+// Where a parameter's value comes from, as ParameterSources reports it
+const (
+	// SourceFlag is a value given with the parameter's flag
+	SourceFlag = "flag"
+	// SourceArgument is a value given as a positional argument
+	SourceArgument = "argument"
+	// SourceDefault is the parameter's default:
+	SourceDefault = "default"
+	// SourceComputed is a default: template worked out from the other values
+	SourceComputed = "computed default"
+	// SourceUnset means the parameter has no value
+	SourceUnset = "not set"
+)
+
+// Explanation is how a command resolves on a platform, step by step
+type Explanation struct {
+	// Platform is the platform whose templates are used
+	Platform platform.SupportedPlatform
+	// System describes what is known of the platform, e.g. "ubuntu (debian
+	// family, glibc), amd64, release 22.04"; empty for another platform
+	System string
+	// Keys are the platforms: keys tried, most specific first
+	Keys []string
+	// Selection is the template that was chosen
+	Selection *Selection
+	// Reason says why that template was chosen
+	Reason string
+	// Shell is the program that would run the command line
+	Shell string
+	// Vars are the command's vars as rendered, with secrets masked
+	Vars map[string]string
+	// Rendered is the command line that would run, with secrets masked
+	Rendered string
+}
+
+// Explain works out how ctx's command would run, without running it
+func (e *Engine) Explain(ctx *ExecutionContext) (*Explanation, error) {
+	if err := e.validateContext(ctx); err != nil {
+		return nil, fmt.Errorf("invalid execution context: %w", err)
+	}
+	selection, err := e.selectTemplate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	detector := platform.NewDetector()
+	explanation := &Explanation{
+		Platform:  ctx.Platform,
+		System:    describeSystem(detector, ctx.Platform),
+		Keys:      detector.TemplateKeys(ctx.Platform),
+		Selection: selection,
+		Reason:    selectionReason(ctx.Command, ctx.Platform, selection),
+		Shell:     platformShell(selection.Command.Shell, ctx.Platform.String()),
+	}
+	if explanation.Shell == "sh" && e.shProgram != "" {
+		explanation.Shell = e.shProgram
+	}
+
+	data, err := e.commandData(selection.command(ctx.Command), ctx.Platform.String(), ctx.Parameters, ctx.revealedSet())
+	if err != nil {
+		return nil, err
+	}
+	if vars, ok := data["vars"].(map[string]string); ok {
+		explanation.Vars = make(map[string]string, len(vars))
+		for name, value := range vars {
+			explanation.Vars[name] = e.maskSecrets(ctx, value)
+		}
+	}
+
+	explanation.Rendered, err = e.Preview(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return explanation, nil
+}
+
+// describeSystem says what is known of platform p: only the platform
+// goldfish runs on has a known distribution, CPU and release
+func describeSystem(detector *platform.Detector, p platform.SupportedPlatform) string {
+	var parts []string
+	if distro := detector.DistroFor(p); distro.ID != "" {
+		details := []string{}
+		if distro.Family != "" && distro.Family != distro.ID {
+			details = append(details, distro.Family+" family")
+		}
+		if distro.Libc != "" {
+			details = append(details, distro.Libc)
+		}
+		if len(details) > 0 {
+			parts = append(parts, fmt.Sprintf("%s (%s)", distro.ID, strings.Join(details, ", ")))
+		} else {
+			parts = append(parts, distro.ID)
+		}
+	}
+	if arch := detector.ArchFor(p); arch != "" {
+		parts = append(parts, arch)
+	}
+	if version := detector.VersionFor(p); version != "" {
+		parts = append(parts, "release "+version)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// selectionReason says why SelectPlatform chose s for cmd on platform p
+func selectionReason(cmd *config.Command, p platform.SupportedPlatform, s *Selection) string {
+	var reason string
+	key, _, _ := strings.Cut(s.Key, "/")
+	switch {
+	case s.Fallback != "":
+		reason = fmt.Sprintf("the command has no %s template, and fallback_platforms lets %s use %s's", p, p, s.Fallback)
+	case strings.HasPrefix(key, platform.PackageManagerKeyPrefix):
+		reason = fmt.Sprintf("%s is the package manager installed, and package manager templates are tried first", s.BaseCommand)
+	case isFlavorKey(key, p):
+		reason = fmt.Sprintf("the installed %s is the %s build (%s), and templates for it are tried first",
+			cmd.BaseCommand, strings.TrimPrefix(key, p.String()+"-"), s.BaseCommand)
+	default:
+		reason = "it is the first of the keys tried that the command has a template for"
+	}
+
+	// The conditions that had to hold for this template
+	var conditions []string
+	if variant := variantIndex(cmd.Platforms[s.Key], s.Command); variant > 0 {
+		conditions = append(conditions, fmt.Sprintf("it is variant %d of %s", variant, s.Key))
+	}
+	if len(s.Command.Arch) > 0 {
+		conditions = append(conditions, "arch: "+strings.Join(s.Command.Arch, ", ")+" matches")
+	}
+	if s.Command.MinVersion != "" || s.Command.MaxVersion != "" {
+		conditions = append(conditions, "the release is within min_version/max_version")
+	}
+	if s.Command.When != "" {
+		conditions = append(conditions, fmt.Sprintf("when: %s holds", s.Command.When))
+	}
+	if len(conditions) > 0 {
+		reason += "; " + strings.Join(conditions, "; ")
+	}
+	return reason
+}
+
+// isFlavorKey reports whether key is a tool flavor key for p, e.g. darwin-gnu
+func isFlavorKey(key string, p platform.SupportedPlatform) bool {
+	suffix, found := strings.CutPrefix(key, p.String()+"-")
+	if !found {
+		return false
+	}
+	for _, flavor := range platform.Flavors {
+		if suffix == flavor {
+			return true
+		}
+	}
+	return false
+}
+
+// variantIndex returns which of entry's variants (counting from 1) chosen is,
+// or 0 when it is the entry's own template
+func variantIndex(entry config.PlatformCommand, chosen config.PlatformCommand) int {
+	for i, variant := range entry.Variants {
+		if variant.Template == chosen.Template && variant.When == chosen.When &&
+			strings.Join(variant.Arch, ",") == strings.Join(chosen.Arch, ",") &&
+			variant.MinVersion == chosen.MinVersion && variant.MaxVersion == chosen.MaxVersion {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// ParameterSources reports where ParseParameters takes each of cmd's
+// parameters from, given the same arguments and flags (see the Source constants)
+func ParameterSources(cmd *config.Command, args []string, flags map[string]interface{}) map[string]string {
+	sources := make(map[string]string, len(cmd.Parameters))
+	for flagName := range flags {
+		cleanFlagName := strings.TrimLeft(flagName, "-")
+		for _, param := range cmd.Parameters {
+			if param.Flag == flagName || param.Flag == "--"+cleanFlagName || param.Name == cleanFlagName {
+				sources[param.Name] = SourceFlag
+				break
+			}
+		}
+	}
+
+	argIndex := 0
+	for _, param := range cmd.Parameters {
+		if _, set := sources[param.Name]; set {
+			continue
+		}
+		switch {
+		case argIndex < len(args):
+			sources[param.Name] = SourceArgument
+			argIndex++
+		case param.Default != nil:
+			if _, templated := param.DefaultTemplate(); templated {
+				sources[param.Name] = SourceComputed
+			} else {
+				sources[param.Name] = SourceDefault
+			}
+		default:
+			sources[param.Name] = SourceUnset
+		}
+	}
+	return sources
+}
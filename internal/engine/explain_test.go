@@ -0,0 +1,112 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// TestEngine_Explain tests explaining the template, vars and command line of a command
+func TestEngine_Explain(t *testing.T) {
+	cmd := &config.Command{
+		Name:        "login",
+		BaseCommand: "curl",
+		Parameters: []config.Parameter{
+			{Name: "verbose", Type: "bool"},
+			{Name: "token", Type: "string", Secret: true},
+		},
+		Vars: map[string]string{"header": "Authorization: {{.params.token}}"},
+		Platforms: map[string]config.PlatformCommand{
+			"darwin": {
+				Template: "curl -H '{{.vars.header}}' https://example.com",
+				Variants: []config.PlatformCommand{
+					{When: "{{.params.verbose}}", Template: "curl -v -H '{{.vars.header}}' https://example.com"},
+				},
+			},
+			"windows": {Shell: "powershell", Template: "Invoke-WebRequest https://example.com"},
+		},
+	}
+	engine := NewEngine(0)
+	params := map[string]interface{}{"verbose": true, "token": "s3cret"}
+	explanation, err := engine.Explain(&ExecutionContext{Command: cmd, Platform: platform.Darwin, Parameters: params})
+	if err != nil {
+		t.Fatalf("Explain() failed: %v", err)
+	}
+
+	if explanation.Selection.Key != "darwin" || explanation.Shell != "sh" {
+		t.Errorf("Expected the darwin template run by sh, got %s and %s", explanation.Selection.Key, explanation.Shell)
+	}
+	if !strings.Contains(explanation.Reason, "variant 1 of darwin") || !strings.Contains(explanation.Reason, "when: {{.params.verbose}} holds") {
+		t.Errorf("Expected the reason to name the variant and its condition, got %q", explanation.Reason)
+	}
+	if len(explanation.Keys) == 0 || explanation.Keys[len(explanation.Keys)-1] != "any" {
+		t.Errorf("Expected the keys tried to end with the catch-all keys, got %v", explanation.Keys)
+	}
+	// Secrets are masked in the vars and the command line
+	if explanation.Vars["header"] != "Authorization: ********" {
+		t.Errorf("Expected the var to be masked, got %q", explanation.Vars["header"])
+	}
+	if explanation.Rendered != "curl -v -H 'Authorization: ********' https://example.com" {
+		t.Errorf("Unexpected command line: %s", explanation.Rendered)
+	}
+
+	explanation, err = engine.Explain(&ExecutionContext{Command: cmd, Platform: platform.Windows, Parameters: map[string]interface{}{}})
+	if err != nil || explanation.Shell != "powershell" || explanation.System != "" {
+		t.Errorf("Expected powershell and nothing detected for another platform, got %+v (%v)", explanation, err)
+	}
+
+	if _, err := engine.Explain(&ExecutionContext{Command: cmd, Platform: platform.Linux, Parameters: params}); err == nil {
+		t.Error("Expected an error for a platform the command has no template for")
+	}
+}
+
+// TestSelectionReason tests the reasons given for each way of choosing a template
+func TestSelectionReason(t *testing.T) {
+	cmd := &config.Command{Name: "sed", BaseCommand: "sed", Platforms: map[string]config.PlatformCommand{
+		"darwin-gnu": {Template: "gsed"},
+		"linux":      {Template: "sed", Arch: []string{"arm64"}},
+	}}
+	testCases := []struct {
+		selection Selection
+		expected  string
+	}{
+		{Selection{Key: "linux", Command: cmd.Platforms["linux"], BaseCommand: "sed", Fallback: platform.Linux}, "no darwin template, and fallback_platforms lets darwin use linux's"},
+		{Selection{Key: "darwin-gnu", Command: cmd.Platforms["darwin-gnu"], BaseCommand: "gsed"}, "the installed sed is the gnu build (gsed)"},
+		{Selection{Key: "pm-brew", BaseCommand: "brew"}, "brew is the package manager installed"},
+		{Selection{Key: "unix", BaseCommand: "sed"}, "the first of the keys tried"},
+	}
+	for _, tc := range testCases {
+		if reason := selectionReason(cmd, platform.Darwin, &tc.selection); !strings.Contains(reason, tc.expected) {
+			t.Errorf("%s: expected %q in %q", tc.selection.Key, tc.expected, reason)
+		}
+	}
+	if reason := selectionReason(cmd, platform.Linux, &Selection{Key: "linux", Command: cmd.Platforms["linux"]}); !strings.Contains(reason, "arch: arm64 matches") {
+		t.Errorf("Expected the arch condition in the reason, got %q", reason)
+	}
+}
+
+// TestParameterSources tests telling flags, arguments and defaults apart
+func TestParameterSources(t *testing.T) {
+	cmd := &config.Command{Parameters: []config.Parameter{
+		{Name: "pattern", Type: "string", Flag: "--expression"},
+		{Name: "file", Type: "string"},
+		{Name: "backup", Type: "string", Default: "{{.params.file}}.bak"},
+		{Name: "lines", Type: "int", Default: 10},
+		{Name: "dry", Type: "bool"},
+	}}
+	sources := ParameterSources(cmd, []string{"notes.txt"}, map[string]interface{}{"--expression": "s/a/b/"})
+	expected := map[string]string{
+		"pattern": SourceFlag,
+		"file":    SourceArgument,
+		"backup":  SourceComputed,
+		"lines":   SourceDefault,
+		"dry":     SourceUnset,
+	}
+	for name, source := range expected {
+		if sources[name] != source {
+			t.Errorf("%s: expected %s, got %s", name, source, sources[name])
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package engine
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// Most templates only put parameters behind flags: "sed {{if .params.in_place}}-i{{end}} ...".
+// A platform entry can give a flag_map instead, and the engine builds that
+// command line itself. Values are quoted for the shell the command runs in,
+// which a hand-written template easily gets wrong.
+
+// safeArgument matches arguments that mean the same to every shell unquoted
+var safeArgument = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// buildFlagMapCommand builds the command line for a platform entry with a flag_map
+// Parameters are appended to base_command in the order they are declared.
+// A bool parameter adds its flag when true. Any other parameter adds its flag
+// followed by its value, joined to it when the flag ends in "=" or ":"
+// (--level=3), or just its value when the flag is empty (a positional
+// argument). Parameters that were not given, and those missing from the
+// flag_map, are left out.
+func buildFlagMapCommand(cmd *config.Command, platformName string, platformCmd *config.PlatformCommand, params map[string]interface{}) string {
+	shell := platformShell(platformCmd.Shell, platformName)
+
+	parts := []string{cmd.BaseCommand}
+	for _, param := range cmd.Parameters {
+		flag, mapped := platformCmd.FlagMap[param.Name]
+		value, given := params[param.Name]
+		if !mapped || !given || value == nil {
+			continue
+		}
+
+		if param.Type == "bool" {
+			if enabled, ok := value.(bool); ok && enabled && flag != "" {
+				parts = append(parts, flag)
+			}
+			continue
+		}
+
+		text := fmt.Sprint(value)
+		if text == "" {
+			continue
+		}
+		quoted := quoteArgument(shell, text)
+		switch {
+		case flag == "":
+			parts = append(parts, quoted)
+		case strings.HasSuffix(flag, "=") || strings.HasSuffix(flag, ":"):
+			parts = append(parts, flag+quoted)
+		default:
+			parts = append(parts, flag, quoted)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// platformShell returns the shell a template for platformName is run in
+// As in shellCommand, that is the template's own or the platform's default.
+func platformShell(shell, platformName string) string {
+	if shell != "" {
+		return shell
+	}
+	if platformName == platform.Windows.String() {
+		return "cmd"
+	}
+	return "sh"
+}
+
+// quoteArgument quotes a value so the shell passes it on as one argument
+// sh and PowerShell take anything literally between single quotes. cmd has
+// no such quotes: the value is double-quoted the way programs split their
+// command line, but cmd still expands %VARIABLES% inside it.
+func quoteArgument(shell, value string) string {
+	if safeArgument.MatchString(value) {
+		return value
+	}
+	switch shell {
+	case "powershell":
+		return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+	case "cmd":
+		return quoteWindowsArgument(value)
+	default:
+		return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+	}
+}
+
+// quoteWindowsArgument double-quotes a value by the rules of CommandLineToArgvW:
+// quotes are escaped with a backslash, and backslashes only need doubling
+// where they come before a quote (or the closing quote)
+func quoteWindowsArgument(value string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	backslashes := 0
+	for _, r := range value {
+		switch r {
+		case '\\':
+			backslashes++
+			continue
+		case '"':
+			b.WriteString(strings.Repeat(`\`, 2*backslashes+1))
+		default:
+			b.WriteString(strings.Repeat(`\`, backslashes))
+		}
+		backslashes = 0
+		b.WriteRune(r)
+	}
+	b.WriteString(strings.Repeat(`\`, 2*backslashes))
+	b.WriteByte('"')
+	return b.String()
+}
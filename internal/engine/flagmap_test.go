@@ -0,0 +1,76 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// TestEngine_FlagMap tests building command lines from flag_map entries
+func TestEngine_FlagMap(t *testing.T) {
+	cmd := &config.Command{
+		Name:        "replace",
+		BaseCommand: "sed",
+		Parameters: []config.Parameter{
+			{Name: "in_place", Type: "bool"},
+			{Name: "backup", Type: "string"},
+			{Name: "pattern", Type: "string"},
+			{Name: "file", Type: "string"},
+		},
+		Platforms: map[string]config.PlatformCommand{
+			"linux":  {FlagMap: map[string]string{"in_place": "-i", "backup": "--suffix=", "pattern": "", "file": ""}},
+			"darwin": {FlagMap: map[string]string{"in_place": "-i ''", "pattern": "", "file": ""}},
+			"windows": {
+				FlagMap: map[string]string{"pattern": "-Pattern", "file": "-Path"},
+				Shell:   "powershell",
+			},
+		},
+	}
+
+	testCases := []struct {
+		platform platform.SupportedPlatform
+		params   map[string]interface{}
+		expected string
+	}{
+		{platform.Linux, map[string]interface{}{"in_place": true, "pattern": "s/a/b/", "file": "notes.txt"}, "sed -i s/a/b/ notes.txt"},
+		{platform.Linux, map[string]interface{}{"in_place": false, "backup": ".orig file", "pattern": "s/it's/it is/", "file": "a.txt"}, `sed --suffix='.orig file' 's/it'\''s/it is/' a.txt`},
+		// Parameters without a flag on a platform are left out
+		{platform.Darwin, map[string]interface{}{"in_place": true, "backup": ".bak", "pattern": "s/a/b/", "file": "a.txt"}, "sed -i '' s/a/b/ a.txt"},
+		{platform.Windows, map[string]interface{}{"pattern": "it's", "file": `C:\My Files\a.txt`}, `sed -Pattern 'it''s' -Path 'C:\My Files\a.txt'`},
+	}
+	engine := NewEngine(0)
+	for _, tc := range testCases {
+		rendered, err := engine.Preview(&ExecutionContext{Command: cmd, Platform: tc.platform, Parameters: tc.params})
+		if err != nil {
+			t.Errorf("%s: Preview failed: %v", tc.platform, err)
+			continue
+		}
+		if rendered != tc.expected {
+			t.Errorf("%s: expected %q, got %q", tc.platform, tc.expected, rendered)
+		}
+	}
+}
+
+// TestQuoteArgument tests quoting values for each shell
+func TestQuoteArgument(t *testing.T) {
+	testCases := []struct {
+		shell    string
+		value    string
+		expected string
+	}{
+		{"sh", "plain-value_1.txt", "plain-value_1.txt"},
+		{"sh", "two words", "'two words'"},
+		{"sh", "$HOME", "'$HOME'"},
+		{"powershell", "$env:PATH", "'$env:PATH'"},
+		{"cmd", "two words", `"two words"`},
+		{"cmd", `say "hi"`, `"say \"hi\""`},
+		{"cmd", `C:\My Dir\`, `"C:\My Dir\\"`},
+		{"cmd", `a\"b`, `"a\\\"b"`},
+	}
+	for _, tc := range testCases {
+		if quoted := quoteArgument(tc.shell, tc.value); quoted != tc.expected {
+			t.Errorf("%s %q: expected %s, got %s", tc.shell, tc.value, tc.expected, quoted)
+		}
+	}
+}
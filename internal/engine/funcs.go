@@ -0,0 +1,122 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/danballance/goldfish/internal/secrets"
+)
+
+// templateFuncs returns the helper functions available to every template
+func (e *Engine) templateFuncs() template.FuncMap {
+	funcs := template.FuncMap{
+		// secret looks up a credential in the OS keyring: {{secret "api-token"}}
+		"secret": e.lookupSecret,
+		// now is the current time, e.g. {{now.Format "20060102-150405"}}
+		"now": time.Now,
+		// has reports whether a program is installed, e.g. {{if has "rg"}}
+		"has": hasProgram,
+		// exists reports whether a file or directory exists, e.g. {{if exists "/opt/cisco"}}
+		"exists": pathExists,
+		// fields and regexReplace help output_filter templates take lines apart (see output_filter.go)
+		"fields":       fields,
+		"regexReplace": regexReplace,
+		// goldfish renders another configured command, e.g. {{goldfish "find-files" "pattern=*.go"}};
+		// it is bound to the command being rendered (see compose.go)
+		"goldfish": unboundCompose,
+		// raw prints a parameter value in a command template without escaping it (see escape.go)
+		"raw": raw,
+	}
+	// toSlash, fromSlash, winPath and wslPath convert paths (see paths.go)
+	for name, fn := range pathFuncs() {
+		funcs[name] = fn
+	}
+	return funcs
+}
+
+// hasProgram reports whether a program is on the PATH of the system goldfish runs on
+func hasProgram(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+// pathExists reports whether a file or directory exists on the system goldfish runs on
+func pathExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// SetSecretStore replaces the store used by the {{secret}} template function
+// The default is the OS keyring; tests and embedders can supply their own
+func (e *Engine) SetSecretStore(store secrets.Store) {
+	e.secrets = store
+}
+
+// lookupSecret resolves a secret for a template
+func (e *Engine) lookupSecret(name string) (string, error) {
+	if e.secrets == nil {
+		return "", fmt.Errorf("no secret store configured")
+	}
+	return e.secrets.Get(name)
+}
+
+// withSecrets returns tmpl with {{secret}} bound to record the values it
+// resolves in revealed, so that they are masked wherever the execution
+// echoes a rendered command (see execlog.go)
+// Parsed templates are cached and shared, so the function is bound on a
+// copy. Templates that do not mention secret, and renders outside an
+// execution (revealed is nil), are returned unchanged.
+func (e *Engine) withSecrets(tmpl *template.Template, text string, revealed *revealedSecrets) (*template.Template, error) {
+	if revealed == nil || !strings.Contains(text, "secret") {
+		return tmpl, nil
+	}
+	bound, err := tmpl.Clone()
+	if err != nil {
+		return nil, err
+	}
+	return bound.Funcs(template.FuncMap{"secret": func(name string) (string, error) {
+		value, err := e.lookupSecret(name)
+		if err != nil {
+			return "", err
+		}
+		revealed.add(value)
+		return value, nil
+	}}), nil
+}
+
+// revealedSecrets records the secret values substituted into an execution's templates
+// It belongs to one ExecutionContext, so a long-running server does not
+// keep the secrets of every command it has run. Each value is kept once,
+// however often the templates that reveal it are rendered.
+type revealedSecrets struct {
+	mu      sync.Mutex
+	secrets map[string]struct{}
+}
+
+// add records a revealed secret value
+func (r *revealedSecrets) add(value string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.secrets == nil {
+		r.secrets = make(map[string]struct{})
+	}
+	r.secrets[value] = struct{}{}
+}
+
+// values returns the revealed secret values
+func (r *revealedSecrets) values() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	values := make([]string, 0, len(r.secrets))
+	for value := range r.secrets {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	return values
+}
@@ -0,0 +1,118 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+	"github.com/danballance/goldfish/internal/secrets"
+)
+
+// memoryStore is an in-memory secrets.Store for tests
+type memoryStore map[string]string
+
+func (m memoryStore) Set(name, value string) error { m[name] = value; return nil }
+func (m memoryStore) Delete(name string) error     { delete(m, name); return nil }
+func (m memoryStore) Get(name string) (string, error) {
+	value, ok := m[name]
+	if !ok {
+		return "", secrets.ErrNotFound
+	}
+	return value, nil
+}
+
+// TestEngine_secretTemplateFunction tests resolving {{secret}} during rendering
+func TestEngine_secretTemplateFunction(t *testing.T) {
+	engine := NewEngine(time.Second)
+	engine.SetSecretStore(memoryStore{"api-token": "s3cr3t"})
+
+	cmd := &config.Command{BaseCommand: "curl"}
+	platformCmd := &config.PlatformCommand{
+		Template: `{{.base_command}} -H "Authorization: Bearer {{secret "api-token"}}"`,
+	}
+
+	ctx := &ExecutionContext{Command: cmd, Platform: platform.Linux, Parameters: map[string]interface{}{}}
+	rendered, err := engine.renderTemplate(cmd, "linux", platformCmd, ctx.Parameters, ctx.revealedSet())
+	if err != nil {
+		t.Fatalf("renderTemplate() failed: %v", err)
+	}
+	if rendered != `curl -H "Authorization: Bearer s3cr3t"` {
+		t.Errorf("Unexpected rendered command: %s", rendered)
+	}
+
+	// Resolved secrets are masked wherever the command is echoed
+	if masked := engine.maskSecrets(ctx, rendered); strings.Contains(masked, "s3cr3t") {
+		t.Errorf("Expected resolved secret to be masked, got %s", masked)
+	}
+
+	// Rendering again, as retries do, records the secret once
+	for i := 0; i < 3; i++ {
+		if _, err := engine.renderTemplate(cmd, "linux", platformCmd, ctx.Parameters, ctx.revealedSet()); err != nil {
+			t.Fatalf("renderTemplate() failed: %v", err)
+		}
+	}
+	if values := ctx.revealedSet().values(); len(values) != 1 || values[0] != "s3cr3t" {
+		t.Errorf("Expected the secret to be recorded once, got %q", values)
+	}
+
+	// The secrets belong to the execution; another one has not revealed any
+	other := &ExecutionContext{Command: cmd, Platform: platform.Linux, Parameters: map[string]interface{}{}}
+	if values := other.revealedSet().values(); len(values) != 0 {
+		t.Errorf("Expected no secrets revealed to another execution, got %q", values)
+	}
+}
+
+// TestEngine_secretTemplateFunction_Missing tests a missing secret fails rendering
+func TestEngine_secretTemplateFunction_Missing(t *testing.T) {
+	engine := NewEngine(time.Second)
+	engine.SetSecretStore(memoryStore{})
+
+	cmd := &config.Command{BaseCommand: "curl"}
+	platformCmd := &config.PlatformCommand{Template: `{{secret "missing"}}`}
+
+	_, err := engine.renderTemplate(cmd, "linux", platformCmd, map[string]interface{}{}, nil)
+	if err == nil || !strings.Contains(err.Error(), "secret not found") {
+		t.Errorf("Expected secret not found error, got %v", err)
+	}
+
+	// Without any store the function reports a clear error
+	engine.SetSecretStore(nil)
+	if _, err := engine.renderTemplate(cmd, "linux", platformCmd, map[string]interface{}{}, nil); err == nil {
+		t.Error("Expected error without a secret store")
+	}
+}
+
+// TestEngine_Preview tests rendering without executing, with secrets masked
+func TestEngine_Preview(t *testing.T) {
+	engine := NewEngine(time.Second)
+	engine.SetSecretStore(memoryStore{"token": "s3cr3t"})
+
+	ctx := &ExecutionContext{
+		Command: &config.Command{
+			Name:        "fetch",
+			BaseCommand: "curl",
+			Parameters:  []config.Parameter{{Name: "url", Type: "string", Required: true}},
+			Platforms: map[string]config.PlatformCommand{
+				"linux": {Template: `{{.base_command}} -H "Token: {{secret "token"}}" {{.params.url}}`},
+			},
+		},
+		Platform:   platform.Linux,
+		Parameters: map[string]interface{}{"url": "example.com"},
+	}
+
+	preview, err := engine.Preview(ctx)
+	if err != nil {
+		t.Fatalf("Preview() failed: %v", err)
+	}
+	if strings.Contains(preview, "s3cr3t") || !strings.Contains(preview, "curl -H") || !strings.Contains(preview, "example.com") {
+		t.Errorf("Unexpected preview: %s", preview)
+	}
+
+	// Missing required parameters are reported rather than rendered
+	ctx.Parameters = map[string]interface{}{}
+	if _, err := engine.Preview(ctx); err == nil {
+		t.Error("Expected error for missing required parameter")
+	}
+}
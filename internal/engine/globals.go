@@ -0,0 +1,56 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// SetGlobals configures the configuration's root vars:, given to every
+// template as .globals; nil or empty means there are none
+func (e *Engine) SetGlobals(globals map[string]string) {
+	e.globals = globals
+}
+
+// globalData renders the globals for platformName
+// The selected profile's vars override the configuration's (see
+// profiles.go). Globals are rendered in dependency order (see
+// config.GlobalOrder) against the environment as .env, the platform as
+// .platform and the globals computed so far as .globals.
+func (e *Engine) globalData(platformName string, revealed *revealedSecrets) (map[string]string, error) {
+	templates := e.globals
+	if e.profile != nil && len(e.profile.Vars) > 0 {
+		templates = make(map[string]string, len(e.globals)+len(e.profile.Vars))
+		for name, text := range e.globals {
+			templates[name] = text
+		}
+		for name, text := range e.profile.Vars {
+			templates[name] = text
+		}
+	}
+	globals := make(map[string]string, len(templates))
+	if len(templates) == 0 {
+		return globals, nil
+	}
+	order, err := config.GlobalOrder(templates)
+	if err != nil {
+		return nil, err
+	}
+
+	data := map[string]interface{}{
+		"globals": globals,
+		"env":     e.environmentData(),
+	}
+	if platformName != "" {
+		data["platform"] = platformData(platform.SupportedPlatform(platformName))
+	}
+	for _, name := range order {
+		value, err := e.renderString("global "+name, templates[name], data, revealed)
+		if err != nil {
+			return nil, fmt.Errorf("global '%s': %w", name, err)
+		}
+		globals[name] = value
+	}
+	return globals, nil
+}
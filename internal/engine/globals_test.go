@@ -0,0 +1,61 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// TestEngine_Globals tests rendering templates with the configuration's globals
+func TestEngine_Globals(t *testing.T) {
+	t.Setenv("GOLDFISH_TEST_PROXY", "http://proxy:3128")
+	cmd := &config.Command{
+		Name:        "fetch",
+		BaseCommand: "curl",
+		Parameters: []config.Parameter{
+			{Name: "url", Type: "string", Required: true},
+			{Name: "out", Type: "string", Default: "page{{.globals.suffix}}"},
+		},
+		Vars: map[string]string{"proxy": "{{.globals.proxy}}"},
+		Platforms: map[string]config.PlatformCommand{
+			"linux":   {Template: "{{.base_command}} -x {{.vars.proxy}} -o {{.params.out}} {{.params.url}} # {{.globals.sep}}"},
+			"windows": {Template: "{{.base_command}} -x {{.vars.proxy}} -o {{.params.out}} {{.params.url}} # {{.globals.sep}}"},
+		},
+	}
+	engine := NewEngine(0)
+	engine.SetGlobals(map[string]string{
+		"proxy":  "{{.env.GOLDFISH_TEST_PROXY}}",
+		"suffix": ".{{.globals.ext}}",
+		"ext":    "html",
+		"sep":    `{{if eq .platform.os "windows"}}\{{else}}/{{end}}`,
+	})
+
+	params, err := engine.ParseParameters(cmd, []string{"example.com"}, map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("ParseParameters failed: %v", err)
+	}
+	if params["out"] != "page.html" {
+		t.Errorf("Expected the default to use a global, got %v", params["out"])
+	}
+
+	rendered, err := engine.Preview(&ExecutionContext{Command: cmd, Platform: platform.Linux, Parameters: params})
+	if err != nil {
+		t.Fatalf("Preview failed: %v", err)
+	}
+	if rendered != "curl -x http://proxy:3128 -o page.html example.com # /" {
+		t.Errorf("Unexpected command line: %q", rendered)
+	}
+	// Globals are escaped like parameters, since they may come from the environment
+	rendered, err = engine.Preview(&ExecutionContext{Command: cmd, Platform: platform.Windows, Parameters: params})
+	if err != nil || !strings.HasSuffix(rendered, `# "\\"`) {
+		t.Errorf("Expected the Windows separator, got %q (%v)", rendered, err)
+	}
+
+	// Errors in a global name it
+	engine.SetGlobals(map[string]string{"proxy": "{{.nope.x | bad}}"})
+	if _, err := engine.Preview(&ExecutionContext{Command: cmd, Platform: platform.Linux, Parameters: params}); err == nil || !strings.Contains(err.Error(), "global 'proxy'") {
+		t.Errorf("Expected the failing global to be named, got %v", err)
+	}
+}
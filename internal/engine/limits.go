@@ -0,0 +1,208 @@
+package engine
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// A wrapped command can print far more than anyone expects: a log dumped by
+// mistake, a binary file sent to stdout. Passing output through to a terminal
+// costs nothing, but goldfish keeps captured output (library callers,
+// --output json) and the stdout that expect.stdout_matches checks in memory,
+// and a server must not run out of it. Those buffers are capped, and lines
+// can be cut short so that a command printing megabytes without a newline
+// cannot bloat the line-based writers either (output_filter, --tee).
+
+const (
+	// DefaultMaxOutput is the number of bytes of each stream kept in memory
+	// when nothing else is configured
+	DefaultMaxOutput int64 = 64 << 20
+	// truncatedLineMarker ends a line that was cut short
+	truncatedLineMarker = "... [line truncated]"
+)
+
+// OutputLimitError reports that a command's output went over a limit
+// The command still ran to the end; the output past the limit was dropped,
+// and its exit code is in the ExecutionResult.
+type OutputLimitError struct {
+	// Command is the name of the command that printed too much
+	Command string
+	// Stream is "stdout" or "stderr"
+	Stream string
+	// Limit is the limit that was exceeded, in bytes
+	Limit int64
+	// Line is set when a line was longer than the line length limit, rather
+	// than the stream as a whole too large to keep
+	Line bool
+}
+
+// Error implements the error interface
+func (e *OutputLimitError) Error() string {
+	if e.Line {
+		return fmt.Sprintf("command '%s' printed a line longer than %d bytes on %s; it was truncated", e.Command, e.Limit, e.Stream)
+	}
+	return fmt.Sprintf("command '%s' printed more than %d bytes on %s; the rest was not kept", e.Command, e.Limit, e.Stream)
+}
+
+// SetOutputLimits changes the limits commands without their own max_output
+// and max_line_length are held to. A maxOutput of zero restores
+// DefaultMaxOutput and a negative one keeps all output; a maxLineLength of
+// zero or less leaves lines alone, which is the default.
+func (e *Engine) SetOutputLimits(maxOutput int64, maxLineLength int) {
+	if maxOutput == 0 {
+		maxOutput = DefaultMaxOutput
+	}
+	if maxLineLength < 0 {
+		maxLineLength = 0
+	}
+	e.maxOutput = maxOutput
+	e.maxLineLength = maxLineLength
+}
+
+// outputLimits returns the limits for a command: its own, or the engine's
+// A negative maxOutput or zero maxLineLength means no limit.
+func (e *Engine) outputLimits(cmd *config.Command) (maxOutput int64, maxLineLength int) {
+	maxOutput, maxLineLength = e.maxOutput, e.maxLineLength
+	if cmd.MaxOutput > 0 {
+		maxOutput = cmd.MaxOutput
+	}
+	if cmd.MaxLineLength > 0 {
+		maxLineLength = cmd.MaxLineLength
+	}
+	return maxOutput, maxLineLength
+}
+
+// cappedBuffer keeps the first limit bytes written to it and drops the rest
+// Like the output filter it never fails a write, so the command is not
+// stopped by a full buffer. A negative limit keeps everything.
+type cappedBuffer struct {
+	// stream names the stream buffered, for errors
+	stream   string
+	buf      bytes.Buffer
+	limit    int64
+	exceeded bool
+}
+
+// Write keeps as much of p as fits
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if b.limit < 0 {
+		return b.buf.Write(p)
+	}
+	room := b.limit - int64(b.buf.Len())
+	if room < int64(len(p)) {
+		b.exceeded = true
+		if room > 0 {
+			b.buf.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+// String returns the output kept
+func (b *cappedBuffer) String() string {
+	return b.buf.String()
+}
+
+// Reset empties the buffer for another attempt
+func (b *cappedBuffer) Reset() {
+	b.buf.Reset()
+	b.exceeded = false
+}
+
+// lineLimiter passes output on with lines longer than limit bytes cut short
+// The rest of a long line is dropped up to its newline, and
+// truncatedLineMarker marks where it was cut.
+type lineLimiter struct {
+	// stream names the stream passed on, for errors
+	stream string
+	target io.Writer
+	limit  int
+	// length is the number of bytes of the current line passed on so far
+	length int
+	// dropping is set while the rest of a truncated line is skipped
+	dropping bool
+	exceeded bool
+}
+
+// Write passes on p, truncating long lines; like cappedBuffer it never fails
+func (l *lineLimiter) Write(p []byte) (int, error) {
+	var out bytes.Buffer
+	rest := p
+	for len(rest) > 0 {
+		end := bytes.IndexByte(rest, '\n')
+		chunk := rest
+		if end >= 0 {
+			chunk = rest[:end]
+		}
+
+		if !l.dropping {
+			room := l.limit - l.length
+			if len(chunk) > room {
+				out.Write(chunk[:room])
+				out.WriteString(truncatedLineMarker)
+				l.dropping = true
+				l.exceeded = true
+			} else {
+				out.Write(chunk)
+				l.length += len(chunk)
+			}
+		}
+
+		if end < 0 {
+			break
+		}
+		out.WriteByte('\n')
+		l.length = 0
+		l.dropping = false
+		rest = rest[end+1:]
+	}
+	l.target.Write(out.Bytes())
+	return len(p), nil
+}
+
+// Reset starts a new line for another attempt; a nil limiter has nothing to reset
+func (l *lineLimiter) Reset() {
+	if l == nil {
+		return
+	}
+	l.length = 0
+	l.dropping = false
+	l.exceeded = false
+}
+
+// limitedWriter is a writer that can go over an output limit
+type limitedWriter interface {
+	// limitExceeded returns the error for the limit, or nil when it was kept to
+	limitExceeded() *OutputLimitError
+}
+
+// limitExceeded implements limitedWriter; a nil limiter has no limit
+func (l *lineLimiter) limitExceeded() *OutputLimitError {
+	if l == nil || !l.exceeded {
+		return nil
+	}
+	return &OutputLimitError{Stream: l.stream, Limit: int64(l.limit), Line: true}
+}
+
+// limitExceeded implements limitedWriter
+func (b *cappedBuffer) limitExceeded() *OutputLimitError {
+	if !b.exceeded {
+		return nil
+	}
+	return &OutputLimitError{Stream: b.stream, Limit: b.limit}
+}
+
+// limitError returns the error for the first of writers that went over its limit, if any
+func limitError(command string, writers ...limitedWriter) error {
+	for _, w := range writers {
+		if exceeded := w.limitExceeded(); exceeded != nil {
+			exceeded.Command = command
+			return exceeded
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// TestCappedBuffer tests keeping only the first bytes written
+func TestCappedBuffer(t *testing.T) {
+	buffer := &cappedBuffer{stream: "stdout", limit: 5}
+	for _, chunk := range []string{"abc", "def", "ghi"} {
+		if n, err := buffer.Write([]byte(chunk)); n != len(chunk) || err != nil {
+			t.Errorf("Expected the write of %q to succeed, got %d, %v", chunk, n, err)
+		}
+	}
+	if buffer.String() != "abcde" || buffer.limitExceeded() == nil {
+		t.Errorf("Expected abcde and the limit exceeded, got %q", buffer.String())
+	}
+
+	buffer.Reset()
+	buffer.Write([]byte("abcde"))
+	if buffer.limitExceeded() != nil {
+		t.Error("Expected output exactly at the limit to be kept")
+	}
+
+	unlimited := &cappedBuffer{limit: -1}
+	unlimited.Write(bytes.Repeat([]byte("x"), 1000))
+	if len(unlimited.String()) != 1000 || unlimited.limitExceeded() != nil {
+		t.Error("Expected a negative limit to keep everything")
+	}
+}
+
+// TestLineLimiter tests truncating long lines, including lines split across writes
+func TestLineLimiter(t *testing.T) {
+	var out bytes.Buffer
+	limiter := &lineLimiter{stream: "stdout", target: &out, limit: 4}
+	for _, chunk := range []string{"ok\nabc", "defgh\n", "1234\n", "toolong"} {
+		limiter.Write([]byte(chunk))
+	}
+	expected := "ok\nabcd" + truncatedLineMarker + "\n1234\ntool" + truncatedLineMarker
+	if out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+	if exceeded := limiter.limitExceeded(); exceeded == nil || !exceeded.Line || exceeded.Limit != 4 {
+		t.Errorf("Expected a line limit error, got %v", exceeded)
+	}
+
+	var none *lineLimiter
+	none.Reset()
+	if none.limitExceeded() != nil {
+		t.Error("Expected a nil limiter to have no limit")
+	}
+}
+
+// TestEngine_Execute_OutputLimits tests capped capture and truncated lines end to end
+func TestEngine_Execute_OutputLimits(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+	entry := config.PlatformCommand{Template: "printf 'short\\n%0200d\\n' 0; exit 3"}
+	cmd := &config.Command{
+		Name:        "dump",
+		BaseCommand: "printf",
+		Platforms:   map[string]config.PlatformCommand{"linux": entry, "darwin": entry},
+	}
+	current := platform.SupportedPlatform(runtime.GOOS)
+	engine := NewEngine(5 * time.Second)
+
+	// Captured output stops at the engine's limit; the exit code is still reported
+	engine.SetOutputLimits(10, 0)
+	result, err := engine.Execute(context.Background(), &ExecutionContext{Command: cmd, Platform: current, Parameters: map[string]interface{}{}, Capture: true})
+	var limitErr *OutputLimitError
+	if !errors.As(err, &limitErr) || limitErr.Line || limitErr.Stream != "stdout" || limitErr.Command != "dump" {
+		t.Fatalf("Expected an output limit error, got %v", err)
+	}
+	if result.Stdout != "short\n0000" || result.ExitCode != 3 {
+		t.Errorf("Expected the first 10 bytes and exit code 3, got %q and %d", result.Stdout, result.ExitCode)
+	}
+
+	// A command's own limits take precedence over the engine's
+	limited := *cmd
+	limited.MaxOutput = 1000
+	limited.MaxLineLength = 8
+	result, err = engine.Execute(context.Background(), &ExecutionContext{Command: &limited, Platform: current, Parameters: map[string]interface{}{}, Capture: true})
+	if !errors.As(err, &limitErr) || !limitErr.Line || limitErr.Limit != 8 {
+		t.Fatalf("Expected a line limit error, got %v", err)
+	}
+	if expected := "short\n00000000" + truncatedLineMarker + "\n"; result.Stdout != expected {
+		t.Errorf("Expected %q, got %q", expected, result.Stdout)
+	}
+
+	// Output within the limits is not an error
+	engine.SetOutputLimits(0, 0)
+	result, err = engine.Execute(context.Background(), &ExecutionContext{Command: cmd, Platform: current, Parameters: map[string]interface{}{}, Capture: true})
+	if err != nil || !strings.HasPrefix(result.Stdout, "short\n") {
+		t.Errorf("Expected the full output without an error, got %v", err)
+	}
+}
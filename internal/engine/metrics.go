@@ -0,0 +1,131 @@
+package engine
+
+import (
+	"expvar"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Engine metrics are process-wide counters published through expvar under
+// "goldfish_engine". They are visible at /debug/vars when goldfish runs in
+// serve mode, and can be read programmatically through Stats.
+var (
+	metricExecutions  = new(expvar.Int)
+	metricFailures    = new(expvar.Int)
+	metricTimeouts    = new(expvar.Int)
+	metricRenderNanos = new(expvar.Int)
+	// metricTemplateCacheHits counts renders that reused a parsed template
+	metricTemplateCacheHits = new(expvar.Int)
+	// metricResultCacheHits counts executions answered from the result cache
+	metricResultCacheHits = new(expvar.Int)
+)
+
+// init registers the counters; expvar names are global, so this happens once
+func init() {
+	published := expvar.NewMap("goldfish_engine")
+	published.Set("executions", metricExecutions)
+	published.Set("failures", metricFailures)
+	published.Set("timeouts", metricTimeouts)
+	published.Set("render_nanoseconds", metricRenderNanos)
+	published.Set("template_cache_hits", metricTemplateCacheHits)
+	published.Set("result_cache_hits", metricResultCacheHits)
+}
+
+// Stats is a snapshot of the engine metrics
+type Stats struct {
+	// Executions counts calls to Execute
+	Executions int64
+	// Failures counts executions that returned an error or a non-zero exit code
+	Failures int64
+	// Timeouts counts executions killed because they exceeded their timeout
+	Timeouts int64
+	// RenderTime is the total time spent rendering templates
+	RenderTime time.Duration
+	// TemplateCacheHits counts renders that reused an already parsed template
+	TemplateCacheHits int64
+	// ResultCacheHits counts executions that printed a cached result (see
+	// config.CachePolicy) instead of running the command
+	ResultCacheHits int64
+}
+
+// CurrentStats returns a snapshot of the process-wide engine metrics
+func CurrentStats() Stats {
+	return Stats{
+		Executions:        metricExecutions.Value(),
+		Failures:          metricFailures.Value(),
+		Timeouts:          metricTimeouts.Value(),
+		RenderTime:        time.Duration(metricRenderNanos.Value()),
+		TemplateCacheHits: metricTemplateCacheHits.Value(),
+		ResultCacheHits:   metricResultCacheHits.Value(),
+	}
+}
+
+// DurationBuckets are the upper bounds, in seconds, of the buckets execution
+// durations are counted in per command, from quick lookups to long jobs
+var DurationBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 300, 900, 3600}
+
+// commandMetrics are the metrics kept for each command name, for monitoring
+// goldfish serve as an execution service; names come from the configuration,
+// so there are only as many as there are commands
+var commandMetrics = struct {
+	sync.Mutex
+	byName map[string]*CommandStats
+}{byName: map[string]*CommandStats{}}
+
+// CommandStats is a snapshot of the metrics of one command
+type CommandStats struct {
+	// Command is the command's name
+	Command string
+	// Executions counts calls to Execute for the command
+	Executions int64
+	// Failures counts executions that returned an error or a non-zero exit code
+	Failures int64
+	// Timeouts counts executions that ended because the command timed out
+	Timeouts int64
+	// BucketCounts counts, for each of DurationBuckets, the executions that
+	// took at most that long; like a Prometheus histogram, the counts are
+	// cumulative, and Executions is the count for +Inf
+	BucketCounts []int64
+	// Duration is the total time the executions took
+	Duration time.Duration
+}
+
+// recordExecution adds an execution of a command to its metrics
+func recordExecution(name string, duration time.Duration, failed, timedOut bool) {
+	commandMetrics.Lock()
+	defer commandMetrics.Unlock()
+	stats := commandMetrics.byName[name]
+	if stats == nil {
+		stats = &CommandStats{Command: name, BucketCounts: make([]int64, len(DurationBuckets))}
+		commandMetrics.byName[name] = stats
+	}
+	stats.Executions++
+	if failed {
+		stats.Failures++
+	}
+	if timedOut {
+		stats.Timeouts++
+	}
+	stats.Duration += duration
+	for i, bound := range DurationBuckets {
+		if duration.Seconds() <= bound {
+			stats.BucketCounts[i]++
+		}
+	}
+}
+
+// CurrentCommandStats returns a snapshot of the metrics of each command that
+// has been executed in this process, sorted by name
+func CurrentCommandStats() []CommandStats {
+	commandMetrics.Lock()
+	defer commandMetrics.Unlock()
+	snapshot := make([]CommandStats, 0, len(commandMetrics.byName))
+	for _, stats := range commandMetrics.byName {
+		copied := *stats
+		copied.BucketCounts = append([]int64(nil), stats.BucketCounts...)
+		snapshot = append(snapshot, copied)
+	}
+	sort.Slice(snapshot, func(i, j int) bool { return snapshot[i].Command < snapshot[j].Command })
+	return snapshot
+}
@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"context"
+	"io"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// TestCurrentStats tests that executions, failures and render time are counted
+func TestCurrentStats(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+	engine := NewEngine(5 * time.Second)
+	before := CurrentStats()
+
+	// A successful execution
+	cmd := &config.Command{
+		Name:        "ok",
+		BaseCommand: "true",
+		Platforms: map[string]config.PlatformCommand{
+			runtime.GOOS: {Template: "{{.base_command}}"},
+		},
+	}
+	ctx := &ExecutionContext{
+		Command:    cmd,
+		Platform:   platform.SupportedPlatform(runtime.GOOS),
+		Parameters: map[string]interface{}{},
+	}
+	if err := engine.ExecuteStatus(context.Background(), ctx); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	// A failed execution (platform not supported by the command)
+	ctx.Platform = platform.SupportedPlatform("plan9")
+	if err := engine.ExecuteStatus(context.Background(), ctx); err == nil {
+		t.Fatal("Expected unsupported platform error")
+	}
+
+	after := CurrentStats()
+	if after.Executions-before.Executions != 2 {
+		t.Errorf("Expected 2 executions to be counted, got %d", after.Executions-before.Executions)
+	}
+	if after.Failures-before.Failures != 1 {
+		t.Errorf("Expected 1 failure to be counted, got %d", after.Failures-before.Failures)
+	}
+	if after.RenderTime <= before.RenderTime {
+		t.Error("Expected render time to increase")
+	}
+}
+
+// TestCurrentCommandStats tests that executions are counted per command,
+// with timeouts and a duration histogram
+func TestCurrentCommandStats(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+	engine := NewEngine(30 * time.Second)
+	cmd := &config.Command{
+		Name:        "metrics-sleep",
+		BaseCommand: "sleep",
+		Parameters:  []config.Parameter{{Name: "seconds", Type: "string"}},
+		Platforms: map[string]config.PlatformCommand{
+			runtime.GOOS: {Template: "sleep {{.params.seconds}}"},
+		},
+	}
+	for _, seconds := range []string{"0", "5", "x"} {
+		engine.Execute(context.Background(), &ExecutionContext{Command: cmd, Platform: platform.SupportedPlatform(runtime.GOOS),
+			Parameters: map[string]interface{}{"seconds": seconds}, Timeout: 100 * time.Millisecond, Stderr: io.Discard})
+	}
+
+	var stats *CommandStats
+	for _, command := range CurrentCommandStats() {
+		if command.Command == cmd.Name {
+			stats = &command
+		}
+	}
+	if stats == nil {
+		t.Fatal("Expected the command to have metrics")
+	}
+	if stats.Executions != 3 || stats.Failures != 2 || stats.Timeouts != 1 {
+		t.Errorf("Expected 3 executions, 2 failures and 1 timeout, got %+v", stats)
+	}
+	// The run that timed out took longer than 100ms, the others far less
+	if stats.BucketCounts[1] > 2 || stats.BucketCounts[4] != 3 || stats.BucketCounts[len(DurationBuckets)-1] != 3 {
+		t.Errorf("Unexpected duration buckets %v", stats.BucketCounts)
+	}
+	if stats.Duration < 100*time.Millisecond {
+		t.Errorf("Expected the durations to add up to the timeout at least, got %v", stats.Duration)
+	}
+}
@@ -0,0 +1,119 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// The mock backend records rendered command lines instead of running them.
+// Tests can assert on exactly what would have run, and users can walk a
+// runbook or workflow through with GOLDFISH_EXEC=mock to see every command
+// it would execute, without touching the system. Each command "succeeds"
+// with no output.
+
+const (
+	// ExecEnvVar selects how commands are executed: "mock" records them
+	// with a MockBackend; empty or "local" runs them as usual
+	ExecEnvVar = "GOLDFISH_EXEC"
+	// MockTranscriptEnvVar names a file the mock backend appends its
+	// transcript to, instead of standard error
+	MockTranscriptEnvVar = "GOLDFISH_MOCK_TRANSCRIPT"
+)
+
+// MockCall is a command line the mock backend was asked to run
+type MockCall struct {
+	// Command is the rendered command line, with secrets masked
+	Command string
+	// Shell is the template's shell, or empty for the platform's default
+	Shell string
+}
+
+// MockBackend is a Backend that records commands rather than running them
+type MockBackend struct {
+	mu    sync.Mutex
+	calls []MockCall
+	// skipChecks are the skip_if: checks that were not run (see SkipChecks)
+	skipChecks []MockCall
+	// transcript receives one line per command when set
+	transcript io.Writer
+	// exitCode is what every command returns
+	exitCode int
+}
+
+// NewMockBackend creates a mock backend that writes each command to transcript
+// A nil transcript only records the commands; see Calls.
+func NewMockBackend(transcript io.Writer) *MockBackend {
+	return &MockBackend{transcript: transcript}
+}
+
+// SetExitCode makes every command return code instead of 0, e.g. to test
+// how a workflow handles a failing step
+func (m *MockBackend) SetExitCode(code int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.exitCode = code
+}
+
+// Run implements Backend by recording the command
+func (m *MockBackend) Run(ctx context.Context, command, shell string, stdout, stderr io.Writer) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, MockCall{Command: command, Shell: shell})
+	if m.transcript != nil {
+		if shell == "" {
+			shell = "default shell"
+		}
+		fmt.Fprintf(m.transcript, "[mock %s] %s\n", shell, command)
+	}
+	return m.exitCode, nil
+}
+
+// recordSkipCheck records a command's skip_if: check, which is not run
+// Every mocked command succeeds, so running the check would make every
+// command with one look done, and the command itself would never be recorded.
+func (m *MockBackend) recordSkipCheck(command, shell string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.skipChecks = append(m.skipChecks, MockCall{Command: command, Shell: shell})
+	if m.transcript != nil {
+		if shell == "" {
+			shell = "default shell"
+		}
+		fmt.Fprintf(m.transcript, "[mock %s] skip_if not run, assuming not done: %s\n", shell, command)
+	}
+}
+
+// SkipChecks returns the skip_if: checks evaluated so far, in order; they
+// were recorded rather than run, and the commands ran as if not done
+func (m *MockBackend) SkipChecks() []MockCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]MockCall(nil), m.skipChecks...)
+}
+
+// Calls returns the commands recorded so far, in order
+func (m *MockBackend) Calls() []MockCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]MockCall(nil), m.calls...)
+}
+
+// Commands returns the command lines recorded so far, in order
+func (m *MockBackend) Commands() []string {
+	calls := m.Calls()
+	commands := make([]string, len(calls))
+	for i, call := range calls {
+		commands[i] = call.Command
+	}
+	return commands
+}
+
+// Reset forgets the commands and checks recorded so far
+func (m *MockBackend) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = nil
+	m.skipChecks = nil
+}
@@ -0,0 +1,89 @@
+package engine
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// TestMockBackend tests recording commands, with secrets masked, instead of running them
+func TestMockBackend(t *testing.T) {
+	var transcript strings.Builder
+	mock := NewMockBackend(&transcript)
+	engine := NewEngine(5 * time.Second)
+	engine.SetBackend(mock)
+
+	cmd := &config.Command{
+		Name:        "login",
+		BaseCommand: "login",
+		Parameters: []config.Parameter{
+			{Name: "user", Type: "string"},
+			{Name: "token", Type: "string", Secret: true},
+		},
+		Platforms: map[string]config.PlatformCommand{
+			"linux":   {Template: "login {{.params.user}} {{.params.token}}"},
+			"windows": {Template: "Connect {{.params.user}} {{.params.token}}", Shell: "powershell"},
+		},
+	}
+	var stdout strings.Builder
+	params := map[string]interface{}{"user": "alice", "token": "s3cr3t"}
+	for _, p := range []platform.SupportedPlatform{platform.Linux, platform.Windows} {
+		exitCode, err := engine.Run(context.Background(), &ExecutionContext{Command: cmd, Platform: p, Parameters: params, Stdout: &stdout})
+		if err != nil || exitCode != 0 {
+			t.Fatalf("Run() on %s: exit %d, %v", p, exitCode, err)
+		}
+	}
+
+	commands := mock.Commands()
+	if len(commands) != 2 || commands[0] != "login alice "+maskedValue || commands[1] != "Connect alice "+maskedValue {
+		t.Errorf("Expected both commands with the secret masked, got %q", commands)
+	}
+	if calls := mock.Calls(); calls[0].Shell != "" || calls[1].Shell != "powershell" {
+		t.Errorf("Expected the templates' shells, got %+v", calls)
+	}
+	expected := "[mock default shell] login alice " + maskedValue + "\n[mock powershell] Connect alice " + maskedValue + "\n"
+	if transcript.String() != expected {
+		t.Errorf("Unexpected transcript:\n%s", transcript.String())
+	}
+	if stdout.Len() != 0 || strings.Contains(transcript.String(), "s3cr3t") {
+		t.Errorf("Expected no output and no secret, got %q", stdout.String())
+	}
+
+	// The exit code is configurable, and Reset forgets what was recorded
+	mock.SetExitCode(3)
+	mock.Reset()
+	exitCode, err := engine.Run(context.Background(), &ExecutionContext{Command: cmd, Platform: platform.Linux, Parameters: params})
+	if err != nil || exitCode != 3 {
+		t.Errorf("Expected exit code 3, got %d (%v)", exitCode, err)
+	}
+	if len(mock.Commands()) != 1 {
+		t.Errorf("Expected only the command since Reset, got %q", mock.Commands())
+	}
+
+	// Check and cleanup steps are recorded with the secret masked too
+	mock.SetExitCode(0)
+	mock.Reset()
+	transcript.Reset()
+	step := &config.Command{Name: cmd.Name, BaseCommand: cmd.BaseCommand, Parameters: cmd.Parameters, Platforms: cmd.Platforms,
+		SkipIf: "test -f {{.params.token}}", Checks: []config.Check{{Run: "curl -H {{.params.token}} check"}}, Always: []string{"logout {{.params.token}}"}}
+	if _, err := engine.Run(context.Background(), &ExecutionContext{Command: step, Platform: platform.Linux, Parameters: params, Stdout: &stdout}); err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+	if strings.Contains(transcript.String(), "s3cr3t") {
+		t.Errorf("Expected no secret in the transcript, got:\n%s", transcript.String())
+	}
+	if commands := mock.Commands(); len(commands) != 3 || commands[0] != "curl -H "+maskedValue+" check" {
+		t.Errorf("Expected the check, command and step with the secret masked, got %q", commands)
+	}
+	// The skip_if: check is recorded but not run, since it would always pass
+	if checks := mock.SkipChecks(); len(checks) != 1 || checks[0].Command != "test -f "+maskedValue {
+		t.Errorf("Expected the skip_if check to be recorded, got %+v", checks)
+	}
+	if !strings.Contains(transcript.String(), "skip_if not run, assuming not done: test -f "+maskedValue) {
+		t.Errorf("Expected the skip_if check in the transcript, got:\n%s", transcript.String())
+	}
+}
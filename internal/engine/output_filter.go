@@ -0,0 +1,132 @@
+package engine
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// Tools print the same information differently on each platform. A platform
+// entry's output_filter: is a template applied to each line of the command's
+// stdout as it is printed, so the output can be normalised, e.g. making
+// `dir` look like `ls -l`. The template sees the line as {{.line}} along with
+// the data command templates see; lines that render to nothing are dropped.
+
+// newOutputFilter prepares a platform entry's output_filter for a run, or returns nil without one
+// The template is parsed before the command starts, so mistakes in it are
+// reported without running anything.
+func (e *Engine) newOutputFilter(ctx *ExecutionContext, cmd *config.Command, platformCmd *config.PlatformCommand, target io.Writer) (*outputFilter, error) {
+	if platformCmd.OutputFilter == "" {
+		return nil, nil
+	}
+	tmpl, err := e.parseTemplate("output_filter", platformCmd.OutputFilter)
+	if err != nil {
+		return nil, fmt.Errorf("output_filter: %w", err)
+	}
+	data, err := e.commandData(cmd, ctx.Platform.String(), ctx.Parameters, ctx.revealedSet())
+	if err != nil {
+		return nil, err
+	}
+	return &outputFilter{template: tmpl, data: data, target: target}, nil
+}
+
+// outputFilter rewrites what is written to it line by line
+type outputFilter struct {
+	mu       sync.Mutex
+	template *template.Template
+	data     map[string]interface{}
+	target   io.Writer
+	// partial is the start of a line whose end has not been written yet
+	partial []byte
+	// err is the first failure to render a line; later lines pass through unchanged
+	err error
+}
+
+// Write filters every complete line and keeps the rest for later
+// It never fails: stopping would leave the command blocked on a full pipe.
+func (f *outputFilter) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	data := append(f.partial, p...)
+	for {
+		end := bytes.IndexByte(data, '\n')
+		if end < 0 {
+			break
+		}
+		f.writeLine(string(data[:end]))
+		data = data[end+1:]
+	}
+	f.partial = append([]byte(nil), data...)
+	return len(p), nil
+}
+
+// Flush filters an unfinished last line and reports any line that could not be rendered
+func (f *outputFilter) Flush() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.partial) > 0 {
+		f.writeLine(string(f.partial))
+		f.partial = nil
+	}
+	err := f.err
+	f.err = nil
+	return err
+}
+
+// writeLine renders one line through the template; the caller holds the lock
+func (f *outputFilter) writeLine(line string) {
+	line = strings.TrimSuffix(line, "\r")
+	if f.err != nil {
+		fmt.Fprintln(f.target, line)
+		return
+	}
+
+	f.data["line"] = line
+	var buf bytes.Buffer
+	if err := f.template.Execute(&buf, f.data); err != nil {
+		f.err = fmt.Errorf("output_filter: %w", err)
+		fmt.Fprintln(f.target, line)
+		return
+	}
+	if buf.Len() > 0 {
+		fmt.Fprintln(f.target, strings.TrimSuffix(buf.String(), "\n"))
+	}
+}
+
+// fields splits text around runs of whitespace, e.g. {{index (fields .line) 4}}
+func fields(text string) []string {
+	return strings.Fields(text)
+}
+
+// regexReplace replaces matches of pattern in text, expanding $1 in the
+// replacement, e.g. {{.line | regexReplace "^(\\S+)\\s+" "$1 "}}
+func regexReplace(pattern, replacement, text string) (string, error) {
+	re, err := compileCachedRegexp(pattern)
+	if err != nil {
+		return "", err
+	}
+	return re.ReplaceAllString(text, replacement), nil
+}
+
+// compiledRegexps caches patterns, since filters use the same ones for every line
+var compiledRegexps sync.Map
+
+// compileCachedRegexp compiles a pattern once
+func compileCachedRegexp(pattern string) (*regexp.Regexp, error) {
+	if cached, ok := compiledRegexps.Load(pattern); ok {
+		return cached.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	compiledRegexps.Store(pattern, re)
+	return re, nil
+}
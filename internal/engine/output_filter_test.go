@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// TestEngine_Execute_OutputFilter tests rewriting stdout line by line
+func TestEngine_Execute_OutputFilter(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+	filtered := func(filter string) *config.Command {
+		entry := config.PlatformCommand{
+			Template:     `printf 'a.txt  10\r\nskip  0\nb.txt  20'`,
+			OutputFilter: filter,
+		}
+		return &config.Command{
+			Name:        "list",
+			BaseCommand: "printf",
+			Parameters:  []config.Parameter{{Name: "unit", Type: "string"}},
+			Platforms:   map[string]config.PlatformCommand{"linux": entry, "darwin": entry},
+		}
+	}
+	engine := NewEngine(5 * time.Second)
+	current := platform.SupportedPlatform(runtime.GOOS)
+	run := func(cmd *config.Command) (string, error) {
+		var stdout bytes.Buffer
+		_, err := engine.Execute(context.Background(), &ExecutionContext{Command: cmd, Platform: current, Parameters: map[string]interface{}{"unit": "KB"}, Stdout: &stdout})
+		return stdout.String(), err
+	}
+
+	// Lines are taken apart, rewritten with the parameters, and dropped when empty;
+	// CRLF endings and an unfinished last line are handled
+	output, err := run(filtered(`{{$f := fields .line}}{{if ne (index $f 0) "skip"}}{{index $f 1}}{{.params.unit}} {{index $f 0 | regexReplace "\\.txt$" ""}}{{end}}`))
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if expected := "10KB a\n20KB b\n"; output != expected {
+		t.Errorf("Expected %q, got %q", expected, output)
+	}
+
+	// A filter that cannot be parsed stops the command before it runs
+	if _, err := run(filtered(`{{.line`)); err == nil || !strings.Contains(err.Error(), "output_filter") {
+		t.Errorf("Expected a parse error, got %v", err)
+	}
+
+	// A line that cannot be rendered passes through, and the failure is reported
+	output, err = run(filtered(`{{index (fields .line) 5}}`))
+	if err == nil || !strings.Contains(output, "b.txt  20") {
+		t.Errorf("Expected the unfiltered output and an error, got %q (%v)", output, err)
+	}
+}
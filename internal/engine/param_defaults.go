@@ -0,0 +1,70 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// applyDefaultTemplates sets parameters that were not given to their templated defaults
+// Defaults are rendered in dependency order (see config.Command.DefaultOrder),
+// so one can use another's computed value. They see the parameters as
+// .params, the environment as .env, e.g. "{{.env.HOME}}/backups", and the
+// globals for the current platform as .globals, and the result is converted
+// to the parameter's type.
+func (e *Engine) applyDefaultTemplates(cmd *config.Command, params map[string]interface{}) error {
+	order, err := cmd.DefaultOrder()
+	if err != nil {
+		return err
+	}
+	if len(order) == 0 {
+		return nil
+	}
+
+	current, _ := e.platformDetector.Current()
+	globals, err := e.globalData(current.String(), nil)
+	if err != nil {
+		return err
+	}
+	data := map[string]interface{}{
+		"params":  params,
+		"env":     e.environmentData(),
+		"globals": globals,
+	}
+	for _, name := range order {
+		if _, given := params[name]; given {
+			continue
+		}
+		param, _ := cmd.FindParameter(name)
+		text, _ := param.DefaultTemplate()
+		rendered, err := e.renderString("default of "+name, text, data, nil)
+		if err != nil {
+			return fmt.Errorf("parameter '%s': default: %w", name, err)
+		}
+		value, err := e.convertArgument(rendered, param.Type)
+		if err != nil {
+			return fmt.Errorf("parameter '%s': default %q: %w", name, rendered, err)
+		}
+		params[name] = value
+	}
+	return nil
+}
+
+// environmentData returns the environment as a map for templates, with the
+// selected profile's env (see profiles.go)
+func (e *Engine) environmentData() map[string]string {
+	env := make(map[string]string)
+	for _, entry := range os.Environ() {
+		if name, value, found := strings.Cut(entry, "="); found {
+			env[name] = value
+		}
+	}
+	if e.profile != nil {
+		for name, value := range e.profile.Env {
+			env[name] = value
+		}
+	}
+	return env
+}
@@ -0,0 +1,50 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// TestEngine_ParseParameters_DefaultTemplates tests computing defaults from other parameters
+func TestEngine_ParseParameters_DefaultTemplates(t *testing.T) {
+	t.Setenv("GOLDFISH_TEST_BACKUPS", "/var/backups")
+	cmd := &config.Command{
+		Name: "backup",
+		Parameters: []config.Parameter{
+			{Name: "archive", Type: "string", Default: "{{.env.GOLDFISH_TEST_BACKUPS}}/{{.params.backup}}"},
+			{Name: "backup", Type: "string", Default: "{{.params.file}}.bak"},
+			{Name: "file", Type: "string", Required: true},
+			{Name: "copies", Type: "int", Default: "{{len .params.file}}"},
+		},
+	}
+	engine := NewEngine(0)
+
+	params, err := engine.ParseParameters(cmd, nil, map[string]interface{}{"--file": "notes.txt"})
+	if err != nil {
+		t.Fatalf("ParseParameters failed: %v", err)
+	}
+	if params["backup"] != "notes.txt.bak" || params["archive"] != "/var/backups/notes.txt.bak" {
+		t.Errorf("Unexpected computed defaults: %v", params)
+	}
+	// The result has the parameter's type
+	if params["copies"] != 9 {
+		t.Errorf("Expected copies to be the int 9, got %#v", params["copies"])
+	}
+
+	// Values that were given are left alone, and defaults use them
+	params, err = engine.ParseParameters(cmd, nil, map[string]interface{}{"--file": "a", "--backup": "b"})
+	if err != nil {
+		t.Fatalf("ParseParameters failed: %v", err)
+	}
+	if params["backup"] != "b" || params["archive"] != "/var/backups/b" {
+		t.Errorf("Expected defaults to build on the given backup, got %v", params)
+	}
+
+	// A default that does not convert to the type is an error
+	cmd.Parameters[3].Default = "{{.params.file}}"
+	if _, err := engine.ParseParameters(cmd, nil, map[string]interface{}{"--file": "a"}); err == nil || !strings.Contains(err.Error(), "parameter 'copies'") {
+		t.Errorf("Expected a conversion error, got %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Paths given as parameters are written the way the user's shell writes them,
+// which is not always the way the command being run expects them: Windows
+// tools want C:\Users\me, WSL and Git tools want /mnt/c/Users/me or
+// C:/Users/me. These template helpers convert between the conventions, e.g.
+// {{winPath .params.file}}.
+
+// windowsDrivePath matches a path starting with a drive letter: C:\ or C:/
+var windowsDrivePath = regexp.MustCompile(`^([A-Za-z]):(?:[\\/]|$)`)
+
+// wslMountPath matches the path WSL mounts a Windows drive at: /mnt/c/...
+var wslMountPath = regexp.MustCompile(`^/mnt/([A-Za-z])(?:/|$)`)
+
+// wslSharePath matches the share Windows sees WSL's files through:
+// \\wsl$\Ubuntu\... or \\wsl.localhost\Ubuntu\...
+var wslSharePath = regexp.MustCompile(`^(?i)[\\/]{2}wsl(?:\$|\.localhost)[\\/][^\\/]+`)
+
+// pathFuncs returns the path conversion template helpers
+func pathFuncs() map[string]interface{} {
+	return map[string]interface{}{
+		"toSlash": toSlash,
+		// fromSlash uses the separator of the system goldfish runs on
+		"fromSlash": filepath.FromSlash,
+		"winPath":   winPath,
+		"wslPath":   wslPath,
+	}
+}
+
+// toSlash replaces backslashes with forward slashes, on every platform
+// (unlike filepath.ToSlash, which only does so on Windows): C:\a\b becomes C:/a/b
+func toSlash(path string) string {
+	return strings.ReplaceAll(path, `\`, "/")
+}
+
+// winPath converts a path to Windows form: forward slashes become
+// backslashes, and WSL drive mounts become drive letters (/mnt/c/a becomes C:\a)
+func winPath(path string) string {
+	if match := wslMountPath.FindStringSubmatch(path); match != nil {
+		rest := strings.TrimPrefix(path[len(match[0]):], "/")
+		return strings.ToUpper(match[1]) + `:\` + strings.ReplaceAll(rest, "/", `\`)
+	}
+	return strings.ReplaceAll(path, "/", `\`)
+}
+
+// wslPath converts a path to the form used inside WSL: drive letters become
+// mounts (C:\a becomes /mnt/c/a), paths on the \\wsl$ share become the Linux
+// paths they are, and backslashes become forward slashes
+func wslPath(path string) string {
+	if match := windowsDrivePath.FindStringSubmatch(path); match != nil {
+		rest := strings.TrimLeft(toSlash(path[2:]), "/")
+		if rest == "" {
+			return "/mnt/" + strings.ToLower(match[1])
+		}
+		return "/mnt/" + strings.ToLower(match[1]) + "/" + rest
+	}
+	if match := wslSharePath.FindString(path); match != "" {
+		rest := toSlash(path[len(match):])
+		if rest == "" {
+			return "/"
+		}
+		return rest
+	}
+	return toSlash(path)
+}
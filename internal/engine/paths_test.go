@@ -0,0 +1,84 @@
+package engine
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// TestToSlash tests converting backslashes on every platform
+func TestToSlash(t *testing.T) {
+	testCases := map[string]string{
+		`C:\Users\me\file.txt`: "C:/Users/me/file.txt",
+		"/already/unix":        "/already/unix",
+		`relative\dir`:         "relative/dir",
+	}
+	for path, expected := range testCases {
+		if got := toSlash(path); got != expected {
+			t.Errorf("toSlash(%q) = %q; expected %q", path, got, expected)
+		}
+	}
+}
+
+// TestWinPath tests converting paths to Windows form
+func TestWinPath(t *testing.T) {
+	testCases := map[string]string{
+		"/mnt/c/Users/me/file.txt": `C:\Users\me\file.txt`,
+		"/mnt/d":                   `D:\`,
+		"/mnt/data/file":           `\mnt\data\file`,
+		"C:/Users/me":              `C:\Users\me`,
+		"relative/dir":             `relative\dir`,
+		`C:\already\windows`:       `C:\already\windows`,
+	}
+	for path, expected := range testCases {
+		if got := winPath(path); got != expected {
+			t.Errorf("winPath(%q) = %q; expected %q", path, got, expected)
+		}
+	}
+}
+
+// TestWslPath tests converting paths to the form used inside WSL
+func TestWslPath(t *testing.T) {
+	testCases := map[string]string{
+		`C:\Users\me\file.txt`:                   "/mnt/c/Users/me/file.txt",
+		"D:/projects":                            "/mnt/d/projects",
+		"E:":                                     "/mnt/e",
+		`C:\`:                                    "/mnt/c",
+		`\\wsl$\Ubuntu\home\me`:                  "/home/me",
+		`\\wsl.localhost\Ubuntu-22.04\etc\hosts`: "/etc/hosts",
+		`\\wsl$\Ubuntu`:                          "/",
+		"/home/me":                               "/home/me",
+		`relative\dir`:                           "relative/dir",
+	}
+	for path, expected := range testCases {
+		if got := wslPath(path); got != expected {
+			t.Errorf("wslPath(%q) = %q; expected %q", path, got, expected)
+		}
+	}
+}
+
+// TestEngine_renderTemplate_PathFuncs tests using the path helpers in templates
+func TestEngine_renderTemplate_PathFuncs(t *testing.T) {
+	engine := NewEngine(time.Second)
+	cmd := &config.Command{Name: "copy", BaseCommand: "cp"}
+	platformCmd := &config.PlatformCommand{
+		Template: "{{winPath .params.src}} {{wslPath .params.src}} {{toSlash .params.dst}} {{fromSlash (toSlash .params.dst)}}",
+	}
+	params := map[string]interface{}{"src": "/mnt/c/src", "dst": `out\dir`}
+
+	rendered, err := engine.renderTemplate(cmd, "windows", platformCmd, params, nil)
+	if err != nil {
+		t.Fatalf("renderTemplate() failed: %v", err)
+	}
+	// fromSlash uses the separator of the system goldfish runs on, and
+	// values with backslashes are quoted for cmd
+	expected := `"C:\src" /mnt/c/src out/dir ` + filepath.FromSlash("out/dir")
+	if filepath.Separator == '\\' {
+		expected = `"C:\src" /mnt/c/src out/dir "out\dir"`
+	}
+	if rendered != expected {
+		t.Errorf("Expected %q, got %q", expected, rendered)
+	}
+}
@@ -0,0 +1,233 @@
+package engine
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// A command's platforms: map is keyed by operating system ("linux"), and a key
+// may narrow that to a distribution or libc ("linux-alpine", "linux-debian",
+// "linux-musl") or to the flavor of the command's base_command ("darwin-gnu",
+// "linux-busybox"). The most specific key present for the running system
+// wins, so a command only needs variants where the plain template does not work.
+// Package management commands can instead be keyed by the package manager
+// installed ("pm-apt", "pm-brew"), which is checked before everything else
+// but flavors.
+
+// Selection is the template a command uses on a platform
+type Selection struct {
+	// Key is the platforms: entry that was chosen, e.g. "linux-alpine"
+	Key string
+	// Command is that entry
+	Command config.PlatformCommand
+	// BaseCommand is the binary templates see as .base_command: normally the
+	// command's base_command, but a "-gnu" template gets the GNU build that
+	// was found, which may be named differently (gsed on macOS), and a "pm-"
+	// template gets the package manager's program (apt-get for pm-apt)
+	BaseCommand string
+	// Fallback is the platform whose template is used because the command has
+	// none for the platform asked for (see SetFallbackPlatforms), or empty
+	Fallback platform.SupportedPlatform
+}
+
+// fallbackPlatforms maps a platform to the platforms whose templates commands
+// without one of their own may use, in order of preference
+var fallbackPlatforms struct {
+	sync.RWMutex
+	byPlatform map[string][]string
+}
+
+// SetFallbackPlatforms sets which other platforms' templates commands may use
+// when they have none for a platform, e.g. {"darwin": {"linux"}}
+// It is set from fallback_platforms in the configuration, and is off (nil)
+// by default: another platform's command line only sometimes works.
+func SetFallbackPlatforms(fallbacks map[string][]string) {
+	fallbackPlatforms.Lock()
+	defer fallbackPlatforms.Unlock()
+	fallbackPlatforms.byPlatform = fallbacks
+}
+
+// fallbacksFor returns the platforms p may fall back to
+func fallbacksFor(p platform.SupportedPlatform) []string {
+	fallbackPlatforms.RLock()
+	defer fallbackPlatforms.RUnlock()
+	return fallbackPlatforms.byPlatform[p.String()]
+}
+
+// SelectPlatform returns the template a command uses on platform p
+// Flavor keys are checked first, then package manager keys, then architecture
+// and distribution keys and finally p itself. Within an entry the first variant whose arch and version
+// conditions hold is used, then the entry itself; when none hold the next key
+// is tried. Tools are only probed for commands that have flavor keys, and
+// only when p is the platform goldfish runs on. When nothing matches, the
+// platforms p falls back to are tried (see SetFallbackPlatforms).
+// when: conditions need the parameters, so they are assumed to hold here;
+// the engine checks them when it runs or previews a command (see when.go).
+func SelectPlatform(cmd *config.Command, p platform.SupportedPlatform) (*Selection, bool) {
+	return selectPlatform(cmd, p, nil)
+}
+
+// selectPlatform is SelectPlatform with when: conditions checked by when
+func selectPlatform(cmd *config.Command, p platform.SupportedPlatform, when func(string) bool) (*Selection, bool) {
+	detector := platform.NewDetector()
+	system := system{arch: detector.ArchFor(p), version: detector.VersionFor(p), when: when}
+
+	if hasFlavorKeys(cmd, p) && detector.IsCurrent(p) {
+		if tool := detector.Tool(cmd.BaseCommand); tool.Flavor != "" {
+			flavorKey := p.String() + "-" + tool.Flavor
+			if key, platformCmd, found := system.firstMatch(cmd, []string{flavorKey + "/" + system.arch, flavorKey}); found {
+				return &Selection{Key: key, Command: platformCmd, BaseCommand: tool.Binary}, true
+			}
+		}
+	}
+
+	if manager, key, platformCmd, found := system.packageManagerMatch(cmd, p); found {
+		return &Selection{Key: key, Command: platformCmd, BaseCommand: manager.Binary}, true
+	}
+
+	if key, platformCmd, found := system.firstMatch(cmd, detector.TemplateKeys(p)); found {
+		return &Selection{Key: key, Command: platformCmd, BaseCommand: cmd.BaseCommand}, true
+	}
+
+	if fallback, key, platformCmd, found := system.fallbackMatch(cmd, p); found {
+		return &Selection{Key: key, Command: platformCmd, BaseCommand: cmd.BaseCommand, Fallback: fallback}, true
+	}
+	return nil, false
+}
+
+// system is what template conditions are checked against
+// Empty fields are unknown (the platform is not the one goldfish runs on),
+// and conditions on them always hold.
+type system struct {
+	arch    string
+	version string
+	// when checks when: conditions; nil assumes they hold
+	when func(string) bool
+}
+
+// firstMatch returns the first of keys the command has a template for that suits the system
+func (s system) firstMatch(cmd *config.Command, keys []string) (string, config.PlatformCommand, bool) {
+	for _, key := range keys {
+		platformCmd, exists := cmd.Platforms[key]
+		if !exists {
+			continue
+		}
+		if resolved, found := platformCmd.ResolveWhen(s.arch, s.version, s.when); found {
+			return key, resolved, true
+		}
+	}
+	return "", config.PlatformCommand{}, false
+}
+
+// packageManagerMatch returns the template for the package manager installed
+// Package managers are only looked up for commands with pm- keys, and only
+// when p is the platform goldfish runs on.
+func (s system) packageManagerMatch(cmd *config.Command, p platform.SupportedPlatform) (platform.PackageManager, string, config.PlatformCommand, bool) {
+	if !hasPackageManagerKeys(cmd) {
+		return platform.PackageManager{}, "", config.PlatformCommand{}, false
+	}
+	manager := platform.NewDetector().PackageManagerFor(p)
+	if manager.Name == "" {
+		return platform.PackageManager{}, "", config.PlatformCommand{}, false
+	}
+	key := platform.PackageManagerKeyPrefix + manager.Name
+	if matched, platformCmd, found := s.firstMatch(cmd, []string{key + "/" + s.arch, key}); found {
+		return manager, matched, platformCmd, true
+	}
+	return platform.PackageManager{}, "", config.PlatformCommand{}, false
+}
+
+// hasPackageManagerKeys reports whether a command has package manager templates
+func hasPackageManagerKeys(cmd *config.Command) bool {
+	for key := range cmd.Platforms {
+		if strings.HasPrefix(key, platform.PackageManagerKeyPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// fallbackMatch returns the first template of the platforms p falls back to
+// Version conditions are ignored there: another OS's release numbers say
+// nothing about the system's.
+func (s system) fallbackMatch(cmd *config.Command, p platform.SupportedPlatform) (platform.SupportedPlatform, string, config.PlatformCommand, bool) {
+	detector := platform.NewDetector()
+	withoutVersion := system{arch: s.arch, when: s.when}
+	for _, name := range fallbacksFor(p) {
+		fallback := platform.SupportedPlatform(name)
+		if key, platformCmd, found := withoutVersion.firstMatch(cmd, detector.TemplateKeys(fallback)); found {
+			return fallback, key, platformCmd, true
+		}
+	}
+	return "", "", config.PlatformCommand{}, false
+}
+
+// command returns cmd as templates should see it, with the selected binary as
+// its base_command; cmd itself is not modified
+func (s *Selection) command(cmd *config.Command) *config.Command {
+	if s.BaseCommand == cmd.BaseCommand {
+		return cmd
+	}
+	selected := *cmd
+	selected.BaseCommand = s.BaseCommand
+	return &selected
+}
+
+// hasFlavorKeys reports whether a command has flavor-specific templates for p
+func hasFlavorKeys(cmd *config.Command, p platform.SupportedPlatform) bool {
+	for key := range cmd.Platforms {
+		suffix, found := strings.CutPrefix(key, p.String()+"-")
+		if !found {
+			continue
+		}
+		// darwin-gnu/arm64 is a flavor key too
+		suffix, _, _ = strings.Cut(suffix, "/")
+		for _, flavor := range platform.Flavors {
+			if suffix == flavor {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Supports reports whether a command has a template for platform p
+// A command with only flavor templates counts as supported without probing
+// its tool, which keeps listing commands fast; running it on a system with
+// another flavor then fails with an UnsupportedPlatformError.
+func Supports(cmd *config.Command, p platform.SupportedPlatform) bool {
+	detector := platform.NewDetector()
+	system := system{arch: detector.ArchFor(p), version: detector.VersionFor(p)}
+	if _, _, found := system.firstMatch(cmd, detector.TemplateKeys(p)); found {
+		return true
+	}
+	if hasFlavorKeys(cmd, p) && detector.IsCurrent(p) {
+		return true
+	}
+	if _, _, _, found := system.packageManagerMatch(cmd, p); found {
+		return true
+	}
+	_, _, _, found := system.fallbackMatch(cmd, p)
+	return found
+}
+
+// platformData describes the platform to templates as .platform
+// For example {{if eq .platform.distro "alpine"}} or {{.platform.arch}}; the
+// distribution fields are empty except on Linux, and like arch they are only
+// known for the platform goldfish runs on.
+func platformData(p platform.SupportedPlatform) map[string]string {
+	distro := platform.NewDetector().DistroFor(p)
+	return map[string]string{
+		"os":        p.String(),
+		"distro":    distro.Family,
+		"distro_id": distro.ID,
+		"libc":      distro.Libc,
+		"arch":      platform.NewDetector().ArchFor(p),
+		"version":   platform.NewDetector().VersionFor(p),
+		// e.g. {{if eq .platform.package_manager "apt"}}
+		"package_manager": platform.NewDetector().PackageManagerFor(p).Name,
+	}
+}
@@ -0,0 +1,292 @@
+package engine
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// TestSelectPlatform tests that distribution-specific templates take precedence
+func TestSelectPlatform(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("distribution templates only apply on Linux")
+	}
+	distro := platform.NewDetector().Distro()
+	if distro.Family == "" {
+		t.Skip("no os-release on this system")
+	}
+
+	cmd := &config.Command{
+		Name: "list",
+		Platforms: map[string]config.PlatformCommand{
+			"linux":                  {Template: "ls --color=never"},
+			"linux-" + distro.Family: {Template: "ls"},
+			"linux-nosuchdistro":     {Template: "dir"},
+		},
+	}
+	selection, found := SelectPlatform(cmd, platform.Linux)
+	if !found || selection.Key != "linux-"+distro.Family || selection.Command.Template != "ls" {
+		t.Errorf("Expected the %s template, got %+v", distro.Family, selection)
+	}
+
+	// Other distributions' templates are ignored, and the plain key is the fallback
+	delete(cmd.Platforms, "linux-"+distro.Family)
+	if selection, _ := SelectPlatform(cmd, platform.Linux); selection.Key != "linux" {
+		t.Errorf("Expected the plain linux template, got %q", selection.Key)
+	}
+
+	// A command with only another distribution's template is unsupported here
+	delete(cmd.Platforms, "linux")
+	if Supports(cmd, platform.Linux) {
+		t.Error("Expected a command for another distribution to be unsupported")
+	}
+}
+
+// TestEngine_PlatformData tests that templates can read the platform
+func TestEngine_PlatformData(t *testing.T) {
+	engine := NewEngine(0)
+	cmd := &config.Command{
+		Name:      "where",
+		Platforms: map[string]config.PlatformCommand{"windows": {Template: "echo {{.platform.os}}-{{.platform.distro}}"}},
+	}
+	rendered, err := engine.Preview(&ExecutionContext{Command: cmd, Platform: platform.Windows, Parameters: map[string]interface{}{}})
+	if err != nil {
+		t.Fatalf("Preview failed: %v", err)
+	}
+	// Windows has no distribution (and is not the platform the tests run on)
+	if rendered != "echo windows-" {
+		t.Errorf("Unexpected command line: %q", rendered)
+	}
+}
+
+// TestSelectPlatform_Flavor tests choosing a template by the flavor of base_command
+func TestSelectPlatform_Flavor(t *testing.T) {
+	current, err := platform.NewDetector().Current()
+	if err != nil || runtime.GOOS == "windows" {
+		t.Skip("uses shell scripts as fake tools")
+	}
+	dir := t.TempDir()
+	t.Setenv("PATH", dir)
+	// A BSD tool with the GNU build installed beside it, as Homebrew does
+	os.WriteFile(filepath.Join(dir, "flavortest-sed"), []byte("#!/bin/sh\necho 'illegal option'\n"), 0755)
+	os.WriteFile(filepath.Join(dir, "gflavortest-sed"), []byte("#!/bin/sh\necho 'sed (GNU sed) 4.9'\n"), 0755)
+
+	cmd := &config.Command{
+		Name:        "replace",
+		BaseCommand: "flavortest-sed",
+		Platforms: map[string]config.PlatformCommand{
+			current.String():          {Template: "{{.base_command}} -i ''"},
+			current.String() + "-gnu": {Template: "{{.base_command}} -i"},
+		},
+	}
+	rendered, err := NewEngine(0).Preview(&ExecutionContext{Command: cmd, Platform: current, Parameters: map[string]interface{}{}})
+	if err != nil {
+		t.Fatalf("Preview failed: %v", err)
+	}
+	if rendered != "gflavortest-sed -i" {
+		t.Errorf("Expected the GNU template and binary, got %q", rendered)
+	}
+	if cmd.BaseCommand != "flavortest-sed" {
+		t.Error("Expected the command definition to be left unchanged")
+	}
+
+	// Only flavor templates still count as supported, without probing
+	gnuOnly := &config.Command{Name: "gnu-only", BaseCommand: "unprobed", Platforms: map[string]config.PlatformCommand{current.String() + "-gnu": {}}}
+	if !Supports(gnuOnly, current) {
+		t.Error("Expected a command with only flavor templates to be supported")
+	}
+}
+
+// TestSelectPlatform_Arch tests architecture keys and arch: conditions
+func TestSelectPlatform_Arch(t *testing.T) {
+	current, err := platform.NewDetector().Current()
+	if err != nil {
+		t.Skip("unsupported test platform")
+	}
+	arch := runtime.GOARCH
+	other := "arm64"
+	if arch == other {
+		other = "amd64"
+	}
+
+	cmd := &config.Command{
+		Name: "download",
+		Platforms: map[string]config.PlatformCommand{
+			current.String():              {Template: "fetch generic"},
+			current.String() + "/" + arch: {Template: "fetch " + arch},
+		},
+	}
+	if selection, _ := SelectPlatform(cmd, current); selection.Key != current.String()+"/"+arch {
+		t.Errorf("Expected the %s template, got %q", arch, selection.Key)
+	}
+
+	// A template limited to another architecture is skipped
+	delete(cmd.Platforms, current.String()+"/"+arch)
+	cmd.Platforms[current.String()] = config.PlatformCommand{Template: "fetch", Arch: []string{other}}
+	if Supports(cmd, current) {
+		t.Errorf("Expected a template for %s only to be unsupported on %s", other, arch)
+	}
+	cmd.Platforms[current.String()] = config.PlatformCommand{Template: "fetch {{.platform.arch}}", Arch: []string{other, arch}}
+	rendered, err := NewEngine(0).Preview(&ExecutionContext{Command: cmd, Platform: current, Parameters: map[string]interface{}{}})
+	if err != nil || rendered != "fetch "+arch {
+		t.Errorf("Expected 'fetch %s', got %q (%v)", arch, rendered, err)
+	}
+}
+
+// TestSelectPlatform_Version tests falling back when the OS release is out of range
+func TestSelectPlatform_Version(t *testing.T) {
+	detector := platform.NewDetector()
+	current, err := detector.Current()
+	if err != nil {
+		t.Skip("unsupported test platform")
+	}
+	if _, ok := platform.ParseVersion(detector.Version()); !ok {
+		t.Skip("OS version is not known on this system")
+	}
+
+	cmd := &config.Command{
+		Name: "versioned",
+		Platforms: map[string]config.PlatformCommand{
+			current.String(): {
+				Template: "echo {{.platform.version}}",
+				Variants: []config.PlatformCommand{{Template: "echo future", MinVersion: "9999"}},
+			},
+		},
+	}
+	rendered, err := NewEngine(0).Preview(&ExecutionContext{Command: cmd, Platform: current, Parameters: map[string]interface{}{}})
+	if err != nil || rendered != "echo "+detector.Version() {
+		t.Errorf("Expected the template for this release, got %q (%v)", rendered, err)
+	}
+
+	// A command only for future releases is not supported yet
+	cmd.Platforms[current.String()] = config.PlatformCommand{Template: "echo future", MinVersion: "9999"}
+	if Supports(cmd, current) {
+		t.Error("Expected a command for future releases to be unsupported")
+	}
+}
+
+// TestSelectPlatform_Groups tests that exact keys beat group keys, which beat catch-alls
+func TestSelectPlatform_Groups(t *testing.T) {
+	cmd := &config.Command{
+		Name: "list",
+		Platforms: map[string]config.PlatformCommand{
+			"darwin":  {Template: "ls -G"},
+			"posix":   {Template: "ls"},
+			"default": {Template: "dir"},
+		},
+	}
+
+	testCases := []struct {
+		platform platform.SupportedPlatform
+		key      string
+	}{
+		{platform.Darwin, "darwin"},
+		{platform.Linux, "posix"},
+		{platform.SupportedPlatform("openbsd"), "posix"},
+		{platform.Windows, "default"},
+	}
+	for _, tc := range testCases {
+		selection, found := SelectPlatform(cmd, tc.platform)
+		if !found || selection.Key != tc.key {
+			t.Errorf("%s: expected the %s template, got %+v", tc.platform, tc.key, selection)
+		}
+	}
+
+	// Without a catch-all, Windows has no template
+	delete(cmd.Platforms, "default")
+	if Supports(cmd, platform.Windows) {
+		t.Error("Expected a unix-only command to be unsupported on Windows")
+	}
+}
+
+// TestSelectPlatform_Fallback tests using another platform's template when allowed
+func TestSelectPlatform_Fallback(t *testing.T) {
+	defer SetFallbackPlatforms(nil)
+
+	cmd := &config.Command{
+		Name:        "count-lines",
+		BaseCommand: "wc",
+		Platforms:   map[string]config.PlatformCommand{"linux": {Template: "wc -l"}},
+	}
+
+	// Fallbacks are off by default
+	if Supports(cmd, platform.Darwin) {
+		t.Fatal("Expected a linux-only command to be unsupported on darwin")
+	}
+
+	SetFallbackPlatforms(map[string][]string{"darwin": {"windows", "linux"}})
+	selection, found := SelectPlatform(cmd, platform.Darwin)
+	if !found || selection.Key != "linux" || selection.Fallback != platform.Linux {
+		t.Errorf("Expected the linux template as a fallback, got %+v", selection)
+	}
+
+	// A template of the platform's own is always preferred
+	cmd.Platforms["darwin"] = config.PlatformCommand{Template: "wc -l"}
+	if selection, _ := SelectPlatform(cmd, platform.Darwin); selection.Fallback != "" {
+		t.Errorf("Expected darwin's own template, got %+v", selection)
+	}
+
+	// Running with a fallback warns on stderr
+	if runtime.GOOS == "windows" {
+		return
+	}
+	current, err := platform.NewDetector().Current()
+	if err != nil {
+		t.Skip("unsupported test platform")
+	}
+	other := platform.Darwin
+	if current == platform.Darwin {
+		other = platform.Linux
+	}
+	SetFallbackPlatforms(map[string][]string{current.String(): {other.String()}})
+	cmd.Platforms = map[string]config.PlatformCommand{other.String(): {Template: "true"}}
+	var stderr strings.Builder
+	ctx := &ExecutionContext{Command: cmd, Platform: current, Parameters: map[string]interface{}{}, Stdout: io.Discard, Stderr: &stderr}
+	if err := NewEngine(5*time.Second).ExecuteStatus(context.Background(), ctx); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if !strings.Contains(stderr.String(), "using the "+other.String()+" one") {
+		t.Errorf("Expected a fallback warning, got %q", stderr.String())
+	}
+}
+
+// TestSelectPlatform_PackageManager tests that pm- keys follow the installed package manager
+func TestSelectPlatform_PackageManager(t *testing.T) {
+	detector := platform.NewDetector()
+	current, err := detector.Current()
+	if err != nil {
+		t.Skip("unsupported test platform")
+	}
+
+	cmd := &config.Command{
+		Name:        "install",
+		BaseCommand: "package-manager",
+		Platforms: map[string]config.PlatformCommand{
+			"pm-nosuchmanager":        {Template: "never"},
+			current.String():          {Template: "fallback"},
+			platform.Windows.String(): {Template: "winget install"},
+		},
+	}
+	manager := detector.PackageManager()
+	if manager.Name != "" {
+		cmd.Platforms["pm-"+manager.Name] = config.PlatformCommand{Template: "{{.base_command}} install"}
+		selection, found := SelectPlatform(cmd, current)
+		if !found || selection.Key != "pm-"+manager.Name || selection.BaseCommand != manager.Binary {
+			t.Errorf("Expected the %s template, got %+v", manager.Name, selection)
+		}
+		delete(cmd.Platforms, "pm-"+manager.Name)
+	}
+
+	// Without a template for the installed manager the platform's own is used
+	if selection, _ := SelectPlatform(cmd, current); selection.Key != current.String() {
+		t.Errorf("Expected the %s template, got %+v", current, selection)
+	}
+}
@@ -0,0 +1,47 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// PluginRequestEnvVar carries the invocation to a plugin that handles execution
+// The plugin is run as "<plugin> --goldfish-exec <command>" with its standard
+// streams connected as for any command; the variable holds a JSON
+// PluginRequest with the parameter values
+const PluginRequestEnvVar = "GOLDFISH_PLUGIN_REQUEST"
+
+// PluginRequest describes one command invocation for a plugin
+type PluginRequest struct {
+	// Command is the name of the plugin's command to run
+	Command string `json:"command"`
+	// Platform is the platform goldfish is running on
+	Platform string `json:"platform"`
+	// Params holds the parsed parameter values by name
+	Params map[string]interface{} `json:"params"`
+}
+
+// executePlugin runs a command through the plugin that provides it
+// The plugin's exit code is the command's exit code
+func (e *Engine) executePlugin(runCtx context.Context, ctx *ExecutionContext, opts processOptions, streams stdio) (int, error) {
+	request, err := json.Marshal(PluginRequest{
+		Command:  ctx.Command.Name,
+		Platform: ctx.Platform.String(),
+		Params:   ctx.Parameters,
+	})
+	if err != nil {
+		return -1, fmt.Errorf("failed to encode plugin request: %w", err)
+	}
+
+	env := append(e.commandEnvironment(ctx.Command), PluginRequestEnvVar+"="+string(request))
+	argv := []string{ctx.Command.Plugin, config.PluginExecFlag, ctx.Command.Name}
+	return e.runProcess(runCtx, argv, pluginCommandLine(ctx.Command), ctx.Timeout, env, opts, streams)
+}
+
+// pluginCommandLine describes a plugin invocation for previews and logs
+func pluginCommandLine(cmd *config.Command) string {
+	return cmd.Plugin + " " + config.PluginExecFlag + " " + cmd.Name
+}
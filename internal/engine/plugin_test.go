@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// TestEngine_Run_Plugin tests that plugin commands run the plugin with the request
+func TestEngine_Run_Plugin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script plugin")
+	}
+
+	// The plugin prints its arguments and the request, then fails with code 5
+	pluginPath := filepath.Join(t.TempDir(), config.PluginPrefix+"test")
+	script := "#!/bin/sh\necho \"$@\"\necho \"$" + PluginRequestEnvVar + "\"\nexit 5\n"
+	if err := os.WriteFile(pluginPath, []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write plugin: %v", err)
+	}
+
+	cmd := &config.Command{
+		Name:       "dock",
+		Plugin:     pluginPath,
+		Parameters: []config.Parameter{{Name: "image", Type: "string"}},
+		Platforms:  map[string]config.PlatformCommand{runtime.GOOS: {Template: "ignored"}},
+	}
+	var stdout bytes.Buffer
+	ctx := &ExecutionContext{
+		Command:    cmd,
+		Platform:   platform.SupportedPlatform(runtime.GOOS),
+		Parameters: map[string]interface{}{"image": "alpine"},
+		Stdout:     &stdout,
+	}
+
+	engine := NewEngine(5 * time.Second)
+	exitCode, err := engine.Run(context.Background(), ctx)
+	if err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+	if exitCode != 5 {
+		t.Errorf("Expected the plugin's exit code 5, got %d", exitCode)
+	}
+
+	lines := strings.SplitN(strings.TrimSpace(stdout.String()), "\n", 2)
+	if len(lines) != 2 || lines[0] != config.PluginExecFlag+" dock" {
+		t.Fatalf("Unexpected plugin output: %q", stdout.String())
+	}
+	var request PluginRequest
+	if err := json.Unmarshal([]byte(lines[1]), &request); err != nil {
+		t.Fatalf("Expected a JSON request, got %q: %v", lines[1], err)
+	}
+	if request.Command != "dock" || request.Params["image"] != "alpine" || request.Platform != runtime.GOOS {
+		t.Errorf("Unexpected request %+v", request)
+	}
+
+	// Previews show the plugin invocation instead of the placeholder template
+	preview, err := engine.Preview(ctx)
+	if err != nil || preview != pluginPath+" "+config.PluginExecFlag+" dock" {
+		t.Errorf("Unexpected preview %q (%v)", preview, err)
+	}
+}
@@ -0,0 +1,74 @@
+package engine
+
+import (
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+const (
+	// DefaultGracePeriod is how long a child gets to exit after goldfish forwards
+	// a termination signal before it is forcibly killed
+	DefaultGracePeriod = 5 * time.Second
+	// waitDelay bounds how long goldfish waits for the child's output pipes to
+	// close after the process tree has been killed
+	waitDelay = 2 * time.Second
+)
+
+// processOptions restrict what a child process may do
+type processOptions struct {
+	// limits lower its priority and cap its resources (see resources_unix.go)
+	limits *config.ResourceLimits
+	// sandbox confines it when set (see sandbox.go)
+	sandbox *config.SandboxPolicy
+}
+
+// terminationSignals are the signals goldfish forwards to its child process
+// syscall.SIGTERM is defined on every platform, including Windows
+var terminationSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+// SetGracePeriod changes how long a child may take to exit after a forwarded signal
+// A value of zero or less restores DefaultGracePeriod
+func (e *Engine) SetGracePeriod(grace time.Duration) {
+	if grace <= 0 {
+		grace = DefaultGracePeriod
+	}
+	e.gracePeriod = grace
+}
+
+// waitForProcess waits for a started command while relaying termination signals
+// The first signal received on signals is forwarded to the child's process group
+// and starts the grace period; if the child is still running when the grace
+// period ends (or a second signal arrives) the whole group is killed.
+// It returns the signal that interrupted the command, or nil if none did.
+func waitForProcess(tree *processTree, signals <-chan os.Signal, grace time.Duration) (os.Signal, error) {
+	// Wait in the background so we can react to signals at the same time
+	done := make(chan error, 1)
+	go func() {
+		done <- tree.cmd.Wait()
+	}()
+
+	var received os.Signal
+	// A nil channel blocks forever, so the kill case is disabled until a signal arrives
+	var killTimer <-chan time.Time
+
+	for {
+		select {
+		case err := <-done:
+			return received, err
+		case sig := <-signals:
+			if received != nil {
+				// A second signal means the user does not want to wait any longer
+				_ = tree.kill()
+				continue
+			}
+			received = sig
+			_ = tree.interrupt(sig)
+			killTimer = time.After(grace)
+		case <-killTimer:
+			_ = tree.kill()
+		}
+	}
+}
@@ -0,0 +1,132 @@
+//go:build unix
+
+package engine
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// foreground guards the terminal's foreground process group, which only one
+// child at a time may have: children run concurrently by batches, workflows
+// and servers would otherwise take it from each other and from goldfish
+var foreground struct {
+	sync.Mutex
+	taken bool
+}
+
+// controllingTerminal reports whether goldfish's stdin is its controlling
+// terminal; tests replace it, since they rarely run on one
+var controllingTerminal = func() bool { return isControllingTerminal(os.Stdin) }
+
+// processTree tracks a child process and everything it spawns
+// On Unix the child leads its own process group, so the whole tree can be
+// signalled at once by addressing the group
+type processTree struct {
+	cmd *exec.Cmd
+	// foreground is true when the group was given the controlling terminal
+	foreground bool
+}
+
+// newProcessTree prepares cmd to start in its own process group
+// When the child reads goldfish's stdin and it is a terminal, the new group
+// is also made the terminal's foreground group so that interactive programs
+// can still read from it and Ctrl-C reaches the whole tree directly; only one
+// child has it at a time, and any other is left in a background group.
+// Resource limits are applied to the command line instead (see
+// limitCommand), and the sandbox wraps the program.
+func newProcessTree(cmd *exec.Cmd, opts processOptions) (*processTree, error) {
+	if err := sandboxCommand(cmd, opts.sandbox); err != nil {
+		return nil, err
+	}
+
+	tree := &processTree{cmd: cmd}
+	attr := &syscall.SysProcAttr{Setpgid: true}
+
+	if takeForeground(cmd) {
+		attr.Foreground = true
+		attr.Ctty = int(os.Stdin.Fd())
+		tree.foreground = true
+	}
+
+	cmd.SysProcAttr = attr
+	return tree, nil
+}
+
+// started is called once the child is running; Unix needs no extra setup
+func (t *processTree) started() error {
+	return nil
+}
+
+// interrupt forwards a termination signal to the child's process group
+func (t *processTree) interrupt(sig os.Signal) error {
+	unixSignal, ok := sig.(syscall.Signal)
+	if !ok {
+		unixSignal = syscall.SIGTERM
+	}
+	// A negative pid addresses every process in the group
+	return syscall.Kill(-t.cmd.Process.Pid, unixSignal)
+}
+
+// kill forcibly terminates the child and everything it spawned
+func (t *processTree) kill() error {
+	return syscall.Kill(-t.cmd.Process.Pid, syscall.SIGKILL)
+}
+
+// takeForeground reports whether cmd's group is to be the terminal's
+// foreground group, reserving it when it is free
+func takeForeground(cmd *exec.Cmd) bool {
+	if cmd.Stdin != os.Stdin {
+		return false
+	}
+	foreground.Lock()
+	defer foreground.Unlock()
+	if foreground.taken || !controllingTerminal() {
+		return false
+	}
+	foreground.taken = true
+	return true
+}
+
+// release gives the terminal back to goldfish once the child has exited
+func (t *processTree) release() {
+	if !t.foreground {
+		return
+	}
+	t.foreground = false
+	defer func() {
+		foreground.Lock()
+		foreground.taken = false
+		foreground.Unlock()
+	}()
+	// A background process changing the foreground group receives SIGTTOU,
+	// so ignore it while doing so
+	signal.Ignore(syscall.SIGTTOU)
+	defer signal.Reset(syscall.SIGTTOU)
+	pgrp := int32(syscall.Getpgrp())
+	_, _, _ = syscall.Syscall(syscall.SYS_IOCTL, os.Stdin.Fd(), uintptr(syscall.TIOCSPGRP), uintptr(unsafe.Pointer(&pgrp)))
+}
+
+// isControllingTerminal reports whether the file is the process's terminal
+// Asking for the terminal's foreground process group only succeeds on a real
+// terminal, unlike a mode check which is also true for /dev/null
+func isControllingTerminal(file *os.File) bool {
+	var pgrp int32
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), uintptr(syscall.TIOCGPGRP), uintptr(unsafe.Pointer(&pgrp)))
+	return errno == 0
+}
+
+// detachProcess prepares cmd to run in a session of its own, for --detach
+// It is then no longer tied to goldfish's terminal, so closing the terminal
+// does not hang it up, and its process ID also names its process group.
+func detachProcess(cmd *exec.Cmd, opts processOptions) (func(), error) {
+	if err := sandboxCommand(cmd, opts.sandbox); err != nil {
+		return nil, err
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	return func() {}, nil
+}
@@ -0,0 +1,164 @@
+//go:build unix
+
+package engine
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// startInGroup starts a shell script in its own process group for signal tests
+// The script must print a line once its traps are installed; startInGroup waits
+// for that line so signals are not sent before the script can handle them
+func startInGroup(t *testing.T, script string) *processTree {
+	t.Helper()
+	cmd := exec.Command("sh", "-c", script)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatalf("Failed to create stdout pipe: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("Failed to start test process: %v", err)
+	}
+	if _, err := bufio.NewReader(stdout).ReadString('\n'); err != nil {
+		t.Fatalf("Test process did not become ready: %v", err)
+	}
+	return &processTree{cmd: cmd}
+}
+
+// TestWaitForProcess_NoSignal tests that a normal exit is reported unchanged
+func TestWaitForProcess_NoSignal(t *testing.T) {
+	tree := startInGroup(t, "echo ready; exit 0")
+
+	interrupted, err := waitForProcess(tree, make(chan os.Signal), time.Second)
+	if interrupted != nil {
+		t.Errorf("Expected no interrupting signal, got %v", interrupted)
+	}
+	if err != nil {
+		t.Errorf("Expected clean exit, got %v", err)
+	}
+}
+
+// TestWaitForProcess_ForwardsSignal tests that a received signal reaches the child
+func TestWaitForProcess_ForwardsSignal(t *testing.T) {
+	// The shell exits with a recognisable code when it receives SIGTERM
+	tree := startInGroup(t, "trap 'exit 42' TERM; echo ready; while true; do sleep 0.05; done")
+
+	signals := make(chan os.Signal, 1)
+	signals <- syscall.SIGTERM
+
+	interrupted, err := waitForProcess(tree, signals, 5*time.Second)
+	if interrupted != syscall.SIGTERM {
+		t.Errorf("Expected SIGTERM to be reported, got %v", interrupted)
+	}
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok || exitErr.ExitCode() != 42 {
+		t.Errorf("Expected child to handle SIGTERM and exit 42, got %v", err)
+	}
+}
+
+// TestWaitForProcess_KillsAfterGracePeriod tests escalation to SIGKILL
+func TestWaitForProcess_KillsAfterGracePeriod(t *testing.T) {
+	// Ignoring SIGTERM means only the grace period kill can stop this process
+	tree := startInGroup(t, "trap '' TERM; echo ready; sleep 30")
+
+	signals := make(chan os.Signal, 1)
+	signals <- syscall.SIGTERM
+
+	start := time.Now()
+	interrupted, _ := waitForProcess(tree, signals, 100*time.Millisecond)
+	if interrupted != syscall.SIGTERM {
+		t.Errorf("Expected SIGTERM to be reported, got %v", interrupted)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Expected child to be killed after the grace period, took %v", elapsed)
+	}
+}
+
+// TestEngine_SetGracePeriod tests configuring the grace period
+func TestEngine_SetGracePeriod(t *testing.T) {
+	engine := NewEngine(time.Second)
+	if engine.gracePeriod != DefaultGracePeriod {
+		t.Errorf("Expected default grace period %v, got %v", DefaultGracePeriod, engine.gracePeriod)
+	}
+
+	engine.SetGracePeriod(2 * time.Second)
+	if engine.gracePeriod != 2*time.Second {
+		t.Errorf("Expected grace period 2s, got %v", engine.gracePeriod)
+	}
+
+	engine.SetGracePeriod(0)
+	if engine.gracePeriod != DefaultGracePeriod {
+		t.Errorf("Expected SetGracePeriod(0) to restore default, got %v", engine.gracePeriod)
+	}
+}
+
+// TestEngine_executeCommand_TimeoutKillsProcessGroup tests that grandchildren die on timeout
+func TestEngine_executeCommand_TimeoutKillsProcessGroup(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping slow process group test in short mode")
+	}
+
+	engine := NewEngine(time.Second)
+	marker := filepath.Join(t.TempDir(), "survived")
+
+	// The background subshell would create the marker if it outlived the timeout
+	command := "(sleep 1; touch " + marker + ") & wait"
+	_, err := engine.executeCommand(context.Background(), command, "", 200*time.Millisecond, os.Environ(), processOptions{}, stdio{in: os.Stdin, out: io.Discard, err: os.Stderr})
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("Expected timeout error, got %v", err)
+	}
+
+	// Give a surviving grandchild enough time to create the marker
+	time.Sleep(1500 * time.Millisecond)
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("Expected grandchild process to be killed on timeout")
+	}
+}
+
+// TestNewProcessTree_Foreground tests that only one child reading goldfish's
+// terminal at a time is given the foreground
+func TestNewProcessTree_Foreground(t *testing.T) {
+	terminal := controllingTerminal
+	controllingTerminal = func() bool { return true }
+	defer func() { controllingTerminal = terminal }()
+
+	newTree := func(stdin io.Reader) *processTree {
+		cmd := exec.Command("true")
+		cmd.Stdin = stdin
+		tree, err := newProcessTree(cmd, processOptions{})
+		if err != nil {
+			t.Fatalf("newProcessTree failed: %v", err)
+		}
+		return tree
+	}
+
+	first := newTree(os.Stdin)
+	if !first.foreground || !first.cmd.SysProcAttr.Foreground {
+		t.Fatal("Expected the first child to get the foreground")
+	}
+	// A concurrent child, or one with other input, stays in the background
+	if second := newTree(os.Stdin); second.foreground || second.cmd.SysProcAttr.Foreground {
+		t.Error("Expected a concurrent child to stay in the background")
+	}
+	if piped := newTree(strings.NewReader("input")); piped.foreground {
+		t.Error("Expected a child not reading the terminal to stay in the background")
+	}
+
+	// Once the first child is done the next may have it
+	first.release()
+	next := newTree(os.Stdin)
+	if !next.foreground {
+		t.Error("Expected the foreground to be free again")
+	}
+	next.release()
+}
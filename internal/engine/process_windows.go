@@ -0,0 +1,227 @@
+//go:build windows
+
+package engine
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"unsafe"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+var (
+	kernel32                     = syscall.NewLazyDLL("kernel32.dll")
+	procGenerateConsoleCtrlEvent = kernel32.NewProc("GenerateConsoleCtrlEvent")
+	procCreateJobObjectW         = kernel32.NewProc("CreateJobObjectW")
+	procSetInformationJobObject  = kernel32.NewProc("SetInformationJobObject")
+	procAssignProcessToJobObject = kernel32.NewProc("AssignProcessToJobObject")
+	procTerminateJobObject       = kernel32.NewProc("TerminateJobObject")
+
+	ntdll               = syscall.NewLazyDLL("ntdll.dll")
+	procNtResumeProcess = ntdll.NewProc("NtResumeProcess")
+)
+
+const (
+	// ctrlBreakEvent is the CTRL_BREAK_EVENT value for GenerateConsoleCtrlEvent
+	ctrlBreakEvent = 1
+	// jobObjectExtendedLimitInformation is the JobObjectExtendedLimitInformation class
+	jobObjectExtendedLimitInformation = 9
+	// jobObjectLimitKillOnJobClose kills every process in the job when its last handle closes
+	jobObjectLimitKillOnJobClose = 0x2000
+	// jobObjectLimitProcessTime and jobObjectLimitProcessMemory enforce
+	// PerProcessUserTimeLimit and ProcessMemoryLimit
+	jobObjectLimitProcessTime   = 0x2
+	jobObjectLimitProcessMemory = 0x100
+	// processSetQuotaTerminate is PROCESS_SET_QUOTA | PROCESS_TERMINATE,
+	// the access rights AssignProcessToJobObject requires
+	processSetQuotaTerminate = 0x0100 | 0x0001
+	// processSuspendResume is PROCESS_SUSPEND_RESUME, which NtResumeProcess requires
+	processSuspendResume = 0x0800
+	// createSuspended is the CREATE_SUSPENDED creation flag: the child's
+	// first thread does not run until it is resumed
+	createSuspended = 0x00000004
+)
+
+// ioCounters mirrors the Win32 IO_COUNTERS structure
+type ioCounters struct {
+	ReadOperationCount, WriteOperationCount, OtherOperationCount uint64
+	ReadTransferCount, WriteTransferCount, OtherTransferCount    uint64
+}
+
+// jobObjectBasicLimitInformation mirrors JOBOBJECT_BASIC_LIMIT_INFORMATION
+type jobObjectBasicLimitInformation struct {
+	PerProcessUserTimeLimit int64
+	PerJobUserTimeLimit     int64
+	LimitFlags              uint32
+	MinimumWorkingSetSize   uintptr
+	MaximumWorkingSetSize   uintptr
+	ActiveProcessLimit      uint32
+	Affinity                uintptr
+	PriorityClass           uint32
+	SchedulingClass         uint32
+}
+
+// jobObjectExtendedLimitInformation mirrors JOBOBJECT_EXTENDED_LIMIT_INFORMATION
+type jobObjectExtendedLimitInfo struct {
+	BasicLimitInformation jobObjectBasicLimitInformation
+	IoInfo                ioCounters
+	ProcessMemoryLimit    uintptr
+	JobMemoryLimit        uintptr
+	PeakProcessMemoryUsed uintptr
+	PeakJobMemoryUsed     uintptr
+}
+
+// processTree tracks a child process and everything it spawns
+// On Windows the child is placed in a Job Object; terminating the job ends
+// every process in it, including grandchildren started by cmd.exe
+type processTree struct {
+	cmd *exec.Cmd
+	// job is the Job Object handle, or 0 if the job could not be created
+	job syscall.Handle
+	// limits are the command's resource limits, set on the job
+	limits *config.ResourceLimits
+	// token is the restricted token of a sandboxed command, or 0
+	token syscall.Token
+}
+
+// newProcessTree prepares cmd to start suspended in a new console process group
+// The process group is required for CTRL_BREAK to be delivered to the child
+// alone. The child is suspended until started has placed it in its Job
+// Object, so it cannot start processes, or use memory and CPU time, outside
+// the job first. A lower (or higher) priority from the limits is given when
+// the process is created, and a sandboxed command is created with a
+// restricted token.
+func newProcessTree(cmd *exec.Cmd, opts processOptions) (*processTree, error) {
+	flags := uint32(syscall.CREATE_NEW_PROCESS_GROUP | createSuspended)
+	if opts.limits != nil {
+		flags |= priorityClass(opts.limits.Nice)
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CreationFlags: flags,
+	}
+	tree := &processTree{cmd: cmd, limits: opts.limits}
+	if opts.sandbox != nil {
+		token, err := sandboxToken(opts.sandbox)
+		if err != nil {
+			return nil, err
+		}
+		tree.token = token
+		cmd.SysProcAttr.Token = token
+	}
+	return tree, nil
+}
+
+// started places the suspended child into a Job Object, then resumes it
+// Processes the child starts inherit the job automatically. The child is
+// resumed even when the job cannot be set up, as it ran before jobs were
+// used; if it cannot be resumed it is killed rather than left hanging.
+func (t *processTree) started() error {
+	process, err := syscall.OpenProcess(processSetQuotaTerminate|processSuspendResume, false, uint32(t.cmd.Process.Pid))
+	if err != nil {
+		_ = t.cmd.Process.Kill()
+		return fmt.Errorf("failed to open child process: %w", err)
+	}
+	defer syscall.CloseHandle(process)
+
+	jobErr := t.assignJob(process)
+	if status, _, _ := procNtResumeProcess.Call(uintptr(process)); status != 0 {
+		_ = t.cmd.Process.Kill()
+		return fmt.Errorf("failed to resume child process: NTSTATUS %#x", status)
+	}
+	return jobErr
+}
+
+// assignJob creates the child's Job Object, with its limits, and assigns the process to it
+func (t *processTree) assignJob(process syscall.Handle) error {
+	job, _, err := procCreateJobObjectW.Call(0, 0)
+	if job == 0 {
+		return fmt.Errorf("failed to create job object: %w", err)
+	}
+	t.job = syscall.Handle(job)
+
+	// Make closing the job handle kill everything, so nothing outlives goldfish,
+	// and cap each process's memory and CPU time when the command has limits
+	info := jobObjectExtendedLimitInfo{}
+	info.BasicLimitInformation.LimitFlags = jobObjectLimitKillOnJobClose
+	if t.limits != nil && t.limits.MemoryMB > 0 {
+		info.BasicLimitInformation.LimitFlags |= jobObjectLimitProcessMemory
+		info.ProcessMemoryLimit = uintptr(t.limits.MemoryMB) << 20
+	}
+	if t.limits != nil && t.limits.CPUSeconds > 0 {
+		// The limit is counted in 100-nanosecond intervals
+		info.BasicLimitInformation.LimitFlags |= jobObjectLimitProcessTime
+		info.BasicLimitInformation.PerProcessUserTimeLimit = int64(t.limits.CPUSeconds) * 10_000_000
+	}
+	result, _, err := procSetInformationJobObject.Call(uintptr(t.job), jobObjectExtendedLimitInformation,
+		uintptr(unsafe.Pointer(&info)), unsafe.Sizeof(info))
+	if result == 0 {
+		return fmt.Errorf("failed to configure job object: %w", err)
+	}
+
+	result, _, err = procAssignProcessToJobObject.Call(uintptr(t.job), uintptr(process))
+	if result == 0 {
+		return fmt.Errorf("failed to assign child to job object: %w", err)
+	}
+	return nil
+}
+
+// interrupt sends CTRL_BREAK to the child's process group
+// Windows has no POSIX signals, so every termination signal maps to CTRL_BREAK
+func (t *processTree) interrupt(_ os.Signal) error {
+	result, _, err := procGenerateConsoleCtrlEvent.Call(ctrlBreakEvent, uintptr(t.cmd.Process.Pid))
+	if result == 0 {
+		return err
+	}
+	return nil
+}
+
+// kill forcibly terminates every process in the job
+// If the job could not be created only the direct child can be killed
+func (t *processTree) kill() error {
+	if t.job == 0 {
+		return t.cmd.Process.Kill()
+	}
+	result, _, err := procTerminateJobObject.Call(uintptr(t.job), 1)
+	if result == 0 {
+		return err
+	}
+	return nil
+}
+
+// release closes the job and token handles once the child has exited
+func (t *processTree) release() {
+	if t.job != 0 {
+		syscall.CloseHandle(t.job)
+		t.job = 0
+	}
+	if t.token != 0 {
+		t.token.Close()
+		t.token = 0
+	}
+}
+
+// detachedProcess is the DETACHED_PROCESS creation flag: the child gets no console
+const detachedProcess = 0x00000008
+
+// detachProcess prepares cmd to run without a console in a new process group,
+// for --detach; the returned function releases what was set up once it started
+// No Job Object is used, since closing it would end the command with goldfish.
+func detachProcess(cmd *exec.Cmd, opts processOptions) (func(), error) {
+	flags := uint32(syscall.CREATE_NEW_PROCESS_GROUP | detachedProcess)
+	if opts.limits != nil {
+		flags |= priorityClass(opts.limits.Nice)
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: flags}
+	if opts.sandbox == nil {
+		return func() {}, nil
+	}
+	token, err := sandboxToken(opts.sandbox)
+	if err != nil {
+		return nil, err
+	}
+	cmd.SysProcAttr.Token = token
+	return func() { token.Close() }, nil
+}
@@ -0,0 +1,38 @@
+package engine
+
+import (
+	"sort"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// SetProfile selects the profile whose vars and env apply to every command,
+// e.g. the prod endpoint and credentials; nil selects none
+// Its vars override the globals of the same name, and its env is set for the
+// commands that run and seen by templates as .env. Templates can tell which
+// profile is selected from .profile.
+func (e *Engine) SetProfile(profile *config.Profile) {
+	e.profile = profile
+}
+
+// profileName returns the name of the selected profile, or ""
+func (e *Engine) profileName() string {
+	if e.profile == nil {
+		return ""
+	}
+	return e.profile.Name
+}
+
+// profileEnvironment returns the selected profile's env as NAME=value
+// entries, sorted so that verbose logs are the same every run
+func (e *Engine) profileEnvironment() []string {
+	if e.profile == nil {
+		return nil
+	}
+	entries := make([]string, 0, len(e.profile.Env))
+	for name, value := range e.profile.Env {
+		entries = append(entries, name+"="+value)
+	}
+	sort.Strings(entries)
+	return entries
+}
@@ -0,0 +1,63 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// TestEngine_SetProfile tests that a profile's vars and env reach templates and commands
+func TestEngine_SetProfile(t *testing.T) {
+	t.Setenv("DEPLOY_REGION", "eu-west-1")
+	cmd := &config.Command{
+		Name:        "deploy",
+		BaseCommand: "deploy",
+		Vars:        map[string]string{"region": "{{.env.DEPLOY_REGION}}"},
+		Platforms: map[string]config.PlatformCommand{
+			"linux": {Template: "{{.base_command}} --to {{.globals.endpoint}} --region {{.vars.region}} # {{.profile}}"},
+		},
+	}
+	engine := NewEngine(0)
+	engine.SetGlobals(map[string]string{
+		"host":     "localhost",
+		"endpoint": "http://{{.globals.host}}:8080",
+	})
+	ctx := &ExecutionContext{Command: cmd, Platform: platform.Linux, Parameters: map[string]interface{}{}}
+
+	rendered, err := engine.Preview(ctx)
+	if err != nil {
+		t.Fatalf("Preview failed: %v", err)
+	}
+	if rendered != "deploy --to http://localhost:8080 --region eu-west-1 #" {
+		t.Errorf("Unexpected command line without a profile: %q", rendered)
+	}
+
+	// The profile's vars override globals, which still see each other
+	engine.SetProfile(&config.Profile{
+		Name: "prod",
+		Vars: map[string]string{"host": "deploy.example.com"},
+		Env:  map[string]string{"DEPLOY_REGION": "us-east-1", "DEPLOY_TOKEN": "t"},
+	})
+	rendered, err = engine.Preview(ctx)
+	if err != nil {
+		t.Fatalf("Preview failed: %v", err)
+	}
+	if rendered != "deploy --to http://deploy.example.com:8080 --region us-east-1 # prod" {
+		t.Errorf("Unexpected command line with the prod profile: %q", rendered)
+	}
+
+	// The env is set for the child, before goldfish's own variables
+	env := engine.commandEnvironment(cmd)
+	if value, _ := envValue(env, "DEPLOY_TOKEN"); value != "t" {
+		t.Errorf("Expected the profile's DEPLOY_TOKEN, got %q", value)
+	}
+	if value, _ := envValue(env, "DEPLOY_REGION"); value != "us-east-1" {
+		t.Errorf("Expected the profile's DEPLOY_REGION, got %q", value)
+	}
+	overrides := strings.Join(engine.envOverrides(cmd), " ")
+	if !strings.HasPrefix(overrides, "DEPLOY_REGION=us-east-1 DEPLOY_TOKEN=t ") {
+		t.Errorf("Expected the profile's env first and sorted, got %q", overrides)
+	}
+}
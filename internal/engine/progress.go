@@ -0,0 +1,133 @@
+package engine
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Backup and sync commands can run for minutes without printing anything and
+// look frozen. When progress output is set (the CLI sets it when stderr is a
+// terminal), a spinner with the command name and elapsed time is shown once
+// the command has been silent for a while. It is cleared as soon as the
+// command prints something, and when it finishes.
+//
+// Watching for output means the command writes into a pipe rather than the
+// terminal, so programs that check for a terminal stop using colors or
+// refuse to run interactively. The spinner is therefore only used for
+// commands that ask for it with progress: true, or when --progress is given.
+
+// DefaultProgressDelay is how long a command may be silent before the spinner appears
+const DefaultProgressDelay = 3 * time.Second
+
+// progressFrames are the spinner's animation frames
+var progressFrames = []string{"|", "/", "-", `\`}
+
+// progressTick is how often the spinner is redrawn
+const progressTick = 200 * time.Millisecond
+
+// clearLine returns the cursor to the start of the line and erases it
+const clearLine = "\r\033[K"
+
+// SetProgressOutput shows a spinner on w while a command prints nothing for longer than delay
+// A nil writer turns the spinner off; a delay of zero or less uses DefaultProgressDelay.
+func (e *Engine) SetProgressOutput(w io.Writer, delay time.Duration) {
+	if delay <= 0 {
+		delay = DefaultProgressDelay
+	}
+	e.progressOutput = w
+	e.progressDelay = delay
+}
+
+// progress draws the spinner for one command execution
+// The command's output goes through it, so the spinner can be cleared
+// before anything else is written to the terminal.
+type progress struct {
+	mu     sync.Mutex
+	out    io.Writer
+	name   string
+	delay  time.Duration
+	start  time.Time
+	quiet  time.Time // when the command last printed something
+	shown  bool
+	frame  int
+	done   chan struct{}
+	exited sync.WaitGroup
+}
+
+// startProgress starts the spinner for a command that wants one and routes its streams through it
+// It returns the streams to use and a function that stops and clears the spinner.
+func (e *Engine) startProgress(ctx *ExecutionContext, streams stdio) (stdio, func()) {
+	if e.progressOutput == nil || !(ctx.Progress || ctx.Command.Progress) {
+		return streams, func() {}
+	}
+	now := time.Now()
+	p := &progress{out: e.progressOutput, name: ctx.Command.Name, delay: e.progressDelay, start: now, quiet: now, done: make(chan struct{})}
+	streams.out = &progressWriter{progress: p, target: streams.out}
+	streams.err = &progressWriter{progress: p, target: streams.err}
+
+	p.exited.Add(1)
+	go p.run()
+	return streams, p.stop
+}
+
+// run redraws the spinner until the command finishes
+func (p *progress) run() {
+	defer p.exited.Done()
+	ticker := time.NewTicker(progressTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.done:
+			return
+		case now := <-ticker.C:
+			p.draw(now)
+		}
+	}
+}
+
+// draw shows the next spinner frame if the command has been silent long enough
+func (p *progress) draw(now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if now.Sub(p.quiet) < p.delay {
+		return
+	}
+	elapsed := now.Sub(p.start).Truncate(time.Second)
+	fmt.Fprintf(p.out, "%s%s %s (%s)", clearLine, progressFrames[p.frame%len(progressFrames)], p.name, elapsed)
+	p.frame++
+	p.shown = true
+}
+
+// clear erases the spinner if it is shown; the caller holds the lock
+func (p *progress) clear() {
+	if p.shown {
+		io.WriteString(p.out, clearLine)
+		p.shown = false
+	}
+}
+
+// stop ends the spinner and erases it
+func (p *progress) stop() {
+	close(p.done)
+	p.exited.Wait()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clear()
+}
+
+// progressWriter passes a command's output on, clearing the spinner first
+type progressWriter struct {
+	progress *progress
+	target   io.Writer
+}
+
+// Write clears the spinner, records the activity and writes the output
+func (w *progressWriter) Write(data []byte) (int, error) {
+	w.progress.mu.Lock()
+	defer w.progress.mu.Unlock()
+	w.progress.clear()
+	w.progress.quiet = time.Now()
+	return w.target.Write(data)
+}
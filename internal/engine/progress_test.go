@@ -0,0 +1,52 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// TestEngine_Execute_Progress tests showing and clearing the spinner for a silent command
+func TestEngine_Execute_Progress(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+	template := "sleep 0.5; echo done"
+	cmd := &config.Command{
+		Name:        "backup",
+		BaseCommand: "sh",
+		Progress:    true,
+		Platforms: map[string]config.PlatformCommand{
+			"linux":  {Template: template},
+			"darwin": {Template: template},
+		},
+	}
+	engine := NewEngine(5 * time.Second)
+	var spinner, stdout bytes.Buffer
+	engine.SetProgressOutput(&spinner, 50*time.Millisecond)
+	current := platform.SupportedPlatform(runtime.GOOS)
+
+	if _, err := engine.Execute(context.Background(), &ExecutionContext{Command: cmd, Platform: current, Parameters: map[string]interface{}{}, Stdout: &stdout}); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !strings.Contains(spinner.String(), " backup (0s)") || !strings.HasSuffix(spinner.String(), clearLine) {
+		t.Errorf("Expected a spinner that is cleared at the end, got %q", spinner.String())
+	}
+	if stdout.String() != "done\n" {
+		t.Errorf("Expected the output to pass through, got %q", stdout.String())
+	}
+
+	// Commands that do not ask for the spinner keep their streams untouched
+	cmd.Progress = false
+	spinner.Reset()
+	engine.Execute(context.Background(), &ExecutionContext{Command: cmd, Platform: current, Parameters: map[string]interface{}{}, Stdout: &stdout})
+	if spinner.Len() != 0 {
+		t.Errorf("Expected no spinner, got %q", spinner.String())
+	}
+}
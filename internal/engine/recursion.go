@@ -0,0 +1,74 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+const (
+	// DepthEnvVar is the environment variable goldfish uses to record how
+	// deeply nested the current invocation is. Every child process started
+	// by the engine inherits the current depth plus one, so a template that
+	// calls goldfish again can be detected by the nested invocation.
+	DepthEnvVar = "GOLDFISH_DEPTH"
+	// MaxDepthEnvVar lets users override the maximum nesting depth without
+	// recompiling goldfish (e.g. GOLDFISH_MAX_DEPTH=3)
+	MaxDepthEnvVar = "GOLDFISH_MAX_DEPTH"
+	// DefaultMaxDepth is the nesting limit used when nothing else is configured.
+	// Legitimate command composition rarely goes more than a couple of levels deep.
+	DefaultMaxDepth = 10
+)
+
+// SetMaxDepth changes the maximum allowed nesting depth for this engine
+// A value of zero or less restores DefaultMaxDepth
+func (e *Engine) SetMaxDepth(depth int) {
+	if depth <= 0 {
+		depth = DefaultMaxDepth
+	}
+	e.maxDepth = depth
+}
+
+// MaxDepth returns the effective maximum nesting depth
+// GOLDFISH_MAX_DEPTH takes precedence over the engine setting so that a user
+// can tighten or relax the limit for a single shell session
+func (e *Engine) MaxDepth() int {
+	if value := os.Getenv(MaxDepthEnvVar); value != "" {
+		if depth, err := strconv.Atoi(value); err == nil && depth > 0 {
+			return depth
+		}
+	}
+	if e.maxDepth <= 0 {
+		return DefaultMaxDepth
+	}
+	return e.maxDepth
+}
+
+// currentDepth reads the nesting depth inherited from a parent goldfish process
+// A missing or malformed value is treated as depth zero (a top-level invocation)
+func currentDepth() int {
+	depth, err := strconv.Atoi(os.Getenv(DepthEnvVar))
+	if err != nil || depth < 0 {
+		return 0
+	}
+	return depth
+}
+
+// checkDepth returns an error when the current invocation is nested too deeply
+// This stops templates that (directly or indirectly) invoke themselves from
+// spawning processes forever
+func (e *Engine) checkDepth(commandName string) error {
+	depth := currentDepth()
+	maxDepth := e.MaxDepth()
+	if depth >= maxDepth {
+		return fmt.Errorf("command '%s' exceeded maximum goldfish nesting depth of %d (%s=%d): a template is probably invoking goldfish recursively; raise %s if this is intentional",
+			commandName, maxDepth, DepthEnvVar, depth, MaxDepthEnvVar)
+	}
+	return nil
+}
+
+// depthVariable returns the environment entry recording a child's nesting depth
+// The child is always one level deeper than the current process
+func depthVariable() string {
+	return fmt.Sprintf("%s=%d", DepthEnvVar, currentDepth()+1)
+}
@@ -0,0 +1,103 @@
+package engine
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// TestCurrentDepth tests reading the inherited nesting depth from the environment
+func TestCurrentDepth(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected int
+	}{
+		{"", 0},
+		{"3", 3},
+		{"not-a-number", 0},
+		{"-2", 0},
+	}
+
+	for _, tc := range testCases {
+		t.Setenv(DepthEnvVar, tc.value)
+		if got := currentDepth(); got != tc.expected {
+			t.Errorf("currentDepth() with %s=%q: expected %d, got %d", DepthEnvVar, tc.value, tc.expected, got)
+		}
+	}
+}
+
+// TestEngine_MaxDepth tests the precedence of the max depth settings
+func TestEngine_MaxDepth(t *testing.T) {
+	engine := NewEngine(time.Second)
+	t.Setenv(MaxDepthEnvVar, "")
+
+	if engine.MaxDepth() != DefaultMaxDepth {
+		t.Errorf("Expected default max depth %d, got %d", DefaultMaxDepth, engine.MaxDepth())
+	}
+
+	engine.SetMaxDepth(4)
+	if engine.MaxDepth() != 4 {
+		t.Errorf("Expected max depth 4, got %d", engine.MaxDepth())
+	}
+
+	// Zero resets to the default
+	engine.SetMaxDepth(0)
+	if engine.MaxDepth() != DefaultMaxDepth {
+		t.Errorf("Expected SetMaxDepth(0) to restore default, got %d", engine.MaxDepth())
+	}
+
+	// The environment variable wins over the engine setting
+	t.Setenv(MaxDepthEnvVar, "2")
+	if engine.MaxDepth() != 2 {
+		t.Errorf("Expected %s to override max depth, got %d", MaxDepthEnvVar, engine.MaxDepth())
+	}
+
+	// Invalid environment values are ignored
+	t.Setenv(MaxDepthEnvVar, "zero")
+	if engine.MaxDepth() != DefaultMaxDepth {
+		t.Errorf("Expected invalid %s to be ignored, got %d", MaxDepthEnvVar, engine.MaxDepth())
+	}
+}
+
+// TestDepthVariable tests that child processes receive the incremented depth
+func TestDepthVariable(t *testing.T) {
+	t.Setenv(DepthEnvVar, "2")
+
+	if got := depthVariable(); got != DepthEnvVar+"=3" {
+		t.Errorf("Expected %s=3, got %s", DepthEnvVar, got)
+	}
+}
+
+// TestEngine_Execute_RecursionLimit tests that Execute refuses to run when nested too deeply
+func TestEngine_Execute_RecursionLimit(t *testing.T) {
+	engine := NewEngine(time.Second)
+	engine.SetMaxDepth(3)
+	t.Setenv(MaxDepthEnvVar, "")
+	t.Setenv(DepthEnvVar, "3")
+
+	ctx := &ExecutionContext{
+		Command: &config.Command{
+			Name:        "loop",
+			BaseCommand: "goldfish",
+			Platforms: map[string]config.PlatformCommand{
+				"linux":   {Template: "goldfish loop"},
+				"darwin":  {Template: "goldfish loop"},
+				"windows": {Template: "goldfish loop"},
+			},
+		},
+		Platform:   platform.Linux,
+		Parameters: map[string]interface{}{},
+	}
+
+	err := engine.ExecuteStatus(context.Background(), ctx)
+	if err == nil {
+		t.Fatal("Expected error when nesting depth is exceeded")
+	}
+	if !strings.Contains(err.Error(), "nesting depth") {
+		t.Errorf("Expected nesting depth error, got: %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+//go:build unix
+
+package engine
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// limitCommand returns argv changed to run under the command's resource limits
+// Unix applies them with the standard tools, so they are in place before
+// the command's shell starts: sh sets the rlimits with ulimit and execs
+// nice, which execs ionice (on Linux, where it is installed), which execs
+// the shell. Everything the command starts inherits them.
+func limitCommand(argv []string, limits *config.ResourceLimits) []string {
+	if limits == nil {
+		return argv
+	}
+
+	var prefix []string
+	var ulimits []string
+	if limits.CPUSeconds > 0 {
+		ulimits = append(ulimits, fmt.Sprintf("ulimit -t %d", limits.CPUSeconds))
+	}
+	// macOS does not enforce address space limits and refuses to set one
+	if limits.MemoryMB > 0 && runtime.GOOS != "darwin" {
+		ulimits = append(ulimits, fmt.Sprintf("ulimit -v %d", limits.MemoryMB*1024))
+	}
+	if len(ulimits) > 0 {
+		// "$@" is the rest of argv; the "sh" after the script becomes $0
+		script := strings.Join(append(ulimits, `exec "$@"`), " && ")
+		prefix = append(prefix, "sh", "-c", script, "sh")
+	}
+	if limits.Nice != 0 {
+		prefix = append(prefix, "nice", "-n", strconv.Itoa(limits.Nice))
+	}
+	if limits.IOPriority != "" {
+		if _, err := exec.LookPath("ionice"); err == nil {
+			prefix = append(prefix, "ionice")
+			if limits.IOPriority == "idle" {
+				prefix = append(prefix, "-c", "3")
+			} else {
+				prefix = append(prefix, "-c", "2", "-n", "7")
+			}
+		}
+	}
+	return append(prefix, argv...)
+}
@@ -0,0 +1,58 @@
+//go:build unix
+
+package engine
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// TestLimitCommand tests wrapping a command line in the tools that apply resource limits
+func TestLimitCommand(t *testing.T) {
+	argv := []string{"sh", "-c", "gzip big.log"}
+	if limited := limitCommand(argv, nil); strings.Join(limited, " ") != "sh -c gzip big.log" {
+		t.Errorf("Expected the command unchanged without limits, got %q", limited)
+	}
+
+	limited := limitCommand(argv, &config.ResourceLimits{Nice: 10, CPUSeconds: 60})
+	expected := []string{"sh", "-c", `ulimit -t 60 && exec "$@"`, "sh", "nice", "-n", "10", "sh", "-c", "gzip big.log"}
+	if strings.Join(limited, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected %q, got %q", expected, limited)
+	}
+}
+
+// TestEngine_Execute_Limits tests that the command runs under its limits
+func TestEngine_Execute_Limits(t *testing.T) {
+	entry := config.PlatformCommand{Template: "ulimit -t; ulimit -v"}
+	cmd := &config.Command{
+		Name:        "limited",
+		BaseCommand: "sh",
+		Platforms:   map[string]config.PlatformCommand{"linux": entry, "darwin": entry},
+		Limits:      &config.ResourceLimits{CPUSeconds: 30, MemoryMB: 2048},
+	}
+	if runtime.GOOS == "linux" {
+		// The niceness is the 19th field of a process's stat
+		entry.Template += "; cut -d' ' -f19 /proc/self/stat"
+		cmd.Platforms["linux"] = entry
+		cmd.Limits.Nice = 5
+	}
+
+	engine := NewEngine(5 * time.Second)
+	result, err := engine.Execute(context.Background(), &ExecutionContext{Command: cmd, Platform: platform.SupportedPlatform(runtime.GOOS), Parameters: map[string]interface{}{}, Capture: true})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	lines := strings.Fields(result.Stdout)
+	if len(lines) < 2 || lines[0] != "30" {
+		t.Fatalf("Expected a CPU time limit of 30, got %q", result.Stdout)
+	}
+	if runtime.GOOS == "linux" && (lines[1] != "2097152" || len(lines) != 3 || lines[2] != "5") {
+		t.Errorf("Expected a 2097152 KB memory limit and niceness 5, got %q", result.Stdout)
+	}
+}
@@ -0,0 +1,38 @@
+//go:build windows
+
+package engine
+
+import (
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// Windows priority classes for CreateProcess
+const (
+	idlePriorityClass        = 0x40
+	belowNormalPriorityClass = 0x4000
+	aboveNormalPriorityClass = 0x8000
+	highPriorityClass        = 0x80
+)
+
+// limitCommand returns argv unchanged: on Windows the process is created
+// with the priority class and its Job Object enforces the other limits (see
+// process_windows.go). There is no I/O priority for a job to set.
+func limitCommand(argv []string, _ *config.ResourceLimits) []string {
+	return argv
+}
+
+// priorityClass returns the priority class closest to a nice value, or 0
+// to keep the normal one
+func priorityClass(nice int) uint32 {
+	switch {
+	case nice >= 10:
+		return idlePriorityClass
+	case nice > 0:
+		return belowNormalPriorityClass
+	case nice <= -10:
+		return highPriorityClass
+	case nice < 0:
+		return aboveNormalPriorityClass
+	}
+	return 0
+}
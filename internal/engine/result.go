@@ -0,0 +1,46 @@
+package engine
+
+import (
+	"io"
+	"time"
+)
+
+// ExecutionResult describes how a command run went
+// Library users and servers read it instead of scraping goldfish's output.
+type ExecutionResult struct {
+	// Command is the rendered command line with secrets masked; it is empty
+	// when the command failed before rendering
+	Command string `json:"command"`
+	// ExitCode is the command's exit code, or -1 when it could not run
+	ExitCode int `json:"exit_code"`
+	// Duration is the time from the start of Execute until it returned,
+	// including any retries
+	Duration time.Duration `json:"duration"`
+	// Attempts is the number of times the command ran (more than one with a retry policy)
+	Attempts int `json:"attempts"`
+	// Stdout and Stderr hold the output of the final attempt when
+	// ExecutionContext.Capture is set
+	Stdout string `json:"stdout,omitempty"`
+	Stderr string `json:"stderr,omitempty"`
+	// Cached is set when the output is a cached result printed again instead
+	// of running the command, which then has no attempts
+	Cached bool `json:"cached,omitempty"`
+	// Skipped is set when the command's skip_if: check succeeded, so the
+	// command did not run and has no attempts
+	Skipped bool `json:"skipped,omitempty"`
+}
+
+// Succeeded reports whether the command ran and exited with code 0
+func (r *ExecutionResult) Succeeded() bool {
+	return r.ExitCode == 0
+}
+
+// captureStream returns the writer a captured stream is written to
+// Output goes to the buffer, and also to the caller's own writer when one
+// was given and the stream is not silenced.
+func captureStream(given io.Writer, silenced bool, buffer io.Writer) io.Writer {
+	if given == nil || silenced {
+		return buffer
+	}
+	return io.MultiWriter(given, buffer)
+}
@@ -0,0 +1,143 @@
+package engine
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// Commands with a cache: policy, typically slow discovery wrappers around
+// cloud CLIs, have the output of a successful run kept in the cache
+// directory. A later run with the same rendered command line, cache key and
+// profile within the TTL prints that output again instead of running the
+// command. Results are files named by a hash of what identifies them, so
+// the command line, which may hold secrets, is never written.
+
+// resultCacheDir is the directory under config.CacheDir holding results
+const resultCacheDir = "results"
+
+// cachedResult is a command's result as it is kept in the cache
+type cachedResult struct {
+	// Command is the rendered command line with secrets masked
+	Command string    `json:"command"`
+	Created time.Time `json:"created"`
+	Stdout  string    `json:"stdout"`
+	Stderr  string    `json:"stderr"`
+}
+
+// resultCacheKey returns the key the command's result is cached under, or
+// "" when it is not cached
+// Plugins and commands run on a backend are never cached, and neither are
+// runs given piped input, whose output depends on it.
+func (e *Engine) resultCacheKey(ctx *ExecutionContext, cmd *config.Command, platformCmd *config.PlatformCommand) (string, error) {
+	if ctx.Command.Cache == nil || ctx.Command.Plugin != "" || e.backend != nil || e.input != nil {
+		return "", nil
+	}
+	platformName := ctx.Platform.String()
+	rendered, err := e.renderTemplate(cmd, platformName, platformCmd, ctx.Parameters, ctx.revealedSet())
+	if err != nil {
+		return "", fmt.Errorf("failed to render command template: %w", err)
+	}
+	var key string
+	if ctx.Command.Cache.Key != "" {
+		data, err := e.commandData(cmd, platformName, ctx.Parameters, ctx.revealedSet())
+		if err != nil {
+			return "", err
+		}
+		if key, err = e.renderString("cache.key", ctx.Command.Cache.Key, data, ctx.revealedSet()); err != nil {
+			return "", fmt.Errorf("cache.key: %w", err)
+		}
+	}
+
+	hash := sha256.New()
+	for _, part := range []string{ctx.Command.Name, platformName, e.profileName(), rendered, key} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// resultCachePath returns the file a result is cached in
+func resultCachePath(key string) (string, error) {
+	dir, err := config.CacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, resultCacheDir, key+".json"), nil
+}
+
+// loadCachedResult returns the result cached under key when it is younger
+// than ttl, or nil
+func loadCachedResult(key string, ttl time.Duration) *cachedResult {
+	path, err := resultCachePath(key)
+	if err != nil {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var cached cachedResult
+	if err := json.Unmarshal(data, &cached); err != nil || time.Since(cached.Created) >= ttl {
+		return nil
+	}
+	return &cached
+}
+
+// storeCachedResult caches a successful run's output under key
+// Output cut short by the output limit is not kept, since replaying it
+// would silently lose the rest.
+func storeCachedResult(key, command string, stdout, stderr *cappedBuffer) error {
+	if stdout.exceeded || stderr.exceeded {
+		return fmt.Errorf("the output is larger than the output limit")
+	}
+	path, err := resultCachePath(key)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(cachedResult{Command: command, Created: time.Now(), Stdout: stdout.String(), Stderr: stderr.String()})
+	if err != nil {
+		return err
+	}
+	// Output may be sensitive, so only the user may read it
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), key+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// replayCachedResult prints a cached result's output as the command would
+// have, and completes result from it
+func replayCachedResult(ctx *ExecutionContext, result *ExecutionResult, cached *cachedResult) {
+	streams := ctx.streams()
+	if ctx.Capture {
+		result.Stdout, result.Stderr = cached.Stdout, cached.Stderr
+		streams.out = captureStream(ctx.Stdout, ctx.Quiet, io.Discard)
+		streams.err = captureStream(ctx.Stderr, ctx.NoStderr, io.Discard)
+	}
+	io.WriteString(streams.out, cached.Stdout)
+	io.WriteString(streams.err, cached.Stderr)
+	result.Command = cached.Command
+	result.ExitCode = 0
+	result.Cached = true
+}
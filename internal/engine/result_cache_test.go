@@ -0,0 +1,121 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// TestEngine_Execute_Cache tests that a cached command's output is reused
+// within its TTL, per cache key
+func TestEngine_Execute_Cache(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	t.Setenv(config.CacheDirEnvVar, t.TempDir())
+	// Each run appends to the file, so the number of lines counts the runs
+	runs := filepath.Join(t.TempDir(), "runs")
+	cmd := &config.Command{
+		Name:        "discover",
+		BaseCommand: "sh",
+		Parameters:  []config.Parameter{{Name: "host", Type: "string"}},
+		Cache:       &config.CachePolicy{TTL: time.Minute, Key: "{{.params.host}}"},
+		Platforms: map[string]config.PlatformCommand{
+			"linux": {Template: "echo run >> " + runs + "; wc -l < " + runs + "; echo warning >&2"},
+		},
+	}
+	engine := NewEngine(30 * time.Second)
+	run := func(host string, noCache bool) *ExecutionResult {
+		t.Helper()
+		var out, errOut bytes.Buffer
+		ctx := &ExecutionContext{Command: cmd, Platform: platform.Linux, Parameters: map[string]interface{}{"host": host},
+			Stdout: &out, Stderr: &errOut, NoCache: noCache}
+		result, err := engine.Execute(context.Background(), ctx)
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if errOut.String() != "warning\n" {
+			t.Errorf("Expected stderr to be printed, got %q", errOut.String())
+		}
+		result.Stdout = out.String()
+		return result
+	}
+
+	if result := run("a", false); result.Cached || result.Stdout != "1\n" {
+		t.Errorf("Expected the first run to run the command, got %+v", result)
+	}
+	if result := run("a", false); !result.Cached || result.Stdout != "1\n" || result.Command == "" {
+		t.Errorf("Expected the second run to print the cached output, got %+v", result)
+	}
+	// Another key is another result
+	if result := run("b", false); result.Cached || result.Stdout != "2\n" {
+		t.Errorf("Expected another key to run the command, got %+v", result)
+	}
+	// NoCache runs the command and caches its new result
+	if result := run("a", true); result.Cached || result.Stdout != "3\n" {
+		t.Errorf("Expected NoCache to run the command, got %+v", result)
+	}
+	if result := run("a", false); !result.Cached || result.Stdout != "3\n" {
+		t.Errorf("Expected the new result to be cached, got %+v", result)
+	}
+
+	// Results older than the TTL are not used
+	cmd.Cache.TTL = time.Nanosecond
+	if result := run("a", false); result.Cached || result.Stdout != "4\n" {
+		t.Errorf("Expected an expired result to be ignored, got %+v", result)
+	}
+}
+
+// TestEngine_Execute_CacheFailure tests that failed runs are not cached
+func TestEngine_Execute_CacheFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	t.Setenv(config.CacheDirEnvVar, t.TempDir())
+	cmd := &config.Command{
+		Name:        "flaky",
+		BaseCommand: "sh",
+		Cache:       &config.CachePolicy{TTL: time.Minute},
+		Platforms:   map[string]config.PlatformCommand{"linux": {Template: "exit 3"}},
+	}
+	engine := NewEngine(30 * time.Second)
+	ctx := &ExecutionContext{Command: cmd, Platform: platform.Linux, Parameters: map[string]interface{}{}}
+	for i := 0; i < 2; i++ {
+		result, err := engine.Execute(context.Background(), ctx)
+		if err != nil || result.ExitCode != 3 || result.Cached {
+			t.Errorf("Expected the command to fail each time, got %+v (%v)", result, err)
+		}
+	}
+	dir, _ := config.CacheDir()
+	if entries, _ := os.ReadDir(filepath.Join(dir, resultCacheDir)); len(entries) != 0 {
+		t.Errorf("Expected nothing to be cached, got %d entries", len(entries))
+	}
+}
+
+// TestEngine_Execute_CacheCapture tests that a cached result fills a captured result
+func TestEngine_Execute_CacheCapture(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	t.Setenv(config.CacheDirEnvVar, t.TempDir())
+	cmd := &config.Command{
+		Name:        "hello",
+		BaseCommand: "echo",
+		Cache:       &config.CachePolicy{TTL: time.Minute},
+		Platforms:   map[string]config.PlatformCommand{"linux": {Template: "echo hello"}},
+	}
+	engine := NewEngine(30 * time.Second)
+	for i := 0; i < 2; i++ {
+		result, err := engine.Execute(context.Background(), &ExecutionContext{Command: cmd, Platform: platform.Linux, Parameters: map[string]interface{}{}, Capture: true})
+		if err != nil || result.Stdout != "hello\n" || result.Cached != (i == 1) {
+			t.Errorf("Run %d: expected the output to be captured, got %+v (%v)", i+1, result, err)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// TestEngine_Execute_Result tests the result of running a command with and without capture
+func TestEngine_Execute_Result(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+	cmd := &config.Command{
+		Name:        "speak",
+		BaseCommand: "sh",
+		Platforms: map[string]config.PlatformCommand{
+			"linux":  {Template: "echo out; echo err >&2; exit 3"},
+			"darwin": {Template: "echo out; echo err >&2; exit 3"},
+		},
+	}
+	engine := NewEngine(5 * time.Second)
+	current := platform.SupportedPlatform(runtime.GOOS)
+
+	// Captured output is returned, and still written to streams that were given
+	var stderr bytes.Buffer
+	result, err := engine.Execute(context.Background(), &ExecutionContext{Command: cmd, Platform: current, Parameters: map[string]interface{}{}, Stderr: &stderr, Capture: true})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if result.ExitCode != 3 || result.Succeeded() || result.Attempts != 1 || result.Duration <= 0 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if result.Command != "echo out; echo err >&2; exit 3" || result.Stdout != "out\n" || result.Stderr != "err\n" {
+		t.Errorf("Unexpected command line or output: %+v", result)
+	}
+	if stderr.String() != "err\n" {
+		t.Errorf("Expected stderr to be written to the given stream too, got %q", stderr.String())
+	}
+
+	// Without capture the output only goes to the streams
+	var stdout bytes.Buffer
+	result, _ = engine.Execute(context.Background(), &ExecutionContext{Command: cmd, Platform: current, Parameters: map[string]interface{}{}, Stdout: &stdout, Stderr: &stderr})
+	if result.Stdout != "" || stdout.String() != "out\n" {
+		t.Errorf("Expected uncaptured output, got %q and %q", result.Stdout, stdout.String())
+	}
+
+	// A command that cannot run still has a result
+	result, err = engine.Execute(context.Background(), &ExecutionContext{Command: cmd, Platform: platform.Windows, Parameters: map[string]interface{}{}})
+	if err == nil || result == nil || result.ExitCode != -1 {
+		t.Errorf("Expected a failed result for an unsupported platform, got %+v (%v)", result, err)
+	}
+}
+
+// TestEngine_Execute_Quiet tests discarding the command's output streams
+func TestEngine_Execute_Quiet(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+	cmd := &config.Command{
+		Name:        "speak",
+		BaseCommand: "sh",
+		Platforms: map[string]config.PlatformCommand{
+			"linux":  {Template: "echo out; echo err >&2"},
+			"darwin": {Template: "echo out; echo err >&2"},
+		},
+	}
+	engine := NewEngine(5 * time.Second)
+	current := platform.SupportedPlatform(runtime.GOOS)
+
+	testCases := []struct {
+		quiet, noStderr bool
+		stdout, stderr  string
+	}{
+		{false, false, "out\n", "err\n"},
+		{true, false, "", "err\n"},
+		{false, true, "out\n", ""},
+		{true, true, "", ""},
+	}
+	for _, tc := range testCases {
+		var stdout, stderr bytes.Buffer
+		result, err := engine.Execute(context.Background(), &ExecutionContext{
+			Command: cmd, Platform: current, Parameters: map[string]interface{}{},
+			Stdout: &stdout, Stderr: &stderr, Quiet: tc.quiet, NoStderr: tc.noStderr, Capture: true,
+		})
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if stdout.String() != tc.stdout || stderr.String() != tc.stderr {
+			t.Errorf("quiet=%v no-stderr=%v: expected %q and %q, got %q and %q",
+				tc.quiet, tc.noStderr, tc.stdout, tc.stderr, stdout.String(), stderr.String())
+		}
+		// Silenced output is still captured
+		if result.Stdout != "out\n" || result.Stderr != "err\n" {
+			t.Errorf("Expected captured output, got %+v", result)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package engine
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// With --sandbox a command runs confined by the operating system: bubblewrap
+// (bwrap) on Linux, sandbox-exec on macOS and a restricted token on Windows
+// (see sandbox_unix.go and sandbox_windows.go). Its policy comes from the
+// command's sandbox: section; commands without one get defaultSandbox.
+
+// defaultSandbox is the policy for commands without one: nothing may be
+// written and the network cannot be reached
+var defaultSandbox = config.SandboxPolicy{ReadOnly: true}
+
+// commandProcessOptions returns what restricts the processes ctx's command
+// runs: its resource limits and, with --sandbox, its sandbox policy
+// The command line and every step run for it (skip_if:, check: run commands
+// and cleanup steps) run with the same options.
+func (e *Engine) commandProcessOptions(ctx *ExecutionContext, cmd *config.Command) (processOptions, error) {
+	options := processOptions{limits: ctx.Command.Limits}
+	if ctx.Sandbox {
+		policy, err := e.sandboxPolicy(ctx, cmd)
+		if err != nil {
+			return options, err
+		}
+		options.sandbox = policy
+	}
+	return options, nil
+}
+
+// sandboxPolicy returns the policy a command runs under with --sandbox
+// Templates in allow_paths are rendered with the command's data, and the
+// paths made absolute, as the sandboxing tools require.
+func (e *Engine) sandboxPolicy(ctx *ExecutionContext, cmd *config.Command) (*config.SandboxPolicy, error) {
+	if e.backend != nil {
+		return nil, fmt.Errorf("command '%s' cannot be sandboxed on a remote host", ctx.Command.Name)
+	}
+	policy := defaultSandbox
+	if ctx.Command.Sandbox != nil {
+		policy = *ctx.Command.Sandbox
+	}
+	if len(policy.AllowPaths) == 0 {
+		return &policy, nil
+	}
+
+	data, err := e.commandData(cmd, ctx.Platform.String(), ctx.Parameters, ctx.revealedSet())
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(policy.AllowPaths))
+	for _, pathTemplate := range policy.AllowPaths {
+		path, err := e.renderString("sandbox", pathTemplate, data, ctx.revealedSet())
+		if err != nil {
+			return nil, fmt.Errorf("failed to render sandbox.allow_paths entry %q: %w", pathTemplate, err)
+		}
+		if path == "" {
+			continue
+		}
+		if path, err = filepath.Abs(path); err != nil {
+			return nil, fmt.Errorf("sandbox.allow_paths: %w", err)
+		}
+		paths = append(paths, path)
+	}
+	policy.AllowPaths = paths
+	return &policy, nil
+}
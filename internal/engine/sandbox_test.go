@@ -0,0 +1,50 @@
+package engine
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// TestEngine_sandboxPolicy tests choosing and rendering the policy a command is sandboxed with
+func TestEngine_sandboxPolicy(t *testing.T) {
+	cmd := &config.Command{
+		Name:        "convert",
+		BaseCommand: "ffmpeg",
+		Parameters:  []config.Parameter{{Name: "output", Type: "string"}},
+	}
+	ctx := &ExecutionContext{Command: cmd, Platform: platform.Linux, Parameters: map[string]interface{}{"output": "out"}}
+	engine := NewEngine(0)
+
+	// Commands without a policy can neither write nor reach the network
+	policy, err := engine.sandboxPolicy(ctx, cmd)
+	if err != nil {
+		t.Fatalf("sandboxPolicy failed: %v", err)
+	}
+	if !policy.ReadOnly || policy.Network || len(policy.AllowPaths) != 0 {
+		t.Errorf("Expected the default policy, got %+v", policy)
+	}
+
+	// Allowed paths are rendered and made absolute
+	cmd.Sandbox = &config.SandboxPolicy{ReadOnly: true, Network: true, AllowPaths: []string{"{{.params.output}}", "{{if false}}x{{end}}"}}
+	policy, err = engine.sandboxPolicy(ctx, cmd)
+	if err != nil {
+		t.Fatalf("sandboxPolicy failed: %v", err)
+	}
+	expected, _ := filepath.Abs("out")
+	if !policy.Network || len(policy.AllowPaths) != 1 || policy.AllowPaths[0] != expected {
+		t.Errorf("Expected network access and %s writable, got %+v", expected, policy)
+	}
+	if cmd.Sandbox.AllowPaths[0] != "{{.params.output}}" {
+		t.Error("Expected the command's own policy to be left unchanged")
+	}
+
+	// Remote hosts cannot be sandboxed from here
+	engine.SetBackend(&fakeBackend{})
+	if _, err := engine.sandboxPolicy(ctx, cmd); err == nil || !strings.Contains(err.Error(), "remote") {
+		t.Errorf("Expected a remote host to be refused, got %v", err)
+	}
+}
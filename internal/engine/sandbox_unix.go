@@ -0,0 +1,93 @@
+//go:build unix
+
+package engine
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// sandboxCommand changes cmd to run inside a sandbox with the given policy
+// The program is run by bwrap on Linux and by sandbox-exec on macOS; the
+// other Unix systems have no sandbox goldfish can use, and a command asked
+// to run sandboxed there is refused rather than run without one.
+func sandboxCommand(cmd *exec.Cmd, policy *config.SandboxPolicy) error {
+	if policy == nil || cmd.Err != nil {
+		return nil
+	}
+
+	var wrapper []string
+	switch runtime.GOOS {
+	case "linux":
+		wrapper = bubblewrapArgs(policy)
+	case "darwin":
+		wrapper = []string{"sandbox-exec", "-p", seatbeltProfile(policy)}
+	default:
+		return fmt.Errorf("sandbox: not supported on %s", runtime.GOOS)
+	}
+	program, err := exec.LookPath(wrapper[0])
+	if err != nil {
+		return fmt.Errorf("sandbox: %s is not installed", wrapper[0])
+	}
+
+	// The wrapper runs the original program by its full path
+	args := append([]string{program}, wrapper[1:]...)
+	args = append(args, cmd.Path)
+	cmd.Args = append(args, cmd.Args[1:]...)
+	cmd.Path = program
+	return nil
+}
+
+// bubblewrapArgs returns the bwrap command line for a policy, up to the program
+// The whole file system is bound into the sandbox, read-only when the policy
+// says so. A read-only sandbox gets an empty /tmp of its own, and the allowed
+// paths are bound writable over the rest.
+func bubblewrapArgs(policy *config.SandboxPolicy) []string {
+	args := []string{"bwrap", "--die-with-parent"}
+	if policy.ReadOnly {
+		args = append(args, "--ro-bind", "/", "/", "--tmpfs", "/tmp")
+	} else {
+		args = append(args, "--bind", "/", "/")
+	}
+	for _, path := range policy.AllowPaths {
+		// --bind-try skips paths that do not exist (yet)
+		args = append(args, "--bind-try", path, path)
+	}
+	if !policy.Network {
+		args = append(args, "--unshare-net")
+	}
+	return append(args, "--")
+}
+
+// seatbeltProfile returns the sandbox-exec profile for a policy
+// Everything is allowed except what the policy forbids; later rules win,
+// so the allowed paths are listed after the ban on writing.
+func seatbeltProfile(policy *config.SandboxPolicy) string {
+	rules := []string{"(version 1)", "(allow default)"}
+	if policy.ReadOnly {
+		rules = append(rules, "(deny file-write*)")
+		writable := []string{`(subpath "/dev")`}
+		for _, path := range policy.AllowPaths {
+			// Rules match real paths, e.g. /private/tmp rather than /tmp
+			if resolved, err := filepath.EvalSymlinks(path); err == nil {
+				path = resolved
+			}
+			writable = append(writable, fmt.Sprintf("(subpath %s)", seatbeltString(path)))
+		}
+		rules = append(rules, "(allow file-write* "+strings.Join(writable, " ")+")")
+	}
+	if !policy.Network {
+		rules = append(rules, "(deny network*)")
+	}
+	return strings.Join(rules, "\n")
+}
+
+// seatbeltString quotes text as a sandbox profile string
+func seatbeltString(text string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(text) + `"`
+}
@@ -0,0 +1,95 @@
+//go:build unix
+
+package engine
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// TestBubblewrapArgs tests the bwrap command line built for a policy
+func TestBubblewrapArgs(t *testing.T) {
+	testCases := []struct {
+		policy   config.SandboxPolicy
+		expected string
+	}{
+		{config.SandboxPolicy{ReadOnly: true}, "bwrap --die-with-parent --ro-bind / / --tmpfs /tmp --unshare-net --"},
+		{config.SandboxPolicy{ReadOnly: true, Network: true, AllowPaths: []string{"/srv/out"}}, "bwrap --die-with-parent --ro-bind / / --tmpfs /tmp --bind-try /srv/out /srv/out --"},
+		{config.SandboxPolicy{}, "bwrap --die-with-parent --bind / / --unshare-net --"},
+	}
+	for _, tc := range testCases {
+		if args := strings.Join(bubblewrapArgs(&tc.policy), " "); args != tc.expected {
+			t.Errorf("%+v: expected %q, got %q", tc.policy, tc.expected, args)
+		}
+	}
+}
+
+// TestSeatbeltProfile tests the sandbox-exec profile built for a policy
+func TestSeatbeltProfile(t *testing.T) {
+	profile := seatbeltProfile(&config.SandboxPolicy{ReadOnly: true, AllowPaths: []string{`/no/such/"dir"`}})
+	expected := "(version 1)\n(allow default)\n(deny file-write*)\n" +
+		`(allow file-write* (subpath "/dev") (subpath "/no/such/\"dir\""))` + "\n(deny network*)"
+	if profile != expected {
+		t.Errorf("Expected %q, got %q", expected, profile)
+	}
+	if profile := seatbeltProfile(&config.SandboxPolicy{Network: true}); profile != "(version 1)\n(allow default)" {
+		t.Errorf("Expected a profile allowing everything, got %q", profile)
+	}
+}
+
+// TestSandboxCommand tests wrapping a program in the sandbox tool
+func TestSandboxCommand(t *testing.T) {
+	cmd := exec.Command("sh", "-c", "true")
+	if err := sandboxCommand(cmd, nil); err != nil || cmd.Args[0] != "sh" {
+		t.Fatalf("Expected the command unchanged without a policy, got %q (%v)", cmd.Args, err)
+	}
+
+	original := cmd.Path
+	err := sandboxCommand(cmd, &config.SandboxPolicy{ReadOnly: true})
+	if err != nil {
+		// Without the sandbox tool the command must be refused, never run unconfined
+		if !strings.Contains(err.Error(), "sandbox") {
+			t.Errorf("Expected a sandbox error, got %v", err)
+		}
+		return
+	}
+	args := strings.Join(cmd.Args, " ")
+	if cmd.Path == original || !strings.HasSuffix(args, original+" -c true") {
+		t.Errorf("Expected the program to be run by the sandbox tool, got %q", args)
+	}
+}
+
+// TestEngine_Execute_SandboxSteps tests that skip_if:, check: run commands
+// and cleanup steps run in the command's sandbox, like its command line
+func TestEngine_Execute_SandboxSteps(t *testing.T) {
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "marker")
+	touch := "touch " + marker
+	testCases := map[string]*config.Command{
+		"skip_if": {SkipIf: touch},
+		"check":   {Checks: []config.Check{{Run: touch}}},
+		"always":  {Always: []string{touch}},
+	}
+	engine := NewEngine(30 * time.Second)
+	for name, cmd := range testCases {
+		cmd.Name = "edit"
+		cmd.BaseCommand = "sh"
+		cmd.Platforms = map[string]config.PlatformCommand{"linux": {Template: "true"}}
+		// Without the sandbox tool the step is refused; with it, the file system is read-only
+		engine.Execute(context.Background(), &ExecutionContext{Command: cmd, Platform: platform.Linux,
+			Parameters: map[string]interface{}{}, Sandbox: true, Stdout: io.Discard, Stderr: io.Discard})
+		if _, err := os.Stat(marker); !os.IsNotExist(err) {
+			t.Errorf("%s: expected the step to be sandboxed, got %v", name, err)
+			os.Remove(marker)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+//go:build windows
+
+package engine
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+var (
+	advapi32                  = syscall.NewLazyDLL("advapi32.dll")
+	procCreateRestrictedToken = advapi32.NewProc("CreateRestrictedToken")
+	procSetTokenInformation   = advapi32.NewProc("SetTokenInformation")
+)
+
+const (
+	// disableMaxPrivilege removes every privilege from a restricted token
+	disableMaxPrivilege = 0x1
+	// tokenIntegrityLevel is the TokenIntegrityLevel information class
+	tokenIntegrityLevel = 25
+	// seGroupIntegrity marks the SID that sets a token's integrity level
+	seGroupIntegrity = 0x20
+	// administratorsSID is the local Administrators group
+	administratorsSID = "S-1-5-32-544"
+	// lowIntegritySID is the low mandatory level browsers run tabs at
+	lowIntegritySID = "S-1-16-4096"
+)
+
+// sidAndAttributes mirrors the Win32 SID_AND_ATTRIBUTES structure
+type sidAndAttributes struct {
+	Sid        *syscall.SID
+	Attributes uint32
+}
+
+// sandboxToken returns a restricted copy of goldfish's own token for a sandboxed command
+// The command loses every privilege and the rights of the Administrators
+// group. A read-only policy also lowers it to low integrity, where it can
+// only write to the few places meant for low integrity processes. Windows
+// has no way to grant it allow_paths or to block the network from here.
+func sandboxToken(policy *config.SandboxPolicy) (syscall.Token, error) {
+	process, err := syscall.GetCurrentProcess()
+	if err != nil {
+		return 0, fmt.Errorf("sandbox: %w", err)
+	}
+	var current syscall.Token
+	access := uint32(syscall.TOKEN_DUPLICATE | syscall.TOKEN_QUERY | syscall.TOKEN_ASSIGN_PRIMARY | syscall.TOKEN_ADJUST_DEFAULT)
+	if err := syscall.OpenProcessToken(process, access, &current); err != nil {
+		return 0, fmt.Errorf("sandbox: failed to open process token: %w", err)
+	}
+	defer current.Close()
+
+	admins, err := syscall.StringToSid(administratorsSID)
+	if err != nil {
+		return 0, fmt.Errorf("sandbox: %w", err)
+	}
+	denied := sidAndAttributes{Sid: admins}
+	var restricted syscall.Token
+	result, _, err := procCreateRestrictedToken.Call(uintptr(current), disableMaxPrivilege,
+		1, uintptr(unsafe.Pointer(&denied)), 0, 0, 0, 0, uintptr(unsafe.Pointer(&restricted)))
+	if result == 0 {
+		return 0, fmt.Errorf("sandbox: failed to create restricted token: %w", err)
+	}
+
+	if policy.ReadOnly {
+		low, err := syscall.StringToSid(lowIntegritySID)
+		if err != nil {
+			restricted.Close()
+			return 0, fmt.Errorf("sandbox: %w", err)
+		}
+		label := sidAndAttributes{Sid: low, Attributes: seGroupIntegrity}
+		result, _, err = procSetTokenInformation.Call(uintptr(restricted), tokenIntegrityLevel,
+			uintptr(unsafe.Pointer(&label)), unsafe.Sizeof(label)+uintptr(low.Len()))
+		if result == 0 {
+			restricted.Close()
+			return 0, fmt.Errorf("sandbox: failed to lower integrity level: %w", err)
+		}
+	}
+	return restricted, nil
+}
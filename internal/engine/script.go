@@ -0,0 +1,147 @@
+package engine
+
+import (
+	"fmt"
+
+	"go.starlark.net/starlark"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// Command scripts are Starlark programs for logic Go templates cannot express
+//
+// A script sees these predeclared values:
+//
+//	params        dict of parameter values; changes are visible to the template
+//	platform      the current platform, e.g. "linux"
+//	base_command  the command's base_command
+//	templates     dict of the command's templates by platform
+//
+// and may set these globals:
+//
+//	template  a template to render instead of the platform's own
+//	command   the finished command line; no template is rendered at all
+//
+// Starlark has no built-in file, network or environment access and load() is
+// not available, so scripts can only compute values. They are also stopped
+// after scriptMaxSteps steps.
+const scriptMaxSteps = 1000000
+
+// scriptResult holds what a command script decided
+type scriptResult struct {
+	params   map[string]interface{}
+	template string
+	command  string
+}
+
+// runScript runs a command's script for the given platform and parameters
+// The caller's parameter map is not modified
+func (e *Engine) runScript(cmd *config.Command, platformName string, params map[string]interface{}) (*scriptResult, error) {
+	paramDict := starlark.NewDict(len(params))
+	for name, value := range params {
+		converted, err := toStarlark(value)
+		if err != nil {
+			return nil, fmt.Errorf("script: parameter '%s': %w", name, err)
+		}
+		paramDict.SetKey(starlark.String(name), converted)
+	}
+	templates := starlark.NewDict(len(cmd.Platforms))
+	for name, platformCmd := range cmd.Platforms {
+		templates.SetKey(starlark.String(name), starlark.String(platformCmd.Template))
+	}
+
+	thread := &starlark.Thread{
+		Name: cmd.Name,
+		// print() output is shown with --verbose
+		Print: func(_ *starlark.Thread, msg string) { e.debugf("script: %s", msg) },
+	}
+	thread.SetMaxExecutionSteps(scriptMaxSteps)
+
+	predeclared := starlark.StringDict{
+		"params":       paramDict,
+		"platform":     starlark.String(platformName),
+		"base_command": starlark.String(cmd.BaseCommand),
+		"templates":    templates,
+	}
+	globals, err := starlark.ExecFileOptions(config.ScriptOptions, thread, cmd.Name+".star", cmd.Script, predeclared)
+	if err != nil {
+		return nil, fmt.Errorf("script failed: %w", err)
+	}
+
+	result := &scriptResult{params: make(map[string]interface{})}
+	for _, item := range paramDict.Items() {
+		name, ok := starlark.AsString(item[0])
+		if !ok {
+			return nil, fmt.Errorf("script: parameter names must be strings, got %s", item[0])
+		}
+		value, err := fromStarlark(item[1])
+		if err != nil {
+			return nil, fmt.Errorf("script: parameter '%s': %w", name, err)
+		}
+		// None removes a parameter, as if it had not been given
+		if value != nil {
+			result.params[name] = value
+		}
+	}
+	if result.template, err = scriptString(globals, "template"); err != nil {
+		return nil, err
+	}
+	if result.command, err = scriptString(globals, "command"); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// scriptString returns a string global set by a script, or "" when it is unset
+func scriptString(globals starlark.StringDict, name string) (string, error) {
+	value, ok := globals[name]
+	if !ok || value == starlark.None {
+		return "", nil
+	}
+	text, ok := starlark.AsString(value)
+	if !ok {
+		return "", fmt.Errorf("script: %s must be a string, got %s", name, value.Type())
+	}
+	return text, nil
+}
+
+// toStarlark converts a parameter value into a Starlark value
+func toStarlark(value interface{}) (starlark.Value, error) {
+	switch v := value.(type) {
+	case string:
+		return starlark.String(v), nil
+	case bool:
+		return starlark.Bool(v), nil
+	case int:
+		return starlark.MakeInt(v), nil
+	case int64:
+		return starlark.MakeInt64(v), nil
+	case float64:
+		return starlark.Float(v), nil
+	default:
+		return nil, fmt.Errorf("unsupported value type %T", value)
+	}
+}
+
+// fromStarlark converts a Starlark value back into a parameter value
+// None becomes nil; other types are not valid parameter values
+func fromStarlark(value starlark.Value) (interface{}, error) {
+	switch v := value.(type) {
+	case starlark.NoneType:
+		return nil, nil
+	case starlark.String:
+		return string(v), nil
+	case starlark.Bool:
+		return bool(v), nil
+	case starlark.Int:
+		n, ok := v.Int64()
+		if !ok {
+			return nil, fmt.Errorf("integer %s is too large", v)
+		}
+		return int(n), nil
+	case starlark.Float:
+		return float64(v), nil
+	default:
+		return nil, fmt.Errorf("unsupported value type %s", value.Type())
+	}
+}
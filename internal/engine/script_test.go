@@ -0,0 +1,112 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// TestEngine_Script tests scripts that compute defaults, pick templates and build commands
+func TestEngine_Script(t *testing.T) {
+	engine := NewEngine(0)
+	platforms := map[string]config.PlatformCommand{
+		"linux":  {Template: "make -j{{.params.jobs}} {{.params.target}}"},
+		"darwin": {},
+	}
+
+	tests := []struct {
+		name     string
+		script   string
+		platform platform.SupportedPlatform
+		params   map[string]interface{}
+		want     string
+	}{
+		{
+			name:     "computed default",
+			script:   "if 'jobs' not in params:\n    params['jobs'] = len(params['target']) * 2\n",
+			platform: platform.Linux,
+			params:   map[string]interface{}{"target": "all"},
+			want:     "make -j6 all",
+		},
+		{
+			name:     "given value kept",
+			script:   "if 'jobs' not in params:\n    params['jobs'] = 1\n",
+			platform: platform.Linux,
+			params:   map[string]interface{}{"target": "all", "jobs": 8},
+			want:     "make -j8 all",
+		},
+		{
+			name:     "chosen template",
+			script:   "if platform == 'darwin':\n    template = templates['linux'].replace('make', 'gmake')\nparams.setdefault('jobs', 2)\n",
+			platform: platform.Darwin,
+			params:   map[string]interface{}{"target": "test"},
+			want:     "gmake -j2 test",
+		},
+		{
+			name:     "generated command",
+			script:   "command = ' && '.join([base_command + ' ' + t for t in params['target'].split(',')])\n",
+			platform: platform.Linux,
+			params:   map[string]interface{}{"target": "build,test"},
+			want:     "make build && make test",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &config.Command{
+				Name:        "build",
+				BaseCommand: "make",
+				Parameters: []config.Parameter{
+					{Name: "target", Type: "string", Required: true},
+					{Name: "jobs", Type: "int"},
+				},
+				Platforms: platforms,
+				Script:    tt.script,
+			}
+			rendered, err := engine.Preview(&ExecutionContext{Command: cmd, Platform: tt.platform, Parameters: tt.params})
+			if err != nil {
+				t.Fatalf("Preview failed: %v", err)
+			}
+			if rendered != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, rendered)
+			}
+			if _, set := tt.params["jobs"]; set && tt.name == "computed default" {
+				t.Error("Expected the caller's parameters to be left unchanged")
+			}
+		})
+	}
+}
+
+// TestEngine_ScriptErrors tests scripts that fail or return unusable values
+func TestEngine_ScriptErrors(t *testing.T) {
+	engine := NewEngine(0)
+
+	tests := []struct {
+		name   string
+		script string
+		want   string
+	}{
+		{"runtime error", "fail('nope')\n", "nope"},
+		{"no filesystem", "load('os.star', 'read')\n", "script failed"},
+		{"endless loop", "for i in range(100000000):\n    pass\n", "too many steps"},
+		{"wrong type", "command = 42\n", "command must be a string"},
+		{"no template", "pass\n", "neither template nor command"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &config.Command{
+				Name:        "broken",
+				BaseCommand: "true",
+				Platforms:   map[string]config.PlatformCommand{"linux": {}},
+				Script:      tt.script,
+			}
+			_, err := engine.Preview(&ExecutionContext{Command: cmd, Platform: platform.Linux, Parameters: map[string]interface{}{}})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package engine
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// Rendered templates are run by a shell so they can use pipes, redirects and
+// the like. Most templates are written for sh (or cmd on Windows), but modern
+// Windows administration is done in PowerShell, whose commands cannot be run
+// from cmd without wrapping them in "powershell -Command" and quoting twice.
+// A template with shell: powershell is handed to PowerShell directly.
+
+// powerShells are the PowerShell programs in order of preference: PowerShell
+// 7+ (pwsh, which also runs on Linux and macOS), then Windows PowerShell 5.1,
+// which every Windows installation has
+var powerShells = []string{"pwsh", "powershell"}
+
+// SetShellProgram makes templates written for sh run with program instead,
+// e.g. "bash" for users whose templates rely on its extensions; empty
+// restores sh
+func (e *Engine) SetShellProgram(program string) {
+	e.shProgram = program
+}
+
+// shellArgv returns shellCommand's program and arguments, with the engine's
+// own program for sh when one is set
+func (e *Engine) shellArgv(shell, command string) ([]string, error) {
+	argv, err := shellCommand(shell, command)
+	if err == nil && argv[0] == "sh" && e.shProgram != "" {
+		argv[0] = e.shProgram
+	}
+	return argv, err
+}
+
+// shellCommand returns the program and arguments that run a rendered command
+// line in shell ("sh", "cmd", "powershell", or "" for the platform default)
+func shellCommand(shell, command string) ([]string, error) {
+	if shell == "" {
+		shell = "sh"
+		if isWindows() {
+			shell = "cmd"
+		}
+	}
+
+	switch shell {
+	case "sh":
+		return []string{"sh", "-c", command}, nil
+	case "cmd":
+		return []string{"cmd", "/c", command}, nil
+	case "powershell":
+		program, err := findPowerShell()
+		if err != nil {
+			return nil, err
+		}
+		// -NoProfile keeps the user's profile from changing the command's
+		// behaviour (and speeds up start-up). The command is passed as one
+		// argument: Go quotes it the way PowerShell parses its command line.
+		return []string{program, "-NoProfile", "-NonInteractive", "-Command", command}, nil
+	default:
+		return nil, fmt.Errorf("unknown shell '%s'", shell)
+	}
+}
+
+// findPowerShell returns the preferred PowerShell installed
+func findPowerShell() (string, error) {
+	for _, program := range powerShells {
+		if path, err := exec.LookPath(program); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("shell powershell: neither pwsh nor powershell is installed")
+}
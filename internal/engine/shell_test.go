@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// TestShellCommand tests the program and arguments for each shell
+func TestShellCommand(t *testing.T) {
+	defaultShell := []string{"sh", "-c", "echo hi"}
+	if runtime.GOOS == "windows" {
+		defaultShell = []string{"cmd", "/c", "echo hi"}
+	}
+	testCases := []struct {
+		shell    string
+		expected []string
+	}{
+		{"", defaultShell},
+		{"sh", []string{"sh", "-c", "echo hi"}},
+		{"cmd", []string{"cmd", "/c", "echo hi"}},
+	}
+	for _, tc := range testCases {
+		argv, err := shellCommand(tc.shell, "echo hi")
+		if err != nil || !reflect.DeepEqual(argv, tc.expected) {
+			t.Errorf("shellCommand(%q) = %v, %v; expected %v", tc.shell, argv, err, tc.expected)
+		}
+	}
+
+	if _, err := shellCommand("fish", "echo hi"); err == nil {
+		t.Error("Expected an unknown shell to be rejected")
+	}
+}
+
+// TestShellCommand_PowerShell tests preferring pwsh over Windows PowerShell
+func TestShellCommand_PowerShell(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses shell scripts as fake programs")
+	}
+	dir := t.TempDir()
+	t.Setenv("PATH", dir)
+	fake := func(name string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := shellCommand("powershell", "Get-Date"); err == nil {
+		t.Error("Expected an error without PowerShell")
+	}
+
+	fake("powershell")
+	argv, err := shellCommand("powershell", "Get-Date")
+	expected := []string{filepath.Join(dir, "powershell"), "-NoProfile", "-NonInteractive", "-Command", "Get-Date"}
+	if err != nil || !reflect.DeepEqual(argv, expected) {
+		t.Errorf("Expected %v, got %v, %v", expected, argv, err)
+	}
+
+	fake("pwsh")
+	if argv, _ := shellCommand("powershell", "Get-Date"); argv[0] != filepath.Join(dir, "pwsh") {
+		t.Errorf("Expected pwsh to be preferred, got %v", argv)
+	}
+}
+
+// TestEngine_Execute_PowerShell tests running a template with shell: powershell
+func TestEngine_Execute_PowerShell(t *testing.T) {
+	if _, err := findPowerShell(); err != nil {
+		t.Skip("PowerShell is not installed")
+	}
+	current, err := platform.NewDetector().Current()
+	if err != nil {
+		t.Skip("unsupported test platform")
+	}
+
+	cmd := &config.Command{
+		Name:        "ps-echo",
+		BaseCommand: "Write-Output",
+		Platforms: map[string]config.PlatformCommand{
+			// Quotes and $ would need escaping twice if run through cmd or sh
+			current.String(): {Template: `Write-Output "it's $(1 + 1)"`, Shell: "powershell"},
+		},
+	}
+	var stdout strings.Builder
+	ctx := &ExecutionContext{Command: cmd, Platform: current, Parameters: map[string]interface{}{}, Stdout: &stdout}
+	if _, err := NewEngine(30*time.Second).Run(context.Background(), ctx); err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+	if strings.TrimSpace(stdout.String()) != "it's 2" {
+		t.Errorf("Expected PowerShell output, got %q", stdout.String())
+	}
+}
+
+// TestEngine_SetShellProgram tests running sh templates with another program
+func TestEngine_SetShellProgram(t *testing.T) {
+	engine := NewEngine(time.Second)
+	engine.SetShellProgram("bash")
+	argv, err := engine.shellArgv("sh", "echo hi")
+	if err != nil || !reflect.DeepEqual(argv, []string{"bash", "-c", "echo hi"}) {
+		t.Errorf("Expected bash to run sh templates, got %v (%v)", argv, err)
+	}
+	if argv, _ := engine.shellArgv("cmd", "echo hi"); argv[0] != "cmd" {
+		t.Errorf("Expected other shells to be unaffected, got %v", argv)
+	}
+
+	engine.SetShellProgram("")
+	if argv, _ := engine.shellArgv("sh", "echo hi"); argv[0] != "sh" {
+		t.Errorf("Expected sh to be restored, got %v", argv)
+	}
+}
@@ -0,0 +1,43 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// skipCheck runs a command's skip_if: check and reports whether it succeeded,
+// meaning the command's work is already done and it need not run
+// The check is a command line rendered like the command's template, with
+// the same data and escaping, and run in the same shell and environment.
+// Its output is discarded, and it gets no input, so piped input is left for
+// the command.
+func (e *Engine) skipCheck(runCtx context.Context, ctx *ExecutionContext, cmd *config.Command, platformCmd *config.PlatformCommand) (bool, error) {
+	if ctx.Command.SkipIf == "" {
+		return false, nil
+	}
+	data, err := e.commandData(cmd, ctx.Platform.String(), ctx.Parameters, ctx.revealedSet())
+	if err != nil {
+		return false, err
+	}
+	// Every mocked command succeeds, so a mocked check would always make the
+	// command look done; it is rendered and recorded, and the command runs
+	if mock, ok := e.backend.(*MockBackend); ok {
+		check, err := e.renderStep(ctx, platformCmd, ctx.Command.SkipIf, data)
+		if err != nil {
+			return false, fmt.Errorf("skip_if: %w", err)
+		}
+		mock.recordSkipCheck(e.maskSecrets(ctx, check), platformCmd.Shell)
+		return false, nil
+	}
+	start := time.Now()
+	check, exitCode, err := e.runStep(runCtx, ctx, cmd, platformCmd, ctx.Command.SkipIf, data, stdio{out: io.Discard, err: io.Discard})
+	if err != nil {
+		return false, fmt.Errorf("skip_if: %w", err)
+	}
+	e.debugf("skip_if: %s exited with code %d after %v", e.maskSecrets(ctx, check), exitCode, time.Since(start))
+	return exitCode == 0, nil
+}
@@ -0,0 +1,58 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// TestEngine_Execute_SkipIf tests that a command whose skip_if: check
+// succeeds is reported as done instead of running
+func TestEngine_Execute_SkipIf(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	dir := filepath.Join(t.TempDir(), "new dir")
+	cmd := &config.Command{
+		Name:        "mkd",
+		BaseCommand: "mkdir",
+		Parameters:  []config.Parameter{{Name: "dir", Type: "string"}},
+		SkipIf:      "test -d {{.params.dir}}",
+		Platforms:   map[string]config.PlatformCommand{"linux": {Template: "mkdir {{.params.dir}} && echo made"}},
+	}
+	engine := NewEngine(30 * time.Second)
+	run := func() (*ExecutionResult, string, string) {
+		t.Helper()
+		var out, errOut bytes.Buffer
+		result, err := engine.Execute(context.Background(), &ExecutionContext{Command: cmd, Platform: platform.Linux,
+			Parameters: map[string]interface{}{"dir": dir}, Stdout: &out, Stderr: &errOut})
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		return result, out.String(), errOut.String()
+	}
+
+	// The first run does the work, with the check's output discarded
+	if result, out, errOut := run(); result.Skipped || result.ExitCode != 0 || out != "made\n" || errOut != "" {
+		t.Errorf("Expected the command to run, got %+v, %q, %q", result, out, errOut)
+	}
+	if _, err := os.Stat(dir); err != nil {
+		t.Fatalf("Expected the directory to be made: %v", err)
+	}
+	// The second finds it done
+	result, out, errOut := run()
+	if !result.Skipped || result.ExitCode != 0 || result.Attempts != 0 || out != "" {
+		t.Errorf("Expected the command to be skipped, got %+v, %q", result, out)
+	}
+	if !strings.Contains(errOut, "'mkd' is already done") {
+		t.Errorf("Expected the skip to be reported, got %q", errOut)
+	}
+}
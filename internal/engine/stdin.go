@@ -0,0 +1,104 @@
+package engine
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+)
+
+// MaxInputData caps the piped input templates can see as .stdin; larger
+// input is still streamed to .stdin_file
+const MaxInputData = 16 << 20
+
+// readsStdin matches templates that use .stdin, as opposed to .stdin_file
+var readsStdin = regexp.MustCompile(`\.stdin\b`)
+
+// Input is standard input piped to goldfish, as in "cat data | goldfish
+// transform --to json", saved to a temporary file so that templates can
+// use it and the command can still read it
+type Input struct {
+	// Path is the temporary file holding the input
+	Path string
+	// Size is the length of the input in bytes
+	Size int64
+	file *os.File
+	// text is the input, unless it is larger than MaxInputData
+	text    string
+	tooLong bool
+}
+
+// ReadInput saves r, normally goldfish's standard input, to a temporary file
+// The caller must Close the input to remove the file.
+func ReadInput(r io.Reader) (*Input, error) {
+	file, err := os.CreateTemp("", "goldfish-stdin-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to save standard input: %w", err)
+	}
+	input := &Input{Path: file.Name(), file: file}
+
+	// Keep the start of the input in memory while streaming all of it to the file
+	head := &cappedBuffer{stream: "stdin", limit: MaxInputData}
+	input.Size, err = io.Copy(io.MultiWriter(file, head), r)
+	if err != nil {
+		input.Close()
+		return nil, fmt.Errorf("failed to save standard input: %w", err)
+	}
+	input.tooLong = head.exceeded
+	if !input.tooLong {
+		input.text = head.String()
+	}
+	return input, nil
+}
+
+// Close removes the input's temporary file
+func (in *Input) Close() error {
+	if in == nil {
+		return nil
+	}
+	in.file.Close()
+	return os.Remove(in.Path)
+}
+
+// Text returns the input, or an error when it is larger than MaxInputData
+func (in *Input) Text() (string, error) {
+	if in.tooLong {
+		return "", fmt.Errorf("the piped input is %d bytes, more than %d", in.Size, MaxInputData)
+	}
+	return in.text, nil
+}
+
+// rewind returns the input, read from the start, for a command's stdin
+func (in *Input) rewind() (io.Reader, error) {
+	if _, err := in.file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to rewind standard input: %w", err)
+	}
+	return in.file, nil
+}
+
+// SetInput gives templates the piped input as .stdin and .stdin_file, and
+// commands that do not have their own stdin a copy of it; nil means none
+func (e *Engine) SetInput(in *Input) {
+	e.input = in
+}
+
+// inputData adds the piped input to a template's data
+func (e *Engine) inputData(data map[string]interface{}) {
+	if e.input == nil {
+		return
+	}
+	data["stdin"] = e.input.text
+	data["stdin_file"] = e.input.Path
+}
+
+// checkInputSize refuses a template that uses .stdin when the input is too
+// large to hold in memory, rather than rendering it empty
+func (e *Engine) checkInputSize(text string) error {
+	if e.input == nil || !readsStdin.MatchString(text) {
+		return nil
+	}
+	if _, err := e.input.Text(); err != nil {
+		return fmt.Errorf("%w; use .stdin_file instead of .stdin", err)
+	}
+	return nil
+}
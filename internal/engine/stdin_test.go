@@ -0,0 +1,107 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// TestReadInput tests saving piped input to a temporary file
+func TestReadInput(t *testing.T) {
+	input, err := ReadInput(strings.NewReader("one\ntwo\n"))
+	if err != nil {
+		t.Fatalf("ReadInput failed: %v", err)
+	}
+	if text, err := input.Text(); err != nil || text != "one\ntwo\n" || input.Size != 8 {
+		t.Errorf("Expected the input to be kept, got %q, %d bytes (%v)", text, input.Size, err)
+	}
+	if saved, err := os.ReadFile(input.Path); err != nil || string(saved) != "one\ntwo\n" {
+		t.Errorf("Expected the input in %s, got %q (%v)", input.Path, saved, err)
+	}
+	// The input can be read from the start as often as needed
+	for i := 0; i < 2; i++ {
+		r, err := input.rewind()
+		if err != nil {
+			t.Fatalf("rewind failed: %v", err)
+		}
+		if data, _ := io.ReadAll(r); string(data) != "one\ntwo\n" {
+			t.Errorf("Expected the whole input after rewinding, got %q", data)
+		}
+	}
+	if err := input.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+	if _, err := os.Stat(input.Path); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be removed, got %v", input.Path, err)
+	}
+}
+
+// TestReadInput_TooLong tests that input larger than MaxInputData is only in the file
+func TestReadInput_TooLong(t *testing.T) {
+	input, err := ReadInput(bytes.NewReader(make([]byte, MaxInputData+1)))
+	if err != nil {
+		t.Fatalf("ReadInput failed: %v", err)
+	}
+	defer input.Close()
+	if input.Size != MaxInputData+1 {
+		t.Errorf("Expected %d bytes, got %d", MaxInputData+1, input.Size)
+	}
+	if _, err := input.Text(); err == nil {
+		t.Error("Expected the input to be too long to keep")
+	}
+
+	engine := NewEngine(0)
+	engine.SetInput(input)
+	if err := engine.checkInputSize("wc -c < {{.stdin_file}}"); err != nil {
+		t.Errorf("Expected .stdin_file to be usable, got %v", err)
+	}
+	if err := engine.checkInputSize("echo {{.stdin}}"); err == nil || !strings.Contains(err.Error(), "use .stdin_file") {
+		t.Errorf("Expected .stdin to be refused, got %v", err)
+	}
+}
+
+// TestEngine_SetInput tests rendering piped input, escaped for shell, and
+// giving it to the command
+func TestEngine_SetInput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	input, err := ReadInput(strings.NewReader("a b; c\n"))
+	if err != nil {
+		t.Fatalf("ReadInput failed: %v", err)
+	}
+	defer input.Close()
+	engine := NewEngine(30 * time.Second)
+	engine.SetInput(input)
+
+	cmd := &config.Command{
+		Name:        "echo",
+		BaseCommand: "echo",
+		Platforms:   map[string]config.PlatformCommand{"linux": {Template: "echo {{.stdin}} {{.stdin_file}}; cat"}},
+	}
+	ctx := &ExecutionContext{Command: cmd, Platform: platform.Linux, Parameters: map[string]interface{}{}}
+	rendered, err := engine.Preview(ctx)
+	if err != nil {
+		t.Fatalf("Preview failed: %v", err)
+	}
+	if expected := "echo 'a b; c\n' " + input.Path + "; cat"; rendered != expected {
+		t.Errorf("Expected %q, got %q", expected, rendered)
+	}
+
+	var out bytes.Buffer
+	ctx.Stdout = &out
+	if _, err := engine.Execute(context.Background(), ctx); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if expected := "a b; c\n " + input.Path + "\na b; c\n"; out.String() != expected {
+		t.Errorf("Expected the input echoed and then read by cat, got %q", out.String())
+	}
+}
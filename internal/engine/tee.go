@@ -0,0 +1,127 @@
+package engine
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// Long maintenance commands often need their output archived. With --tee or
+// a command's log_output: the output is still streamed as usual, and copied
+// into a file with every line stamped with the time and the stream it came
+// from:
+//
+//	=== 2026-01-02T03:04:05.000Z backup: rsync -a /srv /mnt/backup
+//	2026-01-02T03:04:06.120Z stdout sending incremental file list
+//	2026-01-02T03:04:09.875Z stderr rsync: some files vanished
+//
+// The file is appended to, so repeated runs build up a history.
+
+// outputLogTimeFormat is how lines in an output log are timestamped
+const outputLogTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// outputLog is a file that command output is copied to
+// stdout and stderr are written from different goroutines, so writes are
+// serialised by mu; each stream keeps its own unfinished line.
+type outputLog struct {
+	mu      sync.Mutex
+	file    *os.File
+	streams []*outputLogStream
+	// now is the clock lines are stamped with (replaced in tests)
+	now func() time.Time
+}
+
+// openOutputLog opens the file a command's output is copied to, or returns
+// nil when there is none. ctx.OutputLog (--tee) is used as given; a command's
+// log_output: is a template rendered like the command's own.
+func (e *Engine) openOutputLog(ctx *ExecutionContext, cmd *config.Command) (*outputLog, error) {
+	path := ctx.OutputLog
+	if path == "" && cmd.LogOutput != "" {
+		data, err := e.commandData(cmd, ctx.Platform.String(), ctx.Parameters, ctx.revealedSet())
+		if err != nil {
+			return nil, err
+		}
+		path, err = e.renderString("log_output", cmd.LogOutput, data, ctx.revealedSet())
+		if err != nil {
+			return nil, fmt.Errorf("log_output: %w", err)
+		}
+	}
+	if path == "" {
+		return nil, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output log directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open output log: %w", err)
+	}
+	e.debugf("output log: %s", path)
+	return &outputLog{file: file, now: time.Now}, nil
+}
+
+// header records the start of a run, with the command line that is run
+func (l *outputLog) header(name, commandLine string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintf(l.file, "=== %s %s: %s\n", l.now().Format(outputLogTimeFormat), name, commandLine)
+}
+
+// stream returns a writer that copies one of the command's streams into the log
+func (l *outputLog) stream(label string) io.Writer {
+	stream := &outputLogStream{log: l, label: label}
+	l.streams = append(l.streams, stream)
+	return stream
+}
+
+// Close writes out unfinished lines and closes the file
+func (l *outputLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, stream := range l.streams {
+		if len(stream.partial) > 0 {
+			stream.writeLine(stream.partial)
+			stream.partial = nil
+		}
+	}
+	return l.file.Close()
+}
+
+// outputLogStream stamps each line of one stream as it is completed
+type outputLogStream struct {
+	log   *outputLog
+	label string
+	// partial is the start of a line whose end has not been written yet
+	partial []byte
+}
+
+// Write copies complete lines into the log and keeps the rest for later
+func (s *outputLogStream) Write(p []byte) (int, error) {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+
+	data := append(s.partial, p...)
+	for {
+		end := bytes.IndexByte(data, '\n')
+		if end < 0 {
+			break
+		}
+		s.writeLine(data[:end])
+		data = data[end+1:]
+	}
+	s.partial = append([]byte(nil), data...)
+	// A failing log must not break the command, so errors are not reported
+	return len(p), nil
+}
+
+// writeLine writes one stamped line; the caller holds the log's lock
+func (s *outputLogStream) writeLine(line []byte) {
+	fmt.Fprintf(s.log.file, "%s %s %s\n", s.log.now().Format(outputLogTimeFormat), s.label, bytes.TrimSuffix(line, []byte("\r")))
+}
@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// TestEngine_Execute_OutputLog tests copying output into a timestamped log
+func TestEngine_Execute_OutputLog(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+	dir := t.TempDir()
+	template := "echo one; printf 'two\\nthree' >&2"
+	cmd := &config.Command{
+		Name:        "backup",
+		BaseCommand: "sh",
+		Parameters:  []config.Parameter{{Name: "name", Type: "string"}},
+		LogOutput:   filepath.Join(dir, "logs", "{{.params.name}}.log"),
+		Platforms: map[string]config.PlatformCommand{
+			"linux":  {Template: template},
+			"darwin": {Template: template},
+		},
+	}
+	engine := NewEngine(5 * time.Second)
+	current := platform.SupportedPlatform(runtime.GOOS)
+
+	// log_output is rendered with the parameters; the output is still captured as usual
+	result, err := engine.Execute(context.Background(), &ExecutionContext{Command: cmd, Platform: current, Parameters: map[string]interface{}{"name": "nightly"}, Capture: true})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if result.Stdout != "one\n" {
+		t.Errorf("Expected output to still be captured, got %q", result.Stdout)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "logs", "nightly.log"))
+	if err != nil {
+		t.Fatalf("Expected the output log to be written: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "=== ") || !strings.HasSuffix(lines[0], "backup: "+template) {
+		t.Fatalf("Unexpected log:\n%s", data)
+	}
+	// Each line starts with a timestamp; stdout and stderr are read
+	// concurrently, so only the lines of one stream keep their order
+	var texts []string
+	for _, line := range lines[1:] {
+		stamp, text, _ := strings.Cut(line, " ")
+		if _, err := time.Parse(outputLogTimeFormat, stamp); err != nil {
+			t.Errorf("Expected a stamped line, got %q", line)
+		}
+		texts = append(texts, text)
+	}
+	sort.Strings(texts)
+	if expected := []string{"stderr three", "stderr two", "stdout one"}; strings.Join(texts, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected lines %q, got %q", expected, texts)
+	}
+
+	// --tee takes the place of log_output, and runs are appended
+	tee := filepath.Join(dir, "tee.log")
+	for i := 0; i < 2; i++ {
+		if _, err := engine.Execute(context.Background(), &ExecutionContext{Command: cmd, Platform: current, Parameters: map[string]interface{}{}, Capture: true, OutputLog: tee}); err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+	}
+	data, _ = os.ReadFile(tee)
+	if strings.Count(string(data), "=== ") != 2 {
+		t.Errorf("Expected two runs in the tee log, got:\n%s", data)
+	}
+}
@@ -0,0 +1,113 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// Executions are traced with OpenTelemetry: a span per execution, with
+// spans for its preflight checks and, for each attempt, rendering and
+// running the command. The spans go to the global tracer provider, which
+// does nothing until goldfish installs an exporter (GOLDFISH_OTEL_ENDPOINT).
+
+// tracerName identifies goldfish's spans among those of other libraries
+const tracerName = "github.com/danballance/goldfish/internal/engine"
+
+// traceContext carries trace context in the W3C traceparent and tracestate
+// formats, to and from the environment
+var traceContext = propagation.TraceContext{}
+
+// The environment variables trace context is passed in, named after the
+// W3C headers as OpenTelemetry's environment carrier does
+const (
+	TraceparentEnvVar = "TRACEPARENT"
+	TracestateEnvVar  = "TRACESTATE"
+)
+
+// parentTraceContext returns parent, in the trace goldfish was started in
+// unless parent is already part of a trace (e.g. a server request's), so
+// that when a traced process (a CI job, or goldfish itself) runs goldfish,
+// its executions join that trace
+func parentTraceContext(parent context.Context) context.Context {
+	if oteltrace.SpanContextFromContext(parent).IsValid() {
+		return parent
+	}
+	carrier := propagation.MapCarrier{
+		"traceparent": os.Getenv(TraceparentEnvVar),
+		"tracestate":  os.Getenv(TracestateEnvVar),
+	}
+	return traceContext.Extract(parent, carrier)
+}
+
+// traceEnvironment returns the variables that pass the trace context of
+// parent on to a child process, or nothing when it is not being traced
+func traceEnvironment(parent context.Context) []string {
+	if !oteltrace.SpanContextFromContext(parent).IsValid() {
+		return nil
+	}
+	carrier := propagation.MapCarrier{}
+	traceContext.Inject(parent, carrier)
+	env := []string{TraceparentEnvVar + "=" + carrier.Get("traceparent")}
+	if state := carrier.Get("tracestate"); state != "" {
+		env = append(env, TracestateEnvVar+"="+state)
+	}
+	return env
+}
+
+// startSpan starts a span as a child of parent's
+func startSpan(parent context.Context, name string, attributes ...attribute.KeyValue) (context.Context, oteltrace.Span) {
+	return otel.Tracer(tracerName).Start(parent, name, oteltrace.WithAttributes(attributes...))
+}
+
+// endSpan ends a span, marking it as failed with err, whose message has the
+// command's secrets masked
+func (e *Engine) endSpan(ctx *ExecutionContext, span oteltrace.Span, err error) {
+	if err != nil && span.IsRecording() {
+		message := err.Error()
+		if ctx.Command != nil {
+			message = e.maskSecrets(ctx, message)
+		}
+		span.AddEvent("exception", oteltrace.WithAttributes(
+			attribute.String("exception.message", message)))
+		span.SetStatus(codes.Error, message)
+	}
+	span.End()
+}
+
+// startExecutionSpan starts the span of an execution of ctx's command as a
+// child of parent's, or of the trace goldfish was started in
+func startExecutionSpan(parent context.Context, ctx *ExecutionContext) (context.Context, oteltrace.Span) {
+	name := "goldfish"
+	attributes := []attribute.KeyValue{attribute.String("goldfish.platform", ctx.Platform.String())}
+	if ctx.Command != nil {
+		name += " " + ctx.Command.Name
+		attributes = append(attributes, attribute.String("goldfish.command", ctx.Command.Name))
+	}
+	return startSpan(parentTraceContext(parent), name, attributes...)
+}
+
+// endExecutionSpan records the result of an execution on its span and ends it
+func (e *Engine) endExecutionSpan(ctx *ExecutionContext, span oteltrace.Span, result *ExecutionResult, err error) {
+	span.SetAttributes(
+		attribute.Int("goldfish.attempts", result.Attempts),
+		attribute.Bool("goldfish.cached", result.Cached),
+		attribute.Bool("goldfish.skipped", result.Skipped))
+	e.endCommandSpan(ctx, span, result.ExitCode, err)
+}
+
+// endCommandSpan records a command's exit code on a span and ends it; a
+// non-zero exit code fails the span, though it is not an exception
+func (e *Engine) endCommandSpan(ctx *ExecutionContext, span oteltrace.Span, exitCode int, err error) {
+	span.SetAttributes(attribute.Int("process.exit.code", exitCode))
+	if err == nil && exitCode != 0 {
+		span.SetStatus(codes.Error, fmt.Sprintf("exited with code %d", exitCode))
+	}
+	e.endSpan(ctx, span, err)
+}
@@ -0,0 +1,118 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// recordSpans installs a tracer provider that keeps the spans ended for the rest of the test
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+	return recorder
+}
+
+// TestEngine_Execute_Telemetry tests the spans of an execution and that the
+// command is given the trace context of its attempt
+func TestEngine_Execute_Telemetry(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	recorder := recordSpans(t)
+	// The trace goldfish was started in is joined
+	t.Setenv(TraceparentEnvVar, "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	cmd := &config.Command{
+		Name:        "flaky",
+		BaseCommand: "sh",
+		Retry:       &config.RetryPolicy{Attempts: 2},
+		Platforms:   map[string]config.PlatformCommand{"linux": {Template: "echo $TRACEPARENT; exit 3"}},
+	}
+	var out bytes.Buffer
+	result, err := NewEngine(30*time.Second).Execute(context.Background(), &ExecutionContext{Command: cmd, Platform: platform.Linux,
+		Parameters: map[string]interface{}{}, Stdout: &out})
+	if err != nil || result.ExitCode != 3 {
+		t.Fatalf("Expected the command to fail, got %+v (%v)", result, err)
+	}
+
+	var names []string
+	spans := recorder.Ended()
+	for _, span := range spans {
+		names = append(names, span.Name())
+	}
+	if strings.Join(names, ",") != "preflight,render,exec,render,exec,goldfish flaky" {
+		t.Fatalf("Unexpected spans %v", names)
+	}
+	root := spans[len(spans)-1]
+	if root.SpanContext().TraceID().String() != "0af7651916cd43dd8448eb211c80319c" || root.Parent().SpanID().String() != "b7ad6b7169203331" {
+		t.Errorf("Expected the execution to join the parent trace, got parent %v", root.Parent())
+	}
+	if root.Status().Description != "exited with code 3" || len(root.Events()) != 1 || root.Events()[0].Name != "retry" {
+		t.Errorf("Unexpected execution span status %v and events %v", root.Status(), root.Events())
+	}
+
+	// Each attempt sees its own exec span as its parent
+	lines := strings.Fields(out.String())
+	for i, exec := range []sdktrace.ReadOnlySpan{spans[2], spans[4]} {
+		expected := "00-" + exec.SpanContext().TraceID().String() + "-" + exec.SpanContext().SpanID().String() + "-01"
+		if i >= len(lines) || lines[i] != expected {
+			t.Errorf("Attempt %d: expected TRACEPARENT %s, got %q", i+1, expected, out.String())
+		}
+	}
+}
+
+// TestEngine_Execute_TelemetryParent tests that an execution joins the trace
+// of the context it is given before the one goldfish was started in
+func TestEngine_Execute_TelemetryParent(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	recorder := recordSpans(t)
+	t.Setenv(TraceparentEnvVar, "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	parent, request := otel.Tracer("test").Start(context.Background(), "request")
+	cmd := &config.Command{Name: "hello", BaseCommand: "true", Platforms: map[string]config.PlatformCommand{"linux": {Template: "true"}}}
+	if _, err := NewEngine(30*time.Second).Execute(parent, &ExecutionContext{Command: cmd, Platform: platform.Linux, Parameters: map[string]interface{}{}}); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	request.End()
+
+	spans := recorder.Ended()
+	root := spans[len(spans)-2]
+	if root.Name() != "goldfish hello" || root.Parent().SpanID() != request.SpanContext().SpanID() {
+		t.Errorf("Expected %s to be a child of the request span, got parent %v", root.Name(), root.Parent())
+	}
+}
+
+// TestEngine_Execute_TelemetryOff tests that without a tracer provider the
+// command is not given a trace context
+func TestEngine_Execute_TelemetryOff(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	t.Setenv(TraceparentEnvVar, "")
+	cmd := &config.Command{
+		Name:        "hello",
+		BaseCommand: "sh",
+		Platforms:   map[string]config.PlatformCommand{"linux": {Template: "echo \"[$TRACEPARENT]\""}},
+	}
+	var out bytes.Buffer
+	if _, err := NewEngine(30*time.Second).Execute(context.Background(), &ExecutionContext{Command: cmd, Platform: platform.Linux,
+		Parameters: map[string]interface{}{}, Stdout: &out}); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if out.String() != "[]\n" {
+		t.Errorf("Expected no TRACEPARENT, got %q", out.String())
+	}
+}
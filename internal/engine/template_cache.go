@@ -0,0 +1,41 @@
+package engine
+
+import "text/template"
+
+// Parsing a template costs far more than executing it, and servers, batches
+// and the UI render the same commands over and over. Parsed command templates
+// are therefore kept in Engine.templates, keyed by command and platform.
+// Parsed templates are safe to execute concurrently.
+
+// templateKey identifies a command's template for one platform
+type templateKey struct {
+	command  string
+	platform string
+}
+
+// cachedTemplate is a parsed template together with the text it came from
+// Keeping the text means a command redefined with a new template (e.g. after
+// a config reload) is parsed again instead of using the stale entry.
+type cachedTemplate struct {
+	text     string
+	template *template.Template
+}
+
+// cachedTemplate returns the parsed template for a command on a platform
+// It parses text on first use, or when it differs from the cached text.
+// shell is the shell the template runs in, which parameter values are
+// escaped for; a platform entry always runs in the same one.
+func (e *Engine) cachedTemplate(command, platformName, shell, text string) (*template.Template, error) {
+	key := templateKey{command: command, platform: platformName}
+	if entry, ok := e.templates.Load(key); ok && entry.(*cachedTemplate).text == text {
+		metricTemplateCacheHits.Add(1)
+		return entry.(*cachedTemplate).template, nil
+	}
+
+	tmpl, err := e.parseCommandTemplate(text, shell)
+	if err != nil {
+		return nil, err
+	}
+	e.templates.Store(key, &cachedTemplate{text: text, template: tmpl})
+	return tmpl, nil
+}
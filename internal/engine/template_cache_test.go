@@ -0,0 +1,85 @@
+package engine
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// TestEngine_TemplateCache tests that templates are parsed once per command and platform
+func TestEngine_TemplateCache(t *testing.T) {
+	engine := NewEngine(time.Second)
+	cmd := &config.Command{Name: "greet", BaseCommand: "echo"}
+	platformCmd := &config.PlatformCommand{Template: "{{.base_command}} {{.params.name}}"}
+
+	before := CurrentStats().TemplateCacheHits
+	for _, name := range []string{"a", "b"} {
+		rendered, err := engine.renderTemplate(cmd, "linux", platformCmd, map[string]interface{}{"name": name}, nil)
+		if err != nil {
+			t.Fatalf("renderTemplate failed: %v", err)
+		}
+		if rendered != "echo "+name {
+			t.Errorf("Expected 'echo %s', got %q", name, rendered)
+		}
+	}
+	if hits := CurrentStats().TemplateCacheHits - before; hits != 1 {
+		t.Errorf("Expected the second render to hit the cache, got %d hits", hits)
+	}
+
+	// A changed template for the same command is parsed again
+	platformCmd.Template = "{{.base_command}} hi {{.params.name}}"
+	rendered, err := engine.renderTemplate(cmd, "linux", platformCmd, map[string]interface{}{"name": "c"}, nil)
+	if err != nil {
+		t.Fatalf("renderTemplate failed: %v", err)
+	}
+	if rendered != "echo hi c" {
+		t.Errorf("Expected the new template to be used, got %q", rendered)
+	}
+
+	// Templates that fail to parse are not cached
+	platformCmd.Template = "{{.params.name"
+	for i := 0; i < 2; i++ {
+		if _, err := engine.renderTemplate(cmd, "linux", platformCmd, map[string]interface{}{}, nil); err == nil {
+			t.Error("Expected parse error")
+		}
+	}
+}
+
+// TestEngine_TemplateCache_Concurrent tests rendering the same command from many goroutines
+func TestEngine_TemplateCache_Concurrent(t *testing.T) {
+	engine := NewEngine(time.Second)
+	cmd := &config.Command{Name: "greet", BaseCommand: "echo"}
+	platformCmd := &config.PlatformCommand{Template: "{{.base_command}} {{.params.n}}"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			if _, err := engine.renderTemplate(cmd, "linux", platformCmd, map[string]interface{}{"n": n}, nil); err != nil {
+				t.Errorf("renderTemplate failed: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+// BenchmarkEngine_renderTemplate_Uncached parses the template on every render,
+// as the engine did before templates were cached; compare with
+// BenchmarkEngine_renderTemplate
+func BenchmarkEngine_renderTemplate_Uncached(b *testing.B) {
+	engine := NewEngine(time.Second)
+	cmd := &config.Command{
+		BaseCommand: "echo",
+	}
+	params := map[string]interface{}{
+		"message": "benchmark test",
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = engine.renderString("command", "{{.base_command}} '{{.params.message}}'", templateData(cmd, "linux", params), nil)
+	}
+}
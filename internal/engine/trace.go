@@ -0,0 +1,9 @@
+package engine
+
+import "github.com/danballance/goldfish/internal/trace"
+
+// SetTrace makes the engine record how long rendering templates and running
+// commands take, for --trace; pass nil to stop
+func (e *Engine) SetTrace(recorder *trace.Recorder) {
+	e.trace = recorder
+}
@@ -0,0 +1,48 @@
+package engine
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+	"github.com/danballance/goldfish/internal/trace"
+)
+
+// TestEngine_SetTrace tests timing the rendering and running of a command
+func TestEngine_SetTrace(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+
+	engine := NewEngine(5 * time.Second)
+	recorder := trace.New()
+	engine.SetTrace(recorder)
+	ctx := &ExecutionContext{
+		Command: &config.Command{
+			Name:        "noop",
+			BaseCommand: "true",
+			Platforms: map[string]config.PlatformCommand{
+				runtime.GOOS: {Template: "{{.base_command}}"},
+			},
+		},
+		Platform:   platform.SupportedPlatform(runtime.GOOS),
+		Parameters: map[string]interface{}{},
+	}
+	if err := engine.ExecuteStatus(context.Background(), ctx); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if _, err := engine.Preview(ctx); err != nil {
+		t.Fatalf("Preview() failed: %v", err)
+	}
+
+	counts := make(map[string]int)
+	for _, phase := range recorder.Phases() {
+		counts[phase.Name] = phase.Count
+	}
+	if counts[trace.PhaseRender] != 2 || counts[trace.PhaseExecute] != 1 {
+		t.Errorf("Expected two renders and one execution, got %v", counts)
+	}
+}
@@ -0,0 +1,74 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// ExitCodeUnsupportedPlatform is the exit code used when a command cannot run
+// on the current platform. It matches EX_UNAVAILABLE from sysexits.h so that
+// scripts can tell "not available here" apart from an ordinary failure.
+const ExitCodeUnsupportedPlatform = 69
+
+// UnsupportedPlatformError reports that a command has no template for the current platform
+// Its message is a short report of where the command does work and what the
+// user can do about it, rather than a bare "not supported" error
+type UnsupportedPlatformError struct {
+	// Command is the name of the command that was requested
+	Command string
+	// Platform is the platform goldfish is running on
+	Platform string
+	// SupportedPlatforms lists the platforms the command does have templates for
+	SupportedPlatforms []string
+	// Fallback is another command suggested by the configuration, if any
+	Fallback string
+	// InstallHint is advice for making the command work on this platform, if any
+	InstallHint string
+}
+
+// NewUnsupportedPlatformError builds the report for a command on an unsupported platform
+func NewUnsupportedPlatformError(cmd *config.Command, platformName string) *UnsupportedPlatformError {
+	supported := make([]string, 0, len(cmd.Platforms))
+	for name := range cmd.Platforms {
+		supported = append(supported, name)
+	}
+	// Map iteration order is random, so sort for a stable report
+	sort.Strings(supported)
+
+	return &UnsupportedPlatformError{
+		Command:            cmd.Name,
+		Platform:           platformName,
+		SupportedPlatforms: supported,
+		Fallback:           cmd.Fallback,
+		InstallHint:        cmd.InstallHints[platformName],
+	}
+}
+
+// Error implements the error interface, returning the full report
+func (e *UnsupportedPlatformError) Error() string {
+	var report strings.Builder
+	fmt.Fprintf(&report, "command '%s' not supported on platform '%s'", e.Command, e.Platform)
+	if len(e.SupportedPlatforms) > 0 {
+		fmt.Fprintf(&report, "\n  supported platforms: %s", strings.Join(e.SupportedPlatforms, ", "))
+	}
+	if e.Fallback != "" {
+		fmt.Fprintf(&report, "\n  fallback: try 'goldfish %s' instead", e.Fallback)
+	}
+	if e.InstallHint != "" {
+		fmt.Fprintf(&report, "\n  hint: %s", e.InstallHint)
+	}
+	return report.String()
+}
+
+// ExitCode returns the dedicated exit code for unsupported platforms
+func (e *UnsupportedPlatformError) ExitCode() int {
+	return ExitCodeUnsupportedPlatform
+}
+
+// Is reports whether target is ErrPlatformUnsupported
+func (e *UnsupportedPlatformError) Is(target error) bool {
+	return target == ErrPlatformUnsupported
+}
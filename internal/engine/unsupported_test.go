@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// TestUnsupportedPlatformError tests the degradation report
+func TestUnsupportedPlatformError(t *testing.T) {
+	cmd := &config.Command{
+		Name: "gnu-sed",
+		Platforms: map[string]config.PlatformCommand{
+			"linux":  {Template: "sed"},
+			"darwin": {Template: "gsed"},
+		},
+		Fallback:     "replace",
+		InstallHints: map[string]string{"windows": "install sed via scoop"},
+	}
+
+	err := NewUnsupportedPlatformError(cmd, "windows")
+	report := err.Error()
+
+	expected := []string{
+		"command 'gnu-sed' not supported on platform 'windows'",
+		"supported platforms: darwin, linux",
+		"fallback: try 'goldfish replace' instead",
+		"hint: install sed via scoop",
+	}
+	for _, line := range expected {
+		if !strings.Contains(report, line) {
+			t.Errorf("Expected report to contain %q, got:\n%s", line, report)
+		}
+	}
+
+	if err.ExitCode() != ExitCodeUnsupportedPlatform {
+		t.Errorf("Expected exit code %d, got %d", ExitCodeUnsupportedPlatform, err.ExitCode())
+	}
+}
+
+// TestUnsupportedPlatformError_Minimal tests the report without optional details
+func TestUnsupportedPlatformError_Minimal(t *testing.T) {
+	cmd := &config.Command{
+		Name:      "only-linux",
+		Platforms: map[string]config.PlatformCommand{"linux": {Template: "true"}},
+	}
+
+	report := NewUnsupportedPlatformError(cmd, "darwin").Error()
+	if strings.Contains(report, "fallback") || strings.Contains(report, "hint") {
+		t.Errorf("Expected no fallback or hint lines, got:\n%s", report)
+	}
+}
+
+// TestEngine_Execute_UnsupportedPlatform tests that Execute returns the typed report
+func TestEngine_Execute_UnsupportedPlatform(t *testing.T) {
+	engine := NewEngine(time.Second)
+	ctx := &ExecutionContext{
+		Command: &config.Command{
+			Name:      "only-linux",
+			Platforms: map[string]config.PlatformCommand{"linux": {Template: "true"}},
+		},
+		Platform:   platform.Windows,
+		Parameters: map[string]interface{}{},
+	}
+
+	var unsupported *UnsupportedPlatformError
+	if err := engine.ExecuteStatus(context.Background(), ctx); !errors.As(err, &unsupported) {
+		t.Fatalf("Expected UnsupportedPlatformError, got %v", err)
+	}
+	if unsupported.Platform != "windows" {
+		t.Errorf("Expected platform windows, got %s", unsupported.Platform)
+	}
+}
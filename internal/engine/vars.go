@@ -0,0 +1,46 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// commandData returns the data a command's templates are rendered against
+// That is templateData plus the globals as .globals, the selected profile's
+// name as .profile, any piped input as .stdin and .stdin_file (see
+// stdin.go) and the command's vars as .vars. Vars are rendered in
+// dependency order (see config.Command.VarOrder) and see the same data, the
+// vars computed so far and the environment as .env.
+func (e *Engine) commandData(cmd *config.Command, platformName string, params map[string]interface{}, revealed *revealedSecrets) (map[string]interface{}, error) {
+	data := templateData(cmd, platformName, params)
+	globals, err := e.globalData(platformName, revealed)
+	if err != nil {
+		return nil, err
+	}
+	data["globals"] = globals
+	data["profile"] = e.profileName()
+	e.inputData(data)
+	if len(cmd.Vars) == 0 {
+		return data, nil
+	}
+	order, err := cmd.VarOrder()
+	if err != nil {
+		return nil, err
+	}
+
+	vars := make(map[string]string, len(order))
+	varData := map[string]interface{}{"vars": vars, "env": e.environmentData()}
+	for key, value := range data {
+		varData[key] = value
+	}
+	for _, name := range order {
+		value, err := e.renderString("var "+name, cmd.Vars[name], varData, revealed)
+		if err != nil {
+			return nil, fmt.Errorf("var '%s': %w", name, err)
+		}
+		vars[name] = value
+	}
+	data["vars"] = vars
+	return data, nil
+}
@@ -0,0 +1,49 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// TestEngine_Vars tests rendering templates with computed vars
+func TestEngine_Vars(t *testing.T) {
+	t.Setenv("TEMP", `C:\Temp`)
+	cmd := &config.Command{
+		Name:        "archive",
+		BaseCommand: "tar",
+		Parameters:  []config.Parameter{{Name: "name", Type: "string"}},
+		Vars: map[string]string{
+			"tmp":     `{{if eq .platform.os "windows"}}{{.env.TEMP}}{{else}}/tmp{{end}}`,
+			"archive": `{{.vars.tmp}}/{{.params.name}}-{{now.Format "2006"}}.tar`,
+		},
+		Platforms: map[string]config.PlatformCommand{
+			"linux":   {Template: "{{.base_command}} -cf {{.vars.archive}} ."},
+			"windows": {Template: "{{.base_command}} -cf {{.vars.archive}} ."},
+		},
+	}
+	year := time.Now().Format("2006")
+	engine := NewEngine(0)
+
+	rendered, err := engine.Preview(&ExecutionContext{Command: cmd, Platform: platform.Linux, Parameters: map[string]interface{}{"name": "site"}})
+	if err != nil {
+		t.Fatalf("Preview failed: %v", err)
+	}
+	if rendered != "tar -cf /tmp/site-"+year+".tar ." {
+		t.Errorf("Unexpected command line: %q", rendered)
+	}
+
+	rendered, err = engine.Preview(&ExecutionContext{Command: cmd, Platform: platform.Windows, Parameters: map[string]interface{}{"name": "site"}})
+	if err != nil || !strings.HasPrefix(rendered, `tar -cf "C:\Temp/site-`) {
+		t.Errorf("Expected the Windows temp dir, got %q (%v)", rendered, err)
+	}
+
+	// Errors in a var name it
+	cmd.Vars["tmp"] = "{{.nope.x | bad}}"
+	if _, err := engine.Preview(&ExecutionContext{Command: cmd, Platform: platform.Linux, Parameters: map[string]interface{}{}}); err == nil || !strings.Contains(err.Error(), "var 'tmp'") {
+		t.Errorf("Expected the failing var to be named, got %v", err)
+	}
+}
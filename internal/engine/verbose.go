@@ -0,0 +1,21 @@
+package engine
+
+import (
+	"fmt"
+	"io"
+)
+
+// SetVerboseOutput enables verbose logging of each execution to w
+// The log shows the chosen platform, the template, the rendered command, the
+// working directory, environment overrides and timing. Pass nil to disable it.
+func (e *Engine) SetVerboseOutput(w io.Writer) {
+	e.verboseOutput = w
+}
+
+// debugf writes a verbose log line when verbose output is enabled
+func (e *Engine) debugf(format string, args ...interface{}) {
+	if e.verboseOutput == nil {
+		return
+	}
+	fmt.Fprintf(e.verboseOutput, "[goldfish] "+format+"\n", args...)
+}
@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// TestEngine_SetVerboseOutput tests the verbose execution log
+func TestEngine_SetVerboseOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+
+	engine := NewEngine(5 * time.Second)
+	var log bytes.Buffer
+	engine.SetVerboseOutput(&log)
+
+	ctx := &ExecutionContext{
+		Command: &config.Command{
+			Name:            "noop",
+			BaseCommand:     "true",
+			NormalizeLocale: true,
+			Platforms: map[string]config.PlatformCommand{
+				runtime.GOOS: {Template: "{{.base_command}} --ignored"},
+			},
+		},
+		Platform:   platform.SupportedPlatform(runtime.GOOS),
+		Parameters: map[string]interface{}{},
+	}
+	if err := engine.ExecuteStatus(context.Background(), ctx); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	output := log.String()
+	expected := []string{
+		"[goldfish] command: noop",
+		"[goldfish] platform: " + runtime.GOOS,
+		"[goldfish] template: {{.base_command}} --ignored",
+		"[goldfish] rendered: true --ignored",
+		"[goldfish] working directory: ",
+		"LC_ALL=" + DefaultLocale,
+		"with exit code 0",
+	}
+	for _, line := range expected {
+		if !strings.Contains(output, line) {
+			t.Errorf("Expected verbose output to contain %q, got:\n%s", line, output)
+		}
+	}
+}
+
+// TestEngine_debugf_Disabled tests that nothing is logged by default
+func TestEngine_debugf_Disabled(t *testing.T) {
+	engine := NewEngine(time.Second)
+	// Must not panic or write anywhere without an output configured
+	engine.debugf("ignored %d", 1)
+
+	var log bytes.Buffer
+	engine.SetVerboseOutput(&log)
+	engine.SetVerboseOutput(nil)
+	engine.debugf("ignored %d", 2)
+	if log.Len() != 0 {
+		t.Errorf("Expected no output after disabling verbose mode, got %q", log.String())
+	}
+}
@@ -0,0 +1,238 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// WASM modules extend templates and parameter validation (see config/wasm.go)
+//
+// A module talks to goldfish through strings in its linear memory:
+//
+//   - it exports its memory as "memory" and a function
+//     goldfish_alloc(size i32) -> i32 that returns room for size bytes
+//   - helper_<name>(ptr i32, len i32) -> i64 becomes the template function
+//     <name>, e.g. {{slug .params.title}}; it receives the argument as a string
+//     and returns the result packed as ptr<<32 | len
+//   - validate_<name>(ptr i32, len i32) -> i64 becomes a validator that
+//     parameters reference with "validate: <name>"; it returns an error message
+//     packed the same way, or 0 when the value is valid
+//
+// Modules may import WASI so that toolchains such as TinyGo and Rust work, but
+// they get no files, environment, arguments or network, their memory is capped
+// and every call must finish within wasmCallTimeout.
+const (
+	wasmHelperPrefix    = "helper_"
+	wasmValidatorPrefix = "validate_"
+	wasmAllocFunction   = "goldfish_alloc"
+	// wasmMemoryLimitPages caps each module's memory at 16MB (64KB pages)
+	wasmMemoryLimitPages = 256
+	// wasmCallTimeout bounds a single helper or validator call
+	wasmCallTimeout = time.Second
+)
+
+// wasmFunction is an exported helper or validator of a loaded module
+type wasmFunction struct {
+	module *wasmModule
+	fn     api.Function
+}
+
+// wasmModule is an instantiated module
+type wasmModule struct {
+	name     string
+	instance api.Module
+	alloc    api.Function
+}
+
+// wasmExtensions holds the helpers and validators of every configured module
+// Module instances are not safe for concurrent use, so calls are serialised
+type wasmExtensions struct {
+	mu         sync.Mutex
+	helpers    map[string]*wasmFunction
+	validators map[string]*wasmFunction
+}
+
+// SetWasmModules configures the WASM modules that provide template helpers and validators
+// Modules are only loaded when a template or validator first needs them
+func (e *Engine) SetWasmModules(modules []config.WasmModule) {
+	e.wasmModules = modules
+	e.wasmOnce = sync.Once{}
+	e.wasm, e.wasmErr = nil, nil
+	// Cached templates were parsed with the previous modules' helpers
+	e.templates.Clear()
+}
+
+// wasmExtensions loads the configured modules on first use
+// Without configured modules it returns nil, which has no helpers or validators
+func (e *Engine) wasmExtensions() (*wasmExtensions, error) {
+	if len(e.wasmModules) == 0 {
+		return nil, nil
+	}
+	e.wasmOnce.Do(func() {
+		e.wasm, e.wasmErr = loadWasmModules(e.wasmModules, e.templateFuncs())
+	})
+	return e.wasm, e.wasmErr
+}
+
+// loadWasmModules compiles and instantiates modules in a shared sandboxed runtime
+// reserved holds template function names that helpers may not replace
+func loadWasmModules(modules []config.WasmModule, reserved map[string]interface{}) (*wasmExtensions, error) {
+	ctx := context.Background()
+	runtime := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(wasmMemoryLimitPages).
+		WithCloseOnContextDone(true))
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, runtime); err != nil {
+		return nil, fmt.Errorf("failed to set up WASM runtime: %w", err)
+	}
+
+	extensions := &wasmExtensions{
+		helpers:    make(map[string]*wasmFunction),
+		validators: make(map[string]*wasmFunction),
+	}
+	for _, module := range modules {
+		if err := extensions.load(ctx, runtime, module, reserved); err != nil {
+			runtime.Close(ctx)
+			return nil, fmt.Errorf("wasm module '%s': %w", module.Name, err)
+		}
+	}
+	return extensions, nil
+}
+
+// load instantiates one module and registers its helpers and validators
+func (w *wasmExtensions) load(ctx context.Context, runtime wazero.Runtime, module config.WasmModule, reserved map[string]interface{}) error {
+	code, err := os.ReadFile(module.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", module.Path, err)
+	}
+	compiled, err := runtime.CompileModule(ctx, code)
+	if err != nil {
+		return fmt.Errorf("failed to compile %s: %w", module.Path, err)
+	}
+	// Reactor-style modules (TinyGo, Rust) initialise themselves in _initialize;
+	// start functions the module does not export are skipped
+	instance, err := runtime.InstantiateModule(ctx, compiled, wazero.NewModuleConfig().
+		WithName(module.Name).
+		WithStartFunctions("_initialize"))
+	if err != nil {
+		return fmt.Errorf("failed to instantiate %s: %w", module.Path, err)
+	}
+
+	loaded := &wasmModule{name: module.Name, instance: instance, alloc: instance.ExportedFunction(wasmAllocFunction)}
+	if loaded.alloc == nil || instance.Memory() == nil {
+		return fmt.Errorf("module must export memory and %s", wasmAllocFunction)
+	}
+
+	for export, definition := range compiled.ExportedFunctions() {
+		var registry map[string]*wasmFunction
+		var name string
+		switch {
+		case strings.HasPrefix(export, wasmHelperPrefix):
+			registry, name = w.helpers, strings.TrimPrefix(export, wasmHelperPrefix)
+			if _, taken := reserved[name]; taken {
+				return fmt.Errorf("helper '%s' would replace a built-in template function", name)
+			}
+		case strings.HasPrefix(export, wasmValidatorPrefix):
+			registry, name = w.validators, strings.TrimPrefix(export, wasmValidatorPrefix)
+		default:
+			continue
+		}
+
+		if !isStringFunction(definition) {
+			return fmt.Errorf("%s must take (i32, i32) and return i64", export)
+		}
+		if _, taken := registry[name]; taken {
+			return fmt.Errorf("%s is already provided by another module", export)
+		}
+		registry[name] = &wasmFunction{module: loaded, fn: instance.ExportedFunction(export)}
+	}
+	return nil
+}
+
+// isStringFunction reports whether a function has the string-in, string-out signature
+func isStringFunction(definition api.FunctionDefinition) bool {
+	params, results := definition.ParamTypes(), definition.ResultTypes()
+	return len(params) == 2 && params[0] == api.ValueTypeI32 && params[1] == api.ValueTypeI32 &&
+		len(results) == 1 && results[0] == api.ValueTypeI64
+}
+
+// templateFuncs returns the helpers as template functions
+func (w *wasmExtensions) templateFuncs() map[string]interface{} {
+	funcs := make(map[string]interface{})
+	if w == nil {
+		return funcs
+	}
+	for name, helper := range w.helpers {
+		helper := helper
+		funcs[name] = func(value interface{}) (string, error) {
+			return w.call(helper, fmt.Sprint(value))
+		}
+	}
+	return funcs
+}
+
+// validate runs the named validator against a parameter value
+func (w *wasmExtensions) validate(name string, value interface{}) error {
+	var validator *wasmFunction
+	if w != nil {
+		validator = w.validators[name]
+	}
+	if validator == nil {
+		return fmt.Errorf("unknown validator '%s'", name)
+	}
+	message, err := w.call(validator, fmt.Sprint(value))
+	if err != nil {
+		return err
+	}
+	if message != "" {
+		return fmt.Errorf("%s", message)
+	}
+	return nil
+}
+
+// call passes input to a module function and returns the string it produces
+func (w *wasmExtensions) call(function *wasmFunction, input string) (string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), wasmCallTimeout)
+	defer cancel()
+
+	module := function.module
+	name := module.name + "." + function.fn.Definition().ExportNames()[0]
+
+	// Copy the input into memory the module allocated for it
+	results, err := module.alloc.Call(ctx, uint64(len(input)))
+	if err != nil {
+		return "", fmt.Errorf("%s: allocation failed: %w", name, err)
+	}
+	ptr := uint32(results[0])
+	if !module.instance.Memory().WriteString(ptr, input) {
+		return "", fmt.Errorf("%s: allocation out of range", name)
+	}
+
+	results, err = function.fn.Call(ctx, uint64(ptr), uint64(len(input)))
+	if err != nil {
+		return "", fmt.Errorf("%s failed: %w", name, err)
+	}
+
+	// The result is packed as ptr<<32 | len
+	resultPtr, resultLen := uint32(results[0]>>32), uint32(results[0])
+	if resultLen == 0 {
+		return "", nil
+	}
+	output, ok := module.instance.Memory().Read(resultPtr, resultLen)
+	if !ok {
+		return "", fmt.Errorf("%s: result out of range", name)
+	}
+	return string(output), nil
+}
@@ -0,0 +1,189 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// testWasmModule assembles a minimal module by hand, as no WASM toolchain is
+// needed to run the tests. It exports:
+//
+//	goldfish_alloc      a bump allocator
+//	helper_echo         returns its input unchanged
+//	helper_fail         traps
+//	validate_nonempty   rejects the empty string with "must not be empty"
+func testWasmModule() []byte {
+	const message = "must not be empty"
+	const messageAt = 1024
+
+	section := func(id byte, content ...byte) []byte {
+		return append([]byte{id, byte(len(content))}, content...)
+	}
+	name := func(s string) []byte {
+		return append([]byte{byte(len(s))}, s...)
+	}
+	export := func(s string, kind, index byte) []byte {
+		return append(name(s), kind, index)
+	}
+	body := func(code ...byte) []byte {
+		// Each body is its size, an empty locals vector and the code
+		return append([]byte{byte(len(code) + 1), 0}, code...)
+	}
+	// sleb encodes a signed LEB128 integer, as used by the const instructions
+	sleb := func(v int64) []byte {
+		var out []byte
+		for {
+			b := byte(v & 0x7f)
+			v >>= 7
+			if (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0) {
+				return append(out, b)
+			}
+			out = append(out, b|0x80)
+		}
+	}
+
+	module := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	// Types: 0 is (i32) -> i32, 1 is (i32, i32) -> i64
+	module = append(module, section(1, 2, 0x60, 1, 0x7f, 1, 0x7f, 0x60, 2, 0x7f, 0x7f, 1, 0x7e)...)
+	// Functions: alloc, echo, fail, nonempty
+	module = append(module, section(3, 4, 0, 1, 1, 1)...)
+	// Memory: one page
+	module = append(module, section(5, 1, 0, 1)...)
+	// Global 0: the mutable allocation pointer, starting at 2048
+	module = append(module, section(6, append(append([]byte{1, 0x7f, 1, 0x41}, sleb(2048)...), 0x0b)...)...)
+
+	var exports []byte
+	exports = append(exports, 5)
+	exports = append(exports, export("memory", 2, 0)...)
+	exports = append(exports, export("goldfish_alloc", 0, 0)...)
+	exports = append(exports, export("helper_echo", 0, 1)...)
+	exports = append(exports, export("helper_fail", 0, 2)...)
+	exports = append(exports, export("validate_nonempty", 0, 3)...)
+	module = append(module, section(7, exports...)...)
+
+	var code []byte
+	code = append(code, 4)
+	// alloc: return the pointer, then advance it by size
+	code = append(code, body(0x23, 0, 0x23, 0, 0x20, 0, 0x6a, 0x24, 0, 0x0b)...)
+	// echo: return ptr<<32 | len
+	code = append(code, body(0x20, 0, 0xad, 0x42, 32, 0x86, 0x20, 1, 0xad, 0x84, 0x0b)...)
+	// fail: unreachable
+	code = append(code, body(0x00, 0x0b)...)
+	// nonempty: if len == 0 return the message, else 0
+	nonempty := []byte{0x20, 1, 0x45, 0x04, 0x7e, 0x42}
+	nonempty = append(nonempty, sleb(messageAt<<32|int64(len(message)))...)
+	nonempty = append(nonempty, 0x05, 0x42, 0, 0x0b, 0x0b)
+	code = append(code, body(nonempty...)...)
+	module = append(module, section(10, code...)...)
+
+	// Data: the validation message
+	data := append([]byte{1, 0, 0x41}, sleb(messageAt)...)
+	data = append(data, 0x0b)
+	data = append(data, name(message)...)
+	module = append(module, section(11, data...)...)
+
+	return module
+}
+
+// writeTestWasmModule writes the test module to a temporary file
+func writeTestWasmModule(t *testing.T) config.WasmModule {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.wasm")
+	if err := os.WriteFile(path, testWasmModule(), 0644); err != nil {
+		t.Fatalf("Failed to write module: %v", err)
+	}
+	return config.WasmModule{Name: "test", Path: path}
+}
+
+// TestEngine_WasmHelpers tests calling WASM helpers from templates
+func TestEngine_WasmHelpers(t *testing.T) {
+	engine := NewEngine(0)
+	engine.SetWasmModules([]config.WasmModule{writeTestWasmModule(t)})
+
+	cmd := &config.Command{
+		Name:        "greet",
+		BaseCommand: "echo",
+		Parameters:  []config.Parameter{{Name: "name", Type: "string"}},
+		Platforms: map[string]config.PlatformCommand{
+			"linux": {Template: "echo {{echo .params.name}}"},
+		},
+	}
+	rendered, err := engine.Preview(&ExecutionContext{
+		Command:    cmd,
+		Platform:   platform.Linux,
+		Parameters: map[string]interface{}{"name": "goldfish"},
+	})
+	if err != nil {
+		t.Fatalf("Preview failed: %v", err)
+	}
+	if rendered != "echo goldfish" {
+		t.Errorf("Expected 'echo goldfish', got %q", rendered)
+	}
+
+	// A trapping helper is reported as an error rather than crashing goldfish
+	if _, err := engine.renderString("test", "{{fail \"x\"}}", nil, nil); err == nil || !strings.Contains(err.Error(), "helper_fail") {
+		t.Errorf("Expected helper_fail error, got %v", err)
+	}
+}
+
+// TestEngine_WasmValidators tests parameters checked by WASM validators
+func TestEngine_WasmValidators(t *testing.T) {
+	engine := NewEngine(0)
+	engine.SetWasmModules([]config.WasmModule{writeTestWasmModule(t)})
+
+	cmd := &config.Command{
+		Name:        "ticket",
+		BaseCommand: "echo",
+		Parameters:  []config.Parameter{{Name: "id", Type: "string", Validate: "nonempty"}},
+		Platforms:   map[string]config.PlatformCommand{"linux": {Template: "echo {{.params.id}}"}},
+	}
+	ctx := &ExecutionContext{Command: cmd, Platform: platform.Linux, Parameters: map[string]interface{}{"id": "T-1"}}
+	if err := engine.validateContext(ctx); err != nil {
+		t.Errorf("Expected valid parameter, got %v", err)
+	}
+
+	ctx.Parameters["id"] = ""
+	if err := engine.validateContext(ctx); err == nil || !strings.Contains(err.Error(), "must not be empty") {
+		t.Errorf("Expected validator message, got %v", err)
+	}
+
+	cmd.Parameters[0].Validate = "missing"
+	if err := engine.validateContext(ctx); err == nil || !strings.Contains(err.Error(), "unknown validator") {
+		t.Errorf("Expected unknown validator error, got %v", err)
+	}
+}
+
+// TestEngine_WasmLoadErrors tests modules that cannot be used
+func TestEngine_WasmLoadErrors(t *testing.T) {
+	invalid := filepath.Join(t.TempDir(), "invalid.wasm")
+	if err := os.WriteFile(invalid, []byte("not wasm"), 0644); err != nil {
+		t.Fatalf("Failed to write module: %v", err)
+	}
+	module := writeTestWasmModule(t)
+
+	tests := []struct {
+		name    string
+		modules []config.WasmModule
+		want    string
+	}{
+		{"missing file", []config.WasmModule{{Name: "gone", Path: "/nonexistent.wasm"}}, "failed to read"},
+		{"invalid module", []config.WasmModule{{Name: "bad", Path: invalid}}, "failed to compile"},
+		{"duplicate helper", []config.WasmModule{module, {Name: "again", Path: module.Path}}, "already provided"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := NewEngine(0)
+			engine.SetWasmModules(tt.modules)
+			_, err := engine.renderString("test", "x", nil, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
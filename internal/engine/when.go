@@ -0,0 +1,93 @@
+package engine
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// selectTemplate returns the template a command uses, checking when: conditions
+// A template's when: is rendered against the same data as the template
+// (parameters, platform and vars; parameters that were not given are false,
+// empty or 0) and holds when the result is true: "true", "1" or any other
+// text except "false", "0", "no" and nothing. Templates are tried in the
+// usual order (see SelectPlatform) until one holds.
+func (e *Engine) selectTemplate(ctx *ExecutionContext) (*Selection, error) {
+	// The data is only built for commands that have when: conditions
+	var data map[string]interface{}
+	var whenErr error
+	holds := func(when string) bool {
+		if whenErr != nil {
+			return false
+		}
+		if data == nil {
+			data, whenErr = e.commandData(ctx.Command, ctx.Platform.String(), withZeroValues(ctx.Command, ctx.Parameters), ctx.revealedSet())
+			if whenErr != nil {
+				return false
+			}
+		}
+		var result bool
+		result, whenErr = e.evaluateWhen(when, data, ctx.revealedSet())
+		return result
+	}
+
+	selection, found := selectPlatform(ctx.Command, ctx.Platform, holds)
+	if whenErr != nil {
+		return nil, whenErr
+	}
+	if !found {
+		// Say so when the platform has templates, just none whose condition holds
+		if _, exists := SelectPlatform(ctx.Command, ctx.Platform); exists {
+			return nil, fmt.Errorf("command '%s': no %s template's when: condition holds for these parameters", ctx.Command.Name, ctx.Platform)
+		}
+		return nil, NewUnsupportedPlatformError(ctx.Command, ctx.Platform.String())
+	}
+	return selection, nil
+}
+
+// evaluateWhen renders a when: condition and reports whether it holds
+func (e *Engine) evaluateWhen(when string, data map[string]interface{}, revealed *revealedSecrets) (bool, error) {
+	rendered, err := e.renderString("when", when, data, revealed)
+	if err != nil {
+		return false, fmt.Errorf("when %q: %w", when, err)
+	}
+	return conditionHolds(rendered), nil
+}
+
+// conditionHolds reports whether a rendered condition is true: "true", "1"
+// or any other text except "false", "0", "no" and nothing
+func conditionHolds(rendered string) bool {
+	if value, err := strconv.ParseBool(rendered); err == nil {
+		return value
+	}
+	switch strings.ToLower(rendered) {
+	case "", "no":
+		return false
+	}
+	return true
+}
+
+// withZeroValues returns params with the zero value of their type for parameters
+// that were not given, so that conditions such as "{{.params.extended}}" are
+// false rather than "<no value>" when a flag is left out
+func withZeroValues(cmd *config.Command, params map[string]interface{}) map[string]interface{} {
+	complete := make(map[string]interface{}, len(cmd.Parameters))
+	for _, param := range cmd.Parameters {
+		switch param.Type {
+		case "bool":
+			complete[param.Name] = false
+		case "int":
+			complete[param.Name] = 0
+		case "float":
+			complete[param.Name] = 0.0
+		default:
+			complete[param.Name] = ""
+		}
+	}
+	for name, value := range params {
+		complete[name] = value
+	}
+	return complete
+}
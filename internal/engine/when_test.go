@@ -0,0 +1,58 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// TestEngine_When tests choosing a template by a condition over the parameters
+func TestEngine_When(t *testing.T) {
+	cmd := &config.Command{
+		Name:        "replace",
+		BaseCommand: "sed",
+		Parameters: []config.Parameter{
+			{Name: "extended", Type: "bool"},
+			{Name: "pattern", Type: "string"},
+		},
+		Platforms: map[string]config.PlatformCommand{
+			"linux": {
+				Template: "sed {{.params.pattern}}",
+				Variants: []config.PlatformCommand{
+					{When: "{{.params.extended}}", Template: "sed -E {{.params.pattern}}"},
+				},
+			},
+			"darwin": {When: `{{eq .params.pattern "never"}}`, Template: "sed"},
+			"unix":   {When: "{{if has \"goldfish-no-such-program\"}}yes{{end}}", Template: "unix"},
+		},
+	}
+	engine := NewEngine(0)
+	preview := func(p platform.SupportedPlatform, params map[string]interface{}) (string, error) {
+		return engine.Preview(&ExecutionContext{Command: cmd, Platform: p, Parameters: params})
+	}
+
+	if rendered, err := preview(platform.Linux, map[string]interface{}{"extended": true, "pattern": "s/a+/b/"}); err != nil || rendered != "sed -E s/a+/b/" {
+		t.Errorf("Expected the extended variant, got %q (%v)", rendered, err)
+	}
+	if rendered, err := preview(platform.Linux, map[string]interface{}{"pattern": "s/a/b/"}); err != nil || rendered != "sed s/a/b/" {
+		t.Errorf("Expected the plain template, got %q (%v)", rendered, err)
+	}
+
+	// With no condition holding the command cannot run, and says why
+	_, err := preview(platform.Darwin, map[string]interface{}{"pattern": "s/a/b/"})
+	if err == nil || !strings.Contains(err.Error(), "when: condition") {
+		t.Errorf("Expected a when: error, got %v", err)
+	}
+	// Listing commands does not know the parameters, so conditions hold there
+	if !Supports(cmd, platform.Darwin) {
+		t.Error("Expected darwin to be listed as supported")
+	}
+
+	// Errors in conditions are reported
+	cmd.Platforms["darwin"] = config.PlatformCommand{When: "{{.params.pattern | nosuchfunc}}", Template: "sed"}
+	if _, err := preview(platform.Darwin, map[string]interface{}{}); err == nil || !strings.Contains(err.Error(), "nosuchfunc") {
+		t.Errorf("Expected the condition's error, got %v", err)
+	}
+}
@@ -0,0 +1,245 @@
+// Package history records the commands goldfish runs, so that goldfish
+// history can list them and goldfish rerun can run one again exactly as it
+// was typed. The history is a JSON lines file in the user's state directory
+// (e.g. ~/.local/state/goldfish/history.jsonl), holding the newest entries.
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// EnvVar names a history file to use instead of the default location
+const EnvVar = "GOLDFISH_HISTORY"
+
+// MaxEntries is how many entries the history keeps; older ones are dropped
+const MaxEntries = 1000
+
+// Last refers to the newest entry, as in "goldfish rerun last"
+const Last = "last"
+
+// maskedValue replaces secret values, as in the execution log
+const maskedValue = "********"
+
+// Entry is one command run by goldfish
+type Entry struct {
+	// ID numbers the entries in the order they were run, starting at 1
+	ID int `json:"id"`
+	// Time is when the command started
+	Time time.Time `json:"time"`
+	// Command is the name of the goldfish command
+	Command string `json:"command"`
+	// Args are goldfish's arguments as typed (without the program name),
+	// with secret values masked
+	Args []string `json:"args"`
+	// Parameters are the parsed parameter values, with secrets masked
+	Parameters map[string]interface{} `json:"params,omitempty"`
+	// Dir is the working directory the command ran in
+	Dir string `json:"dir,omitempty"`
+	// ExitCode is the command's exit code (-1 if it did not run to completion)
+	ExitCode int `json:"exit_code"`
+	// DurationMS is the execution time in milliseconds
+	DurationMS int64 `json:"duration_ms"`
+	// Error describes why the command did not run to completion, if it didn't
+	Error string `json:"error,omitempty"`
+	// Masked is set when secret values were removed from Args, so the
+	// entry cannot be run again as it was
+	Masked bool `json:"masked,omitempty"`
+}
+
+// New creates an entry for a command run with args, masking the values of
+// its secret parameters in both the arguments and the parameters
+func New(cmd *config.Command, args []string, params map[string]interface{}) Entry {
+	entry := Entry{
+		Command:    cmd.Name,
+		Args:       append([]string{}, args...),
+		Parameters: make(map[string]interface{}, len(params)),
+	}
+	for name, value := range params {
+		entry.Parameters[name] = value
+	}
+	for _, param := range cmd.Parameters {
+		value, set := params[param.Name]
+		if !set || !param.Secret {
+			continue
+		}
+		entry.Parameters[param.Name] = maskedValue
+		// Skip empty values, which would otherwise mask between every character
+		secret := fmt.Sprint(value)
+		if secret == "" {
+			continue
+		}
+		for i, arg := range entry.Args {
+			if strings.Contains(arg, secret) {
+				entry.Args[i] = strings.ReplaceAll(arg, secret, maskedValue)
+				entry.Masked = true
+			}
+		}
+	}
+	return entry
+}
+
+// Failed reports whether the command failed or could not run
+func (e *Entry) Failed() bool {
+	return e.ExitCode != 0 || e.Error != ""
+}
+
+// CommandLine returns the goldfish command line of the entry, quoted so that
+// it can be pasted into a shell, e.g. "goldfish greet --name 'Ada Lovelace'"
+func (e *Entry) CommandLine() string {
+	words := []string{"goldfish"}
+	for _, arg := range e.Args {
+		words = append(words, shellQuote(arg))
+	}
+	return strings.Join(words, " ")
+}
+
+// shellQuote quotes an argument for POSIX shells when it needs quoting
+func shellQuote(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\n'\"\\$`|&;<>()*?[]{}~#!") {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// Path returns the history file: $GOLDFISH_HISTORY, or else history.jsonl in
+// the user's state directory (e.g. ~/.local/state/goldfish/history.jsonl)
+func Path() (string, error) {
+	if path := os.Getenv(EnvVar); path != "" {
+		return path, nil
+	}
+	dir, err := config.UserStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "history.jsonl"), nil
+}
+
+// Load reads the entries of the history file at path, oldest first
+// A missing file is an empty history.
+func Load(path string) ([]Entry, error) {
+	data, err := read(path)
+	if err != nil {
+		return nil, err
+	}
+	return parse(data), nil
+}
+
+// read returns the contents of the history file at path; nil if it is missing
+func read(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history %s: %w", path, err)
+	}
+	return data, nil
+}
+
+// parse decodes the entries of a history file, one JSON object per line
+// Lines that cannot be read, such as one cut short by a crash, are skipped
+// rather than losing the whole history.
+func parse(data []byte) []Entry {
+	var entries []Entry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if json.Unmarshal(scanner.Bytes(), &entry) == nil && entry.ID > 0 {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// Append numbers entry after the newest one in the history file at path and
+// adds it, creating the file if needed; the numbered entry is returned
+// Once the file holds more than MaxEntries, the oldest are dropped.
+func Append(path string, entry Entry) (Entry, error) {
+	data, err := read(path)
+	if err != nil {
+		return entry, err
+	}
+	entries := parse(data)
+	entry.ID = 1
+	if len(entries) > 0 {
+		entry.ID = entries[len(entries)-1].ID + 1
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return entry, fmt.Errorf("failed to encode history entry: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return entry, fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+
+	if len(entries) >= MaxEntries {
+		return entry, rewrite(path, append(entries[len(entries)-MaxEntries+1:], entry))
+	}
+	// The history may hold command lines worth keeping private, so only the user can read it
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return entry, fmt.Errorf("failed to open history: %w", err)
+	}
+	defer file.Close()
+	// A line cut short must not swallow the new one
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		line = append([]byte{'\n'}, line...)
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return entry, fmt.Errorf("failed to write history: %w", err)
+	}
+	return entry, nil
+}
+
+// rewrite replaces the history file with entries
+func rewrite(path string, entries []Entry) error {
+	var out bytes.Buffer
+	encoder := json.NewEncoder(&out)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			return fmt.Errorf("failed to encode history entry: %w", err)
+		}
+	}
+	// Write to a temporary file first so a failed write never truncates the history
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, out.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
+
+// Find returns the entry that ref refers to: an ID, or Last for the newest
+func Find(entries []Entry, ref string) (*Entry, error) {
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("the history is empty")
+	}
+	if ref == Last {
+		return &entries[len(entries)-1], nil
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(ref, "#"))
+	if err != nil {
+		return nil, fmt.Errorf("'%s' is not a history ID or '%s'", ref, Last)
+	}
+	for i := range entries {
+		if entries[i].ID == id {
+			return &entries[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no history entry %d (goldfish history lists them)", id)
+}
@@ -0,0 +1,136 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// TestNew tests that secret values are masked in the arguments and parameters
+func TestNew(t *testing.T) {
+	cmd := &config.Command{
+		Name: "login",
+		Parameters: []config.Parameter{
+			{Name: "user", Type: "string"},
+			{Name: "token", Type: "string", Secret: true},
+		},
+	}
+	params := map[string]interface{}{"user": "ada", "token": "s3cret"}
+	entry := New(cmd, []string{"login", "--user", "ada", "--token=s3cret"}, params)
+
+	if strings.Contains(strings.Join(entry.Args, " "), "s3cret") || !entry.Masked {
+		t.Errorf("Expected the token to be masked, got %v (masked %t)", entry.Args, entry.Masked)
+	}
+	if entry.Parameters["token"] != maskedValue || entry.Parameters["user"] != "ada" {
+		t.Errorf("Expected only the token parameter to be masked, got %v", entry.Parameters)
+	}
+	if params["token"] != "s3cret" {
+		t.Error("Expected the parameters passed in to be left unchanged")
+	}
+
+	plain := New(cmd, []string{"login", "--user", "ada"}, map[string]interface{}{"user": "ada"})
+	if plain.Masked {
+		t.Error("Expected an entry without secrets not to be masked")
+	}
+}
+
+// TestEntry_CommandLine tests quoting the arguments for a shell
+func TestEntry_CommandLine(t *testing.T) {
+	entry := Entry{Args: []string{"greet", "--name", "Ada Lovelace", "--note=it's", ""}}
+	expected := `goldfish greet --name 'Ada Lovelace' '--note=it'\''s' ''`
+	if line := entry.CommandLine(); line != expected {
+		t.Errorf("Expected %s, got %s", expected, line)
+	}
+}
+
+// TestAppend tests numbering entries and loading them back
+func TestAppend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "history.jsonl")
+	if entries, err := Load(path); err != nil || len(entries) != 0 {
+		t.Fatalf("Expected an empty history for a missing file, got %v (%v)", entries, err)
+	}
+
+	for _, name := range []string{"first", "second"} {
+		if _, err := Append(path, Entry{Command: name, Args: []string{name}}); err != nil {
+			t.Fatalf("Append() failed: %v", err)
+		}
+	}
+	// A line cut short by a crash is skipped
+	file, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	file.WriteString(`{"id": 3, "comm`)
+	file.Close()
+	entry, err := Append(path, Entry{Command: "third", ExitCode: 2})
+	if err != nil || entry.ID != 3 {
+		t.Fatalf("Expected the third entry to be numbered 3, got %d (%v)", entry.ID, err)
+	}
+
+	entries, err := Load(path)
+	if err != nil || len(entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %v (%v)", entries, err)
+	}
+	if entries[0].Command != "first" || entries[2].Command != "third" || !entries[2].Failed() || entries[0].Failed() {
+		t.Errorf("Unexpected entries: %+v", entries)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the history to be private to the user, got %v (%v)", info.Mode(), err)
+	}
+}
+
+// TestAppend_Trim tests that only the newest MaxEntries are kept
+func TestAppend_Trim(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	entries := make([]Entry, MaxEntries)
+	for i := range entries {
+		entries[i] = Entry{ID: i + 1, Command: "old"}
+	}
+	if err := rewrite(path, entries); err != nil {
+		t.Fatalf("rewrite() failed: %v", err)
+	}
+	if _, err := Append(path, Entry{Command: "new"}); err != nil {
+		t.Fatalf("Append() failed: %v", err)
+	}
+
+	entries, _ = Load(path)
+	if len(entries) != MaxEntries || entries[0].ID != 2 || entries[len(entries)-1].ID != MaxEntries+1 {
+		t.Errorf("Expected entries 2 to %d, got %d entries from %d", MaxEntries+1, len(entries), entries[0].ID)
+	}
+}
+
+// TestFind tests looking entries up by ID and "last"
+func TestFind(t *testing.T) {
+	entries := []Entry{{ID: 7, Command: "a"}, {ID: 8, Command: "b"}}
+	testCases := map[string]string{"last": "b", "7": "a", "#8": "b"}
+	for ref, expected := range testCases {
+		entry, err := Find(entries, ref)
+		if err != nil || entry.Command != expected {
+			t.Errorf("Find(%s): expected %s, got %+v (%v)", ref, expected, entry, err)
+		}
+	}
+
+	errorCases := map[string]string{"9": "no history entry 9", "first": "not a history ID"}
+	for ref, expected := range errorCases {
+		if _, err := Find(entries, ref); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Find(%s): expected an error containing %q, got %v", ref, expected, err)
+		}
+	}
+	if _, err := Find(nil, Last); err == nil || !strings.Contains(err.Error(), "empty") {
+		t.Errorf("Expected an empty history error, got %v", err)
+	}
+}
+
+// TestPath tests that GOLDFISH_HISTORY overrides the history location
+func TestPath(t *testing.T) {
+	t.Setenv(EnvVar, "/tmp/custom-history.jsonl")
+	if path, err := Path(); err != nil || path != "/tmp/custom-history.jsonl" {
+		t.Errorf("Expected the path from $%s, got %s (%v)", EnvVar, path, err)
+	}
+
+	t.Setenv(EnvVar, "")
+	t.Setenv("XDG_STATE_HOME", "/xdg-state")
+	if path, err := Path(); err != nil || path != filepath.Join("/xdg-state", "goldfish", "history.jsonl") {
+		t.Errorf("Expected the history in the state directory, got %s (%v)", path, err)
+	}
+}
@@ -0,0 +1,175 @@
+// Package jobs keeps track of the commands goldfish --detach leaves running in
+// the background, so that goldfish jobs can list them, show their output and
+// stop them. Each job is a JSON file and a log file named after its ID in the
+// user's state directory (e.g. ~/.local/state/goldfish/jobs/3.json and 3.log).
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// EnvVar names a directory to keep the jobs in instead of the default location
+const EnvVar = "GOLDFISH_JOBS"
+
+// Last refers to the newest job, as in "goldfish jobs logs last"
+const Last = "last"
+
+// The states Status reports
+const (
+	StatusRunning = "running"
+	StatusExited  = "exited"
+)
+
+// Job is a command started in the background
+type Job struct {
+	// ID numbers the jobs in the order they were started, starting at 1
+	ID int `json:"id"`
+	// PID is the process ID of the shell running the command; on Unix it
+	// also names the command's process group
+	PID int `json:"pid"`
+	// Command is the name of the goldfish command
+	Command string `json:"command"`
+	// CommandLine is the rendered command line, with secrets masked
+	CommandLine string `json:"command_line"`
+	// Dir is the working directory the command was started in
+	Dir string `json:"dir,omitempty"`
+	// Log is the file the command's output is written to
+	Log string `json:"log"`
+	// Started is when the command was started
+	Started time.Time `json:"started"`
+}
+
+// Status reports whether the job's process is still running
+// Process IDs are reused once a process has exited, so a job that ended
+// long ago may be reported as running if another process took its ID.
+func (j *Job) Status() string {
+	if processRunning(j.PID) {
+		return StatusRunning
+	}
+	return StatusExited
+}
+
+// Stop asks the job's command, and everything it started, to terminate
+func (j *Job) Stop() error {
+	if !processRunning(j.PID) {
+		return fmt.Errorf("job %d is no longer running", j.ID)
+	}
+	if err := stopProcess(j.PID); err != nil {
+		return fmt.Errorf("failed to stop job %d: %w", j.ID, err)
+	}
+	return nil
+}
+
+// Dir returns the directory the jobs are kept in: $GOLDFISH_JOBS, or else
+// jobs in the user's state directory (e.g. ~/.local/state/goldfish/jobs)
+func Dir() (string, error) {
+	if dir := os.Getenv(EnvVar); dir != "" {
+		return dir, nil
+	}
+	dir, err := config.UserStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "jobs"), nil
+}
+
+// Create numbers a new job after the newest one in dir and creates its log
+// file, which is returned open for the command's output
+// The job is only listed once Save records it with the command's process ID.
+func Create(dir, command string) (*Job, *os.File, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, nil, fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	existing, err := List(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	id := 1
+	if len(existing) > 0 {
+		id = existing[len(existing)-1].ID + 1
+	}
+	// Another goldfish may be starting a job at the same time; creating the
+	// log exclusively gives each of them an ID of its own
+	for {
+		job := &Job{ID: id, Command: command, Log: filepath.Join(dir, strconv.Itoa(id)+".log")}
+		log, err := os.OpenFile(job.Log, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if errors.Is(err, os.ErrExist) {
+			id++
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create job log: %w", err)
+		}
+		return job, log, nil
+	}
+}
+
+// Save records job in dir
+func Save(dir string, job *Job) error {
+	data, err := json.MarshalIndent(job, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode job %d: %w", job.ID, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, strconv.Itoa(job.ID)+".json"), append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to record job %d: %w", job.ID, err)
+	}
+	return nil
+}
+
+// List reads the jobs recorded in dir, oldest first
+// A missing directory has no jobs, and files that cannot be read are skipped.
+func List(dir string) ([]Job, error) {
+	files, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read jobs in %s: %w", dir, err)
+	}
+	var jobs []Job
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			continue
+		}
+		var job Job
+		if json.Unmarshal(data, &job) == nil && job.ID > 0 {
+			jobs = append(jobs, job)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].ID < jobs[j].ID })
+	return jobs, nil
+}
+
+// Find returns the job that ref refers to: an ID, or Last for the newest
+func Find(jobs []Job, ref string) (*Job, error) {
+	if len(jobs) == 0 {
+		return nil, fmt.Errorf("there are no jobs; goldfish --detach <command> starts one")
+	}
+	if ref == Last {
+		return &jobs[len(jobs)-1], nil
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(ref, "%"))
+	if err != nil {
+		return nil, fmt.Errorf("'%s' is not a job ID or '%s'", ref, Last)
+	}
+	for i := range jobs {
+		if jobs[i].ID == id {
+			return &jobs[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no job %d (goldfish jobs list shows them)", id)
+}
@@ -0,0 +1,110 @@
+package jobs
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// TestCreateAndList tests numbering, recording and listing jobs
+func TestCreateAndList(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "jobs")
+	if jobs, err := List(dir); err != nil || len(jobs) != 0 {
+		t.Fatalf("Expected no jobs in a missing directory, got %v (%v)", jobs, err)
+	}
+
+	first, log, err := Create(dir, "backup")
+	if err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+	log.Close()
+	first.PID = os.Getpid()
+	if err := Save(dir, first); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+	// A job whose log exists but was never saved still takes its ID
+	unsaved, log, err := Create(dir, "sync")
+	if err != nil {
+		t.Fatal(err)
+	}
+	log.Close()
+	second, log, err := Create(dir, "sync")
+	if err != nil {
+		t.Fatal(err)
+	}
+	log.Close()
+	if first.ID != 1 || unsaved.ID != 2 || second.ID != 3 {
+		t.Errorf("Expected IDs 1, 2 and 3, got %d, %d and %d", first.ID, unsaved.ID, second.ID)
+	}
+	if second.Log != filepath.Join(dir, "3.log") {
+		t.Errorf("Unexpected log file %s", second.Log)
+	}
+	if err := Save(dir, second); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "9.json"), []byte("{not json"), 0600)
+
+	jobs, err := List(dir)
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+	if len(jobs) != 2 || jobs[0].Command != "backup" || jobs[1].ID != 3 {
+		t.Fatalf("Expected jobs 1 and 3, got %+v", jobs)
+	}
+	if jobs[0].Status() != StatusRunning {
+		t.Errorf("Expected this test's own process to be running")
+	}
+}
+
+// TestFind tests looking jobs up by ID and as the newest
+func TestFind(t *testing.T) {
+	jobs := []Job{{ID: 1}, {ID: 4}}
+	testCases := []struct {
+		ref      string
+		expected int
+		err      string
+	}{
+		{"1", 1, ""},
+		{"%4", 4, ""},
+		{Last, 4, ""},
+		{"2", 0, "no job 2"},
+		{"backup", 0, "not a job ID"},
+	}
+	for _, tc := range testCases {
+		job, err := Find(jobs, tc.ref)
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("%s: expected an error containing %q, got %v", tc.ref, tc.err, err)
+			}
+			continue
+		}
+		if err != nil || job.ID != tc.expected {
+			t.Errorf("%s: expected job %d, got %+v (%v)", tc.ref, tc.expected, job, err)
+		}
+	}
+	if _, err := Find(nil, Last); err == nil {
+		t.Error("Expected an error when there are no jobs")
+	}
+}
+
+// TestJob_Status tests telling running jobs from those that have exited
+func TestJob_Status(t *testing.T) {
+	program := "true"
+	if runtime.GOOS == "windows" {
+		program = "hostname"
+	}
+	cmd := exec.Command(program)
+	if err := cmd.Run(); err != nil {
+		t.Skipf("cannot run %s: %v", program, err)
+	}
+	job := &Job{ID: 1, PID: cmd.Process.Pid}
+	if job.Status() != StatusExited {
+		t.Errorf("Expected a finished process to have exited")
+	}
+	if err := job.Stop(); err == nil || !strings.Contains(err.Error(), "no longer running") {
+		t.Errorf("Expected stopping an exited job to fail, got %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+//go:build unix
+
+package jobs
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// processRunning reports whether a process with the ID exists
+// Signal 0 checks for the process without sending anything; a process owned
+// by someone else exists too, although it cannot be signalled.
+func processRunning(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := syscall.Kill(pid, 0)
+	if err != nil && !errors.Is(err, syscall.EPERM) {
+		return false
+	}
+	return !zombie(pid)
+}
+
+// zombie reports whether the process has exited but not yet been reaped
+// That happens when the init process of a container does not reap orphans.
+// Only Linux says so in /proc; elsewhere the process counts as running.
+func zombie(pid int) bool {
+	stat, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return false
+	}
+	// The state follows the program name, which is in parentheses and may
+	// itself contain them
+	end := bytes.LastIndexByte(stat, ')')
+	return end >= 0 && end+2 < len(stat) && stat[end+2] == 'Z'
+}
+
+// stopProcess sends SIGTERM to the process group the job's shell leads, so
+// the programs it started stop too
+func stopProcess(pid int) error {
+	return syscall.Kill(-pid, syscall.SIGTERM)
+}
@@ -0,0 +1,62 @@
+//go:build unix
+
+package jobs
+
+import (
+	"os/exec"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestJob_Stop tests that stopping a job ends its whole process group
+func TestJob_Stop(t *testing.T) {
+	// The shell starts a child of its own, which must stop too
+	cmd := exec.Command("sh", "-c", "sleep 30 & wait")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	job := &Job{ID: 1, PID: cmd.Process.Pid}
+	if err := job.Stop(); err != nil {
+		t.Fatalf("Stop() failed: %v", err)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the job to stop")
+	}
+	// The group is gone once its last member has exited
+	deadline := time.Now().Add(5 * time.Second)
+	for syscall.Kill(-job.PID, 0) == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the job's child to stop too")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestJob_Status_Zombie tests that a process nobody has reaped counts as exited
+func TestJob_Status_Zombie(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("zombies are only recognized on Linux")
+	}
+	cmd := exec.Command("true")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	// Until Wait, the finished process stays a zombie of this test
+	defer cmd.Wait()
+	job := &Job{ID: 1, PID: cmd.Process.Pid}
+	deadline := time.Now().Add(5 * time.Second)
+	for job.Status() != StatusExited {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the unreaped process to have exited")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
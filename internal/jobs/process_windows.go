@@ -0,0 +1,40 @@
+//go:build windows
+
+package jobs
+
+import (
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+const (
+	// processQueryLimitedInformation is the access right GetExitCodeProcess needs
+	processQueryLimitedInformation = 0x1000
+	// stillActive is the exit code GetExitCodeProcess reports for a running process
+	stillActive = 259
+)
+
+// processRunning reports whether a process with the ID is still running
+func processRunning(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	process, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer syscall.CloseHandle(process)
+	var code uint32
+	if err := syscall.GetExitCodeProcess(process, &code); err != nil {
+		return false
+	}
+	return code == stillActive
+}
+
+// stopProcess ends the job's process and the processes it started
+// A detached process has no console to send CTRL_BREAK to, so the tree is
+// terminated with taskkill.
+func stopProcess(pid int) error {
+	return exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(pid)).Run()
+}
@@ -0,0 +1,299 @@
+// Package lint checks a goldfish configuration for mistakes that loading it
+// does not catch. Templates are only rendered when a command runs, so a
+// misspelled {{.params.fiel}} prints "<no value>" on the one platform that
+// uses it, long after the configuration was reviewed. The linter parses every
+// template and reports references to parameters that do not exist,
+// parameters nothing uses and platforms a command has no template for.
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"text/template/parse"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/engine"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// Severities of findings
+const (
+	// Error is a mistake that breaks the command when it runs
+	Error = "error"
+	// Warning is something that is probably a mistake
+	Warning = "warning"
+)
+
+// Finding is one problem found in a command
+type Finding struct {
+	// Command is the name of the command
+	Command string
+	// Location is where in the command the problem is, such as
+	// "platforms.linux.template"; it is empty for the command as a whole
+	Location string
+	// Severity is Error or Warning
+	Severity string
+	Message  string
+}
+
+// String formats the finding as "command (location): severity: message"
+func (f Finding) String() string {
+	if f.Location == "" {
+		return fmt.Sprintf("%s: %s: %s", f.Command, f.Severity, f.Message)
+	}
+	return fmt.Sprintf("%s (%s): %s: %s", f.Command, f.Location, f.Severity, f.Message)
+}
+
+// Lint checks every command of a configuration
+// Findings are in the order of the commands, then of their templates.
+func Lint(cfg *config.Config) []Finding {
+	var findings []Finding
+	for i := range cfg.Commands {
+		findings = append(findings, Command(&cfg.Commands[i])...)
+	}
+	return findings
+}
+
+// Command checks one command
+// Plugins get their parameters as they are and commands with a script can
+// use them there, so neither has parameters reported as unused.
+func Command(cmd *config.Command) []Finding {
+	l := &linter{cmd: cmd, used: make(map[string]bool)}
+	for _, source := range templateSources(cmd) {
+		l.template(source.location, source.text)
+	}
+	for _, platformCmd := range cmd.Platforms {
+		l.flagMap(platformCmd)
+	}
+
+	if cmd.Plugin == "" && cmd.Script == "" {
+		for _, param := range cmd.Parameters {
+			if !l.used[param.Name] {
+				l.add("params."+param.Name, Warning, fmt.Sprintf("parameter '%s' is not used by any template", param.Name))
+			}
+		}
+	}
+	l.coverage()
+	return l.findings
+}
+
+// linter collects the findings for one command
+type linter struct {
+	cmd      *config.Command
+	used     map[string]bool
+	findings []Finding
+}
+
+// add records a finding
+func (l *linter) add(location, severity, message string) {
+	l.findings = append(l.findings, Finding{Command: l.cmd.Name, Location: location, Severity: severity, Message: message})
+}
+
+// templateSource is a template of a command and where it is
+type templateSource struct {
+	location string
+	text     string
+}
+
+// templateSources returns every template of a command that sees its parameters
+// Platforms are in sorted order so that findings are reported the same way
+// every time.
+func templateSources(cmd *config.Command) []templateSource {
+	var sources []templateSource
+	add := func(location, text string) {
+		if text != "" {
+			sources = append(sources, templateSource{location, text})
+		}
+	}
+
+	keys := make([]string, 0, len(cmd.Platforms))
+	for key := range cmd.Platforms {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		platformCmd := cmd.Platforms[key]
+		addPlatform(add, "platforms."+key, &platformCmd)
+	}
+
+	vars := make([]string, 0, len(cmd.Vars))
+	for name := range cmd.Vars {
+		vars = append(vars, name)
+	}
+	sort.Strings(vars)
+	for _, name := range vars {
+		add("vars."+name, cmd.Vars[name])
+	}
+	for _, param := range cmd.Parameters {
+		if text, ok := param.Default.(string); ok {
+			add("params."+param.Name+".default", text)
+		}
+	}
+	add("log_output", cmd.LogOutput)
+	add("skip_if", cmd.SkipIf)
+	for i, check := range cmd.Checks {
+		location := fmt.Sprintf("check[%d]", i)
+		add(location+".run", check.Run)
+		add(location+".file_exists", check.FileExists)
+		add(location+".port_open", check.PortOpen)
+		add(location+".min_disk_space", check.MinDiskSpace)
+		add(location+".path", check.Path)
+		add(location+".message", check.Message)
+	}
+	for i, step := range cmd.OnFailure {
+		add(fmt.Sprintf("on_failure[%d]", i), step)
+	}
+	for i, step := range cmd.Always {
+		add(fmt.Sprintf("always[%d]", i), step)
+	}
+	if cmd.Sandbox != nil {
+		for i, path := range cmd.Sandbox.AllowPaths {
+			add(fmt.Sprintf("sandbox.allow_paths[%d]", i), path)
+		}
+	}
+	return sources
+}
+
+// addPlatform adds the templates of a platform entry and its variants
+func addPlatform(add func(location, text string), location string, platformCmd *config.PlatformCommand) {
+	add(location+".template", platformCmd.Template)
+	add(location+".when", platformCmd.When)
+	add(location+".output_filter", platformCmd.OutputFilter)
+	for i := range platformCmd.Variants {
+		addPlatform(add, fmt.Sprintf("%s.variants[%d]", location, i), &platformCmd.Variants[i])
+	}
+}
+
+// template checks the parameters one template refers to
+// Functions are not checked, since WASM modules add their own; the engine
+// reports unknown functions when the template is parsed to run.
+func (l *linter) template(location, text string) {
+	tree := parse.New(location)
+	tree.Mode = parse.SkipFuncCheck
+	if _, err := tree.Parse(text, "", "", make(map[string]*parse.Tree)); err != nil {
+		l.add(location, Error, fmt.Sprintf("template does not parse: %v", err))
+		return
+	}
+
+	reported := make(map[string]bool)
+	for _, name := range paramReferences(tree.Root) {
+		if _, known := l.cmd.FindParameter(name); known {
+			l.used[name] = true
+			continue
+		}
+		if !reported[name] {
+			reported[name] = true
+			l.add(location, Error, fmt.Sprintf("refers to unknown parameter '%s'", name))
+		}
+	}
+}
+
+// flagMap marks the parameters a platform entry's flag_map uses, and those of its variants
+func (l *linter) flagMap(platformCmd config.PlatformCommand) {
+	for name := range platformCmd.FlagMap {
+		l.used[name] = true
+	}
+	for _, variant := range platformCmd.Variants {
+		l.flagMap(variant)
+	}
+}
+
+// coverage reports the platforms the command cannot run on
+// A platform the command has install hints for is known to be unsupported.
+func (l *linter) coverage() {
+	if l.cmd.Plugin != "" {
+		return
+	}
+	for _, p := range platform.NewDetector().GetSupportedPlatforms() {
+		if _, hinted := l.cmd.InstallHints[p.String()]; hinted || engine.Supports(l.cmd, p) {
+			continue
+		}
+		message := fmt.Sprintf("no template for %s", p)
+		if l.cmd.Fallback != "" {
+			message += fmt.Sprintf(" (fallback '%s' is suggested there)", l.cmd.Fallback)
+		}
+		l.add("platforms", Warning, message)
+	}
+}
+
+// paramReferences returns the names of the parameters a template refers to:
+// {{.params.name}}, {{$.params.name}} and {{index .params "name"}}
+func paramReferences(node parse.Node) []string {
+	var names []string
+	var walk func(node parse.Node)
+	walk = func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.IfNode:
+			walkBranch(walk, &n.BranchNode)
+		case *parse.WithNode:
+			walkBranch(walk, &n.BranchNode)
+		case *parse.RangeNode:
+			walkBranch(walk, &n.BranchNode)
+		case *parse.TemplateNode:
+			walk(n.Pipe)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, cmd := range n.Cmds {
+				walk(cmd)
+			}
+		case *parse.CommandNode:
+			if name, ok := indexedParam(n); ok {
+				names = append(names, name)
+				return
+			}
+			for _, arg := range n.Args {
+				walk(arg)
+			}
+		case *parse.ChainNode:
+			walk(n.Node)
+		case *parse.FieldNode:
+			if len(n.Ident) > 1 && n.Ident[0] == "params" {
+				names = append(names, n.Ident[1])
+			}
+		case *parse.VariableNode:
+			if len(n.Ident) > 2 && n.Ident[0] == "$" && n.Ident[1] == "params" {
+				names = append(names, n.Ident[2])
+			}
+		}
+	}
+	walk(node)
+	return names
+}
+
+// walkBranch walks the pipeline and both lists of an {{if}}, {{with}} or {{range}}
+func walkBranch(walk func(parse.Node), n *parse.BranchNode) {
+	walk(n.Pipe)
+	walk(n.List)
+	walk(n.ElseList)
+}
+
+// indexedParam returns the parameter an {{index .params "name"}} looks up
+func indexedParam(cmd *parse.CommandNode) (string, bool) {
+	if len(cmd.Args) != 3 {
+		return "", false
+	}
+	function, ok := cmd.Args[0].(*parse.IdentifierNode)
+	if !ok || function.Ident != "index" {
+		return "", false
+	}
+	field, ok := cmd.Args[1].(*parse.FieldNode)
+	if !ok || len(field.Ident) != 1 || field.Ident[0] != "params" {
+		return "", false
+	}
+	name, ok := cmd.Args[2].(*parse.StringNode)
+	if !ok {
+		return "", false
+	}
+	return name.Text, true
+}
@@ -0,0 +1,150 @@
+package lint
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// allPlatforms gives a command the same template on every platform
+func allPlatforms(template string) map[string]config.PlatformCommand {
+	return map[string]config.PlatformCommand{
+		"linux":   {Template: template},
+		"darwin":  {Template: template},
+		"windows": {Template: template},
+	}
+}
+
+// TestCommand tests the findings for single commands
+func TestCommand(t *testing.T) {
+	testCases := []struct {
+		name     string
+		cmd      config.Command
+		expected []string
+	}{
+		{
+			name: "clean",
+			cmd: config.Command{
+				Name:       "list",
+				Parameters: []config.Parameter{{Name: "path", Type: "string"}, {Name: "all", Type: "bool"}},
+				Platforms:  allPlatforms("ls {{if .params.all}}-a{{end}} {{.params.path}}"),
+			},
+		},
+		{
+			name: "unknown parameter",
+			cmd: config.Command{
+				Name:       "list",
+				Parameters: []config.Parameter{{Name: "path", Type: "string"}},
+				Platforms:  allPlatforms("ls {{.params.pth}} {{.params.path}} {{.params.pth}}"),
+			},
+			expected: []string{
+				"list (platforms.darwin.template): error: refers to unknown parameter 'pth'",
+				"list (platforms.linux.template): error: refers to unknown parameter 'pth'",
+				"list (platforms.windows.template): error: refers to unknown parameter 'pth'",
+			},
+		},
+		{
+			name: "other references",
+			cmd: config.Command{
+				Name:       "list",
+				Parameters: []config.Parameter{{Name: "a", Type: "string"}, {Name: "b", Type: "string"}, {Name: "c", Type: "string"}},
+				Platforms:  allPlatforms(`ls {{$.params.a}} {{index .params "b"}} {{with .params.c}}{{.}}{{end}} {{index .params "d"}}`),
+			},
+			expected: []string{
+				"list (platforms.darwin.template): error: refers to unknown parameter 'd'",
+				"list (platforms.linux.template): error: refers to unknown parameter 'd'",
+				"list (platforms.windows.template): error: refers to unknown parameter 'd'",
+			},
+		},
+		{
+			name: "unused parameter",
+			cmd: config.Command{
+				Name:       "list",
+				Parameters: []config.Parameter{{Name: "path", Type: "string"}, {Name: "depth", Type: "int"}},
+				Platforms:  allPlatforms("ls {{.params.path}}"),
+			},
+			expected: []string{"list (params.depth): warning: parameter 'depth' is not used by any template"},
+		},
+		{
+			name: "used elsewhere",
+			cmd: config.Command{
+				Name: "list",
+				Parameters: []config.Parameter{
+					{Name: "a", Type: "string"}, {Name: "b", Type: "string"}, {Name: "c", Type: "string"},
+					{Name: "d", Type: "string", Default: "{{.params.c}}"},
+				},
+				Vars: map[string]string{"target": "{{.params.a}}"},
+				Platforms: map[string]config.PlatformCommand{
+					"linux":   {Template: "ls {{.vars.target}} {{.params.d}}", Variants: []config.PlatformCommand{{When: "{{.params.b}}", Template: "ls"}}},
+					"darwin":  {FlagMap: map[string]string{"a": "", "b": "", "c": "", "d": ""}},
+					"windows": {Template: "dir"},
+				},
+			},
+		},
+		{
+			name: "parse error",
+			cmd: config.Command{
+				Name:      "list",
+				Platforms: allPlatforms("ls {{if}}"),
+			},
+			expected: []string{
+				"list (platforms.darwin.template): error: template does not parse",
+				"list (platforms.linux.template): error: template does not parse",
+				"list (platforms.windows.template): error: template does not parse",
+			},
+		},
+		{
+			name: "missing platform",
+			cmd: config.Command{
+				Name:      "list",
+				Fallback:  "dir",
+				Platforms: map[string]config.PlatformCommand{"linux": {Template: "ls"}, "darwin": {Template: "ls"}},
+			},
+			expected: []string{"list (platforms): warning: no template for windows (fallback 'dir' is suggested there)"},
+		},
+		{
+			name: "hinted platform",
+			cmd: config.Command{
+				Name:         "list",
+				InstallHints: map[string]string{"windows": "use WSL"},
+				Platforms:    map[string]config.PlatformCommand{"linux": {Template: "ls"}, "darwin": {Template: "ls"}},
+			},
+		},
+		{
+			name: "script",
+			cmd: config.Command{
+				Name:       "list",
+				Script:     "params.path = '.'",
+				Parameters: []config.Parameter{{Name: "path", Type: "string"}},
+				Platforms:  allPlatforms("ls"),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		findings := Command(&tc.cmd)
+		if len(findings) != len(tc.expected) {
+			t.Errorf("%s: expected %d findings, got %v", tc.name, len(tc.expected), findings)
+			continue
+		}
+		for i, finding := range findings {
+			if !strings.HasPrefix(finding.String(), tc.expected[i]) {
+				t.Errorf("%s: expected %q, got %q", tc.name, tc.expected[i], finding.String())
+			}
+		}
+	}
+}
+
+// TestLint tests that the embedded default commands lint cleanly
+func TestLint(t *testing.T) {
+	cfg, err := config.LoadDefaults()
+	if err != nil {
+		t.Fatalf("LoadDefaults() failed: %v", err)
+	}
+	for _, finding := range Lint(cfg) {
+		if finding.Severity == Error {
+			t.Errorf("Unexpected error in the default commands: %s", finding)
+		}
+	}
+}
@@ -0,0 +1,362 @@
+// Package mcp serves goldfish commands as Model Context Protocol tools.
+// LLM agents connect over stdio (JSON-RPC 2.0, one message per line) and can
+// then call the vetted, cross-platform commands in commands.yml instead of
+// generating raw shell. Destructive commands are only offered when allowed and
+// must be confirmed on every call.
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/engine"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// ProtocolVersion is the MCP revision this server implements
+const ProtocolVersion = "2025-06-18"
+
+// ConfirmArgument is the tool argument a caller sets to true to run a destructive command
+const ConfirmArgument = "confirm"
+
+// maxOutput caps how much command output is returned to the agent per stream
+const maxOutput = 64 << 10
+
+// JSON-RPC error codes used by the server
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// Options controls which commands are exposed
+type Options struct {
+	// AllowDestructive offers commands marked destructive; they still need confirm: true
+	AllowDestructive bool
+}
+
+// Server answers MCP requests for one platform's commands
+type Server struct {
+	commands []config.Command
+	engine   *engine.Engine
+	platform platform.SupportedPlatform
+	timeout  time.Duration
+	version  string
+}
+
+// NewServer creates an MCP server exposing the listed commands that support currentPlatform
+// version is reported to clients as the server version
+func NewServer(cfg *config.Config, eng *engine.Engine, currentPlatform platform.SupportedPlatform, timeout time.Duration, version string, opts Options) *Server {
+	var commands []config.Command
+	for _, cmd := range cfg.Commands {
+		if !engine.Supports(&cmd, currentPlatform) || !cmd.Listed() {
+			continue
+		}
+		if cmd.Destructive && !opts.AllowDestructive {
+			continue
+		}
+		commands = append(commands, cmd)
+	}
+	return &Server{
+		commands: commands,
+		engine:   eng,
+		platform: currentPlatform,
+		timeout:  timeout,
+		version:  version,
+	}
+}
+
+// request is an incoming JSON-RPC message; notifications have no ID
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// response is an outgoing JSON-RPC reply
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// rpcError is a JSON-RPC error object
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Serve reads requests from in and writes responses to out until in is closed
+func (s *Server) Serve(in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	// Tool calls can carry long arguments; allow lines well beyond the 64KB default
+	scanner.Buffer(make([]byte, 0, 64<<10), 4<<20)
+	encoder := json.NewEncoder(out)
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if resp := s.handle(line); resp != nil {
+			if err := encoder.Encode(resp); err != nil {
+				return fmt.Errorf("failed to write response: %w", err)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read request: %w", err)
+	}
+	return nil
+}
+
+// handle processes one message, returning nil for notifications
+func (s *Server) handle(line []byte) *response {
+	var req request
+	if err := json.Unmarshal(line, &req); err != nil {
+		return &response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{codeParseError, "parse error: " + err.Error()}}
+	}
+	if len(req.ID) == 0 {
+		// Notifications (e.g. notifications/initialized) need no reply
+		return nil
+	}
+
+	resp := &response{JSONRPC: "2.0", ID: req.ID}
+	if req.JSONRPC != "2.0" {
+		resp.Error = &rpcError{codeInvalidRequest, "jsonrpc must be \"2.0\""}
+		return resp
+	}
+
+	switch req.Method {
+	case "initialize":
+		resp.Result = s.initialize()
+	case "ping":
+		resp.Result = struct{}{}
+	case "tools/list":
+		resp.Result = map[string]interface{}{"tools": s.tools()}
+	case "tools/call":
+		result, err := s.callTool(req.Params)
+		if err != nil {
+			resp.Error = err
+		} else {
+			resp.Result = result
+		}
+	default:
+		resp.Error = &rpcError{codeMethodNotFound, "method not found: " + req.Method}
+	}
+	return resp
+}
+
+// initialize answers the MCP handshake, advertising tool support
+func (s *Server) initialize() map[string]interface{} {
+	return map[string]interface{}{
+		"protocolVersion": ProtocolVersion,
+		"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+		"serverInfo":      map[string]interface{}{"name": "goldfish", "version": s.version},
+		"instructions": "Each tool runs a vetted goldfish command for this machine's platform. " +
+			"Prefer these tools over raw shell. Tools marked destructive need \"" + ConfirmArgument + "\": true, " +
+			"which must only be set after the user has approved the call.",
+	}
+}
+
+// tools describes every exposed command as an MCP tool
+func (s *Server) tools() []map[string]interface{} {
+	tools := make([]map[string]interface{}, 0, len(s.commands))
+	for i := range s.commands {
+		tools = append(tools, toolDefinition(&s.commands[i]))
+	}
+	return tools
+}
+
+// toolDefinition converts a command into an MCP tool with a JSON Schema for its parameters
+func toolDefinition(cmd *config.Command) map[string]interface{} {
+	properties := make(map[string]interface{})
+	required := []string{}
+	for _, param := range cmd.Parameters {
+		property := map[string]interface{}{"type": jsonType(param.Type)}
+		if param.Description != "" {
+			property["description"] = param.Description
+		}
+		// A templated default is computed from the other arguments, so it is
+		// not a value the agent could send
+		if _, templated := param.DefaultTemplate(); param.Default != nil && !templated {
+			property["default"] = param.Default
+		}
+		properties[param.Name] = property
+		if param.Required {
+			required = append(required, param.Name)
+		}
+	}
+
+	description := cmd.Description
+	if cmd.Destructive {
+		properties[ConfirmArgument] = map[string]interface{}{
+			"type":        "boolean",
+			"description": "Must be true to run this destructive command; only set it once the user has approved",
+		}
+		required = append(required, ConfirmArgument)
+		description += " (destructive: requires user confirmation)"
+	}
+	sort.Strings(required)
+
+	return map[string]interface{}{
+		"name":        cmd.Name,
+		"description": description,
+		"inputSchema": map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"required":             required,
+			"additionalProperties": false,
+		},
+		"annotations": map[string]interface{}{
+			"destructiveHint": cmd.Destructive,
+			"readOnlyHint":    false,
+			"openWorldHint":   false,
+		},
+	}
+}
+
+// jsonType maps a goldfish parameter type to its JSON Schema type
+func jsonType(paramType string) string {
+	switch paramType {
+	case "bool":
+		return "boolean"
+	case "int":
+		return "integer"
+	case "float":
+		return "number"
+	default:
+		return "string"
+	}
+}
+
+// callTool runs the command behind a tools/call request
+// Failures of the command itself are reported in the result (isError) so the
+// agent can see them; protocol errors are reserved for malformed requests
+func (s *Server) callTool(raw json.RawMessage) (map[string]interface{}, *rpcError) {
+	var params struct {
+		Name      string                     `json:"name"`
+		Arguments map[string]json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, &rpcError{codeInvalidParams, "invalid tools/call params: " + err.Error()}
+	}
+
+	cmd := s.findCommand(params.Name)
+	if cmd == nil {
+		return nil, &rpcError{codeInvalidParams, "unknown tool: " + params.Name}
+	}
+
+	// Destructive commands run only when the caller confirms this specific call
+	if cmd.Destructive {
+		var confirmed bool
+		if value, found := params.Arguments[ConfirmArgument]; found {
+			_ = json.Unmarshal(value, &confirmed)
+		}
+		if !confirmed {
+			return toolResult(fmt.Sprintf("%s is destructive and was not run: ask the user for approval, then call it again with \"%s\": true", cmd.Name, ConfirmArgument), true), nil
+		}
+	}
+
+	// Arguments are passed like flags, as text converted to each parameter's type
+	flags := make(map[string]interface{}, len(params.Arguments))
+	for name, value := range params.Arguments {
+		if name == ConfirmArgument && cmd.Destructive {
+			continue
+		}
+		flags["--"+name] = argumentText(value)
+	}
+	parsed, err := s.engine.ParseParameters(cmd, nil, flags)
+	if err != nil {
+		return toolResult("invalid arguments: "+err.Error(), true), nil
+	}
+
+	var stdout, stderr limitedBuffer
+	execCtx := &engine.ExecutionContext{
+		Command:    cmd,
+		Platform:   s.platform,
+		Parameters: parsed,
+		Timeout:    s.timeout,
+		Stdin:      bytes.NewReader(nil),
+		Stdout:     &stdout,
+		Stderr:     &stderr,
+	}
+	exitCode, runErr := s.engine.Run(context.Background(), execCtx)
+
+	var text strings.Builder
+	text.WriteString(stdout.String())
+	if stderr.Len() > 0 {
+		fmt.Fprintf(&text, "\n[stderr]\n%s", stderr.String())
+	}
+	if runErr != nil {
+		// Errors such as a timeout name the command line, secrets included
+		fmt.Fprintf(&text, "\n[error] %s", s.engine.MaskError(execCtx, runErr))
+	}
+	fmt.Fprintf(&text, "\n[exit code %d]", exitCode)
+	return toolResult(strings.TrimLeft(text.String(), "\n"), runErr != nil || exitCode != 0), nil
+}
+
+// findCommand returns the exposed command with the given name
+func (s *Server) findCommand(name string) *config.Command {
+	for i := range s.commands {
+		if s.commands[i].Name == name {
+			return &s.commands[i]
+		}
+	}
+	return nil
+}
+
+// argumentText converts a JSON argument into the text form ParseParameters expects
+func argumentText(value json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(value, &text); err == nil {
+		return text
+	}
+	// Numbers and booleans are used exactly as written
+	return string(bytes.TrimSpace(value))
+}
+
+// toolResult builds a tools/call result with a single text block
+func toolResult(text string, isError bool) map[string]interface{} {
+	return map[string]interface{}{
+		"content": []map[string]interface{}{{"type": "text", "text": text}},
+		"isError": isError,
+	}
+}
+
+// limitedBuffer keeps the first maxOutput bytes written and discards the rest
+type limitedBuffer struct {
+	bytes.Buffer
+	truncated bool
+}
+
+// Write never fails, so a chatty command is not killed by a full buffer
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := maxOutput - b.Len(); room < len(p) {
+		b.truncated = true
+		if room > 0 {
+			b.Buffer.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
+// String returns the kept output, noting when some was dropped
+func (b *limitedBuffer) String() string {
+	if b.truncated {
+		return b.Buffer.String() + "\n[output truncated]"
+	}
+	return b.Buffer.String()
+}
@@ -0,0 +1,214 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/engine"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// testServer returns a server with one safe and one destructive command
+func testServer(t *testing.T, opts Options) *Server {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+	cfg := &config.Config{
+		Commands: []config.Command{
+			{
+				Name:        "greet",
+				Description: "Say hello",
+				BaseCommand: "echo",
+				Parameters: []config.Parameter{
+					{Name: "who", Type: "string", Required: true, Description: "who to greet"},
+					{Name: "times", Type: "int", Default: 1},
+				},
+				Platforms: map[string]config.PlatformCommand{runtime.GOOS: {Template: "echo hello {{.params.who}} x{{.params.times}}; exit {{if eq .params.who \"fail\"}}4{{else}}0{{end}}"}},
+			},
+			{
+				Name:        "wipe",
+				Description: "Delete a file",
+				BaseCommand: "rm",
+				Destructive: true,
+				Parameters:  []config.Parameter{{Name: "file", Type: "string", Required: true}},
+				Platforms:   map[string]config.PlatformCommand{runtime.GOOS: {Template: "rm {{.params.file}}"}},
+			},
+		},
+	}
+	return NewServer(cfg, engine.NewEngine(5*time.Second), platform.SupportedPlatform(runtime.GOOS), 5*time.Second, "test", opts)
+}
+
+// exchange sends newline-delimited requests and returns the decoded responses
+func exchange(t *testing.T, server *Server, requests ...string) []map[string]interface{} {
+	t.Helper()
+	var out bytes.Buffer
+	if err := server.Serve(strings.NewReader(strings.Join(requests, "\n")+"\n"), &out); err != nil {
+		t.Fatalf("Serve() failed: %v", err)
+	}
+	var responses []map[string]interface{}
+	decoder := json.NewDecoder(&out)
+	for decoder.More() {
+		var resp map[string]interface{}
+		if err := decoder.Decode(&resp); err != nil {
+			t.Fatalf("Invalid response: %v", err)
+		}
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+// resultText returns the text of a tools/call result and whether it is an error
+func resultText(t *testing.T, resp map[string]interface{}) (string, bool) {
+	t.Helper()
+	result, ok := resp["result"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected a result, got %v", resp)
+	}
+	content := result["content"].([]interface{})[0].(map[string]interface{})
+	return content["text"].(string), result["isError"].(bool)
+}
+
+// TestServer_Handshake tests initialize, notifications and unknown methods
+func TestServer_Handshake(t *testing.T) {
+	responses := exchange(t, testServer(t, Options{}),
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18"}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"ping"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"resources/list"}`,
+		`not json`,
+	)
+	// The notification gets no reply
+	if len(responses) != 4 {
+		t.Fatalf("Expected 4 responses, got %d", len(responses))
+	}
+	result := responses[0]["result"].(map[string]interface{})
+	if result["protocolVersion"] != ProtocolVersion {
+		t.Errorf("Unexpected protocol version %v", result["protocolVersion"])
+	}
+	if responses[2]["error"].(map[string]interface{})["code"].(float64) != codeMethodNotFound {
+		t.Errorf("Expected method not found, got %v", responses[2])
+	}
+	if responses[3]["error"].(map[string]interface{})["code"].(float64) != codeParseError {
+		t.Errorf("Expected parse error, got %v", responses[3])
+	}
+}
+
+// TestServer_ToolsList tests the tool schemas and hiding destructive commands
+func TestServer_ToolsList(t *testing.T) {
+	request := `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`
+
+	tools := exchange(t, testServer(t, Options{}), request)[0]["result"].(map[string]interface{})["tools"].([]interface{})
+	if len(tools) != 1 {
+		t.Fatalf("Expected destructive commands to be hidden by default, got %d tools", len(tools))
+	}
+	greet := tools[0].(map[string]interface{})
+	schema := greet["inputSchema"].(map[string]interface{})
+	properties := schema["properties"].(map[string]interface{})
+	if properties["times"].(map[string]interface{})["type"] != "integer" {
+		t.Errorf("Expected int params to be integers, got %v", properties["times"])
+	}
+	if required := schema["required"].([]interface{}); len(required) != 1 || required[0] != "who" {
+		t.Errorf("Unexpected required list %v", required)
+	}
+
+	tools = exchange(t, testServer(t, Options{AllowDestructive: true}), request)[0]["result"].(map[string]interface{})["tools"].([]interface{})
+	if len(tools) != 2 {
+		t.Fatalf("Expected destructive commands with AllowDestructive, got %d tools", len(tools))
+	}
+	wipe := tools[1].(map[string]interface{})
+	if wipe["annotations"].(map[string]interface{})["destructiveHint"] != true {
+		t.Errorf("Expected destructiveHint on wipe")
+	}
+	if _, found := wipe["inputSchema"].(map[string]interface{})["properties"].(map[string]interface{})[ConfirmArgument]; !found {
+		t.Errorf("Expected a confirm argument on destructive tools")
+	}
+}
+
+// TestServer_ToolsCall tests running tools and reporting failures
+func TestServer_ToolsCall(t *testing.T) {
+	responses := exchange(t, testServer(t, Options{}),
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"greet","arguments":{"who":"agent","times":3}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"greet","arguments":{"who":"fail"}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"greet","arguments":{}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"wipe","arguments":{"file":"x","confirm":true}}}`,
+	)
+
+	if text, isError := resultText(t, responses[0]); isError || !strings.Contains(text, "hello agent x3") {
+		t.Errorf("Unexpected result %q (error %v)", text, isError)
+	}
+	if text, isError := resultText(t, responses[1]); !isError || !strings.Contains(text, "[exit code 4]") {
+		t.Errorf("Expected a failing exit code, got %q", text)
+	}
+	if text, isError := resultText(t, responses[2]); !isError || !strings.Contains(text, "who") {
+		t.Errorf("Expected missing argument error, got %q", text)
+	}
+	// Hidden destructive tools cannot be called even with confirmation
+	if responses[3]["error"] == nil {
+		t.Errorf("Expected unexposed destructive tool to be unknown, got %v", responses[3])
+	}
+}
+
+// TestServer_ToolsCall_Destructive tests that destructive tools need confirmation
+func TestServer_ToolsCall_Destructive(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "victim.txt")
+	if err := os.WriteFile(target, []byte("data"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	server := testServer(t, Options{AllowDestructive: true})
+
+	unconfirmed := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"wipe","arguments":{"file":"` + target + `"}}}`
+	text, isError := resultText(t, exchange(t, server, unconfirmed)[0])
+	if !isError || !strings.Contains(text, "not run") {
+		t.Errorf("Expected unconfirmed call to be refused, got %q", text)
+	}
+	if _, err := os.Stat(target); err != nil {
+		t.Fatalf("Expected file to survive an unconfirmed call")
+	}
+
+	confirmed := `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"wipe","arguments":{"file":"` + target + `","confirm":true}}}`
+	if text, isError := resultText(t, exchange(t, server, confirmed)[0]); isError {
+		t.Fatalf("Expected confirmed call to run, got %q", text)
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Errorf("Expected file to be removed after confirmation")
+	}
+}
+
+// TestServer_ToolsCall_MasksSecrets tests that an execution error naming the
+// command line does not send its secret parameters to the client
+func TestServer_ToolsCall_MasksSecrets(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+	cfg := &config.Config{Commands: []config.Command{{
+		Name:        "slow",
+		BaseCommand: "sleep",
+		Parameters:  []config.Parameter{{Name: "token", Type: "string", Secret: true}},
+		Platforms:   map[string]config.PlatformCommand{runtime.GOOS: {Template: "sleep 5 # {{.params.token}}"}},
+	}}}
+	server := NewServer(cfg, engine.NewEngine(time.Second), platform.SupportedPlatform(runtime.GOOS), 200*time.Millisecond, "test", Options{})
+	responses := exchange(t, server, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"slow","arguments":{"token":"s3cr3t"}}}`)
+	if text, isError := resultText(t, responses[0]); !isError || !strings.Contains(text, "timed out") || strings.Contains(text, "s3cr3t") {
+		t.Errorf("Expected the timeout with the secret masked, got %q", text)
+	}
+}
+
+// TestLimitedBuffer tests that output beyond the cap is dropped
+func TestLimitedBuffer(t *testing.T) {
+	var buffer limitedBuffer
+	n, err := buffer.Write(bytes.Repeat([]byte("x"), maxOutput+10))
+	if err != nil || n != maxOutput+10 {
+		t.Errorf("Expected writes to always succeed, got %d, %v", n, err)
+	}
+	if buffer.Len() != maxOutput || !strings.HasSuffix(buffer.String(), "[output truncated]") {
+		t.Errorf("Expected output to be capped and marked truncated")
+	}
+}
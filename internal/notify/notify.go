@@ -0,0 +1,196 @@
+// Package notify tells people that a command goldfish ran has finished:
+// with a desktop notification on the machine goldfish runs on, and with a
+// JSON summary posted to a webhook, such as a Slack incoming webhook, for
+// long running jobs nobody sits and watches.
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Title is the title of desktop notifications
+const Title = "goldfish"
+
+// client posts to webhooks; a slow webhook must not hold goldfish up for long
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// Event describes a command that has finished
+type Event struct {
+	// Command is the name of the goldfish command
+	Command string
+	// Host is the machine it ran on
+	Host string
+	// Time is when it started
+	Time time.Time
+	// Duration is how long it ran
+	Duration time.Duration
+	// ExitCode is its exit code (-1 if it did not run to completion)
+	ExitCode int
+	// Error describes why it did not run to completion, if it didn't
+	Error string
+}
+
+// Succeeded reports whether the command ran and exited with 0
+func (ev *Event) Succeeded() bool {
+	return ev.Error == "" && ev.ExitCode == 0
+}
+
+// Summary describes the run in a line, e.g. "'backup' failed with exit code
+// 2 after 3m2.5s"
+func (ev *Event) Summary() string {
+	duration := formatDuration(ev.Duration)
+	switch {
+	case ev.Error != "":
+		return fmt.Sprintf("'%s' failed after %s: %s", ev.Command, duration, ev.Error)
+	case ev.ExitCode != 0:
+		return fmt.Sprintf("'%s' failed with exit code %d after %s", ev.Command, ev.ExitCode, duration)
+	}
+	return fmt.Sprintf("'%s' succeeded in %s", ev.Command, duration)
+}
+
+// formatDuration shortens a duration for messages, e.g. "3m2.5s" or "120ms"
+func formatDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
+
+// Payload is the JSON posted to webhooks
+// Text is the field Slack (and the many services compatible with its
+// incoming webhooks) shows as the message; the others are for services
+// that process the run.
+type Payload struct {
+	Text       string    `json:"text"`
+	Command    string    `json:"command"`
+	Status     string    `json:"status"`
+	ExitCode   int       `json:"exit_code"`
+	DurationMS int64     `json:"duration_ms"`
+	Host       string    `json:"host,omitempty"`
+	Time       time.Time `json:"time"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// NewPayload returns the webhook payload for an event
+func NewPayload(ev *Event) *Payload {
+	payload := &Payload{
+		Text:       Title + ": " + ev.Summary(),
+		Command:    ev.Command,
+		Status:     "succeeded",
+		ExitCode:   ev.ExitCode,
+		DurationMS: ev.Duration.Milliseconds(),
+		Host:       ev.Host,
+		Time:       ev.Time.UTC(),
+		Error:      ev.Error,
+	}
+	if !ev.Succeeded() {
+		payload.Status = "failed"
+	}
+	if ev.Host != "" {
+		payload.Text += " on " + ev.Host
+	}
+	return payload
+}
+
+// Webhook posts the event's payload to a webhook URL
+// Any 2xx response is success; the start of the body of any other is
+// included in the error, since services explain rejected messages there.
+func Webhook(webhook string, ev *Event) error {
+	body, err := json.Marshal(NewPayload(ev))
+	if err != nil {
+		return fmt.Errorf("failed to encode the notification: %w", err)
+	}
+	resp, err := client.Post(webhook, "application/json", bytes.NewReader(body))
+	if err != nil {
+		// The error names the URL, which may be secret
+		return fmt.Errorf("webhook request failed: %w", unwrapURLError(err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		reply, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		if text := strings.TrimSpace(string(reply)); text != "" {
+			return fmt.Errorf("webhook returned %s: %s", resp.Status, text)
+		}
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// unwrapURLError drops the method and URL net/http adds to request errors
+func unwrapURLError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
+
+// Desktop shows the event as a desktop notification
+// It uses notify-send on Linux and the BSDs, osascript on macOS and
+// PowerShell on Windows, so it fails where those are missing, e.g. on a
+// server without a desktop.
+func Desktop(ev *Event) error {
+	name, args := desktopCommand(runtime.GOOS, Title, ev.Summary())
+	if _, err := exec.LookPath(name); err != nil {
+		return fmt.Errorf("desktop notifications need %s: %w", name, err)
+	}
+	if output, err := exec.Command(name, args...).CombinedOutput(); err != nil {
+		if text := strings.TrimSpace(string(output)); text != "" {
+			return fmt.Errorf("%s failed: %w: %s", name, err, text)
+		}
+		return fmt.Errorf("%s failed: %w", name, err)
+	}
+	return nil
+}
+
+// windowsAppID is the application notifications are shown as on Windows;
+// Windows only shows toasts from registered applications, and PowerShell is one
+const windowsAppID = `{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe`
+
+// desktopCommand returns the program and arguments that show a desktop
+// notification on an operating system
+// The title and message are passed as arguments, never as script text,
+// except on Windows, where they are PowerShell string literals.
+func desktopCommand(goos, title, message string) (string, []string) {
+	switch goos {
+	case "darwin":
+		return "osascript", []string{
+			"-e", "on run argv",
+			"-e", "display notification (item 2 of argv) with title (item 1 of argv)",
+			"-e", "end run",
+			title, message,
+		}
+	case "windows":
+		script := strings.Join([]string{
+			"[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null",
+			"$toast = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)",
+			"$texts = $toast.GetElementsByTagName('text')",
+			"$texts.Item(0).AppendChild($toast.CreateTextNode(" + powershellString(title) + ")) > $null",
+			"$texts.Item(1).AppendChild($toast.CreateTextNode(" + powershellString(message) + ")) > $null",
+			"[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier(" + powershellString(windowsAppID) + ").Show([Windows.UI.Notifications.ToastNotification]::new($toast))",
+		}, "; ")
+		return "powershell", []string{"-NoProfile", "-NonInteractive", "-Command", script}
+	}
+	return "notify-send", []string{"--app-name=" + title, title, message}
+}
+
+// powershellQuotes doubles the characters PowerShell ends a literal string
+// at: the apostrophe and the typographic single quotes
+var powershellQuotes = strings.NewReplacer("'", "''", "\u2018", "\u2018\u2018", "\u2019", "\u2019\u2019",
+	"\u201a", "\u201a\u201a", "\u201b", "\u201b\u201b")
+
+// powershellString quotes text as a PowerShell literal string, in which
+// nothing but a doubled quote is special
+func powershellString(text string) string {
+	return "'" + powershellQuotes.Replace(text) + "'"
+}
@@ -0,0 +1,93 @@
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestEvent_Summary tests the one line descriptions of runs
+func TestEvent_Summary(t *testing.T) {
+	testCases := []struct {
+		event    Event
+		expected string
+	}{
+		{Event{Command: "backup", Duration: 3*time.Minute + 2512*time.Millisecond}, "'backup' succeeded in 3m2.5s"},
+		{Event{Command: "backup", Duration: 120 * time.Millisecond, ExitCode: 2}, "'backup' failed with exit code 2 after 120ms"},
+		{Event{Command: "backup", Duration: time.Second, ExitCode: -1, Error: "timed out"}, "'backup' failed after 1s: timed out"},
+	}
+	for _, tc := range testCases {
+		if summary := tc.event.Summary(); summary != tc.expected {
+			t.Errorf("Expected %q, got %q", tc.expected, summary)
+		}
+	}
+}
+
+// TestWebhook tests that the payload is posted as JSON with a Slack text field
+func TestWebhook(t *testing.T) {
+	var received Payload
+	var contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("Failed to decode the payload: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	event := &Event{Command: "deploy", Host: "build1", Time: time.Now(), Duration: 90 * time.Second, ExitCode: 3}
+	if err := Webhook(server.URL, event); err != nil {
+		t.Fatalf("Webhook failed: %v", err)
+	}
+	if contentType != "application/json" {
+		t.Errorf("Expected JSON, got %q", contentType)
+	}
+	if received.Text != "goldfish: 'deploy' failed with exit code 3 after 1m30s on build1" {
+		t.Errorf("Unexpected text %q", received.Text)
+	}
+	if received.Command != "deploy" || received.Status != "failed" || received.ExitCode != 3 || received.DurationMS != 90000 || received.Host != "build1" {
+		t.Errorf("Unexpected payload %+v", received)
+	}
+}
+
+// TestWebhook_Rejected tests that a webhook's error response is reported
+func TestWebhook_Rejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid_token", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	err := Webhook(server.URL+"/secret-token", &Event{Command: "deploy"})
+	if err == nil || !strings.Contains(err.Error(), "403 Forbidden: invalid_token") {
+		t.Errorf("Expected the response to be reported, got %v", err)
+	}
+
+	// The URL is left out of connection errors
+	server.Close()
+	err = Webhook(server.URL+"/secret-token", &Event{Command: "deploy"})
+	if err == nil || strings.Contains(err.Error(), "secret-token") {
+		t.Errorf("Expected an error without the URL, got %v", err)
+	}
+}
+
+// TestDesktopCommand tests the notification programs used on each system
+func TestDesktopCommand(t *testing.T) {
+	name, args := desktopCommand("linux", "goldfish", "'backup' succeeded")
+	if name != "notify-send" || strings.Join(args, " ") != "--app-name=goldfish goldfish 'backup' succeeded" {
+		t.Errorf("Unexpected Linux command %s %q", name, args)
+	}
+
+	// The title and message are arguments to the script, not part of it
+	name, args = desktopCommand("darwin", "goldfish", `"; do shell script "rm`)
+	if name != "osascript" || args[len(args)-1] != `"; do shell script "rm` || strings.Contains(strings.Join(args[:len(args)-2], " "), "rm") {
+		t.Errorf("Unexpected macOS command %s %q", name, args)
+	}
+
+	name, args = desktopCommand("windows", "goldfish", "it's ’done’")
+	if name != "powershell" || !strings.Contains(args[len(args)-1], "CreateTextNode('it''s ’’done’’')") {
+		t.Errorf("Unexpected Windows command %s %q", name, args)
+	}
+}
@@ -0,0 +1,144 @@
+// Package output prints goldfish's own errors and warnings for people.
+// Errors are split into one line per layer of context, configuration errors
+// name the offending YAML path, and the "Error:" and "Warning:" labels are
+// colored on terminals unless NO_COLOR or --no-color ask otherwise.
+package output
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/danballance/goldfish/internal/config"
+	"golang.org/x/term"
+)
+
+// NoColorEnvVar turns colors off when set to any value (see https://no-color.org)
+const NoColorEnvVar = "NO_COLOR"
+
+// ANSI escape sequences for the few styles goldfish uses
+const (
+	styleError   = "\033[1;31m"
+	styleWarning = "\033[1;33m"
+	styleDim     = "\033[2m"
+	styleReset   = "\033[0m"
+)
+
+// Printer writes errors and warnings, colored when it writes to a terminal
+type Printer struct {
+	w     io.Writer
+	color bool
+}
+
+// New creates a Printer for w
+// Colors are used when w is a terminal, NO_COLOR is not set and noColor is false.
+func New(w io.Writer, noColor bool) *Printer {
+	return &Printer{w: w, color: !noColor && ColorEnabled(w)}
+}
+
+// NewColored creates a Printer that colors its output exactly when color is
+// set, whatever w is, e.g. for the color: always setting
+func NewColored(w io.Writer, color bool) *Printer {
+	return &Printer{w: w, color: color}
+}
+
+// ColorEnabled reports whether colors suit w: it must be a terminal and NO_COLOR unset
+func ColorEnabled(w io.Writer) bool {
+	if _, set := os.LookupEnv(NoColorEnvVar); set {
+		return false
+	}
+	file, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(file.Fd()))
+}
+
+// style wraps text in an escape sequence when colors are on
+func (p *Printer) style(code, text string) string {
+	if !p.color {
+		return text
+	}
+	return code + text + styleReset
+}
+
+// Error prints an error
+// The first line is the outermost message; each error it wraps follows on
+// its own indented line, so long chains from template failures stay readable:
+//
+//	Error: failed to render command template
+//	  failed to execute template
+//	  template: command:1:12: executing "command" at <.params.x>: ...
+//
+// Configuration errors are followed by the file and YAML path they concern.
+func (p *Printer) Error(err error) {
+	lines := Chain(err)
+	fmt.Fprintf(p.w, "%s %s\n", p.style(styleError, "Error:"), lines[0])
+	for _, line := range lines[1:] {
+		fmt.Fprintf(p.w, "  %s\n", line)
+	}
+
+	var invalid *config.ValidationError
+	if errors.As(err, &invalid) {
+		location := invalid.Path
+		if invalid.Source != "" {
+			location = invalid.Source + ": " + location
+		}
+		fmt.Fprintf(p.w, "  %s\n", p.style(styleDim, "at "+location))
+	}
+}
+
+// Warning prints a warning
+func (p *Printer) Warning(message string) {
+	fmt.Fprintf(p.w, "%s %s\n", p.style(styleWarning, "Warning:"), strings.TrimSuffix(message, "\n"))
+}
+
+// Chain splits an error into the messages of the errors it wraps, outermost first
+// fmt.Errorf("context: %w", err) produces "context: <err>"; each layer
+// contributes its context. A layer that adds text after the wrapped error
+// (such as a hint) is kept whole, since it cannot be split cleanly.
+func Chain(err error) []string {
+	var lines []string
+	for err != nil {
+		message := err.Error()
+		inner := errors.Unwrap(err)
+		if inner == nil || !strings.HasSuffix(message, inner.Error()) {
+			lines = append(lines, message)
+			break
+		}
+		context := strings.TrimSuffix(strings.TrimSuffix(message, inner.Error()), ": ")
+		if context != "" && context != message {
+			lines = append(lines, context)
+		}
+		err = inner
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "unknown error")
+	}
+	return lines
+}
+
+// WarningWriter returns a writer for messages that already start with "Warning:"
+// (such as config.Warnings); the label is colored like Warning's.
+func (p *Printer) WarningWriter() io.Writer {
+	return &warningWriter{printer: p}
+}
+
+// warningWriter colors the "Warning:" label of each message written to it
+type warningWriter struct {
+	mu      sync.Mutex
+	printer *Printer
+}
+
+// Write prints the message with its label styled
+func (w *warningWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	message := bytes.TrimPrefix(data, []byte("Warning: "))
+	if len(message) == len(data) {
+		return w.printer.w.Write(data)
+	}
+	w.printer.Warning(string(message))
+	return len(data), nil
+}
@@ -0,0 +1,75 @@
+package output
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// TestChain tests splitting wrapped errors into one message per layer
+func TestChain(t *testing.T) {
+	inner := errors.New(`template: command:1:12: executing "command" at <.params.x>: map has no entry for key "x"`)
+	err := fmt.Errorf("failed to render command template: %w", fmt.Errorf("failed to execute template: %w", inner))
+
+	lines := Chain(err)
+	expected := []string{"failed to render command template", "failed to execute template", inner.Error()}
+	if fmt.Sprint(lines) != fmt.Sprint(expected) {
+		t.Errorf("Expected %q, got %q", expected, lines)
+	}
+
+	// Text after the wrapped error keeps the layer whole
+	hinted := fmt.Errorf("%w\nRun 'goldfish --help' for usage", inner)
+	if lines := Chain(hinted); len(lines) != 1 || lines[0] != hinted.Error() {
+		t.Errorf("Expected the hint to stay with its error, got %q", lines)
+	}
+}
+
+// TestPrinter_Error tests printing errors with their YAML path
+func TestPrinter_Error(t *testing.T) {
+	var buf bytes.Buffer
+	printer := New(&buf, false)
+
+	err := fmt.Errorf("config validation failed: %w", &config.ValidationError{
+		Source: "commands.yml",
+		Path:   "commands[2].params[0].type",
+		Err:    errors.New("command 'x': parameter 'a': invalid type 'strng'"),
+	})
+	printer.Error(err)
+
+	expected := "Error: config validation failed\n  command 'x': parameter 'a': invalid type 'strng'\n  at commands.yml: commands[2].params[0].type\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+// TestPrinter_Color tests coloring labels unless NO_COLOR is set
+func TestPrinter_Color(t *testing.T) {
+	var buf bytes.Buffer
+	printer := &Printer{w: &buf, color: true}
+	printer.Warning("something odd\n")
+	if expected := styleWarning + "Warning:" + styleReset + " something odd\n"; buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+
+	// Warnings written by other packages get the same label
+	buf.Reset()
+	fmt.Fprint(printer.WarningWriter(), "Warning: skipping x\n")
+	if expected := styleWarning + "Warning:" + styleReset + " skipping x\n"; buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+
+	// Buffers are not terminals, and NO_COLOR turns colors off everywhere
+	if ColorEnabled(&buf) {
+		t.Error("Expected no colors for a buffer")
+	}
+	t.Setenv(NoColorEnvVar, "")
+	if New(&buf, false).color {
+		t.Error("Expected NO_COLOR to turn colors off")
+	}
+	if !NewColored(&buf, true).color {
+		t.Error("Expected NewColored to color a buffer")
+	}
+}
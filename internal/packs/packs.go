@@ -0,0 +1,363 @@
+// Package packs installs and manages command packs: shareable commands.yml
+// files such as "docker-essentials" or "macos-admin" that are downloaded into
+// the packs directory and merged into the configuration like any other layer.
+package packs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// RegistryEnvVar names the base URL packs are looked up in when installed by name
+// A pack "name" at version "v" is fetched from <registry>/<name>/<v>.yml, and
+// the newest release from <registry>/<name>/latest.yml
+const RegistryEnvVar = "GOLDFISH_PACK_REGISTRY"
+
+// LockFileName is the file in the packs directory recording what is installed
+const LockFileName = "packs.lock"
+
+// maxPackSize caps how much is read from a pack download
+const maxPackSize = 1 << 20
+
+// InstalledPack is one entry in the lock file
+type InstalledPack struct {
+	// Name is the pack name from its metadata
+	Name string `yaml:"name"`
+	// Version is the installed version from the pack metadata
+	Version string `yaml:"version"`
+	// Source is the URL the pack was downloaded from
+	Source string `yaml:"source"`
+	// SHA256 is the checksum of the downloaded file
+	SHA256 string `yaml:"sha256"`
+	// Pinned packs were installed at an explicit version and are skipped by update
+	Pinned bool `yaml:"pinned,omitempty"`
+}
+
+// lockFile is the on-disk format of packs.lock
+type lockFile struct {
+	Packs []InstalledPack `yaml:"packs"`
+}
+
+// Manager installs, updates and removes packs in a packs directory
+type Manager struct {
+	dir      string
+	registry string
+	client   *http.Client
+	// trustedKeys, when set, require every pack to be signed by one of them
+	trustedKeys []string
+}
+
+// NewManager creates a manager for the configured packs directory and registry
+func NewManager() (*Manager, error) {
+	dir, err := config.PacksDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewManagerFor(dir, os.Getenv(RegistryEnvVar)).TrustKeys(config.TrustedKeys()), nil
+}
+
+// NewManagerFor creates a manager for an explicit directory and registry URL
+// The registry may be empty, in which case packs can only be installed by URL
+func NewManagerFor(dir, registry string) *Manager {
+	return &Manager{
+		dir:      dir,
+		registry: strings.TrimSuffix(registry, "/"),
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// TrustKeys makes the manager reject packs not signed by one of keys
+// The signature is downloaded from the pack URL plus config.SignatureSuffix
+func (m *Manager) TrustKeys(keys []string) *Manager {
+	m.trustedKeys = keys
+	return m
+}
+
+// Dir returns the directory packs are installed into
+func (m *Manager) Dir() string {
+	return m.dir
+}
+
+// Install downloads a pack and records it in the lock file
+// ref is either an http(s) URL or a registry name with an optional version,
+// e.g. "docker-essentials" or "docker-essentials@1.2.0"; an explicit version
+// pins the pack so update leaves it alone
+func (m *Manager) Install(ref string) (*InstalledPack, error) {
+	source, wantName, wantVersion, err := m.resolve(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	installed, err := m.download(source, wantName, wantVersion)
+	if err != nil {
+		return nil, err
+	}
+	installed.Pinned = wantVersion != ""
+
+	if err := m.record(*installed); err != nil {
+		return nil, err
+	}
+	return installed, nil
+}
+
+// List returns the installed packs sorted by name
+func (m *Manager) List() ([]InstalledPack, error) {
+	lock, err := m.readLock()
+	if err != nil {
+		return nil, err
+	}
+	return lock.Packs, nil
+}
+
+// Update re-downloads every unpinned pack from its source
+// It returns the packs whose version changed
+func (m *Manager) Update() ([]InstalledPack, error) {
+	lock, err := m.readLock()
+	if err != nil {
+		return nil, err
+	}
+
+	var updated []InstalledPack
+	for _, current := range lock.Packs {
+		if current.Pinned {
+			continue
+		}
+		latest, err := m.download(current.Source, current.Name, "")
+		if err != nil {
+			return updated, fmt.Errorf("failed to update pack '%s': %w", current.Name, err)
+		}
+		if err := m.record(*latest); err != nil {
+			return updated, err
+		}
+		if latest.Version != current.Version {
+			updated = append(updated, *latest)
+		}
+	}
+	return updated, nil
+}
+
+// Remove deletes an installed pack and its lock file entry
+func (m *Manager) Remove(name string) error {
+	lock, err := m.readLock()
+	if err != nil {
+		return err
+	}
+
+	kept := lock.Packs[:0]
+	found := false
+	for _, pack := range lock.Packs {
+		if pack.Name == name {
+			found = true
+			continue
+		}
+		kept = append(kept, pack)
+	}
+	if !found {
+		return fmt.Errorf("pack '%s' is not installed", name)
+	}
+
+	if err := os.Remove(m.packPath(name)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove pack '%s': %w", name, err)
+	}
+	os.Remove(m.packPath(name) + config.SignatureSuffix)
+	lock.Packs = kept
+	return m.writeLock(lock)
+}
+
+// resolve turns an install reference into a download URL plus the name and
+// version the downloaded pack must declare (empty when not known up front)
+func (m *Manager) resolve(ref string) (source, name, version string, err error) {
+	if strings.HasPrefix(ref, "https://") || strings.HasPrefix(ref, "http://") {
+		return ref, "", "", nil
+	}
+
+	name, version, _ = strings.Cut(ref, "@")
+	if !validPackName(name) {
+		return "", "", "", fmt.Errorf("invalid pack name '%s'", name)
+	}
+	if strings.ContainsAny(version, "/\\") {
+		return "", "", "", fmt.Errorf("invalid pack version '%s'", version)
+	}
+	if m.registry == "" {
+		return "", "", "", fmt.Errorf("no pack registry configured: set %s or install from a URL", RegistryEnvVar)
+	}
+
+	file := "latest.yml"
+	if version != "" {
+		file = version + ".yml"
+	}
+	return m.registry + "/" + name + "/" + file, name, version, nil
+}
+
+// download fetches, validates and saves a pack
+// wantName and wantVersion, when set, must match the pack's metadata
+func (m *Manager) download(source, wantName, wantVersion string) (*InstalledPack, error) {
+	data, err := m.fetch(source)
+	if err != nil {
+		return nil, err
+	}
+
+	// Verify the signature before anything from the pack is trusted
+	var signature []byte
+	if len(m.trustedKeys) > 0 {
+		signature, err = m.fetch(source + config.SignatureSuffix)
+		if err != nil {
+			return nil, fmt.Errorf("%w: no signature for %s", config.ErrUntrustedSignature, source)
+		}
+		if err := config.VerifySignature(data, signature, m.trustedKeys); err != nil {
+			return nil, fmt.Errorf("pack from %s: %w", source, err)
+		}
+	}
+
+	// A pack must be a valid configuration in its own right
+	packConfig, err := config.NewLoader(source).Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pack from %s: %w", source, err)
+	}
+	meta := packConfig.Pack
+	if meta == nil || meta.Name == "" || meta.Version == "" {
+		return nil, fmt.Errorf("pack from %s is missing pack name/version metadata", source)
+	}
+	if !validPackName(meta.Name) {
+		return nil, fmt.Errorf("pack from %s has invalid name '%s'", source, meta.Name)
+	}
+	if wantName != "" && meta.Name != wantName {
+		return nil, fmt.Errorf("pack from %s is named '%s', expected '%s'", source, meta.Name, wantName)
+	}
+	if wantVersion != "" && meta.Version != wantVersion {
+		return nil, fmt.Errorf("pack from %s is version %s, expected %s", source, meta.Version, wantVersion)
+	}
+
+	if err := os.MkdirAll(m.dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create packs directory: %w", err)
+	}
+	// Write to a temporary file first so a failed write never leaves a half pack behind
+	tmp := m.packPath(meta.Name) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write pack: %w", err)
+	}
+	if err := os.Rename(tmp, m.packPath(meta.Name)); err != nil {
+		os.Remove(tmp)
+		return nil, fmt.Errorf("failed to install pack: %w", err)
+	}
+	// The signature is kept beside the pack so it is verified again when loaded
+	signaturePath := m.packPath(meta.Name) + config.SignatureSuffix
+	if signature != nil {
+		if err := os.WriteFile(signaturePath, signature, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write pack signature: %w", err)
+		}
+	} else {
+		os.Remove(signaturePath)
+	}
+
+	sum := sha256.Sum256(data)
+	return &InstalledPack{
+		Name:    meta.Name,
+		Version: meta.Version,
+		Source:  source,
+		SHA256:  hex.EncodeToString(sum[:]),
+	}, nil
+}
+
+// fetch downloads a URL, capped at maxPackSize
+func (m *Manager) fetch(source string) ([]byte, error) {
+	resp, err := m.client.Get(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download pack: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: %s", source, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxPackSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", source, err)
+	}
+	if len(data) > maxPackSize {
+		return nil, fmt.Errorf("%s exceeds %d bytes", source, maxPackSize)
+	}
+	return data, nil
+}
+
+// record adds or replaces a pack's lock file entry
+func (m *Manager) record(pack InstalledPack) error {
+	lock, err := m.readLock()
+	if err != nil {
+		return err
+	}
+	replaced := false
+	for i := range lock.Packs {
+		if lock.Packs[i].Name == pack.Name {
+			lock.Packs[i] = pack
+			replaced = true
+		}
+	}
+	if !replaced {
+		lock.Packs = append(lock.Packs, pack)
+	}
+	return m.writeLock(lock)
+}
+
+// readLock reads packs.lock, returning an empty lock if it does not exist yet
+func (m *Manager) readLock() (*lockFile, error) {
+	lock := &lockFile{}
+	data, err := os.ReadFile(filepath.Join(m.dir, LockFileName))
+	if os.IsNotExist(err) {
+		return lock, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pack lock file: %w", err)
+	}
+	if err := yaml.Unmarshal(data, lock); err != nil {
+		return nil, fmt.Errorf("failed to parse pack lock file: %w", err)
+	}
+	return lock, nil
+}
+
+// writeLock writes packs.lock with entries sorted by name
+func (m *Manager) writeLock(lock *lockFile) error {
+	sort.Slice(lock.Packs, func(i, j int) bool {
+		return lock.Packs[i].Name < lock.Packs[j].Name
+	})
+	data, err := yaml.Marshal(lock)
+	if err != nil {
+		return fmt.Errorf("failed to encode pack lock file: %w", err)
+	}
+	if err := os.MkdirAll(m.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create packs directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(m.dir, LockFileName), data, 0644); err != nil {
+		return fmt.Errorf("failed to write pack lock file: %w", err)
+	}
+	return nil
+}
+
+// packPath returns where the named pack is stored
+func (m *Manager) packPath(name string) string {
+	return filepath.Join(m.dir, name+".yml")
+}
+
+// validPackName reports whether name is safe to use as a file name and URL segment
+func validPackName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
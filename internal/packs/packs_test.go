@@ -0,0 +1,190 @@
+package packs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// packYAML returns a minimal pack definition
+func packYAML(name, version string) string {
+	return "pack:\n  name: \"" + name + "\"\n  version: \"" + version + "\"\n" +
+		"commands:\n  - name: \"" + name + "-cmd\"\n    base_command: \"echo\"\n    platforms:\n      linux:\n        template: \"echo " + version + "\"\n"
+}
+
+// testRegistry serves packs from a map of path -> content
+func testRegistry(t *testing.T, files map[string]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(content))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestManager_Install tests installing by name, by pinned version and by URL
+func TestManager_Install(t *testing.T) {
+	files := map[string]string{
+		"/docker-essentials/latest.yml": packYAML("docker-essentials", "1.1.0"),
+		"/docker-essentials/1.0.0.yml":  packYAML("docker-essentials", "1.0.0"),
+		"/direct.yml":                   packYAML("macos-admin", "0.3.0"),
+	}
+	server := testRegistry(t, files)
+	dir := t.TempDir()
+	manager := NewManagerFor(dir, server.URL+"/")
+
+	latest, err := manager.Install("docker-essentials")
+	if err != nil {
+		t.Fatalf("Install() failed: %v", err)
+	}
+	if latest.Version != "1.1.0" || latest.Pinned {
+		t.Errorf("Expected unpinned 1.1.0, got %+v", latest)
+	}
+
+	pinned, err := manager.Install("docker-essentials@1.0.0")
+	if err != nil {
+		t.Fatalf("Install() pinned failed: %v", err)
+	}
+	if pinned.Version != "1.0.0" || !pinned.Pinned || len(pinned.SHA256) != 64 {
+		t.Errorf("Expected pinned 1.0.0 with checksum, got %+v", pinned)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "docker-essentials.yml"))
+	if !strings.Contains(string(data), "echo 1.0.0") {
+		t.Errorf("Expected pinned version on disk, got %s", data)
+	}
+
+	if _, err := manager.Install(server.URL + "/direct.yml"); err != nil {
+		t.Fatalf("Install() by URL failed: %v", err)
+	}
+
+	installed, err := manager.List()
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+	if len(installed) != 2 || installed[0].Name != "docker-essentials" || installed[1].Name != "macos-admin" {
+		t.Errorf("Expected two packs sorted by name, got %+v", installed)
+	}
+}
+
+// TestManager_Install_Errors tests rejected installs
+func TestManager_Install_Errors(t *testing.T) {
+	server := testRegistry(t, map[string]string{
+		"/wrong/latest.yml":   packYAML("other", "1.0.0"),
+		"/nometa/latest.yml":  "commands:\n  - name: \"x\"\n    base_command: \"x\"\n    platforms:\n      linux:\n        template: \"x\"\n",
+		"/badver/2.0.0.yml":   packYAML("badver", "1.0.0"),
+		"/invalid/latest.yml": "pack:\n  name: invalid\n  version: \"1\"\n",
+	})
+	manager := NewManagerFor(t.TempDir(), server.URL)
+
+	refs := []string{"wrong", "nometa", "badver@2.0.0", "invalid", "missing", "../etc", "ok@../x"}
+	for _, ref := range refs {
+		if _, err := manager.Install(ref); err == nil {
+			t.Errorf("Expected Install(%q) to fail", ref)
+		}
+	}
+
+	// Without a registry only URLs can be installed
+	if _, err := NewManagerFor(t.TempDir(), "").Install("docker-essentials"); err == nil ||
+		!strings.Contains(err.Error(), RegistryEnvVar) {
+		t.Errorf("Expected missing registry error, got %v", err)
+	}
+}
+
+// TestManager_Update tests that only unpinned packs are refreshed
+func TestManager_Update(t *testing.T) {
+	files := map[string]string{
+		"/floating/latest.yml": packYAML("floating", "1.0.0"),
+		"/fixed/1.0.0.yml":     packYAML("fixed", "1.0.0"),
+	}
+	server := testRegistry(t, files)
+	manager := NewManagerFor(t.TempDir(), server.URL)
+
+	for _, ref := range []string{"floating", "fixed@1.0.0"} {
+		if _, err := manager.Install(ref); err != nil {
+			t.Fatalf("Install(%s) failed: %v", ref, err)
+		}
+	}
+
+	// Publish new releases and update
+	files["/floating/latest.yml"] = packYAML("floating", "2.0.0")
+	updated, err := manager.Update()
+	if err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+	if len(updated) != 1 || updated[0].Name != "floating" || updated[0].Version != "2.0.0" {
+		t.Errorf("Expected floating to update to 2.0.0, got %+v", updated)
+	}
+
+	installed, _ := manager.List()
+	for _, pack := range installed {
+		if pack.Name == "fixed" && (pack.Version != "1.0.0" || !pack.Pinned) {
+			t.Errorf("Expected pinned pack to stay at 1.0.0, got %+v", pack)
+		}
+	}
+}
+
+// TestManager_Remove tests removing packs
+func TestManager_Remove(t *testing.T) {
+	server := testRegistry(t, map[string]string{"/tools/latest.yml": packYAML("tools", "1.0.0")})
+	dir := t.TempDir()
+	manager := NewManagerFor(dir, server.URL)
+
+	if _, err := manager.Install("tools"); err != nil {
+		t.Fatalf("Install() failed: %v", err)
+	}
+	if err := manager.Remove("tools"); err != nil {
+		t.Fatalf("Remove() failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "tools.yml")); !os.IsNotExist(err) {
+		t.Errorf("Expected pack file to be deleted")
+	}
+	if installed, _ := manager.List(); len(installed) != 0 {
+		t.Errorf("Expected no packs, got %+v", installed)
+	}
+	if err := manager.Remove("tools"); err == nil {
+		t.Errorf("Expected removing a missing pack to fail")
+	}
+}
+
+// TestManager_Install_Signed tests that trusted keys require a valid signature
+func TestManager_Install_Signed(t *testing.T) {
+	publicKey, privateKey, err := config.GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("GenerateKeyPair() failed: %v", err)
+	}
+	signed := packYAML("signed", "1.0.0")
+	signature, _ := config.Sign([]byte(signed), privateKey)
+	server := testRegistry(t, map[string]string{
+		"/signed/latest.yml":     signed,
+		"/signed/latest.yml.sig": signature,
+		"/unsigned/latest.yml":   packYAML("unsigned", "1.0.0"),
+	})
+	dir := t.TempDir()
+	manager := NewManagerFor(dir, server.URL).TrustKeys([]string{publicKey})
+
+	if _, err := manager.Install("signed"); err != nil {
+		t.Fatalf("Install() of a signed pack failed: %v", err)
+	}
+	// The signature is stored beside the pack for load-time verification
+	if _, err := os.Stat(filepath.Join(dir, "signed.yml.sig")); err != nil {
+		t.Errorf("Expected signature to be saved: %v", err)
+	}
+
+	_, err = manager.Install("unsigned")
+	if err == nil || !strings.Contains(err.Error(), config.ErrUntrustedSignature.Error()) {
+		t.Errorf("Expected unsigned pack to be rejected, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "unsigned.yml")); !os.IsNotExist(err) {
+		t.Errorf("Expected rejected pack not to be written")
+	}
+}
@@ -0,0 +1,160 @@
+package platform
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Linux distributions differ in their userland: Alpine ships BusyBox and musl
+// instead of GNU coreutils and glibc, so the same flags do not work everywhere.
+// The distribution is read from os-release, the file every modern distribution
+// provides (see https://www.freedesktop.org/software/systemd/man/os-release.html).
+
+// Distro describes a Linux distribution
+type Distro struct {
+	// ID is the distribution's own name, e.g. "ubuntu"
+	ID string
+	// Family groups related distributions: "debian", "rhel", "alpine", "arch"
+	// or "suse". Distributions outside these families use their ID.
+	Family string
+	// Libc is the C library, "glibc" or "musl"
+	Libc string
+	// Version is the distribution's release, e.g. "22.04" (empty for rolling releases)
+	Version string
+}
+
+// Libc flavors
+const (
+	Glibc = "glibc"
+	Musl  = "musl"
+)
+
+// osReleasePaths are where os-release may live, in the order they are checked
+var osReleasePaths = []string{"/etc/os-release", "/usr/lib/os-release"}
+
+// muslLoaderPattern matches the dynamic loader of musl-based systems
+const muslLoaderPattern = "/lib/ld-musl-*.so.1"
+
+// distroFamilies maps distribution IDs (and the ID_LIKE values derivatives
+// declare) to their family
+var distroFamilies = map[string]string{
+	"debian":    "debian",
+	"ubuntu":    "debian",
+	"rhel":      "rhel",
+	"fedora":    "rhel",
+	"centos":    "rhel",
+	"alpine":    "alpine",
+	"arch":      "arch",
+	"archlinux": "arch",
+	"suse":      "suse",
+	"opensuse":  "suse",
+}
+
+// ParseOSRelease reads the distribution from the content of an os-release file
+// Derivatives are placed in their parent's family through ID_LIKE, so Linux
+// Mint (ID_LIKE="ubuntu debian") is in the debian family. Libc is assumed from
+// the family; Detector.Distro also checks the installed loader.
+func ParseOSRelease(data []byte) Distro {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		key, value, found := strings.Cut(line, "=")
+		if !found || strings.HasPrefix(line, "#") {
+			continue
+		}
+		values[key] = strings.ToLower(strings.Trim(value, `"'`))
+	}
+
+	distro := Distro{ID: values["ID"], Family: values["ID"], Libc: Glibc, Version: values["VERSION_ID"]}
+	for _, candidate := range append([]string{values["ID"]}, strings.Fields(values["ID_LIKE"])...) {
+		if family, known := distroFamilies[candidate]; known {
+			distro.Family = family
+			break
+		}
+	}
+	if distro.Family == "alpine" {
+		distro.Libc = Musl
+	}
+	return distro
+}
+
+// hostDistro is the distribution goldfish runs on, read once per process
+var hostDistro = sync.OnceValue(func() Distro {
+	if runtime.GOOS != "linux" {
+		return Distro{}
+	}
+	var distro Distro
+	for _, path := range osReleasePaths {
+		if data, err := os.ReadFile(path); err == nil {
+			distro = ParseOSRelease(data)
+			break
+		}
+	}
+	// Some distributions (e.g. Void) offer both libcs, so trust the loader
+	if matches, _ := filepath.Glob(muslLoaderPattern); len(matches) > 0 {
+		distro.Libc = Musl
+	} else if distro.Libc == "" {
+		distro.Libc = Glibc
+	}
+	return distro
+})
+
+// Distro returns the Linux distribution goldfish runs on
+// It is empty on other operating systems.
+func (d *Detector) Distro() Distro {
+	return hostDistro()
+}
+
+// DistroFor returns the distribution commands for platform p run on
+// Only the platform goldfish runs on has a known distribution; rendering for
+// another platform (e.g. Linux from macOS) gets an empty Distro.
+func (d *Detector) DistroFor(p SupportedPlatform) Distro {
+	if !d.IsCurrent(p) {
+		return Distro{}
+	}
+	return d.Distro()
+}
+
+// TemplateKeys returns the platforms: keys a command's template is looked up
+// by on platform p, most specific first
+// On Alpine that is linux-alpine, linux-musl and then linux, so a command can
+// give BusyBox a template of its own while other distributions share "linux".
+// Group keys (unix) follow, and catch-all keys (default) come last.
+// Each key may also be narrowed to a CPU architecture (linux/arm64,
+// linux-alpine/arm64, unix/arm64); within each of those three tiers the
+// narrowed keys come first, since commands that download binaries or pass
+// architecture flags cannot work with the wrong one.
+func (d *Detector) TemplateKeys(p SupportedPlatform) []string {
+	distro := d.DistroFor(p)
+	var platformKeys []string
+	seen := make(map[string]bool)
+	for _, variant := range []string{distro.ID, distro.Family} {
+		if variant != "" && !seen[variant] {
+			seen[variant] = true
+			platformKeys = append(platformKeys, p.String()+"-"+variant)
+		}
+	}
+	// glibc is what plain "linux" templates are written for
+	if distro.Libc == Musl && !seen[Musl] {
+		platformKeys = append(platformKeys, p.String()+"-"+Musl)
+	}
+	platformKeys = append(platformKeys, p.String())
+
+	arch := d.ArchFor(p)
+	var keys []string
+	for _, tier := range [][]string{platformKeys, GroupKeys(p), catchAllKeys} {
+		if arch != "" {
+			for _, key := range tier {
+				keys = append(keys, key+"/"+arch)
+			}
+		}
+		keys = append(keys, tier...)
+	}
+	return keys
+}
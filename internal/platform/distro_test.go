@@ -0,0 +1,84 @@
+package platform
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+// TestParseOSRelease tests reading the distribution family from os-release content
+func TestParseOSRelease(t *testing.T) {
+	testCases := []struct {
+		name     string
+		content  string
+		expected Distro
+	}{
+		{
+			name:     "debian",
+			content:  "PRETTY_NAME=\"Debian GNU/Linux 12 (bookworm)\"\nID=debian\nVERSION_ID=\"12\"\n",
+			expected: Distro{ID: "debian", Family: "debian", Libc: Glibc, Version: "12"},
+		},
+		{
+			name:     "derivative through ID_LIKE",
+			content:  "NAME=\"Linux Mint\"\nID=linuxmint\nID_LIKE=\"ubuntu debian\"\n",
+			expected: Distro{ID: "linuxmint", Family: "debian", Libc: Glibc},
+		},
+		{
+			name:     "rhel family",
+			content:  "ID=\"rocky\"\nID_LIKE=\"rhel centos fedora\"\n",
+			expected: Distro{ID: "rocky", Family: "rhel", Libc: Glibc},
+		},
+		{
+			name:     "alpine uses musl",
+			content:  "# comment\nID=alpine\nVERSION_ID=3.19.1\n",
+			expected: Distro{ID: "alpine", Family: "alpine", Libc: Musl, Version: "3.19.1"},
+		},
+		{
+			name:     "unknown family",
+			content:  "ID=nixos\n",
+			expected: Distro{ID: "nixos", Family: "nixos", Libc: Glibc},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := ParseOSRelease([]byte(tc.content)); got != tc.expected {
+				t.Errorf("Expected %+v, got %+v", tc.expected, got)
+			}
+		})
+	}
+}
+
+// TestDetector_TemplateKeys tests the order templates are looked up in
+func TestDetector_TemplateKeys(t *testing.T) {
+	detector := NewDetector()
+
+	// Platforms goldfish is not running on have no distribution
+	other := Windows
+	if runtime.GOOS == "windows" {
+		other = Linux
+	}
+	expected := []string{other.String(), "default", "*", "any"}
+	if other == Linux {
+		expected = []string{"linux", "unix", "posix", "default", "*", "any"}
+	}
+	if keys := detector.TemplateKeys(other); !reflect.DeepEqual(keys, expected) {
+		t.Errorf("Expected %v, got %v", expected, keys)
+	}
+
+	if runtime.GOOS != "linux" {
+		return
+	}
+	keys := detector.TemplateKeys(Linux)
+	distro := detector.Distro()
+	arch := detector.Arch()
+	// Each tier starts with its architecture keys: platform, group, catch-all
+	tail := []string{"linux", "unix/" + arch, "posix/" + arch, "unix", "posix",
+		"default/" + arch, "*/" + arch, "any/" + arch, "default", "*", "any"}
+	if len(keys) < len(tail) || !reflect.DeepEqual(keys[len(keys)-len(tail):], tail) {
+		t.Errorf("Expected keys to end with %v, got %v", tail, keys)
+	}
+	if distro.ID != "" && keys[0] != "linux-"+distro.ID+"/"+arch {
+		t.Errorf("Expected linux-%s keys first, got %v", distro.ID, keys)
+	}
+}
@@ -0,0 +1,93 @@
+package platform
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// The OS alone does not say which userland a system has: macOS users often
+// install GNU coreutils with Homebrew (as gsed, gdate, ...), and Alpine uses
+// BusyBox. Tools are probed by running "<tool> --version", which GNU tools
+// answer with their name, BusyBox answers as itself and BSD tools reject.
+
+// Tool flavors
+const (
+	FlavorGNU     = "gnu"
+	FlavorBSD     = "bsd"
+	FlavorBusyBox = "busybox"
+)
+
+// Flavors lists every tool flavor, used as platforms: key suffixes (darwin-gnu)
+var Flavors = []string{FlavorGNU, FlavorBSD, FlavorBusyBox}
+
+// probeTimeout bounds how long a tool may take to print its version
+const probeTimeout = 2 * time.Second
+
+// Tool describes an installed command-line tool
+type Tool struct {
+	// Name is the tool asked about, e.g. "sed"
+	Name string
+	// Binary is what to run: Name, or the GNU build installed beside it (gsed)
+	Binary string
+	// Flavor is FlavorGNU, FlavorBusyBox or FlavorBSD (any other
+	// implementation), or empty when the tool is not installed
+	Flavor string
+}
+
+// toolProbes caches probe results for the life of the process
+var toolProbes sync.Map
+
+// Tool probes which flavor of a tool is installed
+// GNU builds are preferred: when the tool itself is not GNU but g<name> is,
+// that is returned. Results are cached, so each tool is only run once.
+func (d *Detector) Tool(name string) Tool {
+	if cached, found := toolProbes.Load(name); found {
+		return cached.(Tool)
+	}
+	tool := probeTool(name)
+	toolProbes.Store(name, tool)
+	return tool
+}
+
+// probeTool determines a tool's flavor without the cache
+func probeTool(name string) Tool {
+	tool := Tool{Name: name, Binary: name, Flavor: toolFlavor(name)}
+	if tool.Flavor != FlavorGNU && runtime.GOOS != "windows" {
+		// Homebrew's coreutils, gnu-sed, grep and gnu-tar use a g prefix
+		if toolFlavor("g"+name) == FlavorGNU {
+			tool.Binary, tool.Flavor = "g"+name, FlavorGNU
+		}
+	}
+	return tool
+}
+
+// toolFlavor runs a binary with --version and classifies its answer
+func toolFlavor(binary string) string {
+	path, err := exec.LookPath(binary)
+	if err != nil {
+		return ""
+	}
+	// BusyBox applets are links to one binary, and some (like sed) even
+	// claim to be "not GNU sed version 4.0"
+	if resolved, err := filepath.EvalSymlinks(path); err == nil && filepath.Base(resolved) == "busybox" {
+		return FlavorBusyBox
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+	// BSD tools reject --version, so the exit status is irrelevant
+	output, _ := exec.CommandContext(ctx, path, "--version").CombinedOutput()
+	switch {
+	case bytes.Contains(output, []byte("BusyBox")), bytes.Contains(output, []byte("not GNU")):
+		return FlavorBusyBox
+	case bytes.Contains(output, []byte("GNU")), bytes.Contains(output, []byte("Free Software Foundation")):
+		return FlavorGNU
+	default:
+		return FlavorBSD
+	}
+}
@@ -0,0 +1,50 @@
+package platform
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// writeFakeTool creates an executable script on dir that prints output for --version
+func writeFakeTool(t *testing.T, dir, name, output string) {
+	t.Helper()
+	script := "#!/bin/sh\necho '" + output + "'\n"
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+}
+
+// TestProbeTool tests classifying tools by their --version output
+func TestProbeTool(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses shell scripts as fake tools")
+	}
+	dir := t.TempDir()
+	t.Setenv("PATH", dir)
+	writeFakeTool(t, dir, "gnutool", "gnutool (GNU coreutils) 9.4")
+	writeFakeTool(t, dir, "bsdtool", "bsdtool: illegal option -- -")
+	writeFakeTool(t, dir, "boxtool", "This is not GNU sed version 4.0")
+	writeFakeTool(t, dir, "brewtool", "usage: brewtool [-n] script")
+	writeFakeTool(t, dir, "gbrewtool", "gbrewtool (GNU sed) 4.9")
+
+	testCases := []struct {
+		name     string
+		expected Tool
+	}{
+		{"gnutool", Tool{Name: "gnutool", Binary: "gnutool", Flavor: FlavorGNU}},
+		{"bsdtool", Tool{Name: "bsdtool", Binary: "bsdtool", Flavor: FlavorBSD}},
+		{"boxtool", Tool{Name: "boxtool", Binary: "boxtool", Flavor: FlavorBusyBox}},
+		// The GNU build installed with a g prefix is preferred
+		{"brewtool", Tool{Name: "brewtool", Binary: "gbrewtool", Flavor: FlavorGNU}},
+		{"missingtool", Tool{Name: "missingtool", Binary: "missingtool"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := probeTool(tc.name); got != tc.expected {
+				t.Errorf("Expected %+v, got %+v", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package platform
+
+import "strings"
+
+// Most commands run the same command line on every Unix-like system, so a
+// template can be given once for a group of platforms instead of copied
+// under each of them. Keys for a single platform still take precedence over
+// group keys, and group keys over catch-all keys, so a group template can be
+// overridden where it does not work.
+
+// Group keys
+const (
+	// GroupUnix matches Linux, macOS and the BSDs
+	GroupUnix = "unix"
+	// GroupPOSIX is another name for GroupUnix
+	GroupPOSIX = "posix"
+	// CatchAll matches every platform
+	CatchAll = "default"
+)
+
+// unixPlatforms are the platforms GroupUnix matches
+// goldfish only detects Linux, macOS and Windows, but on other systems it
+// looks templates up by the raw OS name (see GoldfishApp.currentPlatform), so
+// listing the BSDs here lets unix templates work there too.
+var unixPlatforms = []string{"linux", "darwin", "freebsd", "openbsd", "netbsd", "dragonfly"}
+
+// keyAliases maps alternative spellings of group keys to their canonical key
+var keyAliases = map[string]string{
+	GroupPOSIX: GroupUnix,
+	"*":        CatchAll,
+	"any":      CatchAll,
+}
+
+// GroupKeys returns the group keys that match platform p, e.g. unix and posix
+// Every spelling of a key is returned; a configuration may use only one of
+// them (see CanonicalKey).
+func GroupKeys(p SupportedPlatform) []string {
+	for _, name := range unixPlatforms {
+		if p.String() == name {
+			return []string{GroupUnix, GroupPOSIX}
+		}
+	}
+	return nil
+}
+
+// catchAllKeys are the spellings of CatchAll, which matches every platform
+var catchAllKeys = []string{CatchAll, "*", "any"}
+
+// CanonicalKey returns the usual spelling of a platforms: key
+// "posix" becomes "unix" and "*" or "any" becomes "default"; an architecture
+// suffix is kept ("posix/arm64" becomes "unix/arm64"). Other keys are
+// returned unchanged.
+func CanonicalKey(key string) string {
+	base, arch, hasArch := strings.Cut(key, "/")
+	if canonical, found := keyAliases[base]; found {
+		base = canonical
+	}
+	if hasArch {
+		return base + "/" + arch
+	}
+	return base
+}
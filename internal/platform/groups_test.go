@@ -0,0 +1,42 @@
+package platform
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestGroupKeys tests which platforms group keys match
+func TestGroupKeys(t *testing.T) {
+	testCases := []struct {
+		platform SupportedPlatform
+		expected []string
+	}{
+		{Linux, []string{"unix", "posix"}},
+		{Darwin, []string{"unix", "posix"}},
+		{SupportedPlatform("freebsd"), []string{"unix", "posix"}},
+		{Windows, nil},
+	}
+	for _, tc := range testCases {
+		if got := GroupKeys(tc.platform); !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("GroupKeys(%s) = %v; expected %v", tc.platform, got, tc.expected)
+		}
+	}
+}
+
+// TestCanonicalKey tests normalizing alternative spellings of keys
+func TestCanonicalKey(t *testing.T) {
+	testCases := map[string]string{
+		"posix":       "unix",
+		"posix/arm64": "unix/arm64",
+		"*":           "default",
+		"any/amd64":   "default/amd64",
+		"unix":        "unix",
+		"linux":       "linux",
+		"darwin-gnu":  "darwin-gnu",
+	}
+	for key, expected := range testCases {
+		if got := CanonicalKey(key); got != expected {
+			t.Errorf("CanonicalKey(%q) = %q; expected %q", key, got, expected)
+		}
+	}
+}
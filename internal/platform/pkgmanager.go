@@ -0,0 +1,92 @@
+package platform
+
+import (
+	"os/exec"
+	"runtime"
+	"sync"
+)
+
+// Installing software is the same task everywhere but a different tool on
+// every machine: apt on Debian, dnf on Fedora, brew on macOS, winget on
+// Windows. Commands give a template per package manager under pm-<name>
+// keys (pm-apt, pm-brew, ...), and the one installed is used.
+
+// PackageManagerKeyPrefix starts platforms: keys for a package manager
+const PackageManagerKeyPrefix = "pm-"
+
+// PackageManager describes a package manager
+type PackageManager struct {
+	// Name identifies it in pm- keys and templates, e.g. "apt"
+	Name string
+	// Binary is the program to run, e.g. "apt-get"
+	Binary string
+}
+
+// packageManagers lists the package managers looked for on each OS, in order
+// of preference when several are installed
+// Homebrew also runs on Linux, but a system's own manager comes first there.
+var packageManagers = map[string][]PackageManager{
+	"linux": {
+		{Name: "apt", Binary: "apt-get"},
+		{Name: "dnf", Binary: "dnf"},
+		{Name: "pacman", Binary: "pacman"},
+		{Name: "zypper", Binary: "zypper"},
+		{Name: "apk", Binary: "apk"},
+		{Name: "brew", Binary: "brew"},
+	},
+	"darwin": {
+		{Name: "brew", Binary: "brew"},
+	},
+	"windows": {
+		{Name: "winget", Binary: "winget"},
+		{Name: "choco", Binary: "choco"},
+		{Name: "scoop", Binary: "scoop"},
+	},
+}
+
+// PackageManagerNames lists every package manager goldfish knows, e.g. for
+// validating pm- keys
+func PackageManagerNames() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, goos := range []string{"linux", "darwin", "windows"} {
+		for _, manager := range packageManagers[goos] {
+			if !seen[manager.Name] {
+				seen[manager.Name] = true
+				names = append(names, manager.Name)
+			}
+		}
+	}
+	return names
+}
+
+// hostPackageManager is the package manager of the system goldfish runs on,
+// looked up once per process
+var hostPackageManager = sync.OnceValue(func() PackageManager {
+	return findPackageManager(runtime.GOOS)
+})
+
+// findPackageManager returns the first of an OS's package managers on PATH
+func findPackageManager(goos string) PackageManager {
+	for _, manager := range packageManagers[goos] {
+		if _, err := exec.LookPath(manager.Binary); err == nil {
+			return manager
+		}
+	}
+	return PackageManager{}
+}
+
+// PackageManager returns the package manager installed on the system goldfish
+// runs on, or an empty PackageManager if none is known
+func (d *Detector) PackageManager() PackageManager {
+	return hostPackageManager()
+}
+
+// PackageManagerFor returns the package manager commands for platform p use
+// Like DistroFor, it is only known for the platform goldfish runs on.
+func (d *Detector) PackageManagerFor(p SupportedPlatform) PackageManager {
+	if !d.IsCurrent(p) {
+		return PackageManager{}
+	}
+	return d.PackageManager()
+}
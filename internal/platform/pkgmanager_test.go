@@ -0,0 +1,62 @@
+package platform
+
+import (
+	"runtime"
+	"testing"
+)
+
+// TestFindPackageManager tests choosing between installed package managers
+func TestFindPackageManager(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses shell scripts as fake tools")
+	}
+	dir := t.TempDir()
+	t.Setenv("PATH", dir)
+
+	if got := findPackageManager("linux"); got != (PackageManager{}) {
+		t.Errorf("Expected no package manager on an empty PATH, got %+v", got)
+	}
+
+	// The system's own manager is preferred over Homebrew on Linux
+	writeFakeTool(t, dir, "brew", "Homebrew 4.2.0")
+	writeFakeTool(t, dir, "dnf", "4.18.0")
+	if got := findPackageManager("linux"); got != (PackageManager{Name: "dnf", Binary: "dnf"}) {
+		t.Errorf("Expected dnf, got %+v", got)
+	}
+	if got := findPackageManager("darwin"); got.Name != "brew" {
+		t.Errorf("Expected brew on darwin, got %+v", got)
+	}
+
+	// apt is run as apt-get, whose interface is stable for scripts
+	writeFakeTool(t, dir, "apt-get", "apt 2.7.14")
+	if got := findPackageManager("linux"); got != (PackageManager{Name: "apt", Binary: "apt-get"}) {
+		t.Errorf("Expected apt, got %+v", got)
+	}
+}
+
+// TestPackageManagerNames tests that every package manager is listed once
+func TestPackageManagerNames(t *testing.T) {
+	names := PackageManagerNames()
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if seen[name] {
+			t.Errorf("Expected %s to be listed once", name)
+		}
+		seen[name] = true
+	}
+	for _, expected := range []string{"apt", "dnf", "pacman", "brew", "choco", "winget", "scoop"} {
+		if !seen[expected] {
+			t.Errorf("Expected %s in %v", expected, names)
+		}
+	}
+}
+
+// TestDetector_PackageManagerFor tests that only the running platform has a package manager
+func TestDetector_PackageManagerFor(t *testing.T) {
+	detector := NewDetector()
+	for _, p := range detector.GetSupportedPlatforms() {
+		if !detector.IsCurrent(p) && detector.PackageManagerFor(p) != (PackageManager{}) {
+			t.Errorf("Expected no package manager for %s", p)
+		}
+	}
+}
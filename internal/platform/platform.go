@@ -48,6 +48,29 @@ func (d *Detector) Current() (SupportedPlatform, error) {
 	}
 }
 
+// Architectures lists the CPU architectures goldfish runs on, by their GOARCH names
+var Architectures = []string{"amd64", "arm64", "386", "arm", "riscv64", "ppc64le", "s390x", "loong64"}
+
+// Arch returns the CPU architecture goldfish runs on, e.g. "amd64" or "arm64"
+func (d *Detector) Arch() string {
+	return runtime.GOARCH
+}
+
+// ArchFor returns the CPU architecture commands for platform p run on
+// Like DistroFor, it is only known for the platform goldfish runs on.
+func (d *Detector) ArchFor(p SupportedPlatform) string {
+	if !d.IsCurrent(p) {
+		return ""
+	}
+	return d.Arch()
+}
+
+// IsCurrent reports whether p is the platform goldfish is running on
+func (d *Detector) IsCurrent(p SupportedPlatform) bool {
+	current, err := d.Current()
+	return err == nil && current == p
+}
+
 // IsSupported checks if the given platform string is supported
 func (d *Detector) IsSupported(platform string) bool {
 	switch SupportedPlatform(platform) {
@@ -0,0 +1,124 @@
+package platform
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Flag availability often depends on the OS release rather than the OS:
+// BSD sed on macOS 13 and later, for example, accepts flags older releases do not.
+// The version is the one users know the system by:
+//   - macOS: the product version, e.g. "14.2.1"
+//   - Windows: major.minor.build, e.g. "10.0.22631" (Windows 11 is build 22000 and up)
+//   - Linux: the distribution's VERSION_ID, e.g. "22.04" or "3.19.1", since the
+//     distribution decides which tool versions are installed
+
+// macOSVersionFile records the macOS version without running sw_vers
+const macOSVersionFile = "/System/Library/CoreServices/SystemVersion.plist"
+
+// macOSVersionPattern extracts ProductVersion from SystemVersion.plist
+var macOSVersionPattern = regexp.MustCompile(`<key>ProductVersion</key>\s*<string>([0-9.]+)</string>`)
+
+// windowsVersionPattern extracts the version from the output of "ver"
+var windowsVersionPattern = regexp.MustCompile(`(\d+\.\d+\.\d+)`)
+
+// hostVersion is the version of the OS goldfish runs on, read once per process
+var hostVersion = sync.OnceValue(func() string {
+	switch runtime.GOOS {
+	case "linux":
+		return hostDistro().Version
+	case "darwin":
+		if data, err := os.ReadFile(macOSVersionFile); err == nil {
+			if match := macOSVersionPattern.FindSubmatch(data); match != nil {
+				return string(match[1])
+			}
+		}
+		return commandVersion(nil, "sw_vers", "-productVersion")
+	case "windows":
+		// "Microsoft Windows [Version 10.0.22631.2861]"
+		return commandVersion(windowsVersionPattern, "cmd", "/c", "ver")
+	default:
+		return ""
+	}
+})
+
+// commandVersion runs a command that prints the OS version
+// pattern, when set, extracts the version from the output
+func commandVersion(pattern *regexp.Regexp, name string, args ...string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, name, args...).Output()
+	if err != nil {
+		return ""
+	}
+	if pattern != nil {
+		return pattern.FindString(string(output))
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// Version returns the version of the OS goldfish runs on, or "" if unknown
+func (d *Detector) Version() string {
+	return hostVersion()
+}
+
+// VersionFor returns the OS version commands for platform p run on
+// Like DistroFor, it is only known for the platform goldfish runs on.
+func (d *Detector) VersionFor(p SupportedPlatform) string {
+	if !d.IsCurrent(p) {
+		return ""
+	}
+	return d.Version()
+}
+
+// ParseVersion splits a dotted version such as "13.4.1" into its numbers
+// It reports false for anything else, such as "rolling" or "13.x".
+func ParseVersion(version string) ([]int, bool) {
+	if version == "" {
+		return nil, false
+	}
+	parts := strings.Split(version, ".")
+	numbers := make([]int, len(parts))
+	for i, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 {
+			return nil, false
+		}
+		numbers[i] = number
+	}
+	return numbers, true
+}
+
+// CompareVersion compares a version to a constraint, returning -1, 0 or 1
+// Only as many parts as the constraint has are compared, so "13.4.1" equals
+// the constraint "13": max_version "13" includes every 13.x release.
+// ok is false when either is not a dotted version.
+func CompareVersion(version, constraint string) (result int, ok bool) {
+	have, ok := ParseVersion(version)
+	if !ok {
+		return 0, false
+	}
+	want, ok := ParseVersion(constraint)
+	if !ok {
+		return 0, false
+	}
+	for i, wanted := range want {
+		part := 0
+		if i < len(have) {
+			part = have[i]
+		}
+		switch {
+		case part < wanted:
+			return -1, true
+		case part > wanted:
+			return 1, true
+		}
+	}
+	return 0, true
+}
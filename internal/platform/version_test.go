@@ -0,0 +1,70 @@
+package platform
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestParseVersion tests splitting dotted versions into numbers
+func TestParseVersion(t *testing.T) {
+	testCases := []struct {
+		version  string
+		expected []int
+		ok       bool
+	}{
+		{"13", []int{13}, true},
+		{"10.0.22631", []int{10, 0, 22631}, true},
+		{"", nil, false},
+		{"rolling", nil, false},
+		{"13.x", nil, false},
+		{"1..2", nil, false},
+	}
+	for _, tc := range testCases {
+		got, ok := ParseVersion(tc.version)
+		if ok != tc.ok || !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("ParseVersion(%q) = %v, %v; expected %v, %v", tc.version, got, ok, tc.expected, tc.ok)
+		}
+	}
+}
+
+// TestCompareVersion tests comparing versions to constraints of any precision
+func TestCompareVersion(t *testing.T) {
+	testCases := []struct {
+		version    string
+		constraint string
+		expected   int
+		ok         bool
+	}{
+		{"14.2.1", "13", 1, true},
+		{"13.6", "13", 0, true},
+		{"12.7.4", "13", -1, true},
+		{"13", "13.1", -1, true},
+		{"10.0.22631", "10.0.22000", 1, true},
+		{"10.0.19045", "10.0.22000", -1, true},
+		{"", "13", 0, false},
+		{"13", "new", 0, false},
+	}
+	for _, tc := range testCases {
+		got, ok := CompareVersion(tc.version, tc.constraint)
+		if got != tc.expected || ok != tc.ok {
+			t.Errorf("CompareVersion(%q, %q) = %d, %v; expected %d, %v", tc.version, tc.constraint, got, ok, tc.expected, tc.ok)
+		}
+	}
+}
+
+// TestDetector_VersionFor tests that only the running platform has a version
+func TestDetector_VersionFor(t *testing.T) {
+	detector := NewDetector()
+	current, err := detector.Current()
+	if err != nil {
+		t.Skip("unsupported test platform")
+	}
+	if detector.VersionFor(current) != detector.Version() {
+		t.Error("Expected the running platform's version")
+	}
+	for _, other := range detector.GetSupportedPlatforms() {
+		if other != current && detector.VersionFor(other) != "" {
+			t.Errorf("Expected no version for %s", other)
+		}
+	}
+}
@@ -0,0 +1,844 @@
+// The goldfish gRPC API, served by "goldfish serve --grpc".
+// It lets IDE plugins and agents list the configured commands, preview the
+// command line a command would run, and run it while streaming its output.
+//
+// Regenerate the Go code after editing with:
+//   protoc --go_out=. --go_opt=paths=source_relative \
+//     --go-grpc_out=. --go-grpc_opt=paths=source_relative goldfish.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: goldfish.proto
+
+package goldfishpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type OutputChunk_Stream int32
+
+const (
+	OutputChunk_STDOUT OutputChunk_Stream = 0
+	OutputChunk_STDERR OutputChunk_Stream = 1
+)
+
+// Enum value maps for OutputChunk_Stream.
+var (
+	OutputChunk_Stream_name = map[int32]string{
+		0: "STDOUT",
+		1: "STDERR",
+	}
+	OutputChunk_Stream_value = map[string]int32{
+		"STDOUT": 0,
+		"STDERR": 1,
+	}
+)
+
+func (x OutputChunk_Stream) Enum() *OutputChunk_Stream {
+	p := new(OutputChunk_Stream)
+	*p = x
+	return p
+}
+
+func (x OutputChunk_Stream) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (OutputChunk_Stream) Descriptor() protoreflect.EnumDescriptor {
+	return file_goldfish_proto_enumTypes[0].Descriptor()
+}
+
+func (OutputChunk_Stream) Type() protoreflect.EnumType {
+	return &file_goldfish_proto_enumTypes[0]
+}
+
+func (x OutputChunk_Stream) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use OutputChunk_Stream.Descriptor instead.
+func (OutputChunk_Stream) EnumDescriptor() ([]byte, []int) {
+	return file_goldfish_proto_rawDescGZIP(), []int{9, 0}
+}
+
+// Parameter describes one parameter a command accepts.
+type Parameter struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// type is one of string, bool, int or float.
+	Type        string `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Required    bool   `protobuf:"varint,3,opt,name=required,proto3" json:"required,omitempty"`
+	Description string `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
+	// secret parameters hold values that are masked in logs and previews.
+	Secret        bool `protobuf:"varint,5,opt,name=secret,proto3" json:"secret,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Parameter) Reset() {
+	*x = Parameter{}
+	mi := &file_goldfish_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Parameter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Parameter) ProtoMessage() {}
+
+func (x *Parameter) ProtoReflect() protoreflect.Message {
+	mi := &file_goldfish_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Parameter.ProtoReflect.Descriptor instead.
+func (*Parameter) Descriptor() ([]byte, []int) {
+	return file_goldfish_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Parameter) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Parameter) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Parameter) GetRequired() bool {
+	if x != nil {
+		return x.Required
+	}
+	return false
+}
+
+func (x *Parameter) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Parameter) GetSecret() bool {
+	if x != nil {
+		return x.Secret
+	}
+	return false
+}
+
+// Command describes a configured goldfish command.
+type Command struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Name        string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Alias       string                 `protobuf:"bytes,2,opt,name=alias,proto3" json:"alias,omitempty"`
+	Description string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	Parameters  []*Parameter           `protobuf:"bytes,4,rep,name=parameters,proto3" json:"parameters,omitempty"`
+	// platforms lists every platform the command has a template for.
+	Platforms     []string `protobuf:"bytes,5,rep,name=platforms,proto3" json:"platforms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Command) Reset() {
+	*x = Command{}
+	mi := &file_goldfish_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Command) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Command) ProtoMessage() {}
+
+func (x *Command) ProtoReflect() protoreflect.Message {
+	mi := &file_goldfish_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Command.ProtoReflect.Descriptor instead.
+func (*Command) Descriptor() ([]byte, []int) {
+	return file_goldfish_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Command) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Command) GetAlias() string {
+	if x != nil {
+		return x.Alias
+	}
+	return ""
+}
+
+func (x *Command) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Command) GetParameters() []*Parameter {
+	if x != nil {
+		return x.Parameters
+	}
+	return nil
+}
+
+func (x *Command) GetPlatforms() []string {
+	if x != nil {
+		return x.Platforms
+	}
+	return nil
+}
+
+type ListCommandsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCommandsRequest) Reset() {
+	*x = ListCommandsRequest{}
+	mi := &file_goldfish_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCommandsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCommandsRequest) ProtoMessage() {}
+
+func (x *ListCommandsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_goldfish_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCommandsRequest.ProtoReflect.Descriptor instead.
+func (*ListCommandsRequest) Descriptor() ([]byte, []int) {
+	return file_goldfish_proto_rawDescGZIP(), []int{2}
+}
+
+type ListCommandsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Commands      []*Command             `protobuf:"bytes,1,rep,name=commands,proto3" json:"commands,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCommandsResponse) Reset() {
+	*x = ListCommandsResponse{}
+	mi := &file_goldfish_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCommandsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCommandsResponse) ProtoMessage() {}
+
+func (x *ListCommandsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_goldfish_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCommandsResponse.ProtoReflect.Descriptor instead.
+func (*ListCommandsResponse) Descriptor() ([]byte, []int) {
+	return file_goldfish_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListCommandsResponse) GetCommands() []*Command {
+	if x != nil {
+		return x.Commands
+	}
+	return nil
+}
+
+// Invocation identifies a command and the values to run it with, exactly as
+// they would be given on the command line.
+type Invocation struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// command is the command name or alias.
+	Command string `protobuf:"bytes,1,opt,name=command,proto3" json:"command,omitempty"`
+	// args are positional arguments.
+	Args []string `protobuf:"bytes,2,rep,name=args,proto3" json:"args,omitempty"`
+	// params sets parameters by name; values are converted to the parameter type.
+	Params        map[string]string `protobuf:"bytes,3,rep,name=params,proto3" json:"params,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Invocation) Reset() {
+	*x = Invocation{}
+	mi := &file_goldfish_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Invocation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Invocation) ProtoMessage() {}
+
+func (x *Invocation) ProtoReflect() protoreflect.Message {
+	mi := &file_goldfish_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Invocation.ProtoReflect.Descriptor instead.
+func (*Invocation) Descriptor() ([]byte, []int) {
+	return file_goldfish_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Invocation) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *Invocation) GetArgs() []string {
+	if x != nil {
+		return x.Args
+	}
+	return nil
+}
+
+func (x *Invocation) GetParams() map[string]string {
+	if x != nil {
+		return x.Params
+	}
+	return nil
+}
+
+type RenderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Invocation    *Invocation            `protobuf:"bytes,1,opt,name=invocation,proto3" json:"invocation,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RenderRequest) Reset() {
+	*x = RenderRequest{}
+	mi := &file_goldfish_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RenderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenderRequest) ProtoMessage() {}
+
+func (x *RenderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_goldfish_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenderRequest.ProtoReflect.Descriptor instead.
+func (*RenderRequest) Descriptor() ([]byte, []int) {
+	return file_goldfish_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *RenderRequest) GetInvocation() *Invocation {
+	if x != nil {
+		return x.Invocation
+	}
+	return nil
+}
+
+type RenderResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// command_line is the rendered command, with secret values masked.
+	CommandLine   string `protobuf:"bytes,1,opt,name=command_line,json=commandLine,proto3" json:"command_line,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RenderResponse) Reset() {
+	*x = RenderResponse{}
+	mi := &file_goldfish_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RenderResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenderResponse) ProtoMessage() {}
+
+func (x *RenderResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_goldfish_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenderResponse.ProtoReflect.Descriptor instead.
+func (*RenderResponse) Descriptor() ([]byte, []int) {
+	return file_goldfish_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *RenderResponse) GetCommandLine() string {
+	if x != nil {
+		return x.CommandLine
+	}
+	return ""
+}
+
+type ExecuteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Invocation    *Invocation            `protobuf:"bytes,1,opt,name=invocation,proto3" json:"invocation,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExecuteRequest) Reset() {
+	*x = ExecuteRequest{}
+	mi := &file_goldfish_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExecuteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecuteRequest) ProtoMessage() {}
+
+func (x *ExecuteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_goldfish_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecuteRequest.ProtoReflect.Descriptor instead.
+func (*ExecuteRequest) Descriptor() ([]byte, []int) {
+	return file_goldfish_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ExecuteRequest) GetInvocation() *Invocation {
+	if x != nil {
+		return x.Invocation
+	}
+	return nil
+}
+
+// ExecuteResponse is one event in an Execute stream: output chunks as they
+// are produced, then a single exit status.
+type ExecuteResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*ExecuteResponse_Output
+	//	*ExecuteResponse_Exit
+	Event         isExecuteResponse_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExecuteResponse) Reset() {
+	*x = ExecuteResponse{}
+	mi := &file_goldfish_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExecuteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecuteResponse) ProtoMessage() {}
+
+func (x *ExecuteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_goldfish_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecuteResponse.ProtoReflect.Descriptor instead.
+func (*ExecuteResponse) Descriptor() ([]byte, []int) {
+	return file_goldfish_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ExecuteResponse) GetEvent() isExecuteResponse_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *ExecuteResponse) GetOutput() *OutputChunk {
+	if x != nil {
+		if x, ok := x.Event.(*ExecuteResponse_Output); ok {
+			return x.Output
+		}
+	}
+	return nil
+}
+
+func (x *ExecuteResponse) GetExit() *ExitStatus {
+	if x != nil {
+		if x, ok := x.Event.(*ExecuteResponse_Exit); ok {
+			return x.Exit
+		}
+	}
+	return nil
+}
+
+type isExecuteResponse_Event interface {
+	isExecuteResponse_Event()
+}
+
+type ExecuteResponse_Output struct {
+	Output *OutputChunk `protobuf:"bytes,1,opt,name=output,proto3,oneof"`
+}
+
+type ExecuteResponse_Exit struct {
+	Exit *ExitStatus `protobuf:"bytes,2,opt,name=exit,proto3,oneof"`
+}
+
+func (*ExecuteResponse_Output) isExecuteResponse_Event() {}
+
+func (*ExecuteResponse_Exit) isExecuteResponse_Event() {}
+
+// OutputChunk is a piece of the command's output.
+type OutputChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Stream        OutputChunk_Stream     `protobuf:"varint,1,opt,name=stream,proto3,enum=goldfish.v1.OutputChunk_Stream" json:"stream,omitempty"`
+	Data          []byte                 `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OutputChunk) Reset() {
+	*x = OutputChunk{}
+	mi := &file_goldfish_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OutputChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OutputChunk) ProtoMessage() {}
+
+func (x *OutputChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_goldfish_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OutputChunk.ProtoReflect.Descriptor instead.
+func (*OutputChunk) Descriptor() ([]byte, []int) {
+	return file_goldfish_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *OutputChunk) GetStream() OutputChunk_Stream {
+	if x != nil {
+		return x.Stream
+	}
+	return OutputChunk_STDOUT
+}
+
+func (x *OutputChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+// ExitStatus ends an Execute stream.
+type ExitStatus struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	ExitCode int32                  `protobuf:"varint,1,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`
+	// error is set when the command could not run to completion (e.g. it timed
+	// out or a postcondition failed).
+	Error         string `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExitStatus) Reset() {
+	*x = ExitStatus{}
+	mi := &file_goldfish_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExitStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExitStatus) ProtoMessage() {}
+
+func (x *ExitStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_goldfish_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExitStatus.ProtoReflect.Descriptor instead.
+func (*ExitStatus) Descriptor() ([]byte, []int) {
+	return file_goldfish_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ExitStatus) GetExitCode() int32 {
+	if x != nil {
+		return x.ExitCode
+	}
+	return 0
+}
+
+func (x *ExitStatus) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+var File_goldfish_proto protoreflect.FileDescriptor
+
+const file_goldfish_proto_rawDesc = "" +
+	"\n" +
+	"\x0egoldfish.proto\x12\vgoldfish.v1\"\x89\x01\n" +
+	"\tParameter\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x1a\n" +
+	"\brequired\x18\x03 \x01(\bR\brequired\x12 \n" +
+	"\vdescription\x18\x04 \x01(\tR\vdescription\x12\x16\n" +
+	"\x06secret\x18\x05 \x01(\bR\x06secret\"\xab\x01\n" +
+	"\aCommand\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05alias\x18\x02 \x01(\tR\x05alias\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x126\n" +
+	"\n" +
+	"parameters\x18\x04 \x03(\v2\x16.goldfish.v1.ParameterR\n" +
+	"parameters\x12\x1c\n" +
+	"\tplatforms\x18\x05 \x03(\tR\tplatforms\"\x15\n" +
+	"\x13ListCommandsRequest\"H\n" +
+	"\x14ListCommandsResponse\x120\n" +
+	"\bcommands\x18\x01 \x03(\v2\x14.goldfish.v1.CommandR\bcommands\"\xb2\x01\n" +
+	"\n" +
+	"Invocation\x12\x18\n" +
+	"\acommand\x18\x01 \x01(\tR\acommand\x12\x12\n" +
+	"\x04args\x18\x02 \x03(\tR\x04args\x12;\n" +
+	"\x06params\x18\x03 \x03(\v2#.goldfish.v1.Invocation.ParamsEntryR\x06params\x1a9\n" +
+	"\vParamsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"H\n" +
+	"\rRenderRequest\x127\n" +
+	"\n" +
+	"invocation\x18\x01 \x01(\v2\x17.goldfish.v1.InvocationR\n" +
+	"invocation\"3\n" +
+	"\x0eRenderResponse\x12!\n" +
+	"\fcommand_line\x18\x01 \x01(\tR\vcommandLine\"I\n" +
+	"\x0eExecuteRequest\x127\n" +
+	"\n" +
+	"invocation\x18\x01 \x01(\v2\x17.goldfish.v1.InvocationR\n" +
+	"invocation\"}\n" +
+	"\x0fExecuteResponse\x122\n" +
+	"\x06output\x18\x01 \x01(\v2\x18.goldfish.v1.OutputChunkH\x00R\x06output\x12-\n" +
+	"\x04exit\x18\x02 \x01(\v2\x17.goldfish.v1.ExitStatusH\x00R\x04exitB\a\n" +
+	"\x05event\"|\n" +
+	"\vOutputChunk\x127\n" +
+	"\x06stream\x18\x01 \x01(\x0e2\x1f.goldfish.v1.OutputChunk.StreamR\x06stream\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data\" \n" +
+	"\x06Stream\x12\n" +
+	"\n" +
+	"\x06STDOUT\x10\x00\x12\n" +
+	"\n" +
+	"\x06STDERR\x10\x01\"?\n" +
+	"\n" +
+	"ExitStatus\x12\x1b\n" +
+	"\texit_code\x18\x01 \x01(\x05R\bexitCode\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error2\xea\x01\n" +
+	"\bGoldfish\x12S\n" +
+	"\fListCommands\x12 .goldfish.v1.ListCommandsRequest\x1a!.goldfish.v1.ListCommandsResponse\x12A\n" +
+	"\x06Render\x12\x1a.goldfish.v1.RenderRequest\x1a\x1b.goldfish.v1.RenderResponse\x12F\n" +
+	"\aExecute\x12\x1b.goldfish.v1.ExecuteRequest\x1a\x1c.goldfish.v1.ExecuteResponse0\x01B9Z7github.com/danballance/goldfish/internal/rpc/goldfishpbb\x06proto3"
+
+var (
+	file_goldfish_proto_rawDescOnce sync.Once
+	file_goldfish_proto_rawDescData []byte
+)
+
+func file_goldfish_proto_rawDescGZIP() []byte {
+	file_goldfish_proto_rawDescOnce.Do(func() {
+		file_goldfish_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_goldfish_proto_rawDesc), len(file_goldfish_proto_rawDesc)))
+	})
+	return file_goldfish_proto_rawDescData
+}
+
+var file_goldfish_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_goldfish_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_goldfish_proto_goTypes = []any{
+	(OutputChunk_Stream)(0),      // 0: goldfish.v1.OutputChunk.Stream
+	(*Parameter)(nil),            // 1: goldfish.v1.Parameter
+	(*Command)(nil),              // 2: goldfish.v1.Command
+	(*ListCommandsRequest)(nil),  // 3: goldfish.v1.ListCommandsRequest
+	(*ListCommandsResponse)(nil), // 4: goldfish.v1.ListCommandsResponse
+	(*Invocation)(nil),           // 5: goldfish.v1.Invocation
+	(*RenderRequest)(nil),        // 6: goldfish.v1.RenderRequest
+	(*RenderResponse)(nil),       // 7: goldfish.v1.RenderResponse
+	(*ExecuteRequest)(nil),       // 8: goldfish.v1.ExecuteRequest
+	(*ExecuteResponse)(nil),      // 9: goldfish.v1.ExecuteResponse
+	(*OutputChunk)(nil),          // 10: goldfish.v1.OutputChunk
+	(*ExitStatus)(nil),           // 11: goldfish.v1.ExitStatus
+	nil,                          // 12: goldfish.v1.Invocation.ParamsEntry
+}
+var file_goldfish_proto_depIdxs = []int32{
+	1,  // 0: goldfish.v1.Command.parameters:type_name -> goldfish.v1.Parameter
+	2,  // 1: goldfish.v1.ListCommandsResponse.commands:type_name -> goldfish.v1.Command
+	12, // 2: goldfish.v1.Invocation.params:type_name -> goldfish.v1.Invocation.ParamsEntry
+	5,  // 3: goldfish.v1.RenderRequest.invocation:type_name -> goldfish.v1.Invocation
+	5,  // 4: goldfish.v1.ExecuteRequest.invocation:type_name -> goldfish.v1.Invocation
+	10, // 5: goldfish.v1.ExecuteResponse.output:type_name -> goldfish.v1.OutputChunk
+	11, // 6: goldfish.v1.ExecuteResponse.exit:type_name -> goldfish.v1.ExitStatus
+	0,  // 7: goldfish.v1.OutputChunk.stream:type_name -> goldfish.v1.OutputChunk.Stream
+	3,  // 8: goldfish.v1.Goldfish.ListCommands:input_type -> goldfish.v1.ListCommandsRequest
+	6,  // 9: goldfish.v1.Goldfish.Render:input_type -> goldfish.v1.RenderRequest
+	8,  // 10: goldfish.v1.Goldfish.Execute:input_type -> goldfish.v1.ExecuteRequest
+	4,  // 11: goldfish.v1.Goldfish.ListCommands:output_type -> goldfish.v1.ListCommandsResponse
+	7,  // 12: goldfish.v1.Goldfish.Render:output_type -> goldfish.v1.RenderResponse
+	9,  // 13: goldfish.v1.Goldfish.Execute:output_type -> goldfish.v1.ExecuteResponse
+	11, // [11:14] is the sub-list for method output_type
+	8,  // [8:11] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_goldfish_proto_init() }
+func file_goldfish_proto_init() {
+	if File_goldfish_proto != nil {
+		return
+	}
+	file_goldfish_proto_msgTypes[8].OneofWrappers = []any{
+		(*ExecuteResponse_Output)(nil),
+		(*ExecuteResponse_Exit)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_goldfish_proto_rawDesc), len(file_goldfish_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   12,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_goldfish_proto_goTypes,
+		DependencyIndexes: file_goldfish_proto_depIdxs,
+		EnumInfos:         file_goldfish_proto_enumTypes,
+		MessageInfos:      file_goldfish_proto_msgTypes,
+	}.Build()
+	File_goldfish_proto = out.File
+	file_goldfish_proto_goTypes = nil
+	file_goldfish_proto_depIdxs = nil
+}
@@ -0,0 +1,219 @@
+// The goldfish gRPC API, served by "goldfish serve --grpc".
+// It lets IDE plugins and agents list the configured commands, preview the
+// command line a command would run, and run it while streaming its output.
+//
+// Regenerate the Go code after editing with:
+//   protoc --go_out=. --go_opt=paths=source_relative \
+//     --go-grpc_out=. --go-grpc_opt=paths=source_relative goldfish.proto
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: goldfish.proto
+
+package goldfishpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Goldfish_ListCommands_FullMethodName = "/goldfish.v1.Goldfish/ListCommands"
+	Goldfish_Render_FullMethodName       = "/goldfish.v1.Goldfish/Render"
+	Goldfish_Execute_FullMethodName      = "/goldfish.v1.Goldfish/Execute"
+)
+
+// GoldfishClient is the client API for Goldfish service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Goldfish exposes the configured commands for the platform the server runs on.
+type GoldfishClient interface {
+	// ListCommands returns the commands available on the server's platform.
+	ListCommands(ctx context.Context, in *ListCommandsRequest, opts ...grpc.CallOption) (*ListCommandsResponse, error)
+	// Render returns the command line a command would run, with secrets masked.
+	Render(ctx context.Context, in *RenderRequest, opts ...grpc.CallOption) (*RenderResponse, error)
+	// Execute runs a command, streaming its output followed by its exit status.
+	Execute(ctx context.Context, in *ExecuteRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ExecuteResponse], error)
+}
+
+type goldfishClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewGoldfishClient(cc grpc.ClientConnInterface) GoldfishClient {
+	return &goldfishClient{cc}
+}
+
+func (c *goldfishClient) ListCommands(ctx context.Context, in *ListCommandsRequest, opts ...grpc.CallOption) (*ListCommandsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListCommandsResponse)
+	err := c.cc.Invoke(ctx, Goldfish_ListCommands_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goldfishClient) Render(ctx context.Context, in *RenderRequest, opts ...grpc.CallOption) (*RenderResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RenderResponse)
+	err := c.cc.Invoke(ctx, Goldfish_Render_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goldfishClient) Execute(ctx context.Context, in *ExecuteRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ExecuteResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Goldfish_ServiceDesc.Streams[0], Goldfish_Execute_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ExecuteRequest, ExecuteResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Goldfish_ExecuteClient = grpc.ServerStreamingClient[ExecuteResponse]
+
+// GoldfishServer is the server API for Goldfish service.
+// All implementations must embed UnimplementedGoldfishServer
+// for forward compatibility.
+//
+// Goldfish exposes the configured commands for the platform the server runs on.
+type GoldfishServer interface {
+	// ListCommands returns the commands available on the server's platform.
+	ListCommands(context.Context, *ListCommandsRequest) (*ListCommandsResponse, error)
+	// Render returns the command line a command would run, with secrets masked.
+	Render(context.Context, *RenderRequest) (*RenderResponse, error)
+	// Execute runs a command, streaming its output followed by its exit status.
+	Execute(*ExecuteRequest, grpc.ServerStreamingServer[ExecuteResponse]) error
+	mustEmbedUnimplementedGoldfishServer()
+}
+
+// UnimplementedGoldfishServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedGoldfishServer struct{}
+
+func (UnimplementedGoldfishServer) ListCommands(context.Context, *ListCommandsRequest) (*ListCommandsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCommands not implemented")
+}
+func (UnimplementedGoldfishServer) Render(context.Context, *RenderRequest) (*RenderResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Render not implemented")
+}
+func (UnimplementedGoldfishServer) Execute(*ExecuteRequest, grpc.ServerStreamingServer[ExecuteResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method Execute not implemented")
+}
+func (UnimplementedGoldfishServer) mustEmbedUnimplementedGoldfishServer() {}
+func (UnimplementedGoldfishServer) testEmbeddedByValue()                  {}
+
+// UnsafeGoldfishServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to GoldfishServer will
+// result in compilation errors.
+type UnsafeGoldfishServer interface {
+	mustEmbedUnimplementedGoldfishServer()
+}
+
+func RegisterGoldfishServer(s grpc.ServiceRegistrar, srv GoldfishServer) {
+	// If the following call pancis, it indicates UnimplementedGoldfishServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Goldfish_ServiceDesc, srv)
+}
+
+func _Goldfish_ListCommands_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCommandsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoldfishServer).ListCommands(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Goldfish_ListCommands_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoldfishServer).ListCommands(ctx, req.(*ListCommandsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Goldfish_Render_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoldfishServer).Render(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Goldfish_Render_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoldfishServer).Render(ctx, req.(*RenderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Goldfish_Execute_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExecuteRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GoldfishServer).Execute(m, &grpc.GenericServerStream[ExecuteRequest, ExecuteResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Goldfish_ExecuteServer = grpc.ServerStreamingServer[ExecuteResponse]
+
+// Goldfish_ServiceDesc is the grpc.ServiceDesc for Goldfish service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Goldfish_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "goldfish.v1.Goldfish",
+	HandlerType: (*GoldfishServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListCommands",
+			Handler:    _Goldfish_ListCommands_Handler,
+		},
+		{
+			MethodName: "Render",
+			Handler:    _Goldfish_Render_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Execute",
+			Handler:       _Goldfish_Execute_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "goldfish.proto",
+}
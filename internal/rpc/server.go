@@ -0,0 +1,183 @@
+// Package rpc serves goldfish commands over gRPC (see goldfishpb/goldfish.proto).
+// IDE plugins and agents use it to list commands, preview the command line a
+// command would run, and run commands while streaming their output live.
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/engine"
+	"github.com/danballance/goldfish/internal/platform"
+	"github.com/danballance/goldfish/internal/rpc/goldfishpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server implements the Goldfish gRPC service for one platform
+type Server struct {
+	goldfishpb.UnimplementedGoldfishServer
+
+	config   *config.Config
+	engine   *engine.Engine
+	platform platform.SupportedPlatform
+	timeout  time.Duration
+}
+
+// NewServer creates a service running commands from cfg on the given platform
+func NewServer(cfg *config.Config, eng *engine.Engine, currentPlatform platform.SupportedPlatform, timeout time.Duration) *Server {
+	return &Server{
+		config:   cfg,
+		engine:   eng,
+		platform: currentPlatform,
+		timeout:  timeout,
+	}
+}
+
+// Register adds the service to a gRPC server
+func (s *Server) Register(grpcServer *grpc.Server) {
+	goldfishpb.RegisterGoldfishServer(grpcServer, s)
+}
+
+// ListCommands returns the listed commands that support the server's platform
+func (s *Server) ListCommands(ctx context.Context, req *goldfishpb.ListCommandsRequest) (*goldfishpb.ListCommandsResponse, error) {
+	resp := &goldfishpb.ListCommandsResponse{}
+	for i := range s.config.Commands {
+		cmd := &s.config.Commands[i]
+		if !engine.Supports(cmd, s.platform) || !cmd.Listed() {
+			continue
+		}
+		resp.Commands = append(resp.Commands, commandMessage(cmd))
+	}
+	return resp, nil
+}
+
+// Render returns the command line an invocation would run, with secrets masked
+func (s *Server) Render(ctx context.Context, req *goldfishpb.RenderRequest) (*goldfishpb.RenderResponse, error) {
+	execCtx, err := s.executionContext(req.GetInvocation())
+	if err != nil {
+		return nil, err
+	}
+	rendered, err := s.engine.Preview(execCtx)
+	if err != nil {
+		return nil, renderStatus(err)
+	}
+	return &goldfishpb.RenderResponse{CommandLine: rendered}, nil
+}
+
+// Execute runs an invocation, streaming output chunks and then the exit status
+// A command that runs but fails is reported in the exit status, not as an RPC
+// error; the command is stopped when the client cancels or disconnects
+func (s *Server) Execute(req *goldfishpb.ExecuteRequest, stream grpc.ServerStreamingServer[goldfishpb.ExecuteResponse]) error {
+	execCtx, err := s.executionContext(req.GetInvocation())
+	if err != nil {
+		return err
+	}
+
+	// stdout and stderr are copied on separate goroutines, so sends are serialized
+	var mu sync.Mutex
+	execCtx.Stdin = bytes.NewReader(nil)
+	execCtx.Stdout = &chunkWriter{stream: stream, mu: &mu, kind: goldfishpb.OutputChunk_STDOUT}
+	execCtx.Stderr = &chunkWriter{stream: stream, mu: &mu, kind: goldfishpb.OutputChunk_STDERR}
+
+	exitCode, runErr := s.engine.Run(stream.Context(), execCtx)
+	exit := &goldfishpb.ExitStatus{ExitCode: int32(exitCode)}
+	if runErr != nil {
+		// Errors such as a timeout name the command line, secrets included
+		exit.Error = s.engine.MaskError(execCtx, runErr)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	return stream.Send(&goldfishpb.ExecuteResponse{Event: &goldfishpb.ExecuteResponse_Exit{Exit: exit}})
+}
+
+// executionContext resolves an invocation the same way batch jobs are resolved
+func (s *Server) executionContext(invocation *goldfishpb.Invocation) (*engine.ExecutionContext, error) {
+	if invocation.GetCommand() == "" {
+		return nil, status.Error(codes.InvalidArgument, "invocation.command is required")
+	}
+	cmd, found := s.config.FindCommand(invocation.GetCommand())
+	if !found {
+		return nil, status.Errorf(codes.NotFound, "unknown command '%s'", invocation.GetCommand())
+	}
+
+	// Named parameters are passed like flags; values are converted to each parameter's type
+	flags := make(map[string]interface{}, len(invocation.GetParams()))
+	for name, value := range invocation.GetParams() {
+		flags["--"+name] = value
+	}
+	params, err := s.engine.ParseParameters(cmd, invocation.GetArgs(), flags)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to parse parameters: %v", err)
+	}
+
+	return &engine.ExecutionContext{
+		Command:    cmd,
+		Platform:   s.platform,
+		Parameters: params,
+		Timeout:    s.timeout,
+	}, nil
+}
+
+// renderStatus converts a Preview error into a gRPC status
+func renderStatus(err error) error {
+	switch {
+	case errors.Is(err, engine.ErrPlatformUnsupported):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, config.ErrCommandNotFound):
+		// e.g. a {{goldfish}} call composing a command that is not configured
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return status.Error(codes.InvalidArgument, err.Error())
+}
+
+// commandMessage converts a command definition into its API representation
+func commandMessage(cmd *config.Command) *goldfishpb.Command {
+	message := &goldfishpb.Command{
+		Name:        cmd.Name,
+		Alias:       cmd.Alias,
+		Description: cmd.Description,
+	}
+	for _, param := range cmd.Parameters {
+		message.Parameters = append(message.Parameters, &goldfishpb.Parameter{
+			Name:        param.Name,
+			Type:        param.Type,
+			Required:    param.Required,
+			Description: param.Description,
+			Secret:      param.Secret,
+		})
+	}
+	for name := range cmd.Platforms {
+		message.Platforms = append(message.Platforms, name)
+	}
+	// Map iteration order is random; keep responses stable
+	sort.Strings(message.Platforms)
+	return message
+}
+
+// chunkWriter sends everything written to it as output chunks on an Execute stream
+type chunkWriter struct {
+	stream grpc.ServerStreamingServer[goldfishpb.ExecuteResponse]
+	mu     *sync.Mutex
+	kind   goldfishpb.OutputChunk_Stream
+}
+
+// Write sends p as one chunk; the message is encoded before Send returns, so p may be reused
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	err := w.stream.Send(&goldfishpb.ExecuteResponse{
+		Event: &goldfishpb.ExecuteResponse_Output{Output: &goldfishpb.OutputChunk{Stream: w.kind, Data: p}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,194 @@
+package rpc
+
+import (
+	"context"
+	"io"
+	"net"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/engine"
+	"github.com/danballance/goldfish/internal/platform"
+	"github.com/danballance/goldfish/internal/rpc/goldfishpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// testClient starts a server on an in-memory connection and returns a client for it
+func testClient(t *testing.T) goldfishpb.GoldfishClient {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+
+	template := "echo {{.params.word}}; echo oops >&2; exit {{.params.code}}"
+	cfg := &config.Config{
+		Commands: []config.Command{
+			{
+				Name:        "say",
+				Alias:       "s",
+				BaseCommand: "sh",
+				Parameters: []config.Parameter{
+					{Name: "word", Type: "string", Required: true},
+					{Name: "code", Type: "int", Default: 0},
+				},
+				Platforms: map[string]config.PlatformCommand{
+					"linux":  {Template: template},
+					"darwin": {Template: template},
+				},
+			},
+			{Name: "hidden", BaseCommand: "true", Hidden: true, Platforms: map[string]config.PlatformCommand{runtime.GOOS: {Template: "true"}}},
+			{Name: "elsewhere", BaseCommand: "true", Platforms: map[string]config.PlatformCommand{"plan9": {Template: "true"}}},
+		},
+	}
+
+	return serveTest(t, cfg, 5*time.Second)
+}
+
+// serveTest serves cfg on an in-memory connection and returns a client for it
+func serveTest(t *testing.T, cfg *config.Config, timeout time.Duration) goldfishpb.GoldfishClient {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer()
+	NewServer(cfg, engine.NewEngine(timeout), platform.SupportedPlatform(runtime.GOOS), timeout).Register(grpcServer)
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return goldfishpb.NewGoldfishClient(conn)
+}
+
+// TestServer_ListCommands tests that only listed commands for this platform are returned
+func TestServer_ListCommands(t *testing.T) {
+	client := testClient(t)
+	resp, err := client.ListCommands(context.Background(), &goldfishpb.ListCommandsRequest{})
+	if err != nil {
+		t.Fatalf("ListCommands() failed: %v", err)
+	}
+	if len(resp.Commands) != 1 || resp.Commands[0].Name != "say" {
+		t.Fatalf("Expected only 'say', got %v", resp.Commands)
+	}
+	say := resp.Commands[0]
+	if len(say.Parameters) != 2 || !say.Parameters[0].Required || say.Platforms[0] != "darwin" {
+		t.Errorf("Unexpected command description: %v", say)
+	}
+}
+
+// TestServer_Render tests previewing a command line
+func TestServer_Render(t *testing.T) {
+	client := testClient(t)
+	resp, err := client.Render(context.Background(), &goldfishpb.RenderRequest{
+		Invocation: &goldfishpb.Invocation{Command: "s", Params: map[string]string{"word": "hi", "code": "2"}},
+	})
+	if err != nil {
+		t.Fatalf("Render() failed: %v", err)
+	}
+	if resp.CommandLine != "echo hi; echo oops >&2; exit 2" {
+		t.Errorf("Unexpected command line %q", resp.CommandLine)
+	}
+
+	// Errors map to gRPC status codes
+	cases := map[string]*goldfishpb.Invocation{
+		"NotFound":           {Command: "missing"},
+		"InvalidArgument":    {Command: "say", Params: map[string]string{"code": "x", "word": "hi"}},
+		"FailedPrecondition": {Command: "elsewhere"},
+	}
+	for want, invocation := range cases {
+		_, err := client.Render(context.Background(), &goldfishpb.RenderRequest{Invocation: invocation})
+		if status.Code(err).String() != want {
+			t.Errorf("Render(%s): expected %s, got %v", invocation.Command, want, err)
+		}
+	}
+}
+
+// TestServer_Execute tests streaming output and the final exit status
+func TestServer_Execute(t *testing.T) {
+	client := testClient(t)
+	stream, err := client.Execute(context.Background(), &goldfishpb.ExecuteRequest{
+		Invocation: &goldfishpb.Invocation{Command: "say", Params: map[string]string{"word": "hello", "code": "3"}},
+	})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	var stdout, stderr string
+	var exit *goldfishpb.ExitStatus
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv() failed: %v", err)
+		}
+		if output := resp.GetOutput(); output != nil {
+			if output.Stream == goldfishpb.OutputChunk_STDERR {
+				stderr += string(output.Data)
+			} else {
+				stdout += string(output.Data)
+			}
+		}
+		if resp.GetExit() != nil {
+			exit = resp.GetExit()
+		}
+	}
+
+	if stdout != "hello\n" || stderr != "oops\n" {
+		t.Errorf("Unexpected output: stdout %q, stderr %q", stdout, stderr)
+	}
+	if exit == nil || exit.ExitCode != 3 || exit.Error != "" {
+		t.Errorf("Expected exit code 3 without error, got %v", exit)
+	}
+
+	// Unknown commands fail the RPC itself
+	stream, _ = client.Execute(context.Background(), &goldfishpb.ExecuteRequest{Invocation: &goldfishpb.Invocation{Command: "missing"}})
+	if _, err := stream.Recv(); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound, got %v", err)
+	}
+}
+
+// TestServer_Execute_MasksSecrets tests that an execution error naming the
+// command line does not send its secret parameters to the client
+func TestServer_Execute_MasksSecrets(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+	cfg := &config.Config{Commands: []config.Command{{
+		Name:        "slow",
+		BaseCommand: "sleep",
+		Parameters:  []config.Parameter{{Name: "token", Type: "string", Secret: true}},
+		Platforms:   map[string]config.PlatformCommand{runtime.GOOS: {Template: "sleep 5 # {{.params.token}}"}},
+	}}}
+	client := serveTest(t, cfg, 200*time.Millisecond)
+	stream, err := client.Execute(context.Background(), &goldfishpb.ExecuteRequest{
+		Invocation: &goldfishpb.Invocation{Command: "slow", Params: map[string]string{"token": "s3cr3t"}},
+	})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	var exit *goldfishpb.ExitStatus
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			break
+		}
+		if resp.GetExit() != nil {
+			exit = resp.GetExit()
+		}
+	}
+	if exit == nil || !strings.Contains(exit.Error, "timed out") || strings.Contains(exit.Error, "s3cr3t") {
+		t.Errorf("Expected the timeout with the secret masked, got %v", exit)
+	}
+}
@@ -0,0 +1,278 @@
+// Package runbook runs runbooks: YAML files listing goldfish invocations to
+// carry out in order, with descriptions, confirmation pauses and variables
+// shared between steps. Operational runbooks that live in wikis and are
+// followed by hand can be written as one and run with "goldfish run".
+package runbook
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/danballance/goldfish/internal/batch"
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/workflow"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrDeclined is returned when the operator does not confirm a pause
+var ErrDeclined = errors.New("not confirmed")
+
+// Runbook is the content of a runbook file
+type Runbook struct {
+	// Name and Description are shown when the runbook starts
+	Name        string `yaml:"name,omitempty"`
+	Description string `yaml:"description,omitempty"`
+	// Vars are shared by every step as {{.vars.name}}; steps can add more with capture:
+	Vars map[string]string `yaml:"vars,omitempty"`
+	// Steps run one after another
+	Steps []Step `yaml:"steps"`
+}
+
+// Step is one entry of a runbook: a goldfish command, a pause, or both
+type Step struct {
+	// Name labels the step and makes its outcome available as {{.steps.<name>.status}}
+	Name string `yaml:"name,omitempty"`
+	// Description explains the step to the operator; it may use templates
+	Description string `yaml:"description,omitempty"`
+	// Pause asks the operator to confirm before the step goes on; answering
+	// anything but yes stops the runbook
+	Pause string `yaml:"pause,omitempty"`
+	// Command, Args and Params are the goldfish invocation, as in a workflow step
+	Command string                 `yaml:"command,omitempty"`
+	Args    []string               `yaml:"args,omitempty"`
+	Params  map[string]interface{} `yaml:"params,omitempty"`
+	// If is a template condition; the step is skipped unless it renders to a true value
+	If string `yaml:"if,omitempty"`
+	// Capture stores the command's output, trimmed, as the variable of that name
+	Capture string `yaml:"capture,omitempty"`
+	// ContinueOnError lets the runbook go on when the command fails
+	ContinueOnError bool `yaml:"continue_on_error,omitempty"`
+}
+
+// Label returns the name shown for the step
+func (s *Step) Label() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	if s.Command != "" {
+		return strings.TrimSpace(s.Command + " " + strings.Join(s.Args, " "))
+	}
+	return "pause"
+}
+
+// varName matches names steps may capture output into
+var varName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Load reads and validates a runbook file
+// Unknown keys are rejected, since a mistyped pause: must not be ignored.
+func Load(path string) (*Runbook, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read runbook %s: %w", path, err)
+	}
+
+	var book Runbook
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&book); err != nil {
+		return nil, fmt.Errorf("failed to parse runbook %s: %w", path, err)
+	}
+	if err := book.validate(); err != nil {
+		return nil, fmt.Errorf("runbook %s: %w", path, err)
+	}
+	return &book, nil
+}
+
+// validate checks that every step does something and names are unique
+func (b *Runbook) validate() error {
+	if len(b.Steps) == 0 {
+		return fmt.Errorf("no steps defined")
+	}
+	names := make(map[string]bool, len(b.Steps))
+	for i, step := range b.Steps {
+		if step.Command == "" && step.Pause == "" {
+			return fmt.Errorf("step %d: command or pause is required", i+1)
+		}
+		if step.Name != "" {
+			if names[step.Name] {
+				return fmt.Errorf("duplicate step name: %s", step.Name)
+			}
+			names[step.Name] = true
+		}
+		if step.Capture != "" {
+			if step.Command == "" {
+				return fmt.Errorf("step %d: capture needs a command", i+1)
+			}
+			if !varName.MatchString(step.Capture) {
+				return fmt.Errorf("step %d: invalid capture variable '%s'", i+1, step.Capture)
+			}
+		}
+	}
+	return nil
+}
+
+// Options control how a runbook is run
+type Options struct {
+	// Vars override the runbook's own variables (e.g. from --var)
+	Vars map[string]string
+	// AssumeYes confirms every pause without asking
+	AssumeYes bool
+}
+
+// Runner runs runbooks, executing each command as a batch job
+type Runner struct {
+	jobs *batch.Runner
+	// in answers pauses; out receives step headers, prompts and command output
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// NewRunner creates a runbook runner that executes commands with the given batch runner
+func NewRunner(jobs *batch.Runner, in io.Reader, out io.Writer) *Runner {
+	return &Runner{jobs: jobs, in: bufio.NewReader(in), out: out}
+}
+
+// Run carries out the runbook's steps in order
+// It returns one result per command step, in order. A failed command stops
+// the runbook unless the step continues on error, and the commands after it
+// are reported as skipped. When a pause is not confirmed the results are
+// returned with an error wrapping ErrDeclined.
+func (r *Runner) Run(book *Runbook, opts Options) ([]batch.Result, error) {
+	vars := make(map[string]string, len(book.Vars)+len(opts.Vars))
+	for name, value := range book.Vars {
+		vars[name] = value
+	}
+	for name, value := range opts.Vars {
+		vars[name] = value
+	}
+	stepData := make(map[string]map[string]interface{}, len(book.Steps))
+	data := map[string]interface{}{
+		"vars":  vars,
+		"steps": stepData,
+	}
+
+	if book.Name != "" {
+		fmt.Fprintf(r.out, "Runbook: %s\n", book.Name)
+	}
+	if book.Description != "" {
+		fmt.Fprintln(r.out, strings.TrimSpace(book.Description))
+	}
+
+	var results []batch.Result
+	// stopped is set once a step fails or a pause is declined
+	var stopped error
+	for i, step := range book.Steps {
+		skipped := batch.Result{Job: batch.Job{Name: step.Label(), Command: step.Command}, ExitCode: -1, Skipped: true}
+		if stopped != nil {
+			if step.Command != "" {
+				results = append(results, skipped)
+			}
+			continue
+		}
+
+		fmt.Fprintf(r.out, "\n==> Step %d/%d: %s\n", i+1, len(book.Steps), step.Label())
+		result, status, err := r.runStep(step, data, skipped, opts)
+		if err != nil {
+			stopped = err
+		}
+		if step.Command != "" {
+			results = append(results, result)
+			if status == workflow.StatusFailed && !step.ContinueOnError {
+				stopped = fmt.Errorf("step '%s' failed", step.Label())
+			}
+		}
+		if step.Name != "" {
+			stepData[step.Name] = map[string]interface{}{
+				"status":    status,
+				"exit_code": result.ExitCode,
+			}
+		}
+	}
+
+	if errors.Is(stopped, ErrDeclined) {
+		return results, stopped
+	}
+	return results, nil
+}
+
+// runStep runs one step and returns its result and status for later conditions
+// The error is only set when the step's pause was declined.
+func (r *Runner) runStep(step Step, data map[string]interface{}, skipped batch.Result, opts Options) (batch.Result, string, error) {
+	if step.If != "" {
+		run, err := workflow.EvaluateCondition(step.If, data)
+		if err != nil {
+			return batch.Result{Job: skipped.Job, ExitCode: -1, Err: fmt.Errorf("if: %w", err)}, workflow.StatusFailed, nil
+		}
+		if !run {
+			fmt.Fprintln(r.out, "    skipped (if: condition is false)")
+			return skipped, workflow.StatusSkipped, nil
+		}
+	}
+
+	if step.Description != "" {
+		description, err := workflow.Render(step.Description, data)
+		if err != nil {
+			return batch.Result{Job: skipped.Job, ExitCode: -1, Err: fmt.Errorf("description: %w", err)}, workflow.StatusFailed, nil
+		}
+		for _, line := range strings.Split(description, "\n") {
+			fmt.Fprintln(r.out, "    "+line)
+		}
+	}
+
+	if step.Pause != "" {
+		prompt, err := workflow.Render(step.Pause, data)
+		if err != nil {
+			return batch.Result{Job: skipped.Job, ExitCode: -1, Err: fmt.Errorf("pause: %w", err)}, workflow.StatusFailed, nil
+		}
+		if !r.confirm(prompt, opts.AssumeYes) {
+			return skipped, workflow.StatusSkipped, fmt.Errorf("step '%s' was %w", step.Label(), ErrDeclined)
+		}
+	}
+	if step.Command == "" {
+		return batch.Result{Job: skipped.Job}, workflow.StatusOK, nil
+	}
+
+	job, err := workflow.RenderJob(config.WorkflowStep{Name: step.Label(), Command: step.Command, Args: step.Args, Params: step.Params}, data)
+	if err != nil {
+		return batch.Result{Job: skipped.Job, ExitCode: -1, Err: err}, workflow.StatusFailed, nil
+	}
+
+	// Captured output is still shown to the operator
+	var captured bytes.Buffer
+	stdout := r.out
+	if step.Capture != "" {
+		stdout = io.MultiWriter(r.out, &captured)
+	}
+	result := r.jobs.RunJobTo(job, stdout)
+	if step.Capture != "" {
+		data["vars"].(map[string]string)[step.Capture] = strings.TrimSpace(captured.String())
+	}
+	if result.Failed() {
+		return result, workflow.StatusFailed, nil
+	}
+	return result, workflow.StatusOK, nil
+}
+
+// confirm shows a pause and reports whether the operator answered yes
+// End of input counts as no, so a runbook never runs past a pause unattended
+// unless AssumeYes is set.
+func (r *Runner) confirm(prompt string, assumeYes bool) bool {
+	if assumeYes {
+		fmt.Fprintf(r.out, "    %s (confirmed by --yes)\n", prompt)
+		return true
+	}
+	fmt.Fprintf(r.out, "    %s Continue? [y/N] ", prompt)
+	answer, _ := r.in.ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	fmt.Fprintln(r.out)
+	return false
+}
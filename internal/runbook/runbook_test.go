@@ -0,0 +1,182 @@
+package runbook
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/batch"
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/engine"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// testRunner creates a runbook runner with a "say" command that echoes a
+// message and a "fail" command that always fails; answers are read from input
+func testRunner(t *testing.T, input string) (*Runner, *bytes.Buffer) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+
+	posix := func(template string) map[string]config.PlatformCommand {
+		return map[string]config.PlatformCommand{
+			"linux":  {Template: template},
+			"darwin": {Template: template},
+		}
+	}
+	cfg := &config.Config{
+		Commands: []config.Command{
+			{
+				Name:        "say",
+				BaseCommand: "echo",
+				Parameters:  []config.Parameter{{Name: "msg", Type: "string", Required: true}},
+				Platforms:   posix("echo {{.params.msg}}"),
+			},
+			{Name: "fail", BaseCommand: "sh", Platforms: posix("exit 1")},
+		},
+	}
+
+	eng := engine.NewEngine(5 * time.Second)
+	jobs := batch.NewRunner(cfg, eng, platform.SupportedPlatform(runtime.GOOS), 5*time.Second)
+	var out bytes.Buffer
+	return NewRunner(jobs, strings.NewReader(input), &out), &out
+}
+
+// sayStep builds a step that echoes msg
+func sayStep(name, msg string) Step {
+	return Step{Name: name, Command: "say", Params: map[string]interface{}{"msg": msg}}
+}
+
+// TestLoad tests reading and validating runbook files
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	book, err := Load(write("ok.yml", `
+name: failover
+vars:
+  region: eu-west-1
+steps:
+  - name: check
+    description: Check replication lag
+    command: say
+    params:
+      msg: "{{.vars.region}}"
+  - pause: Promote the replica?
+`))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if book.Name != "failover" || len(book.Steps) != 2 || book.Steps[1].Pause == "" {
+		t.Errorf("Unexpected runbook: %+v", book)
+	}
+
+	invalid := map[string]string{
+		"empty.yml":     "name: nothing\n",
+		"typo.yml":      "steps:\n  - command: say\n    pasue: sure?\n",
+		"noop.yml":      "steps:\n  - description: nothing to do\n",
+		"duplicate.yml": "steps:\n  - {name: a, command: say}\n  - {name: a, command: say}\n",
+		"capture.yml":   "steps:\n  - {command: say, capture: not-a-name}\n",
+	}
+	for name, content := range invalid {
+		if _, err := Load(write(name, content)); err == nil {
+			t.Errorf("%s: expected a validation error", name)
+		}
+	}
+}
+
+// TestRunner_Run tests running steps in order with captured variables
+func TestRunner_Run(t *testing.T) {
+	runner, out := testRunner(t, "")
+	book := &Runbook{
+		Name: "greet",
+		Vars: map[string]string{"who": "world"},
+		Steps: []Step{
+			{Name: "first", Command: "say", Params: map[string]interface{}{"msg": "hello-{{.vars.who}}"}, Capture: "greeting"},
+			sayStep("second", "got-{{.vars.greeting}}"),
+			{Name: "skipped", Command: "say", Params: map[string]interface{}{"msg": "never"}, If: `{{eq .steps.first.status "failed"}}`},
+		},
+	}
+
+	results, err := runner.Run(book, Options{Vars: map[string]string{"who": "there"}})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(results) != 3 || results[0].Failed() || results[1].Failed() || !results[2].Skipped {
+		t.Fatalf("Unexpected results: %+v", results)
+	}
+	output := out.String()
+	if !strings.Contains(output, "got-hello-there") {
+		t.Errorf("Expected the captured greeting in the output, got:\n%s", output)
+	}
+	if !strings.Contains(output, "==> Step 2/3: second") {
+		t.Errorf("Expected step headers, got:\n%s", output)
+	}
+}
+
+// TestRunner_Run_Failure tests that a failed step stops the runbook
+func TestRunner_Run_Failure(t *testing.T) {
+	runner, _ := testRunner(t, "")
+	book := &Runbook{Steps: []Step{
+		{Name: "tolerated", Command: "fail", ContinueOnError: true},
+		{Name: "broken", Command: "fail"},
+		sayStep("after", "unreachable"),
+	}}
+
+	results, err := runner.Run(book, Options{})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(results) != 3 || !results[0].Failed() || !results[1].Failed() || !results[2].Skipped {
+		t.Errorf("Expected the last step to be skipped after the failure, got %+v", results)
+	}
+}
+
+// TestRunner_Run_Pause tests confirming and declining pauses
+func TestRunner_Run_Pause(t *testing.T) {
+	book := &Runbook{Steps: []Step{
+		{Pause: "Ready?"},
+		{Name: "promote", Command: "say", Params: map[string]interface{}{"msg": "promoted"}, Pause: "Promote?"},
+		sayStep("after", "done"),
+	}}
+
+	// The first pause is confirmed, the second declined
+	runner, out := testRunner(t, "y\nno\n")
+	results, err := runner.Run(book, Options{})
+	if !errors.Is(err, ErrDeclined) || !strings.Contains(err.Error(), "promote") {
+		t.Fatalf("Expected the promote step to be declined, got %v", err)
+	}
+	if len(results) != 2 || !results[0].Skipped || !results[1].Skipped {
+		t.Errorf("Expected both commands to be skipped, got %+v", results)
+	}
+	if strings.Contains(out.String(), "promoted") {
+		t.Errorf("Declined command ran:\n%s", out.String())
+	}
+
+	// Without an answer a pause is declined
+	runner, _ = testRunner(t, "")
+	if _, err := runner.Run(book, Options{}); !errors.Is(err, ErrDeclined) {
+		t.Errorf("Expected end of input to decline, got %v", err)
+	}
+
+	// --yes confirms everything
+	runner, out = testRunner(t, "")
+	if _, err := runner.Run(book, Options{AssumeYes: true}); err != nil {
+		t.Fatalf("Run with AssumeYes failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "promoted") || !strings.Contains(out.String(), "done") {
+		t.Errorf("Expected every step to run, got:\n%s", out.String())
+	}
+}
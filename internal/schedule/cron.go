@@ -0,0 +1,206 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron is a parsed five-field cron expression: minute, hour, day of month,
+// month and day of week. Each field is "*", a number, a range ("1-5"), a
+// step ("*/15", "0-30/10") or a list of those ("1,15"); months and days of
+// the week may also be named ("jan", "mon"). The macros @hourly, @daily
+// (@midnight), @weekly, @monthly and @yearly (@annually) are accepted too.
+type Cron struct {
+	// Minute, Hour, Day, Month and Weekday are the values each field allows,
+	// as bit sets; Weekday counts from Sunday = 0
+	Minute, Hour, Day, Month, Weekday uint64
+	// AnyDay and AnyWeekday are set when the field is "*"; as in cron, when
+	// both day fields are restricted a time matching either of them is due
+	AnyDay, AnyWeekday bool
+}
+
+// cronField describes one field of an expression
+type cronField struct {
+	name     string
+	min, max int
+	names    []string
+}
+
+// The fields of an expression, in order
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	// 7 is Sunday as well as 0, as most crons allow
+	{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+// cronMacros are the shorthands for common expressions
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron parses a cron expression (see Cron)
+func ParseCron(expr string) (*Cron, error) {
+	text := strings.TrimSpace(expr)
+	if macro, found := cronMacros[strings.ToLower(text)]; found {
+		text = macro
+	}
+	fields := strings.Fields(text)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("invalid schedule '%s': expected 5 fields (minute hour day month weekday), got %d", expr, len(fields))
+	}
+
+	sets := make([]uint64, len(fields))
+	for i, field := range fields {
+		set, err := parseCronField(field, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule '%s': %w", expr, err)
+		}
+		sets[i] = set
+	}
+	// Sunday may be written as 7
+	if sets[4]&(1<<7) != 0 {
+		sets[4] = sets[4]&^(1<<7) | 1
+	}
+	return &Cron{
+		Minute:     sets[0],
+		Hour:       sets[1],
+		Day:        sets[2],
+		Month:      sets[3],
+		Weekday:    sets[4],
+		AnyDay:     fields[2] == "*",
+		AnyWeekday: fields[4] == "*",
+	}, nil
+}
+
+// parseCronField returns the values a field of an expression allows
+func parseCronField(text string, field cronField) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(text, ",") {
+		rangeText, stepText, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepText)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step '%s' in the %s field", stepText, field.name)
+			}
+		}
+
+		low, high := field.min, field.max
+		switch {
+		case rangeText == "*":
+		case strings.Contains(rangeText, "-"):
+			lowText, highText, _ := strings.Cut(rangeText, "-")
+			var err error
+			if low, err = cronValue(lowText, field); err != nil {
+				return 0, err
+			}
+			if high, err = cronValue(highText, field); err != nil {
+				return 0, err
+			}
+			if low > high {
+				return 0, fmt.Errorf("invalid range '%s' in the %s field", rangeText, field.name)
+			}
+		default:
+			value, err := cronValue(rangeText, field)
+			if err != nil {
+				return 0, err
+			}
+			low = value
+			// "5/10" means from 5 to the end in steps of 10
+			if !hasStep {
+				high = value
+			}
+		}
+		for value := low; value <= high; value += step {
+			set |= 1 << value
+		}
+	}
+	return set, nil
+}
+
+// cronValue parses a single number or name of a field
+func cronValue(text string, field cronField) (int, error) {
+	for i, name := range field.names {
+		if strings.EqualFold(text, name) {
+			return field.min + i, nil
+		}
+	}
+	value, err := strconv.Atoi(text)
+	if err != nil || value < field.min || value > field.max {
+		return 0, fmt.Errorf("invalid value '%s' in the %s field (%d-%d)", text, field.name, field.min, field.max)
+	}
+	return value, nil
+}
+
+// Matches reports whether the expression is due in the minute of t
+func (c *Cron) Matches(t time.Time) bool {
+	return c.Minute&(1<<t.Minute()) != 0 && c.Hour&(1<<t.Hour()) != 0 &&
+		c.Month&(1<<int(t.Month())) != 0 && c.dayMatches(t)
+}
+
+// Next returns the first minute after t in which the expression is due, or
+// the zero time if there is none within five years (e.g. "0 0 30 2 *")
+func (c *Cron) Next(t time.Time) time.Time {
+	next := t.Truncate(time.Minute).Add(time.Minute)
+	limit := next.AddDate(5, 0, 0)
+	for next.Before(limit) {
+		// Skip whole months and days that cannot match rather than every minute
+		if c.Month&(1<<int(next.Month())) == 0 {
+			next = time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, next.Location())
+			continue
+		}
+		if !c.dayMatches(next) {
+			next = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, next.Location())
+			continue
+		}
+		if c.Hour&(1<<next.Hour()) == 0 {
+			next = time.Date(next.Year(), next.Month(), next.Day(), next.Hour()+1, 0, 0, 0, next.Location())
+			continue
+		}
+		if c.Minute&(1<<next.Minute()) == 0 {
+			next = next.Add(time.Minute)
+			continue
+		}
+		return next
+	}
+	return time.Time{}
+}
+
+// dayMatches reports whether the day fields allow the day of t
+func (c *Cron) dayMatches(t time.Time) bool {
+	day := c.Day&(1<<t.Day()) != 0
+	weekday := c.Weekday&(1<<int(t.Weekday())) != 0
+	switch {
+	case c.AnyDay && c.AnyWeekday:
+		return true
+	case c.AnyDay:
+		return weekday
+	case c.AnyWeekday:
+		return day
+	default:
+		return day || weekday
+	}
+}
+
+// values returns the members of a field's set, in order
+func values(set uint64, field cronField) []int {
+	var members []int
+	for value := field.min; value <= field.max; value++ {
+		if set&(1<<value) != 0 {
+			members = append(members, value)
+		}
+	}
+	return members
+}
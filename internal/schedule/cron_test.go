@@ -0,0 +1,74 @@
+package schedule
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseCron tests parsing fields, ranges, steps, names and macros
+func TestParseCron(t *testing.T) {
+	testCases := []struct {
+		expr string
+		err  string
+	}{
+		{"0 3 * * *", ""},
+		{"*/15 9-17 * * mon-fri", ""},
+		{"0 0 1,15 jan,jul *", ""},
+		{"5/10 * * * 7", ""},
+		{"@daily", ""},
+		{"0 3 * *", "expected 5 fields"},
+		{"60 * * * *", "invalid value '60' in the minute field"},
+		{"* * * * funday", "day of week"},
+		{"*/0 * * * *", "invalid step"},
+		{"0 5-1 * * *", "invalid range"},
+	}
+	for _, tc := range testCases {
+		_, err := ParseCron(tc.expr)
+		if tc.err == "" && err != nil {
+			t.Errorf("%s: unexpected error %v", tc.expr, err)
+		}
+		if tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)) {
+			t.Errorf("%s: expected an error containing %q, got %v", tc.expr, tc.err, err)
+		}
+	}
+
+	cron, _ := ParseCron("5/20 * * * 7")
+	if got := values(cron.Minute, cronFields[0]); len(got) != 3 || got[2] != 45 {
+		t.Errorf("Expected minutes 5, 25 and 45, got %v", got)
+	}
+	if cron.Weekday != 1 {
+		t.Errorf("Expected 7 to mean Sunday, got %b", cron.Weekday)
+	}
+}
+
+// TestCron_Next tests finding the next minute an expression is due
+func TestCron_Next(t *testing.T) {
+	// A Wednesday
+	from := time.Date(2026, 3, 4, 10, 30, 45, 0, time.UTC)
+	testCases := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"0 3 * * *", time.Date(2026, 3, 5, 3, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 3, 4, 10, 45, 0, 0, time.UTC)},
+		{"0 9 * * mon", time.Date(2026, 3, 9, 9, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+		// Either day field may match when both are restricted
+		{"0 0 20 * fri", time.Date(2026, 3, 6, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, tc := range testCases {
+		cron, err := ParseCron(tc.expr)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.expr, err)
+		}
+		if next := cron.Next(from); !next.Equal(tc.expected) {
+			t.Errorf("%s: expected %v, got %v", tc.expr, tc.expected, next)
+		}
+		if !tc.expected.IsZero() && !cron.Matches(tc.expected) {
+			t.Errorf("%s: expected it to be due at %v", tc.expr, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package schedule
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The native schedulers goldfish schedule export writes entries for
+const (
+	FormatCron     = "cron"
+	FormatLaunchd  = "launchd"
+	FormatSchtasks = "schtasks"
+)
+
+// Formats lists them, for messages and flag help
+var Formats = []string{FormatCron, FormatLaunchd, FormatSchtasks}
+
+// maxCalendarIntervals bounds the StartCalendarInterval entries of a launchd
+// job; launchd has no steps or ranges, so "*/5 * * * *" alone needs 12
+const maxCalendarIntervals = 500
+
+// LaunchdLabelPrefix starts the label, and file name, of exported launchd jobs
+const LaunchdLabelPrefix = "io.goldfish.schedule."
+
+// Crontab returns crontab lines that run the schedules with program, the
+// path of goldfish
+func Crontab(schedules []Schedule, program string) string {
+	var out strings.Builder
+	for _, s := range schedules {
+		line := shellJoin(append([]string{program}, s.Args...))
+		if s.Dir != "" {
+			line = "cd " + shellQuote(s.Dir) + " && " + line
+		}
+		// An unescaped % ends the command in a crontab, even within quotes
+		line = strings.ReplaceAll(line, "%", `\%`)
+		fmt.Fprintf(&out, "# goldfish schedule %s\n%s %s\n", s.Name, strings.Join(strings.Fields(expandMacro(s.Cron)), " "), line)
+	}
+	return out.String()
+}
+
+// expandMacro returns the five fields a macro such as @daily stands for
+func expandMacro(expr string) string {
+	if macro, found := cronMacros[strings.ToLower(strings.TrimSpace(expr))]; found {
+		return macro
+	}
+	return expr
+}
+
+// LaunchdLabel returns the label of the launchd job for a schedule
+func LaunchdLabel(s *Schedule) string {
+	return LaunchdLabelPrefix + s.Name
+}
+
+// LaunchdPlist returns a launchd agent property list that runs the schedule
+// with program, the path of goldfish
+func LaunchdPlist(s *Schedule, program string) ([]byte, error) {
+	cron, err := ParseCron(s.Cron)
+	if err != nil {
+		return nil, err
+	}
+	intervals, err := calendarIntervals(cron)
+	if err != nil {
+		return nil, fmt.Errorf("schedule '%s' cannot be exported for launchd: %w", s.Name, err)
+	}
+
+	var out bytes.Buffer
+	out.WriteString(xml.Header)
+	out.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n")
+	out.WriteString("<plist version=\"1.0\">\n<dict>\n")
+	fmt.Fprintf(&out, "\t<key>Label</key>\n\t<string>%s</string>\n", xmlEscape(LaunchdLabel(s)))
+	out.WriteString("\t<key>ProgramArguments</key>\n\t<array>\n")
+	for _, arg := range append([]string{program}, s.Args...) {
+		fmt.Fprintf(&out, "\t\t<string>%s</string>\n", xmlEscape(arg))
+	}
+	out.WriteString("\t</array>\n")
+	if s.Dir != "" {
+		fmt.Fprintf(&out, "\t<key>WorkingDirectory</key>\n\t<string>%s</string>\n", xmlEscape(s.Dir))
+	}
+	// An interval that fixes nothing means every minute
+	if len(intervals) == 1 && len(intervals[0]) == 0 {
+		out.WriteString("\t<key>StartInterval</key>\n\t<integer>60</integer>\n</dict>\n</plist>\n")
+		return out.Bytes(), nil
+	}
+	out.WriteString("\t<key>StartCalendarInterval</key>\n\t<array>\n")
+	for _, interval := range intervals {
+		out.WriteString("\t\t<dict>\n")
+		for _, key := range []string{"Month", "Day", "Weekday", "Hour", "Minute"} {
+			if value, set := interval[key]; set {
+				fmt.Fprintf(&out, "\t\t\t<key>%s</key>\n\t\t\t<integer>%d</integer>\n", key, value)
+			}
+		}
+		out.WriteString("\t\t</dict>\n")
+	}
+	out.WriteString("\t</array>\n</dict>\n</plist>\n")
+	return out.Bytes(), nil
+}
+
+// calendarIntervals lists the launchd calendar intervals equivalent to cron
+// launchd matches fixed values only, so every combination of the restricted
+// fields is listed; a field that allows every value is left out. As in cron,
+// restricted days of the month and of the week are alternatives.
+func calendarIntervals(cron *Cron) ([]map[string]int, error) {
+	intervals := []map[string]int{{}}
+	intervals = expandInterval(intervals, "Minute", cron.Minute, cronFields[0])
+	intervals = expandInterval(intervals, "Hour", cron.Hour, cronFields[1])
+	intervals = expandInterval(intervals, "Month", cron.Month, cronFields[3])
+	switch {
+	case !cron.AnyDay && !cron.AnyWeekday:
+		byDay := expandInterval(intervals, "Day", cron.Day, cronFields[2])
+		byWeekday := expandInterval(intervals, "Weekday", cron.Weekday, cronFields[4])
+		intervals = append(byDay, byWeekday...)
+	case !cron.AnyDay:
+		intervals = expandInterval(intervals, "Day", cron.Day, cronFields[2])
+	case !cron.AnyWeekday:
+		intervals = expandInterval(intervals, "Weekday", cron.Weekday, cronFields[4])
+	}
+	if len(intervals) > maxCalendarIntervals {
+		return nil, fmt.Errorf("it needs %d calendar intervals (at most %d)", len(intervals), maxCalendarIntervals)
+	}
+	return intervals, nil
+}
+
+// expandInterval sets key to each value of a restricted field in a copy of
+// every interval; a field that allows every value is left unset
+func expandInterval(intervals []map[string]int, key string, set uint64, field cronField) []map[string]int {
+	members := values(set, field)
+	all := field.max - field.min + 1
+	if field.name == "day of week" {
+		// Sunday is only kept as 0
+		all--
+	}
+	if len(members) == all {
+		return intervals
+	}
+	var expanded []map[string]int
+	for _, interval := range intervals {
+		for _, value := range members {
+			next := make(map[string]int, len(interval)+1)
+			for k, v := range interval {
+				next[k] = v
+			}
+			next[key] = value
+			expanded = append(expanded, next)
+		}
+	}
+	return expanded
+}
+
+// xmlEscape escapes text for an XML element
+func xmlEscape(text string) string {
+	var out bytes.Buffer
+	xml.EscapeText(&out, []byte(text))
+	return out.String()
+}
+
+// The names Task Scheduler gives days and months
+var (
+	schtasksDays   = []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}
+	schtasksMonths = []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}
+)
+
+// SchtasksCommand returns the schtasks command that creates a Task Scheduler
+// task running the schedule with program, the path of goldfish.exe
+// Task Scheduler has a trigger for every minute or hour in steps, every day,
+// and some days of the week or month at a fixed time; other expressions
+// cannot be exported and must be run by goldfish schedule run.
+func SchtasksCommand(s *Schedule, program string) (string, error) {
+	cron, err := ParseCron(s.Cron)
+	if err != nil {
+		return "", err
+	}
+	trigger, err := schtasksTrigger(cron)
+	if err != nil {
+		return "", fmt.Errorf("schedule '%s' cannot be exported for Task Scheduler: %w; use goldfish schedule run instead", s.Name, err)
+	}
+
+	run := windowsQuote(program)
+	for _, arg := range s.Args {
+		run += " " + windowsQuote(arg)
+	}
+	if s.Dir != "" {
+		run = "cmd /c cd /d " + windowsQuote(s.Dir) + " && " + run
+	}
+	// The task's command line is a single argument of schtasks
+	taskRun := `"` + strings.ReplaceAll(run, `"`, `\"`) + `"`
+	return fmt.Sprintf(`schtasks /Create /F /TN "goldfish\%s" %s /TR %s`, s.Name, trigger, taskRun), nil
+}
+
+// schtasksTrigger returns the schtasks options equivalent to cron
+func schtasksTrigger(cron *Cron) (string, error) {
+	minutes := values(cron.Minute, cronFields[0])
+	hours := values(cron.Hour, cronFields[1])
+	anyMonth := len(values(cron.Month, cronFields[3])) == 12
+
+	// Every n minutes, all day
+	if step, ok := evenStep(minutes, 60); ok && len(minutes) > 1 && len(hours) == 24 && cron.AnyDay && cron.AnyWeekday && anyMonth {
+		return fmt.Sprintf("/SC MINUTE /MO %d", step), nil
+	}
+	if len(minutes) != 1 {
+		return "", fmt.Errorf("only every n minutes, or a single minute, can be expressed")
+	}
+	// Every n hours, at a minute past the hour
+	if step, ok := evenStep(hours, 24); ok && len(hours) > 1 && cron.AnyDay && cron.AnyWeekday && anyMonth {
+		return fmt.Sprintf("/SC HOURLY /MO %d /ST %02d:%02d", step, hours[0], minutes[0]), nil
+	}
+	if len(hours) != 1 {
+		return "", fmt.Errorf("only every n hours, or a single hour, can be expressed")
+	}
+	start := fmt.Sprintf("/ST %02d:%02d", hours[0], minutes[0])
+
+	switch {
+	case cron.AnyDay && cron.AnyWeekday && anyMonth:
+		return "/SC DAILY " + start, nil
+	case cron.AnyDay && anyMonth:
+		var days []string
+		for _, day := range values(cron.Weekday, cronFields[4]) {
+			days = append(days, schtasksDays[day])
+		}
+		return "/SC WEEKLY /D " + strings.Join(days, ",") + " " + start, nil
+	case cron.AnyWeekday:
+		var days []string
+		for _, day := range values(cron.Day, cronFields[2]) {
+			days = append(days, strconv.Itoa(day))
+		}
+		trigger := "/SC MONTHLY /D " + strings.Join(days, ",")
+		if !anyMonth {
+			var months []string
+			for _, month := range values(cron.Month, cronFields[3]) {
+				months = append(months, schtasksMonths[month-1])
+			}
+			trigger += " /M " + strings.Join(months, ",")
+		}
+		return trigger + " " + start, nil
+	}
+	return "", fmt.Errorf("days of the week can only be combined with every month and day")
+}
+
+// evenStep reports the step of values that start at 0 and repeat evenly
+// through a cycle of length cycle, e.g. 0, 15, 30 and 45 in an hour
+func evenStep(values []int, cycle int) (int, bool) {
+	if len(values) == 0 || values[0] != 0 {
+		return 0, false
+	}
+	step := cycle
+	if len(values) > 1 {
+		step = values[1]
+	}
+	if cycle%step != 0 || len(values) != cycle/step {
+		return 0, false
+	}
+	for i, value := range values {
+		if value != i*step {
+			return 0, false
+		}
+	}
+	return step, true
+}
+
+// windowsQuote quotes an argument for a Windows command line when it needs it
+func windowsQuote(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\"&|<>^") {
+		return arg
+	}
+	return `"` + strings.ReplaceAll(arg, `"`, `\"`) + `"`
+}
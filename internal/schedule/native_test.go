@@ -0,0 +1,91 @@
+package schedule
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestCrontab tests the crontab lines written for schedules
+func TestCrontab(t *testing.T) {
+	schedules := []Schedule{
+		{Name: "backup-home", Cron: "0 3 * * *", Args: []string{"backup-home", "--label", "100% done"}},
+		{Name: "sync", Cron: "@hourly", Args: []string{"sync"}, Dir: "/srv/data"},
+	}
+	expected := "# goldfish schedule backup-home\n" +
+		"0 3 * * * /usr/local/bin/goldfish backup-home --label '100\\% done'\n" +
+		"# goldfish schedule sync\n" +
+		"0 * * * * cd /srv/data && /usr/local/bin/goldfish sync\n"
+	if crontab := Crontab(schedules, "/usr/local/bin/goldfish"); crontab != expected {
+		t.Errorf("Unexpected crontab:\n%s", crontab)
+	}
+}
+
+// TestLaunchdPlist tests the launchd agents written for schedules
+func TestLaunchdPlist(t *testing.T) {
+	s := &Schedule{Name: "report", Cron: "30 9 * * 1,5", Args: []string{"report", "--to", "a&b"}, Dir: "/Users/ada"}
+	plist, err := LaunchdPlist(s, "/opt/homebrew/bin/goldfish")
+	if err != nil {
+		t.Fatalf("LaunchdPlist() failed: %v", err)
+	}
+	for _, expected := range []string{
+		"<string>io.goldfish.schedule.report</string>",
+		"<string>a&amp;b</string>",
+		"<key>WorkingDirectory</key>\n\t<string>/Users/ada</string>",
+		"<key>Weekday</key>\n\t\t\t<integer>5</integer>",
+	} {
+		if !strings.Contains(string(plist), expected) {
+			t.Errorf("Expected %q in the plist:\n%s", expected, plist)
+		}
+	}
+	if count := strings.Count(string(plist), "<key>Hour</key>"); count != 2 {
+		t.Errorf("Expected one interval for each weekday, got %d", count)
+	}
+
+	// A schedule that fixes nothing runs every minute
+	plist, err = LaunchdPlist(&Schedule{Name: "often", Cron: "* * * * *", Args: []string{"x"}}, "goldfish")
+	if err != nil || !strings.Contains(string(plist), "<key>StartInterval</key>\n\t<integer>60</integer>") {
+		t.Errorf("Expected a 60 second interval, got %s (%v)", plist, err)
+	}
+
+	// Every combination of the restricted fields needs an interval of its own
+	if _, err := LaunchdPlist(&Schedule{Name: "busy", Cron: "*/2 */2 * * 1-5", Args: []string{"x"}}, "goldfish"); err == nil {
+		t.Error("Expected too many calendar intervals to be refused")
+	}
+}
+
+// TestSchtasksCommand tests the Task Scheduler triggers for schedules
+func TestSchtasksCommand(t *testing.T) {
+	testCases := []struct {
+		cron     string
+		expected string
+	}{
+		{"*/10 * * * *", "/SC MINUTE /MO 10"},
+		{"15 */6 * * *", "/SC HOURLY /MO 6 /ST 00:15"},
+		{"0 * * * *", "/SC HOURLY /MO 1 /ST 00:00"},
+		{"0 3 * * *", "/SC DAILY /ST 03:00"},
+		{"30 8 * * mon-fri", "/SC WEEKLY /D MON,TUE,WED,THU,FRI /ST 08:30"},
+		{"0 0 1,15 * *", "/SC MONTHLY /D 1,15 /ST 00:00"},
+		{"0 0 1 jan,jul *", "/SC MONTHLY /D 1 /M JAN,JUL /ST 00:00"},
+	}
+	for _, tc := range testCases {
+		s := &Schedule{Name: "backup", Cron: tc.cron, Args: []string{"backup", "--dest", `D:\My Backups`}}
+		command, err := SchtasksCommand(s, `C:\goldfish\goldfish.exe`)
+		if err != nil {
+			t.Errorf("%s: %v", tc.cron, err)
+			continue
+		}
+		if !strings.Contains(command, " "+tc.expected+" ") {
+			t.Errorf("%s: expected %q in %s", tc.cron, tc.expected, command)
+		}
+		if !strings.HasSuffix(command, `/TR "C:\goldfish\goldfish.exe backup --dest \"D:\My Backups\""`) {
+			t.Errorf("%s: unexpected task command line in %s", tc.cron, command)
+		}
+	}
+
+	for _, cron := range []string{"*/7 * * * *", "0 9-17 * * *", "0 0 1 * mon"} {
+		_, err := SchtasksCommand(&Schedule{Name: "x", Cron: cron, Args: []string{"x"}}, "goldfish.exe")
+		if err == nil || !strings.Contains(err.Error(), "goldfish schedule run") {
+			t.Errorf("%s: expected it to be refused, got %v", cron, err)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+// Package schedule runs goldfish commands on cron schedules. The schedules are
+// declared once, in schedules.yml beside the user's commands.yml, and either
+// run by goldfish schedule run, a small daemon, or exported as entries for
+// the platform's own scheduler: a crontab, launchd agents or Task Scheduler
+// tasks.
+package schedule
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// EnvVar names a schedules file to use instead of the default location
+const EnvVar = "GOLDFISH_SCHEDULES"
+
+// validName is what a schedule may be called; the name becomes part of file
+// and task names when the schedule is exported
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// Schedule runs a goldfish command line on a cron schedule
+type Schedule struct {
+	// Name identifies the schedule; it defaults to the command's name
+	Name string `yaml:"name"`
+	// Cron is when the command runs, e.g. "0 3 * * *" (see Cron)
+	Cron string `yaml:"cron"`
+	// Args are goldfish's arguments, e.g. [backup-home, --dest, /mnt/backup]
+	Args []string `yaml:"args"`
+	// Dir is the working directory the command runs in; when empty it runs
+	// wherever the scheduler starts it
+	Dir string `yaml:"dir,omitempty"`
+}
+
+// File is the contents of schedules.yml
+type File struct {
+	Schedules []Schedule `yaml:"schedules"`
+}
+
+// Validate checks the schedule's name, expression and command line
+func (s *Schedule) Validate() error {
+	if !validName.MatchString(s.Name) {
+		return fmt.Errorf("invalid schedule name '%s': use letters, digits, '.', '-' and '_'", s.Name)
+	}
+	if _, err := ParseCron(s.Cron); err != nil {
+		return fmt.Errorf("schedule '%s': %w", s.Name, err)
+	}
+	if len(s.Args) == 0 {
+		return fmt.Errorf("schedule '%s' has no command", s.Name)
+	}
+	return nil
+}
+
+// Next returns when the schedule is next due after t
+func (s *Schedule) Next(t time.Time) time.Time {
+	cron, err := ParseCron(s.Cron)
+	if err != nil {
+		return time.Time{}
+	}
+	return cron.Next(t)
+}
+
+// CommandLine returns the goldfish command line of the schedule, quoted so
+// that it can be pasted into a shell
+func (s *Schedule) CommandLine() string {
+	return shellJoin(append([]string{"goldfish"}, s.Args...))
+}
+
+// Path returns the schedules file: $GOLDFISH_SCHEDULES, or else schedules.yml
+// beside the user's commands.yml (e.g. ~/.config/goldfish/schedules.yml)
+func Path() (string, error) {
+	if path := os.Getenv(EnvVar); path != "" {
+		return path, nil
+	}
+	dir, err := config.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "schedules.yml"), nil
+}
+
+// Load reads the schedules file at path; a missing file has no schedules
+func Load(path string) (*File, error) {
+	file := &File{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return file, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schedules %s: %w", path, err)
+	}
+
+	// Unknown keys are rejected, so a typo does not silently do nothing
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse schedules %s: %w", path, err)
+	}
+	seen := make(map[string]bool, len(file.Schedules))
+	for i := range file.Schedules {
+		if err := file.Schedules[i].Validate(); err != nil {
+			return nil, fmt.Errorf("schedules %s: %w", path, err)
+		}
+		if seen[file.Schedules[i].Name] {
+			return nil, fmt.Errorf("schedules %s: '%s' is defined twice", path, file.Schedules[i].Name)
+		}
+		seen[file.Schedules[i].Name] = true
+	}
+	return file, nil
+}
+
+// Save writes the schedules to path, creating its directory if needed
+func (f *File) Save(path string) error {
+	data, err := yaml.Marshal(f)
+	if err != nil {
+		return fmt.Errorf("failed to encode schedules: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	// Write to a temporary file first so a failed write never truncates the schedules
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write schedules: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write schedules: %w", err)
+	}
+	return nil
+}
+
+// Find returns the schedule with the name, or nil
+func (f *File) Find(name string) *Schedule {
+	for i := range f.Schedules {
+		if f.Schedules[i].Name == name {
+			return &f.Schedules[i]
+		}
+	}
+	return nil
+}
+
+// Add adds a schedule after checking it; names must be unique
+func (f *File) Add(s Schedule) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	if f.Find(s.Name) != nil {
+		return fmt.Errorf("a schedule named '%s' already exists; choose another with --name", s.Name)
+	}
+	f.Schedules = append(f.Schedules, s)
+	return nil
+}
+
+// Remove removes the schedule with the name
+func (f *File) Remove(name string) error {
+	for i := range f.Schedules {
+		if f.Schedules[i].Name == name {
+			f.Schedules = append(f.Schedules[:i], f.Schedules[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("no schedule named '%s' (goldfish schedule list shows them)", name)
+}
+
+// Due returns the schedules due in the minute of t
+func (f *File) Due(t time.Time) []Schedule {
+	var due []Schedule
+	for _, s := range f.Schedules {
+		if cron, err := ParseCron(s.Cron); err == nil && cron.Matches(t) {
+			due = append(due, s)
+		}
+	}
+	return due
+}
+
+// shellJoin quotes words for POSIX shells where needed and joins them
+func shellJoin(words []string) string {
+	quoted := make([]string, len(words))
+	for i, word := range words {
+		quoted[i] = shellQuote(word)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// shellQuote quotes a word for POSIX shells when it needs quoting
+func shellQuote(word string) string {
+	if word != "" && !strings.ContainsAny(word, " \t\n'\"\\$`|&;<>()*?[]{}~#!%") {
+		return word
+	}
+	return "'" + strings.ReplaceAll(word, "'", `'\''`) + "'"
+}
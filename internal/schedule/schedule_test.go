@@ -0,0 +1,72 @@
+package schedule
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestFile tests adding, saving, loading and removing schedules
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "goldfish", "schedules.yml")
+	file, err := Load(path)
+	if err != nil || len(file.Schedules) != 0 {
+		t.Fatalf("Expected no schedules in a missing file, got %+v (%v)", file, err)
+	}
+
+	if err := file.Add(Schedule{Name: "backup-home", Cron: "0 3 * * *", Args: []string{"backup-home", "--dest", "/mnt/my backup"}}); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	if err := file.Add(Schedule{Name: "backup-home", Cron: "@hourly", Args: []string{"backup-home"}}); err == nil || !strings.Contains(err.Error(), "--name") {
+		t.Errorf("Expected a duplicate name to be refused, got %v", err)
+	}
+	if err := file.Add(Schedule{Name: "bad name", Cron: "@hourly", Args: []string{"sync"}}); err == nil {
+		t.Error("Expected an invalid name to be refused")
+	}
+	if err := file.Add(Schedule{Name: "sync", Cron: "@hourly", Args: []string{"sync"}, Dir: "/srv"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Save(path); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+
+	file, err = Load(path)
+	if err != nil || len(file.Schedules) != 2 {
+		t.Fatalf("Expected 2 schedules, got %+v (%v)", file, err)
+	}
+	if line := file.Find("backup-home").CommandLine(); line != "goldfish backup-home --dest '/mnt/my backup'" {
+		t.Errorf("Unexpected command line: %s", line)
+	}
+	due := file.Due(time.Date(2026, 3, 4, 3, 0, 0, 0, time.Local))
+	if len(due) != 2 {
+		t.Errorf("Expected both schedules at 03:00, got %+v", due)
+	}
+	if due := file.Due(time.Date(2026, 3, 4, 4, 0, 0, 0, time.Local)); len(due) != 1 || due[0].Name != "sync" {
+		t.Errorf("Expected only sync at 04:00, got %+v", due)
+	}
+
+	if err := file.Remove("backup-home"); err != nil || len(file.Schedules) != 1 {
+		t.Errorf("Remove() failed: %v", err)
+	}
+	if err := file.Remove("backup-home"); err == nil {
+		t.Error("Expected removing a missing schedule to fail")
+	}
+}
+
+// TestLoad_Invalid tests that mistakes in the file are reported
+func TestLoad_Invalid(t *testing.T) {
+	testCases := map[string]string{
+		"schedules:\n  - name: a\n    cron: '0 25 * * *'\n    args: [a]\n": "hour field",
+		"schedules:\n  - name: a\n    cron: '@daily'\n    argz: [a]\n":     "argz",
+		"schedules:\n  - name: a\n    cron: '@daily'\n    args: [a]\n  - name: a\n    cron: '@daily'\n    args: [b]\n": "defined twice",
+	}
+	for content, expected := range testCases {
+		path := filepath.Join(t.TempDir(), "schedules.yml")
+		os.WriteFile(path, []byte(content), 0644)
+		if _, err := Load(path); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected an error containing %q, got %v", expected, err)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+// Package search finds commands in the merged goldfish configuration by
+// keyword, for catalogs too large to scan in help. Every keyword must match
+// one of a command's fields; hits are ranked so that a match on the name
+// beats one buried in the description.
+package search
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// The fields a keyword can match, as reported in Hit.Fields
+const (
+	FieldName        = "name"
+	FieldAlias       = "alias"
+	FieldTags        = "tags"
+	FieldBaseCommand = "base_command"
+	FieldParams      = "params"
+	FieldDescription = "description"
+)
+
+// Hit is a command that matched every keyword
+type Hit struct {
+	// Command is the matching command
+	Command *config.Command
+	// Score ranks the hit; higher is better
+	Score int
+	// Fields are the fields that matched, in the order of the constants above
+	Fields []string
+}
+
+// Weights of a keyword matching a field exactly, at its start or anywhere in it
+// Each keyword scores its best match across the fields: a name beats an
+// alias, which beats tags and the base command, then parameter names, then
+// the description.
+var weights = []struct {
+	field                    string
+	exact, prefix, substring int
+}{
+	{FieldName, 100, 60, 40},
+	{FieldAlias, 90, 50, 30},
+	{FieldTags, 40, 25, 0},
+	{FieldBaseCommand, 35, 20, 10},
+	{FieldParams, 25, 15, 10},
+	{FieldDescription, 20, 15, 5},
+}
+
+// Commands returns the commands matching every keyword of query, best first
+// Matching ignores case. Ties are broken by name, so results are stable.
+func Commands(commands []config.Command, query string) []Hit {
+	keywords := strings.Fields(strings.ToLower(query))
+	if len(keywords) == 0 {
+		return nil
+	}
+
+	var hits []Hit
+	for i := range commands {
+		cmd := &commands[i]
+		fields := fieldWords(cmd)
+		matched := make(map[string]bool)
+		score := 0
+		for _, keyword := range keywords {
+			best := 0
+			for _, weight := range weights {
+				points := matchPoints(keyword, fields[weight.field], weight.exact, weight.prefix, weight.substring)
+				if points > 0 {
+					matched[weight.field] = true
+				}
+				if points > best {
+					best = points
+				}
+			}
+			if best == 0 {
+				score = 0
+				break
+			}
+			score += best
+		}
+		if score == 0 {
+			continue
+		}
+
+		hit := Hit{Command: cmd, Score: score}
+		for _, weight := range weights {
+			if matched[weight.field] {
+				hit.Fields = append(hit.Fields, weight.field)
+			}
+		}
+		hits = append(hits, hit)
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Command.Name < hits[j].Command.Name
+	})
+	return hits
+}
+
+// fieldWords returns the lowercased words of each searchable field
+// Names are kept whole, and descriptions split into words.
+func fieldWords(cmd *config.Command) map[string][]string {
+	params := make([]string, 0, len(cmd.Parameters))
+	for _, param := range cmd.Parameters {
+		params = append(params, param.Name)
+		if param.Flag != "" {
+			params = append(params, strings.TrimLeft(param.Flag, "-"))
+		}
+	}
+	words := map[string][]string{
+		FieldName:        {cmd.Name},
+		FieldAlias:       {cmd.Alias},
+		FieldTags:        cmd.Tags,
+		FieldBaseCommand: {cmd.BaseCommand},
+		FieldParams:      params,
+		FieldDescription: strings.FieldsFunc(cmd.Description, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_'
+		}),
+	}
+	lowered := make(map[string][]string, len(words))
+	for field, list := range words {
+		for _, word := range list {
+			if word != "" {
+				lowered[field] = append(lowered[field], strings.ToLower(word))
+			}
+		}
+	}
+	return lowered
+}
+
+// matchPoints returns the points of keyword's best match among words
+// A whole word scores exact; a match at the start of a word, or of one of
+// its hyphen or underscore separated parts ("files" in find-files), scores
+// prefix, and a match anywhere else scores substring.
+func matchPoints(keyword string, words []string, exact, prefix, substring int) int {
+	best := 0
+	for _, word := range words {
+		points := 0
+		switch {
+		case word == keyword:
+			points = exact
+		case strings.HasPrefix(word, keyword) || strings.Contains(word, "-"+keyword) || strings.Contains(word, "_"+keyword):
+			points = prefix
+		case strings.Contains(word, keyword):
+			points = substring
+		}
+		if points > best {
+			best = points
+		}
+	}
+	return best
+}
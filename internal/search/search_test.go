@@ -0,0 +1,70 @@
+package search
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// testCommands covers a match on each field
+var testCommands = []config.Command{
+	{Name: "find-files", Alias: "ff", Description: "Search for files by name", BaseCommand: "find", Tags: []string{"files"},
+		Parameters: []config.Parameter{{Name: "pattern"}}},
+	{Name: "replace", Alias: "rp", Description: "Replace text in files", BaseCommand: "sed",
+		Parameters: []config.Parameter{{Name: "in_place", Flag: "--in-place"}}},
+	{Name: "fetch", Description: "Download a URL", BaseCommand: "curl", Tags: []string{"network"},
+		Parameters: []config.Parameter{{Name: "output"}}},
+	{Name: "files", Description: "List files", BaseCommand: "ls"},
+}
+
+// names returns the names of the hits' commands in order
+func names(hits []Hit) string {
+	list := make([]string, len(hits))
+	for i, hit := range hits {
+		list[i] = hit.Command.Name
+	}
+	return strings.Join(list, ",")
+}
+
+// TestCommands tests matching and ranking commands by keyword
+func TestCommands(t *testing.T) {
+	testCases := []struct {
+		query    string
+		expected string
+	}{
+		// An exact name beats a name part, a tag and a description
+		{"files", "files,find-files,replace"},
+		{"FILES", "files,find-files,replace"},
+		{"ff", "find-files"},
+		{"curl", "fetch"},
+		{"network", "fetch"},
+		{"in-place", "replace"},
+		{"download", "fetch"},
+		// Every keyword must match
+		{"files text", "replace"},
+		{"files network", ""},
+		{"", ""},
+	}
+	for _, tc := range testCases {
+		if got := names(Commands(testCommands, tc.query)); got != tc.expected {
+			t.Errorf("%q: expected %q, got %q", tc.query, tc.expected, got)
+		}
+	}
+}
+
+// TestCommands_Fields tests reporting which fields matched
+func TestCommands_Fields(t *testing.T) {
+	hits := Commands(testCommands, "find")
+	if len(hits) != 1 {
+		t.Fatalf("Expected one hit, got %d", len(hits))
+	}
+	if fields := strings.Join(hits[0].Fields, ","); fields != "name,base_command" {
+		t.Errorf("Expected name and base_command, got %s", fields)
+	}
+
+	hits = Commands(testCommands, "pattern")
+	if len(hits) != 1 || strings.Join(hits[0].Fields, ",") != "params" {
+		t.Errorf("Expected a parameter match, got %+v", hits)
+	}
+}
@@ -0,0 +1,72 @@
+// Package secrets provides access to credentials stored in the OS keyring.
+// It lets templates reference secrets by name (macOS Keychain, Linux Secret
+// Service, Windows Credential Manager) so they never have to be written into
+// commands.yml.
+package secrets
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/zalando/go-keyring"
+)
+
+// ServiceName is the keyring service under which goldfish stores its secrets
+const ServiceName = "goldfish"
+
+// ErrNotFound is returned when a secret does not exist in the store
+var ErrNotFound = errors.New("secret not found")
+
+// Store reads and writes named secrets
+// The engine depends on this interface so tests can substitute an in-memory store
+type Store interface {
+	// Set stores value under name, replacing any existing value
+	Set(name, value string) error
+	// Get returns the value stored under name, or ErrNotFound
+	Get(name string) (string, error)
+	// Delete removes the secret stored under name, or returns ErrNotFound
+	Delete(name string) error
+}
+
+// KeyringStore is a Store backed by the operating system's keyring
+type KeyringStore struct{}
+
+// NewKeyringStore creates a store backed by the platform keyring
+func NewKeyringStore() *KeyringStore {
+	return &KeyringStore{}
+}
+
+// Set stores a secret in the keyring
+func (s *KeyringStore) Set(name, value string) error {
+	if name == "" {
+		return fmt.Errorf("secret name is required")
+	}
+	if err := keyring.Set(ServiceName, name, value); err != nil {
+		return fmt.Errorf("failed to store secret '%s': %w", name, err)
+	}
+	return nil
+}
+
+// Get reads a secret from the keyring
+func (s *KeyringStore) Get(name string) (string, error) {
+	value, err := keyring.Get(ServiceName, name)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", fmt.Errorf("secret '%s': %w", name, ErrNotFound)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret '%s': %w", name, err)
+	}
+	return value, nil
+}
+
+// Delete removes a secret from the keyring
+func (s *KeyringStore) Delete(name string) error {
+	err := keyring.Delete(ServiceName, name)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return fmt.Errorf("secret '%s': %w", name, ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete secret '%s': %w", name, err)
+	}
+	return nil
+}
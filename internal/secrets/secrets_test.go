@@ -0,0 +1,48 @@
+// Package secrets provides unit tests for the keyring secret store.
+package secrets
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/zalando/go-keyring"
+)
+
+// TestKeyringStore tests storing, reading and deleting secrets
+func TestKeyringStore(t *testing.T) {
+	// Replace the OS keyring with an in-memory one for the test
+	keyring.MockInit()
+	store := NewKeyringStore()
+
+	if err := store.Set("api-token", "s3cr3t"); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+
+	value, err := store.Get("api-token")
+	if err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	if value != "s3cr3t" {
+		t.Errorf("Expected 's3cr3t', got %q", value)
+	}
+
+	if err := store.Delete("api-token"); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+
+	// Missing secrets report ErrNotFound
+	if _, err := store.Get("api-token"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+	if err := store.Delete("api-token"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound deleting a missing secret, got %v", err)
+	}
+}
+
+// TestKeyringStore_EmptyName tests that a name is required
+func TestKeyringStore_EmptyName(t *testing.T) {
+	keyring.MockInit()
+	if err := NewKeyringStore().Set("", "value"); err == nil {
+		t.Error("Expected error for empty secret name")
+	}
+}
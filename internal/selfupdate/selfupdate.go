@@ -0,0 +1,339 @@
+// Package selfupdate replaces the running goldfish binary with the latest
+// GitHub release. The release archive for this OS and CPU is downloaded,
+// checked against the release's checksums.txt (whose signature must verify
+// when trusted_keys: are configured), and the binary inside is swapped in
+// with a rename so a failed update never leaves a half-written executable.
+package selfupdate
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+const (
+	// DefaultRepository is the GitHub repository releases are fetched from
+	DefaultRepository = "danballance/goldfish"
+	// RepositoryEnvVar overrides the repository, e.g. for a fork or an
+	// internal mirror with its own builds
+	RepositoryEnvVar = "GOLDFISH_UPDATE_REPO"
+	// DefaultAPI is the GitHub REST API the latest release is looked up in
+	DefaultAPI = "https://api.github.com"
+	// ChecksumsFile is the release asset listing the SHA256 of every archive
+	// Its signature, when the release has one, is ChecksumsFile plus
+	// config.SignatureSuffix.
+	ChecksumsFile = "checksums.txt"
+)
+
+// maxArchiveSize caps how much is read from a release download
+const maxArchiveSize = 200 << 20
+
+// Release is a GitHub release
+type Release struct {
+	// Tag is the release's git tag, e.g. "v1.2.0"
+	Tag    string  `json:"tag_name"`
+	Assets []Asset `json:"assets"`
+}
+
+// Asset is a file attached to a release
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// Version returns the release's version without the tag's "v"
+func (r *Release) Version() string {
+	return strings.TrimPrefix(r.Tag, "v")
+}
+
+// asset returns the asset with the given name
+func (r *Release) asset(name string) (Asset, bool) {
+	for _, asset := range r.Assets {
+		if asset.Name == name {
+			return asset, true
+		}
+	}
+	return Asset{}, false
+}
+
+// Newer reports whether version latest is newer than current
+// Versions that cannot be compared (e.g. a development build) count as
+// older whenever they differ, so such builds can always be updated.
+func Newer(current, latest string) bool {
+	current, latest = strings.TrimPrefix(current, "v"), strings.TrimPrefix(latest, "v")
+	if result, ok := platform.CompareVersion(latest, current); ok {
+		return result > 0
+	}
+	return latest != current
+}
+
+// ArchiveName returns the name of the release archive for an OS and CPU, as
+// the release workflow builds them, e.g. goldfish_1.2.0_linux_amd64.tar.gz
+func ArchiveName(version, goos, goarch string) string {
+	name := fmt.Sprintf("goldfish_%s_%s_%s", version, goos, goarch)
+	if goos == "windows" {
+		return name + ".zip"
+	}
+	return name + ".tar.gz"
+}
+
+// Updater looks up and installs releases
+type Updater struct {
+	api        string
+	repository string
+	client     *http.Client
+	// trustedKeys, when set, require checksums.txt to be signed by one of them
+	trustedKeys []string
+}
+
+// NewUpdater creates an updater for the configured repository on GitHub
+// It trusts the trusted_keys: of the local configuration, as packs do.
+func NewUpdater() *Updater {
+	repository := os.Getenv(RepositoryEnvVar)
+	if repository == "" {
+		repository = DefaultRepository
+	}
+	return NewUpdaterFor(DefaultAPI, repository).TrustKeys(config.TrustedKeys())
+}
+
+// NewUpdaterFor creates an updater for an explicit API base URL and repository
+func NewUpdaterFor(api, repository string) *Updater {
+	return &Updater{
+		api:        strings.TrimSuffix(api, "/"),
+		repository: repository,
+		client:     &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+// TrustKeys makes the updater reject releases whose checksums are not signed by one of keys
+func (u *Updater) TrustKeys(keys []string) *Updater {
+	u.trustedKeys = keys
+	return u
+}
+
+// Latest returns the repository's latest release
+func (u *Updater) Latest() (*Release, error) {
+	url := fmt.Sprintf("%s/repos/%s/releases/latest", u.api, u.repository)
+	data, err := u.fetch(url, 1<<20)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up the latest release: %w", err)
+	}
+	var release Release
+	if err := json.Unmarshal(data, &release); err != nil {
+		return nil, fmt.Errorf("failed to parse the latest release: %w", err)
+	}
+	if release.Tag == "" {
+		return nil, fmt.Errorf("the latest release of %s has no tag", u.repository)
+	}
+	return &release, nil
+}
+
+// Download returns the goldfish binary of a release for an OS and CPU
+// The archive must match its line in checksums.txt, and checksums.txt its
+// signature when the updater has trusted keys.
+func (u *Updater) Download(release *Release, goos, goarch string) ([]byte, error) {
+	archiveName := ArchiveName(release.Version(), goos, goarch)
+	archive, ok := release.asset(archiveName)
+	if !ok {
+		return nil, fmt.Errorf("release %s has no build for %s/%s (%s)", release.Tag, goos, goarch, archiveName)
+	}
+	checksumsAsset, ok := release.asset(ChecksumsFile)
+	if !ok {
+		return nil, fmt.Errorf("release %s has no %s", release.Tag, ChecksumsFile)
+	}
+
+	checksums, err := u.fetch(checksumsAsset.URL, 1<<20)
+	if err != nil {
+		return nil, err
+	}
+	// Verify the signature before anything in the release is trusted
+	if len(u.trustedKeys) > 0 {
+		signatureAsset, ok := release.asset(ChecksumsFile + config.SignatureSuffix)
+		if !ok {
+			return nil, fmt.Errorf("%w: release %s has no signature", config.ErrUntrustedSignature, release.Tag)
+		}
+		signature, err := u.fetch(signatureAsset.URL, 1<<10)
+		if err != nil {
+			return nil, err
+		}
+		if err := config.VerifySignature(checksums, signature, u.trustedKeys); err != nil {
+			return nil, fmt.Errorf("release %s: %w", release.Tag, err)
+		}
+	}
+	expected, err := checksumFor(checksums, archiveName)
+	if err != nil {
+		return nil, fmt.Errorf("release %s: %w", release.Tag, err)
+	}
+
+	data, err := u.fetch(archive.URL, maxArchiveSize)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	if actual := hex.EncodeToString(sum[:]); actual != expected {
+		return nil, fmt.Errorf("checksum mismatch for %s: expected %s, got %s", archiveName, expected, actual)
+	}
+	return extractBinary(archiveName, data, binaryName(goos))
+}
+
+// Update downloads the latest-release binary for this system and replaces exe with it
+func (u *Updater) Update(release *Release, exe string) error {
+	binary, err := u.Download(release, runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		return err
+	}
+	return Replace(exe, binary)
+}
+
+// fetch downloads url, reading at most limit bytes
+func (u *Updater) fetch(url string, limit int64) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: %s", url, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", url, err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%s exceeds %d bytes", url, limit)
+	}
+	return data, nil
+}
+
+// checksumFor returns the SHA256 of a file from sha256sum output
+func checksumFor(checksums []byte, name string) (string, error) {
+	for _, line := range strings.Split(string(checksums), "\n") {
+		fields := strings.Fields(line)
+		// sha256sum marks files read in binary mode with a leading "*"
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("%s has no checksum for %s", ChecksumsFile, name)
+}
+
+// binaryName returns the file name of the goldfish executable on an OS
+func binaryName(goos string) string {
+	if goos == "windows" {
+		return "goldfish.exe"
+	}
+	return "goldfish"
+}
+
+// extractBinary returns the contents of the file named binary from a
+// .tar.gz or .zip archive; the release archives keep it in a directory
+func extractBinary(archiveName string, data []byte, binary string) ([]byte, error) {
+	if strings.HasSuffix(archiveName, ".zip") {
+		reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", archiveName, err)
+		}
+		for _, file := range reader.File {
+			if path.Base(file.Name) != binary || file.FileInfo().IsDir() {
+				continue
+			}
+			rc, err := file.Open()
+			if err != nil {
+				return nil, fmt.Errorf("failed to extract %s: %w", binary, err)
+			}
+			defer rc.Close()
+			return io.ReadAll(io.LimitReader(rc, maxArchiveSize))
+		}
+		return nil, fmt.Errorf("%s does not contain %s", archiveName, binary)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", archiveName, err)
+	}
+	defer gz.Close()
+	reader := tar.NewReader(gz)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%s does not contain %s", archiveName, binary)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", archiveName, err)
+		}
+		if header.Typeflag == tar.TypeReg && path.Base(header.Name) == binary {
+			return io.ReadAll(io.LimitReader(reader, maxArchiveSize))
+		}
+	}
+}
+
+// Replace atomically replaces the executable at exe with binary
+// The new binary is written beside exe and renamed over it, so exe is
+// always either the old or the new program. Windows cannot replace a
+// running executable, so there the old one is first moved to exe.old,
+// which the next update removes.
+func Replace(exe string, binary []byte) error {
+	info, err := os.Stat(exe)
+	if err != nil {
+		return fmt.Errorf("failed to find the goldfish executable: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(exe), ".goldfish-update-*")
+	if err != nil {
+		return fmt.Errorf("failed to write the new binary next to %s: %w", exe, err)
+	}
+	tmpPath := tmp.Name()
+	_, err = tmp.Write(binary)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpPath, info.Mode().Perm()|0111)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write the new binary: %w", err)
+	}
+
+	if runtime.GOOS == "windows" {
+		old := exe + ".old"
+		os.Remove(old)
+		if err := os.Rename(exe, old); err != nil {
+			os.Remove(tmpPath)
+			return fmt.Errorf("failed to move the old binary aside: %w", err)
+		}
+		if err := os.Rename(tmpPath, exe); err != nil {
+			os.Rename(old, exe)
+			os.Remove(tmpPath)
+			return fmt.Errorf("failed to install the new binary: %w", err)
+		}
+		return nil
+	}
+	if err := os.Rename(tmpPath, exe); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to install the new binary: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package selfupdate
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// tarGz returns a release-style .tar.gz with the binary in a directory
+func tarGz(t *testing.T, binary string, content []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: "goldfish_1.2.0_linux_amd64/README.md", Mode: 0644, Size: 2, Typeflag: tar.TypeReg})
+	tw.Write([]byte("hi"))
+	tw.WriteHeader(&tar.Header{Name: "goldfish_1.2.0_linux_amd64/" + binary, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg})
+	tw.Write(content)
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+// testRelease serves a latest release with a linux/amd64 archive and checksums
+// Files can be replaced or removed through the returned map before requests.
+func testRelease(t *testing.T, binary []byte) (*httptest.Server, map[string][]byte) {
+	t.Helper()
+	archive := tarGz(t, "goldfish", binary)
+	sum := sha256.Sum256(archive)
+	files := map[string][]byte{
+		"/goldfish_1.2.0_linux_amd64.tar.gz": archive,
+		"/checksums.txt":                     []byte(hex.EncodeToString(sum[:]) + "  goldfish_1.2.0_linux_amd64.tar.gz\n"),
+	}
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/repos/acme/goldfish/releases/latest" {
+			fmt.Fprint(w, `{"tag_name": "v1.2.0", "assets": [`)
+			first := true
+			for name := range files {
+				if !first {
+					fmt.Fprint(w, ",")
+				}
+				first = false
+				fmt.Fprintf(w, `{"name": %q, "browser_download_url": %q}`, strings.TrimPrefix(name, "/"), server.URL+name)
+			}
+			fmt.Fprint(w, "]}")
+			return
+		}
+		content, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(content)
+	}))
+	t.Cleanup(server.Close)
+	return server, files
+}
+
+// TestNewer tests comparing release versions
+func TestNewer(t *testing.T) {
+	testCases := []struct {
+		current, latest string
+		expected        bool
+	}{
+		{"0.1.0", "0.2.0", true},
+		{"0.1.0", "v0.1.0", false},
+		{"1.10.0", "1.9.0", false},
+		{"1.2.0", "1.2.1", true},
+		{"dev", "1.0.0", true},
+	}
+	for _, tc := range testCases {
+		if got := Newer(tc.current, tc.latest); got != tc.expected {
+			t.Errorf("Newer(%q, %q): expected %v, got %v", tc.current, tc.latest, tc.expected, got)
+		}
+	}
+}
+
+// TestArchiveName tests naming archives as the release workflow does
+func TestArchiveName(t *testing.T) {
+	if name := ArchiveName("1.2.0", "darwin", "arm64"); name != "goldfish_1.2.0_darwin_arm64.tar.gz" {
+		t.Errorf("Unexpected archive name %s", name)
+	}
+	if name := ArchiveName("1.2.0", "windows", "amd64"); name != "goldfish_1.2.0_windows_amd64.zip" {
+		t.Errorf("Unexpected archive name %s", name)
+	}
+}
+
+// TestUpdater_Download tests finding, verifying and extracting the binary of the latest release
+func TestUpdater_Download(t *testing.T) {
+	server, files := testRelease(t, []byte("new goldfish"))
+	updater := NewUpdaterFor(server.URL, "acme/goldfish")
+
+	release, err := updater.Latest()
+	if err != nil {
+		t.Fatalf("Latest() failed: %v", err)
+	}
+	if release.Version() != "1.2.0" {
+		t.Errorf("Expected version 1.2.0, got %s", release.Version())
+	}
+	binary, err := updater.Download(release, "linux", "amd64")
+	if err != nil || string(binary) != "new goldfish" {
+		t.Fatalf("Expected the binary, got %q (%v)", binary, err)
+	}
+
+	if _, err := updater.Download(release, "plan9", "amd64"); err == nil || !strings.Contains(err.Error(), "no build for plan9/amd64") {
+		t.Errorf("Expected a missing build to fail, got %v", err)
+	}
+
+	// A tampered archive fails the checksum
+	files["/goldfish_1.2.0_linux_amd64.tar.gz"] = tarGz(t, "goldfish", []byte("evil"))
+	if _, err := updater.Download(release, "linux", "amd64"); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("Expected a checksum mismatch, got %v", err)
+	}
+}
+
+// TestUpdater_Download_Signature tests that trusted keys require signed checksums
+func TestUpdater_Download_Signature(t *testing.T) {
+	server, files := testRelease(t, []byte("new goldfish"))
+	public, private, err := config.GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("GenerateKeyPair() failed: %v", err)
+	}
+	updater := NewUpdaterFor(server.URL, "acme/goldfish").TrustKeys([]string{public})
+
+	release, _ := updater.Latest()
+	if _, err := updater.Download(release, "linux", "amd64"); !errors.Is(err, config.ErrUntrustedSignature) {
+		t.Errorf("Expected an unsigned release to be refused, got %v", err)
+	}
+
+	signature, _ := config.Sign(files["/checksums.txt"], private)
+	files["/checksums.txt.sig"] = []byte(signature)
+	release, _ = updater.Latest()
+	if _, err := updater.Download(release, "linux", "amd64"); err != nil {
+		t.Errorf("Expected a signed release to download, got %v", err)
+	}
+
+	// The signature must be by a trusted key
+	otherPublic, _, _ := config.GenerateKeyPair()
+	updater.TrustKeys([]string{otherPublic})
+	if _, err := updater.Download(release, "linux", "amd64"); !errors.Is(err, config.ErrUntrustedSignature) {
+		t.Errorf("Expected a signature by another key to be refused, got %v", err)
+	}
+}
+
+// TestExtractBinary_Zip tests extracting the Windows binary from a zip archive
+func TestExtractBinary_Zip(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, _ := zw.Create("goldfish_1.2.0_windows_amd64/goldfish.exe")
+	w.Write([]byte("windows goldfish"))
+	zw.Close()
+
+	binary, err := extractBinary("goldfish_1.2.0_windows_amd64.zip", buf.Bytes(), "goldfish.exe")
+	if err != nil || string(binary) != "windows goldfish" {
+		t.Errorf("Expected the binary, got %q (%v)", binary, err)
+	}
+	if _, err := extractBinary("x.zip", buf.Bytes(), "goldfish"); err == nil {
+		t.Error("Expected an error for an archive without the binary")
+	}
+}
+
+// TestReplace tests replacing an executable, keeping it executable
+func TestReplace(t *testing.T) {
+	exe := filepath.Join(t.TempDir(), "goldfish")
+	if err := os.WriteFile(exe, []byte("old"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := Replace(exe, []byte("new")); err != nil {
+		t.Fatalf("Replace() failed: %v", err)
+	}
+	data, _ := os.ReadFile(exe)
+	info, _ := os.Stat(exe)
+	if string(data) != "new" || info.Mode().Perm()&0100 == 0 {
+		t.Errorf("Expected the new executable, got %q with mode %v", data, info.Mode())
+	}
+	entries, _ := os.ReadDir(filepath.Dir(exe))
+	if len(entries) > 2 {
+		t.Errorf("Expected no temporary files left behind, got %d entries", len(entries))
+	}
+
+	if err := Replace(filepath.Join(t.TempDir(), "missing"), []byte("new")); err == nil {
+		t.Error("Expected an error for a missing executable")
+	}
+}
@@ -0,0 +1,288 @@
+// Package settings reads and writes the user's goldfish settings: how
+// goldfish itself behaves (default timeout, colors, shell, confirmation,
+// history, notifications),
+// kept in settings.yml apart from the command definitions in commands.yml.
+// goldfish config get and set read and change them one key at a time.
+package settings
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// EnvVar names a settings file to use instead of the default location
+const EnvVar = "GOLDFISH_SETTINGS"
+
+// The values of the color setting
+const (
+	// ColorAuto colors errors and warnings on terminals (the default)
+	ColorAuto = "auto"
+	// ColorAlways colors them even when stderr is not a terminal
+	ColorAlways = "always"
+	// ColorNever never colors them, like --no-color
+	ColorNever = "never"
+)
+
+// The values of the confirm setting
+const (
+	// ConfirmNever runs commands without asking (the default)
+	ConfirmNever = "never"
+	// ConfirmDestructive asks before commands marked destructive
+	ConfirmDestructive = "destructive"
+	// ConfirmAlways asks before every command
+	ConfirmAlways = "always"
+)
+
+// The values of the history setting
+const (
+	// HistoryOn records every command run for goldfish history (the default)
+	HistoryOn = "on"
+	// HistoryOff records nothing
+	HistoryOff = "off"
+)
+
+// Settings are the user's preferences; the zero value means every default
+type Settings struct {
+	// Timeout limits how long commands may run (default 30s)
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// Color is ColorAuto, ColorAlways or ColorNever
+	Color string `yaml:"color,omitempty"`
+	// Shell is the program that runs templates written for sh, e.g. bash
+	Shell string `yaml:"shell,omitempty"`
+	// Confirm is ConfirmNever, ConfirmDestructive or ConfirmAlways
+	Confirm string `yaml:"confirm,omitempty"`
+	// History is HistoryOn or HistoryOff
+	History string `yaml:"history,omitempty"`
+	// NotifyWebhook is a URL --notify posts to, besides the desktop
+	// notification; $VARIABLES are expanded from the environment
+	NotifyWebhook string `yaml:"notify_webhook,omitempty"`
+}
+
+// Key is a setting that goldfish config get and set can read and change
+type Key struct {
+	// Name is the key as typed, e.g. "timeout"
+	Name string
+	// Description explains the setting and its values
+	Description string
+	// Default is shown when the setting is not set
+	Default string
+	get     func(s *Settings) string
+	set     func(s *Settings, value string) error
+}
+
+// Keys lists every setting in the order they are shown
+var Keys = []Key{
+	{
+		Name:        "timeout",
+		Description: "how long commands may run before they are stopped, e.g. 30s or 5m",
+		Default:     "30s",
+		get: func(s *Settings) string {
+			if s.Timeout == 0 {
+				return ""
+			}
+			return s.Timeout.String()
+		},
+		set: func(s *Settings, value string) error {
+			if value == "" {
+				s.Timeout = 0
+				return nil
+			}
+			timeout, err := time.ParseDuration(value)
+			if err != nil || timeout <= 0 {
+				return fmt.Errorf("timeout must be a positive duration such as 30s or 5m, got '%s'", value)
+			}
+			s.Timeout = timeout
+			return nil
+		},
+	},
+	{
+		Name:        "color",
+		Description: "color errors and warnings: auto (on terminals), always or never",
+		Default:     ColorAuto,
+		get:         func(s *Settings) string { return s.Color },
+		set: func(s *Settings, value string) error {
+			return setChoice(&s.Color, "color", value, ColorAuto, ColorAlways, ColorNever)
+		},
+	},
+	{
+		Name:        "shell",
+		Description: "the program that runs templates written for sh, e.g. bash",
+		Default:     "sh",
+		get:         func(s *Settings) string { return s.Shell },
+		set: func(s *Settings, value string) error {
+			if strings.ContainsAny(value, " \t") {
+				return fmt.Errorf("shell must be a program name or path, got '%s'", value)
+			}
+			s.Shell = value
+			return nil
+		},
+	},
+	{
+		Name:        "confirm",
+		Description: "ask before running commands: never, destructive (those marked destructive) or always",
+		Default:     ConfirmNever,
+		get:         func(s *Settings) string { return s.Confirm },
+		set: func(s *Settings, value string) error {
+			return setChoice(&s.Confirm, "confirm", value, ConfirmNever, ConfirmDestructive, ConfirmAlways)
+		},
+	},
+	{
+		Name:        "history",
+		Description: "record the commands run, for goldfish history and rerun: on or off",
+		Default:     HistoryOn,
+		get:         func(s *Settings) string { return s.History },
+		set: func(s *Settings, value string) error {
+			return setChoice(&s.History, "history", value, HistoryOn, HistoryOff)
+		},
+	},
+	{
+		Name:        "notify_webhook",
+		Description: "a webhook URL (e.g. a Slack incoming webhook) --notify also posts to, or $VARIABLE holding one",
+		Default:     "none",
+		get:         func(s *Settings) string { return s.NotifyWebhook },
+		set: func(s *Settings, value string) error {
+			// A URL taken from the environment is checked when it is used
+			if value != "" && !strings.Contains(value, "$") {
+				if err := config.ValidateWebhook(value); err != nil {
+					return fmt.Errorf("notify_webhook: %w", err)
+				}
+			}
+			s.NotifyWebhook = value
+			return nil
+		},
+	},
+}
+
+// setChoice sets a setting that takes one of a fixed set of values
+func setChoice(field *string, name, value string, choices ...string) error {
+	if value == "" {
+		*field = ""
+		return nil
+	}
+	for _, choice := range choices {
+		if value == choice {
+			*field = value
+			return nil
+		}
+	}
+	return fmt.Errorf("%s must be one of %s, got '%s'", name, strings.Join(choices, ", "), value)
+}
+
+// Lookup returns the key with the given name
+func Lookup(name string) (*Key, error) {
+	for i := range Keys {
+		if Keys[i].Name == name {
+			return &Keys[i], nil
+		}
+	}
+	names := make([]string, len(Keys))
+	for i, key := range Keys {
+		names[i] = key.Name
+	}
+	return nil, fmt.Errorf("unknown setting '%s' (settings: %s)", name, strings.Join(names, ", "))
+}
+
+// Get returns the key's value in s, or "" when it is not set
+func (k *Key) Get(s *Settings) string {
+	return k.get(s)
+}
+
+// Set changes the key's value in s; an empty value restores the default
+func (k *Key) Set(s *Settings, value string) error {
+	return k.set(s, value)
+}
+
+// TimeoutOr returns the timeout setting, or fallback when it is not set
+func (s *Settings) TimeoutOr(fallback time.Duration) time.Duration {
+	if s == nil || s.Timeout == 0 {
+		return fallback
+	}
+	return s.Timeout
+}
+
+// validate checks the values of settings read from a file
+func (s *Settings) validate() error {
+	for i := range Keys {
+		key := &Keys[i]
+		if err := key.Set(s, key.Get(s)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Path returns the settings file: $GOLDFISH_SETTINGS, or else settings.yml
+// beside the user's commands.yml (e.g. ~/.config/goldfish/settings.yml)
+func Path() (string, error) {
+	if path := os.Getenv(EnvVar); path != "" {
+		return path, nil
+	}
+	dir, err := config.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "settings.yml"), nil
+}
+
+// Load reads the settings file at Path
+func Load() (*Settings, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	return LoadFile(path)
+}
+
+// LoadFile reads a settings file; a missing file means every default
+func LoadFile(path string) (*Settings, error) {
+	settings := &Settings{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return settings, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read settings %s: %w", path, err)
+	}
+
+	// Unknown keys are rejected, so a typo does not silently do nothing
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(settings); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse settings %s: %w", path, err)
+	}
+	if err := settings.validate(); err != nil {
+		return nil, fmt.Errorf("settings %s: %w", path, err)
+	}
+	return settings, nil
+}
+
+// Save writes the settings to path, creating its directory if needed
+func (s *Settings) Save(path string) error {
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to encode settings: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	// Write to a temporary file first so a failed write never truncates the settings
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write settings: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write settings: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package settings
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestLoadFile tests reading settings, with defaults for a missing file
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	settings, err := LoadFile(filepath.Join(dir, "missing.yml"))
+	if err != nil || *settings != (Settings{}) {
+		t.Fatalf("Expected defaults for a missing file, got %+v (%v)", settings, err)
+	}
+
+	path := filepath.Join(dir, "settings.yml")
+	os.WriteFile(path, []byte("timeout: 5m\ncolor: never\nshell: bash\nconfirm: destructive\n"), 0644)
+	settings, err = LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() failed: %v", err)
+	}
+	expected := Settings{Timeout: 5 * time.Minute, Color: ColorNever, Shell: "bash", Confirm: ConfirmDestructive}
+	if *settings != expected {
+		t.Errorf("Expected %+v, got %+v", expected, *settings)
+	}
+
+	testCases := map[string]string{
+		"colour: never\n":  "field colour not found",
+		"confirm: maybe\n": "confirm must be one of",
+		"timeout: -1s\n":   "timeout must be a positive duration",
+		"shell: bash -x\n": "shell must be a program name",
+		"history: yes\n":   "history must be one of",
+	}
+	for content, expected := range testCases {
+		os.WriteFile(path, []byte(content), 0644)
+		if _, err := LoadFile(path); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("LoadFile() of %q: expected an error containing %q, got %v", content, expected, err)
+		}
+	}
+}
+
+// TestKey_Set tests changing and restoring settings by key
+func TestKey_Set(t *testing.T) {
+	var settings Settings
+	key, err := Lookup("timeout")
+	if err != nil {
+		t.Fatalf("Lookup() failed: %v", err)
+	}
+	if err := key.Set(&settings, "90s"); err != nil || key.Get(&settings) != "1m30s" {
+		t.Errorf("Expected timeout 1m30s, got %q (%v)", key.Get(&settings), err)
+	}
+	if settings.TimeoutOr(time.Second) != 90*time.Second {
+		t.Errorf("Expected TimeoutOr to return the setting, got %v", settings.TimeoutOr(time.Second))
+	}
+	if err := key.Set(&settings, ""); err != nil || settings.TimeoutOr(time.Second) != time.Second {
+		t.Errorf("Expected an empty value to restore the default (%v)", err)
+	}
+
+	webhook, _ := Lookup("notify_webhook")
+	if err := webhook.Set(&settings, "hooks.slack.com/services/T0"); err == nil {
+		t.Error("Expected a webhook that is not a URL to be rejected")
+	}
+	if err := webhook.Set(&settings, "$SLACK_WEBHOOK_URL"); err != nil || settings.NotifyWebhook != "$SLACK_WEBHOOK_URL" {
+		t.Errorf("Expected a webhook from the environment to be accepted (%v)", err)
+	}
+
+	if _, err := Lookup("colour"); err == nil || !strings.Contains(err.Error(), "timeout, color, shell, confirm") {
+		t.Errorf("Expected an unknown key to list the settings, got %v", err)
+	}
+}
+
+// TestSettings_Save tests that saved settings load back unchanged
+func TestSettings_Save(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "goldfish", "settings.yml")
+	settings := &Settings{Timeout: time.Minute, Confirm: ConfirmAlways}
+	if err := settings.Save(path); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+	loaded, err := LoadFile(path)
+	if err != nil || *loaded != *settings {
+		t.Errorf("Expected %+v back, got %+v (%v)", *settings, loaded, err)
+	}
+}
+
+// TestPath tests that GOLDFISH_SETTINGS overrides the settings location
+func TestPath(t *testing.T) {
+	t.Setenv(EnvVar, "/tmp/custom.yml")
+	if path, err := Path(); err != nil || path != "/tmp/custom.yml" {
+		t.Errorf("Expected the override, got %s (%v)", path, err)
+	}
+	t.Setenv(EnvVar, "")
+	t.Setenv("XDG_CONFIG_HOME", "/xdg")
+	if path, err := Path(); err != nil || path != filepath.Join("/xdg", "goldfish", "settings.yml") {
+		t.Errorf("Expected the default location, got %s (%v)", path, err)
+	}
+}
@@ -0,0 +1,186 @@
+package templatetest
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/docs"
+	"github.com/danballance/goldfish/internal/engine"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// Tests only check the platforms their authors thought of. Golden files
+// record what every command renders to on every platform it supports, one
+// YAML file per command, so that a refactor of shared templates shows up as
+// a diff. "goldfish test --update-golden" writes them; later runs compare.
+// Commands without tests are recorded with example values for their
+// parameters, as in the generated documentation.
+
+// DefaultGoldenDir is where golden files are kept unless --golden-dir says otherwise
+const DefaultGoldenDir = "goldens"
+
+// exampleCase names the case recorded for commands without tests
+const exampleCase = "example"
+
+// Golden is what a command renders to: command lines by case, then platform
+// A case that fails to render records "error: " and the error.
+type Golden map[string]map[string]string
+
+// GoldenPath returns the golden file of a command in dir
+func GoldenPath(dir, command string) string {
+	return filepath.Join(dir, command+".yml")
+}
+
+// Snapshot renders every case of a command on every platform it supports
+func (r *Runner) Snapshot(cmd *config.Command) Golden {
+	golden := make(Golden)
+	for _, p := range platform.NewDetector().GetSupportedPlatforms() {
+		if cmd.Plugin == "" && !engine.Supports(cmd, p) {
+			continue
+		}
+		record := func(name, rendered string, err error) {
+			if golden[name] == nil {
+				golden[name] = make(map[string]string)
+			}
+			if err != nil {
+				rendered = "error: " + err.Error()
+			}
+			golden[name][p.String()] = rendered
+		}
+
+		if len(cmd.Tests) == 0 {
+			rendered, err := r.engine.Preview(&engine.ExecutionContext{Command: cmd, Platform: p, Parameters: docs.ExampleParameters(cmd)})
+			record(exampleCase, rendered, err)
+		}
+		for _, test := range cmd.Tests {
+			rendered, err := r.Render(cmd, test, p)
+			record(test.Name, rendered, err)
+		}
+	}
+	return golden
+}
+
+// UpdateGolden writes the golden file of every command, or only of the one named
+// It returns the paths written.
+func (r *Runner) UpdateGolden(dir, only string) ([]string, error) {
+	commands, err := r.commands(only)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create golden directory: %w", err)
+	}
+
+	var written []string
+	for _, cmd := range commands {
+		data, err := yaml.Marshal(r.Snapshot(cmd))
+		if err != nil {
+			return written, fmt.Errorf("command '%s': %w", cmd.Name, err)
+		}
+		path := GoldenPath(dir, cmd.Name)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return written, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+// CheckGolden compares what commands render to with their golden files in dir
+// Every case and platform in either is a result; one only in the golden file
+// is reported as no longer rendered, and a command without a golden file
+// fails as a whole. Results are in the order of the commands, then of cases
+// and platforms by name.
+func (r *Runner) CheckGolden(dir, only string) ([]Result, error) {
+	commands, err := r.commands(only)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	for _, cmd := range commands {
+		path := GoldenPath(dir, cmd.Name)
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			results = append(results, Result{Command: cmd.Name, Test: "*", Platform: "*", Golden: true, Err: fmt.Errorf("no golden file %s; run with --update-golden", path)})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		var expected Golden
+		if err := yaml.Unmarshal(data, &expected); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		results = append(results, compareGolden(cmd.Name, expected, r.Snapshot(cmd))...)
+	}
+	return results, nil
+}
+
+// compareGolden returns a result for every case and platform in either golden
+func compareGolden(command string, expected, actual Golden) []Result {
+	cases := make(map[string]bool)
+	for name := range expected {
+		cases[name] = true
+	}
+	for name := range actual {
+		cases[name] = true
+	}
+
+	var results []Result
+	for _, name := range sortedKeys(cases) {
+		platforms := make(map[string]bool)
+		for p := range expected[name] {
+			platforms[p] = true
+		}
+		for p := range actual[name] {
+			platforms[p] = true
+		}
+		for _, p := range sortedKeys(platforms) {
+			result := Result{Command: command, Test: name, Platform: p, Golden: true}
+			want, recorded := expected[name][p]
+			got, rendered := actual[name][p]
+			switch {
+			case !recorded:
+				result.Err = fmt.Errorf("not in the golden file; run with --update-golden")
+			case !rendered:
+				result.Err = fmt.Errorf("no longer rendered")
+			default:
+				result.Expected, result.Rendered = want, got
+			}
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// commands returns the commands of the configuration, or only the one named
+func (r *Runner) commands(only string) ([]*config.Command, error) {
+	var commands []*config.Command
+	for i := range r.config.Commands {
+		cmd := &r.config.Commands[i]
+		if only == "" || cmd.Name == only || cmd.Alias == only {
+			commands = append(commands, cmd)
+		}
+	}
+	if only != "" && len(commands) == 0 {
+		return nil, &config.CommandNotFoundError{Name: only}
+	}
+	return commands, nil
+}
+
+// sortedKeys returns the keys of a set in order
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,97 @@
+package templatetest
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// TestRunner_Golden tests writing golden files and comparing with them
+func TestRunner_Golden(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{Commands: []config.Command{
+		{
+			Name:       "list",
+			Parameters: []config.Parameter{{Name: "path", Type: "string"}},
+			Platforms:  map[string]config.PlatformCommand{"linux": {Template: "ls {{.params.path}}"}},
+			Tests:      []config.TemplateTest{{Name: "basic", Args: []string{"src"}, Expect: map[string]string{"linux": "ls src"}}},
+		},
+		{
+			// Without tests, example values are recorded
+			Name:       "show",
+			Parameters: []config.Parameter{{Name: "file", Type: "string", Required: true}},
+			Platforms:  map[string]config.PlatformCommand{"linux": {Template: "cat {{.params.file}}"}, "windows": {Template: "type {{.params.file}}"}},
+		},
+	}}
+
+	written, err := NewRunner(cfg).UpdateGolden(dir, "")
+	if err != nil {
+		t.Fatalf("UpdateGolden() failed: %v", err)
+	}
+	if len(written) != 2 {
+		t.Fatalf("Expected 2 golden files, got %v", written)
+	}
+	data, err := os.ReadFile(GoldenPath(dir, "show"))
+	if err != nil {
+		t.Fatalf("Failed to read golden file: %v", err)
+	}
+	for _, expected := range []string{"example:", "linux: cat '<file>'", `windows: type "<file>"`} {
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("Expected the golden file to contain %q, got:\n%s", expected, data)
+		}
+	}
+
+	results, err := NewRunner(cfg).CheckGolden(dir, "")
+	if err != nil {
+		t.Fatalf("CheckGolden() failed: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %+v", results)
+	}
+	for _, result := range results {
+		if result.Failed() {
+			t.Errorf("%s: expected to match the golden file, got %q (%v)", result.Name(), result.Rendered, result.Err)
+		}
+	}
+
+	// A changed template, a new platform and a removed one differ from the golden files
+	cfg.Commands[1].Platforms = map[string]config.PlatformCommand{"linux": {Template: "cat -- {{.params.file}}"}, "darwin": {Template: "cat {{.params.file}}"}}
+	results, err = NewRunner(cfg).CheckGolden(dir, "show")
+	if err != nil {
+		t.Fatalf("CheckGolden() failed: %v", err)
+	}
+	expected := []struct {
+		name string
+		err  string
+	}{
+		{"show/example (darwin, golden)", "not in the golden file"},
+		{"show/example (linux, golden)", ""},
+		{"show/example (windows, golden)", "no longer rendered"},
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %d results, got %+v", len(expected), results)
+	}
+	for i, result := range results {
+		if result.Name() != expected[i].name || !result.Failed() {
+			t.Errorf("Expected %s to fail, got %s failed=%v", expected[i].name, result.Name(), result.Failed())
+		}
+		if expected[i].err != "" && (result.Err == nil || !strings.Contains(result.Err.Error(), expected[i].err)) {
+			t.Errorf("%s: expected an error containing %q, got %v", result.Name(), expected[i].err, result.Err)
+		}
+	}
+	if results[1].Expected != "cat '<file>'" || results[1].Rendered != "cat -- '<file>'" {
+		t.Errorf("Expected the golden and rendered command lines, got %q and %q", results[1].Expected, results[1].Rendered)
+	}
+
+	// A command without a golden file fails
+	os.Remove(GoldenPath(dir, "list"))
+	results, err = NewRunner(cfg).CheckGolden(dir, "list")
+	if err != nil {
+		t.Fatalf("CheckGolden() failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Err == nil || !strings.Contains(results[0].Err.Error(), "no golden file") {
+		t.Errorf("Expected a missing golden file to fail, got %+v", results)
+	}
+}
@@ -0,0 +1,124 @@
+// Package templatetest checks the tests: sections of commands. Each test is
+// an example invocation and the command line it should render to on each
+// platform; rendering runs nothing, so the tests of a shared commands.yml
+// can run in CI on any system.
+package templatetest
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/engine"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// placeholderSecrets stands in for the OS keyring while rendering tests
+// CI runners have no keyring, and a test must not depend on the secrets of
+// whoever runs it; every secret renders as a visible placeholder instead.
+type placeholderSecrets struct{}
+
+func (placeholderSecrets) Set(name, value string) error { return nil }
+func (placeholderSecrets) Delete(name string) error     { return nil }
+func (placeholderSecrets) Get(name string) (string, error) {
+	return "<secret:" + name + ">", nil
+}
+
+// Result is the outcome of one test on one platform
+type Result struct {
+	Command  string
+	Test     string
+	Platform string
+	// Expected is the command line the test expects, Rendered the one the
+	// template produced
+	Expected string
+	Rendered string
+	// Err is set when the parameters were rejected or the template failed
+	Err error
+	// Golden is set when the expected command line came from a golden file
+	Golden bool
+}
+
+// Failed reports whether the test failed on the platform
+func (r *Result) Failed() bool {
+	return r.Err != nil || r.Rendered != r.Expected
+}
+
+// Name identifies the test and platform, e.g. "replace/basic (linux)"
+func (r *Result) Name() string {
+	if r.Golden {
+		return fmt.Sprintf("%s/%s (%s, golden)", r.Command, r.Test, r.Platform)
+	}
+	return fmt.Sprintf("%s/%s (%s)", r.Command, r.Test, r.Platform)
+}
+
+// Runner renders command tests
+type Runner struct {
+	engine *engine.Engine
+	config *config.Config
+}
+
+// NewRunner creates a runner for the tests of cfg's commands
+// Templates may use the configuration's WASM helpers and compose its other
+// commands, as when they run.
+func NewRunner(cfg *config.Config) *Runner {
+	eng := engine.NewEngine(0)
+	eng.SetSecretStore(placeholderSecrets{})
+	eng.SetWasmModules(cfg.Wasm)
+	eng.SetCommands(cfg.Commands)
+	eng.SetGlobals(cfg.Vars)
+	return &Runner{engine: eng, config: cfg}
+}
+
+// Run renders the tests of every command, or only of the one named
+// Results are in the order of the commands and their tests, with the
+// platforms of each test in sorted order.
+func (r *Runner) Run(only string) ([]Result, error) {
+	commands, err := r.commands(only)
+	if err != nil {
+		return nil, err
+	}
+	var results []Result
+	for _, cmd := range commands {
+		for _, test := range cmd.Tests {
+			results = append(results, r.RunTest(cmd, test)...)
+		}
+	}
+	return results, nil
+}
+
+// RunTest renders one test on each platform it expects a command line for
+func (r *Runner) RunTest(cmd *config.Command, test config.TemplateTest) []Result {
+	platforms := make([]string, 0, len(test.Expect))
+	for name := range test.Expect {
+		platforms = append(platforms, name)
+	}
+	sort.Strings(platforms)
+
+	var results []Result
+	for _, name := range platforms {
+		result := Result{Command: cmd.Name, Test: test.Name, Platform: name, Expected: test.Expect[name]}
+		result.Rendered, result.Err = r.Render(cmd, test, platform.SupportedPlatform(name))
+		results = append(results, result)
+	}
+	return results
+}
+
+// Render returns the command line a test's invocation renders to on a platform
+// Named parameters are passed like flags, as in a batch job, so they are
+// converted to each parameter's type and computed defaults apply. Unlike a
+// flag, a misspelled name is an error rather than ignored.
+func (r *Runner) Render(cmd *config.Command, test config.TemplateTest, p platform.SupportedPlatform) (string, error) {
+	flags := make(map[string]interface{}, len(test.Params))
+	for name, value := range test.Params {
+		if _, known := cmd.FindParameter(name); !known {
+			return "", fmt.Errorf("unknown parameter '%s'", name)
+		}
+		flags["--"+name] = fmt.Sprint(value)
+	}
+	params, err := r.engine.ParseParameters(cmd, test.Args, flags)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse parameters: %w", err)
+	}
+	return r.engine.Preview(&engine.ExecutionContext{Command: cmd, Platform: p, Parameters: params})
+}
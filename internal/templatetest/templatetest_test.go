@@ -0,0 +1,80 @@
+package templatetest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// TestRunner_Run tests rendering command tests on each platform
+func TestRunner_Run(t *testing.T) {
+	cfg := &config.Config{Commands: []config.Command{{
+		Name:       "list",
+		Parameters: []config.Parameter{{Name: "path", Type: "string"}, {Name: "all", Type: "bool", Flag: "-a"}},
+		Platforms: map[string]config.PlatformCommand{
+			"linux":   {Template: "ls {{if .params.all}}-a {{end}}{{.params.path}}"},
+			"windows": {Template: "dir {{.params.path}}"},
+		},
+		Tests: []config.TemplateTest{
+			{Name: "passes", Args: []string{"my dir"}, Params: map[string]interface{}{"all": true}, Expect: map[string]string{
+				"linux":   "ls -a 'my dir'",
+				"windows": `dir "my dir"`,
+			}},
+			{Name: "differs", Args: []string{"src"}, Expect: map[string]string{"linux": "ls -l src"}},
+			{Name: "unknown parameter", Params: map[string]interface{}{"al": true}, Expect: map[string]string{"linux": "ls"}},
+			{Name: "unsupported", Expect: map[string]string{"darwin": "ls"}},
+		},
+	}}}
+
+	results, err := NewRunner(cfg).Run("")
+	if err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+	expected := []struct {
+		name   string
+		failed bool
+		err    string
+	}{
+		{"list/passes (linux)", false, ""},
+		{"list/passes (windows)", false, ""},
+		{"list/differs (linux)", true, ""},
+		{"list/unknown parameter (linux)", true, "unknown parameter 'al'"},
+		{"list/unsupported (darwin)", true, "darwin"},
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %d results, got %+v", len(expected), results)
+	}
+	for i, result := range results {
+		if result.Name() != expected[i].name || result.Failed() != expected[i].failed {
+			t.Errorf("Expected %s failed=%v, got %s failed=%v (%q, %v)", expected[i].name, expected[i].failed, result.Name(), result.Failed(), result.Rendered, result.Err)
+		}
+		if expected[i].err != "" && (result.Err == nil || !strings.Contains(result.Err.Error(), expected[i].err)) {
+			t.Errorf("%s: expected an error containing %q, got %v", result.Name(), expected[i].err, result.Err)
+		}
+	}
+
+	if _, err := NewRunner(cfg).Run("missing"); err == nil {
+		t.Errorf("Expected an error for an unknown command")
+	}
+}
+
+// TestRunner_Defaults tests that the tests of the embedded default commands pass
+func TestRunner_Defaults(t *testing.T) {
+	cfg, err := config.LoadDefaults()
+	if err != nil {
+		t.Fatalf("LoadDefaults() failed: %v", err)
+	}
+	results, err := NewRunner(cfg).Run("")
+	if err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+	if len(results) == 0 {
+		t.Fatalf("Expected the default commands to have tests")
+	}
+	for _, result := range results {
+		if result.Failed() {
+			t.Errorf("%s: expected %q, got %q (%v)", result.Name(), result.Expected, result.Rendered, result.Err)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+// Package trace times the phases of a goldfish run for --trace: loading the
+// configuration, generating commands, parsing parameters, rendering the
+// template and running the child process. It gives the numbers to look at
+// before optimizing startup for large configurations, and can also write a
+// CPU profile for go tool pprof.
+package trace
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime/pprof"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// The phases of a run, in the order they happen
+const (
+	PhaseConfig   = "config load"
+	PhaseGenerate = "command generation"
+	PhaseParse    = "parameter parsing"
+	PhaseRender   = "template render"
+	PhaseExecute  = "child execution"
+)
+
+// Phase is the time spent in one phase of a run
+type Phase struct {
+	// Name is one of the Phase constants
+	Name string
+	// Duration is the time spent in the phase, summed when it happened more
+	// than once (e.g. rendering for each retry)
+	Duration time.Duration
+	// Count is how many times the phase happened
+	Count int
+}
+
+// Recorder collects phase timings
+// A nil *Recorder records nothing, so callers need not check whether
+// tracing is on.
+type Recorder struct {
+	mu      sync.Mutex
+	started time.Time
+	phases  []Phase
+}
+
+// New creates a recorder; the total it reports is the time since New
+func New() *Recorder {
+	return &Recorder{started: time.Now()}
+}
+
+// Add records time spent in the named phase
+func (r *Recorder) Add(name string, duration time.Duration) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.phases {
+		if r.phases[i].Name == name {
+			r.phases[i].Duration += duration
+			r.phases[i].Count++
+			return
+		}
+	}
+	r.phases = append(r.phases, Phase{Name: name, Duration: duration, Count: 1})
+}
+
+// Start begins timing the named phase; calling the returned function ends it
+func (r *Recorder) Start(name string) func() {
+	if r == nil {
+		return func() {}
+	}
+	start := time.Now()
+	return func() { r.Add(name, time.Since(start)) }
+}
+
+// Phases returns the phases recorded, in the order they first happened
+func (r *Recorder) Phases() []Phase {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Phase{}, r.phases...)
+}
+
+// Write prints the phases with their share of the total time since New;
+// the rest of the total is goldfish's own work between the phases
+func (r *Recorder) Write(w io.Writer) error {
+	if r == nil {
+		return nil
+	}
+	total := time.Since(r.started)
+	fmt.Fprintln(w, "goldfish trace:")
+	writer := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, phase := range r.Phases() {
+		fmt.Fprintf(writer, "  %s\t%s\t%s", phase.Name, round(phase.Duration), percent(phase.Duration, total))
+		if phase.Count > 1 {
+			fmt.Fprintf(writer, "\t(%d times)", phase.Count)
+		}
+		fmt.Fprintln(writer)
+	}
+	fmt.Fprintf(writer, "  total\t%s\n", round(total))
+	return writer.Flush()
+}
+
+// round shortens a duration for display, e.g. 1.234ms
+func round(d time.Duration) time.Duration {
+	if d >= time.Millisecond {
+		return d.Round(time.Microsecond)
+	}
+	return d
+}
+
+// percent returns part's share of total, e.g. "12.5%"
+func percent(part, total time.Duration) string {
+	if total <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", float64(part)*100/float64(total))
+}
+
+// StartCPUProfile writes a CPU profile to path until the returned function
+// is called, for go tool pprof
+func StartCPUProfile(path string) (func() error, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CPU profile: %w", err)
+	}
+	if err := pprof.StartCPUProfile(file); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to start CPU profile: %w", err)
+	}
+	return func() error {
+		pprof.StopCPUProfile()
+		if err := file.Close(); err != nil {
+			return fmt.Errorf("failed to write CPU profile: %w", err)
+		}
+		return nil
+	}, nil
+}
@@ -0,0 +1,65 @@
+package trace
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRecorder tests summing phases and printing them with the total
+func TestRecorder(t *testing.T) {
+	recorder := New()
+	recorder.Add(PhaseConfig, 2*time.Millisecond)
+	recorder.Add(PhaseRender, time.Millisecond)
+	recorder.Add(PhaseRender, time.Millisecond)
+	stop := recorder.Start(PhaseExecute)
+	stop()
+
+	phases := recorder.Phases()
+	if len(phases) != 3 || phases[0].Name != PhaseConfig || phases[1].Duration != 2*time.Millisecond || phases[1].Count != 2 {
+		t.Fatalf("Unexpected phases: %+v", phases)
+	}
+
+	var out bytes.Buffer
+	if err := recorder.Write(&out); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	for _, expected := range []string{"goldfish trace:", "config load", "2ms", "template render", "(2 times)", "child execution", "total"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected %q in the trace, got:\n%s", expected, out.String())
+		}
+	}
+}
+
+// TestRecorder_Nil tests that a nil recorder records nothing
+func TestRecorder_Nil(t *testing.T) {
+	var recorder *Recorder
+	recorder.Add(PhaseConfig, time.Second)
+	recorder.Start(PhaseParse)()
+	var out bytes.Buffer
+	if err := recorder.Write(&out); err != nil || out.Len() != 0 || recorder.Phases() != nil {
+		t.Errorf("Expected nothing from a nil recorder, got %q (%v)", out.String(), err)
+	}
+}
+
+// TestStartCPUProfile tests writing a CPU profile
+func TestStartCPUProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cpu.out")
+	stop, err := StartCPUProfile(path)
+	if err != nil {
+		t.Fatalf("StartCPUProfile() failed: %v", err)
+	}
+	if err := stop(); err != nil {
+		t.Fatalf("stopping the profile failed: %v", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+		t.Errorf("Expected a profile to be written (%v)", err)
+	}
+
+	if _, err := StartCPUProfile(filepath.Join(t.TempDir(), "missing", "cpu.out")); err == nil {
+		t.Error("Expected an error for a directory that does not exist")
+	}
+}
@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// The command list is a fuzzy finder: typing narrows it to the commands whose
+// name and description contain the typed characters in order, as in fzf, so
+// "ffl" finds find-files. Matches on the name, at the start of words and in
+// runs of consecutive characters rank first.
+
+// fuzzyScore reports whether query's characters appear in text in order,
+// ignoring case, and how good the match is; higher is better
+// nameLength is how many leading characters of text are the command name.
+func fuzzyScore(query, text string, nameLength int) (int, bool) {
+	if query == "" {
+		return 0, true
+	}
+	wanted := []rune(strings.ToLower(query))
+	runes := []rune(strings.ToLower(text))
+	score, next, previous := 0, 0, -2
+	for i, r := range runes {
+		if next == len(wanted) {
+			break
+		}
+		if r != wanted[next] {
+			continue
+		}
+		points := 1
+		if i < nameLength {
+			points += 2
+		}
+		if i == 0 || !unicode.IsLetter(runes[i-1]) && !unicode.IsDigit(runes[i-1]) {
+			points += 3
+		}
+		if previous == i-1 {
+			points += 4
+		}
+		score += points
+		previous = i
+		next++
+	}
+	if next < len(wanted) {
+		return 0, false
+	}
+	return score, true
+}
+
+// filter returns the positions in commands of those matching m.query, best
+// first; ties keep the configuration's order
+func (m Model) filter() []int {
+	type match struct{ index, score int }
+	var matches []match
+	for i, cmd := range m.commands {
+		if score, ok := fuzzyScore(m.query, cmd.Name+" "+cmd.Description, len([]rune(cmd.Name))); ok {
+			matches = append(matches, match{i, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+
+	indexes := make([]int, len(matches))
+	for i, match := range matches {
+		indexes[i] = match.index
+	}
+	return indexes
+}
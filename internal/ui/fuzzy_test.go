@@ -0,0 +1,37 @@
+package ui
+
+import "testing"
+
+// TestFuzzyScore tests matching characters in order and ranking the matches
+func TestFuzzyScore(t *testing.T) {
+	testCases := []struct {
+		query, text string
+		matches     bool
+	}{
+		{"", "anything", true},
+		{"ffl", "find-files Search for files", true},
+		{"FF", "find-files", true},
+		{"fif", "find-files", true},
+		{"sz", "find-files", false},
+		{"for search", "find-files Search for files", false},
+		{"for files", "find-files Search for files", true},
+	}
+	for _, tc := range testCases {
+		if _, ok := fuzzyScore(tc.query, tc.text, 10); ok != tc.matches {
+			t.Errorf("%q in %q: expected match=%t", tc.query, tc.text, tc.matches)
+		}
+	}
+
+	// Consecutive characters at the start of a name beat scattered ones
+	prefix, _ := fuzzyScore("date", "date Show the date", 4)
+	scattered, _ := fuzzyScore("date", "delete-tags Remove tags", 11)
+	if prefix <= scattered {
+		t.Errorf("Expected the name prefix to rank first, got %d and %d", prefix, scattered)
+	}
+	// A match on the name beats one in the description
+	name, _ := fuzzyScore("greet", "greet Say hello", 5)
+	description, _ := fuzzyScore("greet", "hello Print a greeting", 5)
+	if name <= description {
+		t.Errorf("Expected the name to rank first, got %d and %d", name, description)
+	}
+}
@@ -0,0 +1,323 @@
+// Package ui provides goldfish's interactive terminal interface.
+// It lists the available commands in a fuzzy finder, shows a form for a
+// command's parameters with a live preview of the rendered command line, and
+// hands the chosen invocation back to the caller to execute once the user
+// confirms.
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/engine"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// screen identifies which view the UI is showing
+type screen int
+
+const (
+	// listScreen shows the list of commands
+	listScreen screen = iota
+	// formScreen shows the parameter form for the chosen command
+	formScreen
+)
+
+// Model is the Bubble Tea model for goldfish ui
+// Bubble Tea calls Update for every key press and View to redraw the screen
+type Model struct {
+	commands []config.Command
+	engine   *engine.Engine
+	platform platform.SupportedPlatform
+
+	screen screen
+	// query is what the user typed to filter the list (see fuzzy.go)
+	query string
+	// matches are the positions in commands of those matching query, best first
+	matches []int
+	// cursor is the highlighted entry of matches on the list screen
+	cursor int
+	// inputs holds one text field per parameter of the chosen command
+	inputs []textinput.Model
+	// focus is the index of the text field receiving key presses
+	focus int
+
+	// selected is set when the user confirms a command; the UI then exits
+	selected *engine.ExecutionContext
+}
+
+// New creates the UI model
+// Only listed commands with a template for the given platform are shown
+func New(cfg *config.Config, eng *engine.Engine, currentPlatform platform.SupportedPlatform) Model {
+	var commands []config.Command
+	for _, cmd := range cfg.Commands {
+		if engine.Supports(&cmd, currentPlatform) && cmd.Listed() {
+			commands = append(commands, cmd)
+		}
+	}
+	m := Model{
+		commands: commands,
+		engine:   eng,
+		platform: currentPlatform,
+	}
+	m.matches = m.filter()
+	return m
+}
+
+// Run shows the UI and returns the invocation the user confirmed
+// The result is nil if the user quit without choosing a command.
+func Run(cfg *config.Config, eng *engine.Engine, currentPlatform platform.SupportedPlatform) (*engine.ExecutionContext, error) {
+	final, err := tea.NewProgram(New(cfg, eng, currentPlatform)).Run()
+	if err != nil {
+		return nil, fmt.Errorf("terminal UI failed: %w", err)
+	}
+	return final.(Model).Selected(), nil
+}
+
+// Selected returns the confirmed invocation, or nil if there is none
+func (m Model) Selected() *engine.ExecutionContext {
+	return m.selected
+}
+
+// Init implements tea.Model; there is no initial work to do
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements tea.Model, handling key presses
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	if key.String() == "ctrl+c" {
+		return m, tea.Quit
+	}
+
+	if m.screen == listScreen {
+		return m.updateList(key)
+	}
+	return m.updateForm(key)
+}
+
+// updateList handles keys on the command list
+// Typed characters filter the list, so moving uses the arrows (or ctrl+p
+// and ctrl+n), and esc first clears the filter, then quits.
+func (m Model) updateList(key tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch key.String() {
+	case "esc":
+		if m.query == "" {
+			return m, tea.Quit
+		}
+		m.setQuery("")
+	case "up", "ctrl+p", "shift+tab":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "ctrl+n", "tab":
+		if m.cursor < len(m.matches)-1 {
+			m.cursor++
+		}
+	case "backspace":
+		if query := []rune(m.query); len(query) > 0 {
+			m.setQuery(string(query[:len(query)-1]))
+		}
+	case "enter":
+		if len(m.matches) == 0 {
+			return m, nil
+		}
+		m.openForm()
+		// Commands without parameters can be confirmed straight away
+		if len(m.inputs) == 0 {
+			return m.confirm()
+		}
+	default:
+		switch key.Type {
+		case tea.KeyRunes:
+			m.setQuery(m.query + string(key.Runes))
+		case tea.KeySpace:
+			m.setQuery(m.query + " ")
+		}
+	}
+	return m, nil
+}
+
+// setQuery changes the filter and moves the cursor back to the best match
+func (m *Model) setQuery(query string) {
+	m.query = query
+	m.matches = m.filter()
+	m.cursor = 0
+}
+
+// chosen returns the highlighted command
+func (m Model) chosen() config.Command {
+	return m.commands[m.matches[m.cursor]]
+}
+
+// openForm creates a text field for each parameter of the highlighted command
+func (m *Model) openForm() {
+	cmd := m.chosen()
+	m.screen = formScreen
+	m.focus = 0
+	m.inputs = make([]textinput.Model, len(cmd.Parameters))
+	for i, param := range cmd.Parameters {
+		input := textinput.New()
+		input.Prompt = ""
+		input.Placeholder = param.Type
+		if param.Default != nil {
+			input.Placeholder = fmt.Sprint(param.Default)
+		}
+		// Secret parameters are not echoed on screen
+		if param.Secret {
+			input.EchoMode = textinput.EchoPassword
+		}
+		m.inputs[i] = input
+	}
+	if len(m.inputs) > 0 {
+		m.inputs[0].Focus()
+	}
+}
+
+// updateForm handles keys on the parameter form
+func (m Model) updateForm(key tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch key.String() {
+	case "esc":
+		m.screen = listScreen
+		m.inputs = nil
+		return m, nil
+	case "enter":
+		return m.confirm()
+	case "tab", "down":
+		m.moveFocus(1)
+		return m, nil
+	case "shift+tab", "up":
+		m.moveFocus(-1)
+		return m, nil
+	}
+
+	// Everything else is typing into the focused field
+	var cmd tea.Cmd
+	m.inputs[m.focus], cmd = m.inputs[m.focus].Update(key)
+	return m, cmd
+}
+
+// moveFocus moves input focus by delta fields, wrapping around
+func (m *Model) moveFocus(delta int) {
+	m.inputs[m.focus].Blur()
+	m.focus = (m.focus + delta + len(m.inputs)) % len(m.inputs)
+	m.inputs[m.focus].Focus()
+}
+
+// confirm selects the current command if its parameters are valid
+func (m Model) confirm() (tea.Model, tea.Cmd) {
+	ctx, err := m.context()
+	if err != nil {
+		// The form shows the problem in its preview line
+		return m, nil
+	}
+	if _, err := m.engine.Preview(ctx); err != nil {
+		return m, nil
+	}
+	m.selected = ctx
+	return m, tea.Quit
+}
+
+// context builds an execution context from the form's current values
+func (m Model) context() (*engine.ExecutionContext, error) {
+	cmd := m.chosen()
+
+	// Filled-in fields are passed like flags; the engine converts them to each
+	// parameter's type and applies defaults for the rest
+	flags := make(map[string]interface{})
+	for i, param := range cmd.Parameters {
+		if value := m.inputs[i].Value(); value != "" {
+			flags["--"+param.Name] = value
+		}
+	}
+	params, err := m.engine.ParseParameters(&cmd, nil, flags)
+	if err != nil {
+		return nil, err
+	}
+
+	return &engine.ExecutionContext{
+		Command:    &cmd,
+		Platform:   m.platform,
+		Parameters: params,
+	}, nil
+}
+
+// preview returns the rendered command line, or the reason it cannot be rendered
+func (m Model) preview() string {
+	ctx, err := m.context()
+	if err != nil {
+		return "! " + err.Error()
+	}
+	rendered, err := m.engine.Preview(ctx)
+	if err != nil {
+		return "! " + err.Error()
+	}
+	return "$ " + rendered
+}
+
+// View implements tea.Model, drawing the current screen
+func (m Model) View() string {
+	if m.screen == formScreen {
+		return m.viewForm()
+	}
+	return m.viewList()
+}
+
+// viewList draws the command list
+func (m Model) viewList() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "goldfish commands for %s\n\n", m.platform)
+	fmt.Fprintf(&b, "search: %s\n\n", m.query)
+
+	switch {
+	case len(m.commands) == 0:
+		b.WriteString("  no commands are available on this platform\n")
+	case len(m.matches) == 0:
+		b.WriteString("  no commands match\n")
+	}
+	for i, index := range m.matches {
+		cmd := m.commands[index]
+		marker := "  "
+		if i == m.cursor {
+			marker = "> "
+		}
+		fmt.Fprintf(&b, "%s%-16s %s\n", marker, cmd.Name, cmd.Description)
+	}
+
+	b.WriteString("\ntype to filter • ↑/↓ move • enter select • esc clear/quit\n")
+	return b.String()
+}
+
+// viewForm draws the parameter form with the live preview
+func (m Model) viewForm() string {
+	cmd := m.chosen()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s - %s\n\n", cmd.Name, cmd.Description)
+
+	for i, param := range cmd.Parameters {
+		marker := "  "
+		if i == m.focus {
+			marker = "> "
+		}
+		label := param.Name
+		if param.Required {
+			label += "*"
+		}
+		fmt.Fprintf(&b, "%s%-16s %s\n", marker, label, m.inputs[i].View())
+		if param.Description != "" {
+			fmt.Fprintf(&b, "  %-16s %s\n", "", param.Description)
+		}
+	}
+
+	fmt.Fprintf(&b, "\n%s\n", m.preview())
+	b.WriteString("\ntab/↑/↓ move • enter run • esc back\n")
+	return b.String()
+}
@@ -0,0 +1,159 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/engine"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// testModel creates a UI model with two Linux commands and one Windows-only command
+func testModel() Model {
+	cfg := &config.Config{
+		Commands: []config.Command{
+			{
+				Name:        "greet",
+				Description: "Say hello",
+				BaseCommand: "echo",
+				Parameters: []config.Parameter{
+					{Name: "name", Type: "string", Required: true, Description: "who to greet"},
+					{Name: "loud", Type: "bool"},
+				},
+				Platforms: map[string]config.PlatformCommand{
+					"linux": {Template: "{{.base_command}} hello {{.params.name}}{{if .params.loud}}!{{end}}"},
+				},
+			},
+			{
+				Name:        "date",
+				Description: "Show the date",
+				BaseCommand: "date",
+				Platforms:   map[string]config.PlatformCommand{"linux": {Template: "date"}},
+			},
+			{
+				Name:        "winonly",
+				BaseCommand: "ver",
+				Platforms:   map[string]config.PlatformCommand{"windows": {Template: "ver"}},
+			},
+		},
+	}
+	return New(cfg, engine.NewEngine(time.Second), platform.Linux)
+}
+
+// press sends key presses to the model and returns the updated model
+func press(m Model, keys ...tea.KeyMsg) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+	for _, key := range keys {
+		var next tea.Model
+		next, cmd = m.Update(key)
+		m = next.(Model)
+	}
+	return m, cmd
+}
+
+// typeText converts text into rune key presses
+func typeText(text string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(text)}
+}
+
+var (
+	keyEnter = tea.KeyMsg{Type: tea.KeyEnter}
+	keyDown  = tea.KeyMsg{Type: tea.KeyDown}
+	keyTab   = tea.KeyMsg{Type: tea.KeyTab}
+	keyEsc   = tea.KeyMsg{Type: tea.KeyEsc}
+	keyBack  = tea.KeyMsg{Type: tea.KeyBackspace}
+)
+
+// TestModel_List tests that only commands for the platform are listed
+func TestModel_List(t *testing.T) {
+	view := testModel().View()
+
+	if !strings.Contains(view, "greet") || !strings.Contains(view, "date") {
+		t.Errorf("Expected platform commands to be listed, got:\n%s", view)
+	}
+	if strings.Contains(view, "winonly") {
+		t.Errorf("Expected windows-only command to be hidden, got:\n%s", view)
+	}
+}
+
+// TestModel_Form tests filling in parameters with a live preview
+func TestModel_Form(t *testing.T) {
+	m, _ := press(testModel(), keyEnter)
+
+	// The required parameter is reported until it is filled in
+	if !strings.Contains(m.View(), "! ") {
+		t.Errorf("Expected missing parameter to be reported, got:\n%s", m.View())
+	}
+
+	m, _ = press(m, typeText("world"), keyTab, typeText("true"))
+	if !strings.Contains(m.View(), "$ echo hello world!") {
+		t.Errorf("Expected live preview, got:\n%s", m.View())
+	}
+
+	// Enter confirms the command and quits the UI
+	m, cmd := press(m, keyEnter)
+	if cmd == nil || m.Selected() == nil {
+		t.Fatal("Expected command to be selected")
+	}
+	if m.Selected().Parameters["name"] != "world" || m.Selected().Parameters["loud"] != true {
+		t.Errorf("Unexpected parameters: %v", m.Selected().Parameters)
+	}
+}
+
+// TestModel_FormInvalid tests that an incomplete form cannot be confirmed
+func TestModel_FormInvalid(t *testing.T) {
+	m, _ := press(testModel(), keyEnter, keyEnter)
+	if m.Selected() != nil {
+		t.Error("Expected incomplete form not to be selected")
+	}
+
+	// Esc goes back to the list
+	m, _ = press(m, keyEsc)
+	if !strings.Contains(m.View(), "goldfish commands") {
+		t.Errorf("Expected list view after esc, got:\n%s", m.View())
+	}
+}
+
+// TestModel_NoParameters tests that commands without parameters run straight away
+func TestModel_NoParameters(t *testing.T) {
+	m, cmd := press(testModel(), keyDown, keyEnter)
+	if cmd == nil || m.Selected() == nil || m.Selected().Command.Name != "date" {
+		t.Errorf("Expected 'date' to be selected, got %+v", m.Selected())
+	}
+}
+
+// TestModel_Filter tests narrowing the list by typing
+func TestModel_Filter(t *testing.T) {
+	m, _ := press(testModel(), typeText("dt"))
+	if view := m.View(); !strings.Contains(view, "search: dt") || !strings.Contains(view, "date") || strings.Contains(view, "greet") {
+		t.Errorf("Expected only date to match, got:\n%s", view)
+	}
+
+	// The best match is highlighted, and enter picks it
+	m, cmd := press(m, keyEnter)
+	if cmd == nil || m.Selected() == nil || m.Selected().Command.Name != "date" {
+		t.Errorf("Expected 'date' to be selected, got %+v", m.Selected())
+	}
+
+	// Descriptions match too, and backspace widens the list again
+	m, _ = press(testModel(), typeText("hellx"))
+	if !strings.Contains(m.View(), "no commands match") {
+		t.Errorf("Expected no matches, got:\n%s", m.View())
+	}
+	m, _ = press(m, keyBack)
+	if view := m.View(); !strings.Contains(view, "greet") || strings.Contains(view, "date") {
+		t.Errorf("Expected greet to match its description, got:\n%s", view)
+	}
+
+	// Esc clears the filter before it quits
+	m, cmd = press(m, keyEsc)
+	if cmd != nil || m.query != "" || !strings.Contains(m.View(), "date") {
+		t.Errorf("Expected esc to clear the filter, got query %q", m.query)
+	}
+	if _, cmd = press(m, keyEsc); cmd == nil {
+		t.Error("Expected esc with an empty filter to quit")
+	}
+}
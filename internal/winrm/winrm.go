@@ -0,0 +1,509 @@
+// Package winrm runs commands on remote Windows hosts over WinRM, the
+// WS-Management protocol that PowerShell Remoting is built on.
+// It implements just enough of the protocol for goldfish: open a remote shell,
+// run one command line in it, stream its output back and collect the exit code.
+//
+// Only Basic authentication is supported. It has to be enabled on the host
+// (winrm set winrm/config/service/auth @{Basic="true"}) and should be used
+// with the HTTPS listener on port 5986, so the password is not sent in clear.
+package winrm
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+const (
+	// DefaultHTTPSPort and DefaultHTTPPort are the ports of WinRM's listeners
+	DefaultHTTPSPort = "5986"
+	DefaultHTTPPort  = "5985"
+	// DefaultOperationTimeout is how long the host holds a request for output
+	// before answering it empty; the client then simply asks again
+	DefaultOperationTimeout = 60 * time.Second
+	// cleanupTimeout bounds the requests that stop a command and close its
+	// shell after the command has been cancelled
+	cleanupTimeout = 10 * time.Second
+)
+
+// WS-Management actions and URIs used by the client
+const (
+	actionCreate  = "http://schemas.xmlsoap.org/ws/2004/09/transfer/Create"
+	actionDelete  = "http://schemas.xmlsoap.org/ws/2004/09/transfer/Delete"
+	actionCommand = "http://schemas.microsoft.com/wbem/wsman/1/windows/shell/Command"
+	actionReceive = "http://schemas.microsoft.com/wbem/wsman/1/windows/shell/Receive"
+	actionSignal  = "http://schemas.microsoft.com/wbem/wsman/1/windows/shell/Signal"
+	// resourceShell is the cmd.exe shell that commands run in
+	resourceShell = "http://schemas.microsoft.com/wbem/wsman/1/windows/shell/cmd"
+	// signalTerminate stops a running command
+	signalTerminate = "http://schemas.microsoft.com/wbem/wsman/1/windows/shell/signal/terminate"
+	// stateDone is the suffix of the state of a command that has exited
+	stateDone = "/CommandState/Done"
+	// timedOutCode is the WSManFault code of a Receive that had no output to return
+	timedOutCode = "2150858793"
+)
+
+// Client runs commands on one Windows host
+type Client struct {
+	// Endpoint is the WS-Management URL, e.g. https://server01:5986/wsman
+	Endpoint string
+	// User and Password are the credentials of a local account on the host
+	User     string
+	Password string
+	// HTTPClient sends the requests; nil means http.DefaultClient
+	HTTPClient *http.Client
+	// OperationTimeout is how long the host may hold a request; zero means
+	// DefaultOperationTimeout
+	OperationTimeout time.Duration
+}
+
+// NewClient creates a client for the host at address (see ParseAddress)
+// The user may be given in the address ("admin@server01") or as user, which
+// takes precedence. With insecure, the host's TLS certificate is not verified:
+// WinRM's HTTPS listener often uses a self-signed one.
+func NewClient(address, user, password string, insecure bool) (*Client, error) {
+	endpoint, addressUser, err := ParseAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	if user == "" {
+		user = addressUser
+	}
+	if user == "" {
+		return nil, fmt.Errorf("no user for WinRM host '%s'; give it as user@host", address)
+	}
+
+	client := &Client{Endpoint: endpoint, User: user, Password: password}
+	if insecure {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+		client.HTTPClient = &http.Client{Transport: transport}
+	}
+	return client, nil
+}
+
+// ParseAddress turns a host address into a WS-Management endpoint and user
+// The address may be a bare host ("server01"), include a user and port
+// ("admin@server01:5986") or be a full URL ("http://server01:5985/wsman").
+// HTTPS is the default; the port defaults to that of the scheme's listener
+// and the path to /wsman. Passwords are not accepted in addresses, which end
+// up in shell history and process lists.
+func ParseAddress(address string) (string, string, error) {
+	if address == "" {
+		return "", "", fmt.Errorf("empty WinRM host address")
+	}
+	raw := address
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid WinRM host address '%s': %w", address, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", "", fmt.Errorf("invalid WinRM host address '%s': scheme must be http or https", address)
+	}
+	if u.Hostname() == "" {
+		return "", "", fmt.Errorf("invalid WinRM host address '%s': no host", address)
+	}
+
+	var user string
+	if u.User != nil {
+		if _, hasPassword := u.User.Password(); hasPassword {
+			return "", "", fmt.Errorf("WinRM host address '%s' must not contain a password", u.Redacted())
+		}
+		user = u.User.Username()
+		u.User = nil
+	}
+	if u.Port() == "" {
+		port := DefaultHTTPSPort
+		if u.Scheme == "http" {
+			port = DefaultHTTPPort
+		}
+		u.Host = u.Host + ":" + port
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/wsman"
+	}
+	return u.String(), user, nil
+}
+
+// CommandLine returns what runs on the host for a template's command and shell
+// Remote shells are cmd.exe, which is also the default. PowerShell scripts
+// are passed to powershell.exe (always installed, unlike pwsh) as
+// -EncodedCommand, so no quoting of the script can go wrong on the way.
+func CommandLine(command, shell string) (string, error) {
+	switch shell {
+	case "", "cmd":
+		return command, nil
+	case "powershell":
+		return "powershell.exe -NoProfile -NonInteractive -EncodedCommand " + encodeCommand(command), nil
+	default:
+		return "", fmt.Errorf("shell '%s' is not available on Windows hosts", shell)
+	}
+}
+
+// encodeCommand encodes a script for powershell -EncodedCommand: base64 of UTF-16LE
+func encodeCommand(script string) string {
+	units := utf16.Encode([]rune(script))
+	encoded := make([]byte, 2*len(units))
+	for i, unit := range units {
+		binary.LittleEndian.PutUint16(encoded[2*i:], unit)
+	}
+	return base64.StdEncoding.EncodeToString(encoded)
+}
+
+// Run runs a command line on the host, as in CommandLine, and returns its exit code
+// Its output is written to stdout and stderr as it arrives. When ctx is done
+// the command is terminated on the host and ctx's error is returned.
+func (c *Client) Run(ctx context.Context, command, shell string, stdout, stderr io.Writer) (int, error) {
+	commandLine, err := CommandLine(command, shell)
+	if err != nil {
+		return -1, err
+	}
+
+	shellID, err := c.createShell(ctx)
+	if err != nil {
+		return -1, err
+	}
+	// The shell is closed even when the command was cancelled
+	defer c.cleanup(func(ctx context.Context) error { return c.deleteShell(ctx, shellID) })
+
+	commandID, err := c.startCommand(ctx, shellID, commandLine)
+	if err != nil {
+		return -1, err
+	}
+
+	// Collect output until the command is done; each request waits up to the
+	// operation timeout for new output
+	for {
+		exitCode, done, err := c.receive(ctx, shellID, commandID, stdout, stderr)
+		if ctx.Err() != nil {
+			c.cleanup(func(ctx context.Context) error { return c.signal(ctx, shellID, commandID, signalTerminate) })
+			return -1, ctx.Err()
+		}
+		if err != nil {
+			return -1, err
+		}
+		if done {
+			return exitCode, nil
+		}
+	}
+}
+
+// cleanup runs a request that must be sent even though the command's context may be done
+func (c *Client) cleanup(request func(ctx context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+	defer cancel()
+	// Failing to clean up leaves the host to close the shell when it idles out
+	_ = request(ctx)
+}
+
+// createShell opens a cmd.exe shell on the host and returns its ID
+func (c *Client) createShell(ctx context.Context) (string, error) {
+	response, err := c.send(ctx, request{
+		action: actionCreate,
+		options: []option{
+			{"WINRS_NOPROFILE", "FALSE"},
+			// Output is decoded as UTF-8
+			{"WINRS_CODEPAGE", "65001"},
+		},
+		body: "<rsp:Shell><rsp:InputStreams>stdin</rsp:InputStreams><rsp:OutputStreams>stdout stderr</rsp:OutputStreams></rsp:Shell>",
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to open a shell on %s: %w", c.host(), err)
+	}
+	// Hosts name the new shell in a selector; some also describe the shell
+	shellID := response.Body.ResourceCreated.Selector
+	if shellID == "" {
+		shellID = response.Body.Shell.ShellID
+	}
+	if shellID == "" {
+		return "", fmt.Errorf("failed to open a shell on %s: no shell ID in the response", c.host())
+	}
+	return shellID, nil
+}
+
+// startCommand starts a command line in a shell and returns the command's ID
+func (c *Client) startCommand(ctx context.Context, shellID, commandLine string) (string, error) {
+	response, err := c.send(ctx, request{
+		action:  actionCommand,
+		shellID: shellID,
+		options: []option{
+			{"WINRS_CONSOLEMODE_STDIN", "TRUE"},
+			// Run through cmd /c, so pipes and redirects work as in a local shell
+			{"WINRS_SKIP_CMD_SHELL", "FALSE"},
+		},
+		body: "<rsp:CommandLine><rsp:Command>" + escape(commandLine) + "</rsp:Command></rsp:CommandLine>",
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to start the command on %s: %w", c.host(), err)
+	}
+	commandID := response.Body.CommandResponse.CommandID
+	if commandID == "" {
+		return "", fmt.Errorf("failed to start the command on %s: no command ID in the response", c.host())
+	}
+	return commandID, nil
+}
+
+// receive writes the output the command has produced since the last call
+// done reports whether the command has exited, with exitCode as its code.
+func (c *Client) receive(ctx context.Context, shellID, commandID string, stdout, stderr io.Writer) (exitCode int, done bool, err error) {
+	response, err := c.send(ctx, request{
+		action:  actionReceive,
+		shellID: shellID,
+		body:    `<rsp:Receive><rsp:DesiredStream CommandId="` + escape(commandID) + `">stdout stderr</rsp:DesiredStream></rsp:Receive>`,
+	})
+	var fault *Fault
+	if errors.As(err, &fault) && fault.timedOut() {
+		// No output within the operation timeout; the command is still running
+		return 0, false, nil
+	}
+	if err != nil {
+		return -1, false, fmt.Errorf("failed to read the command's output from %s: %w", c.host(), err)
+	}
+
+	received := response.Body.ReceiveResponse
+	for _, stream := range received.Streams {
+		data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(stream.Data))
+		if err != nil {
+			return -1, false, fmt.Errorf("invalid %s output from %s: %w", stream.Name, c.host(), err)
+		}
+		w := stdout
+		if stream.Name == "stderr" {
+			w = stderr
+		}
+		if _, err := w.Write(data); err != nil {
+			return -1, false, err
+		}
+	}
+	state := received.CommandState
+	if state == nil || !strings.HasSuffix(state.State, stateDone) {
+		return 0, false, nil
+	}
+	return state.ExitCode, true, nil
+}
+
+// signal sends a signal such as signalTerminate to a running command
+func (c *Client) signal(ctx context.Context, shellID, commandID, code string) error {
+	_, err := c.send(ctx, request{
+		action:  actionSignal,
+		shellID: shellID,
+		body:    `<rsp:Signal CommandId="` + escape(commandID) + `"><rsp:Code>` + code + `</rsp:Code></rsp:Signal>`,
+	})
+	return err
+}
+
+// deleteShell closes a shell, and with it any command still running in it
+func (c *Client) deleteShell(ctx context.Context, shellID string) error {
+	_, err := c.send(ctx, request{action: actionDelete, shellID: shellID})
+	return err
+}
+
+// host returns the host name of the endpoint, for error messages
+func (c *Client) host() string {
+	if u, err := url.Parse(c.Endpoint); err == nil && u.Hostname() != "" {
+		return u.Hostname()
+	}
+	return c.Endpoint
+}
+
+// request is one WS-Management request to the shell resource
+type request struct {
+	action string
+	// shellID selects the shell the request is about; empty when creating one
+	shellID string
+	options []option
+	// body is the XML content of the SOAP body
+	body string
+}
+
+// option is a WS-Management option, e.g. the code page of a new shell
+type option struct {
+	name  string
+	value string
+}
+
+// envelope is the part of a response the client reads
+// encoding/xml matches elements by local name here, whatever their prefix.
+type envelope struct {
+	Body struct {
+		Fault           *Fault `xml:"Fault"`
+		ResourceCreated struct {
+			Selector string `xml:"ReferenceParameters>SelectorSet>Selector"`
+		} `xml:"ResourceCreated"`
+		Shell struct {
+			ShellID string `xml:"ShellId"`
+		} `xml:"Shell"`
+		CommandResponse struct {
+			CommandID string `xml:"CommandId"`
+		} `xml:"CommandResponse"`
+		ReceiveResponse struct {
+			Streams      []stream      `xml:"Stream"`
+			CommandState *commandState `xml:"CommandState"`
+		} `xml:"ReceiveResponse"`
+	} `xml:"Body"`
+}
+
+// stream is a chunk of base64-encoded output
+type stream struct {
+	Name string `xml:"Name,attr"`
+	Data string `xml:",chardata"`
+}
+
+// commandState reports whether a command is still running, and its exit code once done
+type commandState struct {
+	State    string `xml:"State,attr"`
+	ExitCode int    `xml:"ExitCode"`
+}
+
+// Fault is an error reported by the host, such as an unknown shell or access denied
+type Fault struct {
+	// Subcode is the SOAP fault subcode, e.g. "w:TimedOut"
+	Subcode string `xml:"Code>Subcode>Value"`
+	// Reason is the host's description of the fault
+	Reason string `xml:"Reason>Text"`
+	Detail struct {
+		WSManFault struct {
+			// Code is the Windows error code, as a decimal number
+			Code    string `xml:"Code,attr"`
+			Message string `xml:"Message"`
+		} `xml:"WSManFault"`
+	} `xml:"Detail"`
+}
+
+// Error describes the fault with the most specific message the host gave
+func (f *Fault) Error() string {
+	message := strings.TrimSpace(f.Detail.WSManFault.Message)
+	if message == "" {
+		message = strings.TrimSpace(f.Reason)
+	}
+	if message == "" {
+		message = f.Subcode
+	}
+	if f.Detail.WSManFault.Code != "" {
+		return fmt.Sprintf("WinRM fault %s: %s", f.Detail.WSManFault.Code, message)
+	}
+	return "WinRM fault: " + message
+}
+
+// timedOut reports whether the fault only says that no output arrived in time
+func (f *Fault) timedOut() bool {
+	return f.Detail.WSManFault.Code == timedOutCode || strings.HasSuffix(f.Subcode, "TimedOut")
+}
+
+// send posts a request to the endpoint and decodes the response
+// Faults reported by the host are returned as *Fault errors.
+func (c *Client) send(ctx context.Context, r request) (*envelope, error) {
+	messageID, err := newMessageID()
+	if err != nil {
+		return nil, err
+	}
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Endpoint, strings.NewReader(c.envelope(r, messageID)))
+	if err != nil {
+		return nil, err
+	}
+	httpRequest.Header.Set("Content-Type", "application/soap+xml;charset=UTF-8")
+	httpRequest.SetBasicAuth(c.User, c.Password)
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	httpResponse, err := httpClient.Do(httpRequest)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResponse.Body.Close()
+	data, err := io.ReadAll(httpResponse.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if httpResponse.StatusCode == http.StatusUnauthorized {
+		return nil, fmt.Errorf("access denied for user '%s' (is Basic authentication enabled on the host?)", c.User)
+	}
+	// Faults come with status 500, so the body is decoded before the status is checked
+	var response envelope
+	decodeErr := xml.Unmarshal(data, &response)
+	if decodeErr == nil && response.Body.Fault != nil {
+		return nil, response.Body.Fault
+	}
+	if httpResponse.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected HTTP status %s", httpResponse.Status)
+	}
+	if decodeErr != nil {
+		return nil, fmt.Errorf("invalid response: %w", decodeErr)
+	}
+	return &response, nil
+}
+
+// envelope renders a request as a SOAP envelope
+func (c *Client) envelope(r request, messageID string) string {
+	timeout := c.OperationTimeout
+	if timeout <= 0 {
+		timeout = DefaultOperationTimeout
+	}
+
+	var b bytes.Buffer
+	b.WriteString(`<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope"` +
+		` xmlns:a="http://schemas.xmlsoap.org/ws/2004/08/addressing"` +
+		` xmlns:w="http://schemas.dmtf.org/wbem/wsman/1/wsman.xsd"` +
+		` xmlns:p="http://schemas.microsoft.com/wbem/wsman/1/wsman.xsd"` +
+		` xmlns:rsp="http://schemas.microsoft.com/wbem/wsman/1/windows/shell">`)
+	b.WriteString("<s:Header>")
+	b.WriteString("<a:To>" + escape(c.Endpoint) + "</a:To>")
+	b.WriteString(`<a:ReplyTo><a:Address s:mustUnderstand="true">http://schemas.xmlsoap.org/ws/2004/08/addressing/role/anonymous</a:Address></a:ReplyTo>`)
+	b.WriteString(`<w:MaxEnvelopeSize s:mustUnderstand="true">153600</w:MaxEnvelopeSize>`)
+	b.WriteString("<a:MessageID>" + messageID + "</a:MessageID>")
+	b.WriteString(`<w:Locale xml:lang="en-US" s:mustUnderstand="false"/>`)
+	b.WriteString(`<p:DataLocale xml:lang="en-US" s:mustUnderstand="false"/>`)
+	fmt.Fprintf(&b, "<w:OperationTimeout>PT%dS</w:OperationTimeout>", int(timeout.Seconds()))
+	b.WriteString(`<w:ResourceURI s:mustUnderstand="true">` + resourceShell + "</w:ResourceURI>")
+	b.WriteString(`<a:Action s:mustUnderstand="true">` + r.action + "</a:Action>")
+	if r.shellID != "" {
+		b.WriteString(`<w:SelectorSet><w:Selector Name="ShellId">` + escape(r.shellID) + "</w:Selector></w:SelectorSet>")
+	}
+	if len(r.options) > 0 {
+		b.WriteString("<w:OptionSet>")
+		for _, o := range r.options {
+			b.WriteString(`<w:Option Name="` + o.name + `">` + escape(o.value) + "</w:Option>")
+		}
+		b.WriteString("</w:OptionSet>")
+	}
+	b.WriteString("</s:Header>")
+	b.WriteString("<s:Body>" + r.body + "</s:Body>")
+	b.WriteString("</s:Envelope>")
+	return b.String()
+}
+
+// escape escapes text for use in XML content and attribute values
+func escape(text string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(text))
+	return b.String()
+}
+
+// newMessageID returns a random message ID in the uuid: form WS-Addressing uses
+func newMessageID() (string, error) {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", fmt.Errorf("failed to generate a message ID: %w", err)
+	}
+	// Mark it as a random (version 4) UUID
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+	return fmt.Sprintf("uuid:%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:16]), nil
+}
@@ -0,0 +1,238 @@
+package winrm
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf16"
+)
+
+// fakeHost is a WinRM host that runs one command with canned output
+type fakeHost struct {
+	mu sync.Mutex
+	// actions records the action of every request, in order
+	actions []string
+	// command is the command line the client asked to run
+	command string
+	// receives are the bodies of successive Receive responses
+	receives []string
+}
+
+var (
+	actionPattern  = regexp.MustCompile(`<a:Action[^>]*>([^<]+)</a:Action>`)
+	commandPattern = regexp.MustCompile(`<rsp:Command>([^<]*)</rsp:Command>`)
+)
+
+// ServeHTTP answers a request the way a Windows host would
+func (h *fakeHost) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	user, password, _ := r.BasicAuth()
+	if user != "admin" || password != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	data, _ := io.ReadAll(r.Body)
+	body := string(data)
+	action := actionPattern.FindStringSubmatch(body)[1]
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.actions = append(h.actions, action)
+
+	var reply string
+	switch action {
+	case actionCreate:
+		reply = `<x:ResourceCreated xmlns:x="http://schemas.xmlsoap.org/ws/2004/09/transfer"><a:ReferenceParameters><w:SelectorSet><w:Selector Name="ShellId">SHELL-1</w:Selector></w:SelectorSet></a:ReferenceParameters></x:ResourceCreated>`
+	case actionCommand:
+		if !strings.Contains(body, "SHELL-1") {
+			w.WriteHeader(http.StatusInternalServerError)
+			reply = fault("", "The request for the Windows Remote Shell with ShellId unknown failed.")
+			break
+		}
+		h.command = commandPattern.FindStringSubmatch(body)[1]
+		reply = `<rsp:CommandResponse><rsp:CommandId>COMMAND-1</rsp:CommandId></rsp:CommandResponse>`
+	case actionReceive:
+		reply = h.receives[0]
+		if len(h.receives) > 1 {
+			h.receives = h.receives[1:]
+		}
+		if strings.Contains(reply, "Fault") {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}
+	io.WriteString(w, `<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope" xmlns:a="http://schemas.xmlsoap.org/ws/2004/08/addressing" xmlns:w="http://schemas.dmtf.org/wbem/wsman/1/wsman.xsd" xmlns:rsp="http://schemas.microsoft.com/wbem/wsman/1/windows/shell"><s:Header/><s:Body>`+reply+`</s:Body></s:Envelope>`)
+}
+
+// fault renders a SOAP fault body
+func fault(code, message string) string {
+	return `<s:Fault><s:Code><s:Value>s:Receiver</s:Value><s:Subcode><s:Value>w:InternalError</s:Value></s:Subcode></s:Code>` +
+		`<s:Reason><s:Text>` + message + `</s:Text></s:Reason>` +
+		`<s:Detail><f:WSManFault xmlns:f="http://schemas.microsoft.com/wbem/wsman/1/wsmanfault" Code="` + code + `"><f:Message>` + message + `</f:Message></f:WSManFault></s:Detail></s:Fault>`
+}
+
+// output renders a Receive response with a chunk of output
+func output(name, text string, exitCode int, done bool) string {
+	reply := `<rsp:ReceiveResponse><rsp:Stream Name="` + name + `" CommandId="COMMAND-1">` + base64.StdEncoding.EncodeToString([]byte(text)) + `</rsp:Stream>`
+	state := "Running"
+	if done {
+		state = "Done"
+	}
+	reply += `<rsp:CommandState CommandId="COMMAND-1" State="http://schemas.microsoft.com/wbem/wsman/1/windows/shell/CommandState/` + state + `">`
+	if done {
+		reply += `<rsp:ExitCode>` + string(rune('0'+exitCode)) + `</rsp:ExitCode>`
+	}
+	return reply + `</rsp:CommandState></rsp:ReceiveResponse>`
+}
+
+// TestParseAddress tests turning host addresses into endpoints
+func TestParseAddress(t *testing.T) {
+	testCases := []struct {
+		address  string
+		endpoint string
+		user     string
+	}{
+		{"server01", "https://server01:5986/wsman", ""},
+		{"admin@server01", "https://server01:5986/wsman", "admin"},
+		{"server01:443", "https://server01:443/wsman", ""},
+		{"http://server01", "http://server01:5985/wsman", ""},
+		{"https://admin@10.0.0.5:5986/custom", "https://10.0.0.5:5986/custom", "admin"},
+		{"[::1]", "https://[::1]:5986/wsman", ""},
+	}
+	for _, tc := range testCases {
+		endpoint, user, err := ParseAddress(tc.address)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.address, err)
+			continue
+		}
+		if endpoint != tc.endpoint || user != tc.user {
+			t.Errorf("%s: expected %s as '%s', got %s as '%s'", tc.address, tc.endpoint, tc.user, endpoint, user)
+		}
+	}
+
+	for _, address := range []string{"", "ftp://server01", "admin:hunter2@server01"} {
+		if _, _, err := ParseAddress(address); err == nil {
+			t.Errorf("%q: expected an error", address)
+		}
+	}
+	// The password is not repeated in the error
+	if _, _, err := ParseAddress("admin:hunter2@server01"); err != nil && strings.Contains(err.Error(), "hunter2") {
+		t.Errorf("Expected the password to be redacted, got %v", err)
+	}
+}
+
+// TestCommandLine tests running templates in the shell they were written for
+func TestCommandLine(t *testing.T) {
+	if line, err := CommandLine("dir /b", ""); err != nil || line != "dir /b" {
+		t.Errorf("Expected cmd templates to run as they are, got %q (%v)", line, err)
+	}
+
+	line, err := CommandLine(`Get-ChildItem "C:\Program Files"`, "powershell")
+	if err != nil {
+		t.Fatalf("CommandLine() failed: %v", err)
+	}
+	encoded, found := strings.CutPrefix(line, "powershell.exe -NoProfile -NonInteractive -EncodedCommand ")
+	if !found {
+		t.Fatalf("Expected powershell.exe with an encoded command, got %q", line)
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatalf("Invalid base64: %v", err)
+	}
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(data[2*i:])
+	}
+	if script := string(utf16.Decode(units)); script != `Get-ChildItem "C:\Program Files"` {
+		t.Errorf("Expected the script to round-trip, got %q", script)
+	}
+
+	if _, err := CommandLine("ls", "sh"); err == nil {
+		t.Error("Expected an error for sh on a Windows host")
+	}
+}
+
+// TestClient_Run tests running a command and streaming its output
+func TestClient_Run(t *testing.T) {
+	host := &fakeHost{receives: []string{
+		output("stdout", "hello ", 0, false),
+		// The host answers with a timeout fault when there was no output for a while
+		fault(timedOutCode, "The WS-Management service cannot complete the operation within the time specified in OperationTimeout."),
+		output("stderr", "warning\n", 0, false),
+		output("stdout", "world\n", 3, true),
+	}}
+	server := httptest.NewServer(host)
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "admin", "secret", false)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	var stdout, stderr strings.Builder
+	exitCode, err := client.Run(context.Background(), "echo hello & echo <world>", "cmd", &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+	if exitCode != 3 {
+		t.Errorf("Expected exit code 3, got %d", exitCode)
+	}
+	if stdout.String() != "hello world\n" || stderr.String() != "warning\n" {
+		t.Errorf("Unexpected output: %q, %q", stdout.String(), stderr.String())
+	}
+	// The command line arrives escaped, and is run as written
+	if host.command != "echo hello &amp; echo &lt;world&gt;" {
+		t.Errorf("Unexpected command line: %q", host.command)
+	}
+	// The shell is closed afterwards
+	if last := host.actions[len(host.actions)-1]; last != actionDelete {
+		t.Errorf("Expected the shell to be deleted, got %v", host.actions)
+	}
+}
+
+// TestClient_Run_Cancel tests that a cancelled command is terminated on the host
+func TestClient_Run_Cancel(t *testing.T) {
+	host := &fakeHost{receives: []string{output("stdout", "", 0, false)}}
+	server := httptest.NewServer(host)
+	defer server.Close()
+
+	client := &Client{Endpoint: server.URL, User: "admin", Password: "secret"}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := client.Run(ctx, "ping -t localhost", "", io.Discard, io.Discard); err != context.DeadlineExceeded {
+		t.Fatalf("Expected the deadline error, got %v", err)
+	}
+
+	host.mu.Lock()
+	defer host.mu.Unlock()
+	actions := strings.Join(host.actions, " ")
+	if !strings.Contains(actions, actionSignal) || !strings.HasSuffix(actions, actionDelete) {
+		t.Errorf("Expected the command to be terminated and the shell deleted, got %v", host.actions)
+	}
+}
+
+// TestClient_Run_Errors tests reporting authentication failures and faults
+func TestClient_Run_Errors(t *testing.T) {
+	host := &fakeHost{}
+	server := httptest.NewServer(host)
+	defer server.Close()
+
+	client := &Client{Endpoint: server.URL, User: "admin", Password: "wrong"}
+	_, err := client.Run(context.Background(), "dir", "", io.Discard, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "access denied for user 'admin'") {
+		t.Errorf("Expected an access denied error, got %v", err)
+	}
+
+	// A fault from the host is reported with its message
+	var f Fault
+	f.Detail.WSManFault.Code = "5"
+	f.Detail.WSManFault.Message = "Access is denied."
+	if f.Error() != "WinRM fault 5: Access is denied." {
+		t.Errorf("Unexpected fault message: %q", f.Error())
+	}
+}
@@ -0,0 +1,171 @@
+// Package workflow runs named workflows: goldfish commands composed into a DAG.
+// Steps run in dependency order, share variables, and may be skipped by a
+// condition or because a step they depend on did not succeed.
+package workflow
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/danballance/goldfish/internal/batch"
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// Step statuses exposed to conditions as {{.steps.<name>.status}}
+const (
+	StatusOK     = "ok"
+	StatusFailed = "failed"
+	// StatusSkipped means the step's if: condition was false
+	StatusSkipped = "skipped"
+	// StatusBlocked means a step it depends on failed or was itself blocked
+	StatusBlocked = "blocked"
+)
+
+// Runner runs workflows, executing each step as a batch job
+type Runner struct {
+	jobs *batch.Runner
+}
+
+// NewRunner creates a workflow runner that executes steps with the given batch runner
+func NewRunner(jobs *batch.Runner) *Runner {
+	return &Runner{jobs: jobs}
+}
+
+// Run executes the workflow's steps in dependency order
+// vars override the workflow's own variables (e.g. from --var on the command line).
+// It returns one result per step, in execution order.
+func (r *Runner) Run(workflow *config.Workflow, vars map[string]string) ([]batch.Result, error) {
+	steps, err := workflow.StepOrder()
+	if err != nil {
+		return nil, fmt.Errorf("workflow '%s': %w", workflow.Name, err)
+	}
+
+	// Shared variables: workflow defaults overridden by the caller
+	shared := make(map[string]string, len(workflow.Vars)+len(vars))
+	for name, value := range workflow.Vars {
+		shared[name] = value
+	}
+	for name, value := range vars {
+		shared[name] = value
+	}
+
+	// stepData records finished steps for use in later templates
+	stepData := make(map[string]map[string]interface{}, len(steps))
+	data := map[string]interface{}{
+		"vars":  shared,
+		"steps": stepData,
+	}
+
+	results := make([]batch.Result, 0, len(steps))
+	for _, step := range steps {
+		result, status := r.runStep(step, stepData, data)
+		results = append(results, result)
+		stepData[step.Name] = map[string]interface{}{
+			"status":    status,
+			"exit_code": result.ExitCode,
+		}
+	}
+
+	return results, nil
+}
+
+// runStep runs one step unless its dependencies or condition prevent it
+// It returns the step's result and its status for later conditions
+func (r *Runner) runStep(step config.WorkflowStep, stepData map[string]map[string]interface{}, data map[string]interface{}) (batch.Result, string) {
+	skipped := batch.Result{Job: batch.Job{Name: step.Name, Command: step.Command}, ExitCode: -1, Skipped: true}
+
+	// Dependencies must not have failed; a dependency skipped by its condition still counts
+	for _, dep := range step.DependsOn {
+		switch stepData[dep]["status"] {
+		case StatusFailed, StatusBlocked:
+			return skipped, StatusBlocked
+		}
+	}
+
+	// Evaluate the step's condition
+	if step.If != "" {
+		run, err := EvaluateCondition(step.If, data)
+		if err != nil {
+			return batch.Result{Job: skipped.Job, ExitCode: -1, Err: fmt.Errorf("if: %w", err)}, StatusFailed
+		}
+		if !run {
+			return skipped, StatusSkipped
+		}
+	}
+
+	// Render templated arguments and parameters
+	job, err := RenderJob(step, data)
+	if err != nil {
+		return batch.Result{Job: skipped.Job, ExitCode: -1, Err: err}, StatusFailed
+	}
+
+	result := r.jobs.RunJob(job)
+	if result.Failed() {
+		return result, StatusFailed
+	}
+	return result, StatusOK
+}
+
+// RenderJob converts a step into a batch job, rendering its templates
+func RenderJob(step config.WorkflowStep, data map[string]interface{}) (batch.Job, error) {
+	job := batch.Job{Name: step.Name, Command: step.Command}
+
+	for _, arg := range step.Args {
+		rendered, err := Render(arg, data)
+		if err != nil {
+			return job, fmt.Errorf("argument %q: %w", arg, err)
+		}
+		job.Args = append(job.Args, rendered)
+	}
+
+	if len(step.Params) > 0 {
+		job.Params = make(map[string]interface{}, len(step.Params))
+		for name, value := range step.Params {
+			// Only text values can contain templates; others are passed through
+			if text, ok := value.(string); ok {
+				rendered, err := Render(text, data)
+				if err != nil {
+					return job, fmt.Errorf("parameter '%s': %w", name, err)
+				}
+				value = rendered
+			}
+			job.Params[name] = value
+		}
+	}
+
+	return job, nil
+}
+
+// EvaluateCondition renders an if: template and interprets the result as a boolean
+// Empty output, "false", "0" and "no" are false; anything else is true
+func EvaluateCondition(condition string, data map[string]interface{}) (bool, error) {
+	rendered, err := Render(condition, data)
+	if err != nil {
+		return false, err
+	}
+	if value, err := strconv.ParseBool(rendered); err == nil {
+		return value, nil
+	}
+	switch strings.ToLower(rendered) {
+	case "", "no":
+		return false, nil
+	}
+	return true, nil
+}
+
+// Render executes a template string against the workflow data
+// Unknown variables are errors, so typos in variable names are caught
+func Render(text string, data map[string]interface{}) (string, error) {
+	tmpl, err := template.New("workflow").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to execute template: %w", err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
@@ -0,0 +1,183 @@
+package workflow
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danballance/goldfish/internal/batch"
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/engine"
+	"github.com/danballance/goldfish/internal/platform"
+)
+
+// testRunner creates a workflow runner with a "note" command that appends a
+// message to a file and a "fail" command that always fails
+func testRunner(t *testing.T) *Runner {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+
+	posix := func(template string) map[string]config.PlatformCommand {
+		return map[string]config.PlatformCommand{
+			"linux":  {Template: template},
+			"darwin": {Template: template},
+		}
+	}
+	cfg := &config.Config{
+		Commands: []config.Command{
+			{
+				Name:        "note",
+				BaseCommand: "echo",
+				Parameters: []config.Parameter{
+					{Name: "msg", Type: "string", Required: true},
+					{Name: "file", Type: "string", Required: true},
+				},
+				Platforms: posix("echo {{.params.msg}} >> {{.params.file}}"),
+			},
+			{Name: "fail", BaseCommand: "sh", Platforms: posix("exit 1")},
+		},
+	}
+
+	eng := engine.NewEngine(5 * time.Second)
+	jobs := batch.NewRunner(cfg, eng, platform.SupportedPlatform(runtime.GOOS), 5*time.Second)
+	return NewRunner(jobs)
+}
+
+// noteStep builds a step that appends msg to the file named by the "out" variable
+func noteStep(name, msg string, dependsOn ...string) config.WorkflowStep {
+	return config.WorkflowStep{
+		Name:      name,
+		Command:   "note",
+		Params:    map[string]interface{}{"msg": msg, "file": "{{.vars.out}}"},
+		DependsOn: dependsOn,
+	}
+}
+
+// readNotes returns the lines written by note steps
+func readNotes(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatalf("Failed to read notes: %v", err)
+	}
+	return strings.Join(strings.Fields(string(data)), ",")
+}
+
+// TestRunner_Run tests dependency order and shared variables
+func TestRunner_Run(t *testing.T) {
+	runner := testRunner(t)
+	out := filepath.Join(t.TempDir(), "notes.txt")
+
+	workflow := &config.Workflow{
+		Name: "release",
+		Vars: map[string]string{"out": "/nonexistent/overridden", "version": "1.0"},
+		Steps: []config.WorkflowStep{
+			noteStep("package", "package-{{.vars.version}}", "build", "test"),
+			noteStep("build", "build"),
+			noteStep("test", "test", "build"),
+		},
+	}
+
+	results, err := runner.Run(workflow, map[string]string{"out": out})
+	if err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+	if batch.CountFailures(results) != 0 {
+		t.Errorf("Expected no failures, got %+v", results)
+	}
+	if got := readNotes(t, out); got != "build,test,package-1.0" {
+		t.Errorf("Unexpected execution order: %s", got)
+	}
+}
+
+// TestRunner_Run_Conditions tests if: conditions and blocked dependents
+func TestRunner_Run_Conditions(t *testing.T) {
+	runner := testRunner(t)
+	out := filepath.Join(t.TempDir(), "notes.txt")
+
+	workflow := &config.Workflow{
+		Name: "deploy",
+		Vars: map[string]string{"out": out, "env": "dev"},
+		Steps: []config.WorkflowStep{
+			{Name: "check", Command: "fail"},
+			noteStep("deploy", "deploy", "check"),
+			noteStep("after-deploy", "after", "deploy"),
+			noteStep("prod-only", "prod"),
+			noteStep("cleanup", "cleanup", "prod-only"),
+			noteStep("report", "report"),
+		},
+	}
+	workflow.Steps[3].If = `{{eq .vars.env "prod"}}`
+	workflow.Steps[5].If = `{{eq .steps.check.status "failed"}}`
+
+	results, err := runner.Run(workflow, nil)
+	if err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+
+	// The failed check blocks deploy and, transitively, after-deploy; the
+	// condition-skipped prod-only step does not block cleanup
+	if got := readNotes(t, out); got != "cleanup,report" {
+		t.Errorf("Unexpected steps run: %s", got)
+	}
+	skipped := 0
+	for _, result := range results {
+		if result.Skipped {
+			skipped++
+		}
+	}
+	if skipped != 3 {
+		t.Errorf("Expected 3 skipped steps, got %d", skipped)
+	}
+}
+
+// TestRunner_Run_UnknownVariable tests that template errors fail the step
+func TestRunner_Run_UnknownVariable(t *testing.T) {
+	runner := testRunner(t)
+
+	workflow := &config.Workflow{
+		Name:  "typo",
+		Steps: []config.WorkflowStep{noteStep("note", "{{.vars.mesage}}")},
+	}
+
+	results, err := runner.Run(workflow, map[string]string{"out": filepath.Join(t.TempDir(), "x")})
+	if err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+	if results[0].Err == nil {
+		t.Error("Expected error for unknown variable")
+	}
+}
+
+// TestEvaluateCondition tests interpretation of rendered conditions
+func TestEvaluateCondition(t *testing.T) {
+	data := map[string]interface{}{"vars": map[string]string{"flag": "yes", "empty": ""}}
+
+	testCases := []struct {
+		condition string
+		expected  bool
+	}{
+		{"true", true},
+		{"false", false},
+		{"0", false},
+		{"{{.vars.flag}}", true},
+		{"{{.vars.empty}}", false},
+		{`{{eq .vars.flag "yes"}}`, true},
+	}
+
+	for _, tc := range testCases {
+		got, err := EvaluateCondition(tc.condition, data)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tc.condition, err)
+			continue
+		}
+		if got != tc.expected {
+			t.Errorf("%s: expected %v, got %v", tc.condition, tc.expected, got)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package goldfish
+
+import (
+	"context"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/danballance/goldfish/internal/cli"
+	"github.com/danballance/goldfish/internal/config"
+)
+
+// NewCommandTree returns a Cobra command with one subcommand per configured command
+// It lets another CLI mount goldfish commands under its own, e.g.:
+//
+//	tree, err := goldfish.NewCommandTree(cfg, goldfish.Options{Program: "acme tools"})
+//	tree.Use = "tools"
+//	acmeCmd.AddCommand(tree)
+//
+// Commands write to opts.Stdout and opts.Stderr, or when those are unset to
+// the Cobra command's own output (see cobra.Command.SetOut). Every run is
+// checked against opts.Policy, and a command exiting non-zero returns an
+// *ExitError rather than ending the process.
+func NewCommandTree(cfg *Config, opts Options) (*cobra.Command, error) {
+	gf, err := New(cfg, opts)
+	if err != nil {
+		return nil, err
+	}
+	return gf.CommandTree(), nil
+}
+
+// CommandTree returns the Cobra command tree for the engine's configuration
+// See NewCommandTree.
+func (e *Engine) CommandTree() *cobra.Command {
+	program := e.options.Program
+	if program == "" {
+		program = "goldfish"
+	}
+	fields := strings.Fields(program)
+
+	root := &cobra.Command{
+		Use:   fields[len(fields)-1],
+		Short: "Cross-platform command unification",
+	}
+	for i := range e.config.Commands {
+		root.AddCommand(cli.NewCommand(&e.config.Commands[i], e.options.Platform, program, e.runCobra))
+	}
+	return root
+}
+
+// runCobra runs a command from the tree once Cobra has parsed its flags
+func (e *Engine) runCobra(cmd *config.Command, cobraCmd *cobra.Command, args []string) error {
+	stdout, stderr := e.options.Stdout, e.options.Stderr
+	if stdout == nil {
+		stdout = cobraCmd.OutOrStdout()
+	}
+	if stderr == nil {
+		stderr = cobraCmd.ErrOrStderr()
+	}
+
+	// Cancelling the context the host ran the tree with stops the command
+	ctx := cobraCmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	exitCode, err := e.run(ctx, cmd, args, cli.FlagValues(cmd, cobraCmd), stdout, stderr)
+	if err != nil {
+		return err
+	}
+	if exitCode != 0 {
+		return &ExitError{Command: cmd.Name, Code: exitCode}
+	}
+	return nil
+}
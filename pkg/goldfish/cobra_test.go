@@ -0,0 +1,90 @@
+package goldfish
+
+import (
+	"bytes"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// TestNewCommandTree tests running commands through a mounted command tree
+func TestNewCommandTree(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a Unix shell")
+	}
+	cfg, err := ParseConfig([]byte(testConfig + `  - name: "fail"
+    description: "Exit with a code"
+    base_command: "sh"
+    destructive: true
+    params:
+      - name: "code"
+        type: "int"
+    platforms:
+      linux:
+        template: "exit {{.params.code}}"
+`))
+	if err != nil {
+		t.Fatalf("ParseConfig failed: %v", err)
+	}
+
+	var stdout bytes.Buffer
+	tree, err := NewCommandTree(cfg, Options{Platform: Linux, Stdout: &stdout, Program: "acme tools"})
+	if err != nil {
+		t.Fatalf("NewCommandTree failed: %v", err)
+	}
+	if tree.Use != "tools" {
+		t.Errorf("Expected the tree to be named after the last word of Program, got %q", tree.Use)
+	}
+
+	// Flags and positional arguments are parsed like in the goldfish CLI
+	tree.SetArgs([]string{"greet", "--name", "tree", "--times", "2"})
+	if err := tree.Execute(); err != nil {
+		t.Fatalf("greet failed: %v", err)
+	}
+	if strings.TrimSpace(stdout.String()) != "hello tree x2" {
+		t.Errorf("Unexpected output: %q", stdout.String())
+	}
+
+	// Examples name the host program
+	greet, _, err := tree.Find([]string{"hi"})
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if !strings.Contains(greet.Example, "acme tools greet <name>") || !strings.Contains(greet.Example, "acme tools hi <name>") {
+		t.Errorf("Expected examples for the host program, got %q", greet.Example)
+	}
+
+	// A failing command is reported, not exited
+	tree.SetArgs([]string{"fail", "--code", "3"})
+	tree.SilenceErrors, tree.SilenceUsage = true, true
+	tree.SetOut(&bytes.Buffer{})
+	var exitErr *ExitError
+	if err := tree.Execute(); !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+		t.Errorf("Expected ExitError with code 3, got %v", err)
+	}
+}
+
+// TestEngine_Policy tests refusing commands with an execution policy
+func TestEngine_Policy(t *testing.T) {
+	var seen string
+	gf := newTestEngine(t, Options{Policy: func(cmd *Command, commandLine string) error {
+		seen = commandLine
+		return errors.New("not today")
+	}})
+
+	exitCode, err := gf.Run("greet", []string{"x"}, nil)
+	if err == nil || err.Error() != "not today" || exitCode != -1 {
+		t.Errorf("Expected the policy to refuse the command, got %d, %v", exitCode, err)
+	}
+	if seen != "echo hello x x1" {
+		t.Errorf("Expected the policy to see the command line, got %q", seen)
+	}
+
+	if err := DenyDestructive(&Command{Name: "rm", Destructive: true}, "rm -rf /"); err == nil {
+		t.Error("Expected DenyDestructive to refuse a destructive command")
+	}
+	if err := DenyDestructive(&Command{Name: "ls"}, "ls"); err != nil {
+		t.Errorf("Expected DenyDestructive to allow other commands, got %v", err)
+	}
+}
@@ -0,0 +1,334 @@
+// Package goldfish lets other Go programs load goldfish command definitions,
+// render them for the current platform and run them.
+//
+// It is the stable public face of the packages under internal/, which may
+// change at any time. A typical embedding looks like:
+//
+//	cfg, err := goldfish.LoadConfig()
+//	if err != nil { ... }
+//	gf, err := goldfish.New(cfg, goldfish.Options{Stdout: &buf})
+//	if err != nil { ... }
+//	exitCode, err := gf.Run("find-files", []string{"."}, map[string]interface{}{"name": "*.go"})
+package goldfish
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/danballance/goldfish/internal/config"
+	"github.com/danballance/goldfish/internal/engine"
+	"github.com/danballance/goldfish/internal/platform"
+	"github.com/danballance/goldfish/internal/secrets"
+)
+
+// The configuration types are shared with the goldfish CLI
+// They are aliases, so values can be passed to and from either freely.
+type (
+	// Config is a complete set of command definitions
+	Config = config.Config
+	// Command is one command definition
+	Command = config.Command
+	// Parameter describes a parameter a command accepts
+	Parameter = config.Parameter
+	// Platform names an operating system goldfish supports
+	Platform = platform.SupportedPlatform
+	// SecretStore resolves {{secret "name"}} in templates
+	SecretStore = secrets.Store
+	// UnsupportedPlatformError is returned for commands the platform has no template for
+	UnsupportedPlatformError = engine.UnsupportedPlatformError
+	// ExitError is returned by a command tree's commands that exit with a non-zero code
+	// The goldfish CLI exits with the same code; host programs can find it
+	// with errors.As and do likewise.
+	ExitError = engine.ExitError
+	// CommandNotFoundError is returned for a name no configured command has
+	CommandNotFoundError = config.CommandNotFoundError
+	// ValidationError is returned for an invalid configuration, with where it is in the file
+	ValidationError = config.ValidationError
+	// ParameterError is returned for a missing, unknown or invalid parameter value
+	ParameterError = engine.ParameterError
+	// OutputLimitError is returned when a command's output went over MaxOutput or MaxLineLength
+	OutputLimitError = engine.OutputLimitError
+	// Result describes a finished command: its command line, exit code, duration and output
+	Result = engine.ExecutionResult
+	// MockBackend records the commands an Engine would run instead of running them
+	MockBackend = engine.MockBackend
+)
+
+// Errors can be told apart with errors.Is against these, rather than by
+// their messages
+var (
+	// ErrCommandNotFound matches a CommandNotFoundError
+	ErrCommandNotFound = config.ErrCommandNotFound
+	// ErrPlatformUnsupported matches an UnsupportedPlatformError
+	ErrPlatformUnsupported = engine.ErrPlatformUnsupported
+	// ErrValidation matches a ValidationError or a ParameterError
+	ErrValidation = config.ErrValidation
+	// ErrTimeout matches the error of a command killed for exceeding its timeout
+	ErrTimeout = engine.ErrTimeout
+)
+
+// The supported platforms
+const (
+	Linux   = platform.Linux
+	Darwin  = platform.Darwin
+	Windows = platform.Windows
+)
+
+// DefaultTimeout is how long a command may run when Options.Timeout is not set
+const DefaultTimeout = 30 * time.Second
+
+// LoadConfig loads the configuration the goldfish CLI would use
+// That is the embedded defaults merged with every commands.yml, pack and
+// plugin found in the standard locations.
+func LoadConfig() (*Config, error) {
+	return config.LoadDefaultWithEmbedded()
+}
+
+// LoadConfigFile loads the embedded defaults with a single file (or URL) on top
+func LoadConfigFile(path string) (*Config, error) {
+	return config.LoadWithDefaults(path)
+}
+
+// DefaultConfig returns only the command definitions built into goldfish
+func DefaultConfig() (*Config, error) {
+	return config.LoadDefaults()
+}
+
+// ParseConfig parses and validates commands.yml content held in memory
+func ParseConfig(data []byte) (*Config, error) {
+	return config.NewLoader("").Parse(data)
+}
+
+// DetectPlatform returns the platform the program is running on
+func DetectPlatform() (Platform, error) {
+	return platform.NewDetector().Current()
+}
+
+// NewMockBackend creates a MockBackend that writes each command line to
+// transcript; a nil transcript only records them (see MockBackend.Commands)
+func NewMockBackend(transcript io.Writer) *MockBackend {
+	return engine.NewMockBackend(transcript)
+}
+
+// Options configures an Engine; the zero value is ready to use
+type Options struct {
+	// Platform selects which templates are used (default: the current platform)
+	Platform Platform
+	// Timeout limits how long each command may run (default: DefaultTimeout)
+	Timeout time.Duration
+	// Stdin, Stdout and Stderr connect commands' standard streams (default: the process's own)
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	// Secrets resolves {{secret "name"}} (default: the OS keyring)
+	Secrets SecretStore
+	// Verbose receives debug logging of each execution when set
+	Verbose io.Writer
+	// Policy, when set, decides whether each command may run (see Policy)
+	Policy Policy
+	// MaxOutput caps the bytes of each stream Execute keeps (default:
+	// 64 MiB; negative keeps everything), and MaxLineLength truncates longer
+	// lines (default: no limit); commands' max_output and max_line_length win
+	MaxOutput     int64
+	MaxLineLength int
+	// Program is how users invoke the command tree, shown in its usage
+	// examples (default "goldfish"; e.g. "acme tools" when mounted there)
+	Program string
+	// Mock, when set, records every command line instead of running it, as
+	// GOLDFISH_EXEC=mock does for the CLI; plugins are refused
+	Mock *MockBackend
+	// Profile selects one of the configuration's profiles, whose vars and
+	// env apply to every command, as --profile does for the CLI
+	Profile string
+}
+
+// Policy decides whether a command may run
+// It receives the command and the command line it would run (with secrets
+// masked) and returns an error to refuse it; Run then returns that error
+// without starting anything.
+type Policy func(cmd *Command, commandLine string) error
+
+// DenyDestructive is a Policy that refuses commands marked destructive
+func DenyDestructive(cmd *Command, commandLine string) error {
+	if cmd.Destructive {
+		return fmt.Errorf("command '%s' is destructive and not allowed here", cmd.Name)
+	}
+	return nil
+}
+
+// Engine renders and runs the commands of one configuration
+type Engine struct {
+	config  *Config
+	engine  *engine.Engine
+	options Options
+}
+
+// New creates an Engine for cfg
+func New(cfg *Config, opts Options) (*Engine, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("config is nil")
+	}
+	if opts.Platform == "" {
+		current, err := DetectPlatform()
+		if err != nil {
+			return nil, err
+		}
+		opts.Platform = current
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultTimeout
+	}
+
+	eng := engine.NewEngine(opts.Timeout)
+	eng.SetWasmModules(cfg.Wasm)
+	eng.SetOutputLimits(opts.MaxOutput, opts.MaxLineLength)
+	eng.SetCommands(cfg.Commands)
+	eng.SetGlobals(cfg.Vars)
+	if opts.Profile != "" {
+		profile, found := cfg.FindProfile(opts.Profile)
+		if !found {
+			return nil, fmt.Errorf("unknown profile '%s'", opts.Profile)
+		}
+		eng.SetProfile(profile)
+	}
+	if opts.Secrets != nil {
+		eng.SetSecretStore(opts.Secrets)
+	}
+	if opts.Verbose != nil {
+		eng.SetVerboseOutput(opts.Verbose)
+	}
+	if opts.Mock != nil {
+		eng.SetBackend(opts.Mock)
+	}
+	return &Engine{config: cfg, engine: eng, options: opts}, nil
+}
+
+// Platform returns the platform the engine renders commands for
+func (e *Engine) Platform() Platform {
+	return e.options.Platform
+}
+
+// Commands returns the listed commands available on the engine's platform
+func (e *Engine) Commands() []Command {
+	var commands []Command
+	for _, cmd := range e.config.Commands {
+		if engine.Supports(&cmd, e.options.Platform) && cmd.Listed() {
+			commands = append(commands, cmd)
+		}
+	}
+	return commands
+}
+
+// Render returns the command line that Run would execute, with secrets masked
+// args fill positional parameters in order; params sets parameters by name
+// and may hold typed values or strings, which are converted to the parameter's type.
+func (e *Engine) Render(name string, args []string, params map[string]interface{}) (string, error) {
+	cmd, flags, err := e.resolve(name, params)
+	if err != nil {
+		return "", err
+	}
+	ctx, err := e.executionContext(cmd, args, flags, e.options.Stdout, e.options.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return e.engine.Preview(ctx)
+}
+
+// Run executes a command and returns its exit code
+// The error is only set when the command could not run (unknown command,
+// invalid parameters, refused by the policy, timeout) or its declared
+// postconditions failed.
+func (e *Engine) Run(name string, args []string, params map[string]interface{}) (int, error) {
+	return e.RunContext(context.Background(), name, args, params)
+}
+
+// RunContext runs a command like Run until it finishes or ctx is cancelled
+// Cancelling ctx stops the command as its timeout does, and ctx's deadline,
+// when sooner, shortens the timeout. The command is traced as part of ctx's
+// trace, if it has one.
+func (e *Engine) RunContext(ctx context.Context, name string, args []string, params map[string]interface{}) (int, error) {
+	cmd, flags, err := e.resolve(name, params)
+	if err != nil {
+		return -1, err
+	}
+	return e.run(ctx, cmd, args, flags, e.options.Stdout, e.options.Stderr)
+}
+
+// resolve finds a command by name or alias and passes named parameters as flags
+func (e *Engine) resolve(name string, params map[string]interface{}) (*Command, map[string]interface{}, error) {
+	cmd, found := e.config.FindCommand(name)
+	if !found {
+		return nil, nil, &config.CommandNotFoundError{Name: name}
+	}
+	flags := make(map[string]interface{}, len(params))
+	for param, value := range params {
+		flags["--"+param] = value
+	}
+	return cmd, flags, nil
+}
+
+// Execute runs a command like Run and returns its result, with its output captured
+// The output is still written to Options.Stdout and Options.Stderr when they
+// are set. The result is nil when the command was not found, its
+// parameters could not be parsed or it could not be rendered.
+func (e *Engine) Execute(name string, args []string, params map[string]interface{}) (*Result, error) {
+	return e.ExecuteContext(context.Background(), name, args, params)
+}
+
+// ExecuteContext runs a command like Execute until it finishes or ctx is
+// cancelled, as described for RunContext
+func (e *Engine) ExecuteContext(ctx context.Context, name string, args []string, params map[string]interface{}) (*Result, error) {
+	cmd, flags, err := e.resolve(name, params)
+	if err != nil {
+		return nil, err
+	}
+	return e.execute(ctx, cmd, args, flags, e.options.Stdout, e.options.Stderr, true)
+}
+
+// run checks the policy and executes a command, writing to stdout and stderr
+func (e *Engine) run(parent context.Context, cmd *Command, args []string, flags map[string]interface{}, stdout, stderr io.Writer) (int, error) {
+	result, err := e.execute(parent, cmd, args, flags, stdout, stderr, false)
+	if result == nil {
+		return -1, err
+	}
+	return result.ExitCode, err
+}
+
+// execute checks the policy and executes a command, capturing its output when asked
+func (e *Engine) execute(parent context.Context, cmd *Command, args []string, flags map[string]interface{}, stdout, stderr io.Writer, capture bool) (*Result, error) {
+	ctx, err := e.executionContext(cmd, args, flags, stdout, stderr)
+	if err != nil {
+		return nil, err
+	}
+	ctx.Capture = capture
+	if e.options.Policy != nil {
+		commandLine, err := e.engine.Preview(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := e.options.Policy(ctx.Command, commandLine); err != nil {
+			return &Result{Command: commandLine, ExitCode: -1}, err
+		}
+	}
+	return e.engine.Execute(parent, ctx)
+}
+
+// executionContext parses the parameters of an invocation like the CLI does
+func (e *Engine) executionContext(cmd *Command, args []string, flags map[string]interface{}, stdout, stderr io.Writer) (*engine.ExecutionContext, error) {
+	parsed, err := e.engine.ParseParameters(cmd, args, flags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse parameters: %w", err)
+	}
+
+	return &engine.ExecutionContext{
+		Command:    cmd,
+		Platform:   e.options.Platform,
+		Parameters: parsed,
+		Timeout:    e.options.Timeout,
+		Stdin:      e.options.Stdin,
+		Stdout:     stdout,
+		Stderr:     stderr,
+	}, nil
+}
@@ -0,0 +1,229 @@
+package goldfish
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testConfig is a small configuration with commands for each situation
+const testConfig = `commands:
+  - name: "greet"
+    alias: "hi"
+    description: "Print a greeting"
+    base_command: "echo"
+    params:
+      - name: "name"
+        type: "string"
+        required: true
+      - name: "times"
+        type: "int"
+        default: 1
+    platforms:
+      linux:
+        template: "echo hello {{.params.name}} x{{.params.times}}"
+      darwin:
+        template: "echo hello {{.params.name}} x{{.params.times}}"
+  - name: "winonly"
+    description: "Only on Windows"
+    base_command: "ver"
+    platforms:
+      windows:
+        template: "ver"
+`
+
+// newTestEngine parses testConfig and creates an engine for Linux
+func newTestEngine(t *testing.T, opts Options) *Engine {
+	t.Helper()
+	cfg, err := ParseConfig([]byte(testConfig))
+	if err != nil {
+		t.Fatalf("ParseConfig failed: %v", err)
+	}
+	if opts.Platform == "" {
+		opts.Platform = Linux
+	}
+	gf, err := New(cfg, opts)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return gf
+}
+
+// TestEngine_Render tests rendering by name and alias with args and named parameters
+func TestEngine_Render(t *testing.T) {
+	gf := newTestEngine(t, Options{})
+
+	rendered, err := gf.Render("hi", []string{"world"}, map[string]interface{}{"times": "3"})
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if rendered != "echo hello world x3" {
+		t.Errorf("Unexpected command line: %q", rendered)
+	}
+
+	if _, err := gf.Render("missing", nil, nil); err == nil {
+		t.Error("Expected error for unknown command")
+	}
+	if _, err := gf.Render("greet", nil, nil); err == nil {
+		t.Error("Expected error for a missing required parameter")
+	}
+
+	var unsupported *UnsupportedPlatformError
+	if _, err := gf.Render("winonly", nil, nil); !errors.As(err, &unsupported) {
+		t.Errorf("Expected UnsupportedPlatformError, got %v", err)
+	}
+}
+
+// TestEngine_Run tests running a command with injected output streams
+func TestEngine_Run(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a Unix shell")
+	}
+	var stdout bytes.Buffer
+	gf := newTestEngine(t, Options{Stdout: &stdout, Stderr: &bytes.Buffer{}})
+
+	exitCode, err := gf.Run("greet", []string{"embedder"}, nil)
+	if err != nil || exitCode != 0 {
+		t.Fatalf("Run failed: exit %d, %v", exitCode, err)
+	}
+	if strings.TrimSpace(stdout.String()) != "hello embedder x1" {
+		t.Errorf("Unexpected output: %q", stdout.String())
+	}
+}
+
+// TestEngine_RunContext tests that cancelling the context stops a command
+func TestEngine_RunContext(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a Unix shell")
+	}
+	cfg, err := ParseConfig([]byte("commands:\n  - name: \"slow\"\n    base_command: \"sleep\"\n    platforms:\n      linux:\n        template: \"sleep 5\"\n"))
+	if err != nil {
+		t.Fatalf("ParseConfig failed: %v", err)
+	}
+	gf, err := New(cfg, Options{Platform: Linux})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	exitCode, err := gf.RunContext(ctx, "slow", nil, nil)
+	if !errors.Is(err, context.DeadlineExceeded) || exitCode != -1 || time.Since(start) > 3*time.Second {
+		t.Errorf("Expected the command to be stopped, got exit %d (%v) after %v", exitCode, err, time.Since(start))
+	}
+}
+
+// TestEngine_Execute tests that results carry the command line and captured output
+func TestEngine_Execute(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a Unix shell")
+	}
+	gf := newTestEngine(t, Options{})
+
+	result, err := gf.Execute("greet", []string{"embedder"}, map[string]interface{}{"times": 2})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !result.Succeeded() || result.Command != "echo hello embedder x2" || result.Stdout != "hello embedder x2\n" {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+
+	if result, err := gf.Execute("missing", nil, nil); err == nil || result != nil {
+		t.Errorf("Expected no result for an unknown command, got %+v (%v)", result, err)
+	}
+}
+
+// TestEngine_Commands tests listing the commands available on the platform
+func TestEngine_Commands(t *testing.T) {
+	commands := newTestEngine(t, Options{}).Commands()
+	if len(commands) != 1 || commands[0].Name != "greet" {
+		t.Errorf("Expected only greet on Linux, got %+v", commands)
+	}
+	commands = newTestEngine(t, Options{Platform: Windows}).Commands()
+	if len(commands) != 1 || commands[0].Name != "winonly" {
+		t.Errorf("Expected only winonly on Windows, got %+v", commands)
+	}
+}
+
+// TestEngine_Errors tests that errors can be told apart with errors.Is and errors.As
+func TestEngine_Errors(t *testing.T) {
+	gf := newTestEngine(t, Options{})
+
+	_, err := gf.Render("missing", nil, nil)
+	var notFound *CommandNotFoundError
+	if !errors.Is(err, ErrCommandNotFound) || !errors.As(err, &notFound) || notFound.Name != "missing" {
+		t.Errorf("Expected a CommandNotFoundError, got %v", err)
+	}
+	if _, err := gf.Render("winonly", nil, nil); !errors.Is(err, ErrPlatformUnsupported) {
+		t.Errorf("Expected %v to match ErrPlatformUnsupported", err)
+	}
+	_, err = gf.Render("greet", nil, nil)
+	var paramErr *ParameterError
+	if !errors.Is(err, ErrValidation) || !errors.As(err, &paramErr) || paramErr.Parameter != "name" {
+		t.Errorf("Expected a ParameterError for name, got %v", err)
+	}
+	if _, err := ParseConfig([]byte("commands:\n  - name: \"broken\"\n")); !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected %v to match ErrValidation", err)
+	}
+}
+
+// TestNew_NilConfig tests that a config is required
+func TestNew_NilConfig(t *testing.T) {
+	if _, err := New(nil, Options{}); err == nil {
+		t.Error("Expected error for nil config")
+	}
+}
+
+// TestEngine_Mock tests recording commands instead of running them
+func TestEngine_Mock(t *testing.T) {
+	mock := NewMockBackend(nil)
+	var stdout bytes.Buffer
+	gf := newTestEngine(t, Options{Mock: mock, Stdout: &stdout})
+
+	exitCode, err := gf.Run("greet", []string{"embedder"}, nil)
+	if err != nil || exitCode != 0 {
+		t.Fatalf("Run failed: exit %d, %v", exitCode, err)
+	}
+	if commands := mock.Commands(); len(commands) != 1 || commands[0] != "echo hello embedder x1" || stdout.Len() != 0 {
+		t.Errorf("Expected the command to be recorded and not run, got %q and output %q", commands, stdout.String())
+	}
+}
+
+// TestNew_Profile tests selecting a profile
+func TestNew_Profile(t *testing.T) {
+	cfg, err := ParseConfig([]byte(`
+vars:
+  endpoint: http://localhost
+profiles:
+  - name: prod
+    vars: {endpoint: "https://example.com"}
+commands:
+  - name: deploy
+    description: Deploy
+    base_command: echo
+    platforms:
+      linux: {template: "echo {{.globals.endpoint}}"}
+      darwin: {template: "echo {{.globals.endpoint}}"}
+      windows: {template: "echo {{.globals.endpoint}}"}
+`))
+	if err != nil {
+		t.Fatalf("ParseConfig failed: %v", err)
+	}
+	eng, err := New(cfg, Options{Profile: "prod"})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	rendered, err := eng.Render("deploy", nil, nil)
+	if err != nil || rendered != "echo https://example.com" {
+		t.Errorf("Expected the profile's endpoint, got %q (%v)", rendered, err)
+	}
+
+	if _, err := New(cfg, Options{Profile: "staging"}); err == nil || !strings.Contains(err.Error(), "unknown profile 'staging'") {
+		t.Errorf("Expected an unknown profile to fail, got %v", err)
+	}
+}